@@ -50,6 +50,51 @@ type ClusterSpec struct {
 
 	// The target distribution configuration
 	TargetDistribution *TargetDistributionConfig `json:"targetDistribution,omitempty"`
+
+	// The applied plan history configuration
+	PlanHistory *PlanHistoryConfig `json:"planHistory,omitempty"`
+
+	// The rollout policy applied when the plan sent to the gNMIc pods changes
+	Rollout *RolloutConfig `json:"rollout,omitempty"`
+}
+
+type PlanHistoryConfig struct {
+	// The number of applied plans kept per cluster.
+	// The oldest revisions are pruned first; a pinned revision is never pruned.
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Limit int32 `json:"limit,omitempty"`
+	// Pins the cluster to a previously applied plan revision.
+	// While set, the pinned plan is applied to the gNMIc pods instead of the plan
+	// built from the current pipelines. Unset it to release the pin.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PinnedRevision int64 `json:"pinnedRevision,omitempty"`
+}
+
+type RolloutConfig struct {
+	// The rollout strategy.
+	// AllAtOnce applies a changed plan to every pod in one pass.
+	// Canary applies it to a single pod first and only proceeds with
+	// the remaining pods once that pod stayed healthy for the bake duration,
+	// otherwise the pod is reverted to the configuration it held before.
+	// +kubebuilder:validation:Enum=AllAtOnce;Canary
+	// +kubebuilder:default=AllAtOnce
+	Strategy string `json:"strategy,omitempty"`
+	// How long the canary pod runs the new plan before
+	// the rollout proceeds to the remaining pods.
+	// Defaults to 2m.
+	// +optional
+	BakeDuration *metav1.Duration `json:"bakeDuration,omitempty"`
+	// The percentage of the canary pod's targets allowed to become unhealthy
+	// (not running, not connected or with a subscription not running)
+	// at any point of the bake duration.
+	// Targets already unhealthy when the canary started are not counted.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	MaxUnhealthyTargetsPercent int32 `json:"maxUnhealthyTargetsPercent,omitempty"`
 }
 
 type TargetDistributionConfig struct {
@@ -65,6 +110,8 @@ type APIConfig struct {
 	// The port for the gNMI Server
 	// exposed by the gNMIc pods.
 	// If not set, the gNMI server is not enabled.
+	// It requires tls.issuerRef, the gNMI server only accepts
+	// the controller's client certificate.
 	GNMIPort int32 `json:"gnmiPort,omitempty"`
 	// The TLS configuration for the REST and gNMI servers
 	// If not set, the TLS is not enabled.
@@ -125,6 +172,8 @@ type ClusterStatus struct {
 	InputsCount int32 `json:"inputsCount"`
 	// The number of outputs referenced by the pipelines
 	OutputsCount int32 `json:"outputsCount"`
+	// The revision of the plan currently applied to the gNMIc pods
+	PlanRevision int64 `json:"planRevision,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -140,6 +189,7 @@ type ClusterStatus struct {
 // +kubebuilder:printcolumn:name="Subs",type=integer,JSONPath=`.status.subscriptionsCount`
 // +kubebuilder:printcolumn:name="Inputs",type=integer,JSONPath=`.status.inputsCount`
 // +kubebuilder:printcolumn:name="Outputs",type=integer,JSONPath=`.status.outputsCount`
+// +kubebuilder:printcolumn:name="Revision",type=integer,JSONPath=`.status.planRevision`,priority=1
 
 // Cluster is the Schema for the clusters API
 type Cluster struct {
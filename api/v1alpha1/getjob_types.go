@@ -0,0 +1,73 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetJobSpec defines the desired state of GetJob
+type GetJobSpec struct {
+	GNMIJobSpec `json:",inline"`
+	// The gNMI prefix of the paths
+	Prefix string `json:"prefix,omitempty"`
+	// The gNMI paths to get
+	// +kubebuilder:validation:MinItems=1
+	Paths []string `json:"paths"`
+	// The gNMI GetRequest data type (ALL, CONFIG, STATE or OPERATIONAL)
+	// +kubebuilder:validation:Enum=ALL;CONFIG;STATE;OPERATIONAL
+	DataType string `json:"dataType,omitempty"`
+	// The gNMI GetRequest encoding (JSON, BYTES, PROTO, ASCII, JSON_IETF)
+	// +kubebuilder:validation:Enum=JSON;BYTES;PROTO;ASCII;JSON_IETF
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// GetJobStatus defines the observed state of GetJob
+type GetJobStatus struct {
+	GNMIJobStatus `json:",inline"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.clusterRef`
+// +kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`
+// +kubebuilder:printcolumn:name="Succeeded",type=integer,JSONPath=`.status.succeeded`
+// +kubebuilder:printcolumn:name="Failed",type=integer,JSONPath=`.status.failed`
+// +kubebuilder:printcolumn:name="Last_Run",type=date,JSONPath=`.status.lastRunTime`
+
+// GetJob is the Schema for the getjobs API
+type GetJob struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GetJobSpec   `json:"spec,omitempty"`
+	Status GetJobStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// GetJobList contains a list of GetJob
+type GetJobList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GetJob `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GetJob{}, &GetJobList{})
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GNMIJobSpec is the part of the spec shared by GetJobs and SetJobs:
+// the targets the job runs against and when it runs.
+type GNMIJobSpec struct {
+	// The cluster running the job. The request for each target is sent by
+	// the pod of the cluster the target is assigned to, through the gNMI
+	// server of the pod, which must be enabled with api.gnmiPort.
+	// +kubebuilder:validation:MinLength=1
+	ClusterRef string `json:"clusterRef"`
+	// The selector for the targets
+	TargetSelectors []metav1.LabelSelector `json:"targetSelectors,omitempty"`
+	// The targets to run the job against
+	TargetRefs []string `json:"targetRefs,omitempty"`
+	// A standard 5 field cron expression (minute hour day-of-month month day-of-week)
+	// at which the job runs, e.g. "0 * * * *" for every hour.
+	// If not set, the job runs once for each generation of its spec.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+	// The IANA time zone the schedule is evaluated in, e.g. "Europe/Paris".
+	// Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+	// Whether the job is suspended. A suspended job does not run.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+	// How long to wait for the response of each target. Defaults to 30s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// Names of the Outputs the responses are written to, as events in the
+	// gNMIc event format. They are written by the controller, which supports
+	// the tcp, udp and influxdb output types.
+	// +optional
+	Outputs []string `json:"outputs,omitempty"`
+}
+
+// GNMIJobStatus is the observed state shared by GetJobs and SetJobs.
+type GNMIJobStatus struct {
+	// The generation of the spec last processed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// When the job last ran.
+	// +optional
+	LastRunTime *metav1.Time `json:"lastRunTime,omitempty"`
+	// When the job runs next according to its schedule.
+	// +optional
+	NextRunTime *metav1.Time `json:"nextRunTime,omitempty"`
+	// The run in progress, if any. The requests of a run are sent in the
+	// background and its results replace the ones below once it completes.
+	// +optional
+	Active *GNMIJobActiveRun `json:"active,omitempty"`
+	// The number of targets the last run succeeded on.
+	// +optional
+	Succeeded int32 `json:"succeeded,omitempty"`
+	// The number of targets the last run failed on.
+	// +optional
+	Failed int32 `json:"failed,omitempty"`
+	// The result of the last run for each target, sorted by target name.
+	// +optional
+	Targets []GNMIJobTargetResult `json:"targets,omitempty"`
+	// The conditions of the job.
+	// The Succeeded condition is True when the last run succeeded on all
+	// of its targets.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// GNMIJobActiveRun is the progress of the run of a job in progress.
+type GNMIJobActiveRun struct {
+	// When the run started.
+	StartTime metav1.Time `json:"startTime"`
+	// The number of targets the run sends requests to.
+	Targets int32 `json:"targets"`
+	// The number of targets the run is done with, successfully or not.
+	// +optional
+	Completed int32 `json:"completed,omitempty"`
+}
+
+// GNMIJobTargetResult is the result of the last run of a job for a target.
+type GNMIJobTargetResult struct {
+	// The name of the Target.
+	Target string `json:"target"`
+	// The pod that sent the request to the target.
+	// +optional
+	Pod string `json:"pod,omitempty"`
+	// Succeeded or Failed.
+	// +kubebuilder:validation:Enum=Succeeded;Failed
+	Result string `json:"result"`
+	// Why the request failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// The number of notifications in the GetResponse.
+	// +optional
+	Notifications int32 `json:"notifications,omitempty"`
+	// The number of updates in the notifications of the GetResponse,
+	// or of update results in the SetResponse.
+	// +optional
+	Updates int32 `json:"updates,omitempty"`
+	// The timestamp of the response.
+	// +optional
+	Timestamp *metav1.Time `json:"timestamp,omitempty"`
+}
@@ -33,12 +33,15 @@ type InputSpec struct {
 	// +kubebuilder:pruning:PreserveUnknownFields
 	// +kubebuilder:validation:Schemaless
 	Config apiextensionsv1.JSON `json:"config,omitempty"`
+	// SecretRef references a Secret whose keys are written into the input config,
+	// for credentials such as a Kafka SASL password or NATS credentials.
+	// +optional
+	SecretRef *ConfigSecretRef `json:"secretRef,omitempty"`
 }
 
 // InputStatus defines the observed state of Input
 type InputStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	ResourceStatus `json:",inline"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,91 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ModelSetSpec defines the desired state of ModelSet
+type ModelSetSpec struct {
+	// The version of the models, e.g. the OpenConfig release or the
+	// network OS release shipping the native models
+	// +kubebuilder:validation:MinLength=1
+	Version string `json:"version"`
+	// The ConfigMaps holding the YANG modules, one module or submodule per
+	// key ending with .yang. The modules they import must be included.
+	// +kubebuilder:validation:MinItems=1
+	ConfigMaps []string `json:"configMaps"`
+	// The modules whose data nodes the paths are checked against.
+	// All the modules of the ConfigMaps when empty.
+	Modules []string `json:"modules,omitempty"`
+}
+
+// ModelSetStatus defines the observed state of ModelSet
+type ModelSetStatus struct {
+	// The generation of the ModelSet last loaded.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The modules loaded, as name@revision
+	// +optional
+	Modules []string `json:"modules,omitempty"`
+	// The conditions of the ModelSet.
+	// The Ready condition is False with reason LoadFailed when the
+	// modules could not be read or parsed.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ModelSetReference refers to a ModelSet in the same namespace
+type ModelSetReference struct {
+	// The name of the ModelSet
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// The version the ModelSet must have, any version when empty
+	Version string `json:"version,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.spec.version`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+
+// ModelSet is the Schema for the modelsets API. It is a versioned set of
+// YANG modules, the gNMI paths of Subscriptions are checked against.
+type ModelSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ModelSetSpec   `json:"spec,omitempty"`
+	Status ModelSetStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ModelSetList contains a list of ModelSet
+type ModelSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ModelSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ModelSet{}, &ModelSetList{})
+}
@@ -25,7 +25,7 @@ import (
 // OutputSpec defines the desired state of Output
 type OutputSpec struct {
 	// The output type
-	// +kubebuilder:validation:Enum=file;kafka;prometheus;prometheus_write;nats;jetstream;influxdb;tcp;udp
+	// +kubebuilder:validation:Enum=file;kafka;prometheus;prometheus_write;nats;jetstream;influxdb;tcp;udp;otlp
 	Type string `json:"type"`
 	// The output-specific config object.
 	// +kubebuilder:pruning:PreserveUnknownFields
@@ -36,15 +36,37 @@ type OutputSpec struct {
 	// +optional
 	Service *OutputServiceSpec `json:"service,omitempty"`
 	// ServiceRef references a Kubernetes Service to use as the output address.
-	// Supported for: nats, jetstream, kafka, remote_write, influxdb outputs.
+	// Supported for: nats, jetstream, kafka, remote_write, influxdb, otlp outputs.
 	// The service address will be resolved and injected into the output config.
 	// +optional
 	ServiceRef *ServiceReference `json:"serviceRef,omitempty"`
 	// ServiceSelector selects Kubernetes Services by labels to use as output addresses.
-	// Supported for: nats, jetstream, kafka, remote_write, influxdb outputs.
+	// Supported for: nats, jetstream, kafka, remote_write, influxdb, otlp outputs.
 	// All matching service addresses will be resolved and injected into the output config.
 	// +optional
 	ServiceSelector *ServiceSelector `json:"serviceSelector,omitempty"`
+	// SecretRef references a Secret whose keys are written into the output config,
+	// for credentials such as a Kafka SASL password or an InfluxDB token.
+	// +optional
+	SecretRef *ConfigSecretRef `json:"secretRef,omitempty"`
+}
+
+// ConfigSecretRef references a Secret whose keys are written into an Output or Input config
+type ConfigSecretRef struct {
+	// Name of the Secret, in the namespace of the referencing resource.
+	Name string `json:"name"`
+	// Items map keys of the Secret onto config paths.
+	// +kubebuilder:validation:MinItems=1
+	Items []SecretKeyToPath `json:"items"`
+}
+
+// SecretKeyToPath maps a key of a Secret onto a config path
+type SecretKeyToPath struct {
+	// Key of the Secret.
+	Key string `json:"key"`
+	// Path is the dot-separated path of the config key the value is written to,
+	// for example sasl.password or token. It overrides a value set in the config.
+	Path string `json:"path"`
 }
 
 // ServiceReference references a specific Kubernetes Service
@@ -101,6 +123,7 @@ type OutputServiceSpec struct {
 
 // OutputStatus defines the observed state of Output
 type OutputStatus struct {
+	ResourceStatus `json:",inline"`
 }
 
 // +kubebuilder:object:root=true
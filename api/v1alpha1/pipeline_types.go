@@ -26,6 +26,14 @@ type PipelineSpec struct {
 	ClusterRef string `json:"clusterRef"`
 	// Whether the pipeline is enabled
 	Enabled bool `json:"enabled,omitempty"`
+	// Disables the pipeline once it has been enabled for this long.
+	// It is counted from status.enabledAt and starts over
+	// when the pipeline is disabled and enabled again.
+	// +optional
+	TTLAfterEnable *metav1.Duration `json:"ttlAfterEnable,omitempty"`
+	// The schedule restricting when the pipeline collects
+	// +optional
+	Schedule *PipelineSchedule `json:"schedule,omitempty"`
 
 	// The selector for the targets
 	TargetSelectors []metav1.LabelSelector `json:"targetSelectors,omitempty"`
@@ -52,6 +60,40 @@ type PipelineSpec struct {
 	Labels map[string]string `json:"labels,omitempty"`
 }
 
+type PipelineSchedule struct {
+	// The IANA time zone the cron windows are evaluated in, e.g. "Europe/Paris".
+	// Defaults to UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+	// The windows during which the pipeline collects.
+	// If empty, the pipeline collects at all times outside its maintenance windows.
+	ActiveWindows []ScheduleWindow `json:"activeWindows,omitempty"`
+	// The windows during which collection is paused,
+	// for the whole pipeline or for the targets matching the window's target selector.
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+}
+
+// ScheduleWindow is a time window opening either at a fixed time
+// or every time a cron expression fires, and staying open for Duration.
+type ScheduleWindow struct {
+	// A standard 5 field cron expression (minute hour day-of-month month day-of-week)
+	// at which the window opens, e.g. "0 22 * * 6" for every Saturday at 22:00.
+	// Mutually exclusive with start.
+	Cron string `json:"cron,omitempty"`
+	// The fixed time at which the window opens.
+	// Mutually exclusive with cron.
+	Start *metav1.Time `json:"start,omitempty"`
+	// How long the window stays open
+	Duration metav1.Duration `json:"duration"`
+}
+
+type MaintenanceWindow struct {
+	ScheduleWindow `json:",inline"`
+	// Restricts the window to the targets matching this selector.
+	// If not set, the whole pipeline is paused during the window.
+	// +optional
+	TargetSelector *metav1.LabelSelector `json:"targetSelector,omitempty"`
+}
+
 type OutputSelector struct {
 	// The selector for the outputs
 	OutputSelectors []metav1.LabelSelector `json:"outputSelectors,omitempty"`
@@ -85,6 +127,38 @@ type PipelineStatus struct {
 	OutputsCount              int32              `json:"outputsCount"`
 	TunnelTargetPoliciesCount int32              `json:"tunnelTargetPoliciesCount"`
 	Conditions                []metav1.Condition `json:"conditions,omitempty"`
+	// The effective state of the pipeline once its schedule, TTL
+	// and suspended targets are taken into account:
+	// Active, Suspended (outside active windows or in a maintenance window),
+	// Expired (past ttlAfterEnable) or Disabled.
+	EffectiveState string `json:"effectiveState,omitempty"`
+	// When the effective state, or the set of suspended targets, changes next
+	// according to the schedule and TTL.
+	// +optional
+	NextTransitionTime *metav1.Time `json:"nextTransitionTime,omitempty"`
+	// When the pipeline was last enabled. ttlAfterEnable counts from here.
+	// +optional
+	EnabledAt *metav1.Time `json:"enabledAt,omitempty"`
+	// Number of selected targets not collected because they are suspended
+	// or in a maintenance window.
+	SuspendedTargetsCount int32 `json:"suspendedTargetsCount,omitempty"`
+	// References of the pipeline, or of the resources it selects, that could
+	// not be resolved. Also reported by the ResolvedRefs condition.
+	// +optional
+	UnresolvedRefs []UnresolvedReference `json:"unresolvedRefs,omitempty"`
+}
+
+// UnresolvedReference is a reference that could not be resolved.
+type UnresolvedReference struct {
+	// The kind of the referenced resource: Target, Subscription, Output,
+	// Input, Processor, TunnelTargetPolicy, TargetProfile or Secret.
+	Kind string `json:"kind"`
+	// The name of the referenced resource.
+	Name string `json:"name"`
+	// Why the reference could not be resolved, and what holds it when it is
+	// not the pipeline itself.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -97,6 +171,8 @@ type PipelineStatus struct {
 //+kubebuilder:printcolumn:name="Outputs",type=integer,JSONPath=`.status.outputsCount`
 //+kubebuilder:printcolumn:name="Inputs",type=integer,JSONPath=`.status.inputsCount`
 //+kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.status`
+//+kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.effectiveState`
+//+kubebuilder:printcolumn:name="Next_Transition",type=date,JSONPath=`.status.nextTransitionTime`,priority=1
 
 // Pipeline is the Schema for the pipelines API
 type Pipeline struct {
@@ -35,6 +35,7 @@ type ProcessorSpec struct {
 
 // ProcessorStatus defines the observed state of Processor
 type ProcessorStatus struct {
+	ResourceStatus `json:",inline"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,54 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ResourceStatus is the observed state shared by the resources referenced
+// from Pipelines: Subscriptions, Outputs, Inputs, Processors, TargetProfiles
+// and TunnelTargetPolicies. It is populated by the Cluster controller.
+type ResourceStatus struct {
+	// The generation of the resource last evaluated by a Cluster.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Usage of the resource per cluster, keyed by Cluster CR name.
+	// +optional
+	Clusters map[string]ResourceUsage `json:"clusters,omitempty"`
+	// The number of targets the resource applies to, summed across clusters.
+	// +optional
+	TargetsCount int32 `json:"targetsCount,omitempty"`
+	// The conditions of the resource.
+	// The Ready condition is False with reason Invalid when any cluster
+	// could not use the resource as configured, e.g. an unresolvable serviceRef.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ResourceUsage is the usage of a resource by a single cluster.
+type ResourceUsage struct {
+	// The names of the Pipelines using the resource, sorted.
+	Pipelines []string `json:"pipelines,omitempty"`
+	// The number of targets the resource applies to on this cluster.
+	TargetsCount int32 `json:"targetsCount,omitempty"`
+	// Why this cluster could not use the resource as configured, if it could not.
+	// +optional
+	Invalid string `json:"invalid,omitempty"`
+}
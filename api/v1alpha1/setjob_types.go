@@ -0,0 +1,85 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SetJobSpec defines the desired state of SetJob
+type SetJobSpec struct {
+	GNMIJobSpec `json:",inline"`
+	// The gNMI prefix of the paths
+	Prefix string `json:"prefix,omitempty"`
+	// The paths to delete
+	Deletes []string `json:"deletes,omitempty"`
+	// The values to replace
+	Replaces []SetJobUpdate `json:"replaces,omitempty"`
+	// The values to update
+	Updates []SetJobUpdate `json:"updates,omitempty"`
+	// The encoding of the values (JSON or JSON_IETF). Defaults to JSON.
+	// +kubebuilder:validation:Enum=JSON;JSON_IETF
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// SetJobUpdate is a value to set at a gNMI path.
+type SetJobUpdate struct {
+	// The gNMI path of the value
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+	// The value, sent in the encoding of the job
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	Value apiextensionsv1.JSON `json:"value"`
+}
+
+// SetJobStatus defines the observed state of SetJob
+type SetJobStatus struct {
+	GNMIJobStatus `json:",inline"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.clusterRef`
+// +kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`
+// +kubebuilder:printcolumn:name="Succeeded",type=integer,JSONPath=`.status.succeeded`
+// +kubebuilder:printcolumn:name="Failed",type=integer,JSONPath=`.status.failed`
+// +kubebuilder:printcolumn:name="Last_Run",type=date,JSONPath=`.status.lastRunTime`
+
+// SetJob is the Schema for the setjobs API
+type SetJob struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SetJobSpec   `json:"spec,omitempty"`
+	Status SetJobStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SetJobList contains a list of SetJob
+type SetJobList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SetJob `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SetJob{}, &SetJobList{})
+}
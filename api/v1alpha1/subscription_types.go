@@ -26,6 +26,12 @@ type SubscriptionSpec struct {
 	Prefix string `json:"prefix,omitempty"`
 	// The gNMI target to subscribe to
 	Target string `json:"target,omitempty"`
+	// Whether to set the gNMI target of the prefix to the name of each target
+	// subscribed to. Exclusive with target
+	SetTarget bool `json:"setTarget,omitempty"`
+	// The names of the models the target uses to interpret the paths
+	// (SubscriptionList use_models)
+	Models []string `json:"models,omitempty"`
 	// The gNMI paths to subscribe to
 	Paths []string `json:"paths,omitempty"`
 	// The gNMI SubscriptionList mode (ONCE, STREAM/SAMPLE, STREAM/ON_CHANGE, STREAM/TARGET_DEFINED or POLL)
@@ -35,10 +41,25 @@ type SubscriptionSpec struct {
 	SampleInterval metav1.Duration `json:"sampleInterval,omitempty"`
 	// The gNMI Subscription heartbeat interval
 	HeartbeatInterval metav1.Duration `json:"heartbeatInterval,omitempty"`
+	// How often the operator polls the targets of a POLL Subscription.
+	// Exclusive with pollSchedule
+	PollInterval metav1.Duration `json:"pollInterval,omitempty"`
+	// A standard 5 field cron expression (minute hour day-of-month month day-of-week),
+	// evaluated in UTC, at which the operator polls the targets of a POLL
+	// Subscription. Exclusive with pollInterval
+	PollSchedule string `json:"pollSchedule,omitempty"`
+	// The window the polls of the targets are spread over, each target being
+	// polled at a fixed offset within it. Defaults to a tenth of the time
+	// between two polls
+	PollJitter *metav1.Duration `json:"pollJitter,omitempty"`
 	// Whether to only send updates or all data
 	UpdatesOnly bool `json:"updatesOnly,omitempty"`
-	// The gNMI Subscription stream subscriptions
+	// The Subscriptions of the same pipeline whose paths and stream modes are
+	// sent in the same gNMI SubscriptionList
 	StreamSubscriptions []string `json:"streamSubscriptions,omitempty"`
+	// Paths subscribed to with their own stream mode, in the same gNMI
+	// SubscriptionList. The mode must be STREAM
+	Streams []SubscriptionStream `json:"streams,omitempty"`
 	// The gNMI Subscription depth (Depth extension)
 	Depth uint32 `json:"depth,omitempty"`
 	// The gNMI Subscription encoding (JSON, BYTES, PROTO, ASCII, JSON_IETF)
@@ -50,6 +71,26 @@ type SubscriptionSpec struct {
 	SuppressRedundant bool `json:"suppressRedundant,omitempty"`
 	// The gNMI Subscription history configuration
 	History *SubscriptionHistoryConfig `json:"history,omitempty"`
+	// The ModelSet the paths are checked against when the Subscription is
+	// created or updated
+	ModelSet *ModelSetReference `json:"modelSet,omitempty"`
+}
+
+// SubscriptionStream subscribes to paths with a stream mode of their own
+type SubscriptionStream struct {
+	// The gNMI paths, relative to the prefix of the Subscription
+	// +kubebuilder:validation:MinItems=1
+	Paths []string `json:"paths"`
+	// The gNMI stream mode of the paths, the stream mode of the Subscription
+	// by default
+	// +kubebuilder:validation:Enum=SAMPLE;ON_CHANGE;TARGET_DEFINED
+	Mode string `json:"mode,omitempty"`
+	// The gNMI Subscription sample interval
+	SampleInterval metav1.Duration `json:"sampleInterval,omitempty"`
+	// The gNMI Subscription heartbeat interval
+	HeartbeatInterval metav1.Duration `json:"heartbeatInterval,omitempty"`
+	// Whether to suppress redundant updates
+	SuppressRedundant bool `json:"suppressRedundant,omitempty"`
 }
 
 type SubscriptionHistoryConfig struct {
@@ -63,6 +104,7 @@ type SubscriptionHistoryConfig struct {
 
 // SubscriptionStatus defines the observed state of Subscription
 type SubscriptionStatus struct {
+	ResourceStatus `json:",inline"`
 }
 
 //+kubebuilder:object:root=true
@@ -26,6 +26,41 @@ type TargetSpec struct {
 	Address string `json:"address"`
 	// The profile to use for the target
 	Profile string `json:"profile"`
+	// Name of a Secret in the target namespace holding the credentials of
+	// this target, overriding those of its profile. The Secret is read with
+	// the key names of the profile's Secret credentials, if any, otherwise
+	// with the username, password and token keys.
+	// +optional
+	CredentialsRef string `json:"credentialsRef,omitempty"`
+	// Names of the Subscriptions collected from this target, among those of
+	// the pipelines selecting it. All of them when empty.
+	// +optional
+	Subscriptions []string `json:"subscriptions,omitempty"`
+	// Names of the Outputs the updates of this target are written to, among
+	// those of its subscriptions. All of them when empty.
+	// +optional
+	Outputs []string `json:"outputs,omitempty"`
+	// Number of updates buffered before they are processed, overriding the
+	// bufferSize of the profile
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	BufferSize *int32 `json:"bufferSize,omitempty"`
+	// TLS server name verified for this target, overriding the tls.serverName
+	// of the profile
+	// +optional
+	TLSServerName string `json:"tlsServerName,omitempty"`
+	// gRPC metadata sent with every RPC to this target, merged over the
+	// metadata of the profile
+	// +optional
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Labels added as event tags to the updates of this target, merged over
+	// the labels of the profile
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Suspends collection from this target.
+	// A suspended target stays selected by its pipelines but is not sent to the gNMIc pods.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
 }
 
 // TargetStatus defines the observed state of Target.
@@ -42,6 +77,74 @@ type TargetStatus struct {
 	// A target may be collected by multiple clusters (via different pipelines).
 	// +optional
 	ClusterStates map[string]ClusterTargetState `json:"clusterStates,omitempty"`
+	// Result of the last capabilities probe of the target, when its
+	// TargetProfile enables probing.
+	// +optional
+	Capabilities *TargetCapabilities `json:"capabilities,omitempty"`
+	// The last poll of each POLL Subscription collecting the target.
+	// +listType=map
+	// +listMapKey=cluster
+	// +listMapKey=pipeline
+	// +listMapKey=subscription
+	// +optional
+	Polls []TargetPoll `json:"polls,omitempty"`
+}
+
+// TargetPoll is the last poll of a target for a POLL Subscription.
+type TargetPoll struct {
+	// The Cluster polling the target
+	Cluster string `json:"cluster"`
+	// The Pipeline collecting the target with the Subscription
+	Pipeline string `json:"pipeline"`
+	// The name of the Subscription
+	Subscription string `json:"subscription"`
+	// The pod of the cluster the poll was sent to
+	// +optional
+	Pod string `json:"pod,omitempty"`
+	// Succeeded or Failed
+	// +kubebuilder:validation:Enum=Succeeded;Failed
+	Result string `json:"result"`
+	// Why the poll failed
+	// +optional
+	Message string `json:"message,omitempty"`
+	// When the target was last polled
+	LastPollTime metav1.Time `json:"lastPollTime"`
+}
+
+// TargetCapabilities is the result of the gNMI Capabilities RPC probing a target.
+type TargetCapabilities struct {
+	// Whether the last probe got the capabilities of the target
+	Reachable bool `json:"reachable"`
+	// Why the last probe failed
+	// +optional
+	Error string `json:"error,omitempty"`
+	// When the target was last probed
+	LastProbeTime metav1.Time `json:"lastProbeTime"`
+	// The generation of the Target last probed
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The gNMI version of the target.
+	// The version, encodings and models are kept from the last successful probe.
+	// +optional
+	GNMIVersion string `json:"gnmiVersion,omitempty"`
+	// The encodings supported by the target (JSON, BYTES, PROTO, ASCII, JSON_IETF)
+	// +optional
+	SupportedEncodings []string `json:"supportedEncodings,omitempty"`
+	// The YANG models supported by the target
+	// +optional
+	SupportedModels []TargetModel `json:"supportedModels,omitempty"`
+}
+
+// TargetModel is a YANG model supported by a target.
+type TargetModel struct {
+	// Name of the model
+	Name string `json:"name"`
+	// Organization publishing the model
+	// +optional
+	Organization string `json:"organization,omitempty"`
+	// Version of the model
+	// +optional
+	Version string `json:"version,omitempty"`
 }
 
 // ClusterTargetState represents the state of a target on a specific gNMIc cluster pod.
@@ -66,8 +169,10 @@ type ClusterTargetState struct {
 //+kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Address",type=string,JSONPath=`.spec.address`
 // +kubebuilder:printcolumn:name="Profile",type=string,JSONPath=`.spec.profile`
+// +kubebuilder:printcolumn:name="Suspended",type=boolean,JSONPath=`.spec.suspend`,priority=1
 // +kubebuilder:printcolumn:name="Clusters",type=integer,JSONPath=`.status.clusters`
 // +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.connectionState`
+// +kubebuilder:printcolumn:name="Reachable",type=boolean,JSONPath=`.status.capabilities.reachable`,priority=1
 
 // Target is the Schema for the targets API
 type Target struct {
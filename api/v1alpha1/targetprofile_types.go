@@ -17,15 +17,22 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // TargetProfileSpec defines the desired state of TargetProfile
+// +kubebuilder:validation:XValidation:rule="!(has(self.credentialsRef) && has(self.credentials))",message="credentialsRef and credentials are mutually exclusive"
 type TargetProfileSpec struct {
 	// The credentials of the target
 	// username, password or token keys in the secret referenced by the field
+	// Mutually exclusive with credentials.
 	CredentialsRef string `json:"credentialsRef,omitempty"`
 
+	// The credentials of the target, read from a Kubernetes Secret, Vault or a file.
+	// Mutually exclusive with credentialsRef.
+	Credentials *CredentialsSource `json:"credentials,omitempty"`
+
 	// Target TLS configuration
 	TLS *TargetTLSConfig `json:"tls,omitempty"`
 
@@ -57,8 +64,67 @@ type TargetProfileSpec struct {
 
 	// The gRPC keep-alive configuration
 	GRCPKeepAlive *GRPCKeepAliveConfig `json:"grpcKeepAlive,omitempty"`
+
+	// The scheme sent before the token in the authorization metadata, e.g. Bearer
+	AuthScheme string `json:"authScheme,omitempty"`
+
+	// Number of updates of a target buffered before they are processed
+	// +kubebuilder:validation:Minimum=1
+	BufferSize *int32 `json:"bufferSize,omitempty"`
+
+	// The gRPC buffer and flow control window sizes of the target connections
+	GRPCBuffers *GRPCBufferConfig `json:"grpcBuffers,omitempty"`
+
+	// Protobuf files decoding the gNMI extensions sent by the targets
+	Proto *TargetProtoConfig `json:"proto,omitempty"`
+
+	// gRPC metadata sent with every RPC to the targets
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Labels and annotations of the Targets added as event tags to their
+	// updates, so that every exported metric carries them
+	TargetTags *TargetTagsConfig `json:"targetTags,omitempty"`
+
+	// Probe the targets with a gNMI Capabilities RPC sent by the operator,
+	// and record their supported encodings and models in their status
+	Probe *TargetProbeConfig `json:"probe,omitempty"`
+
+	// The ModelSet the paths of the subscriptions of the targets are
+	// checked against, and reported in the status of their Pipelines
+	ModelSet *ModelSetReference `json:"modelSet,omitempty"`
+}
+
+// TargetProbeConfig configures the capabilities probe of the targets of a
+// TargetProfile. The operator connects to the targets with the TLS settings,
+// credentials and metadata of the profile.
+type TargetProbeConfig struct {
+	// How often the targets are probed
+	// +kubebuilder:default="10m"
+	// +kubebuilder:validation:XValidation:rule="self == '' || duration(self) >= duration('1m')",message="interval must be at least 1 minute"
+	Interval metav1.Duration `json:"interval,omitempty"`
+}
+
+// TargetTagsConfig selects the labels and annotations of the Targets added as
+// event tags. Targets without a selected key get no tag for it.
+type TargetTagsConfig struct {
+	// Target labels added as event tags, e.g. site or netbox.dev/role
+	Labels []TargetTag `json:"labels,omitempty"`
+	// Target annotations added as event tags
+	Annotations []TargetTag `json:"annotations,omitempty"`
+}
+
+// TargetTag adds the value of a Target label or annotation as an event tag
+type TargetTag struct {
+	// Key of the label or annotation
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+	// Name of the event tag. Defaults to the key without its prefix,
+	// e.g. role for netbox.dev/role.
+	// +optional
+	Name string `json:"name,omitempty"`
 }
 
+// +kubebuilder:validation:XValidation:rule="!(has(self.caBundleRef) && has(self.insecureSkipVerify) && self.insecureSkipVerify)",message="caBundleRef and insecureSkipVerify are mutually exclusive"
 type TargetTLSConfig struct {
 	// TLS serverName override value
 	ServerName string `json:"serverName,omitempty"`
@@ -68,6 +134,114 @@ type TargetTLSConfig struct {
 	MinVersion string `json:"minVersion,omitempty"`
 	// List of supported TLS cipher suites
 	CipherSuites []string `json:"cipherSuites,omitempty"`
+	// CA bundle verifying the server certificates of the targets.
+	// Overrides the clientTLS bundleRef of the Cluster.
+	// Without a CA bundle, server certificates are verified with the system roots.
+	CABundleRef *CABundleRef `json:"caBundleRef,omitempty"`
+	// Name of a Secret of type kubernetes.io/tls holding the client certificate
+	// presented to the targets, keys tls.crt and tls.key.
+	// Overrides the client certificate of the Cluster clientTLS.
+	ClientCertificateRef string `json:"clientCertificateRef,omitempty"`
+	// Skip the verification of the server certificates of the targets
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// CABundleRef references a CA bundle in the namespace of the TargetProfile
+// +kubebuilder:validation:ExactlyOneOf=configMap;secret;bundle
+type CABundleRef struct {
+	// Key of a ConfigMap holding PEM encoded CA certificates
+	ConfigMap *corev1.ConfigMapKeySelector `json:"configMap,omitempty"`
+	// Key of a Secret holding PEM encoded CA certificates
+	Secret *corev1.SecretKeySelector `json:"secret,omitempty"`
+	// Name of a trust-manager Bundle. Its target ConfigMap or Secret in the
+	// namespace of the TargetProfile is used.
+	Bundle string `json:"bundle,omitempty"`
+}
+
+// CredentialsSource selects the backend the credentials of the targets are read from
+// +kubebuilder:validation:ExactlyOneOf=secret;vault;file
+type CredentialsSource struct {
+	// Credentials read from a Kubernetes Secret
+	Secret *SecretCredentials `json:"secret,omitempty"`
+	// Credentials read from HashiCorp Vault
+	Vault *VaultCredentials `json:"vault,omitempty"`
+	// Credentials read from files, e.g. rendered by a CSI secrets-store volume
+	File *FileCredentials `json:"file,omitempty"`
+}
+
+// CredentialKeys names the keys holding each credential.
+// Unset keys default to username, password and token.
+type CredentialKeys struct {
+	// Key holding the username
+	UsernameKey string `json:"usernameKey,omitempty"`
+	// Key holding the password
+	PasswordKey string `json:"passwordKey,omitempty"`
+	// Key holding the token
+	TokenKey string `json:"tokenKey,omitempty"`
+}
+
+// SecretCredentials reads the credentials from a Kubernetes Secret
+type SecretCredentials struct {
+	// Name of the Secret in the namespace of the TargetProfile
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	CredentialKeys `json:",inline"`
+}
+
+// VaultCredentials reads the credentials from a HashiCorp Vault secret
+type VaultCredentials struct {
+	// Address of the Vault server, e.g. https://vault.vault.svc:8200
+	// +kubebuilder:validation:Pattern=`^https?://`
+	Address string `json:"address"`
+	// Vault Enterprise namespace
+	Namespace string `json:"namespace,omitempty"`
+	// Path of the secret, e.g. secret/data/devices/arista for a KV version 2 secret
+	// or ssh/creds/devices for a dynamic secret
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+
+	CredentialKeys `json:",inline"`
+
+	// Authentication to Vault
+	Auth VaultAuth `json:"auth"`
+	// How often a secret without lease, e.g. a KV secret, is read again.
+	// A secret with a lease is read again when two thirds of the lease elapsed.
+	// +kubebuilder:default="5m"
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+	// TLS configuration of the connections to Vault
+	TLS *ClientTLSConfig `json:"tls,omitempty"`
+}
+
+// VaultAuth defines how the operator authenticates to Vault
+// +kubebuilder:validation:ExactlyOneOf=kubernetes;tokenSecretRef
+type VaultAuth struct {
+	// Kubernetes auth method, logging in with a token of the gnmic-vault-auth ServiceAccount
+	// of the TargetProfile namespace. Whatever the auth method, only Vault addresses
+	// allowed by the operator (--vault-addresses) are sent a token.
+	Kubernetes *VaultKubernetesAuth `json:"kubernetes,omitempty"`
+	// Key of a Secret in the namespace of the TargetProfile holding a Vault token
+	TokenSecretRef *corev1.SecretKeySelector `json:"tokenSecretRef,omitempty"`
+}
+
+// VaultKubernetesAuth configures the Vault Kubernetes auth method
+type VaultKubernetesAuth struct {
+	// Vault role to log in with
+	// +kubebuilder:validation:MinLength=1
+	Role string `json:"role"`
+	// Mount path of the Kubernetes auth method
+	// +kubebuilder:default="kubernetes"
+	MountPath string `json:"mountPath,omitempty"`
+}
+
+// FileCredentials reads the credentials from files, one per key
+type FileCredentials struct {
+	// Directory holding the files, relative to the credentials directory of the
+	// operator (--credentials-dir), e.g. where a CSI secrets-store volume is mounted
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+
+	CredentialKeys `json:",inline"`
 }
 
 type GRPCKeepAliveConfig struct {
@@ -79,8 +253,45 @@ type GRPCKeepAliveConfig struct {
 	PermitWithoutStream bool `json:"permitWithoutStream,omitempty"`
 }
 
+// GRPCBufferConfig sets the gRPC buffer and window sizes, in bytes.
+// Unset sizes keep the gRPC defaults.
+type GRPCBufferConfig struct {
+	// Size of the read buffer
+	// +kubebuilder:validation:Minimum=0
+	ReadBufferSize *int32 `json:"readBufferSize,omitempty"`
+	// Size of the write buffer
+	// +kubebuilder:validation:Minimum=0
+	WriteBufferSize *int32 `json:"writeBufferSize,omitempty"`
+	// Initial window size of the connection
+	// +kubebuilder:validation:Minimum=65535
+	ConnWindowSize *int32 `json:"connWindowSize,omitempty"`
+	// Initial window size of each stream
+	// +kubebuilder:validation:Minimum=65535
+	WindowSize *int32 `json:"windowSize,omitempty"`
+	// Window size of the connection, disabling the dynamic window (BDP estimation)
+	// +kubebuilder:validation:Minimum=65535
+	StaticConnWindowSize *int32 `json:"staticConnWindowSize,omitempty"`
+	// Window size of each stream, disabling the dynamic window (BDP estimation)
+	// +kubebuilder:validation:Minimum=65535
+	StaticStreamWindowSize *int32 `json:"staticStreamWindowSize,omitempty"`
+}
+
+// TargetProtoConfig loads protobuf files from a ConfigMap, e.g. the
+// definitions of vendor gNMI extensions
+type TargetProtoConfig struct {
+	// Name of a ConfigMap in the namespace of the TargetProfile holding the
+	// .proto files, one per key. All of its keys are mounted so the files
+	// can import each other.
+	// +kubebuilder:validation:MinLength=1
+	ConfigMapName string `json:"configMapName"`
+	// Keys of the ConfigMap holding the files to load
+	// +kubebuilder:validation:MinItems=1
+	Files []string `json:"files"`
+}
+
 // TargetProfileStatus defines the observed state of TargetProfile
 type TargetProfileStatus struct {
+	ResourceStatus `json:",inline"`
 }
 
 // +kubebuilder:object:root=true
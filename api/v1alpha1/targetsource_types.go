@@ -23,11 +23,25 @@ import (
 
 // TargetSourceSpec defines the desired state of TargetSource
 // +kubebuilder:validation:Required
+// +kubebuilder:validation:XValidation:rule="has(self.provider) != has(self.providers)",message="exactly one of provider and providers must be set"
 type TargetSourceSpec struct {
 	// Provider defines the source of targets for this TargetSource
-	// Only one provider can be specified per TargetSource
-	// +kubebuilder:validation:Required
-	Provider *ProviderSpec `json:"provider"`
+	// Only one of provider and providers can be specified
+	// +kubebuilder:validation:Optional
+	Provider *ProviderSpec `json:"provider,omitempty"`
+
+	// Providers combines the targets of several sources, e.g. devices from
+	// an inventory enriched with labels from a CMDB. Targets of different
+	// providers are matched by name and combined according to merge.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MinItems=1
+	// +listType=map
+	// +listMapKey=name
+	Providers []NamedProviderSpec `json:"providers,omitempty"`
+
+	// Merge defines how the targets of providers are combined
+	// +kubebuilder:validation:Optional
+	Merge *MergeSpec `json:"merge,omitempty"`
 
 	// Optional port to use for discovered targets if not specified by the provider
 	// +kubebuilder:validation:Optional
@@ -40,14 +54,126 @@ type TargetSourceSpec struct {
 	// Optional TargetProfile to use for targets discovered by this TargetSource if not specified by the provider
 	// +kubebuilder:validation:Optional
 	TargetProfile string `json:"targetProfile"`
+
+	// AllowedCredentials lists the Secrets the discovered targets may
+	// reference with credentialsRef. Targets referencing another Secret are
+	// not applied. Without it, discovered targets cannot set credentialsRef.
+	// +kubebuilder:validation:Optional
+	AllowedCredentials *AllowedCredentialsSpec `json:"allowedCredentials,omitempty"`
+}
+
+// AllowedCredentialsSpec selects Secrets in the namespace of the
+// TargetSource. A Secret is allowed when it matches any of the fields.
+// +kubebuilder:validation:XValidation:rule="has(self.names) || has(self.namePrefix) || has(self.selector)",message="one of names, namePrefix or selector must be set"
+type AllowedCredentialsSpec struct {
+	// Names of the allowed Secrets
+	// +kubebuilder:validation:Optional
+	Names []string `json:"names,omitempty"`
+	// NamePrefix allows the Secrets whose name starts with it
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MinLength=1
+	NamePrefix string `json:"namePrefix,omitempty"`
+	// Selector allows the Secrets whose labels match it
+	// +kubebuilder:validation:Optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
 }
 
 // ProviderSpec defines the source of targets for a TargetSource
 // Only one provider can be specified per TargetSource
-// +kubebuilder:validation:ExactlyOneOf=http
+// +kubebuilder:validation:ExactlyOneOf=http;static
 type ProviderSpec struct {
 	// HTTP defines the configuration for a HTTP provider
 	HTTP *HTTPConfig `json:"http,omitempty"`
+
+	// Static defines a fixed list of targets
+	Static *StaticConfig `json:"static,omitempty"`
+}
+
+// NamedProviderSpec is a provider of a TargetSource combining several ones
+type NamedProviderSpec struct {
+	// Name identifies the provider in the reported conflicts
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	ProviderSpec `json:",inline"`
+}
+
+// MergePolicy defines how the targets of several providers are combined
+// +kubebuilder:validation:Enum=Union;Precedence;Enrich
+type MergePolicy string
+
+const (
+	// MergePolicyUnion keeps the targets of all providers. A target provided
+	// by several providers is taken from the first one listed.
+	MergePolicyUnion MergePolicy = "Union"
+	// MergePolicyPrecedence keeps the targets of all providers. A target
+	// provided by several providers is merged field by field, each field
+	// taken from the first provider listed that sets it.
+	MergePolicyPrecedence MergePolicy = "Precedence"
+	// MergePolicyEnrich keeps the targets of the first provider only. The
+	// other providers set the fields and labels it leaves empty.
+	MergePolicyEnrich MergePolicy = "Enrich"
+)
+
+// MergeKey is the target field identifying the same target across providers
+// +kubebuilder:validation:Enum=Name;Address;Label
+type MergeKey string
+
+const (
+	// MergeKeyName matches targets by name
+	MergeKeyName MergeKey = "Name"
+	// MergeKeyAddress matches targets by address
+	MergeKeyAddress MergeKey = "Address"
+	// MergeKeyLabel matches targets by the value of the label keyLabel
+	MergeKeyLabel MergeKey = "Label"
+)
+
+// MergeSpec defines how the targets of several providers are combined
+type MergeSpec struct {
+	// Policy combining the targets of the providers
+	// +kubebuilder:default=Union
+	// +kubebuilder:validation:Optional
+	Policy MergePolicy `json:"policy,omitempty"`
+	// Key matching the targets of different providers. Targets without a
+	// value for the key are not matched with any other target.
+	// +kubebuilder:default=Name
+	// +kubebuilder:validation:Optional
+	Key MergeKey `json:"key,omitempty"`
+	// KeyLabel is the label matching the targets when key is Label
+	// +optional
+	KeyLabel string `json:"keyLabel,omitempty"`
+}
+
+// StaticConfig defines the configuration for the static provider
+type StaticConfig struct {
+	// Targets provided as is
+	// +kubebuilder:validation:MinItems=1
+	Targets []StaticTarget `json:"targets"`
+}
+
+// StaticTarget is a target of the static provider
+type StaticTarget struct {
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Address of the target. Can be left empty to only set labels or other
+	// fields of a target of another provider.
+	// +kubebuilder:validation:Optional
+	Address string `json:"address,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	Port int32 `json:"port,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	TargetProfile string `json:"targetProfile,omitempty"`
+
+	// Name of the Secret holding the credentials of the target, overriding
+	// those of its profile.
+	// +kubebuilder:validation:Optional
+	CredentialsRef string `json:"credentialsRef,omitempty"`
 }
 
 // HTTPConfig defines the configuration for the HTTP provider
@@ -360,6 +486,21 @@ type ResponseMappingSpec struct {
 	//
 	// +kubebuilder:validation:Optional
 	TargetProfile string `json:"targetProfile,omitempty"`
+
+	// CEL expression for the name of the Secret holding the credentials of
+	// the target, overriding those of its profile. Devices with unique local
+	// accounts get their own Secret, the others use the profile's.
+	//
+	// If not set, defaults to:
+	//   item["credentialsRef"]
+	//
+	// Example:
+	//   "has(item.custom_fields.credentials) ? item.custom_fields.credentials : ''"
+	//
+	// An empty string keeps the credentials of the profile.
+	//
+	// +kubebuilder:validation:Optional
+	CredentialsRef string `json:"credentialsRef,omitempty"`
 }
 
 // PushSpec defines the settings for event-based update mechanism (i.e. webhooks sent from the server)
@@ -369,6 +510,38 @@ type PushSpec struct {
 
 	// +kubebuilder:validation:Optional
 	Auth *PushAuthSpec `json:"auth,omitempty"`
+
+	// Optional mapping transforming pushed payloads, e.g. the JSON sent by
+	// NetBox event rules, into targets. If not set, payloads must be a list of
+	// targets as described in the REST API documentation.
+	// +kubebuilder:validation:Optional
+	Mapping *PushMappingSpec `json:"mapping,omitempty"`
+}
+
+// PushMappingSpec controls how targets are extracted from a pushed JSON payload.
+//
+// It accepts the fields of ResponseMappingSpec, with `self` being the pushed
+// payload, and adds the operation applied to each target.
+//
+// Example for a NetBox event rule on devices:
+//
+//	targetsField: "[self.data]"
+//	name: "item.name"
+//	address: "item.primary_ip.address.split('/')[0]"
+//	operation: "self.event"
+type PushMappingSpec struct {
+	ResponseMappingSpec `json:",inline"`
+
+	// CEL expression for the operation applied to a target, one of
+	// "created", "updated" or "deleted". Ignored for snapshot pushes.
+	//
+	// If not set, defaults to:
+	//   item["operation"]
+	//
+	// Targets without operation are applied.
+	//
+	// +kubebuilder:validation:Optional
+	Operation string `json:"operation,omitempty"`
 }
 
 // +kubebuilder:validation:ExactlyOneOf:=bearer;signature
@@ -401,6 +574,15 @@ type TargetSourceStatus struct {
 	ObservedGeneration int64       `json:"observedGeneration"`
 	TargetsCount       int32       `json:"targetsCount,omitempty"`
 	LastSync           metav1.Time `json:"lastSync,omitempty"`
+	// QueueDepth is the number of discovery messages received but not
+	// applied yet
+	QueueDepth int32 `json:"queueDepth,omitempty"`
+
+	// Conflicts lists the targets several providers disagree on, with the
+	// merge policy deciding the value used
+	// +listType=atomic
+	// +optional
+	Conflicts []TargetConflict `json:"conflicts,omitempty"`
 
 	// Conditions represent the latest available observations of the
 	// TargetSource's state.
@@ -410,6 +592,17 @@ type TargetSourceStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+// TargetConflict is a target several providers of a TargetSource disagree on
+type TargetConflict struct {
+	// Target is the name of the target as provided by the first provider
+	Target string `json:"target"`
+	// Providers that provide the target, the first one wins
+	Providers []string `json:"providers"`
+	// Fields the providers set to different values
+	// +optional
+	Fields []string `json:"fields,omitempty"`
+}
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 
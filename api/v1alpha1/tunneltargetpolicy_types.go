@@ -39,6 +39,7 @@ type tunnelTargetMatch struct {
 
 // TunnelTargetPolicyStatus defines the observed state of TunnelTargetPolicy.
 type TunnelTargetPolicyStatus struct {
+	ResourceStatus `json:",inline"`
 }
 
 // +kubebuilder:object:root=true
@@ -46,6 +46,31 @@ func (in *APIConfig) DeepCopy() *APIConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AllowedCredentialsSpec) DeepCopyInto(out *AllowedCredentialsSpec) {
+	*out = *in
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllowedCredentialsSpec.
+func (in *AllowedCredentialsSpec) DeepCopy() *AllowedCredentialsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AllowedCredentialsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AuthenticationSpec) DeepCopyInto(out *AuthenticationSpec) {
 	*out = *in
@@ -91,6 +116,31 @@ func (in *BasicAuthSpec) DeepCopy() *BasicAuthSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CABundleRef) DeepCopyInto(out *CABundleRef) {
+	*out = *in
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CABundleRef.
+func (in *CABundleRef) DeepCopy() *CABundleRef {
+	if in == nil {
+		return nil
+	}
+	out := new(CABundleRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClientTLSConfig) DeepCopyInto(out *ClientTLSConfig) {
 	*out = *in
@@ -206,6 +256,16 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 		*out = new(TargetDistributionConfig)
 		**out = **in
 	}
+	if in.PlanHistory != nil {
+		in, out := &in.PlanHistory, &out.PlanHistory
+		*out = new(PlanHistoryConfig)
+		**out = **in
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(RolloutConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSpec.
@@ -278,6 +338,246 @@ func (in *ClusterTargetState) DeepCopy() *ClusterTargetState {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigSecretRef) DeepCopyInto(out *ConfigSecretRef) {
+	*out = *in
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SecretKeyToPath, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigSecretRef.
+func (in *ConfigSecretRef) DeepCopy() *ConfigSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialKeys) DeepCopyInto(out *CredentialKeys) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialKeys.
+func (in *CredentialKeys) DeepCopy() *CredentialKeys {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialKeys)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsSource) DeepCopyInto(out *CredentialsSource) {
+	*out = *in
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(SecretCredentials)
+		**out = **in
+	}
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(VaultCredentials)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.File != nil {
+		in, out := &in.File, &out.File
+		*out = new(FileCredentials)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsSource.
+func (in *CredentialsSource) DeepCopy() *CredentialsSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileCredentials) DeepCopyInto(out *FileCredentials) {
+	*out = *in
+	out.CredentialKeys = in.CredentialKeys
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileCredentials.
+func (in *FileCredentials) DeepCopy() *FileCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(FileCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GNMIJobActiveRun) DeepCopyInto(out *GNMIJobActiveRun) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GNMIJobActiveRun.
+func (in *GNMIJobActiveRun) DeepCopy() *GNMIJobActiveRun {
+	if in == nil {
+		return nil
+	}
+	out := new(GNMIJobActiveRun)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GNMIJobSpec) DeepCopyInto(out *GNMIJobSpec) {
+	*out = *in
+	if in.TargetSelectors != nil {
+		in, out := &in.TargetSelectors, &out.TargetSelectors
+		*out = make([]metav1.LabelSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TargetRefs != nil {
+		in, out := &in.TargetRefs, &out.TargetRefs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GNMIJobSpec.
+func (in *GNMIJobSpec) DeepCopy() *GNMIJobSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GNMIJobSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GNMIJobStatus) DeepCopyInto(out *GNMIJobStatus) {
+	*out = *in
+	if in.LastRunTime != nil {
+		in, out := &in.LastRunTime, &out.LastRunTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextRunTime != nil {
+		in, out := &in.NextRunTime, &out.NextRunTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Active != nil {
+		in, out := &in.Active, &out.Active
+		*out = new(GNMIJobActiveRun)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]GNMIJobTargetResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GNMIJobStatus.
+func (in *GNMIJobStatus) DeepCopy() *GNMIJobStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GNMIJobStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GNMIJobTargetResult) DeepCopyInto(out *GNMIJobTargetResult) {
+	*out = *in
+	if in.Timestamp != nil {
+		in, out := &in.Timestamp, &out.Timestamp
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GNMIJobTargetResult.
+func (in *GNMIJobTargetResult) DeepCopy() *GNMIJobTargetResult {
+	if in == nil {
+		return nil
+	}
+	out := new(GNMIJobTargetResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GRPCBufferConfig) DeepCopyInto(out *GRPCBufferConfig) {
+	*out = *in
+	if in.ReadBufferSize != nil {
+		in, out := &in.ReadBufferSize, &out.ReadBufferSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.WriteBufferSize != nil {
+		in, out := &in.WriteBufferSize, &out.WriteBufferSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ConnWindowSize != nil {
+		in, out := &in.ConnWindowSize, &out.ConnWindowSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.WindowSize != nil {
+		in, out := &in.WindowSize, &out.WindowSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.StaticConnWindowSize != nil {
+		in, out := &in.StaticConnWindowSize, &out.StaticConnWindowSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.StaticStreamWindowSize != nil {
+		in, out := &in.StaticStreamWindowSize, &out.StaticStreamWindowSize
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GRPCBufferConfig.
+func (in *GRPCBufferConfig) DeepCopy() *GRPCBufferConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(GRPCBufferConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GRPCKeepAliveConfig) DeepCopyInto(out *GRPCKeepAliveConfig) {
 	*out = *in
@@ -320,6 +620,102 @@ func (in *GRPCTunnelConfig) DeepCopy() *GRPCTunnelConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GetJob) DeepCopyInto(out *GetJob) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GetJob.
+func (in *GetJob) DeepCopy() *GetJob {
+	if in == nil {
+		return nil
+	}
+	out := new(GetJob)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GetJob) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GetJobList) DeepCopyInto(out *GetJobList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GetJob, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GetJobList.
+func (in *GetJobList) DeepCopy() *GetJobList {
+	if in == nil {
+		return nil
+	}
+	out := new(GetJobList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GetJobList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GetJobSpec) DeepCopyInto(out *GetJobSpec) {
+	*out = *in
+	in.GNMIJobSpec.DeepCopyInto(&out.GNMIJobSpec)
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GetJobSpec.
+func (in *GetJobSpec) DeepCopy() *GetJobSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GetJobSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GetJobStatus) DeepCopyInto(out *GetJobStatus) {
+	*out = *in
+	in.GNMIJobStatus.DeepCopyInto(&out.GNMIJobStatus)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GetJobStatus.
+func (in *GetJobStatus) DeepCopy() *GetJobStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GetJobStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTPConfig) DeepCopyInto(out *HTTPConfig) {
 	*out = *in
@@ -383,7 +779,7 @@ func (in *Input) DeepCopyInto(out *Input) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Input.
@@ -479,6 +875,11 @@ func (in *InputSelector) DeepCopy() *InputSelector {
 func (in *InputSpec) DeepCopyInto(out *InputSpec) {
 	*out = *in
 	in.Config.DeepCopyInto(&out.Config)
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(ConfigSecretRef)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InputSpec.
@@ -494,6 +895,7 @@ func (in *InputSpec) DeepCopy() *InputSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *InputStatus) DeepCopyInto(out *InputStatus) {
 	*out = *in
+	in.ResourceStatus.DeepCopyInto(&out.ResourceStatus)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InputStatus.
@@ -506,13 +908,191 @@ func (in *InputStatus) DeepCopy() *InputStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	in.ScheduleWindow.DeepCopyInto(&out.ScheduleWindow)
+	if in.TargetSelector != nil {
+		in, out := &in.TargetSelector, &out.TargetSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MergeSpec) DeepCopyInto(out *MergeSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MergeSpec.
+func (in *MergeSpec) DeepCopy() *MergeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MergeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModelSet) DeepCopyInto(out *ModelSet) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelSet.
+func (in *ModelSet) DeepCopy() *ModelSet {
+	if in == nil {
+		return nil
+	}
+	out := new(ModelSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ModelSet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModelSetList) DeepCopyInto(out *ModelSetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ModelSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelSetList.
+func (in *ModelSetList) DeepCopy() *ModelSetList {
+	if in == nil {
+		return nil
+	}
+	out := new(ModelSetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ModelSetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModelSetReference) DeepCopyInto(out *ModelSetReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelSetReference.
+func (in *ModelSetReference) DeepCopy() *ModelSetReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ModelSetReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModelSetSpec) DeepCopyInto(out *ModelSetSpec) {
+	*out = *in
+	if in.ConfigMaps != nil {
+		in, out := &in.ConfigMaps, &out.ConfigMaps
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Modules != nil {
+		in, out := &in.Modules, &out.Modules
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelSetSpec.
+func (in *ModelSetSpec) DeepCopy() *ModelSetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ModelSetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModelSetStatus) DeepCopyInto(out *ModelSetStatus) {
+	*out = *in
+	if in.Modules != nil {
+		in, out := &in.Modules, &out.Modules
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelSetStatus.
+func (in *ModelSetStatus) DeepCopy() *ModelSetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ModelSetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamedProviderSpec) DeepCopyInto(out *NamedProviderSpec) {
+	*out = *in
+	in.ProviderSpec.DeepCopyInto(&out.ProviderSpec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamedProviderSpec.
+func (in *NamedProviderSpec) DeepCopy() *NamedProviderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NamedProviderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Output) DeepCopyInto(out *Output) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Output.
@@ -652,6 +1232,11 @@ func (in *OutputSpec) DeepCopyInto(out *OutputSpec) {
 		*out = new(ServiceSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(ConfigSecretRef)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutputSpec.
@@ -667,6 +1252,7 @@ func (in *OutputSpec) DeepCopy() *OutputSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OutputStatus) DeepCopyInto(out *OutputStatus) {
 	*out = *in
+	in.ResourceStatus.DeepCopyInto(&out.ResourceStatus)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutputStatus.
@@ -753,9 +1339,48 @@ func (in *PipelineList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineSchedule) DeepCopyInto(out *PipelineSchedule) {
+	*out = *in
+	if in.ActiveWindows != nil {
+		in, out := &in.ActiveWindows, &out.ActiveWindows
+		*out = make([]ScheduleWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineSchedule.
+func (in *PipelineSchedule) DeepCopy() *PipelineSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PipelineSpec) DeepCopyInto(out *PipelineSpec) {
 	*out = *in
+	if in.TTLAfterEnable != nil {
+		in, out := &in.TTLAfterEnable, &out.TTLAfterEnable
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(PipelineSchedule)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.TargetSelectors != nil {
 		in, out := &in.TargetSelectors, &out.TargetSelectors
 		*out = make([]metav1.LabelSelector, len(*in))
@@ -823,6 +1448,19 @@ func (in *PipelineStatus) DeepCopyInto(out *PipelineStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.NextTransitionTime != nil {
+		in, out := &in.NextTransitionTime, &out.NextTransitionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.EnabledAt != nil {
+		in, out := &in.EnabledAt, &out.EnabledAt
+		*out = (*in).DeepCopy()
+	}
+	if in.UnresolvedRefs != nil {
+		in, out := &in.UnresolvedRefs, &out.UnresolvedRefs
+		*out = make([]UnresolvedReference, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineStatus.
@@ -835,13 +1473,28 @@ func (in *PipelineStatus) DeepCopy() *PipelineStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlanHistoryConfig) DeepCopyInto(out *PlanHistoryConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlanHistoryConfig.
+func (in *PlanHistoryConfig) DeepCopy() *PlanHistoryConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PlanHistoryConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Processor) DeepCopyInto(out *Processor) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Processor.
@@ -913,6 +1566,7 @@ func (in *ProcessorSpec) DeepCopy() *ProcessorSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProcessorStatus) DeepCopyInto(out *ProcessorStatus) {
 	*out = *in
+	in.ResourceStatus.DeepCopyInto(&out.ResourceStatus)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProcessorStatus.
@@ -933,6 +1587,11 @@ func (in *ProviderSpec) DeepCopyInto(out *ProviderSpec) {
 		*out = new(HTTPConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Static != nil {
+		in, out := &in.Static, &out.Static
+		*out = new(StaticConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderSpec.
@@ -990,6 +1649,22 @@ func (in *PushBearerAuthSpec) DeepCopy() *PushBearerAuthSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PushMappingSpec) DeepCopyInto(out *PushMappingSpec) {
+	*out = *in
+	out.ResponseMappingSpec = in.ResponseMappingSpec
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PushMappingSpec.
+func (in *PushMappingSpec) DeepCopy() *PushMappingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PushMappingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PushSignatureAuthSpec) DeepCopyInto(out *PushSignatureAuthSpec) {
 	*out = *in
@@ -1018,6 +1693,11 @@ func (in *PushSpec) DeepCopyInto(out *PushSpec) {
 		*out = new(PushAuthSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Mapping != nil {
+		in, out := &in.Mapping, &out.Mapping
+		*out = new(PushMappingSpec)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PushSpec.
@@ -1030,6 +1710,55 @@ func (in *PushSpec) DeepCopy() *PushSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceStatus) DeepCopyInto(out *ResourceStatus) {
+	*out = *in
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make(map[string]ResourceUsage, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceStatus.
+func (in *ResourceStatus) DeepCopy() *ResourceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceUsage) DeepCopyInto(out *ResourceUsage) {
+	*out = *in
+	if in.Pipelines != nil {
+		in, out := &in.Pipelines, &out.Pipelines
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceUsage.
+func (in *ResourceUsage) DeepCopy() *ResourceUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResponseMappingSpec) DeepCopyInto(out *ResponseMappingSpec) {
 	*out = *in
@@ -1045,6 +1774,77 @@ func (in *ResponseMappingSpec) DeepCopy() *ResponseMappingSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutConfig) DeepCopyInto(out *RolloutConfig) {
+	*out = *in
+	if in.BakeDuration != nil {
+		in, out := &in.BakeDuration, &out.BakeDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutConfig.
+func (in *RolloutConfig) DeepCopy() *RolloutConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduleWindow) DeepCopyInto(out *ScheduleWindow) {
+	*out = *in
+	if in.Start != nil {
+		in, out := &in.Start, &out.Start
+		*out = (*in).DeepCopy()
+	}
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduleWindow.
+func (in *ScheduleWindow) DeepCopy() *ScheduleWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduleWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretCredentials) DeepCopyInto(out *SecretCredentials) {
+	*out = *in
+	out.CredentialKeys = in.CredentialKeys
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretCredentials.
+func (in *SecretCredentials) DeepCopy() *SecretCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeyToPath) DeepCopyInto(out *SecretKeyToPath) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeyToPath.
+func (in *SecretKeyToPath) DeepCopy() *SecretKeyToPath {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeyToPath)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceConfig) DeepCopyInto(out *ServiceConfig) {
 	*out = *in
@@ -1111,13 +1911,183 @@ func (in *ServiceSelector) DeepCopy() *ServiceSelector {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SetJob) DeepCopyInto(out *SetJob) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetJob.
+func (in *SetJob) DeepCopy() *SetJob {
+	if in == nil {
+		return nil
+	}
+	out := new(SetJob)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SetJob) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SetJobList) DeepCopyInto(out *SetJobList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SetJob, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetJobList.
+func (in *SetJobList) DeepCopy() *SetJobList {
+	if in == nil {
+		return nil
+	}
+	out := new(SetJobList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SetJobList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SetJobSpec) DeepCopyInto(out *SetJobSpec) {
+	*out = *in
+	in.GNMIJobSpec.DeepCopyInto(&out.GNMIJobSpec)
+	if in.Deletes != nil {
+		in, out := &in.Deletes, &out.Deletes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Replaces != nil {
+		in, out := &in.Replaces, &out.Replaces
+		*out = make([]SetJobUpdate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Updates != nil {
+		in, out := &in.Updates, &out.Updates
+		*out = make([]SetJobUpdate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetJobSpec.
+func (in *SetJobSpec) DeepCopy() *SetJobSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SetJobSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SetJobStatus) DeepCopyInto(out *SetJobStatus) {
+	*out = *in
+	in.GNMIJobStatus.DeepCopyInto(&out.GNMIJobStatus)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetJobStatus.
+func (in *SetJobStatus) DeepCopy() *SetJobStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SetJobStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SetJobUpdate) DeepCopyInto(out *SetJobUpdate) {
+	*out = *in
+	in.Value.DeepCopyInto(&out.Value)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetJobUpdate.
+func (in *SetJobUpdate) DeepCopy() *SetJobUpdate {
+	if in == nil {
+		return nil
+	}
+	out := new(SetJobUpdate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticConfig) DeepCopyInto(out *StaticConfig) {
+	*out = *in
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]StaticTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticConfig.
+func (in *StaticConfig) DeepCopy() *StaticConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticTarget) DeepCopyInto(out *StaticTarget) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticTarget.
+func (in *StaticTarget) DeepCopy() *StaticTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Subscription) DeepCopyInto(out *Subscription) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Subscription.
@@ -1191,6 +2161,11 @@ func (in *SubscriptionList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SubscriptionSpec) DeepCopyInto(out *SubscriptionSpec) {
 	*out = *in
+	if in.Models != nil {
+		in, out := &in.Models, &out.Models
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Paths != nil {
 		in, out := &in.Paths, &out.Paths
 		*out = make([]string, len(*in))
@@ -1198,11 +2173,24 @@ func (in *SubscriptionSpec) DeepCopyInto(out *SubscriptionSpec) {
 	}
 	out.SampleInterval = in.SampleInterval
 	out.HeartbeatInterval = in.HeartbeatInterval
+	out.PollInterval = in.PollInterval
+	if in.PollJitter != nil {
+		in, out := &in.PollJitter, &out.PollJitter
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 	if in.StreamSubscriptions != nil {
 		in, out := &in.StreamSubscriptions, &out.StreamSubscriptions
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Streams != nil {
+		in, out := &in.Streams, &out.Streams
+		*out = make([]SubscriptionStream, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Qos != nil {
 		in, out := &in.Qos, &out.Qos
 		*out = new(uint32)
@@ -1213,6 +2201,11 @@ func (in *SubscriptionSpec) DeepCopyInto(out *SubscriptionSpec) {
 		*out = new(SubscriptionHistoryConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ModelSet != nil {
+		in, out := &in.ModelSet, &out.ModelSet
+		*out = new(ModelSetReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubscriptionSpec.
@@ -1228,6 +2221,7 @@ func (in *SubscriptionSpec) DeepCopy() *SubscriptionSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SubscriptionStatus) DeepCopyInto(out *SubscriptionStatus) {
 	*out = *in
+	in.ResourceStatus.DeepCopyInto(&out.ResourceStatus)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubscriptionStatus.
@@ -1240,12 +2234,34 @@ func (in *SubscriptionStatus) DeepCopy() *SubscriptionStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubscriptionStream) DeepCopyInto(out *SubscriptionStream) {
+	*out = *in
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.SampleInterval = in.SampleInterval
+	out.HeartbeatInterval = in.HeartbeatInterval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubscriptionStream.
+func (in *SubscriptionStream) DeepCopy() *SubscriptionStream {
+	if in == nil {
+		return nil
+	}
+	out := new(SubscriptionStream)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Target) DeepCopyInto(out *Target) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -1267,6 +2283,57 @@ func (in *Target) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetCapabilities) DeepCopyInto(out *TargetCapabilities) {
+	*out = *in
+	in.LastProbeTime.DeepCopyInto(&out.LastProbeTime)
+	if in.SupportedEncodings != nil {
+		in, out := &in.SupportedEncodings, &out.SupportedEncodings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SupportedModels != nil {
+		in, out := &in.SupportedModels, &out.SupportedModels
+		*out = make([]TargetModel, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetCapabilities.
+func (in *TargetCapabilities) DeepCopy() *TargetCapabilities {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetCapabilities)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetConflict) DeepCopyInto(out *TargetConflict) {
+	*out = *in
+	if in.Providers != nil {
+		in, out := &in.Providers, &out.Providers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetConflict.
+func (in *TargetConflict) DeepCopy() *TargetConflict {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetConflict)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TargetDistributionConfig) DeepCopyInto(out *TargetDistributionConfig) {
 	*out = *in
@@ -1314,13 +2381,60 @@ func (in *TargetList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetModel) DeepCopyInto(out *TargetModel) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetModel.
+func (in *TargetModel) DeepCopy() *TargetModel {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetModel)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetPoll) DeepCopyInto(out *TargetPoll) {
+	*out = *in
+	in.LastPollTime.DeepCopyInto(&out.LastPollTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetPoll.
+func (in *TargetPoll) DeepCopy() *TargetPoll {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetPoll)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetProbeConfig) DeepCopyInto(out *TargetProbeConfig) {
+	*out = *in
+	out.Interval = in.Interval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetProbeConfig.
+func (in *TargetProbeConfig) DeepCopy() *TargetProbeConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetProbeConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TargetProfile) DeepCopyInto(out *TargetProfile) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetProfile.
@@ -1376,6 +2490,11 @@ func (in *TargetProfileList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TargetProfileSpec) DeepCopyInto(out *TargetProfileSpec) {
 	*out = *in
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(CredentialsSource)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.TLS != nil {
 		in, out := &in.TLS, &out.TLS
 		*out = new(TargetTLSConfig)
@@ -1400,6 +2519,43 @@ func (in *TargetProfileSpec) DeepCopyInto(out *TargetProfileSpec) {
 		*out = new(GRPCKeepAliveConfig)
 		**out = **in
 	}
+	if in.BufferSize != nil {
+		in, out := &in.BufferSize, &out.BufferSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.GRPCBuffers != nil {
+		in, out := &in.GRPCBuffers, &out.GRPCBuffers
+		*out = new(GRPCBufferConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Proto != nil {
+		in, out := &in.Proto, &out.Proto
+		*out = new(TargetProtoConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TargetTags != nil {
+		in, out := &in.TargetTags, &out.TargetTags
+		*out = new(TargetTagsConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Probe != nil {
+		in, out := &in.Probe, &out.Probe
+		*out = new(TargetProbeConfig)
+		**out = **in
+	}
+	if in.ModelSet != nil {
+		in, out := &in.ModelSet, &out.ModelSet
+		*out = new(ModelSetReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetProfileSpec.
@@ -1415,6 +2571,7 @@ func (in *TargetProfileSpec) DeepCopy() *TargetProfileSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TargetProfileStatus) DeepCopyInto(out *TargetProfileStatus) {
 	*out = *in
+	in.ResourceStatus.DeepCopyInto(&out.ResourceStatus)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetProfileStatus.
@@ -1427,6 +2584,26 @@ func (in *TargetProfileStatus) DeepCopy() *TargetProfileStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetProtoConfig) DeepCopyInto(out *TargetProtoConfig) {
+	*out = *in
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetProtoConfig.
+func (in *TargetProtoConfig) DeepCopy() *TargetProtoConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetProtoConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TargetSource) DeepCopyInto(out *TargetSource) {
 	*out = *in
@@ -1494,6 +2671,18 @@ func (in *TargetSourceSpec) DeepCopyInto(out *TargetSourceSpec) {
 		*out = new(ProviderSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Providers != nil {
+		in, out := &in.Providers, &out.Providers
+		*out = make([]NamedProviderSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Merge != nil {
+		in, out := &in.Merge, &out.Merge
+		*out = new(MergeSpec)
+		**out = **in
+	}
 	if in.TargetLabels != nil {
 		in, out := &in.TargetLabels, &out.TargetLabels
 		*out = make(map[string]string, len(*in))
@@ -1501,6 +2690,11 @@ func (in *TargetSourceSpec) DeepCopyInto(out *TargetSourceSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.AllowedCredentials != nil {
+		in, out := &in.AllowedCredentials, &out.AllowedCredentials
+		*out = new(AllowedCredentialsSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetSourceSpec.
@@ -1517,6 +2711,13 @@ func (in *TargetSourceSpec) DeepCopy() *TargetSourceSpec {
 func (in *TargetSourceStatus) DeepCopyInto(out *TargetSourceStatus) {
 	*out = *in
 	in.LastSync.DeepCopyInto(&out.LastSync)
+	if in.Conflicts != nil {
+		in, out := &in.Conflicts, &out.Conflicts
+		*out = make([]TargetConflict, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]metav1.Condition, len(*in))
@@ -1539,6 +2740,35 @@ func (in *TargetSourceStatus) DeepCopy() *TargetSourceStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TargetSpec) DeepCopyInto(out *TargetSpec) {
 	*out = *in
+	if in.Subscriptions != nil {
+		in, out := &in.Subscriptions, &out.Subscriptions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BufferSize != nil {
+		in, out := &in.BufferSize, &out.BufferSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetSpec.
@@ -1561,6 +2791,18 @@ func (in *TargetStatus) DeepCopyInto(out *TargetStatus) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.Capabilities != nil {
+		in, out := &in.Capabilities, &out.Capabilities
+		*out = new(TargetCapabilities)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Polls != nil {
+		in, out := &in.Polls, &out.Polls
+		*out = make([]TargetPoll, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetStatus.
@@ -1581,6 +2823,11 @@ func (in *TargetTLSConfig) DeepCopyInto(out *TargetTLSConfig) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.CABundleRef != nil {
+		in, out := &in.CABundleRef, &out.CABundleRef
+		*out = new(CABundleRef)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetTLSConfig.
@@ -1593,6 +2840,46 @@ func (in *TargetTLSConfig) DeepCopy() *TargetTLSConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetTag) DeepCopyInto(out *TargetTag) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetTag.
+func (in *TargetTag) DeepCopy() *TargetTag {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetTag)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetTagsConfig) DeepCopyInto(out *TargetTagsConfig) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]TargetTag, len(*in))
+		copy(*out, *in)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make([]TargetTag, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetTagsConfig.
+func (in *TargetTagsConfig) DeepCopy() *TargetTagsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetTagsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TokenAuthSpec) DeepCopyInto(out *TokenAuthSpec) {
 	*out = *in
@@ -1619,7 +2906,7 @@ func (in *TunnelTargetPolicy) DeepCopyInto(out *TunnelTargetPolicy) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelTargetPolicy.
@@ -1695,6 +2982,7 @@ func (in *TunnelTargetPolicySpec) DeepCopy() *TunnelTargetPolicySpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TunnelTargetPolicyStatus) DeepCopyInto(out *TunnelTargetPolicyStatus) {
 	*out = *in
+	in.ResourceStatus.DeepCopyInto(&out.ResourceStatus)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelTargetPolicyStatus.
@@ -1706,3 +2994,85 @@ func (in *TunnelTargetPolicyStatus) DeepCopy() *TunnelTargetPolicyStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnresolvedReference) DeepCopyInto(out *UnresolvedReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnresolvedReference.
+func (in *UnresolvedReference) DeepCopy() *UnresolvedReference {
+	if in == nil {
+		return nil
+	}
+	out := new(UnresolvedReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultAuth) DeepCopyInto(out *VaultAuth) {
+	*out = *in
+	if in.Kubernetes != nil {
+		in, out := &in.Kubernetes, &out.Kubernetes
+		*out = new(VaultKubernetesAuth)
+		**out = **in
+	}
+	if in.TokenSecretRef != nil {
+		in, out := &in.TokenSecretRef, &out.TokenSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultAuth.
+func (in *VaultAuth) DeepCopy() *VaultAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultCredentials) DeepCopyInto(out *VaultCredentials) {
+	*out = *in
+	out.CredentialKeys = in.CredentialKeys
+	in.Auth.DeepCopyInto(&out.Auth)
+	if in.RefreshInterval != nil {
+		in, out := &in.RefreshInterval, &out.RefreshInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(ClientTLSConfig)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultCredentials.
+func (in *VaultCredentials) DeepCopy() *VaultCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultKubernetesAuth) DeepCopyInto(out *VaultKubernetesAuth) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultKubernetesAuth.
+func (in *VaultKubernetesAuth) DeepCopy() *VaultKubernetesAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultKubernetesAuth)
+	in.DeepCopyInto(out)
+	return out
+}
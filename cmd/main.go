@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"flag"
+	"fmt"
 	"net/http"
 	"os"
 	"strings"
@@ -45,9 +46,11 @@ import (
 	operatorv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
 	"github.com/gnmic/operator/internal/apiserver"
 	"github.com/gnmic/operator/internal/controller"
+	"github.com/gnmic/operator/internal/controller/credentials"
 	"github.com/gnmic/operator/internal/controller/discovery"
 	"github.com/gnmic/operator/internal/controller/discovery/core"
 	webhookv1alpha1 "github.com/gnmic/operator/internal/webhook/v1alpha1"
+	"github.com/gnmic/operator/internal/yang"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -73,9 +76,16 @@ func main() {
 	var apiAddr string
 	var discoveryChunkSize int
 	var discoveryBufferSize int
+	var discoveryJournal string
 	var kubeAPIQPS float64
 	var kubeAPIBurst int
 	var watchNamespaces string
+	var apiAuth apiserver.AuthOptions
+	var apiTokenReviewAudiences string
+	var apiTLS apiserver.TLSOptions
+	var credentialsDir string
+	var vaultAddresses string
+	var vaultOpts credentials.VaultOptions
 	flag.StringVar(&apiAddr, "api-bind-address", "", "The address the operator API endpoint binds to. Disabled if empty.")
 	flag.BoolVar(&devMode, "dev-mode", false, "Enable development mode.")
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
@@ -85,9 +95,19 @@ func main() {
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.IntVar(&discoveryChunkSize, "discovery-chunk-size", 100, "Maximum number of targets/events sent in a single discovery message.")
 	flag.IntVar(&discoveryBufferSize, "discovery-buffer-size", 10, "Amount of discovery messages that can be queued in the channel buffer.")
+	flag.StringVar(&discoveryJournal, "discovery-journal", "", "Journal persisting pushed discovery messages until they are applied, so that they are replayed after a restart. Supported: configmap. Disabled if empty.")
 	flag.Float64Var(&kubeAPIQPS, "kube-api-qps", 50, "Maximum sustained queries per second to the Kubernetes API server. The client-go default (20) is too low for large target populations.")
 	flag.IntVar(&kubeAPIBurst, "kube-api-burst", 100, "Maximum burst of queries to the Kubernetes API server.")
 	flag.StringVar(&watchNamespaces, "watch-namespaces", "", "Comma-separated list of namespaces to watch. Empty (the default) watches all namespaces, which caches every Secret, ConfigMap, Service, StatefulSet and Certificate in the cluster.")
+	flag.BoolVar(&apiAuth.TokenReview, "api-token-review", false, "Authenticate operator API bearer tokens, e.g. ServiceAccount tokens, with a Kubernetes TokenReview.")
+	flag.StringVar(&apiTokenReviewAudiences, "api-token-review-audiences", "", "Comma-separated list of audiences the operator API TokenReview checks tokens against. Empty uses the Kubernetes API server audiences.")
+	flag.StringVar(&apiAuth.ClientCAFile, "api-client-ca-file", "", "PEM CA bundle authenticating operator API TLS client certificates.")
+	flag.StringVar(&apiAuth.TokenFile, "api-token-file", "", "Operator API static token file, one token,user,uid,\"group1,group2\" record per line.")
+	flag.StringVar(&apiTLS.CertDir, "api-tls-cert-dir", "", "Directory holding the operator API serving certificate (tls.crt, tls.key). The API is served over TLS when set, the certificate is reloaded on rotation.")
+	flag.StringVar(&credentialsDir, "credentials-dir", "", "Directory TargetProfiles read file credentials from, e.g. a CSI secrets-store volume. File credentials are disabled if empty.")
+	flag.StringVar(&vaultAddresses, "vault-addresses", "", "Comma-separated list of the Vault servers TargetProfiles may read credentials from. The operator sends them a Vault token, read from a Secret or obtained by logging in with a token of the gnmic-vault-auth ServiceAccount. Vault credentials are disabled if empty.")
+	flag.StringVar(&vaultOpts.Audience, "vault-audience", credentials.DefaultVaultAudience, "Audience of the ServiceAccount tokens the operator logs in to Vault with.")
+	flag.BoolVar(&apiTLS.RequireClientCert, "api-require-client-cert", false, "Reject operator API TLS connections without a client certificate verified against --api-client-ca-file.")
 	opts := zap.Options{
 		Development: devMode,
 	}
@@ -96,7 +116,17 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if vaultAddresses != "" {
+		vaultOpts.Addresses = strings.Split(vaultAddresses, ",")
+	}
+
 	discoveryRegistry := discovery.NewRegistry[types.NamespacedName, core.DiscoveryRegistryValue]()
+	switch discoveryJournal {
+	case "", discovery.JournalConfigMap:
+	default:
+		setupLog.Error(fmt.Errorf("unsupported discovery journal %q", discoveryJournal), "invalid --discovery-journal")
+		os.Exit(1)
+	}
 
 	// The rest-client defaults (20 QPS / 30 burst) throttle every controller in the
 	// process behind whichever one is busiest. With a few thousand Targets that shows up
@@ -131,6 +161,9 @@ func main() {
 	// namespaces this instance does not reconcile. Give them the same list so they can warn
 	// rather than silently accept resources that will never be acted on.
 	webhookv1alpha1.SetWatchedNamespaces(namespaces)
+	// The YANG modules of the ModelSets are parsed once for the controllers
+	// and the Subscription webhook checking paths against them.
+	modelSets := yang.NewLoader()
 
 	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme:                 scheme,
@@ -165,16 +198,39 @@ func main() {
 		Client:  mgr.GetClient(),
 		Scheme:  mgr.GetScheme(),
 		Applied: applyCache,
+		// Vault and file credentials are refreshed in the background, and
+		// their rotation reconciles only the clusters using them.
+		Credentials: credentials.NewStore(mgr.GetClient(), credentialsDir, vaultOpts),
+		Models:      modelSets,
 	}
 	if err = clusterReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Cluster")
 		os.Exit(1)
 	}
 	if err = (&controller.PipelineReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Clusters: clusterReconciler,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Pipeline")
+		os.Exit(1)
+	}
+	if err = (&controller.TargetProbeReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
+		// the probes read the credentials as the plans do
+		Credentials: clusterReconciler,
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "Pipeline")
+		setupLog.Error(err, "unable to create controller", "controller", "TargetProbe")
+		os.Exit(1)
+	}
+	if err = (&controller.SubscriptionPollReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		// the polls follow the plans applied to the clusters
+		Clusters: clusterReconciler,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SubscriptionPoll")
 		os.Exit(1)
 	}
 
@@ -185,12 +241,39 @@ func main() {
 			setupLog.Error(err, "unable to initialize API server")
 			os.Exit(1)
 		}
+		if apiTokenReviewAudiences != "" {
+			apiAuth.TokenReviewAudiences = strings.Split(apiTokenReviewAudiences, ",")
+		}
+		if apiTLS.CertDir != "" {
+			apiTLS.ClientCAFile = apiAuth.ClientCAFile
+			apiCertWatcher, err := api.EnableTLS(apiTLS)
+			if err != nil {
+				setupLog.Error(err, "unable to enable API TLS")
+				os.Exit(1)
+			}
+			if err := mgr.Add(apiCertWatcher); err != nil {
+				setupLog.Error(err, "unable to add API certificate watcher")
+				os.Exit(1)
+			}
+		}
+		if apiAuth.ClientCAFile != "" && apiTLS.CertDir == "" {
+			setupLog.Error(nil, "--api-client-ca-file requires --api-tls-cert-dir, client certificates are only presented over TLS")
+			os.Exit(1)
+		}
+		// requests are authenticated and authorized once any method is configured
+		if apiAuth.Enabled() {
+			if err := api.EnableAuth(mgr.GetClient(), apiAuth); err != nil {
+				setupLog.Error(err, "unable to enable API authentication")
+				os.Exit(1)
+			}
+		}
 	}
 	if err := (&controller.TargetSourceReconciler{
 		Client:            mgr.GetClient(),
 		Scheme:            mgr.GetScheme(),
 		BufferSize:        discoveryBufferSize,
 		ChunkSize:         discoveryChunkSize,
+		Journal:           discoveryJournal,
 		DiscoveryRegistry: discoveryRegistry,
 		APIRouter:         api.Router(),
 	}).SetupWithManager(mgr); err != nil {
@@ -220,7 +303,7 @@ func main() {
 	}
 	// nolint:goconst
 	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
-		if err := webhookv1alpha1.SetupSubscriptionWebhookWithManager(mgr); err != nil {
+		if err := webhookv1alpha1.SetupSubscriptionWebhookWithManager(mgr, modelSets); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Subscription")
 			os.Exit(1)
 		}
@@ -282,6 +365,49 @@ func main() {
 			os.Exit(1)
 		}
 	}
+	if err := (&controller.GetJobReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GetJob")
+		os.Exit(1)
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err := webhookv1alpha1.SetupGetJobWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "GetJob")
+			os.Exit(1)
+		}
+	}
+	if err := (&controller.SetJobReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SetJob")
+		os.Exit(1)
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err := webhookv1alpha1.SetupSetJobWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "SetJob")
+			os.Exit(1)
+		}
+	}
+	if err := (&controller.ModelSetReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		Models: modelSets,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ModelSet")
+		os.Exit(1)
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err := webhookv1alpha1.SetupModelSetWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ModelSet")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -297,7 +423,7 @@ func main() {
 		err = mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
 			errCh := make(chan error)
 			go func() {
-				err := api.Server.ListenAndServe()
+				err := api.ListenAndServe()
 				if err != nil && !errors.Is(err, http.ErrServerClosed) {
 					errCh <- err
 				}
@@ -76,6 +76,33 @@ Certificate name
 {{- printf "%s-serving-cert" (include "gnmic-operator.fullname" .) }}
 {{- end }}
 
+{{/*
+API serving certificate name
+*/}}
+{{- define "gnmic-operator.apiCertificateName" -}}
+{{- printf "%s-api-serving-cert" (include "gnmic-operator.fullname" .) }}
+{{- end }}
+
+{{/*
+Source of the CA bundle verifying API client certificates: "configMap" for
+api.tls.bundleRef, "secret" for the deprecated api.auth.clientCASecret, empty
+when none is set. api.auth.clientCASecret is still honored, with a TLS served
+API, until the next minor release.
+*/}}
+{{- define "gnmic-operator.apiClientCA" -}}
+{{- if and .Values.api.port .Values.api.auth.clientCASecret }}
+{{- if .Values.api.tls.bundleRef }}
+{{- fail "api.auth.clientCASecret is deprecated in favor of api.tls.bundleRef, set only api.tls.bundleRef" }}
+{{- end }}
+{{- if not .Values.api.tls.enabled }}
+{{- fail "api.auth.clientCASecret is deprecated in favor of api.tls.bundleRef and client certificates require api.tls.enabled: set api.tls.enabled=true and move the CA bundle (key ca.crt) to the ConfigMap referenced by api.tls.bundleRef" }}
+{{- end }}
+{{- "secret" }}
+{{- else if and .Values.api.port .Values.api.tls.enabled .Values.api.tls.bundleRef }}
+{{- "configMap" }}
+{{- end }}
+{{- end }}
+
 {{/*
 Issuer name
 */}}
@@ -8,16 +8,21 @@ package apiserver
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gnmic/operator/internal/controller"
 	"github.com/gnmic/operator/internal/controller/discovery"
 	"github.com/gnmic/operator/internal/controller/discovery/core"
 	"github.com/gnmic/operator/internal/controller/discovery/loaders/utils"
+	"github.com/gnmic/operator/internal/gnmic"
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -29,9 +34,32 @@ type APIServer struct {
 		types.NamespacedName,
 		core.DiscoveryRegistryValue,
 	]
-	chunzSize   int
-	logger      logr.Logger
-	bearerToken bool
+	chunzSize int
+	logger    logr.Logger
+	// token required to reveal secret values (?reveal=true) when
+	// authentication is not enabled. Revealing is disabled when empty.
+	bearerToken string
+	// set by EnableAuth, nil when API requests are not authenticated
+	authenticator Authenticator
+	authorizer    Authorizer
+}
+
+// AuthOptions configure the authentication of API requests. Authenticated
+// requests are authorized with a SubjectAccessReview.
+type AuthOptions struct {
+	// Authenticate bearer tokens with a Kubernetes TokenReview
+	TokenReview bool
+	// Audiences the TokenReview checks the tokens against, the API server's when empty
+	TokenReviewAudiences []string
+	// PEM CA bundle verifying TLS client certificates
+	ClientCAFile string
+	// Static token file, token,user,uid,"group1,group2" per line
+	TokenFile string
+}
+
+// Enabled reports whether any authentication method is configured
+func (o AuthOptions) Enabled() bool {
+	return o.TokenReview || o.ClientCAFile != "" || o.TokenFile != ""
 }
 
 type urlStruct struct {
@@ -64,12 +92,60 @@ func New(
 		DiscoveryRegistry: discoveryRegistry,
 		chunzSize:         discoveryChunksize,
 		logger:            logger,
+		bearerToken:       bearerToken,
 	}
-	RegisterHandlers(router, a)
+	RegisterHandlersWithOptions(router, a, GinServerOptions{
+		Middlewares: []MiddlewareFunc{a.authorize},
+	})
 	logger.Info("API server initialized", "addr", addr, "chunkSize", discoveryChunksize)
 	return a, nil
 }
 
+// EnableAuth requires every API request to be authenticated with one of the
+// configured methods and authorized with a SubjectAccessReview. The API bearer
+// token, if set, authenticates as APITokenUser. Client certificates are only
+// presented over TLS: EnableTLS must be called first to authenticate them.
+func (a *APIServer) EnableAuth(c client.Client, opts AuthOptions) error {
+	if opts.ClientCAFile != "" && a.Server.TLSConfig == nil {
+		return fmt.Errorf("client certificates are only presented over TLS, a client CA requires the API to be served over TLS")
+	}
+	tokens := make(map[string]*UserInfo)
+	if opts.TokenFile != "" {
+		var err error
+		if tokens, err = LoadStaticTokens(opts.TokenFile); err != nil {
+			return err
+		}
+	}
+	if a.bearerToken != "" {
+		tokens[a.bearerToken] = &UserInfo{Username: APITokenUser, Groups: []string{AdminGroup}}
+	}
+	var authenticators UnionAuthenticator
+	if len(tokens) > 0 {
+		authenticators = append(authenticators, NewStaticTokenAuthenticator(tokens))
+	}
+	if opts.ClientCAFile != "" {
+		certAuth, err := NewClientCertAuthenticator(opts.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to load client CA: %w", err)
+		}
+		authenticators = append(authenticators, certAuth)
+	}
+	if opts.TokenReview {
+		authenticators = append(authenticators, NewTokenReviewAuthenticator(c, opts.TokenReviewAudiences))
+	}
+	if len(authenticators) == 0 {
+		return fmt.Errorf("no authentication method configured")
+	}
+	a.authenticator = authenticators
+	a.authorizer = NewSubjectAccessReviewAuthorizer(c)
+	a.logger.Info("API authentication enabled",
+		"tokenReview", opts.TokenReview,
+		"clientCertificates", opts.ClientCAFile != "",
+		"staticTokens", len(tokens),
+	)
+	return nil
+}
+
 func (a *APIServer) Router() *gin.Engine {
 	return a.router
 }
@@ -90,18 +166,178 @@ func (a *APIServer) GetClusterPlan(c *gin.Context) {
 		c.String(404, err.Error())
 		return
 	}
-	c.JSON(200, plan)
+	if c.Query("reveal") == "true" {
+		if status, err := a.authorizeReveal(c, uri); err != nil {
+			logger.Info("Rejected request to reveal cluster plan secrets", "error", err.Error())
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Info("Revealed cluster plan secrets")
+		c.JSON(http.StatusOK, plan)
+		return
+	}
+	c.JSON(http.StatusOK, gnmic.RedactPlan(plan))
+}
+
+// ListClusterPlanRevisions returns the applied plan history of a cluster
+func (a *APIServer) ListClusterPlanRevisions(c *gin.Context) {
+	uri := parseURI(c)
+	logger := log.FromContext(c.Request.Context()).WithValues(
+		"component", "apiserver",
+		"namespace", uri.Namespace,
+		"cluster", uri.Name,
+	)
+	logger.Info("Received GET request for ListClusterPlanRevisions")
+
+	revisions, err := a.clusterReconciler.ListPlanRevisions(c.Request.Context(), uri.Namespace, uri.Name)
+	if err != nil {
+		logger.Error(err, "Failed to list cluster plan revisions")
+		c.String(statusCodeForError(err), err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetClusterPlanRevision returns a single applied plan revision of a cluster
+func (a *APIServer) GetClusterPlanRevision(c *gin.Context) {
+	uri := parseURI(c)
+	logger := log.FromContext(c.Request.Context()).WithValues(
+		"component", "apiserver",
+		"namespace", uri.Namespace,
+		"cluster", uri.Name,
+		"revision", c.Param("revision"),
+	)
+	logger.Info("Received GET request for GetClusterPlanRevision")
+
+	revision, err := parseRevision(c.Param("revision"))
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	rev, err := a.clusterReconciler.GetPlanRevision(c.Request.Context(), uri.Namespace, uri.Name, revision)
+	if err != nil {
+		logger.Error(err, "Failed to get cluster plan revision")
+		c.String(statusCodeForError(err), err.Error())
+		return
+	}
+	// credentials are not persisted in the history, but secret keys set
+	// inline in output and input configs are
+	rev.Plan = gnmic.RedactPlan(rev.Plan)
+	c.JSON(http.StatusOK, rev)
+}
+
+// DiffClusterPlanRevision diffs a plan revision against the revision given by
+// the against query parameter, or against the preceding revision.
+func (a *APIServer) DiffClusterPlanRevision(c *gin.Context) {
+	uri := parseURI(c)
+	logger := log.FromContext(c.Request.Context()).WithValues(
+		"component", "apiserver",
+		"namespace", uri.Namespace,
+		"cluster", uri.Name,
+		"revision", c.Param("revision"),
+	)
+	logger.Info("Received GET request for DiffClusterPlanRevision")
+
+	revision, err := parseRevision(c.Param("revision"))
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	var against int64
+	if q := c.Query("against"); q != "" {
+		if against, err = parseRevision(q); err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	diff, err := a.clusterReconciler.DiffPlanRevisions(c.Request.Context(), uri.Namespace, uri.Name, against, revision)
+	if err != nil {
+		logger.Error(err, "Failed to diff cluster plan revisions")
+		c.String(statusCodeForError(err), err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, diff)
 }
 
-// CreateTargets binds payload to payloadTargets struct defined in openapi contract. Creates a []core.DiscoveryEvent sends it to the core package.
+// RollbackClusterPlan pins a cluster to a previous plan revision
+func (a *APIServer) RollbackClusterPlan(c *gin.Context) {
+	uri := parseURI(c)
+	logger := log.FromContext(c.Request.Context()).WithValues(
+		"component", "apiserver",
+		"namespace", uri.Namespace,
+		"cluster", uri.Name,
+		"revision", c.Param("revision"),
+	)
+	logger.Info("Received POST request for RollbackClusterPlan")
+
+	if status, err := a.authorizePlanPin(c); err != nil {
+		logger.Info("Rejected request to pin cluster plan", "error", err.Error())
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	revision, err := parseRevision(c.Param("revision"))
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := a.clusterReconciler.PinPlanRevision(c.Request.Context(), uri.Namespace, uri.Name, revision); err != nil {
+		logger.Error(err, "Failed to pin cluster plan revision")
+		c.String(statusCodeForError(err), err.Error())
+		return
+	}
+	logger.Info("Cluster pinned to plan revision")
+	c.JSON(http.StatusOK, gin.H{"pinnedRevision": revision})
+}
+
+// ReleaseClusterPlanPin releases a cluster's plan pin
+func (a *APIServer) ReleaseClusterPlanPin(c *gin.Context) {
+	uri := parseURI(c)
+	logger := log.FromContext(c.Request.Context()).WithValues(
+		"component", "apiserver",
+		"namespace", uri.Namespace,
+		"cluster", uri.Name,
+	)
+	logger.Info("Received DELETE request for ReleaseClusterPlanPin")
+
+	if status, err := a.authorizePlanPin(c); err != nil {
+		logger.Info("Rejected request to release cluster plan pin", "error", err.Error())
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if err := a.clusterReconciler.ReleasePlanPin(c.Request.Context(), uri.Namespace, uri.Name); err != nil {
+		logger.Error(err, "Failed to release cluster plan pin")
+		c.String(statusCodeForError(err), err.Error())
+		return
+	}
+	logger.Info("Cluster plan pin released")
+	c.Status(http.StatusOK)
+}
+
+// ApplyTargets applies the pushed targets, as events, to the TargetSource. The
+// payload is a list of Targets as defined in the openapi contract, or any JSON
+// document mapped into targets by the TargetSource's push mapping.
 func (a *APIServer) ApplyTargets(c *gin.Context) {
+	a.push(c, false)
+}
+
+// ApplySnapshot replaces the targets of the TargetSource with the pushed
+// ones: targets missing from the snapshot are deleted.
+func (a *APIServer) ApplySnapshot(c *gin.Context) {
+	a.push(c, true)
+}
+
+// push converts a push payload into discovery events or a snapshot, queues
+// them for the TargetSource's MessageProcessor and replies with the operation
+// tracking them.
+func (a *APIServer) push(c *gin.Context, snapshot bool) {
 	uri := parseURI(c)
 	logger := log.FromContext(c.Request.Context()).WithValues(
 		"component", "apiserver",
 		"namespace", uri.Namespace,
 		"targetsource", uri.Name,
+		"snapshot", snapshot,
 	)
-	logger.Info("Received POST request for CreateTargets")
+	logger.Info("Received push request")
 
 	key := getKey(uri)
 	registry, ok := a.DiscoveryRegistry.Get(key)
@@ -120,25 +356,232 @@ func (a *APIServer) ApplyTargets(c *gin.Context) {
 	}
 
 	if authenticated, err := a.verifyAuthentication(c, registry, logger); authenticated == false {
-		logger.Info("Unauthorized request for CreateTargets", "error", err.Error())
+		logger.Info("Unauthorized push request", "error", err.Error())
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err})
 		return
 	}
 
-	var payloadTargets Targets
-	if err := c.ShouldBind(&payloadTargets); err != nil {
-		logger.Error(err, "Failed to bind request payload")
-		c.JSON(http.StatusBadRequest, gin.H{"error": err})
+	// the targets of a provider are merged with the other providers, a
+	// pushed snapshot cannot replace them
+	if snapshot && registry.CommonLoaderConfig.MergePolicy != "" {
+		err := fmt.Errorf("targetSource %s/%s merges several providers and does not accept snapshots", uri.Namespace, uri.Name)
+		logger.Error(err, "Snapshot rejected")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	targets, err := createDiscoveryEvent(payloadTargets)
+	body, err := c.GetRawData()
+	if err != nil {
+		logger.Error(err, "Failed to read request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	events, err := payloadEvents(registry.CommonLoaderConfig.PushMapper, body, logger)
 	if err != nil {
 		logger.Error(err, "failed creating discoveryEvent")
-		c.JSON(http.StatusBadRequest, gin.H{"error": err})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if snapshot {
+		// a snapshot holds the targets to keep, deletions are implied
+		events = slices.DeleteFunc(events, func(e core.DiscoveryEvent) bool {
+			return e.Event == core.EventDelete
+		})
+	}
+	if len(events) == 0 {
+		err := fmt.Errorf("payload contains no targets")
+		if snapshot {
+			err = fmt.Errorf("payload contains no targets, an empty snapshot would delete all targets of the TargetSource")
+		}
+		logger.Info("Push request rejected", "error", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	if registry.Operations == nil {
+		err := fmt.Errorf("targetSource %s/%s does not track push operations", uri.Namespace, uri.Name)
+		logger.Error(err, "POST request rejected")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	idempotencyKey := c.GetHeader(idempotencyKeyHeader)
+	op, created, err := registry.Operations.Create(idempotencyKey, payloadFingerprint(snapshot, body), events)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, core.ErrIdempotencyKeyReused) {
+			status = http.StatusConflict
+		}
+		logger.Info("POST request rejected", "idempotencyKey", idempotencyKey, "error", err.Error())
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	location := operationPath(uri, op.ID)
+	if !created {
+		// the same request was already accepted, its events are not sent again
+		logger.Info("Replayed push operation", "operation", op.ID, "idempotencyKey", idempotencyKey)
+		c.Header("Location", location)
+		c.Header(idempotentReplayedHeader, "true")
+		c.JSON(http.StatusAccepted, op)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if snapshot {
+		err = a.sendSnapshot(ctx, registry, op.ID, events, logger)
+	} else {
+		err = a.sendEvents(ctx, registry, op.ID, events, logger)
+	}
+	switch {
+	case errors.Is(err, utils.ErrTooLarge), errors.Is(err, core.ErrJournalFull):
+		// retrying the same push fails again, it must be split
+		registry.Operations.Discard(op.ID)
+		logger.Info("Push exceeds the discovery queue capacity, push rejected", "targets", len(events), "error", err.Error())
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, errNothingQueued):
+		registry.Operations.Discard(op.ID)
+		logger.Info("Discovery queue is full, push rejected", "targets", len(events))
+		c.Header("Retry-After", strconv.Itoa(pushRetryAfterSeconds))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": utils.ErrQueueFull.Error()})
+		return
+	case err != nil:
+		registry.Operations.Discard(op.ID)
+		logger.Error(err, "Failed to journal push request")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	logger.Info("Accepted push operation", "operation", op.ID, "targets", len(events))
+	op, _ = registry.Operations.Get(op.ID)
+	c.Header("Location", location)
+	c.JSON(http.StatusAccepted, op)
+}
+
+// errNothingQueued is returned when the discovery queue could not take any
+// message of a push, which can then be retried as a whole.
+var errNothingQueued = errors.New("nothing queued")
 
-	utils.SendEvents(context.Background(), registry.Channel, targets, a.chunzSize)
-	c.JSON(http.StatusOK, payloadTargets)
+// sendEvents journals and queues the events of an operation without blocking.
+func (a *APIServer) sendEvents(ctx context.Context, registry core.DiscoveryRegistryValue, opID string, events []core.DiscoveryEvent, logger logr.Logger) error {
+	if registry.Journal != nil {
+		messages := make([]core.DiscoveryMessage, len(events))
+		for i, event := range events {
+			messages[i] = event
+		}
+		seqs, err := registry.Journal.Append(ctx, messages...)
+		if err != nil {
+			return err
+		}
+		for i := range events {
+			events[i].JournalSeq = seqs[i]
+		}
+	}
+	sent, err := utils.TrySendEvents(registry.Channel, events, a.chunzSize)
+	if err != nil {
+		seqs := make([]uint64, 0, len(events)-sent)
+		for _, event := range events[sent:] {
+			seqs = append(seqs, event.JournalSeq)
+		}
+		a.unjournal(ctx, registry, seqs, logger)
+	}
+	if errors.Is(err, utils.ErrTooLarge) {
+		return err
+	}
+	if err != nil && sent == 0 {
+		return errNothingQueued
+	}
+	// another producer filled the queue between the capacity check and the
+	// send: the events that could not be queued fail the operation
+	for _, event := range events[sent:] {
+		registry.Operations.Report(opID, event, err)
+	}
+	return nil
+}
+
+// sendSnapshot journals and queues the targets of a snapshot operation
+// without blocking.
+func (a *APIServer) sendSnapshot(ctx context.Context, registry core.DiscoveryRegistryValue, opID string, events []core.DiscoveryEvent, logger logr.Logger) error {
+	targets := make([]core.DiscoveredTarget, len(events))
+	for i, e := range events {
+		targets[i] = e.Target
+	}
+	chunks := utils.SnapshotChunks(targets, opID, opID, a.chunzSize)
+	if registry.Journal != nil {
+		messages := make([]core.DiscoveryMessage, len(chunks))
+		for i, chunk := range chunks {
+			messages[i] = chunk
+		}
+		seqs, err := registry.Journal.Append(ctx, messages...)
+		if err != nil {
+			return err
+		}
+		for i := range chunks {
+			chunks[i].JournalSeq = seqs[i]
+		}
+	}
+	sent, err := utils.TrySendSnapshot(registry.Channel, chunks)
+	if err != nil {
+		// the chunks that were sent are discarded by the next snapshot, the
+		// others are never handled
+		seqs := make([]uint64, 0, len(chunks)-sent)
+		for _, chunk := range chunks[sent:] {
+			seqs = append(seqs, chunk.JournalSeq)
+		}
+		a.unjournal(ctx, registry, seqs, logger)
+	}
+	if errors.Is(err, utils.ErrTooLarge) {
+		return err
+	}
+	if err != nil && sent == 0 {
+		return errNothingQueued
+	}
+	if err != nil {
+		// another producer filled the queue between the capacity check and
+		// the send: the chunks that were sent are discarded, otherwise the
+		// incomplete snapshot defers all events until the next snapshot
+		registry.Operations.Resolve(opID, core.OperationFailed, err)
+		abortCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), snapshotAbortTimeout)
+		defer cancel()
+		if err := utils.AbortSnapshot(abortCtx, registry.Channel, chunks[0].SnapshotID, opID); err != nil {
+			logger.Error(err, "Failed to abort partially queued snapshot", "operation", opID)
+		}
+	}
+	return nil
+}
+
+// unjournal removes messages that could not be queued from the journal, so
+// that they are not replayed after a restart.
+func (a *APIServer) unjournal(ctx context.Context, registry core.DiscoveryRegistryValue, seqs []uint64, logger logr.Logger) {
+	if registry.Journal == nil || len(seqs) == 0 {
+		return
+	}
+	if err := registry.Journal.Ack(ctx, seqs...); err != nil {
+		logger.Error(err, "Failed to remove rejected messages from the discovery journal")
+	}
+}
+
+// GetTargetSourceOperation returns the state of a push operation and the
+// result of each of its targets.
+func (a *APIServer) GetTargetSourceOperation(c *gin.Context) {
+	uri := parseURI(c)
+	logger := log.FromContext(c.Request.Context()).WithValues(
+		"component", "apiserver",
+		"namespace", uri.Namespace,
+		"targetsource", uri.Name,
+	)
+
+	registry, ok := a.DiscoveryRegistry.Get(getKey(uri))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("targetSource %s/%s does not exist", uri.Namespace, uri.Name)})
+		return
+	}
+	if authenticated, err := a.verifyAuthentication(c, registry, logger); !authenticated {
+		logger.Info("Unauthorized request for GetTargetSourceOperation", "error", err.Error())
+		return
+	}
+	op, ok := registry.Operations.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("operation %q not found", c.Param("id"))})
+		return
+	}
+	c.JSON(http.StatusOK, op)
 }
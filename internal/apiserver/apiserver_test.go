@@ -1,17 +1,26 @@
 package apiserver
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
 	"github.com/gnmic/operator/internal/controller"
 	"github.com/gnmic/operator/internal/controller/discovery"
 	"github.com/gnmic/operator/internal/controller/discovery/core"
+	httploader "github.com/gnmic/operator/internal/controller/discovery/loaders/http"
 	"github.com/gnmic/operator/internal/gnmic"
 	gapi "github.com/openconfig/gnmic/pkg/api/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestGetClusterPlan(t *testing.T) {
@@ -60,3 +69,532 @@ func TestGetClusterPlan(t *testing.T) {
 		}
 	})
 }
+
+func TestGetClusterPlan_Redaction(t *testing.T) {
+	password := "s3cr3t"
+	plan := &gnmic.ApplyPlan{
+		Targets: map[string]*gapi.TargetConfig{
+			"default/t1": {Name: "default/t1", Password: &password},
+		},
+	}
+	reconciler := controller.NewClusterReconcilerForTest()
+	reconciler.CachePlan("default", "cluster-a", plan)
+	registry := discovery.NewRegistry[types.NamespacedName, core.DiscoveryRegistryValue]()
+
+	get := func(t *testing.T, apiToken, query, bearer string) (int, *gnmic.ApplyPlan) {
+		t.Helper()
+		srv, err := New(":0", reconciler, registry, 0, apiToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ts := httptest.NewServer(srv.Server.Handler)
+		defer ts.Close()
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/clusters/default/cluster-a/plan"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return resp.StatusCode, nil
+		}
+		var got gnmic.ApplyPlan
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, &got
+	}
+
+	t.Run("redacted by default", func(t *testing.T) {
+		_, got := get(t, "api-token", "", "")
+		if p := got.Targets["default/t1"].Password; p == nil || *p != gnmic.RedactedValue {
+			t.Fatalf("password = %v, want redacted", p)
+		}
+	})
+	t.Run("revealed with the API token", func(t *testing.T) {
+		_, got := get(t, "api-token", "?reveal=true", "api-token")
+		if p := got.Targets["default/t1"].Password; p == nil || *p != password {
+			t.Fatalf("password = %v, want revealed", p)
+		}
+	})
+	t.Run("wrong token", func(t *testing.T) {
+		if status, _ := get(t, "api-token", "?reveal=true", "other"); status != http.StatusUnauthorized {
+			t.Fatalf("status = %d, want 401", status)
+		}
+	})
+	t.Run("no API token configured", func(t *testing.T) {
+		if status, _ := get(t, "", "?reveal=true", "api-token"); status != http.StatusForbidden {
+			t.Fatalf("status = %d, want 403", status)
+		}
+	})
+}
+
+func TestClusterPlanHistory(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := gnmicv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	cluster := &gnmicv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-a", Namespace: "default"},
+		Spec:       gnmicv1alpha1.ClusterSpec{Image: "gnmic"},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build()
+	reconciler := controller.NewClusterReconcilerForTestWithClient(cl, scheme)
+
+	registry := discovery.NewRegistry[types.NamespacedName, core.DiscoveryRegistryValue]()
+	srv, err := New(":0", reconciler, registry, 0, "api-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv.Server.Handler)
+	defer ts.Close()
+
+	doWithToken := func(method, path, bearer string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, ts.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	do := func(method, path string) *http.Response {
+		t.Helper()
+		return doWithToken(method, path, "api-token")
+	}
+
+	t.Run("empty history", func(t *testing.T) {
+		resp := do(http.MethodGet, "/clusters/default/cluster-a/plans")
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, want 200", resp.StatusCode)
+		}
+		var revisions []controller.PlanRevision
+		if err := json.NewDecoder(resp.Body).Decode(&revisions); err != nil {
+			t.Fatal(err)
+		}
+		if len(revisions) != 0 {
+			t.Fatalf("revisions = %d, want 0", len(revisions))
+		}
+	})
+
+	t.Run("unknown cluster", func(t *testing.T) {
+		resp := do(http.MethodGet, "/clusters/default/missing/plans")
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("status = %d, want 404", resp.StatusCode)
+		}
+	})
+
+	t.Run("invalid revision", func(t *testing.T) {
+		resp := do(http.MethodGet, "/clusters/default/cluster-a/plans/latest")
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("status = %d, want 400", resp.StatusCode)
+		}
+	})
+
+	t.Run("rollback to missing revision", func(t *testing.T) {
+		resp := do(http.MethodPost, "/clusters/default/cluster-a/plans/3/rollback")
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("status = %d, want 404", resp.StatusCode)
+		}
+	})
+
+	t.Run("release pin", func(t *testing.T) {
+		resp := do(http.MethodDelete, "/clusters/default/cluster-a/pin")
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, want 200", resp.StatusCode)
+		}
+	})
+
+	t.Run("pin without the API token", func(t *testing.T) {
+		for _, bearer := range []string{"", "other"} {
+			resp := doWithToken(http.MethodPost, "/clusters/default/cluster-a/plans/3/rollback", bearer)
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusUnauthorized {
+				t.Fatalf("rollback with token %q: status = %d, want 401", bearer, resp.StatusCode)
+			}
+			resp = doWithToken(http.MethodDelete, "/clusters/default/cluster-a/pin", bearer)
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusUnauthorized {
+				t.Fatalf("release with token %q: status = %d, want 401", bearer, resp.StatusCode)
+			}
+		}
+	})
+
+	t.Run("pin with no API token configured", func(t *testing.T) {
+		noToken, err := New(":0", reconciler, registry, 0, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ts := httptest.NewServer(noToken.Server.Handler)
+		defer ts.Close()
+		req, err := http.NewRequest(http.MethodDelete, ts.URL+"/clusters/default/cluster-a/pin", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("status = %d, want 403", resp.StatusCode)
+		}
+	})
+}
+
+func TestApplyTargetsOperations(t *testing.T) {
+	registry := discovery.NewRegistry[types.NamespacedName, core.DiscoveryRegistryValue]()
+	ch := make(chan []core.DiscoveryMessage, 1)
+	operations := core.NewOperationStore(0)
+	if err := registry.Register(types.NamespacedName{Namespace: "default", Name: "ts1"}, core.DiscoveryRegistryValue{
+		Channel: ch,
+		CommonLoaderConfig: &core.CommonLoaderConfig{
+			PushConfig: &gnmicv1alpha1.PushSpec{Enabled: true},
+		},
+		Operations: operations,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	srv, err := New(":0", controller.NewClusterReconcilerForTest(), registry, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv.Server.Handler)
+	defer ts.Close()
+
+	push := func(t *testing.T, key, body string) (*http.Response, core.Operation) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/api/v1/default/target-source/ts1/applyTargets", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var op core.Operation
+		if resp.StatusCode == http.StatusAccepted {
+			if err := json.NewDecoder(resp.Body).Decode(&op); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp, op
+	}
+	body := `[{"name":"r1","address":"10.0.0.1","operation":"created"},{"name":"r2","address":"10.0.0.2","operation":"deleted"}]`
+
+	resp, op := push(t, "key-1", body)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202", resp.StatusCode)
+	}
+	if op.ID == "" || op.State != core.OperationPending || len(op.Targets) != 2 {
+		t.Fatalf("operation = %+v", op)
+	}
+	if want := "/api/v1/default/target-source/ts1/operations/" + op.ID; resp.Header.Get("Location") != want {
+		t.Fatalf("Location = %q, want %q", resp.Header.Get("Location"), want)
+	}
+
+	t.Run("retry with the same key is not sent again", func(t *testing.T) {
+		resp, replayed := push(t, "key-1", body)
+		if resp.StatusCode != http.StatusAccepted || replayed.ID != op.ID {
+			t.Fatalf("status = %d, operation = %q, want 202 and %q", resp.StatusCode, replayed.ID, op.ID)
+		}
+		if resp.Header.Get("Idempotent-Replayed") != "true" {
+			t.Fatal("expected the Idempotent-Replayed header")
+		}
+	})
+
+	t.Run("key reused with another payload", func(t *testing.T) {
+		resp, _ := push(t, "key-1", `[{"name":"r3","address":"10.0.0.3","operation":"created"}]`)
+		if resp.StatusCode != http.StatusConflict {
+			t.Fatalf("status = %d, want 409", resp.StatusCode)
+		}
+	})
+
+	t.Run("full queue", func(t *testing.T) {
+		resp, _ := push(t, "key-2", `[{"name":"r3","address":"10.0.0.3","operation":"created"}]`)
+		if resp.StatusCode != http.StatusTooManyRequests {
+			t.Fatalf("status = %d, want 429", resp.StatusCode)
+		}
+		if resp.Header.Get("Retry-After") == "" {
+			t.Fatal("expected a Retry-After header")
+		}
+	})
+
+	t.Run("push larger than the queue", func(t *testing.T) {
+		// 11 targets need 2 chunks of 10 while the queue holds 1
+		targets := make([]string, 11)
+		for i := range targets {
+			targets[i] = fmt.Sprintf(`{"name":"big%d","address":"10.0.1.%d","operation":"created"}`, i, i)
+		}
+		resp, _ := push(t, "key-3", "["+strings.Join(targets, ",")+"]")
+		if resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Fatalf("status = %d, want 413", resp.StatusCode)
+		}
+		if resp.Header.Get("Retry-After") != "" {
+			t.Fatal("unexpected Retry-After header")
+		}
+	})
+
+	// the message processor reports the results of the queued events
+	batch := <-ch
+	if len(batch) != 2 {
+		t.Fatalf("queued messages = %d, want 2", len(batch))
+	}
+	for _, msg := range batch {
+		event := msg.(core.DiscoveryEvent)
+		if event.OperationID != op.ID {
+			t.Fatalf("event operation = %q, want %q", event.OperationID, op.ID)
+		}
+		var err error
+		if event.Target.Name == "r2" {
+			err = fmt.Errorf("boom")
+		}
+		operations.Report(event.OperationID, event, err)
+	}
+
+	t.Run("operation results", func(t *testing.T) {
+		resp, err := http.Get(ts.URL + "/api/v1/default/target-source/ts1/operations/" + op.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, want 200", resp.StatusCode)
+		}
+		var got core.Operation
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got.State != core.OperationFailed || got.Completed == nil {
+			t.Fatalf("operation state = %s, want Failed and completed", got.State)
+		}
+		if got.Targets[0].State != core.OperationSucceeded || got.Targets[1].State != core.OperationFailed || got.Targets[1].Error != "boom" {
+			t.Fatalf("targets = %+v", got.Targets)
+		}
+	})
+
+	t.Run("unknown operation", func(t *testing.T) {
+		resp, err := http.Get(ts.URL + "/api/v1/default/target-source/ts1/operations/missing")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("status = %d, want 404", resp.StatusCode)
+		}
+	})
+}
+
+func TestApplySnapshotWithPushMapping(t *testing.T) {
+	registry := discovery.NewRegistry[types.NamespacedName, core.DiscoveryRegistryValue]()
+	ch := make(chan []core.DiscoveryMessage, 4)
+	mapping := &gnmicv1alpha1.PushMappingSpec{
+		ResponseMappingSpec: gnmicv1alpha1.ResponseMappingSpec{
+			TargetsField: "has(self.results) ? self.results : [self.data]",
+			Address:      "item.primary_ip.address.split('/')[0]",
+		},
+		Operation: "has(self.event) ? self.event : 'created'",
+	}
+	mapper, err := httploader.NewPushMapper(mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register(types.NamespacedName{Namespace: "default", Name: "netbox"}, core.DiscoveryRegistryValue{
+		Channel: ch,
+		CommonLoaderConfig: &core.CommonLoaderConfig{
+			PushConfig: &gnmicv1alpha1.PushSpec{Enabled: true, Mapping: mapping},
+			PushMapper: mapper,
+		},
+		Operations: core.NewOperationStore(0),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	srv, err := New(":0", controller.NewClusterReconcilerForTest(), registry, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv.Server.Handler)
+	defer ts.Close()
+
+	post := func(t *testing.T, endpoint, body string) int {
+		t.Helper()
+		resp, err := http.Post(ts.URL+"/api/v1/default/target-source/netbox/"+endpoint, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	t.Run("webhook payload", func(t *testing.T) {
+		body := `{"event":"deleted","model":"device","data":{"name":"leaf1","primary_ip":{"address":"10.0.0.1/32"}}}`
+		if got := post(t, "applyTargets", body); got != http.StatusAccepted {
+			t.Fatalf("status = %d, want 202", got)
+		}
+		batch := <-ch
+		event, ok := batch[0].(core.DiscoveryEvent)
+		if !ok || event.Event != core.EventDelete || event.Target.Name != "leaf1" || event.Target.Address != "10.0.0.1" {
+			t.Fatalf("unexpected message: %#v", batch[0])
+		}
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		body := `{"results":[
+			{"name":"leaf1","primary_ip":{"address":"10.0.0.1/32"}},
+			{"name":"leaf2","primary_ip":{"address":"10.0.0.2/32"}}]}`
+		if got := post(t, "applySnapshot", body); got != http.StatusAccepted {
+			t.Fatalf("status = %d, want 202", got)
+		}
+		// one chunk per target with a chunk size of 1
+		for i := 0; i < 2; i++ {
+			batch := <-ch
+			chunk, ok := batch[0].(core.DiscoverySnapshot)
+			if !ok || chunk.TotalChunks != 2 || chunk.ChunkIndex != i || chunk.OperationID == "" || chunk.SnapshotID != chunk.OperationID {
+				t.Fatalf("unexpected message: %#v", batch[0])
+			}
+		}
+	})
+
+	t.Run("empty snapshot", func(t *testing.T) {
+		if got := post(t, "applySnapshot", `{"results":[]}`); got != http.StatusUnprocessableEntity {
+			t.Fatalf("status = %d, want 422", got)
+		}
+	})
+
+	t.Run("snapshot of merged providers", func(t *testing.T) {
+		value, _ := registry.Get(types.NamespacedName{Namespace: "default", Name: "netbox"})
+		value.CommonLoaderConfig.MergePolicy = gnmicv1alpha1.MergePolicyUnion
+		defer func() { value.CommonLoaderConfig.MergePolicy = "" }()
+
+		body := `{"results":[{"name":"leaf1","primary_ip":{"address":"10.0.0.1/32"}}]}`
+		if got := post(t, "applySnapshot", body); got != http.StatusBadRequest {
+			t.Fatalf("status = %d, want 400", got)
+		}
+		if len(ch) != 0 {
+			t.Fatalf("expected no queued message, got %d", len(ch))
+		}
+	})
+}
+
+// memJournal is an in-memory core.Journal
+type memJournal struct {
+	next    uint64
+	pending map[uint64]core.DiscoveryMessage
+	full    bool
+}
+
+func (j *memJournal) Append(_ context.Context, messages ...core.DiscoveryMessage) ([]uint64, error) {
+	if j.full {
+		return nil, core.ErrJournalFull
+	}
+	seqs := make([]uint64, len(messages))
+	for i, msg := range messages {
+		j.next++
+		seqs[i] = j.next
+		j.pending[j.next] = msg
+	}
+	return seqs, nil
+}
+
+func (j *memJournal) Ack(_ context.Context, seqs ...uint64) error {
+	for _, seq := range seqs {
+		delete(j.pending, seq)
+	}
+	return nil
+}
+
+func (j *memJournal) Pending(context.Context) ([]core.DiscoveryMessage, error) {
+	return nil, nil
+}
+
+func TestApplyTargetsJournal(t *testing.T) {
+	registry := discovery.NewRegistry[types.NamespacedName, core.DiscoveryRegistryValue]()
+	ch := make(chan []core.DiscoveryMessage, 1)
+	journal := &memJournal{pending: map[uint64]core.DiscoveryMessage{}}
+	if err := registry.Register(types.NamespacedName{Namespace: "default", Name: "ts1"}, core.DiscoveryRegistryValue{
+		Channel: ch,
+		CommonLoaderConfig: &core.CommonLoaderConfig{
+			PushConfig: &gnmicv1alpha1.PushSpec{Enabled: true},
+		},
+		Operations: core.NewOperationStore(0),
+		Journal:    journal,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	srv, err := New(":0", controller.NewClusterReconcilerForTest(), registry, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv.Server.Handler)
+	defer ts.Close()
+
+	push := func(t *testing.T) *http.Response {
+		t.Helper()
+		resp, err := http.Post(ts.URL+"/api/v1/default/target-source/ts1/applyTargets", "application/json",
+			strings.NewReader(`[{"name":"r1","address":"10.0.0.1","operation":"created"}]`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	if resp := push(t); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202", resp.StatusCode)
+	}
+	msgs := <-ch
+	event, ok := msgs[0].(core.DiscoveryEvent)
+	if !ok || event.JournalSeq != 1 {
+		t.Fatalf("queued message = %+v, want a journaled event", msgs[0])
+	}
+	if len(journal.pending) != 1 {
+		t.Fatalf("journal holds %d messages, want 1", len(journal.pending))
+	}
+
+	t.Run("messages that are not queued are removed from the journal", func(t *testing.T) {
+		ch <- nil
+		defer func() { <-ch }()
+		if resp := push(t); resp.StatusCode != http.StatusTooManyRequests {
+			t.Fatalf("status = %d, want 429", resp.StatusCode)
+		}
+		if len(journal.pending) != 1 {
+			t.Fatalf("journal holds %d messages, want 1", len(journal.pending))
+		}
+	})
+
+	t.Run("full journal", func(t *testing.T) {
+		journal.full = true
+		defer func() { journal.full = false }()
+		if resp := push(t); resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Fatalf("status = %d, want 413", resp.StatusCode)
+		}
+		if len(ch) != 0 {
+			t.Fatal("message queued without being journaled")
+		}
+	})
+}
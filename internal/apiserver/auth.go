@@ -6,6 +6,7 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"crypto/sha512"
+	"crypto/subtle"
 	"encoding/hex"
 	"fmt"
 	"hash"
@@ -116,6 +117,57 @@ func (a *APIServer) verifyBearerToken(ctx *gin.Context, registry core.DiscoveryR
 	return true, nil
 }
 
+// authorizeReveal checks that a request may read the secret values of a
+// cluster plan: with authentication enabled the user needs the reveal verb on
+// clusters/plan, otherwise the operator API token. It returns the HTTP status
+// to reply with on failure.
+func (a *APIServer) authorizeReveal(ctx *gin.Context, uri urlStruct) (int, error) {
+	if a.authenticator == nil {
+		return a.verifyAPIToken(ctx, "revealing secrets")
+	}
+	user, ok := requestUser(ctx)
+	if !ok {
+		return http.StatusUnauthorized, errNoCredentials
+	}
+	return a.authorizeUser(ctx, user, ResourceAttributes{
+		Verb:        verbReveal,
+		Resource:    "clusters",
+		Subresource: "plan",
+		Namespace:   uri.Namespace,
+		Name:        uri.Name,
+	})
+}
+
+// authorizePlanPin checks that a request may pin a cluster to a plan revision
+// or release its pin. With authentication enabled the request was already
+// authorized by the API middleware, otherwise it needs the operator API token.
+// It returns the HTTP status to reply with on failure.
+func (a *APIServer) authorizePlanPin(ctx *gin.Context) (int, error) {
+	if a.authenticator != nil {
+		return http.StatusOK, nil
+	}
+	return a.verifyAPIToken(ctx, "pinning cluster plans")
+}
+
+// verifyAPIToken checks the bearer token of a request for a privileged action
+// against the operator API token. The action is refused when no API token is
+// configured. It returns the HTTP status to reply with on failure.
+func (a *APIServer) verifyAPIToken(ctx *gin.Context, action string) (int, error) {
+	const bearerPrefix = "Bearer "
+	if a.bearerToken == "" {
+		return http.StatusForbidden, fmt.Errorf("%s is disabled: no API bearer token is configured", action)
+	}
+	authHeader := strings.TrimSpace(ctx.GetHeader("Authorization"))
+	if !strings.HasPrefix(authHeader, bearerPrefix) {
+		return http.StatusUnauthorized, fmt.Errorf("missing or invalid authorization header")
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(authHeader, bearerPrefix))
+	if subtle.ConstantTimeCompare([]byte(token), []byte(a.bearerToken)) != 1 {
+		return http.StatusUnauthorized, fmt.Errorf("bearer token does not match the API bearer token")
+	}
+	return http.StatusOK, nil
+}
+
 // getSecret returns Kubernetes Opaque secret as string
 func getSecret(clc *core.CommonLoaderConfig, key string, name string) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
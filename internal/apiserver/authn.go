@@ -0,0 +1,311 @@
+package apiserver
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AdminGroup members are authorized for every API operation without a
+// SubjectAccessReview, as in Kubernetes.
+const AdminGroup = "system:masters"
+
+// APITokenUser is the user of the operator API bearer token (API_BEARER_TOKEN)
+// when authentication is enabled. It is a member of AdminGroup.
+const APITokenUser = "gnmic-operator:api-token"
+
+const (
+	// reviewCacheTTL bounds how long TokenReview and SubjectAccessReview
+	// results are reused, so that revoking a token or a role binding takes
+	// effect quickly.
+	reviewCacheTTL = 30 * time.Second
+	// reviewCacheSize bounds the results of each kind of review held at once.
+	// Only successful reviews are cached, so that invalid tokens cannot fill
+	// the cache.
+	reviewCacheSize = 4096
+)
+
+var errNoCredentials = errors.New("no credentials provided")
+
+// UserInfo identifies the authenticated user of an API request
+type UserInfo struct {
+	Username string
+	UID      string
+	Groups   []string
+	Extra    map[string][]string
+}
+
+// Authenticator identifies the user of an API request.
+type Authenticator interface {
+	// Authenticate returns the user of the request. It returns false, without
+	// an error, when the request carries no credentials the authenticator handles.
+	Authenticate(ctx context.Context, req *http.Request) (*UserInfo, bool, error)
+}
+
+// UnionAuthenticator tries its authenticators in order and returns the first
+// user found. An error of an authenticator handling the request is final.
+type UnionAuthenticator []Authenticator
+
+func (u UnionAuthenticator) Authenticate(ctx context.Context, req *http.Request) (*UserInfo, bool, error) {
+	for _, a := range u {
+		user, ok, err := a.Authenticate(ctx, req)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			return user, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// bearerToken returns the bearer token of a request's Authorization header.
+func bearerToken(req *http.Request) (string, bool) {
+	const bearerPrefix = "Bearer "
+	authHeader := strings.TrimSpace(req.Header.Get("Authorization"))
+	if !strings.HasPrefix(authHeader, bearerPrefix) {
+		return "", false
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(authHeader, bearerPrefix))
+	return token, token != ""
+}
+
+// StaticTokenAuthenticator authenticates bearer tokens from a fixed list.
+// Unknown tokens are left to the next authenticator.
+type StaticTokenAuthenticator struct {
+	tokens map[string]*UserInfo
+}
+
+func NewStaticTokenAuthenticator(tokens map[string]*UserInfo) *StaticTokenAuthenticator {
+	return &StaticTokenAuthenticator{tokens: tokens}
+}
+
+func (s *StaticTokenAuthenticator) Authenticate(_ context.Context, req *http.Request) (*UserInfo, bool, error) {
+	token, ok := bearerToken(req)
+	if !ok {
+		return nil, false, nil
+	}
+	for t, user := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			return user, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// LoadStaticTokens reads a token file in the Kubernetes static token file
+// format: one token,user,uid,"group1,group2" CSV record per line. The uid and
+// groups are optional.
+func LoadStaticTokens(path string) (map[string]*UserInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+	tokens := make(map[string]*UserInfo)
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return tokens, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token file %s: %w", path, err)
+		}
+		if len(record) < 2 || record[0] == "" || record[1] == "" {
+			line, _ := r.FieldPos(0)
+			return nil, fmt.Errorf("token file %s line %d: a token and a user are required", path, line)
+		}
+		user := &UserInfo{Username: record[1]}
+		if len(record) > 2 {
+			user.UID = record[2]
+		}
+		if len(record) > 3 && record[3] != "" {
+			for _, g := range strings.Split(record[3], ",") {
+				if g = strings.TrimSpace(g); g != "" {
+					user.Groups = append(user.Groups, g)
+				}
+			}
+		}
+		tokens[record[0]] = user
+	}
+}
+
+// TokenReviewAuthenticator authenticates bearer tokens, e.g. ServiceAccount
+// tokens, with a Kubernetes TokenReview.
+type TokenReviewAuthenticator struct {
+	client    client.Client
+	audiences []string
+	cache     *reviewCache[*UserInfo]
+}
+
+func NewTokenReviewAuthenticator(c client.Client, audiences []string) *TokenReviewAuthenticator {
+	return &TokenReviewAuthenticator{
+		client:    c,
+		audiences: audiences,
+		cache:     newReviewCache[*UserInfo](reviewCacheTTL, reviewCacheSize),
+	}
+}
+
+func (t *TokenReviewAuthenticator) Authenticate(ctx context.Context, req *http.Request) (*UserInfo, bool, error) {
+	token, ok := bearerToken(req)
+	if !ok {
+		return nil, false, nil
+	}
+	sum := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(sum[:])
+	if user, ok := t.cache.get(key); ok {
+		return user, true, nil
+	}
+
+	review := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token:     token,
+			Audiences: t.audiences,
+		},
+	}
+	if err := t.client.Create(ctx, review); err != nil {
+		return nil, false, fmt.Errorf("token review failed: %w", err)
+	}
+	if !review.Status.Authenticated {
+		if review.Status.Error != "" {
+			return nil, false, fmt.Errorf("invalid bearer token: %s", review.Status.Error)
+		}
+		return nil, false, fmt.Errorf("invalid bearer token")
+	}
+	user := &UserInfo{
+		Username: review.Status.User.Username,
+		UID:      review.Status.User.UID,
+		Groups:   review.Status.User.Groups,
+	}
+	if len(review.Status.User.Extra) > 0 {
+		user.Extra = make(map[string][]string, len(review.Status.User.Extra))
+		for k, v := range review.Status.User.Extra {
+			user.Extra[k] = v
+		}
+	}
+	t.cache.set(key, user)
+	return user, true, nil
+}
+
+// ClientCertAuthenticator authenticates TLS client certificates signed by a
+// CA. The user is the certificate's common name and the groups its
+// organizations, as in Kubernetes. Client certificates are only presented
+// when the API is served over TLS.
+type ClientCertAuthenticator struct {
+	roots *caBundle
+}
+
+// NewClientCertAuthenticator loads the PEM encoded CA bundle client
+// certificates are verified against. The bundle is reloaded when it changes.
+func NewClientCertAuthenticator(caFile string) (*ClientCertAuthenticator, error) {
+	roots, err := newCABundle(caFile)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientCertAuthenticator{roots: roots}, nil
+}
+
+func (c *ClientCertAuthenticator) Authenticate(_ context.Context, req *http.Request) (*UserInfo, bool, error) {
+	if req.TLS == nil || len(req.TLS.PeerCertificates) == 0 {
+		return nil, false, nil
+	}
+	cert := req.TLS.PeerCertificates[0]
+	intermediates := x509.NewCertPool()
+	for _, ic := range req.TLS.PeerCertificates[1:] {
+		intermediates.AddCert(ic)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         c.roots.Pool(),
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return nil, false, fmt.Errorf("invalid client certificate: %w", err)
+	}
+	if cert.Subject.CommonName == "" {
+		return nil, false, fmt.Errorf("client certificate has no common name")
+	}
+	return &UserInfo{
+		Username: cert.Subject.CommonName,
+		Groups:   cert.Subject.Organization,
+	}, true, nil
+}
+
+// reviewCache holds review results for a limited time. It holds at most
+// size results, evicting the least recently used.
+type reviewCache[V any] struct {
+	ttl  time.Duration
+	size int
+	// now defaults to time.Now
+	now func() time.Time
+
+	m       sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type reviewCacheEntry[V any] struct {
+	key     string
+	value   V
+	expires time.Time
+}
+
+func newReviewCache[V any](ttl time.Duration, size int) *reviewCache[V] {
+	return &reviewCache[V]{ttl: ttl, size: size, now: time.Now, lru: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (c *reviewCache[V]) get(key string) (V, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	e := elem.Value.(*reviewCacheEntry[V])
+	if c.now().After(e.expires) {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		var zero V
+		return zero, false
+	}
+	c.lru.MoveToFront(elem)
+	return e.value, true
+}
+
+func (c *reviewCache[V]) set(key string, value V) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	expires := c.now().Add(c.ttl)
+	if elem, ok := c.entries[key]; ok {
+		e := elem.Value.(*reviewCacheEntry[V])
+		e.value, e.expires = value, expires
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&reviewCacheEntry[V]{key: key, value: value, expires: expires})
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*reviewCacheEntry[V]).key)
+	}
+}
@@ -0,0 +1,190 @@
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// userContextKey holds the authenticated *UserInfo in the gin context
+const userContextKey = "apiserver.user"
+
+// verbReveal is the verb authorizing GET plan?reveal=true
+const verbReveal = "reveal"
+
+// ResourceAttributes describe the operator resource an API request acts on,
+// in the terms of a Kubernetes SubjectAccessReview.
+type ResourceAttributes struct {
+	Verb        string
+	Resource    string
+	Subresource string
+	Namespace   string
+	Name        string
+}
+
+// routeAttributes maps the API routes to the resource they act on. The
+// namespace and name are taken from the request path.
+var routeAttributes = map[string]ResourceAttributes{
+	"POST /api/v1/:namespace/target-source/:name/applyTargets":  {Verb: "create", Resource: "targetsources", Subresource: "targets"},
+	"POST /api/v1/:namespace/target-source/:name/applySnapshot": {Verb: "create", Resource: "targetsources", Subresource: "targets"},
+	"GET /api/v1/:namespace/target-source/:name/operations/:id": {Verb: "get", Resource: "targetsources", Subresource: "targets"},
+	"GET /clusters/:namespace/:name/plan":                       {Verb: "get", Resource: "clusters", Subresource: "plan"},
+	"GET /clusters/:namespace/:name/plans":                      {Verb: "get", Resource: "clusters", Subresource: "plan"},
+	"GET /clusters/:namespace/:name/plans/:revision":            {Verb: "get", Resource: "clusters", Subresource: "plan"},
+	"GET /clusters/:namespace/:name/plans/:revision/diff":       {Verb: "get", Resource: "clusters", Subresource: "plan"},
+	"POST /clusters/:namespace/:name/plans/:revision/rollback":  {Verb: "update", Resource: "clusters", Subresource: "plan"},
+	"DELETE /clusters/:namespace/:name/pin":                     {Verb: "update", Resource: "clusters", Subresource: "plan"},
+}
+
+// Authorizer decides whether a user may perform an API request.
+type Authorizer interface {
+	// Authorize returns whether the user is allowed and, when denied, why.
+	Authorize(ctx context.Context, user *UserInfo, attrs ResourceAttributes) (bool, string, error)
+}
+
+// SubjectAccessReviewAuthorizer authorizes API requests with a Kubernetes
+// SubjectAccessReview on the operator resource they act on, so that RBAC
+// bindings in a namespace grant access to the Clusters and TargetSources of
+// that namespace only.
+type SubjectAccessReviewAuthorizer struct {
+	client client.Client
+	// cache holds the requests allowed, denied ones are reviewed again
+	cache *reviewCache[struct{}]
+}
+
+func NewSubjectAccessReviewAuthorizer(c client.Client) *SubjectAccessReviewAuthorizer {
+	return &SubjectAccessReviewAuthorizer{
+		client: c,
+		cache:  newReviewCache[struct{}](reviewCacheTTL, reviewCacheSize),
+	}
+}
+
+func (s *SubjectAccessReviewAuthorizer) Authorize(ctx context.Context, user *UserInfo, attrs ResourceAttributes) (bool, string, error) {
+	if slices.Contains(user.Groups, AdminGroup) {
+		return true, "", nil
+	}
+	key := strings.Join([]string{
+		user.Username, user.UID, strings.Join(user.Groups, ","),
+		attrs.Verb, attrs.Resource, attrs.Subresource, attrs.Namespace, attrs.Name,
+	}, "\x00")
+	if _, ok := s.cache.get(key); ok {
+		return true, "", nil
+	}
+
+	sar := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   attrs.Namespace,
+				Verb:        attrs.Verb,
+				Group:       gnmicv1alpha1.GroupVersion.Group,
+				Resource:    attrs.Resource,
+				Subresource: attrs.Subresource,
+				Name:        attrs.Name,
+			},
+		},
+	}
+	if len(user.Extra) > 0 {
+		sar.Spec.Extra = make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+		for k, v := range user.Extra {
+			sar.Spec.Extra[k] = v
+		}
+	}
+	if err := s.client.Create(ctx, sar); err != nil {
+		return false, "", fmt.Errorf("subject access review failed: %w", err)
+	}
+	if !sar.Status.Allowed {
+		return false, sar.Status.Reason, nil
+	}
+	s.cache.set(key, struct{}{})
+	return true, "", nil
+}
+
+// authorize is the API middleware authenticating and authorizing requests.
+// It lets every request through when authentication is not enabled.
+// Pushes to a TargetSource with its own bearer token or signature are left to
+// that check: webhook senders cannot authenticate against Kubernetes.
+func (a *APIServer) authorize(c *gin.Context) {
+	if a.authenticator == nil {
+		return
+	}
+	logger := log.FromContext(c.Request.Context()).WithValues("component", "apiserver")
+
+	attrs, ok := routeAttributes[c.Request.Method+" "+c.FullPath()]
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+	attrs.Namespace = c.Param("namespace")
+	attrs.Name = c.Param("name")
+	if attrs.Resource == "targetsources" && a.targetSourceAuthenticates(attrs.Namespace, attrs.Name) {
+		return
+	}
+
+	user, ok, err := a.authenticator.Authenticate(c.Request.Context(), c.Request)
+	if err == nil && !ok {
+		err = errNoCredentials
+	}
+	if err != nil {
+		logger.Info("Unauthenticated API request", "path", c.FullPath(), "error", err.Error())
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if status, err := a.authorizeUser(c, user, attrs); err != nil {
+		logger.Info("Unauthorized API request", "user", user.Username, "path", c.FullPath(), "error", err.Error())
+		c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.Set(userContextKey, user)
+}
+
+// authorizeUser checks an authenticated user's access. It returns the HTTP
+// status to reply with on failure.
+func (a *APIServer) authorizeUser(c *gin.Context, user *UserInfo, attrs ResourceAttributes) (int, error) {
+	allowed, reason, err := a.authorizer.Authorize(c.Request.Context(), user, attrs)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if !allowed {
+		err := fmt.Errorf("user %q cannot %s %s/%s in namespace %q", user.Username, attrs.Verb, attrs.Resource, attrs.Subresource, attrs.Namespace)
+		if reason != "" {
+			err = fmt.Errorf("%w: %s", err, reason)
+		}
+		return http.StatusForbidden, err
+	}
+	return http.StatusOK, nil
+}
+
+// targetSourceAuthenticates reports whether a TargetSource authenticates its
+// pushes itself.
+func (a *APIServer) targetSourceAuthenticates(namespace, name string) bool {
+	registry, ok := a.DiscoveryRegistry.Get(getKey(urlStruct{Namespace: namespace, Name: name}))
+	if !ok || registry.CommonLoaderConfig == nil || registry.CommonLoaderConfig.PushConfig == nil {
+		return false
+	}
+	auth := registry.CommonLoaderConfig.PushConfig.Auth
+	return auth != nil && (auth.Bearer != nil || auth.Signature != nil)
+}
+
+// requestUser returns the authenticated user of a request, if any.
+func requestUser(c *gin.Context) (*UserInfo, bool) {
+	v, ok := c.Get(userContextKey)
+	if !ok {
+		return nil, false
+	}
+	user, ok := v.(*UserInfo)
+	return user, ok
+}
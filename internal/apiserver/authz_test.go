@@ -0,0 +1,229 @@
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/controller"
+	"github.com/gnmic/operator/internal/controller/discovery"
+	"github.com/gnmic/operator/internal/controller/discovery/core"
+	"github.com/gnmic/operator/internal/gnmic"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestLoadStaticTokens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.csv")
+	content := "# token,user,uid,groups\n" +
+		"t1,alice,1,\"team-a,viewers\"\n" +
+		"t2,bob\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := LoadStaticTokens(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice := tokens["t1"]; alice == nil || alice.Username != "alice" || alice.UID != "1" || len(alice.Groups) != 2 || alice.Groups[1] != "viewers" {
+		t.Fatalf("t1 = %+v", tokens["t1"])
+	}
+	if bob := tokens["t2"]; bob == nil || bob.Username != "bob" || len(bob.Groups) != 0 {
+		t.Fatalf("t2 = %+v", tokens["t2"])
+	}
+
+	if err := os.WriteFile(path, []byte("t3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadStaticTokens(path); err == nil {
+		t.Fatal("expected an error for a record without user")
+	}
+}
+
+// reviewClient answers TokenReviews for the token "sa-token" as the
+// ServiceAccount team-a/dashboard, and "push-token" as team-a/inventory, and
+// SubjectAccessReviews by allowing the dashboard to get cluster plans and the
+// inventory to push targets, in team-a only.
+func reviewClient(t *testing.T) client.Client {
+	t.Helper()
+	return interceptor.NewClient(
+		fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build(),
+		interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				switch o := obj.(type) {
+				case *authenticationv1.TokenReview:
+					switch o.Spec.Token {
+					case "sa-token":
+						o.Status.Authenticated = true
+						o.Status.User = authenticationv1.UserInfo{Username: "system:serviceaccount:team-a:dashboard"}
+					case "push-token":
+						o.Status.Authenticated = true
+						o.Status.User = authenticationv1.UserInfo{Username: "system:serviceaccount:team-a:inventory"}
+					}
+				case *authorizationv1.SubjectAccessReview:
+					ra := o.Spec.ResourceAttributes
+					switch o.Spec.User {
+					case "system:serviceaccount:team-a:dashboard":
+						o.Status.Allowed = ra.Namespace == "team-a" && ra.Verb == "get" &&
+							ra.Group == "operator.gnmic.dev" && ra.Resource == "clusters" && ra.Subresource == "plan"
+					case "system:serviceaccount:team-a:inventory":
+						o.Status.Allowed = ra.Namespace == "team-a" && ra.Verb == "create" &&
+							ra.Group == "operator.gnmic.dev" && ra.Resource == "targetsources" && ra.Subresource == "targets"
+					}
+				}
+				return nil
+			},
+		},
+	)
+}
+
+func TestAPIAuthentication(t *testing.T) {
+	reconciler := controller.NewClusterReconcilerForTest()
+	reconciler.CachePlan("team-a", "c1", &gnmic.ApplyPlan{})
+	reconciler.CachePlan("team-b", "c1", &gnmic.ApplyPlan{})
+	registry := discovery.NewRegistry[types.NamespacedName, core.DiscoveryRegistryValue]()
+	if err := registry.Register(types.NamespacedName{Namespace: "team-a", Name: "ts1"}, core.DiscoveryRegistryValue{
+		Channel:            make(chan []core.DiscoveryMessage, 10),
+		CommonLoaderConfig: &core.CommonLoaderConfig{PushConfig: &gnmicv1alpha1.PushSpec{Enabled: true}},
+		Operations:         core.NewOperationStore(0),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv, err := New(":0", reconciler, registry, 1, "admin-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.EnableAuth(reviewClient(t), AuthOptions{TokenReview: true}); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv.Server.Handler)
+	defer ts.Close()
+
+	do := func(t *testing.T, method, path, token string) int {
+		t.Helper()
+		var body io.Reader
+		if method == http.MethodPost {
+			body = strings.NewReader(`[{"name":"r1","address":"10.0.0.1","operation":"created"}]`)
+		}
+		req, err := http.NewRequest(method, ts.URL+path, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{"no credentials", http.MethodGet, "/clusters/team-a/c1/plan", "", http.StatusUnauthorized},
+		{"invalid token", http.MethodGet, "/clusters/team-a/c1/plan", "bogus", http.StatusUnauthorized},
+		{"own namespace", http.MethodGet, "/clusters/team-a/c1/plan", "sa-token", http.StatusOK},
+		{"other namespace", http.MethodGet, "/clusters/team-b/c1/plan", "sa-token", http.StatusForbidden},
+		{"reveal needs the reveal verb", http.MethodGet, "/clusters/team-a/c1/plan?reveal=true", "sa-token", http.StatusForbidden},
+		{"rollback needs update", http.MethodDelete, "/clusters/team-a/c1/pin", "sa-token", http.StatusForbidden},
+		{"push needs create", http.MethodPost, "/api/v1/team-a/target-source/ts1/applyTargets", "sa-token", http.StatusForbidden},
+		{"snapshot needs create", http.MethodPost, "/api/v1/team-a/target-source/ts1/applySnapshot", "sa-token", http.StatusForbidden},
+		{"push", http.MethodPost, "/api/v1/team-a/target-source/ts1/applyTargets", "push-token", http.StatusAccepted},
+		{"snapshot", http.MethodPost, "/api/v1/team-a/target-source/ts1/applySnapshot", "push-token", http.StatusAccepted},
+		{"API token is admin", http.MethodGet, "/clusters/team-b/c1/plan?reveal=true", "admin-token", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := do(t, tt.method, tt.path, tt.token); got != tt.want {
+				t.Fatalf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReviewCache(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newReviewCache[int](time.Minute, 2)
+	c.now = func() time.Time { return now }
+	c.set("a", 1)
+	c.set("b", 2)
+	// a is used, b is the least recently used entry and evicted
+	if v, ok := c.get("a"); !ok || v != 1 {
+		t.Fatalf("get(a) = %d, %t", v, ok)
+	}
+	c.set("c", 3)
+	if _, ok := c.get("b"); ok {
+		t.Fatal("b was not evicted")
+	}
+	if c.lru.Len() != 2 || len(c.entries) != 2 {
+		t.Fatalf("cache holds %d entries", len(c.entries))
+	}
+	now = now.Add(time.Minute + time.Second)
+	if _, ok := c.get("a"); ok {
+		t.Fatal("a did not expire")
+	}
+}
+
+// Failed reviews are not cached.
+func TestTokenReviewAuthenticator_InvalidTokens(t *testing.T) {
+	reviews := 0
+	c := interceptor.NewClient(fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build(), interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			reviews++
+			if o, ok := obj.(*authenticationv1.TokenReview); ok && o.Spec.Token == "sa-token" {
+				o.Status.Authenticated = true
+				o.Status.User = authenticationv1.UserInfo{Username: "system:serviceaccount:team-a:dashboard"}
+			}
+			return nil
+		},
+	})
+	a := NewTokenReviewAuthenticator(c, nil)
+	authenticate := func(token string) error {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		_, _, err := a.Authenticate(context.Background(), req)
+		return err
+	}
+	for i := range 3 {
+		if err := authenticate(fmt.Sprintf("bogus-%d", i)); err == nil {
+			t.Fatal("expected an error for an invalid token")
+		}
+		if err := authenticate("sa-token"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if reviews != 4 || a.cache.lru.Len() != 1 {
+		t.Fatalf("%d reviews, %d cached", reviews, a.cache.lru.Len())
+	}
+}
+
+func TestEnableAuth_ClientCARequiresTLS(t *testing.T) {
+	srv, err := New(":0", controller.NewClusterReconcilerForTest(), discovery.NewRegistry[types.NamespacedName, core.DiscoveryRegistryValue](), 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.EnableAuth(reviewClient(t), AuthOptions{ClientCAFile: "ca.crt"}); err == nil || !strings.Contains(err.Error(), "TLS") {
+		t.Fatalf("err = %v, want an error requiring TLS", err)
+	}
+}
@@ -50,6 +50,9 @@ type Target struct {
 	// Address IPv4/IPv6 address or hostname.
 	Address string `json:"address"`
 
+	// CredentialsRef Secret holding the credentials of this router, overriding those of its TargetProfile.
+	CredentialsRef *string `json:"credentialsRef,omitempty"`
+
 	// Labels Labels must be map[string]string. For example vendor:nokia.
 	Labels *[]Label `json:"labels,omitempty"`
 
@@ -77,12 +80,33 @@ type ApplyTargetsJSONRequestBody = Targets
 
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
-	// Interface for real-time target updates, usually using a webhook. Targets are applied in the gNMIc Operator.
+	// Interface for real-time target updates, usually using a webhook. Targets are applied asynchronously in the gNMIc Operator: the request returns an operation whose per-target results are reported by the operations endpoint. Retries with the same `Idempotency-Key` header return the original operation instead of applying the targets again.
 	// (POST /api/v1/:namespace/target-source/:name/applyTargets)
 	ApplyTargets(c *gin.Context)
+	// Replace the targets of a TargetSource with the pushed ones. Targets missing from the snapshot are deleted. Like applyTargets, the request returns an operation and accepts an `Idempotency-Key` header.
+	// (POST /api/v1/:namespace/target-source/:name/applySnapshot)
+	ApplySnapshot(c *gin.Context)
+	// Get the state of a push operation and the result of each of its targets.
+	// (GET /api/v1/:namespace/target-source/:name/operations/:id)
+	GetTargetSourceOperation(c *gin.Context)
+	// Release a cluster's plan pin, returning it to the plan built from its pipelines.
+	// (DELETE /clusters/:namespace/:name/pin)
+	ReleaseClusterPlanPin(c *gin.Context)
 	// Get cluster plan.
 	// (GET /clusters/:namespace/:name/plan)
 	GetClusterPlan(c *gin.Context)
+	// List the applied plan revisions of a cluster, oldest first.
+	// (GET /clusters/:namespace/:name/plans)
+	ListClusterPlanRevisions(c *gin.Context)
+	// Get an applied plan revision of a cluster.
+	// (GET /clusters/:namespace/:name/plans/:revision)
+	GetClusterPlanRevision(c *gin.Context)
+	// Diff a plan revision against another one. The `against` query parameter selects the revision to compare against, it defaults to the preceding revision.
+	// (GET /clusters/:namespace/:name/plans/:revision/diff)
+	DiffClusterPlanRevision(c *gin.Context)
+	// Roll a cluster back to a plan revision. The cluster stays pinned to that revision until the pin is released.
+	// (POST /clusters/:namespace/:name/plans/:revision/rollback)
+	RollbackClusterPlan(c *gin.Context)
 }
 
 // ServerInterfaceWrapper converts contexts to parameters.
@@ -111,6 +135,55 @@ func (siw *ServerInterfaceWrapper) ApplyTargets(c *gin.Context) {
 	siw.Handler.ApplyTargets(c)
 }
 
+// ApplySnapshot operation middleware
+func (siw *ServerInterfaceWrapper) ApplySnapshot(c *gin.Context) {
+
+	c.Set(BearerAuthScopes, []string{})
+
+	c.Set(SignatureScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.ApplySnapshot(c)
+}
+
+// GetTargetSourceOperation operation middleware
+func (siw *ServerInterfaceWrapper) GetTargetSourceOperation(c *gin.Context) {
+
+	c.Set(BearerAuthScopes, []string{})
+
+	c.Set(SignatureScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.GetTargetSourceOperation(c)
+}
+
+// ReleaseClusterPlanPin operation middleware
+func (siw *ServerInterfaceWrapper) ReleaseClusterPlanPin(c *gin.Context) {
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.ReleaseClusterPlanPin(c)
+}
+
 // GetClusterPlan operation middleware
 func (siw *ServerInterfaceWrapper) GetClusterPlan(c *gin.Context) {
 
@@ -124,6 +197,60 @@ func (siw *ServerInterfaceWrapper) GetClusterPlan(c *gin.Context) {
 	siw.Handler.GetClusterPlan(c)
 }
 
+// ListClusterPlanRevisions operation middleware
+func (siw *ServerInterfaceWrapper) ListClusterPlanRevisions(c *gin.Context) {
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.ListClusterPlanRevisions(c)
+}
+
+// GetClusterPlanRevision operation middleware
+func (siw *ServerInterfaceWrapper) GetClusterPlanRevision(c *gin.Context) {
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.GetClusterPlanRevision(c)
+}
+
+// DiffClusterPlanRevision operation middleware
+func (siw *ServerInterfaceWrapper) DiffClusterPlanRevision(c *gin.Context) {
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.DiffClusterPlanRevision(c)
+}
+
+// RollbackClusterPlan operation middleware
+func (siw *ServerInterfaceWrapper) RollbackClusterPlan(c *gin.Context) {
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.RollbackClusterPlan(c)
+}
+
 // GinServerOptions provides options for the Gin server.
 type GinServerOptions struct {
 	BaseURL      string
@@ -152,27 +279,61 @@ func RegisterHandlersWithOptions(router gin.IRouter, si ServerInterface, options
 	}
 
 	router.POST(options.BaseURL+"/api/v1/:namespace/target-source/:name/applyTargets", wrapper.ApplyTargets)
+	router.POST(options.BaseURL+"/api/v1/:namespace/target-source/:name/applySnapshot", wrapper.ApplySnapshot)
+	router.GET(options.BaseURL+"/api/v1/:namespace/target-source/:name/operations/:id", wrapper.GetTargetSourceOperation)
+	router.DELETE(options.BaseURL+"/clusters/:namespace/:name/pin", wrapper.ReleaseClusterPlanPin)
 	router.GET(options.BaseURL+"/clusters/:namespace/:name/plan", wrapper.GetClusterPlan)
+	router.GET(options.BaseURL+"/clusters/:namespace/:name/plans", wrapper.ListClusterPlanRevisions)
+	router.GET(options.BaseURL+"/clusters/:namespace/:name/plans/:revision", wrapper.GetClusterPlanRevision)
+	router.GET(options.BaseURL+"/clusters/:namespace/:name/plans/:revision/diff", wrapper.DiffClusterPlanRevision)
+	router.POST(options.BaseURL+"/clusters/:namespace/:name/plans/:revision/rollback", wrapper.RollbackClusterPlan)
 }
 
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/7xVTW8bNxD9KwO2x7XWboIedHOCNHbbOELtQwFDgEfLkZbRLsnOzCoVAv33guRGH5UC",
-	"5JSTKHI+3rx5M/vFNKGPwZNXMdMvRpqWeszHP3FBXTqgtU5d8NjNOERidZQNdBvJTI0oO78yu+rrRVh8",
-	"okbTxRPyijTZWpKGXUxhzNQ8kH4OvAZLG9cQaIAFQR+808BkJ3DIAz4o9MhrsoACIRYg0A+iycl5+P3x",
-	"4wMsgt1OTGXiCUK0lknkHMD9bPO6vp9tfoXRBAJDG0Q99pTinNXWJTYuRMosyR5Pj/G5OM3LzwR+Cwz0",
-	"L/axI9iQt4GnPqwdpjROqc9Bf2Zamqn5qT60ox57UZdGHPhFZtym/wnsBXKxJwjLb5F7qbjEGRb3/0d7",
-	"57QlhpeGCZXsSwUvQ7T5mDh7sdRR+jM5mAB6e2SFTOAseXUNdvltEbQFjLHbgrYEmlWSgJEfejN9NmMk",
-	"U5kxiqnMmMjML+CPgS+obPXw4b6B9HZUtPNKK+JMZ84747B03QUen46fM1xHNtE5Ig+grRPgMCjxBVp3",
-	"lWH6Z3BMNtWUm1XtJXlM+vybo5O18V0iGUftTCW7ygg1AzvdPibTMhgLQia+HbQ9r/vu6WkGOGhbWpZu",
-	"YRDnV/Ame4GGNXlTlV2RUpVoBwZa1ZiQiFt51IEvkHv34fYt7N+TXJMSEl8kChG3XcDUdZeMW0Kb4xfB",
-	"m7+v7kJYXz3uwx+Kju4PSlXvkusy5DXlNLV3lMPHzHpg+Ovd4xPczu5NZTbEUmBdT64nN+NEeIzOTM2r",
-	"yfXkVdosqG3mrsbo6s1NPU1oJGJDdVHSlYSBGyoPdVbJURdjkKzRfdvvrZma22OrIhgSfRPsNtk2wSv5",
-	"7Jb1V7pRf5IyqaX336cMKaRckvh+eUUUKXsWoXOiFfRDpy4trlKhQAwibtHlHXlQt/JAWe4Sg5cisV+u",
-	"b35cCbKfTxmahkSWQ9flHfm6wDh1us02RcjgBHonWeCBwfkNds6eDI6ZPp+OzPP8RNzP8126GPoeeZu+",
-	"Ll6Jl9gQLAMDE3ZX6vqvJELZaVLBIAN23XacLoTPtGhDWE9gXxQfFo/zeUROVTzJOOumG0SJ5ViTRYWx",
-	"w8zz+Bk+Fd970rfFc5bMzhp4fc7ckT0w6cCeRq721b8nhREQpPQJ4273XwAAAP//UKR4U2kIAAA=",
+	"H4sIAAAAAAAC/+xafWsjR/L+KsX8fnAJjCXvJhyc4P5QEifxZbMRlv+4Y1mi1nRJ03FP96SqRz4R/N2P",
+	"fpk3a7S2c0u4hcCCJU2/VD1P1VPVPftbVtiqtgaN42zxW8ZFiZUIH9+ILWr/QUipnLJG6BXZGskpDAPc",
+	"scZskbEjZfbZQ97+YLe/YOH8Dz/VSMJP9cMlckGqjl+zW0F7dAx1wyVKuFeuBAGszF4jEP7aIDsQRoIr",
+	"/XdutAO7A1HX+qjMHlAUpf/BlVjNsjyrR6Z5pzQ6lKcbr9GBNQUCHpCO4IIdUAoGkfbxy+0sVcJli0wK",
+	"hxdOVZjlpw4XhCJt8rwJSk4CpyRWtXVoiuMPeJwcwk449E/+n3CXLbL/m/fEzRNr8w7vdRjtKYkw+4nK",
+	"YcVPrRBpuQk4DCgVROKYPXyQ43Vr4RjvzbqpkRglyk1Cm+EeCYGw1qJACdujp96ImkvrEqkQLVnbhgqE",
+	"Le4s+T+eeh8DCmXuhx1BWjDWwU4oHebZ1p4ZLE3/DRTD5luhtDfjvkQDwoFGwT4a0G+qHHf27cJAHwho",
+	"mipbvMtWaKSnIs/WTVGgdyfLs7hg+LF1Mns/wXt05hSct+juLd2BxIMqEJyFLUJljXKWUM6gT7jgZCXo",
+	"DiUIBlvHjISqYecnKQP/WP/0FrZWHk/zQUhJyHxqwPXq8OX8enX4K6QhYAlKy86ICmdnYl6icUpovsHd",
+	"6YprLMgnlNUer0DJYEpkVzGQbRxSDvaARCoNtdwxERFbkd0pPW2I9vo04VLQLe6AqUT9Lk56H//M4FtL",
+	"gP8WXiLggEZaWhh7p4Tf5llZErY4TY8886hNsCyq4NcZlqecs+eV80q5Egk2SXw2OWyaWoaPnryNxKB8",
+	"m1k/JAhpP0oQggqMFEKHZ1vryqitgbCYBsPwTytleZZWyfIsbTQZ8LWliXDfv/3xugD/bOC0Mg73SL1a",
+	"JdLP1Yz0uJUBD2ey3A5DawLWhzzzlUWRl+x3kay8y40h6O8nhG4kjYvfHmUYElmaVG48oAkTWiyXq9Wb",
+	"f2V59s3Vm6vbq0n42jj6OGXg4aw3Ly0MkyWBsWhIuePaD41obFEQ0rJx5SmL39/erkA0rowB6H+Fxhd+",
+	"+CrMAmfv0GR57EX8VnG1ns/SuTqAofZGuIYmQuX7H5dfQ/e8rSltX1GLo7bCx7Dyg0sUMqwfYc/+efG9",
+	"tXcX62753ula+QL94N1WZmcDR8pp7II7Ym8Jbq7Wt7BcXWd5dkDiaNbl7HL2KuW3EbXKFtkXs8vZF16w",
+	"hSsDdnNRq/nh1XzhreFaFDiPeXHBoRjGB/MQ8+tUNEM8Wg5/uyi+ltkiW46G+W1IVOiQOFu8e4za11qh",
+	"cbBH45dACXd4TEqxO7Zq3jVnBVlmIHSkkGfwAx45SMsd1g52lkAYKG1DIHYOaVycoevQZudYuO6bogsP",
+	"egoIMZEXD+9jZiO7r6w8xg7QuJR3QShioM1/4Sip/VJPBz1HvsdQLbXuuoWJjiWHWjDHWi1AK3az9Jxj",
+	"r+sndFI9wMUDqHHnwDZBJHvBctRgUDCureGYZ68vX380Z/tmfcLdNoLg1wYb35nclghvbErfSB3UVhnH",
+	"UYdHjdiN7/SOKNvYYSgEUaw1m45nd9GOW4B3dpPWnfmE+fLycqJ7MQehlWzzOYfG3Bl7bx5T0XAJyjik",
+	"nSgQpGKx1SjB0mggVEh7H+WMByShoSZ7UBKJowGvTg1YFgUyR8UCxVApDkpmCVS0LU7924Tt4/AGoQmF",
+	"PELD/XFIqt0OCU2vWH61V1+crnaTktIgSobKUnCzsOGMUyGz2CODK4UJoAcWJztt37dx7h0IiwT9ifOU",
+	"Y/jFNuS7zjBqButaKwf+nwGuhNZIHcWRtdevJzrENpZ82AplGIxtcynvT3jKwb1ttISYJSD6jItLT4D6",
+	"TefzeQ8Vw67ROg/KdRyIk2mqLZKfw1hYI7vEvvEDL5ZhYFKpYd0LQjqseO/ej2rTu/den7ipKkHHwFU4",
+	"9ww6rbCTGJvZqUQ6IVvjRbbVkDbQdmSrMKo7PgnChJicwRt1Fxul422L71DCCV1DhkEMD0rCSBBFgbUL",
+	"DzaPAnWQlQ/5i4rVoOX4QK1qR/1Zqj5WqYqPugPR48KUQ9Vop/xhqI3G2jKrrcYZLFvl8eeo2uNaWLNT",
+	"+4ZQ+qw/ya7PNlxjMWulc+Z7tZkP4VlaYPN5F102HGTCsTVen/D/UMVrM+3TK3hg6VzJ+7OQfcxCtkqp",
+	"MVHHPu0Kdd0FjtdGQqHDjWZyDuIVAOfQcCO0Pqbjm4B73JbW3vVlSlB/Thd8NEVJ1tiG9bEVj/GpafF0",
+	"eboPt0Q10kWyJl7axr0Ia0suXimOUpIBjQzpOgtgKRz04SwqPFvnkhVxOVJ75cOmN0cZdijk6G56WNjF",
+	"XijzolrZ2zxfxPvidHU4LpbfoRsGTa9lJ4J5+ccIZvcwIfYR1ObLc3dAvdD1TIRbYNsY+V+F/nfoYkw4",
+	"4TC2ZUFLx/3R+KVE+y5icIOcGC90ww6Jh5xHlmuVrvY0xgudMbs3qFEwfh3nr7QwK3WO2keipDwBYfZ5",
+	"An48ARy2w8uXMG9Cnd9a5/twex/v3aIQxDveaCnUWpigusb624/Rsp7qvn04S3Fy+vmEPm6vg/MgWpv+",
+	"wsEqqJXJU2ym80Uq3OHptlHaxX7a81irGrXyPfeTTGphPpSlAw6fReBg/NOZRHhAof/uqIknBts4EBAZ",
+	"fQz/WVZHi5RK43PIe3icNcMIaCtAaDbvLUmOaeMXih85viY4CN1gKGO2cXXjclCmbuKbv5qslwlLad9W",
+	"4qUoXNuRbQa2b3xJpSN054augPiXhLknPPWWDK6fu4ED0hasgU1HsvchvSbyODy6rVQMaEI/lQ+qjKVT",
+	"zD7bLFfXP391tby5uvn59qcfrt5uPo9t771ifFZo8dnYeqN4GFw3eFDsy8bzZCKGV5rSBVreRZErUUUy",
+	"+aWZ2oWFtzAg1PYA9XjXIK/J/RyslsgOdorYPQ+a+aJd65kJ2GL0cogGCClT6EZGCYnS8mS/3pn5FJKW",
+	"urFnUfXJJsw0piNIXwri3LfpZ5H8Ru12vxfKpZQeOcLKHlCG7C5KYfat+WhiSzbWuz8KUO8ZiGhJNzg0",
+	"buH/IMRjqjWYJCc9OZUbRo2F49QdpHWcDRcIoROOE4MSSdyJ0La2NYiwwBBU7cwXs0dW660o7s5fsNyk",
+	"Eb+zJPkKalC2Jo85eDZZn2o78oFIenZrYrXu0xM8Fd7uR6EXw6wdxE4ceYS8cL0hjXHp/z3UKjjUtn4+",
+	"eh4e/jMAKeQgid8jAAA=",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
@@ -1,11 +1,18 @@
 package apiserver
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gnmic/operator/internal/controller/discovery/core"
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -27,21 +34,75 @@ func createDiscoveryEvent(payloadTargets []Target) ([]core.DiscoveryEvent, error
 				return nil, err
 			}
 
+			discovered := core.DiscoveredTarget{
+				Name:    target.Name,
+				Address: target.Address,
+				Labels:  convertTargetLabelsToMap(target),
+			}
+			if target.Port != nil {
+				discovered.Port = int32(*target.Port)
+			}
+			if target.TargetProfile != nil {
+				discovered.TargetProfile = *target.TargetProfile
+			}
+			if target.CredentialsRef != nil {
+				discovered.CredentialsRef = *target.CredentialsRef
+			}
 			targets = append(targets, core.DiscoveryEvent{
-				Target: core.DiscoveredTarget{
-					Name:          target.Name,
-					Address:       target.Address,
-					Port:          int32(*target.Port),
-					Labels:        convertTargetLabelsToMap(target),
-					TargetProfile: *target.TargetProfile,
-				},
-				Event: event,
+				Target: discovered,
+				Event:  event,
 			})
 		}
 	}
 	return targets, nil
 }
 
+const (
+	// idempotencyKeyHeader identifies retries of a push request
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotentReplayedHeader marks the response of a retried push request
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	// pushRetryAfterSeconds is the Retry-After of pushes rejected because the
+	// discovery queue of the TargetSource is full
+	pushRetryAfterSeconds = 1
+	// snapshotAbortTimeout bounds the wait for room in the discovery queue
+	// to abort a partially queued snapshot
+	snapshotAbortTimeout = 10 * time.Second
+)
+
+// payloadFingerprint identifies a push payload, so that an idempotency key
+// reused with a different payload, or for another kind of push, is detected.
+func payloadFingerprint(snapshot bool, body []byte) string {
+	h := sha256.New()
+	if snapshot {
+		h.Write([]byte("snapshot\n"))
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// payloadEvents converts a push payload into discovery events, with the
+// TargetSource's compiled push mapping if set, otherwise as a list of Targets.
+func payloadEvents(mapper core.PushMapper, body []byte, logger logr.Logger) ([]core.DiscoveryEvent, error) {
+	if mapper == nil {
+		var payloadTargets Targets
+		if err := json.Unmarshal(body, &payloadTargets); err != nil {
+			return nil, fmt.Errorf("invalid payload: %w", err)
+		}
+		return createDiscoveryEvent(payloadTargets)
+	}
+	var raw any
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	return mapper.Events(raw, logger)
+}
+
+// operationPath returns the API path of a push operation
+func operationPath(u urlStruct, id string) string {
+	return fmt.Sprintf("/api/v1/%s/target-source/%s/operations/%s", u.Namespace, u.Name, id)
+}
+
 // getKey returns key for used to identify correct channel in DiscoveryRegistry
 func getKey(u urlStruct) types.NamespacedName {
 	key := types.NamespacedName{
@@ -94,3 +155,20 @@ func parseURI(c *gin.Context) (url urlStruct) {
 	}
 	return u
 }
+
+// parseRevision parses a plan revision path or query parameter.
+func parseRevision(s string) (int64, error) {
+	revision, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || revision < 1 {
+		return 0, fmt.Errorf("invalid plan revision %q", s)
+	}
+	return revision, nil
+}
+
+// statusCodeForError maps an error returned by the cluster reconciler to an HTTP status code.
+func statusCodeForError(err error) int {
+	if apierrors.IsNotFound(err) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
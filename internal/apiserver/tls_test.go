@@ -0,0 +1,172 @@
+package apiserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gnmic/operator/internal/controller"
+	"github.com/gnmic/operator/internal/controller/discovery"
+	"github.com/gnmic/operator/internal/controller/discovery/core"
+	"github.com/gnmic/operator/internal/gnmic"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key signed by the CA.
+func (ca *testCA) issue(t *testing.T, cn string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"team-a"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestAPIServerTLS(t *testing.T) {
+	serverCA, clientCA := newTestCA(t), newTestCA(t)
+	dir := t.TempDir()
+	certPEM, keyPEM := serverCA.issue(t, "gnmic-operator-api", x509.ExtKeyUsageServerAuth)
+	for name, data := range map[string][]byte{
+		"tls.crt":   certPEM,
+		"tls.key":   keyPEM,
+		"client.ca": clientCA.pem,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reconciler := controller.NewClusterReconcilerForTest()
+	reconciler.CachePlan("default", "cluster-a", &gnmic.ApplyPlan{})
+	registry := discovery.NewRegistry[types.NamespacedName, core.DiscoveryRegistryValue]()
+	srv, err := New("127.0.0.1:0", reconciler, registry, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.EnableTLS(TLSOptions{
+		CertDir:           dir,
+		ClientCAFile:      filepath.Join(dir, "client.ca"),
+		RequireClientCert: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Server.ServeTLS(ln, "", "") //nolint:errcheck
+	defer srv.Server.Close()
+	url := "https://" + ln.Addr().String() + "/clusters/default/cluster-a/plan"
+
+	roots := x509.NewCertPool()
+	roots.AddCert(serverCA.cert)
+	get := func(certs ...tls.Certificate) error {
+		c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: certs,
+		}}}
+		resp, err := c.Get(url)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, want 200", resp.StatusCode)
+		}
+		return nil
+	}
+
+	if err := get(); err == nil {
+		t.Fatal("expected the handshake to fail without a client certificate")
+	}
+	clientCert, clientKey := clientCA.issue(t, "alice", x509.ExtKeyUsageClientAuth)
+	pair, err := tls.X509KeyPair(clientCert, clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := get(pair); err != nil {
+		t.Fatalf("request with a client certificate failed: %v", err)
+	}
+
+	// a rotated client CA is picked up without restarting the server
+	rotatedCA := newTestCA(t)
+	if err := os.WriteFile(filepath.Join(dir, "client.ca"), rotatedCA.pem, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(dir, "client.ca"), future, future); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(pair); err == nil {
+		t.Fatal("expected the old client certificate to be rejected after the CA rotation")
+	}
+	rotatedCert, rotatedKey := rotatedCA.issue(t, "alice", x509.ExtKeyUsageClientAuth)
+	rotatedPair, err := tls.X509KeyPair(rotatedCert, rotatedKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := get(rotatedPair); err != nil {
+		t.Fatalf("request with a certificate of the rotated CA failed: %v", err)
+	}
+}
@@ -32,7 +32,12 @@ const applyRefreshInterval = 5 * time.Minute
 type ApplyCache struct {
 	mu      sync.Mutex
 	entries map[string]applyRecord
-	ttl     time.Duration
+	// last keeps the configuration each pod was last successfully given,
+	// including the body. Unlike entries it survives Invalidate and the
+	// refresh interval: it is what a failed canary is reverted to, and a pod
+	// that restarted still has to be reverted to something.
+	last map[string]appliedConfig
+	ttl  time.Duration
 	// now is swappable for tests; nil means time.Now.
 	now func() time.Time
 }
@@ -42,9 +47,18 @@ type applyRecord struct {
 	at   time.Time
 }
 
+type appliedConfig struct {
+	hash string
+	body []byte
+}
+
 // NewApplyCache returns a cache using the default refresh interval.
 func NewApplyCache() *ApplyCache {
-	return &ApplyCache{entries: make(map[string]applyRecord), ttl: applyRefreshInterval}
+	return &ApplyCache{
+		entries: make(map[string]applyRecord),
+		last:    make(map[string]appliedConfig),
+		ttl:     applyRefreshInterval,
+	}
 }
 
 func (c *ApplyCache) timeNow() time.Time {
@@ -75,13 +89,31 @@ func (c *ApplyCache) Unchanged(key, hash string) bool {
 // Record marks a configuration as successfully applied to a pod. It must only
 // be called after the POST succeeds: recording an attempt would make a failed
 // apply look applied until the plan changes again.
-func (c *ApplyCache) Record(key, hash string) {
+//
+// The body is kept as the pod's previous configuration for a later rollback.
+// A nil body leaves the previously kept one in place.
+func (c *ApplyCache) Record(key, hash string, body []byte) {
 	if c == nil {
 		return
 	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.entries[key] = applyRecord{hash: hash, at: c.timeNow()}
+	if body != nil {
+		c.last[key] = appliedConfig{hash: hash, body: body}
+	}
+}
+
+// Previous returns the configuration last successfully applied to a pod,
+// regardless of the refresh interval or an invalidation since.
+func (c *ApplyCache) Previous(key string) (hash string, body []byte, ok bool) {
+	if c == nil {
+		return "", nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prev, ok := c.last[key]
+	return prev.hash, prev.body, ok
 }
 
 // Invalidate drops one pod's record, forcing the next reconcile to re-apply to
@@ -110,6 +142,11 @@ func (c *ApplyCache) InvalidateCluster(namespace, name string) {
 			delete(c.entries, key)
 		}
 	}
+	for key := range c.last {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.last, key)
+		}
+	}
 }
 
 // fingerprint hashes the exact bytes that go on the wire rather than the plan
@@ -19,7 +19,7 @@ func TestApplyCache_RecordThenUnchanged(t *testing.T) {
 	if c.Unchanged("ns/c1/0", "hashA") {
 		t.Fatal("empty cache reported unchanged; a pod we know nothing about must be applied to")
 	}
-	c.Record("ns/c1/0", "hashA")
+	c.Record("ns/c1/0", "hashA", nil)
 	if !c.Unchanged("ns/c1/0", "hashA") {
 		t.Fatal("recorded hash reported changed")
 	}
@@ -36,7 +36,7 @@ func TestApplyCache_RecordThenUnchanged(t *testing.T) {
 func TestApplyCache_ExpiresAfterRefreshInterval(t *testing.T) {
 	now := time.Unix(1000, 0)
 	c := testCache(t, &now)
-	c.Record("ns/c1/0", "hashA")
+	c.Record("ns/c1/0", "hashA", nil)
 
 	now = now.Add(applyRefreshInterval - time.Second)
 	if !c.Unchanged("ns/c1/0", "hashA") {
@@ -54,8 +54,8 @@ func TestApplyCache_ExpiresAfterRefreshInterval(t *testing.T) {
 func TestApplyCache_InvalidateIsPerPod(t *testing.T) {
 	now := time.Unix(1000, 0)
 	c := testCache(t, &now)
-	c.Record("ns/c1/0", "hashA")
-	c.Record("ns/c1/1", "hashB")
+	c.Record("ns/c1/0", "hashA", nil)
+	c.Record("ns/c1/1", "hashB", nil)
 
 	c.Invalidate("ns/c1/0")
 	if c.Unchanged("ns/c1/0", "hashA") {
@@ -69,10 +69,10 @@ func TestApplyCache_InvalidateIsPerPod(t *testing.T) {
 func TestApplyCache_InvalidateCluster(t *testing.T) {
 	now := time.Unix(1000, 0)
 	c := testCache(t, &now)
-	c.Record("ns/c1/0", "h")
-	c.Record("ns/c1/1", "h")
-	c.Record("ns/c10/0", "h") // prefix-adjacent, must survive
-	c.Record("ns/c2/0", "h")
+	c.Record("ns/c1/0", "h", nil)
+	c.Record("ns/c1/1", "h", nil)
+	c.Record("ns/c10/0", "h", nil) // prefix-adjacent, must survive
+	c.Record("ns/c2/0", "h", nil)
 
 	c.InvalidateCluster("ns", "c1")
 	if c.Unchanged("ns/c1/0", "h") || c.Unchanged("ns/c1/1", "h") {
@@ -86,11 +86,32 @@ func TestApplyCache_InvalidateCluster(t *testing.T) {
 	}
 }
 
+// The previous configuration is what a failed canary reverts to, so it has to
+// outlive both an SSE invalidation and the refresh interval.
+func TestApplyCache_PreviousSurvivesInvalidate(t *testing.T) {
+	now := time.Unix(1000, 0)
+	c := testCache(t, &now)
+	c.Record("ns/c1/0", "hashA", []byte("a"))
+	c.Record("ns/c1/0", "hashA", nil) // a refresh without a body keeps the kept one
+
+	c.Invalidate("ns/c1/0")
+	now = now.Add(2 * applyRefreshInterval)
+	hash, body, ok := c.Previous("ns/c1/0")
+	if !ok || hash != "hashA" || string(body) != "a" {
+		t.Fatalf("Previous = %q, %q, %v; want hashA, a, true", hash, body, ok)
+	}
+
+	c.InvalidateCluster("ns", "c1")
+	if _, _, ok := c.Previous("ns/c1/0"); ok {
+		t.Fatal("previous configuration survived cluster deletion")
+	}
+}
+
 // A reconciler built without a cache must apply unconditionally rather than
 // silently skip, so the short-circuit can never be enabled by accident.
 func TestApplyCache_NilIsAlwaysChanged(t *testing.T) {
 	var c *ApplyCache
-	c.Record("ns/c1/0", "hashA")
+	c.Record("ns/c1/0", "hashA", nil)
 	if c.Unchanged("ns/c1/0", "hashA") {
 		t.Fatal("nil cache reported unchanged")
 	}
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+// clusterAPITLSConfig returns the TLS configuration the controller connects to
+// the REST API and gNMI server of the pods of a cluster with, when its API
+// uses TLS. With certificates from an issuer, the controller authenticates
+// with its client certificate and verifies the pods with the issuer CA.
+func clusterAPITLSConfig(ctx context.Context, c client.Reader, cluster *gnmicv1alpha1.Cluster) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+	if cluster.Spec.API.TLS.IssuerRef != "" {
+		// load controller's client certificate for mTLS
+		cert, err := os.ReadFile(gnmic.GetControllerCertPath())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read controller cert file: %w", err)
+		}
+		key, err := os.ReadFile(gnmic.GetControllerKeyPath())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read controller key file: %w", err)
+		}
+		certificate, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+
+		// fetch the CA from the Issuer's secret to verify gNMIc pod certificates
+		ca, err := getIssuerCA(ctx, c, cluster.Namespace, cluster.Spec.API.TLS.IssuerRef)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get issuer CA: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		tlsConfig.RootCAs.AppendCertsFromPEM(ca)
+	}
+	if cluster.Spec.API.TLS.BundleRef != "" {
+		// load additional CA bundle to verify gNMIc pod server certificates
+		ca, err := os.ReadFile(gnmic.GetControllerCAPath())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read controller ca file: %w", err)
+		}
+		if tlsConfig.RootCAs == nil {
+			tlsConfig.RootCAs = x509.NewCertPool()
+		}
+		tlsConfig.RootCAs.AppendCertsFromPEM(ca)
+	}
+	return tlsConfig, nil
+}
+
+// getIssuerCA fetches the CA certificate from a cert-manager Issuer's backing secret
+func getIssuerCA(ctx context.Context, c client.Reader, namespace, issuerName string) ([]byte, error) {
+	// get the Issuer
+	issuer := &certmanagerv1.Issuer{}
+	if err := c.Get(ctx, types.NamespacedName{Name: issuerName, Namespace: namespace}, issuer); err != nil {
+		return nil, fmt.Errorf("failed to get issuer %s: %w", issuerName, err)
+	}
+
+	// the Issuer should be a CA issuer with a secretName
+	if issuer.Spec.CA == nil || issuer.Spec.CA.SecretName == "" {
+		return nil, fmt.Errorf("issuer %s is not a CA issuer or has no secret configured", issuerName)
+	}
+
+	// get the CA secret
+	caSecret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: issuer.Spec.CA.SecretName, Namespace: namespace}, caSecret); err != nil {
+		return nil, fmt.Errorf("failed to get CA secret %s: %w", issuer.Spec.CA.SecretName, err)
+	}
+
+	// the CA certificate is stored in tls.crt
+	caCert, ok := caSecret.Data["tls.crt"]
+	if !ok {
+		return nil, fmt.Errorf("CA secret %s does not contain tls.crt", issuer.Spec.CA.SecretName)
+	}
+
+	return caCert, nil
+}
@@ -19,9 +19,8 @@ package controller
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"maps"
@@ -39,6 +38,7 @@ import (
 	"gopkg.in/yaml.v2"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -55,10 +55,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/controller/credentials"
 	"github.com/gnmic/operator/internal/gnmic"
 	"github.com/gnmic/operator/internal/utils"
+	"github.com/gnmic/operator/internal/yang"
 	gapi "github.com/openconfig/gnmic/pkg/api/types"
 )
 
@@ -77,6 +80,21 @@ type ClusterReconciler struct {
 	// TargetState controller, which invalidates a pod's entry when its SSE
 	// stream drops. Nil disables the short-circuit.
 	Applied *ApplyCache
+
+	// Credentials caches and refreshes the credentials TargetProfiles read
+	// from Vault or files. Nil only supports credentials from Secrets.
+	Credentials *credentials.Store
+
+	// Models loads the ModelSets the subscription paths are checked against.
+	// Nil disables the check.
+	Models *yang.Loader
+
+	// rollouts holds the canary rollouts in progress, keyed by namespace/name
+	// of the cluster. Protected by m.
+	rollouts map[string]*canaryRollout
+	// rolledOut holds the planConfigFingerprint of the plan last applied to
+	// every pod of a canary cluster, keyed like rollouts. Protected by m.
+	rolledOut map[string]string
 }
 
 const (
@@ -101,6 +119,10 @@ const (
 	ConditionTypeConfigApplied = "ConfigApplied"
 	// ConditionTypeCapacityExhausted indicates some targets could not be assigned
 	ConditionTypeCapacityExhausted = "CapacityExhausted"
+	// ConditionTypePlanPinned indicates the cluster is pinned to a previous plan revision
+	ConditionTypePlanPinned = "PlanPinned"
+	// ConditionTypeRolloutProgressing indicates a canary rollout is baking or was halted
+	ConditionTypeRolloutProgressing = "RolloutProgressing"
 )
 
 // Condition types for Pipeline status
@@ -109,27 +131,57 @@ const (
 	PipelineConditionTypeReady = "Ready"
 	// PipelineConditionTypeResourcesResolved indicates all resources were resolved
 	PipelineConditionTypeResourcesResolved = "ResourcesResolved"
+	// PipelineConditionTypeResolvedRefs indicates every reference of the pipeline, and of the resources it selects, resolved
+	PipelineConditionTypeResolvedRefs = "ResolvedRefs"
+	// PipelineConditionTypeEncodingsSupported indicates the probed targets advertise the encodings of their subscriptions
+	PipelineConditionTypeEncodingsSupported = "EncodingsSupported"
+	// PipelineConditionTypePathsValid indicates the subscription paths are in the ModelSets of their targets
+	PipelineConditionTypePathsValid = "PathsValid"
 )
 
-// fetchCredentials fetches credentials from a secret
-func (r *ClusterReconciler) FetchCredentials(namespace, secretRef string) (*gnmic.Credentials, error) {
-	var secret corev1.Secret
+// FetchCredentials fetches the credentials of a TargetProfile from the backend
+// it selects
+func (r *ClusterReconciler) FetchCredentials(namespace, profileName string, profile *gnmicv1alpha1.TargetProfileSpec) (*gnmic.Credentials, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	if err := r.Get(ctx, types.NamespacedName{Name: secretRef, Namespace: namespace}, &secret); err != nil {
-		return nil, err
+	if src := credentials.SecretSource(profile); src != nil {
+		return credentials.ReadSecret(ctx, r.Client, namespace, src)
 	}
-	creds := &gnmic.Credentials{}
-	if secret.Data["username"] != nil {
-		creds.Username = string(secret.Data["username"])
+	if !credentials.HasCredentials(profile) {
+		return nil, nil
 	}
-	if secret.Data["password"] != nil {
-		creds.Password = string(secret.Data["password"])
+	if r.Credentials == nil {
+		return nil, errors.New("only credentials from Secrets are supported")
 	}
-	if secret.Data["token"] != nil {
-		creds.Token = string(secret.Data["token"])
+	return r.Credentials.Fetch(ctx, namespace, profileName, profile)
+}
+
+// errSecretKeyNotFound is returned by FetchSecretValues when a mapped key is
+// missing from the Secret.
+var errSecretKeyNotFound = errors.New("key not found in Secret")
+
+// FetchSecretValues fetches the Secret values an Output or Input maps onto its
+// config, keyed by config path
+func (r *ClusterReconciler) FetchSecretValues(namespace string, ref *gnmicv1alpha1.ConfigSecretRef) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return fetchSecretValues(ctx, r.Client, namespace, ref)
+}
+
+func fetchSecretValues(ctx context.Context, c client.Reader, namespace string, ref *gnmicv1alpha1.ConfigSecretRef) (map[string]string, error) {
+	var secret corev1.Secret
+	if err := c.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, &secret); err != nil {
+		return nil, err
 	}
-	return creds, nil
+	values := make(map[string]string, len(ref.Items))
+	for _, item := range ref.Items {
+		v, ok := secret.Data[item.Key]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", errSecretKeyNotFound, item.Key)
+		}
+		values[item.Path] = string(v)
+	}
+	return values, nil
 }
 
 //+kubebuilder:rbac:groups=operator.gnmic.dev,resources=clusters,verbs=get;list;watch;create;update;patch;delete
@@ -139,19 +191,29 @@ func (r *ClusterReconciler) FetchCredentials(namespace, secretRef string) (*gnmi
 //+kubebuilder:rbac:groups=operator.gnmic.dev,resources=pipelines/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=operator.gnmic.dev,resources=targets,verbs=get;list;watch
 //+kubebuilder:rbac:groups=operator.gnmic.dev,resources=targetprofiles,verbs=get;list;watch
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=targetprofiles/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=operator.gnmic.dev,resources=subscriptions,verbs=get;list;watch
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=subscriptions/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=operator.gnmic.dev,resources=outputs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=outputs/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=operator.gnmic.dev,resources=inputs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=inputs/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=operator.gnmic.dev,resources=processors,verbs=get;list;watch
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=processors/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=operator.gnmic.dev,resources=tunneltargetpolicies,verbs=get;list;watch
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=tunneltargetpolicies/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=modelsets,verbs=get;list;watch
 //+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 // Secrets are read-only everywhere in this operator (credential and issuer-CA lookups);
 // list and watch are required because they are served from the informer cache.
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// Tokens are only requested for the ServiceAccount logging in to Vault.
+//+kubebuilder:rbac:groups="",resources=serviceaccounts/token,verbs=create,resourceNames=gnmic-vault-auth
 //+kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=cert-manager.io,resources=issuers,verbs=get;list;watch
+//+kubebuilder:rbac:groups=trust.cert-manager.io,resources=bundles,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -180,6 +242,8 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			}
 			// cleanup plan
 			r.cleanupPlan(req.Namespace, req.Name)
+			// drop the cluster from the referenced resources' status
+			r.updateResourceStatuses(ctx, req.Namespace, req.Name, newResourceUsage())
 		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
@@ -238,6 +302,8 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 					return ctrl.Result{}, cleanupErr
 				}
 			}
+			// drop the cluster from the referenced resources' status
+			r.updateResourceStatuses(ctx, req.Namespace, req.Name, newResourceUsage())
 			controllerutil.RemoveFinalizer(&cluster, clusterFinalizer)
 			if updateErr := r.Update(ctx, &cluster); updateErr != nil {
 				return ctrl.Result{}, updateErr
@@ -318,14 +384,6 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 	}
 
-	// reconcile statefulset
-	statefulSet, err := r.reconcileStatefulSet(ctx, &cluster)
-	if err != nil {
-		return ctrl.Result{}, err
-	}
-
-	logger.Info("reconciled cluster statefulset", "replicas", ptr.Deref(statefulSet.Spec.Replicas, 0), "image", statefulSet.Spec.Template.Spec.Containers[0].Image)
-
 	// retrieve enabled pipelines referencing this cluster
 	pipelines, err := r.listPipelinesForCluster(ctx, &cluster)
 	if err != nil {
@@ -341,6 +399,14 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		planBuilder.WithTargetDistributionCapacity(cluster.Spec.TargetDistribution.PodCapacity)
 	}
 	pipelineDataMap := make(map[string]*gnmic.PipelineData)
+	// the earliest schedule boundary across pipelines, to requeue at
+	var nextTransition time.Time
+	scheduleNow := time.Now()
+	// usage of the referenced resources, reported in their status
+	usage := newResourceUsage()
+	// errors of the pipelines that could not be resolved, returned once the
+	// statefulset is reconciled so that they do not hold it back
+	var pipelinesErr error
 
 	for _, pipeline := range pipelines {
 		if !pipeline.Spec.Enabled {
@@ -348,159 +414,125 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 		logger.Info("cluster pipeline", "pipeline", pipeline.Name, "enabled", pipeline.Spec.Enabled)
 		pipelineNN := pipeline.Namespace + gnmic.Delimiter + pipeline.Name
-		pipelineData := gnmic.NewPipelineData()
-
-		// retrieve targets for this pipeline
-		targets, err := r.resolveTargets(ctx, &pipeline)
-		if err != nil {
-			return ctrl.Result{}, err
-		}
-		targetProfilesNames := make(map[string]struct{})
-		for _, target := range targets {
-			pipelineData.Targets[target.Namespace+gnmic.Delimiter+target.Name] = target
-			targetProfilesNames[target.Spec.Profile] = struct{}{}
-		}
 
-		// retrieve target profiles for targets in this pipeline
-		for targetProfileName := range targetProfilesNames {
-			var targetProfile gnmicv1alpha1.TargetProfile
-			if err := r.Get(ctx, types.NamespacedName{Name: targetProfileName, Namespace: pipeline.Namespace}, &targetProfile); err != nil {
-				return ctrl.Result{}, err
-			}
-			pipelineData.TargetProfiles[targetProfile.Namespace+gnmic.Delimiter+targetProfile.Name] = targetProfile.Spec
+		// a pipeline whose own spec is invalid is skipped
+		if err := validatePipelineSpec(&pipeline); err != nil {
+			r.isolatePipelineError(ctx, &pipeline, err)
+			continue
 		}
-		logger.Info("cluster pipeline resolved targets", "count", len(targets), "targetProfiles", len(targetProfilesNames))
 
-		// retrieve subscriptions for this pipeline
-		subscriptions, err := r.resolveSubscriptions(ctx, &pipeline)
+		// honor the pipeline schedule and TTL
+		schedule, err := evaluatePipelineSchedule(&pipeline, scheduleNow)
 		if err != nil {
-			return ctrl.Result{}, err
+			r.isolatePipelineError(ctx, &pipeline, &pipelineSpecError{reason: "InvalidSchedule", err: err})
+			continue
 		}
-		for _, subscription := range subscriptions {
-			// Key by pipeline like outputs so two pipelines sharing one
-			// Subscription CR each get their own output binding. A flat
-			// namespace/name key merges both pipelines' outputs onto every
-			// target that uses the subscription.
-			pipelineData.Subscriptions[pipelineNN+gnmic.Delimiter+subscription.Name] = subscription.Spec
+		if !schedule.next.IsZero() && (nextTransition.IsZero() || schedule.next.Before(nextTransition)) {
+			nextTransition = schedule.next
 		}
-		logger.Info("cluster pipeline resolved subscriptions", "count", len(subscriptions))
-
-		// retrieve outputs for this pipeline
-		outputs, err := r.resolveOutputs(ctx, &pipeline)
-		if err != nil {
-			return ctrl.Result{}, err
-		}
-		for _, output := range outputs {
-			outputNN := pipelineNN + gnmic.Delimiter + output.Name
-			pipelineData.Outputs[outputNN] = output.Spec
-
-			// resolve service addresses for outputs that support it (nats, kafka, jetstream)
-			if gnmic.OutputTypesWithServiceRef[output.Spec.Type] {
-				resolvedAddrs, err := r.resolveOutputServiceAddresses(ctx, &output)
-				if err != nil {
-					logger.Error(err, "failed to resolve service addresses for output", "output", output.Name)
-					// continue without resolved addresses - the output config may have static address
-				} else if len(resolvedAddrs) > 0 {
-					pipelineData.ResolvedOutputAddresses[outputNN] = resolvedAddrs
-				}
+		if schedule.state != PipelineStateActive {
+			logger.Info("pipeline not active", "pipeline", pipeline.Name, "state", schedule.state, "reason", schedule.reason)
+			if err := r.updatePipelineStatusInactive(ctx, &pipeline, schedule); err != nil {
+				logger.Error(err, "failed to update pipeline status", "pipeline", pipeline.Name)
 			}
+			continue
 		}
-		logger.Info("cluster pipeline resolved outputs", "count", len(outputs))
 
-		// retrieve inputs for this pipeline
-		inputs, err := r.resolveInputs(ctx, &pipeline)
+		resolved, err := r.resolvePipeline(ctx, &cluster, &pipeline, schedule, usage)
 		if err != nil {
-			return ctrl.Result{}, err
-		}
-		for _, input := range inputs {
-			pipelineData.Inputs[pipelineNN+gnmic.Delimiter+input.Name] = input.Spec
+			if !r.isolatePipelineError(ctx, &pipeline, err) {
+				pipelinesErr = errors.Join(pipelinesErr, err)
+			}
+			continue
 		}
-		logger.Info("cluster pipeline resolved inputs", "count", len(inputs))
+		pipelineData := resolved.data
 
-		// retrieve output processors for this pipeline (order: refs first, then sorted selectors)
-		outputProcessors, err := r.resolveOutputProcessors(ctx, &pipeline)
-		if err != nil {
-			return ctrl.Result{}, err
+		// record the resources this pipeline uses and the targets they apply to;
+		// inputs, input processors and tunnel target policies do not apply to
+		// the pipeline's static targets
+		pipelineTargets := make([]string, 0, len(pipelineData.Targets))
+		for targetNN, target := range pipelineData.Targets {
+			pipelineTargets = append(pipelineTargets, targetNN)
+			usage.use(resourceKindTargetProfile, target.Spec.Profile, pipeline.Name, targetNN)
 		}
-		for _, processor := range outputProcessors {
-			processorNN := pipelineNN + gnmic.Delimiter + processor.Name
-			pipelineData.OutputProcessors[processorNN] = processor.Spec
-			pipelineData.OutputProcessorOrder = append(pipelineData.OutputProcessorOrder, processorNN)
+		for _, subscription := range resolved.subscriptions {
+			usage.use(resourceKindSubscription, subscription.Name, pipeline.Name, pipelineTargets...)
 		}
-		logger.Info("cluster pipeline resolved output processors", "count", len(outputProcessors))
-
-		// retrieve input processors for this pipeline (order: refs first, then sorted selectors)
-		inputProcessors, err := r.resolveInputProcessors(ctx, &pipeline)
-		if err != nil {
-			return ctrl.Result{}, err
+		for _, output := range resolved.outputs {
+			usage.use(resourceKindOutput, output.Name, pipeline.Name, pipelineTargets...)
 		}
-		for _, processor := range inputProcessors {
-			processorNN := pipelineNN + gnmic.Delimiter + processor.Name
-			pipelineData.InputProcessors[processorNN] = processor.Spec
-			pipelineData.InputProcessorOrder = append(pipelineData.InputProcessorOrder, processorNN)
+		for _, processor := range resolved.outputProcessors {
+			usage.use(resourceKindProcessor, processor.Name, pipeline.Name, pipelineTargets...)
 		}
-		logger.Info("cluster pipeline resolved input processors", "count", len(inputProcessors))
-
-		// retrieve tunnel target policies for this pipeline
-		tunnelTargetPolicies, err := r.resolveTunnelTargetPolicies(ctx, &pipeline)
-		if err != nil {
-			return ctrl.Result{}, err
+		for _, input := range resolved.inputs {
+			usage.use(resourceKindInput, input.Name, pipeline.Name)
 		}
-		// validate: if pipeline has tunnel target policies, cluster must have GRPCTunnel configured
-		if len(tunnelTargetPolicies) > 0 && cluster.Spec.GRPCTunnel == nil {
-			logger.Error(nil, "pipeline has tunnel target policies but cluster has no gRPC tunnel configured",
-				"pipeline", pipeline.Name, "cluster", cluster.Name)
-			// update pipeline status with error
-			if err := r.updatePipelineStatusWithError(ctx, &pipeline,
-				"ClusterMissingTunnel",
-				fmt.Sprintf("Cluster %s does not have gRPC tunnel configured, but pipeline references tunnel target policies", cluster.Name),
-			); err != nil {
-				logger.Error(err, "failed to update pipeline status with error")
-			}
-			continue // skip this pipeline
-		}
-		tunnelProfileNames := make(map[string]struct{})
-		for _, policy := range tunnelTargetPolicies {
-			pipelineData.TunnelTargetPolicies[policy.Namespace+gnmic.Delimiter+policy.Name] = policy.Spec
-			if policy.Spec.Profile != "" {
-				tunnelProfileNames[policy.Spec.Profile] = struct{}{}
-			}
+		for _, processor := range resolved.inputProcessors {
+			usage.use(resourceKindProcessor, processor.Name, pipeline.Name)
 		}
-		// retrieve target profiles for tunnel target policies (they share TargetProfiles)
-		for profileName := range tunnelProfileNames {
-			if _, exists := pipelineData.TargetProfiles[pipeline.Namespace+gnmic.Delimiter+profileName]; exists {
-				continue // already fetched for targets
-			}
-			var targetProfile gnmicv1alpha1.TargetProfile
-			if err := r.Get(ctx, types.NamespacedName{Name: profileName, Namespace: pipeline.Namespace}, &targetProfile); err != nil {
-				if !apierrors.IsNotFound(err) {
-					return ctrl.Result{}, err
-				}
-				logger.Info("target profile not found for tunnel target policy, skipping", "profile", profileName)
-				continue
+		for _, policy := range resolved.tunnelTargetPolicies {
+			usage.use(resourceKindTunnelTargetPolicy, policy.Name, pipeline.Name)
+			if policy.Spec.Profile != "" {
+				usage.use(resourceKindTargetProfile, policy.Spec.Profile, pipeline.Name)
 			}
-			pipelineData.TargetProfiles[targetProfile.Namespace+gnmic.Delimiter+targetProfile.Name] = targetProfile.Spec
 		}
-		logger.Info("cluster pipeline tunnel target policies", "policies", len(tunnelTargetPolicies))
 
 		planBuilder.AddPipeline(pipelineNN, pipelineData)
 		pipelineDataMap[pipelineNN] = pipelineData
 
 		// update pipeline status
-		if err := r.updatePipelineStatus(ctx, &pipeline, pipelineData); err != nil {
+		if err := r.updatePipelineStatus(ctx, &pipeline, pipelineData, schedule, resolved.suspendedTargets, resolved.refs); err != nil {
 			logger.Error(err, "failed to update pipeline status", "pipeline", pipeline.Name)
 			// don't return, continue with other pipelines
 		}
 	}
 
+	// reconcile statefulset, once the pipelines tell which target profile TLS
+	// files the pods mount; the files of the pipelines that failed stay mounted
+	targetTLS := targetTLSProjections(pipelineDataMap)
+	if pipelinesErr != nil {
+		targetTLS, err = r.keepMountedTargetTLS(ctx, &cluster, targetTLS)
+		if err != nil {
+			return ctrl.Result{}, errors.Join(pipelinesErr, err)
+		}
+	}
+	statefulSet, err := r.reconcileStatefulSet(ctx, &cluster, targetTLS)
+	if err != nil {
+		return ctrl.Result{}, errors.Join(pipelinesErr, err)
+	}
+
+	logger.Info("reconciled cluster statefulset", "replicas", ptr.Deref(statefulSet.Spec.Replicas, 0), "image", statefulSet.Spec.Template.Spec.Containers[0].Image)
+	if pipelinesErr != nil {
+		return ctrl.Result{}, pipelinesErr
+	}
+
+	// report usage before building the plan, which fails on some of the
+	// problems reported, e.g. a missing credentials Secret
+	r.updateResourceStatuses(ctx, cluster.Namespace, cluster.Name, usage)
+
 	// build the apply plan
 	applyPlan, err := planBuilder.Build()
 	if err != nil {
 		return ctrl.Result{}, err
 	}
-	r.m.Lock()
-	r.plans[cluster.Namespace+"/"+cluster.Name] = applyPlan
-	r.m.Unlock()
+	// while the cluster is pinned to a revision of its plan history, that
+	// revision is applied instead of the plan built from the current pipelines.
+	planToApply := applyPlan
+	pinnedRevision := pinnedPlanRevision(&cluster)
+	var pinErr error
+	if pinnedRevision > 0 {
+		planToApply, pinErr = r.pinnedPlan(ctx, &cluster, pinnedRevision, applyPlan)
+		if pinErr != nil {
+			logger.Error(pinErr, "failed to load pinned plan revision", "revision", pinnedRevision)
+		} else {
+			logger.Info("cluster is pinned to a plan revision", "revision", pinnedRevision)
+		}
+	}
+	if pinErr == nil {
+		r.m.Lock()
+		r.plans[cluster.Namespace+"/"+cluster.Name] = planToApply
+		r.m.Unlock()
+	}
 
 	// reconcile Prometheus output services
 	if err := r.reconcilePrometheusServices(ctx, &cluster, pipelineDataMap, applyPlan.PrometheusPorts); err != nil {
@@ -534,13 +566,33 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	configApplied := false
 	var configError error
 	var unassignedTargets int32
-	if unassigned, err := r.applyConfigToPods(ctx, &cluster, applyPlan, numPods); err != nil {
-		logger.Error(err, "failed to apply config to gNMIc pods")
-		configError = err
+	planRevision := cluster.Status.PlanRevision
+	var canaryBaking *canaryBakingError
+	var canaryFailed *canaryFailedError
+	if pinErr != nil {
+		configError = pinErr
+	} else if unassigned, err := r.applyConfigToPods(ctx, &cluster, planToApply, numPods); err != nil {
+		if errors.As(err, &canaryBaking) {
+			// not a failure: the other pods keep serving the previous plan
+			unassignedTargets = unassigned
+			logger.Info("canary rollout in progress", "pod", canaryBaking.pod, "remaining", canaryBaking.remaining)
+		} else {
+			errors.As(err, &canaryFailed)
+			logger.Error(err, "failed to apply config to gNMIc pods")
+			configError = err
+		}
 	} else {
 		configApplied = true
 		unassignedTargets = unassigned
 		logger.Info("successfully applied config to gNMIc cluster", "pods", numPods)
+		if pinnedRevision > 0 {
+			planRevision = pinnedRevision
+		} else if rev, err := r.recordPlanRevision(ctx, &cluster, applyPlan); err != nil {
+			// the plan is applied; a missing history entry must not fail the reconcile
+			logger.Error(err, "failed to record plan revision")
+		} else {
+			planRevision = rev
+		}
 	}
 
 	// calculate resource counts from pipelineDataMap
@@ -579,6 +631,7 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		SubscriptionsCount: totalSubscriptions,
 		InputsCount:        totalInputs,
 		OutputsCount:       totalOutputs,
+		PlanRevision:       planRevision,
 	}
 
 	// set conditions
@@ -591,11 +644,13 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		LastTransitionTime: now,
 	}
 	desired := ptr.Deref(cluster.Spec.Replicas, 0)
-	if statefulSet.Status.ReadyReplicas >= desired && configApplied {
+	// while a canary bakes every pod is configured, most with the previous plan
+	configured := configApplied || canaryBaking != nil
+	if statefulSet.Status.ReadyReplicas >= desired && configured {
 		readyCondition.Status = metav1.ConditionTrue
 		readyCondition.Reason = "ClusterReady"
 		readyCondition.Message = fmt.Sprintf("All %d replicas are ready and configured", statefulSet.Status.ReadyReplicas)
-	} else if statefulSet.Status.ReadyReplicas > 0 && configApplied {
+	} else if statefulSet.Status.ReadyReplicas > 0 && configured {
 		readyCondition.Status = metav1.ConditionTrue
 		readyCondition.Reason = "ClusterPartiallyReady"
 		readyCondition.Message = fmt.Sprintf("%d of %d replicas are ready and configured", statefulSet.Status.ReadyReplicas, cluster.Spec.Replicas)
@@ -633,6 +688,10 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		configCondition.Status = metav1.ConditionTrue
 		configCondition.Reason = "ConfigurationApplied"
 		configCondition.Message = fmt.Sprintf("Configuration applied to %d pods", numPods)
+	} else if canaryBaking != nil {
+		configCondition.Status = metav1.ConditionFalse
+		configCondition.Reason = "RolloutInProgress"
+		configCondition.Message = fmt.Sprintf("Configuration applied to canary pod %d only", canaryBaking.pod)
 	} else {
 		configCondition.Status = metav1.ConditionFalse
 		configCondition.Reason = "ConfigurationFailed"
@@ -665,6 +724,39 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		})
 	}
 
+	// planPinned condition (only while pinned)
+	if pinnedRevision > 0 {
+		newStatus.Conditions = append(newStatus.Conditions, metav1.Condition{
+			Type:               ConditionTypePlanPinned,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: cluster.Generation,
+			LastTransitionTime: now,
+			Reason:             "PinnedByUser",
+			Message:            fmt.Sprintf("Cluster is pinned to plan revision %d", pinnedRevision),
+		})
+	}
+
+	// rolloutProgressing condition (only while a canary bakes or is halted)
+	if canaryBaking != nil {
+		newStatus.Conditions = append(newStatus.Conditions, metav1.Condition{
+			Type:               ConditionTypeRolloutProgressing,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: cluster.Generation,
+			LastTransitionTime: now,
+			Reason:             "CanaryBaking",
+			Message:            fmt.Sprintf("Canary pod %d is running the new plan", canaryBaking.pod),
+		})
+	} else if canaryFailed != nil {
+		newStatus.Conditions = append(newStatus.Conditions, metav1.Condition{
+			Type:               ConditionTypeRolloutProgressing,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: cluster.Generation,
+			LastTransitionTime: now,
+			Reason:             "CanaryFailed",
+			Message:            canaryFailed.Error(),
+		})
+	}
+
 	// preserve LastTransitionTime for unchanged conditions
 	for i := range newStatus.Conditions {
 		for _, oldCond := range cluster.Status.Conditions {
@@ -710,17 +802,20 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 	}
 
-	if configError != nil {
-		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	var result ctrl.Result
+	switch {
+	case canaryBaking != nil:
+		result.RequeueAfter = min(canaryBaking.remaining, canaryCheckInterval)
+	case configError != nil:
+		result.RequeueAfter = 10 * time.Second
+	case configApplied && len(pipelines) == 0:
+		// An empty apply from a briefly stale cache can race a Pipeline create.
+		// Requeue once so the next pass sees the live membership and restores
+		// config if needed; non-empty applies are left alone.
+		result.RequeueAfter = time.Second
 	}
-	// An empty apply from a briefly stale cache can race a Pipeline create.
-	// Requeue once so the next pass sees the live membership and restores
-	// config if needed; non-empty applies are left alone.
-	if configApplied && len(pipelines) == 0 {
-		return ctrl.Result{RequeueAfter: time.Second}, nil
-	}
-
-	return ctrl.Result{}, nil
+	// nothing else wakes the controller when a schedule window opens or closes
+	return requeueAtTransition(result, nextTransition, time.Now()), nil
 }
 
 // clusterStatusEqual compares two ClusterStatus structs for equality
@@ -731,7 +826,8 @@ func clusterStatusEqual(a, b gnmicv1alpha1.ClusterStatus) bool {
 		a.UnassignedTargets != b.UnassignedTargets ||
 		a.SubscriptionsCount != b.SubscriptionsCount ||
 		a.InputsCount != b.InputsCount ||
-		a.OutputsCount != b.OutputsCount {
+		a.OutputsCount != b.OutputsCount ||
+		a.PlanRevision != b.PlanRevision {
 		return false
 	}
 	if len(a.Conditions) != len(b.Conditions) {
@@ -806,7 +902,19 @@ func (r *ClusterReconciler) applyConfigToPods(ctx context.Context, cluster *gnmi
 			anyChanged = true
 		}
 	}
+	// The canary stage runs even when nothing changed since the last pass: a
+	// rollout touching a single pod has already applied its full plan there
+	// and still has to bake before it is considered done.
+	send := func(podIndex int, body []byte) error {
+		return r.sendApplyBody(ctx, podURL(podIndex), body, httpClient)
+	}
+	if err := r.canaryGate(ctx, cluster, plan, distResult, hashes, changed, send); err != nil {
+		return int32(len(distResult.UnassignedTargets)), err
+	}
 	if !anyChanged {
+		if err := r.markRolledOut(cluster, plan); err != nil {
+			return 0, err
+		}
 		return int32(len(distResult.UnassignedTargets)), nil
 	}
 
@@ -874,10 +982,13 @@ func (r *ClusterReconciler) applyConfigToPods(ctx context.Context, cluster *gnmi
 		}
 		// Recorded only after the POST succeeds. Recording the attempt would
 		// make a failed apply look applied until the plan changes again.
-		r.Applied.Record(streamKey(cluster.Namespace, cluster.Name, podIndex), hashes[podIndex])
+		r.Applied.Record(streamKey(cluster.Namespace, cluster.Name, podIndex), hashes[podIndex], bodies[podIndex])
 		logger.Info("config applied to pod", "pod", podIndex, "targets", len(podPlan.Targets))
 	}
 
+	if err := r.markRolledOut(cluster, plan); err != nil {
+		return 0, err
+	}
 	unassigned := int32(len(distResult.UnassignedTargets))
 	if unassigned > 0 {
 		logger.Info("targets unassigned due to capacity limits", "count", unassigned)
@@ -892,41 +1003,9 @@ func (r *ClusterReconciler) createHTTPClientForCluster(ctx context.Context, clus
 			Timeout: 30 * time.Second,
 		}, nil
 	}
-	tlsConfig := &tls.Config{}
-	if cluster.Spec.API.TLS.IssuerRef != "" {
-		// load controller's client certificate for mTLS
-		cert, err := os.ReadFile(gnmic.GetControllerCertPath())
-		if err != nil {
-			return nil, fmt.Errorf("failed to read controller cert file: %w", err)
-		}
-		key, err := os.ReadFile(gnmic.GetControllerKeyPath())
-		if err != nil {
-			return nil, fmt.Errorf("failed to read controller key file: %w", err)
-		}
-		certificate, err := tls.X509KeyPair(cert, key)
-		if err != nil {
-			return nil, err
-		}
-		tlsConfig.Certificates = []tls.Certificate{certificate}
-
-		// fetch the CA from the Issuer's secret to verify gNMIc pod certificates
-		ca, err := r.getIssuerCA(ctx, cluster.Namespace, cluster.Spec.API.TLS.IssuerRef)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get issuer CA: %w", err)
-		}
-		tlsConfig.RootCAs = x509.NewCertPool()
-		tlsConfig.RootCAs.AppendCertsFromPEM(ca)
-	}
-	if cluster.Spec.API.TLS.BundleRef != "" {
-		// load additional CA bundle to verify gNMIc pod server certificates
-		ca, err := os.ReadFile(gnmic.GetControllerCAPath())
-		if err != nil {
-			return nil, fmt.Errorf("failed to read controller ca file: %w", err)
-		}
-		if tlsConfig.RootCAs == nil {
-			tlsConfig.RootCAs = x509.NewCertPool()
-		}
-		tlsConfig.RootCAs.AppendCertsFromPEM(ca)
+	tlsConfig, err := clusterAPITLSConfig(ctx, r.Client, cluster)
+	if err != nil {
+		return nil, err
 	}
 	return &http.Client{
 			Timeout: 30 * time.Second, // TODO: make configurable ?
@@ -937,34 +1016,6 @@ func (r *ClusterReconciler) createHTTPClientForCluster(ctx context.Context, clus
 		nil
 }
 
-// getIssuerCA fetches the CA certificate from a cert-manager Issuer's backing secret
-func (r *ClusterReconciler) getIssuerCA(ctx context.Context, namespace, issuerName string) ([]byte, error) {
-	// get the Issuer
-	issuer := &certmanagerv1.Issuer{}
-	if err := r.Get(ctx, types.NamespacedName{Name: issuerName, Namespace: namespace}, issuer); err != nil {
-		return nil, fmt.Errorf("failed to get issuer %s: %w", issuerName, err)
-	}
-
-	// the Issuer should be a CA issuer with a secretName
-	if issuer.Spec.CA == nil || issuer.Spec.CA.SecretName == "" {
-		return nil, fmt.Errorf("issuer %s is not a CA issuer or has no secret configured", issuerName)
-	}
-
-	// get the CA secret
-	caSecret := &corev1.Secret{}
-	if err := r.Get(ctx, types.NamespacedName{Name: issuer.Spec.CA.SecretName, Namespace: namespace}, caSecret); err != nil {
-		return nil, fmt.Errorf("failed to get CA secret %s: %w", issuer.Spec.CA.SecretName, err)
-	}
-
-	// the CA certificate is stored in tls.crt
-	caCert, ok := caSecret.Data["tls.crt"]
-	if !ok {
-		return nil, fmt.Errorf("CA secret %s does not contain tls.crt", issuer.Spec.CA.SecretName)
-	}
-
-	return caCert, nil
-}
-
 // shrinkPodPlan returns a copy of podPlan whose Targets are the intersection of
 // the desired set and the pod's previously assigned targets. A nil previous set
 // yields an empty target map — used to drain a pod.
@@ -1031,7 +1082,7 @@ func (r *ClusterReconciler) sendApplyBody(ctx context.Context, url string, jsonD
 		if err != nil {
 			return fmt.Errorf("failed to read response body: %w", err)
 		}
-		logger.Error(rspErr, "", "body", string(body))
+		logger.Error(rspErr, "", "body", utils.RedactJSON(body))
 		return rspErr
 	}
 
@@ -1057,6 +1108,32 @@ func (p generationOrLabelsChangedPredicate) Update(e event.UpdateEvent) bool {
 	return !maps.Equal(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels())
 }
 
+// targetChangedPredicate also triggers reconciliation when the annotations of
+// a Target change: profiles may add them as event tags, and when the encodings
+// found by its capabilities probe change.
+type targetChangedPredicate struct {
+	generationOrLabelsChangedPredicate
+}
+
+func (p targetChangedPredicate) Update(e event.UpdateEvent) bool {
+	if p.generationOrLabelsChangedPredicate.Update(e) {
+		return true
+	}
+	if !maps.Equal(e.ObjectOld.GetAnnotations(), e.ObjectNew.GetAnnotations()) {
+		return true
+	}
+	oldTarget, okOld := e.ObjectOld.(*gnmicv1alpha1.Target)
+	newTarget, okNew := e.ObjectNew.(*gnmicv1alpha1.Target)
+	return okOld && okNew && !slices.Equal(probedEncodings(oldTarget), probedEncodings(newTarget))
+}
+
+func probedEncodings(target *gnmicv1alpha1.Target) []string {
+	if target.Status.Capabilities == nil {
+		return nil
+	}
+	return target.Status.Capabilities.SupportedEncodings
+}
+
 // secretDataChangedPredicate triggers reconciliation only when a Secret's
 // contents change.
 //
@@ -1078,13 +1155,33 @@ func (secretDataChangedPredicate) Update(e event.UpdateEvent) bool {
 	return !maps.EqualFunc(oldSecret.Data, newSecret.Data, bytes.Equal)
 }
 
+// configMapDataChangedPredicate is the ConfigMap counterpart of
+// secretDataChangedPredicate, for the CA bundles of target profiles.
+type configMapDataChangedPredicate struct {
+	predicate.Funcs
+}
+
+func (configMapDataChangedPredicate) Update(e event.UpdateEvent) bool {
+	oldConfigMap, okOld := e.ObjectOld.(*corev1.ConfigMap)
+	newConfigMap, okNew := e.ObjectNew.(*corev1.ConfigMap)
+	if !okOld || !okNew {
+		return false
+	}
+	return !maps.Equal(oldConfigMap.Data, newConfigMap.Data) ||
+		!maps.EqualFunc(oldConfigMap.BinaryData, newConfigMap.BinaryData, bytes.Equal)
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.m = &sync.RWMutex{}
 	r.plans = make(map[string]*gnmic.ApplyPlan)
 
+	if err := indexResourceStatuses(context.Background(), mgr.GetFieldIndexer()); err != nil {
+		return err
+	}
+
 	specOrLabelsPredicate := generationOrLabelsChangedPredicate{}
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&gnmicv1alpha1.Cluster{},
 			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
 		).
@@ -1101,7 +1198,7 @@ func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Watches(
 			&gnmicv1alpha1.Target{},
 			handler.EnqueueRequestsFromMapFunc(r.findClustersForTarget),
-			builder.WithPredicates(specOrLabelsPredicate),
+			builder.WithPredicates(targetChangedPredicate{}),
 		).
 		Watches(
 			&gnmicv1alpha1.Subscription{},
@@ -1128,6 +1225,11 @@ func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			handler.EnqueueRequestsFromMapFunc(r.findClustersForTargetProfile),
 			builder.WithPredicates(predicate.GenerationChangedPredicate{}), // TargetProfile is referenced by name, not labels
 		).
+		Watches(
+			&gnmicv1alpha1.ModelSet{},
+			handler.EnqueueRequestsFromMapFunc(r.findClustersForModelSet),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
 		Watches(
 			&gnmicv1alpha1.TunnelTargetPolicy{},
 			handler.EnqueueRequestsFromMapFunc(r.findClustersForTunnelTargetPolicy),
@@ -1138,7 +1240,21 @@ func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			handler.EnqueueRequestsFromMapFunc(r.findClustersForSecret),
 			builder.WithPredicates(secretDataChangedPredicate{}),
 		).
-		Complete(r)
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.findClustersForConfigMap),
+			builder.WithPredicates(configMapDataChangedPredicate{}),
+		)
+	if r.Credentials != nil {
+		// Rotated Vault and file credentials wake only the clusters
+		// collecting with the profiles using them.
+		if err := mgr.Add(r.Credentials); err != nil {
+			return err
+		}
+		b = b.WatchesRawSource(source.Channel(r.Credentials.Changes(),
+			handler.EnqueueRequestsFromMapFunc(r.findClustersForTargetProfile)))
+	}
+	return b.Complete(r)
 }
 
 // findClusterForPipeline returns a reconcile request for the Cluster referenced by the Pipeline
@@ -1234,7 +1350,7 @@ func (r *ClusterReconciler) findClustersForTargetProfile(ctx context.Context, ob
 }
 
 // findClustersForSecret finds all Clusters collecting with the credentials this
-// Secret holds.
+// Secret holds, or writing its keys into an output or input config.
 //
 // Without this the credentials baked into each TargetConfig are only rebuilt
 // when something else happens to wake the Cluster reconciler, so a rotated
@@ -1245,29 +1361,128 @@ func (r *ClusterReconciler) findClustersForSecret(ctx context.Context, obj clien
 	if !ok {
 		return nil
 	}
-	// A TargetProfile is the only thing that turns a Secret into target
-	// credentials. Most Secrets in a namespace belong to something else
-	// entirely, and they stop here at the cost of one cached list.
+	// A TargetProfile, a Target, an Output or an Input is the only thing that
+	// turns a Secret into credentials or TLS files. Most Secrets in a namespace
+	// belong to something else entirely, and they stop here at the cost of four
+	// cached lists.
 	var profileList gnmicv1alpha1.TargetProfileList
 	if err := r.List(ctx, &profileList, client.InNamespace(secret.Namespace)); err != nil {
 		return nil
 	}
 	profiles := make(map[string]struct{})
 	for i := range profileList.Items {
-		if profileList.Items[i].Spec.CredentialsRef == secret.Name {
+		if credentials.ReferencesSecret(&profileList.Items[i].Spec, secret.Name) || referencesTargetTLSObject(&profileList.Items[i], "Secret", secret.Name) {
+			profiles[profileList.Items[i].Name] = struct{}{}
+		}
+	}
+	// Targets may override the credentials of their profile
+	var targetList gnmicv1alpha1.TargetList
+	if err := r.List(ctx, &targetList, client.InNamespace(secret.Namespace)); err != nil {
+		return nil
+	}
+	var users []selectedResource
+	for i := range targetList.Items {
+		t := &targetList.Items[i]
+		if t.Spec.CredentialsRef == secret.Name {
+			users = append(users, selectedResource{name: t.Name, labels: t.Labels, kind: "target"})
+		}
+	}
+	var outputList gnmicv1alpha1.OutputList
+	if err := r.List(ctx, &outputList, client.InNamespace(secret.Namespace)); err != nil {
+		return nil
+	}
+	for i := range outputList.Items {
+		o := &outputList.Items[i]
+		if o.Spec.SecretRef != nil && o.Spec.SecretRef.Name == secret.Name {
+			users = append(users, selectedResource{name: o.Name, labels: o.Labels, kind: "output"})
+		}
+	}
+	var inputList gnmicv1alpha1.InputList
+	if err := r.List(ctx, &inputList, client.InNamespace(secret.Namespace)); err != nil {
+		return nil
+	}
+	for i := range inputList.Items {
+		in := &inputList.Items[i]
+		if in.Spec.SecretRef != nil && in.Spec.SecretRef.Name == secret.Name {
+			users = append(users, selectedResource{name: in.Name, labels: in.Labels, kind: "input"})
+		}
+	}
+	if len(profiles) > 0 {
+		profileUsers, ok := r.profileUsers(ctx, secret.Namespace, profiles)
+		if !ok {
+			return nil
+		}
+		users = append(users, profileUsers...)
+	}
+	return r.findClustersSelecting(ctx, secret.Namespace, users)
+}
+
+// findClustersForConfigMap finds all Clusters collecting with a TargetProfile
+// whose CA bundle, or the YANG modules of whose ModelSet, are in this ConfigMap
+func (r *ClusterReconciler) findClustersForConfigMap(ctx context.Context, obj client.Object) []reconcile.Request {
+	configMap, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		return nil
+	}
+	var profileList gnmicv1alpha1.TargetProfileList
+	if err := r.List(ctx, &profileList, client.InNamespace(configMap.Namespace)); err != nil {
+		return nil
+	}
+	// the ConfigMap may hold the YANG modules of a ModelSet
+	var modelSetList gnmicv1alpha1.ModelSetList
+	if err := r.List(ctx, &modelSetList, client.InNamespace(configMap.Namespace)); err != nil {
+		return nil
+	}
+	modelSets := make(map[string]struct{})
+	for _, modelSet := range modelSetList.Items {
+		if slices.Contains(modelSet.Spec.ConfigMaps, configMap.Name) {
+			modelSets[modelSet.Name] = struct{}{}
+		}
+	}
+	profiles := make(map[string]struct{})
+	for i := range profileList.Items {
+		if referencesTargetTLSObject(&profileList.Items[i], "ConfigMap", configMap.Name) || referencesModelSet(&profileList.Items[i], modelSets) {
+			profiles[profileList.Items[i].Name] = struct{}{}
+		}
+	}
+	if len(profiles) == 0 {
+		return nil
+	}
+	return r.findClustersUsingProfiles(ctx, configMap.Namespace, profiles)
+}
+
+// findClustersForModelSet finds the Clusters collecting with the
+// TargetProfiles whose subscription paths are checked against a ModelSet.
+func (r *ClusterReconciler) findClustersForModelSet(ctx context.Context, obj client.Object) []reconcile.Request {
+	var profileList gnmicv1alpha1.TargetProfileList
+	if err := r.List(ctx, &profileList, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+	modelSets := map[string]struct{}{obj.GetName(): {}}
+	profiles := make(map[string]struct{})
+	for i := range profileList.Items {
+		if referencesModelSet(&profileList.Items[i], modelSets) {
 			profiles[profileList.Items[i].Name] = struct{}{}
 		}
 	}
 	if len(profiles) == 0 {
 		return nil
 	}
-	return r.findClustersUsingProfiles(ctx, secret.Namespace, profiles)
+	return r.findClustersUsingProfiles(ctx, obj.GetNamespace(), profiles)
 }
 
-// profileUser is something that names a TargetProfile and can itself be
-// selected by a Pipeline, which is what makes it a path from a profile to a
-// cluster.
-type profileUser struct {
+func referencesModelSet(profile *gnmicv1alpha1.TargetProfile, modelSets map[string]struct{}) bool {
+	if profile.Spec.ModelSet == nil {
+		return false
+	}
+	_, ok := modelSets[profile.Spec.ModelSet.Name]
+	return ok
+}
+
+// selectedResource is a resource a Pipeline can select, by name or labels.
+// Targets and tunnel target policies are what make a path from a TargetProfile
+// to a cluster.
+type selectedResource struct {
 	name   string
 	labels map[string]string
 	kind   string // as understood by pipelineReferencesResource
@@ -1281,15 +1496,25 @@ type profileUser struct {
 // target, which re-lists every Pipeline each time and turns a single event into
 // O(targets x pipelines) work.
 func (r *ClusterReconciler) findClustersUsingProfiles(ctx context.Context, namespace string, profiles map[string]struct{}) []reconcile.Request {
+	users, ok := r.profileUsers(ctx, namespace, profiles)
+	if !ok {
+		return nil
+	}
+	return r.findClustersSelecting(ctx, namespace, users)
+}
+
+// profileUsers returns the targets and tunnel target policies naming one of
+// the TargetProfiles. It returns false if they could not be listed.
+func (r *ClusterReconciler) profileUsers(ctx context.Context, namespace string, profiles map[string]struct{}) ([]selectedResource, bool) {
 	var targetList gnmicv1alpha1.TargetList
 	if err := r.List(ctx, &targetList, client.InNamespace(namespace)); err != nil {
-		return nil
+		return nil, false
 	}
-	var users []profileUser
+	var users []selectedResource
 	for i := range targetList.Items {
 		t := &targetList.Items[i]
 		if _, ok := profiles[t.Spec.Profile]; ok {
-			users = append(users, profileUser{name: t.Name, labels: t.Labels, kind: "target"})
+			users = append(users, selectedResource{name: t.Name, labels: t.Labels, kind: "target"})
 		}
 	}
 
@@ -1298,14 +1523,20 @@ func (r *ClusterReconciler) findClustersUsingProfiles(ctx context.Context, names
 	// find them by.
 	var policyList gnmicv1alpha1.TunnelTargetPolicyList
 	if err := r.List(ctx, &policyList, client.InNamespace(namespace)); err != nil {
-		return nil
+		return nil, false
 	}
 	for i := range policyList.Items {
 		p := &policyList.Items[i]
 		if _, ok := profiles[p.Spec.Profile]; ok {
-			users = append(users, profileUser{name: p.Name, labels: p.Labels, kind: "tunnel-target-policy"})
+			users = append(users, selectedResource{name: p.Name, labels: p.Labels, kind: "tunnel-target-policy"})
 		}
 	}
+	return users, true
+}
+
+// findClustersSelecting returns the Clusters of the enabled Pipelines that
+// select any of the resources, with a single cached list of Pipelines.
+func (r *ClusterReconciler) findClustersSelecting(ctx context.Context, namespace string, users []selectedResource) []reconcile.Request {
 	if len(users) == 0 {
 		return nil
 	}
@@ -1432,8 +1663,8 @@ func pipelineReferencesResource(pipeline *gnmicv1alpha1.Pipeline, resourceName s
 	return false
 }
 
-func (r *ClusterReconciler) reconcileStatefulSet(ctx context.Context, cluster *gnmicv1alpha1.Cluster) (*appsv1.StatefulSet, error) {
-	desired, desiredConfigMap, err := r.buildStatefulSet(cluster)
+func (r *ClusterReconciler) reconcileStatefulSet(ctx context.Context, cluster *gnmicv1alpha1.Cluster, targetTLS []corev1.VolumeProjection) (*appsv1.StatefulSet, error) {
+	desired, desiredConfigMap, err := r.buildStatefulSet(cluster, targetTLS)
 	if err != nil {
 		return nil, err
 	}
@@ -1531,7 +1762,7 @@ func (r *ClusterReconciler) reconcileStatefulSet(ctx context.Context, cluster *g
 }
 
 // volumesEqual compares two volume slices for equality
-// This is a simplified comparison that checks volume names and projected sources count
+// This is a simplified comparison that checks volume names, projected sources and referenced objects
 func volumesEqual(a, b []corev1.Volume) bool {
 	if len(a) != len(b) {
 		return false
@@ -1545,9 +1776,10 @@ func volumesEqual(a, b []corev1.Volume) bool {
 		if !ok {
 			return false
 		}
-		// compare projected volume sources count (this is what changes on scale)
+		// compare projected volume sources: the per-pod certificates change on
+		// scale, the target profile TLS files when profiles come and go
 		if va.Projected != nil && vb.Projected != nil {
-			if len(va.Projected.Sources) != len(vb.Projected.Sources) {
+			if !equality.Semantic.DeepEqual(va.Projected.Sources, vb.Projected.Sources) {
 				return false
 			}
 		} else if (va.Projected == nil) != (vb.Projected == nil) {
@@ -2310,7 +2542,7 @@ func (r *ClusterReconciler) buildConfigMap(cluster *gnmicv1alpha1.Cluster) (*cor
 }
 
 // updatePipelineStatus updates the status of a pipeline based on its resolved resources
-func (r *ClusterReconciler) updatePipelineStatus(ctx context.Context, pipeline *gnmicv1alpha1.Pipeline, pipelineData *gnmic.PipelineData) error {
+func (r *ClusterReconciler) updatePipelineStatus(ctx context.Context, pipeline *gnmicv1alpha1.Pipeline, pipelineData *gnmic.PipelineData, schedule *pipelineSchedule, suspendedTargets int32, refs *pipelineRefs) error {
 	logger := log.FromContext(ctx)
 
 	now := metav1.Now()
@@ -2322,6 +2554,10 @@ func (r *ClusterReconciler) updatePipelineStatus(ctx context.Context, pipeline *
 		InputsCount:               int32(len(pipelineData.Inputs)),
 		OutputsCount:              int32(len(pipelineData.Outputs)),
 		TunnelTargetPoliciesCount: int32(len(pipelineData.TunnelTargetPolicies)),
+		EffectiveState:            schedule.state,
+		NextTransitionTime:        transitionTime(schedule.next),
+		SuspendedTargetsCount:     suspendedTargets,
+		UnresolvedRefs:            refs.sortedUnresolved(),
 	}
 
 	// ready condition
@@ -2372,6 +2608,15 @@ func (r *ClusterReconciler) updatePipelineStatus(ctx context.Context, pipeline *
 	}
 	newStatus.Conditions = append(newStatus.Conditions, resolvedCondition)
 
+	// resolvedRefs condition
+	newStatus.Conditions = append(newStatus.Conditions, refs.condition(pipeline.Generation, now))
+
+	// encodingsSupported condition
+	newStatus.Conditions = append(newStatus.Conditions, encodingsCondition(pipelineData, pipeline.Generation, now))
+
+	// pathsValid condition
+	newStatus.Conditions = append(newStatus.Conditions, r.pathsCondition(ctx, pipelineData, pipeline.Generation, now))
+
 	// preserve LastTransitionTime for unchanged conditions
 	for i := range newStatus.Conditions {
 		for _, oldCond := range pipeline.Status.Conditions {
@@ -2383,6 +2628,7 @@ func (r *ClusterReconciler) updatePipelineStatus(ctx context.Context, pipeline *
 		}
 	}
 
+	newStatus.EnabledAt = pipeline.Status.EnabledAt
 	// update status if changed, with retry on conflict
 	if !pipelineStatusEqual(pipeline.Status, newStatus) {
 		pipelineNN := types.NamespacedName{Name: pipeline.Name, Namespace: pipeline.Namespace}
@@ -2391,6 +2637,8 @@ func (r *ClusterReconciler) updatePipelineStatus(ctx context.Context, pipeline *
 			if err := r.Get(ctx, pipelineNN, pipeline); err != nil {
 				return fmt.Errorf("failed to re-fetch pipeline: %w", err)
 			}
+			// enabledAt is owned by the Pipeline controller
+			newStatus.EnabledAt = pipeline.Status.EnabledAt
 			pipeline.Status = newStatus
 			if err := r.Status().Update(ctx, pipeline); err != nil {
 				if apierrors.IsConflict(err) {
@@ -2413,7 +2661,12 @@ func pipelineStatusEqual(a, b gnmicv1alpha1.PipelineStatus) bool {
 		a.SubscriptionsCount != b.SubscriptionsCount ||
 		a.InputsCount != b.InputsCount ||
 		a.OutputsCount != b.OutputsCount ||
-		a.TunnelTargetPoliciesCount != b.TunnelTargetPoliciesCount {
+		a.TunnelTargetPoliciesCount != b.TunnelTargetPoliciesCount ||
+		a.EffectiveState != b.EffectiveState ||
+		!a.NextTransitionTime.Equal(b.NextTransitionTime) ||
+		!a.EnabledAt.Equal(b.EnabledAt) ||
+		a.SuspendedTargetsCount != b.SuspendedTargetsCount ||
+		!slices.Equal(a.UnresolvedRefs, b.UnresolvedRefs) {
 		return false
 	}
 	if len(a.Conditions) != len(b.Conditions) {
@@ -2453,6 +2706,58 @@ func (r *ClusterReconciler) updatePipelineStatusWithError(ctx context.Context, p
 		if err := r.Get(ctx, pipelineNN, pipeline); err != nil {
 			return fmt.Errorf("failed to re-fetch pipeline: %w", err)
 		}
+		// enabledAt is owned by the Pipeline controller
+		newStatus.EnabledAt = pipeline.Status.EnabledAt
+		pipeline.Status = newStatus
+		if err := r.Status().Update(ctx, pipeline); err != nil {
+			if apierrors.IsConflict(err) {
+				continue
+			}
+			return fmt.Errorf("failed to update pipeline status: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("failed to update pipeline status after retries: conflict")
+}
+
+// updatePipelineStatusInactive reports an enabled pipeline that does not
+// collect because of its schedule or TTL.
+func (r *ClusterReconciler) updatePipelineStatusInactive(ctx context.Context, pipeline *gnmicv1alpha1.Pipeline, schedule *pipelineSchedule) error {
+	reason := "PipelineSuspended"
+	if schedule.state == PipelineStateExpired {
+		reason = "PipelineExpired"
+	}
+	newStatus := gnmicv1alpha1.PipelineStatus{
+		Status:             schedule.state,
+		EffectiveState:     schedule.state,
+		NextTransitionTime: transitionTime(schedule.next),
+		EnabledAt:          pipeline.Status.EnabledAt,
+		Conditions: []metav1.Condition{
+			{
+				Type:               PipelineConditionTypeReady,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: pipeline.Generation,
+				LastTransitionTime: metav1.Now(),
+				Reason:             reason,
+				Message:            fmt.Sprintf("Pipeline is %s: %s", strings.ToLower(schedule.state), schedule.reason),
+			},
+		},
+	}
+	for _, oldCond := range pipeline.Status.Conditions {
+		if oldCond.Type == PipelineConditionTypeReady && oldCond.Status == metav1.ConditionFalse {
+			newStatus.Conditions[0].LastTransitionTime = oldCond.LastTransitionTime
+		}
+	}
+	if pipelineStatusEqual(pipeline.Status, newStatus) {
+		return nil
+	}
+
+	pipelineNN := types.NamespacedName{Name: pipeline.Name, Namespace: pipeline.Namespace}
+	for attempt := 0; attempt < 5; attempt++ {
+		if err := r.Get(ctx, pipelineNN, pipeline); err != nil {
+			return fmt.Errorf("failed to re-fetch pipeline: %w", err)
+		}
+		newStatus.EnabledAt = pipeline.Status.EnabledAt
 		pipeline.Status = newStatus
 		if err := r.Status().Update(ctx, pipeline); err != nil {
 			if apierrors.IsConflict(err) {
@@ -2465,6 +2770,14 @@ func (r *ClusterReconciler) updatePipelineStatusWithError(ctx context.Context, p
 	return fmt.Errorf("failed to update pipeline status after retries: conflict")
 }
 
+func transitionTime(t time.Time) *metav1.Time {
+	if t.IsZero() {
+		return nil
+	}
+	mt := metav1.NewTime(t.Truncate(time.Second))
+	return &mt
+}
+
 // listPipelinesForCluster returns all enabled Pipelines that reference this Cluster
 func (r *ClusterReconciler) listPipelinesForCluster(ctx context.Context, cluster *gnmicv1alpha1.Cluster) ([]gnmicv1alpha1.Pipeline, error) {
 	var pipelineList gnmicv1alpha1.PipelineList
@@ -2515,6 +2828,17 @@ func (r *ClusterReconciler) buildConfigContent(cluster *gnmicv1alpha1.Cluster) (
 		"log": true,
 	}
 
+	// add gnmi-server configuration if configured, it serves the Get and Set
+	// requests of GetJobs and SetJobs and forwards them to the targets, so it
+	// only accepts clients presenting the controller certificate
+	if gnmic.GNMIServerEnabled(cluster) {
+		config["gnmi-server"] = map[string]any{
+			"address":        fmt.Sprintf(":%d", cluster.Spec.API.GNMIPort),
+			"enable-metrics": true,
+			"tls":            tlsConfig,
+		}
+	}
+
 	// add tunnel-server configuration if configured
 	if cluster.Spec.GRPCTunnel != nil && cluster.Spec.GRPCTunnel.Port != 0 {
 		tunnelConfig := map[string]any{
@@ -2562,7 +2886,7 @@ func (r *ClusterReconciler) resolveTargets(ctx context.Context, pipeline *gnmicv
 		var targetList gnmicv1alpha1.TargetList
 		selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %v", errInvalidSelector, err)
 		}
 		if err := r.List(ctx, &targetList, client.InNamespace(pipeline.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
 			return nil, err
@@ -2606,7 +2930,7 @@ func (r *ClusterReconciler) resolveSubscriptions(ctx context.Context, pipeline *
 		var subList gnmicv1alpha1.SubscriptionList
 		selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %v", errInvalidSelector, err)
 		}
 		if err := r.List(ctx, &subList, client.InNamespace(pipeline.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
 			return nil, err
@@ -2650,7 +2974,7 @@ func (r *ClusterReconciler) resolveOutputs(ctx context.Context, pipeline *gnmicv
 		var outputList gnmicv1alpha1.OutputList
 		selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %v", errInvalidSelector, err)
 		}
 		if err := r.List(ctx, &outputList, client.InNamespace(pipeline.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
 			return nil, err
@@ -2667,7 +2991,7 @@ func (r *ClusterReconciler) resolveOutputs(ctx context.Context, pipeline *gnmicv
 }
 
 // resolveOutputServiceAddresses resolves service addresses for outputs that support serviceRef or serviceSelector
-func (r *ClusterReconciler) resolveOutputServiceAddresses(ctx context.Context, output *gnmicv1alpha1.Output) ([]string, error) {
+func resolveOutputServiceAddresses(ctx context.Context, c client.Reader, output *gnmicv1alpha1.Output) ([]string, error) {
 	spec := &output.Spec
 
 	// skip if output type doesn't support service references
@@ -2690,7 +3014,7 @@ func (r *ClusterReconciler) resolveOutputServiceAddresses(ctx context.Context, o
 		}
 
 		var svc corev1.Service
-		if err := r.Get(ctx, types.NamespacedName{Name: spec.ServiceRef.Name, Namespace: namespace}, &svc); err != nil {
+		if err := c.Get(ctx, types.NamespacedName{Name: spec.ServiceRef.Name, Namespace: namespace}, &svc); err != nil {
 			return nil, fmt.Errorf("failed to get service %s/%s: %w", namespace, spec.ServiceRef.Name, err)
 		}
 
@@ -2725,7 +3049,7 @@ func (r *ClusterReconciler) resolveOutputServiceAddresses(ctx context.Context, o
 		}
 
 		var svcList corev1.ServiceList
-		if err := r.List(ctx, &svcList,
+		if err := c.List(ctx, &svcList,
 			client.InNamespace(namespace),
 			client.MatchingLabels(spec.ServiceSelector.MatchLabels),
 		); err != nil {
@@ -2757,6 +3081,11 @@ func (r *ClusterReconciler) resolveOutputServiceAddresses(ctx context.Context, o
 		}
 	}
 
+	// the webhook only admits a serviceRef for otlp outputs, selectors may
+	// have been set before
+	if spec.Type == gnmic.OTLPOutputType && len(resolved) > 1 {
+		return nil, fmt.Errorf("otlp outputs export to a single endpoint, but %d services resolved: use serviceRef", len(resolved))
+	}
 	return resolved, nil
 }
 
@@ -2788,7 +3117,7 @@ func (r *ClusterReconciler) resolveInputs(ctx context.Context, pipeline *gnmicv1
 		var inputList gnmicv1alpha1.InputList
 		selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %v", errInvalidSelector, err)
 		}
 		if err := r.List(ctx, &inputList, client.InNamespace(pipeline.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
 			return nil, err
@@ -2821,6 +3150,7 @@ func (r *ClusterReconciler) resolveOutputProcessors(ctx context.Context, pipelin
 				return nil, err
 			}
 			logger.Info("output processor not found, skipping", "ref", ref)
+			continue
 		}
 		refProcessors = append(refProcessors, processor)
 		inRefs[processor.Name] = struct{}{}
@@ -2835,7 +3165,7 @@ func (r *ClusterReconciler) resolveOutputProcessors(ctx context.Context, pipelin
 		var processorList gnmicv1alpha1.ProcessorList
 		selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %v", errInvalidSelector, err)
 		}
 		if err := r.List(ctx, &processorList, client.InNamespace(pipeline.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
 			return nil, err
@@ -2880,6 +3210,7 @@ func (r *ClusterReconciler) resolveInputProcessors(ctx context.Context, pipeline
 				return nil, err
 			}
 			logger.Info("input processor not found, skipping", "ref", ref)
+			continue
 		}
 		refProcessors = append(refProcessors, processor)
 		inRefs[processor.Name] = struct{}{}
@@ -2894,7 +3225,7 @@ func (r *ClusterReconciler) resolveInputProcessors(ctx context.Context, pipeline
 		var processorList gnmicv1alpha1.ProcessorList
 		selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %v", errInvalidSelector, err)
 		}
 		if err := r.List(ctx, &processorList, client.InNamespace(pipeline.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
 			return nil, err
@@ -2949,7 +3280,7 @@ func (r *ClusterReconciler) resolveTunnelTargetPolicies(ctx context.Context, pip
 		var policyList gnmicv1alpha1.TunnelTargetPolicyList
 		selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %v", errInvalidSelector, err)
 		}
 		if err := r.List(ctx, &policyList, client.InNamespace(pipeline.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
 			return nil, err
@@ -2965,7 +3296,9 @@ func (r *ClusterReconciler) resolveTunnelTargetPolicies(ctx context.Context, pip
 	return result, nil
 }
 
-func (r *ClusterReconciler) buildStatefulSet(cluster *gnmicv1alpha1.Cluster) (*appsv1.StatefulSet, *corev1.ConfigMap, error) {
+// targetTLS holds the projections of the CA bundles and client certificates
+// of the target profiles in use, see resolveTargetTLS.
+func (r *ClusterReconciler) buildStatefulSet(cluster *gnmicv1alpha1.Cluster, targetTLS []corev1.VolumeProjection) (*appsv1.StatefulSet, *corev1.ConfigMap, error) {
 	// build gNMIc pod base configuration
 	configMap, err := r.buildConfigMap(cluster)
 	if err != nil {
@@ -3288,6 +3621,23 @@ func (r *ClusterReconciler) buildStatefulSet(cluster *gnmicv1alpha1.Cluster) (*a
 		}
 	}
 
+	// add the CA bundles and client certificates of the target profiles
+	if len(targetTLS) > 0 {
+		volumes = append(volumes, corev1.Volume{
+			Name: targetTLSVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Projected: &corev1.ProjectedVolumeSource{
+					Sources: targetTLS,
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      targetTLSVolumeName,
+			MountPath: gnmic.TargetTLSMountPath,
+			ReadOnly:  true,
+		})
+	}
+
 	return &appsv1.StatefulSet{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      stsName,
@@ -3343,7 +3693,7 @@ func buildContainerPorts(cluster *gnmicv1alpha1.Cluster) []corev1.ContainerPort
 			ContainerPort: restPort,
 		},
 	}
-	if cluster.Spec.API != nil && cluster.Spec.API.GNMIPort != 0 {
+	if gnmic.GNMIServerEnabled(cluster) {
 		ports = append(ports, corev1.ContainerPort{
 			Name:          "gnmi",
 			ContainerPort: cluster.Spec.API.GNMIPort,
@@ -3425,7 +3775,7 @@ func (r *ClusterReconciler) buildHeadlessService(cluster *gnmicv1alpha1.Cluster)
 			Protocol: corev1.ProtocolTCP,
 		},
 	}
-	if cluster.Spec.API != nil && cluster.Spec.API.GNMIPort != 0 {
+	if gnmic.GNMIServerEnabled(cluster) {
 		ports = append(ports, corev1.ServicePort{
 			Name:     "gnmi",
 			Port:     cluster.Spec.API.GNMIPort,
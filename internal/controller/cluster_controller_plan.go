@@ -1,9 +1,15 @@
 package controller
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
 	"github.com/gnmic/operator/internal/gnmic"
 )
 
@@ -16,6 +22,15 @@ func NewClusterReconcilerForTest() *ClusterReconciler {
 	}
 }
 
+// NewClusterReconcilerForTestWithClient is like NewClusterReconcilerForTest but
+// backed by a client, for tests of components that read the plan history.
+func NewClusterReconcilerForTestWithClient(c client.Client, scheme *runtime.Scheme) *ClusterReconciler {
+	r := NewClusterReconcilerForTest()
+	r.Client = c
+	r.Scheme = scheme
+	return r
+}
+
 // CachePlan stores an apply plan in the reconciler's in-memory cache.
 func (r *ClusterReconciler) CachePlan(namespace, name string, plan *gnmic.ApplyPlan) {
 	r.m.Lock()
@@ -37,9 +52,119 @@ func (r *ClusterReconciler) GetClusterPlan(namespace, name string) (*gnmic.Apply
 func (r *ClusterReconciler) cleanupPlan(namespace, name string) {
 	r.m.Lock()
 	delete(r.plans, namespace+"/"+name)
+	delete(r.rollouts, namespace+"/"+name)
+	delete(r.rolledOut, namespace+"/"+name)
 	r.m.Unlock()
 	// Per-pod apply records go with the plan, so a deleted cluster does not
 	// leave entries behind, and a cluster recreated under the same name starts
 	// from no assumptions about what its pods hold.
 	r.Applied.InvalidateCluster(namespace, name)
 }
+
+// ListPlanRevisions returns the plan history of a cluster, oldest first.
+// The plans themselves are not included.
+func (r *ClusterReconciler) ListPlanRevisions(ctx context.Context, namespace, name string) ([]PlanRevision, error) {
+	var cluster gnmicv1alpha1.Cluster
+	if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &cluster); err != nil {
+		return nil, err
+	}
+	history, err := r.listPlanHistory(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	revisions := make([]PlanRevision, 0, len(history))
+	for i := range history {
+		rev, err := decodePlanRevision(&history[i], false)
+		if err != nil {
+			return nil, err
+		}
+		markPlanRevision(&cluster, rev)
+		revisions = append(revisions, *rev)
+	}
+	return revisions, nil
+}
+
+// GetPlanRevision returns a single revision, including its plan, from a cluster's history.
+func (r *ClusterReconciler) GetPlanRevision(ctx context.Context, namespace, name string, revision int64) (*PlanRevision, error) {
+	var cluster gnmicv1alpha1.Cluster
+	if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &cluster); err != nil {
+		return nil, err
+	}
+	rev, err := r.getPlanRevision(ctx, namespace, name, revision)
+	if err != nil {
+		return nil, err
+	}
+	markPlanRevision(&cluster, rev)
+	return rev, nil
+}
+
+// DiffPlanRevisions compares two revisions of a cluster's plan history.
+// A from revision of 0 compares against the revision preceding to in the
+// history, or against an empty plan if there is none.
+func (r *ClusterReconciler) DiffPlanRevisions(ctx context.Context, namespace, name string, from, to int64) (*PlanRevisionDiff, error) {
+	toRev, err := r.getPlanRevision(ctx, namespace, name, to)
+	if err != nil {
+		return nil, err
+	}
+	if from == 0 {
+		history, err := r.listPlanHistory(ctx, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		for i := range history {
+			if rev := configMapPlanRevision(&history[i]); rev < to {
+				from = rev
+			}
+		}
+	}
+	var fromPlan *gnmic.ApplyPlan
+	if from != 0 {
+		fromRev, err := r.getPlanRevision(ctx, namespace, name, from)
+		if err != nil {
+			return nil, err
+		}
+		fromPlan = fromRev.Plan
+	}
+	return &PlanRevisionDiff{
+		From: from,
+		To:   to,
+		Diff: gnmic.DiffPlans(fromPlan, toRev.Plan),
+	}, nil
+}
+
+// PinPlanRevision pins a cluster to a revision of its plan history: the
+// revision's plan is applied to the cluster's pods until the pin is released.
+func (r *ClusterReconciler) PinPlanRevision(ctx context.Context, namespace, name string, revision int64) error {
+	if _, err := r.getPlanRevision(ctx, namespace, name, revision); err != nil {
+		return err
+	}
+	return r.patchPlanHistory(ctx, namespace, name, func(ph *gnmicv1alpha1.PlanHistoryConfig) {
+		ph.PinnedRevision = revision
+	})
+}
+
+// ReleasePlanPin releases a cluster's pin, returning it to the plan built
+// from its current pipelines.
+func (r *ClusterReconciler) ReleasePlanPin(ctx context.Context, namespace, name string) error {
+	return r.patchPlanHistory(ctx, namespace, name, func(ph *gnmicv1alpha1.PlanHistoryConfig) {
+		ph.PinnedRevision = 0
+	})
+}
+
+func (r *ClusterReconciler) patchPlanHistory(ctx context.Context, namespace, name string, mutate func(*gnmicv1alpha1.PlanHistoryConfig)) error {
+	var cluster gnmicv1alpha1.Cluster
+	if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &cluster); err != nil {
+		return err
+	}
+	patch := client.MergeFrom(cluster.DeepCopy())
+	if cluster.Spec.PlanHistory == nil {
+		cluster.Spec.PlanHistory = &gnmicv1alpha1.PlanHistoryConfig{}
+	}
+	mutate(cluster.Spec.PlanHistory)
+	return r.Patch(ctx, &cluster, patch)
+}
+
+func markPlanRevision(cluster *gnmicv1alpha1.Cluster, rev *PlanRevision) {
+	rev.Pinned = rev.Revision == pinnedPlanRevision(cluster)
+	rev.Current = rev.Revision == cluster.Status.PlanRevision
+}
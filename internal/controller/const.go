@@ -23,6 +23,15 @@ const (
 	LabelValueCertTypeTunnel = "tunnel"
 
 	LabelTargetSourceFinalizer = "operator.gnmic.dev/targetsource-finalizer"
+
+	LabelPlanRevision = "operator.gnmic.dev/plan-revision"
+)
+
+const (
+	// Annotations
+	AnnotationPlanClusterGeneration = "operator.gnmic.dev/cluster-generation"
+	AnnotationPlanFingerprint       = "operator.gnmic.dev/plan-fingerprint"
+	AnnotationPlanCauses            = "operator.gnmic.dev/plan-causes"
 )
 
 const (
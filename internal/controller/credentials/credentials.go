@@ -0,0 +1,131 @@
+// Package credentials reads the credentials of the targets of a TargetProfile
+// from the backend it selects: a Kubernetes Secret, HashiCorp Vault or files
+// rendered by a CSI secrets-store volume.
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+const (
+	defaultUsernameKey = "username"
+	defaultPasswordKey = "password"
+	defaultTokenKey    = "token"
+)
+
+// ErrNoCredentials is returned when none of the credential keys is found
+var ErrNoCredentials = errors.New("none of the credential keys found")
+
+// SecretSource returns the Secret the credentials of a profile are read from,
+// including the legacy credentialsRef. It returns nil for the other backends
+// and for profiles without credentials.
+func SecretSource(profile *gnmicv1alpha1.TargetProfileSpec) *gnmicv1alpha1.SecretCredentials {
+	if profile.CredentialsRef != "" {
+		return &gnmicv1alpha1.SecretCredentials{Name: profile.CredentialsRef}
+	}
+	if profile.Credentials != nil {
+		return profile.Credentials.Secret
+	}
+	return nil
+}
+
+// HasCredentials reports whether a profile sets credentials
+func HasCredentials(profile *gnmicv1alpha1.TargetProfileSpec) bool {
+	return profile.CredentialsRef != "" || profile.Credentials != nil
+}
+
+// ReferencesSecret reports whether the credentials of a profile are read from
+// the Secret, or from Vault with a token read from it.
+func ReferencesSecret(profile *gnmicv1alpha1.TargetProfileSpec, name string) bool {
+	if src := SecretSource(profile); src != nil {
+		return src.Name == name
+	}
+	if profile.Credentials == nil || profile.Credentials.Vault == nil {
+		return false
+	}
+	ref := profile.Credentials.Vault.Auth.TokenSecretRef
+	return ref != nil && ref.Name == name
+}
+
+// ReadSecret reads credentials from a Kubernetes Secret. Missing keys are left
+// empty, as for the legacy credentialsRef.
+func ReadSecret(ctx context.Context, reader client.Reader, namespace string, src *gnmicv1alpha1.SecretCredentials) (*gnmic.Credentials, error) {
+	var secret corev1.Secret
+	if err := reader.Get(ctx, types.NamespacedName{Name: src.Name, Namespace: namespace}, &secret); err != nil {
+		return nil, err
+	}
+	creds, _ := fromValues(src.CredentialKeys, func(key string) (string, bool) {
+		v, ok := secret.Data[key]
+		return string(v), ok
+	})
+	return creds, nil
+}
+
+// fromValues maps the values looked up by key onto credentials. It returns
+// false when none of the keys is found.
+func fromValues(keys gnmicv1alpha1.CredentialKeys, lookup func(key string) (string, bool)) (*gnmic.Credentials, bool) {
+	creds := &gnmic.Credentials{}
+	found := false
+	for _, field := range []struct {
+		key, defaultKey string
+		value           *string
+	}{
+		{keys.UsernameKey, defaultUsernameKey, &creds.Username},
+		{keys.PasswordKey, defaultPasswordKey, &creds.Password},
+		{keys.TokenKey, defaultTokenKey, &creds.Token},
+	} {
+		key := field.key
+		if key == "" {
+			key = field.defaultKey
+		}
+		if v, ok := lookup(key); ok {
+			*field.value = v
+			found = true
+		}
+	}
+	return creds, found
+}
+
+// sourceKind names the backend of a source, for errors and logs
+func sourceKind(src *gnmicv1alpha1.CredentialsSource) string {
+	switch {
+	case src.Vault != nil:
+		return "Vault"
+	case src.File != nil:
+		return "File"
+	case src.Secret != nil:
+		return "Secret"
+	}
+	return ""
+}
+
+// SourceName returns the kind and name identifying the source of a profile's
+// credentials in status messages, e.g. Vault and the secret path.
+func SourceName(profile *gnmicv1alpha1.TargetProfileSpec) (string, string) {
+	if src := SecretSource(profile); src != nil {
+		return "Secret", src.Name
+	}
+	if profile.Credentials == nil {
+		return "", ""
+	}
+	switch {
+	case profile.Credentials.Vault != nil:
+		return sourceKind(profile.Credentials), profile.Credentials.Vault.Path
+	case profile.Credentials.File != nil:
+		return sourceKind(profile.Credentials), profile.Credentials.File.Path
+	}
+	return "", ""
+}
+
+func notFound(kind, name string) error {
+	return fmt.Errorf("%s %q: %w", kind, name, ErrNoCredentials)
+}
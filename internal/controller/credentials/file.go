@@ -0,0 +1,49 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+// readFiles reads the credentials from one file per key in a directory below
+// baseDir. Profiles cannot read anything outside of baseDir: it is the only
+// place of the operator pod meant to hold device credentials.
+func readFiles(baseDir string, src *gnmicv1alpha1.FileCredentials) (*gnmic.Credentials, error) {
+	if baseDir == "" {
+		return nil, errors.New("file credentials are disabled, the operator has no credentials directory")
+	}
+	if !filepath.IsLocal(src.Path) {
+		return nil, fmt.Errorf("path %q is not below the credentials directory", src.Path)
+	}
+	dir := filepath.Join(baseDir, src.Path)
+	var readErr error
+	creds, found := fromValues(src.CredentialKeys, func(key string) (string, bool) {
+		if !filepath.IsLocal(key) {
+			readErr = fmt.Errorf("key %q is not a file name", key)
+			return "", false
+		}
+		b, err := os.ReadFile(filepath.Join(dir, key))
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) && readErr == nil {
+				readErr = err
+			}
+			return "", false
+		}
+		// files rendered by tools commonly end with a newline
+		return strings.TrimRight(string(b), "\r\n"), true
+	})
+	if readErr != nil {
+		return nil, readErr
+	}
+	if !found {
+		return nil, notFound("directory", src.Path)
+	}
+	return creds, nil
+}
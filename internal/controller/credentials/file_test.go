@@ -0,0 +1,60 @@
+package credentials
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+func writeCredentialFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadFiles(t *testing.T) {
+	base := t.TempDir()
+	writeCredentialFiles(t, filepath.Join(base, "arista"), map[string]string{"username": "admin\n", "password": "secret\r\n"})
+
+	creds, err := readFiles(base, &gnmicv1alpha1.FileCredentials{Path: "arista"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *creds != (gnmic.Credentials{Username: "admin", Password: "secret"}) {
+		t.Fatalf("creds = %+v", creds)
+	}
+
+	tests := []struct {
+		name    string
+		baseDir string
+		src     gnmicv1alpha1.FileCredentials
+	}{
+		{name: "disabled", src: gnmicv1alpha1.FileCredentials{Path: "arista"}},
+		{name: "path outside", baseDir: base, src: gnmicv1alpha1.FileCredentials{Path: "../etc"}},
+		{name: "absolute path", baseDir: base, src: gnmicv1alpha1.FileCredentials{Path: "/etc"}},
+		{name: "key outside", baseDir: base, src: gnmicv1alpha1.FileCredentials{
+			Path: "arista", CredentialKeys: gnmicv1alpha1.CredentialKeys{PasswordKey: "../../shadow"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := readFiles(tt.baseDir, &tt.src); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+
+	if _, err := readFiles(base, &gnmicv1alpha1.FileCredentials{Path: "nokia"}); !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("missing directory: err %v, want ErrNoCredentials", err)
+	}
+}
@@ -0,0 +1,266 @@
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+const (
+	defaultVaultRefreshInterval = 5 * time.Minute
+	// DefaultFileRefreshInterval is how often files are read again. The CSI
+	// secrets-store driver rotates them every 2 minutes by default.
+	DefaultFileRefreshInterval = time.Minute
+	// refreshRetryInterval is how soon a failed refresh is retried
+	refreshRetryInterval = 30 * time.Second
+	// refreshTick is how often the store looks for credentials to refresh
+	refreshTick       = 10 * time.Second
+	changesBufferSize = 100
+)
+
+// Store caches the credentials read from Vault and files, and refreshes them
+// in the background. Reconciles read the cached value, so thousands of
+// targets and repeated reconciles cost no backend round trip, and a dynamic
+// Vault secret is not renewed by every reconcile.
+//
+// When a refresh returns different credentials, the TargetProfiles using them
+// are sent on Changes: only the clusters collecting with those profiles are
+// reconciled, each once, instead of every cluster on a timer.
+//
+// Kubernetes Secrets are not cached, they are read from the informer cache and
+// their changes are already watched.
+type Store struct {
+	client client.Client
+	// filesDir is the directory file credentials are read from
+	filesDir    string
+	fileRefresh time.Duration
+	vault       *vaultClient
+	now         func() time.Time
+	changes     chan event.GenericEvent
+
+	m       sync.Mutex
+	entries map[string]*entry
+}
+
+type entry struct {
+	namespace string
+	source    gnmicv1alpha1.CredentialsSource
+	creds     *gnmic.Credentials
+	refreshAt time.Time
+	// profiles using the credentials, by name
+	profiles map[string]struct{}
+}
+
+// NewStore creates a Store reading file credentials below filesDir. File
+// credentials are disabled when filesDir is empty. vault restricts the Vault
+// servers the tokens are sent to.
+func NewStore(c client.Client, filesDir string, vault VaultOptions) *Store {
+	now := time.Now
+	return &Store{
+		client:      c,
+		filesDir:    filesDir,
+		fileRefresh: DefaultFileRefreshInterval,
+		vault:       newVaultClient(c, now, vault),
+		now:         now,
+		changes:     make(chan event.GenericEvent, changesBufferSize),
+		entries:     make(map[string]*entry),
+	}
+}
+
+// Changes returns the channel the TargetProfiles whose credentials changed
+// are sent on
+func (s *Store) Changes() <-chan event.GenericEvent {
+	return s.changes
+}
+
+// Fetch returns the credentials of a TargetProfile. The credentials of Vault
+// and files are cached until their refresh; when a refresh fails, the last
+// credentials read keep being returned.
+func (s *Store) Fetch(ctx context.Context, namespace, profileName string, profile *gnmicv1alpha1.TargetProfileSpec) (*gnmic.Credentials, error) {
+	if src := SecretSource(profile); src != nil {
+		return ReadSecret(ctx, s.client, namespace, src)
+	}
+	if profile.Credentials == nil {
+		return nil, nil
+	}
+
+	key, err := entryKey(namespace, profile.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	s.m.Lock()
+	e, ok := s.entries[key]
+	if ok {
+		e.profiles[profileName] = struct{}{}
+		creds := e.creds
+		s.m.Unlock()
+		return creds, nil
+	}
+	s.m.Unlock()
+
+	creds, refreshIn, err := s.read(ctx, namespace, profile.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	if e, ok := s.entries[key]; ok {
+		// read concurrently by another reconcile
+		e.profiles[profileName] = struct{}{}
+		return e.creds, nil
+	}
+	s.entries[key] = &entry{
+		namespace: namespace,
+		source:    *profile.Credentials.DeepCopy(),
+		creds:     creds,
+		refreshAt: s.now().Add(refreshIn),
+		profiles:  map[string]struct{}{profileName: {}},
+	}
+	return creds, nil
+}
+
+// read reads credentials from their backend, returning when to read them again
+func (s *Store) read(ctx context.Context, namespace string, src *gnmicv1alpha1.CredentialsSource) (*gnmic.Credentials, time.Duration, error) {
+	switch {
+	case src.Vault != nil:
+		creds, lease, err := s.vault.read(ctx, namespace, src.Vault)
+		if err != nil {
+			return nil, 0, err
+		}
+		if lease > 0 {
+			return creds, lease * 2 / 3, nil
+		}
+		if src.Vault.RefreshInterval != nil && src.Vault.RefreshInterval.Duration > 0 {
+			return creds, src.Vault.RefreshInterval.Duration, nil
+		}
+		return creds, defaultVaultRefreshInterval, nil
+	case src.File != nil:
+		creds, err := readFiles(s.filesDir, src.File)
+		return creds, s.fileRefresh, err
+	case src.Secret != nil:
+		creds, err := ReadSecret(ctx, s.client, namespace, src.Secret)
+		return creds, 0, err
+	}
+	return nil, 0, errors.New("no credentials backend")
+}
+
+// Start refreshes the cached credentials until the context is done
+func (s *Store) Start(ctx context.Context) error {
+	ticker := time.NewTicker(refreshTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			s.refresh(ctx)
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable: only the
+// leader reconciles, so only the leader refreshes.
+func (s *Store) NeedLeaderElection() bool {
+	return true
+}
+
+// refresh reads again the credentials due for a refresh, and sends the
+// profiles using them when they changed
+func (s *Store) refresh(ctx context.Context) {
+	logger := log.FromContext(ctx).WithName("credentials")
+	now := s.now()
+	s.m.Lock()
+	due := make(map[string]*entry)
+	for key, e := range s.entries {
+		if !now.Before(e.refreshAt) {
+			due[key] = e
+		}
+	}
+	s.m.Unlock()
+
+	for key, e := range due {
+		profiles := s.usingProfiles(ctx, e)
+		if len(profiles) == 0 {
+			s.m.Lock()
+			delete(s.entries, key)
+			s.m.Unlock()
+			continue
+		}
+		creds, refreshIn, err := s.read(ctx, e.namespace, &e.source)
+		s.m.Lock()
+		e.profiles = profiles
+		if err != nil {
+			// keep the last credentials: targets keep collecting while the
+			// backend is unavailable
+			e.refreshAt = s.now().Add(refreshRetryInterval)
+			s.m.Unlock()
+			logger.Error(err, "failed to refresh credentials", "namespace", e.namespace, "backend", sourceKind(&e.source))
+			continue
+		}
+		changed := *creds != *e.creds
+		e.creds = creds
+		e.refreshAt = s.now().Add(refreshIn)
+		s.m.Unlock()
+		if !changed {
+			continue
+		}
+		logger.Info("credentials changed", "namespace", e.namespace, "backend", sourceKind(&e.source), "profiles", len(profiles))
+		for name := range profiles {
+			profile := &gnmicv1alpha1.TargetProfile{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: e.namespace}}
+			select {
+			case s.changes <- event.GenericEvent{Object: profile}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// usingProfiles returns the profiles of an entry still reading their
+// credentials from its source
+func (s *Store) usingProfiles(ctx context.Context, e *entry) map[string]struct{} {
+	s.m.Lock()
+	names := make([]string, 0, len(e.profiles))
+	for name := range e.profiles {
+		names = append(names, name)
+	}
+	s.m.Unlock()
+
+	profiles := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		var profile gnmicv1alpha1.TargetProfile
+		err := s.client.Get(ctx, types.NamespacedName{Name: name, Namespace: e.namespace}, &profile)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		// keep the profile on other errors, it is checked again next refresh
+		if err == nil && (profile.Spec.Credentials == nil || !equality.Semantic.DeepEqual(*profile.Spec.Credentials, e.source)) {
+			continue
+		}
+		profiles[name] = struct{}{}
+	}
+	return profiles
+}
+
+// entryKey identifies the credentials read from the same source
+func entryKey(namespace string, src *gnmicv1alpha1.CredentialsSource) (string, error) {
+	b, err := json.Marshal(src)
+	if err != nil {
+		return "", fmt.Errorf("credentials source: %w", err)
+	}
+	return namespace + gnmic.Delimiter + string(b), nil
+}
@@ -0,0 +1,126 @@
+package credentials
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+)
+
+func fileProfile(name, path string) *gnmicv1alpha1.TargetProfile {
+	return &gnmicv1alpha1.TargetProfile{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: gnmicv1alpha1.TargetProfileSpec{Credentials: &gnmicv1alpha1.CredentialsSource{
+			File: &gnmicv1alpha1.FileCredentials{Path: path},
+		}},
+	}
+}
+
+func testStore(t *testing.T, filesDir string, objs ...client.Object) (*Store, *time.Time) {
+	t.Helper()
+	s := NewStore(fakeClient(t, objs...), filesDir, VaultOptions{})
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func TestStore_RefreshSendsChangedProfiles(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(base, "arista")
+	writeCredentialFiles(t, dir, map[string]string{"username": "admin", "password": "old"})
+	arista, spine := fileProfile("arista", "arista"), fileProfile("spine", "arista")
+	s, now := testStore(t, base, arista, spine, fileProfile("other", "nokia"))
+	ctx := t.Context()
+
+	for _, p := range []*gnmicv1alpha1.TargetProfile{arista, spine} {
+		creds, err := s.Fetch(ctx, "default", p.Name, &p.Spec)
+		if err != nil || creds.Password != "old" {
+			t.Fatalf("%s: creds %+v, err %v", p.Name, creds, err)
+		}
+	}
+	if len(s.entries) != 1 {
+		t.Fatalf("%d entries, want the profiles sharing the source cached once", len(s.entries))
+	}
+
+	// the value is cached until the refresh is due
+	writeCredentialFiles(t, dir, map[string]string{"password": "new"})
+	if creds, _ := s.Fetch(ctx, "default", "arista", &arista.Spec); creds.Password != "old" {
+		t.Fatalf("password %q read before the refresh", creds.Password)
+	}
+	s.refresh(ctx)
+	if len(s.changes) != 0 {
+		t.Fatal("change sent before the refresh was due")
+	}
+
+	*now = now.Add(DefaultFileRefreshInterval)
+	s.refresh(ctx)
+	if creds, _ := s.Fetch(ctx, "default", "arista", &arista.Spec); creds.Password != "new" {
+		t.Fatalf("password %q after the refresh, want new", creds.Password)
+	}
+	got := map[string]bool{}
+	for len(s.changes) > 0 {
+		ev := <-s.changes
+		got[ev.Object.GetName()] = true
+	}
+	if len(got) != 2 || !got["arista"] || !got["spine"] {
+		t.Fatalf("changed profiles = %v, want arista and spine", got)
+	}
+
+	// an unchanged value sends nothing
+	*now = now.Add(DefaultFileRefreshInterval)
+	s.refresh(ctx)
+	if len(s.changes) != 0 {
+		t.Fatal("change sent for unchanged credentials")
+	}
+}
+
+func TestStore_KeepsCredentialsWhenRefreshFails(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(base, "arista")
+	writeCredentialFiles(t, dir, map[string]string{"password": "old"})
+	arista := fileProfile("arista", "arista")
+	s, now := testStore(t, base, arista)
+	ctx := t.Context()
+
+	if _, err := s.Fetch(ctx, "default", "arista", &arista.Spec); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	*now = now.Add(DefaultFileRefreshInterval)
+	s.refresh(ctx)
+	creds, err := s.Fetch(ctx, "default", "arista", &arista.Spec)
+	if err != nil || creds.Password != "old" {
+		t.Fatalf("creds %+v, err %v; want the last credentials read", creds, err)
+	}
+
+	// credentials never read are an error
+	nokia := fileProfile("nokia", "nokia")
+	if _, err := s.Fetch(ctx, "default", "nokia", &nokia.Spec); !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("err = %v, want ErrNoCredentials", err)
+	}
+}
+
+func TestStore_DropsUnusedCredentials(t *testing.T) {
+	base := t.TempDir()
+	writeCredentialFiles(t, filepath.Join(base, "arista"), map[string]string{"password": "old"})
+	// the profile now reads another directory: the cached source is unused
+	arista := fileProfile("arista", "arista")
+	s, now := testStore(t, base, fileProfile("arista", "moved"))
+
+	if _, err := s.Fetch(t.Context(), "default", "arista", &arista.Spec); err != nil {
+		t.Fatal(err)
+	}
+	*now = now.Add(DefaultFileRefreshInterval)
+	s.refresh(t.Context())
+	if len(s.entries) != 0 || len(s.changes) != 0 {
+		t.Fatalf("%d entries, %d changes; want the unused credentials dropped", len(s.entries), len(s.changes))
+	}
+}
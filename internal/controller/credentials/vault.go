@@ -0,0 +1,329 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+const (
+	vaultRequestTimeout = 10 * time.Second
+	// vaultServiceAccountTokenTTL is the lifetime of the ServiceAccount tokens
+	// requested to log in, only used for the login itself
+	vaultServiceAccountTokenTTL = 10 * time.Minute
+	defaultVaultAuthMountPath   = "kubernetes"
+	// DefaultVaultAudience is the audience of the ServiceAccount tokens the
+	// operator logs in to Vault with, unless --vault-audience sets another
+	DefaultVaultAudience = "vault"
+	// VaultServiceAccountName is the only ServiceAccount the operator requests
+	// tokens for, in the namespace of the TargetProfile. Its RBAC grant is
+	// restricted to this name.
+	VaultServiceAccountName = "gnmic-vault-auth"
+)
+
+// VaultOptions restricts the Vault servers the operator sends tokens to. The
+// tokens of the Kubernetes auth method prove the identity of a
+// ServiceAccount, and the tokens read from Secrets grant access to Vault, so
+// they are only sent to the Vault servers the operator owner allows. The
+// ServiceAccount tokens have the audience the owner sets.
+type VaultOptions struct {
+	// Addresses of the Vault servers the operator reads secrets from, e.g.
+	// https://vault.vault.svc:8200. Vault credentials are disabled when
+	// empty.
+	Addresses []string
+	// Audience of the ServiceAccount tokens, DefaultVaultAudience when empty
+	Audience string
+}
+
+// errVaultForbidden is returned when Vault rejects the token, which is then
+// dropped so that the next read logs in again
+var errVaultForbidden = errors.New("permission denied")
+
+// vaultClient reads secrets from Vault. Tokens obtained with the Kubernetes
+// auth method are kept until two thirds of their lease elapsed.
+type vaultClient struct {
+	client    client.Client
+	now       func() time.Time
+	addresses map[string]struct{}
+	audience  string
+
+	m      sync.Mutex
+	tokens map[string]vaultToken
+	// HTTP clients by TLS configuration, so that their connections are reused
+	httpClients map[string]vaultHTTPClient
+}
+
+type vaultHTTPClient struct {
+	client *http.Client
+	// caBundle the client verifies Vault with
+	caBundle string
+}
+
+type vaultToken struct {
+	token   string
+	renewAt time.Time
+}
+
+// vaultResponse is the part of the Vault responses the operator reads
+type vaultResponse struct {
+	LeaseDuration int                        `json:"lease_duration"`
+	Data          map[string]json.RawMessage `json:"data"`
+	Auth          *struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+	} `json:"auth"`
+	Errors []string `json:"errors"`
+}
+
+func newVaultClient(c client.Client, now func() time.Time, opts VaultOptions) *vaultClient {
+	v := &vaultClient{
+		client:      c,
+		now:         now,
+		addresses:   make(map[string]struct{}, len(opts.Addresses)),
+		audience:    opts.Audience,
+		tokens:      make(map[string]vaultToken),
+		httpClients: make(map[string]vaultHTTPClient),
+	}
+	if v.audience == "" {
+		v.audience = DefaultVaultAudience
+	}
+	for _, address := range opts.Addresses {
+		if address = normalizeVaultAddress(address); address != "" {
+			v.addresses[address] = struct{}{}
+		}
+	}
+	return v
+}
+
+// normalizeVaultAddress returns the scheme, host and path of an address in
+// lower case and without trailing slash, or "" if it is not a URL
+func normalizeVaultAddress(address string) string {
+	u, err := url.Parse(strings.TrimSpace(address))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return ""
+	}
+	return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host) + strings.TrimRight(u.Path, "/")
+}
+
+// read returns the credentials held by a Vault secret and its lease duration,
+// zero for secrets without lease
+func (v *vaultClient) read(ctx context.Context, namespace string, src *gnmicv1alpha1.VaultCredentials) (*gnmic.Credentials, time.Duration, error) {
+	httpClient, err := v.httpClient(ctx, namespace, src.TLS)
+	if err != nil {
+		return nil, 0, err
+	}
+	token, err := v.token(ctx, httpClient, namespace, src)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := v.do(ctx, httpClient, http.MethodGet, src, src.Path, token, nil)
+	if errors.Is(err, errVaultForbidden) && src.Auth.Kubernetes != nil {
+		// the token may have been revoked before its lease ended
+		v.m.Lock()
+		delete(v.tokens, v.loginKey(namespace, src))
+		v.m.Unlock()
+		if token, err = v.token(ctx, httpClient, namespace, src); err != nil {
+			return nil, 0, err
+		}
+		resp, err = v.do(ctx, httpClient, http.MethodGet, src, src.Path, token, nil)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	data := resp.Data
+	// KV version 2 nests the secret under data, next to its metadata
+	if raw, ok := data["data"]; ok {
+		if _, versioned := data["metadata"]; versioned {
+			data = nil
+			if err := json.Unmarshal(raw, &data); err != nil {
+				return nil, 0, fmt.Errorf("vault secret %q: %w", src.Path, err)
+			}
+		}
+	}
+	creds, found := fromValues(src.CredentialKeys, func(key string) (string, bool) {
+		raw, ok := data[key]
+		if !ok {
+			return "", false
+		}
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			// numbers and booleans are used as written
+			return string(raw), true
+		}
+		return s, true
+	})
+	if !found {
+		return nil, 0, notFound("vault secret", src.Path)
+	}
+	return creds, time.Duration(resp.LeaseDuration) * time.Second, nil
+}
+
+// token returns the Vault token to read the secret with. Tokens are only
+// sent to the allowed Vault servers, whatever the auth method.
+func (v *vaultClient) token(ctx context.Context, httpClient *http.Client, namespace string, src *gnmicv1alpha1.VaultCredentials) (string, error) {
+	if _, ok := v.addresses[normalizeVaultAddress(src.Address)]; !ok {
+		return "", fmt.Errorf("vault address %q is not allowed, see --vault-addresses", src.Address)
+	}
+	if ref := src.Auth.TokenSecretRef; ref != nil {
+		var secret corev1.Secret
+		if err := v.client.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, &secret); err != nil {
+			return "", fmt.Errorf("vault token: %w", err)
+		}
+		token, ok := secret.Data[ref.Key]
+		if !ok {
+			return "", fmt.Errorf("vault token: key %q not found in Secret %q", ref.Key, ref.Name)
+		}
+		return strings.TrimSpace(string(token)), nil
+	}
+	auth := src.Auth.Kubernetes
+	if auth == nil {
+		return "", errors.New("no vault auth method")
+	}
+
+	key := v.loginKey(namespace, src)
+	v.m.Lock()
+	cached, ok := v.tokens[key]
+	v.m.Unlock()
+	if ok && v.now().Before(cached.renewAt) {
+		return cached.token, nil
+	}
+
+	tokenRequest := &authenticationv1.TokenRequest{Spec: authenticationv1.TokenRequestSpec{
+		Audiences:         []string{v.audience},
+		ExpirationSeconds: ptr.To(int64(vaultServiceAccountTokenTTL / time.Second)),
+	}}
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: VaultServiceAccountName, Namespace: namespace}}
+	if err := v.client.SubResource("token").Create(ctx, sa, tokenRequest); err != nil {
+		return "", fmt.Errorf("token of ServiceAccount %q: %w", VaultServiceAccountName, err)
+	}
+
+	mountPath := auth.MountPath
+	if mountPath == "" {
+		mountPath = defaultVaultAuthMountPath
+	}
+	body, err := json.Marshal(map[string]string{"role": auth.Role, "jwt": tokenRequest.Status.Token})
+	if err != nil {
+		return "", err
+	}
+	resp, err := v.do(ctx, httpClient, http.MethodPost, src, "auth/"+strings.Trim(mountPath, "/")+"/login", "", body)
+	if err != nil {
+		return "", fmt.Errorf("vault login: %w", err)
+	}
+	if resp.Auth == nil || resp.Auth.ClientToken == "" {
+		return "", errors.New("vault login: no client token")
+	}
+	lease := time.Duration(resp.Auth.LeaseDuration) * time.Second
+	v.m.Lock()
+	v.tokens[key] = vaultToken{token: resp.Auth.ClientToken, renewAt: v.now().Add(lease * 2 / 3)}
+	v.m.Unlock()
+	return resp.Auth.ClientToken, nil
+}
+
+// loginKey identifies the tokens obtained with the same login
+func (v *vaultClient) loginKey(namespace string, src *gnmicv1alpha1.VaultCredentials) string {
+	auth := src.Auth.Kubernetes
+	return strings.Join([]string{src.Address, src.Namespace, auth.MountPath, auth.Role, namespace}, "|")
+}
+
+func (v *vaultClient) do(ctx context.Context, httpClient *http.Client, method string, src *gnmicv1alpha1.VaultCredentials, path, token string, body []byte) (*vaultResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, vaultRequestTimeout)
+	defer cancel()
+	url := strings.TrimRight(src.Address, "/") + "/v1/" + strings.TrimLeft(path, "/")
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if src.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", src.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	httpResp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(httpResp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	resp := &vaultResponse{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, resp); err != nil {
+			return nil, fmt.Errorf("vault %s %s: %w", method, path, err)
+		}
+	}
+	switch {
+	case httpResp.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("vault %s %s: %w", method, path, errVaultForbidden)
+	case httpResp.StatusCode >= 300:
+		return nil, fmt.Errorf("vault %s %s: status %d: %s", method, path, httpResp.StatusCode, strings.Join(resp.Errors, "; "))
+	}
+	return resp, nil
+}
+
+// httpClient returns a client verifying Vault with the configured CA bundle.
+// Clients are cached by TLS configuration, so that the profiles sharing one
+// reuse its connections, and replaced when the CA bundle changes.
+func (v *vaultClient) httpClient(ctx context.Context, namespace string, spec *gnmicv1alpha1.ClientTLSConfig) (*http.Client, error) {
+	if spec == nil {
+		return http.DefaultClient, nil
+	}
+	key := fmt.Sprintf("%t", spec.InsecureSkipVerify)
+	var caBundle string
+	if ref := spec.CABundleRef; ref != nil {
+		key += "|" + namespace + "|" + ref.Name + "|" + ref.Key
+		var cm corev1.ConfigMap
+		if err := v.client.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, &cm); err != nil {
+			return nil, fmt.Errorf("vault CA bundle: %w", err)
+		}
+		caBundle = cm.Data[ref.Key]
+		if caBundle == "" {
+			return nil, fmt.Errorf("vault CA bundle: no certificate in key %q of ConfigMap %q", ref.Key, ref.Name)
+		}
+	}
+	v.m.Lock()
+	defer v.m.Unlock()
+	if cached, ok := v.httpClients[key]; ok && cached.caBundle == caBundle {
+		return cached.client, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: spec.InsecureSkipVerify}
+	if spec.CABundleRef != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caBundle)) {
+			return nil, fmt.Errorf("vault CA bundle: no certificate in key %q of ConfigMap %q", spec.CABundleRef.Key, spec.CABundleRef.Name)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	c := &http.Client{Transport: transport}
+	v.httpClients[key] = vaultHTTPClient{client: c, caBundle: caBundle}
+	return c, nil
+}
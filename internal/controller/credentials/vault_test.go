@@ -0,0 +1,263 @@
+package credentials
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+// fakeVault serves the few Vault endpoints the operator uses: the Kubernetes
+// auth login, a KV version 2 secret and a dynamic secret.
+type fakeVault struct {
+	*httptest.Server
+
+	m        sync.Mutex
+	logins   int
+	reads    int
+	token    string
+	password string
+}
+
+func newFakeVault(t *testing.T) *fakeVault {
+	t.Helper()
+	v := &fakeVault{password: "secret1"}
+	v.Server = httptest.NewServer(http.HandlerFunc(v.serve))
+	t.Cleanup(v.Close)
+	return v
+}
+
+func (v *fakeVault) serve(w http.ResponseWriter, r *http.Request) {
+	v.m.Lock()
+	defer v.m.Unlock()
+	reply := func(status int, body map[string]any) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	}
+	if r.Method == http.MethodPost && r.URL.Path == "/v1/auth/kubernetes/login" {
+		var login map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&login)
+		if login["role"] != "gnmic" || login["jwt"] == "" {
+			reply(http.StatusBadRequest, map[string]any{"errors": []string{"invalid login"}})
+			return
+		}
+		v.logins++
+		v.token = "s.token" + strings.Repeat("x", v.logins)
+		reply(http.StatusOK, map[string]any{"auth": map[string]any{"client_token": v.token, "lease_duration": 3600}})
+		return
+	}
+	if got := r.Header.Get("X-Vault-Token"); got == "" || got != v.token {
+		reply(http.StatusForbidden, map[string]any{"errors": []string{"permission denied"}})
+		return
+	}
+	v.reads++
+	switch r.URL.Path {
+	case "/v1/secret/data/arista":
+		reply(http.StatusOK, map[string]any{"data": map[string]any{
+			"data":     map[string]any{"user": "admin", "pass": v.password},
+			"metadata": map[string]any{"version": 3},
+		}})
+	case "/v1/database/creds/gnmic":
+		reply(http.StatusOK, map[string]any{"lease_duration": 60, "data": map[string]any{"username": "v-gnmic", "password": v.password}})
+	default:
+		reply(http.StatusNotFound, map[string]any{"errors": []string{}})
+	}
+}
+
+func (v *fakeVault) revoke() {
+	v.m.Lock()
+	defer v.m.Unlock()
+	v.token = ""
+}
+
+func fakeClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := gnmicv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func serviceAccount(name string) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+}
+
+func vaultSource(address, path string) *gnmicv1alpha1.VaultCredentials {
+	return &gnmicv1alpha1.VaultCredentials{
+		Address: address,
+		Path:    path,
+		Auth:    gnmicv1alpha1.VaultAuth{Kubernetes: &gnmicv1alpha1.VaultKubernetesAuth{Role: "gnmic"}},
+	}
+}
+
+func TestVaultRead(t *testing.T) {
+	vault := newFakeVault(t)
+	v := newVaultClient(fakeClient(t, serviceAccount(VaultServiceAccountName)), time.Now, VaultOptions{Addresses: []string{vault.URL + "/"}})
+	ctx := t.Context()
+
+	kv := vaultSource(vault.URL, "secret/data/arista")
+	kv.CredentialKeys = gnmicv1alpha1.CredentialKeys{UsernameKey: "user", PasswordKey: "pass"}
+	creds, lease, err := v.read(ctx, "default", kv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *creds != (gnmic.Credentials{Username: "admin", Password: "secret1"}) || lease != 0 {
+		t.Fatalf("KV v2: creds %+v, lease %s", creds, lease)
+	}
+
+	creds, lease, err = v.read(ctx, "default", vaultSource(vault.URL, "database/creds/gnmic"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.Username != "v-gnmic" || lease != time.Minute {
+		t.Fatalf("dynamic secret: creds %+v, lease %s", creds, lease)
+	}
+	if vault.logins != 1 {
+		t.Fatalf("%d logins, want the token reused", vault.logins)
+	}
+
+	// a revoked token is replaced by a new login
+	vault.revoke()
+	if _, _, err := v.read(ctx, "default", kv); err != nil {
+		t.Fatal(err)
+	}
+	if vault.logins != 2 {
+		t.Fatalf("%d logins after revocation, want 2", vault.logins)
+	}
+
+	if _, _, err := v.read(ctx, "default", vaultSource(vault.URL, "secret/data/missing")); err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Fatalf("missing secret: err %v", err)
+	}
+}
+
+func TestVaultRead_TokenSecret(t *testing.T) {
+	vault := newFakeVault(t)
+	vault.token = "s.static"
+	tokenSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault-token", Namespace: "default"},
+		Data:       map[string][]byte{"token": []byte("s.static\n")},
+	}
+	v := newVaultClient(fakeClient(t, tokenSecret), time.Now, VaultOptions{Addresses: []string{vault.URL}})
+	src := vaultSource(vault.URL, "database/creds/gnmic")
+	src.Auth = gnmicv1alpha1.VaultAuth{TokenSecretRef: &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "vault-token"}, Key: "token",
+	}}
+	creds, _, err := v.read(t.Context(), "default", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.Password != "secret1" || vault.logins != 0 {
+		t.Fatalf("creds %+v, %d logins", creds, vault.logins)
+	}
+}
+
+func TestVaultRead_AddressNotAllowed(t *testing.T) {
+	vault := newFakeVault(t)
+	v := newVaultClient(fakeClient(t, serviceAccount(VaultServiceAccountName)), time.Now, VaultOptions{Addresses: []string{"https://vault.example.com:8200"}})
+
+	_, _, err := v.read(t.Context(), "default", vaultSource(vault.URL, "database/creds/gnmic"))
+	if err == nil || !strings.Contains(err.Error(), "is not allowed") {
+		t.Fatalf("err %v, want the address rejected", err)
+	}
+	if vault.logins != 0 {
+		t.Fatalf("%d logins to an address not allowed", vault.logins)
+	}
+
+	// a token read from a Secret is not sent either
+	tokenSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault-token", Namespace: "default"},
+		Data:       map[string][]byte{"token": []byte("s.static")},
+	}
+	v = newVaultClient(fakeClient(t, tokenSecret), time.Now, VaultOptions{})
+	src := vaultSource(vault.URL, "database/creds/gnmic")
+	src.Auth = gnmicv1alpha1.VaultAuth{TokenSecretRef: &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "vault-token"}, Key: "token",
+	}}
+	if _, _, err := v.read(t.Context(), "default", src); err == nil || !strings.Contains(err.Error(), "is not allowed") {
+		t.Fatalf("err %v, want the address rejected", err)
+	}
+	if vault.reads != 0 {
+		t.Fatalf("%d reads from an address not allowed", vault.reads)
+	}
+}
+
+func TestVaultHTTPClient_Cached(t *testing.T) {
+	ca := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault-ca", Namespace: "default"},
+		Data:       map[string]string{"ca.crt": testCA(t)},
+	}
+	c := fakeClient(t, ca)
+	v := newVaultClient(c, time.Now, VaultOptions{})
+	ctx := t.Context()
+	spec := &gnmicv1alpha1.ClientTLSConfig{CABundleRef: &corev1.ConfigMapKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "vault-ca"}, Key: "ca.crt",
+	}}
+
+	first, err := v.httpClient(ctx, "default", spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := v.httpClient(ctx, "default", spec); again != first {
+		t.Fatal("a new client was built for the same TLS configuration")
+	}
+
+	// a rotated CA bundle replaces the client
+	ca.Data["ca.crt"] = testCA(t)
+	if err := c.Update(ctx, ca); err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := v.httpClient(ctx, "default", spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated == first || len(v.httpClients) != 1 {
+		t.Fatalf("rotated CA bundle: same client %t, %d clients cached", rotated == first, len(v.httpClients))
+	}
+}
+
+// testCA returns a PEM encoded self-signed CA certificate.
+func testCA(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "vault-ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
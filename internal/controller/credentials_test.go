@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/controller/credentials"
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+func vaultProfile(name, tokenSecret string) *gnmicv1alpha1.TargetProfile {
+	return &gnmicv1alpha1.TargetProfile{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: gnmicv1alpha1.TargetProfileSpec{Credentials: &gnmicv1alpha1.CredentialsSource{
+			Vault: &gnmicv1alpha1.VaultCredentials{
+				Address: "http://127.0.0.1:1",
+				Path:    "secret/data/arista",
+				Auth: gnmicv1alpha1.VaultAuth{TokenSecretRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: tokenSecret}, Key: "token",
+				}},
+			},
+		}},
+	}
+}
+
+// The Vault token is read from a Secret: replacing it must reach the clusters
+// collecting with the profile, as a credentials Secret does.
+func TestFindClustersForSecret_ReachesVaultTokenSecret(t *testing.T) {
+	r := reconcilerWith(t,
+		secret("vault-token"),
+		vaultProfile("default", "vault-token"),
+		target("leaf1", "default", map[string]string{"tag": "prod"}),
+		pipelineSelectingTargets("p1", "c1", true, map[string]string{"tag": "prod"}),
+	)
+	if got := clusterNames(t, r, secret("vault-token")); len(got) != 1 || got[0] != "c1" {
+		t.Fatalf("clusters = %v, want [c1]", got)
+	}
+}
+
+func TestAddPipelineTargets_SkipsTargetsWithUnreadableCredentials(t *testing.T) {
+	r := reconcilerWith(t, secret("vault-token"), vaultProfile("vault", "vault-token"), profile("ok", ""))
+	r.Credentials = credentials.NewStore(r.Client, "", credentials.VaultOptions{})
+	pipeline := &gnmicv1alpha1.Pipeline{ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "default"}}
+	data := gnmic.NewPipelineData()
+	refs := &pipelineRefs{}
+	usage := newResourceUsage()
+
+	_, err := r.addPipelineTargets(context.Background(), pipeline,
+		[]gnmicv1alpha1.Target{refsTarget("t1", "ok"), refsTarget("t2", "vault")}, data, refs, usage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Targets) != 1 {
+		t.Fatalf("targets %v, want t1 only", data.Targets)
+	}
+	got := refs.sortedUnresolved()
+	if len(got) != 1 || got[0].Kind != "Vault" || got[0].Name != "secret/data/arista" || !strings.Contains(got[0].Message, "1 targets skipped") {
+		t.Fatalf("unresolved = %+v", got)
+	}
+	if reason := usage.invalid[resourceKey{kind: resourceKindTargetProfile, name: "vault"}]; reason == "" {
+		t.Fatal("profile with unreadable credentials not reported invalid")
+	}
+}
+
+func TestFetchCredentials_SecretKeys(t *testing.T) {
+	creds := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "default"},
+		Data:       map[string][]byte{"user": []byte("admin"), "pass": []byte("secret"), "password": []byte("other")},
+	}
+	r := reconcilerWith(t, creds)
+	got, err := r.FetchCredentials("default", "p", &gnmicv1alpha1.TargetProfileSpec{Credentials: &gnmicv1alpha1.CredentialsSource{
+		Secret: &gnmicv1alpha1.SecretCredentials{Name: "creds", CredentialKeys: gnmicv1alpha1.CredentialKeys{UsernameKey: "user", PasswordKey: "pass"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != (gnmic.Credentials{Username: "admin", Password: "secret"}) {
+		t.Fatalf("creds = %+v", got)
+	}
+
+	// without a store, only Secrets are supported
+	if _, err := r.FetchCredentials("default", "p", &vaultProfile("p", "t").Spec); err == nil {
+		t.Fatal("expected an error without credentials store")
+	}
+}
+
+// A device with its own local account must be reconfigured when its Secret
+// rotates, although no TargetProfile references the Secret.
+func TestFindClustersForSecret_ReachesTargetCredentials(t *testing.T) {
+	leaf := target("leaf1", "default", map[string]string{"tag": "prod"})
+	leaf.Spec.CredentialsRef = "leaf1-local"
+	r := reconcilerWith(t,
+		secret("leaf1-local"),
+		profile("default", "creds"),
+		leaf,
+		pipelineSelectingTargets("p1", "c1", true, map[string]string{"tag": "prod"}),
+	)
+	if got := clusterNames(t, r, secret("leaf1-local")); len(got) != 1 || got[0] != "c1" {
+		t.Fatalf("clusters = %v, want [c1]", got)
+	}
+}
+
+func TestAddPipelineTargets_SkipsTargetsWithMissingCredentials(t *testing.T) {
+	r := reconcilerWith(t, secret("t1-local"), profile("ok", ""))
+	pipeline := &gnmicv1alpha1.Pipeline{ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "default"}}
+	t1, t2 := refsTarget("t1", "ok"), refsTarget("t2", "ok")
+	t1.Spec.CredentialsRef = "t1-local"
+	t2.Spec.CredentialsRef = "t2-local"
+	data := gnmic.NewPipelineData()
+	refs := &pipelineRefs{}
+
+	if _, err := r.addPipelineTargets(context.Background(), pipeline, []gnmicv1alpha1.Target{t1, t2}, data, refs, newResourceUsage()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := data.Targets["default/t1"]; !ok || len(data.Targets) != 1 {
+		t.Fatalf("targets %v, want t1 only", data.Targets)
+	}
+	got := refs.sortedUnresolved()
+	if len(got) != 1 || got[0].Kind != "Secret" || got[0].Name != "t2-local" {
+		t.Fatalf("unresolved = %+v", got)
+	}
+}
@@ -47,7 +47,10 @@ func applyTarget(ctx context.Context, c client.Client, s *runtime.Scheme, desire
 	}
 
 	_, err := controllerutil.CreateOrUpdate(ctx, c, existing, func() error {
+		// suspend is set by users, the discovery source knows nothing about it
+		suspend := existing.Spec.Suspend
 		existing.Spec = desired.Spec
+		existing.Spec.Suspend = suspend
 		existing.Labels = desired.Labels
 
 		return controllerutil.SetControllerReference(ts, existing, s)
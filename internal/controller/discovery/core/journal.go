@@ -0,0 +1,37 @@
+package core
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrJournalFull is returned by Journal.Append when the journal cannot
+// persist more messages until earlier ones are acknowledged
+var ErrJournalFull = errors.New("discovery journal is full")
+
+// Journal persists discovery messages until the MessageProcessor has handled
+// them, so that messages accepted before an operator restart are replayed
+// instead of lost. Delivery is at least once: a message handled right before
+// a restart may be replayed.
+type Journal interface {
+	// Append persists messages in order and returns the sequence number
+	// assigned to each of them
+	Append(ctx context.Context, messages ...DiscoveryMessage) ([]uint64, error)
+	// Ack removes handled messages from the journal
+	Ack(ctx context.Context, seqs ...uint64) error
+	// Pending returns the unacknowledged messages in the order they were
+	// appended, with their JournalSeq set
+	Pending(ctx context.Context) ([]DiscoveryMessage, error)
+}
+
+// JournalSeq returns the journal sequence number of a message, 0 if it was
+// not journaled
+func JournalSeq(message DiscoveryMessage) uint64 {
+	switch msg := message.(type) {
+	case DiscoveryEvent:
+		return msg.JournalSeq
+	case DiscoverySnapshot:
+		return msg.JournalSeq
+	}
+	return 0
+}
@@ -14,3 +14,11 @@ type Loader interface {
 	// The loader must stop cleanly when ctx is canceled
 	Run(ctx context.Context, out chan<- []DiscoveryMessage) error
 }
+
+// PushReceiver is implemented by loaders that process the messages pushed
+// through the API before they reach the MessageProcessor, e.g. to merge them
+// with the targets of other providers
+type PushReceiver interface {
+	// PushChannel returns the channel pushed messages are sent to
+	PushChannel() chan<- []DiscoveryMessage
+}
@@ -0,0 +1,269 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// OperationState is the state of a push operation or of one of its targets
+type OperationState string
+
+const (
+	// OperationPending indicates that events are queued and not yet processed
+	OperationPending OperationState = "Pending"
+	// OperationSucceeded indicates that all events were applied
+	OperationSucceeded OperationState = "Succeeded"
+	// OperationFailed indicates that at least one event could not be applied
+	OperationFailed OperationState = "Failed"
+	// OperationSuperseded indicates that an event was dropped because a
+	// snapshot of the TargetSource replaced it before it was applied
+	OperationSuperseded OperationState = "Superseded"
+)
+
+// DefaultOperationTTL is how long finished operations and their idempotency
+// keys are kept
+const DefaultOperationTTL = time.Hour
+
+// TargetResult is the outcome of a single discovery event of an operation
+type TargetResult struct {
+	Name  string         `json:"name"`
+	Event string         `json:"event"`
+	State OperationState `json:"state"`
+	Error string         `json:"error,omitempty"`
+}
+
+// Operation tracks a batch of discovery events submitted together, e.g. by a
+// single push API request
+type Operation struct {
+	ID             string         `json:"id"`
+	IdempotencyKey string         `json:"idempotencyKey,omitempty"`
+	State          OperationState `json:"state"`
+	Created        time.Time      `json:"created"`
+	Completed      *time.Time     `json:"completed,omitempty"`
+	Targets        []TargetResult `json:"targets"`
+
+	// fingerprint identifies the payload submitted with the idempotency key
+	fingerprint string
+}
+
+// OperationStore keeps the operations of a TargetSource. It is shared
+// between the producers of discovery events and the MessageProcessor, which
+// reports per target results. A nil store ignores all reports.
+type OperationStore struct {
+	ttl time.Duration
+
+	m          sync.Mutex
+	operations map[string]*Operation
+	// idempotency key -> operation ID
+	keys map[string]string
+}
+
+// NewOperationStore creates a store keeping operations for ttl
+func NewOperationStore(ttl time.Duration) *OperationStore {
+	if ttl <= 0 {
+		ttl = DefaultOperationTTL
+	}
+	return &OperationStore{
+		ttl:        ttl,
+		operations: make(map[string]*Operation),
+		keys:       make(map[string]string),
+	}
+}
+
+// ErrIdempotencyKeyReused is returned when an idempotency key is submitted
+// again with a different payload
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used with a different payload")
+
+// Create registers a new pending operation for events and sets their
+// OperationID. If idempotencyKey was already used with the same fingerprint,
+// the existing operation is returned with created set to false and events
+// must not be sent again. The events of a snapshot are its applied targets,
+// the caller sets the OperationID of the snapshot.
+func (s *OperationStore) Create(idempotencyKey, fingerprint string, events []DiscoveryEvent) (op Operation, created bool, err error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.prune(time.Now())
+
+	if idempotencyKey != "" {
+		if id, ok := s.keys[idempotencyKey]; ok {
+			existing := s.operations[id]
+			if existing.fingerprint != fingerprint {
+				return Operation{}, false, ErrIdempotencyKeyReused
+			}
+			return existing.copy(), false, nil
+		}
+	}
+
+	id, err := newOperationID()
+	if err != nil {
+		return Operation{}, false, err
+	}
+	o := &Operation{
+		ID:             id,
+		IdempotencyKey: idempotencyKey,
+		State:          OperationPending,
+		Created:        time.Now(),
+		Targets:        make([]TargetResult, len(events)),
+		fingerprint:    fingerprint,
+	}
+	for i := range events {
+		events[i].OperationID = id
+		o.Targets[i] = TargetResult{
+			Name:  events[i].Target.Name,
+			Event: events[i].Event.String(),
+			State: OperationPending,
+		}
+	}
+	s.operations[id] = o
+	if idempotencyKey != "" {
+		s.keys[idempotencyKey] = id
+	}
+	return o.copy(), true, nil
+}
+
+// Get returns a copy of an operation
+func (s *OperationStore) Get(id string) (Operation, bool) {
+	if s == nil {
+		return Operation{}, false
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	o, ok := s.operations[id]
+	if !ok {
+		return Operation{}, false
+	}
+	return o.copy(), true
+}
+
+// Discard forgets an operation whose events could not be sent, releasing its
+// idempotency key for a retry.
+func (s *OperationStore) Discard(id string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if o, ok := s.operations[id]; ok {
+		delete(s.operations, id)
+		if o.IdempotencyKey != "" {
+			delete(s.keys, o.IdempotencyKey)
+		}
+	}
+}
+
+// Report records the outcome of an event of an operation. A nil err marks
+// it Succeeded. Events the operation was not created with, e.g. the deletions
+// of a snapshot, are added to it.
+func (s *OperationStore) Report(operationID string, event DiscoveryEvent, err error) {
+	state := OperationSucceeded
+	if err != nil {
+		state = OperationFailed
+	}
+	s.report(operationID, event, state, err)
+}
+
+// Supersede records that an event of an operation was dropped in favour of a
+// newer snapshot.
+func (s *OperationStore) Supersede(operationID string, event DiscoveryEvent) {
+	s.report(operationID, event, OperationSuperseded, nil)
+}
+
+// Resolve sets all pending targets of an operation to state, e.g. when a
+// snapshot is discarded or the discovery pipeline stops.
+func (s *OperationStore) Resolve(operationID string, state OperationState, err error) {
+	if s == nil || operationID == "" {
+		return
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	o, ok := s.operations[operationID]
+	if !ok {
+		return
+	}
+	for i := range o.Targets {
+		if o.Targets[i].State == OperationPending {
+			o.Targets[i].setState(state, err)
+		}
+	}
+	o.updateState()
+}
+
+func (s *OperationStore) report(operationID string, event DiscoveryEvent, state OperationState, err error) {
+	if s == nil || operationID == "" {
+		return
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	o, ok := s.operations[operationID]
+	if !ok {
+		return
+	}
+	found := false
+	for i := range o.Targets {
+		r := &o.Targets[i]
+		if r.Name == event.Target.Name && r.Event == event.Event.String() && r.State == OperationPending {
+			r.setState(state, err)
+			found = true
+			break
+		}
+	}
+	if !found {
+		r := TargetResult{Name: event.Target.Name, Event: event.Event.String()}
+		r.setState(state, err)
+		o.Targets = append(o.Targets, r)
+	}
+	o.updateState()
+}
+
+func (r *TargetResult) setState(state OperationState, err error) {
+	r.State = state
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+func (o *Operation) updateState() {
+	state := OperationSucceeded
+	for _, r := range o.Targets {
+		switch r.State {
+		case OperationPending:
+			return
+		case OperationFailed:
+			state = OperationFailed
+		}
+	}
+	now := time.Now()
+	o.State = state
+	o.Completed = &now
+}
+
+func (o *Operation) copy() Operation {
+	c := *o
+	c.Targets = append([]TargetResult(nil), o.Targets...)
+	return c
+}
+
+// prune drops completed operations older than the ttl, and pending ones
+// older than twice the ttl whose events were lost, e.g. on a pipeline restart.
+func (s *OperationStore) prune(now time.Time) {
+	for id, o := range s.operations {
+		expired := o.Completed != nil && now.Sub(*o.Completed) > s.ttl ||
+			now.Sub(o.Created) > 2*s.ttl
+		if !expired {
+			continue
+		}
+		delete(s.operations, id)
+		if o.IdempotencyKey != "" {
+			delete(s.keys, o.IdempotencyKey)
+		}
+	}
+}
+
+func newOperationID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate operation ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gnmic/operator/api/v1alpha1"
 )
 
 const (
@@ -25,6 +27,8 @@ type Reason string
 type StatusUpdate struct {
 	Conditions   []metav1.Condition
 	TargetsCount *int32
+	QueueDepth   *int32
+	Conflicts    *[]v1alpha1.TargetConflict
 }
 
 // StatusUpdater defines the interface for TargetLoaders and MessageProcessor to update the status of the TargetSource
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gnmic/operator/api/v1alpha1"
+	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -18,15 +19,40 @@ type DiscoveryRegistryValue struct {
 	Stop context.CancelFunc
 
 	CommonLoaderConfig *CommonLoaderConfig
+	// Operations tracks the events pushed through the API until the
+	// MessageProcessor has applied them
+	Operations *OperationStore
+	// Journal persists the messages pushed through the API until the
+	// MessageProcessor has handled them. Nil if journaling is disabled.
+	Journal Journal
 }
 
 type CommonLoaderConfig struct {
-	TargetsourceNN  types.NamespacedName
-	ChunkSize       int
-	PushConfig      *v1alpha1.PushSpec
+	TargetsourceNN types.NamespacedName
+	ChunkSize      int
+	PushConfig     *v1alpha1.PushSpec
+	// PushMapper is the push mapping of PushConfig, compiled once for the
+	// generation of the TargetSource. Nil when pushed payloads are lists of
+	// Targets.
+	PushMapper      PushMapper
 	Router          *gin.Engine
 	ResourceFetcher ResourceFetcher
 	Updater         StatusUpdater
+	// BufferSize is the capacity of the channels created by loaders, e.g.
+	// to receive pushed messages
+	BufferSize int
+	// MergePolicy combines the targets of several providers, empty if the
+	// TargetSource has a single provider
+	MergePolicy v1alpha1.MergePolicy
+	// Operations and Journal track the pushed messages a loader receives,
+	// see DiscoveryRegistryValue
+	Operations *OperationStore
+	Journal    Journal
+}
+
+// PushMapper converts a pushed JSON document into discovery events
+type PushMapper interface {
+	Events(raw any, logger logr.Logger) ([]DiscoveryEvent, error)
 }
 
 // EventAction represents the type of a discovery event
@@ -47,11 +73,19 @@ type DiscoveredTarget struct {
 	Port          int32
 	Labels        map[string]string
 	TargetProfile string
+	// CredentialsRef names the Secret holding the credentials of the target,
+	// overriding those of its profile
+	CredentialsRef string
 }
 
 type DiscoveryEvent struct {
 	Target DiscoveredTarget
 	Event  EventAction
+	// OperationID links the event to the Operation it was submitted with, if any
+	OperationID string
+	// JournalSeq is the sequence number of the event in the Journal, 0 if it
+	// was not journaled
+	JournalSeq uint64
 }
 
 func (e EventAction) String() string {
@@ -70,4 +104,12 @@ type DiscoverySnapshot struct {
 	ChunkIndex  int
 	TotalChunks int
 	Targets     []DiscoveredTarget
+	// OperationID links the snapshot to the Operation it was pushed with, if any
+	OperationID string
+	// JournalSeq is the sequence number of the chunk in the Journal, 0 if it
+	// was not journaled
+	JournalSeq uint64
+	// Aborted discards the chunks received for SnapshotID, the snapshot is
+	// not completed. An aborted message has no targets.
+	Aborted bool
 }
@@ -0,0 +1,54 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+)
+
+// ErrCredentialsRefNotAllowed is returned when a discovered target references
+// a Secret the allowedCredentials of its TargetSource do not allow
+var ErrCredentialsRefNotAllowed = errors.New("credentialsRef is not allowed by the TargetSource")
+
+// CheckCredentialsRef returns ErrCredentialsRefNotAllowed unless the targets
+// discovered by ts may reference the Secret ref. Discovery input, e.g. an HTTP
+// source or a push, must not point targets at any Secret of the namespace.
+func CheckCredentialsRef(ctx context.Context, c client.Reader, ts *gnmicv1alpha1.TargetSource, ref string) error {
+	if ref == "" {
+		return nil
+	}
+	allowed := ts.Spec.AllowedCredentials
+	if allowed == nil {
+		return fmt.Errorf("%w: %q, allowedCredentials is not set", ErrCredentialsRefNotAllowed, ref)
+	}
+	if slices.Contains(allowed.Names, ref) || (allowed.NamePrefix != "" && strings.HasPrefix(ref, allowed.NamePrefix)) {
+		return nil
+	}
+	if allowed.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(allowed.Selector)
+		if err != nil {
+			return fmt.Errorf("invalid allowedCredentials selector: %w", err)
+		}
+		var secret corev1.Secret
+		err = c.Get(ctx, types.NamespacedName{Namespace: ts.Namespace, Name: ref}, &secret)
+		switch {
+		case apierrors.IsNotFound(err):
+		case err != nil:
+			return fmt.Errorf("failed to get Secret %s: %w", ref, err)
+		case selector.Matches(labels.Set(secret.Labels)):
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrCredentialsRefNotAllowed, ref)
+}
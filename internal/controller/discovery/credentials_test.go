@@ -0,0 +1,80 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/controller/discovery/core"
+	"github.com/go-logr/logr"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestCheckCredentialsRef(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "labeled", Namespace: "default", Labels: map[string]string{"gnmic.dev/device-credentials": "true"}}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unlabeled", Namespace: "default"}},
+	).Build()
+	allowed := &gnmicv1alpha1.AllowedCredentialsSpec{
+		Names:      []string{"router-creds"},
+		NamePrefix: "devices-",
+		Selector:   &metav1.LabelSelector{MatchLabels: map[string]string{"gnmic.dev/device-credentials": "true"}},
+	}
+
+	tests := []struct {
+		name    string
+		allowed *gnmicv1alpha1.AllowedCredentialsSpec
+		ref     string
+		wantErr bool
+	}{
+		{name: "no ref", ref: ""},
+		{name: "not opted in", ref: "router-creds", wantErr: true},
+		{name: "listed name", allowed: allowed, ref: "router-creds"},
+		{name: "name prefix", allowed: allowed, ref: "devices-leaf1"},
+		{name: "selected Secret", allowed: allowed, ref: "labeled"},
+		{name: "unselected Secret", allowed: allowed, ref: "unlabeled", wantErr: true},
+		{name: "missing Secret", allowed: allowed, ref: "operator-token", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := mockTargetSource(func(ts *gnmicv1alpha1.TargetSource) {
+				ts.Spec.AllowedCredentials = tt.allowed
+			})
+			err := CheckCredentialsRef(context.Background(), c, &ts, tt.ref)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrCredentialsRefNotAllowed)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestProcessEvent_RejectsCredentialsRef(t *testing.T) {
+	ops := core.NewOperationStore(0)
+	m := mockMessageProcessor(func(m *MessageProcessor) {
+		m.operations = ops
+	})
+
+	events := []core.DiscoveryEvent{{
+		Event:  core.EventApply,
+		Target: core.DiscoveredTarget{Name: "router-1", Address: "10.0.0.1", CredentialsRef: "operator-token"},
+	}}
+	op, _, err := ops.Create("", "", events)
+	require.NoError(t, err)
+	events[0].OperationID = op.ID
+
+	// the target is rejected without stopping the pipeline
+	require.NoError(t, m.processEvent(context.Background(), events[0], logr.Discard()))
+	got, _ := ops.Get(op.ID)
+	require.Equal(t, core.OperationFailed, got.State)
+	require.Equal(t, int32(0), m.targetCount)
+}
@@ -0,0 +1,278 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/controller/discovery/core"
+)
+
+const (
+	// JournalConfigMap journals discovery messages in a ConfigMap per TargetSource
+	JournalConfigMap = "configmap"
+
+	journalConfigMapSuffix = "-discovery-journal"
+	// ConfigMaps are limited to 1MiB, the rest is left for metadata
+	journalConfigMapMaxBytes = 900 * 1024
+	// keys are zero padded so that they sort in append order
+	journalKeyFormat = "%020d"
+)
+
+// NewJournal returns the Journal of a TargetSource for the given kind, nil
+// if kind is empty
+func NewJournal(kind string, c client.Client, s *runtime.Scheme, ts *gnmicv1alpha1.TargetSource) (core.Journal, error) {
+	switch kind {
+	case "":
+		return nil, nil
+	case JournalConfigMap:
+		return NewConfigMapJournal(c, s, ts), nil
+	default:
+		return nil, fmt.Errorf("unknown discovery journal %q", kind)
+	}
+}
+
+// journalEntry is the persisted form of a discovery message
+type journalEntry struct {
+	Event    *core.DiscoveryEvent    `json:"event,omitempty"`
+	Snapshot *core.DiscoverySnapshot `json:"snapshot,omitempty"`
+}
+
+// configMapJournal is a Journal keeping each message under its sequence number
+// in a ConfigMap owned by the TargetSource. The ConfigMap is read once and
+// then only written, the journal is the single writer of the ConfigMap.
+type configMapJournal struct {
+	client       client.Client
+	scheme       *runtime.Scheme
+	targetSource *gnmicv1alpha1.TargetSource
+
+	mu        sync.Mutex
+	loaded    bool
+	configMap *corev1.ConfigMap
+	size      int
+	nextSeq   uint64
+}
+
+// NewConfigMapJournal returns a Journal persisted in the ConfigMap
+// <targetsource>-discovery-journal
+func NewConfigMapJournal(c client.Client, s *runtime.Scheme, ts *gnmicv1alpha1.TargetSource) *configMapJournal {
+	return &configMapJournal{
+		client:       c,
+		scheme:       s,
+		targetSource: ts,
+	}
+}
+
+// Append persists messages and returns their sequence numbers. It returns
+// core.ErrJournalFull if the ConfigMap would exceed its size limit.
+func (j *configMapJournal) Append(ctx context.Context, messages ...core.DiscoveryMessage) ([]uint64, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.load(ctx); err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]string, len(j.configMap.Data)+len(messages))
+	for k, v := range j.configMap.Data {
+		data[k] = v
+	}
+	size := j.size
+	seqs := make([]uint64, len(messages))
+	for i, message := range messages {
+		var entry journalEntry
+		switch msg := message.(type) {
+		case core.DiscoveryEvent:
+			entry.Event = &msg
+		case core.DiscoverySnapshot:
+			entry.Snapshot = &msg
+		default:
+			return nil, fmt.Errorf("unknown discovery message type %T", msg)
+		}
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return nil, err
+		}
+		seqs[i] = j.nextSeq + uint64(i)
+		key := fmt.Sprintf(journalKeyFormat, seqs[i])
+		data[key] = string(b)
+		size += len(key) + len(b)
+	}
+	if size > journalConfigMapMaxBytes {
+		return nil, core.ErrJournalFull
+	}
+
+	if err := j.write(ctx, data); err != nil {
+		return nil, err
+	}
+	j.size = size
+	j.nextSeq += uint64(len(messages))
+	return seqs, nil
+}
+
+// Ack removes messages from the journal. Unknown sequence numbers are ignored.
+func (j *configMapJournal) Ack(ctx context.Context, seqs ...uint64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.load(ctx); err != nil {
+		return err
+	}
+
+	data := make(map[string]string, len(j.configMap.Data))
+	for k, v := range j.configMap.Data {
+		data[k] = v
+	}
+	size := j.size
+	for _, seq := range seqs {
+		key := fmt.Sprintf(journalKeyFormat, seq)
+		if v, ok := data[key]; ok {
+			size -= len(key) + len(v)
+			delete(data, key)
+		}
+	}
+	if len(data) == len(j.configMap.Data) {
+		return nil
+	}
+
+	if err := j.write(ctx, data); err != nil {
+		return err
+	}
+	j.size = size
+	return nil
+}
+
+// Pending returns the unacknowledged messages in append order
+func (j *configMapJournal) Pending(ctx context.Context) ([]core.DiscoveryMessage, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.load(ctx); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(j.configMap.Data))
+	for k := range j.configMap.Data {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	messages := make([]core.DiscoveryMessage, 0, len(keys))
+	for _, key := range keys {
+		seq, err := strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid journal key %q: %w", key, err)
+		}
+		var entry journalEntry
+		if err := json.Unmarshal([]byte(j.configMap.Data[key]), &entry); err != nil {
+			return nil, fmt.Errorf("invalid journal entry %q: %w", key, err)
+		}
+		switch {
+		case entry.Event != nil:
+			entry.Event.JournalSeq = seq
+			messages = append(messages, *entry.Event)
+		case entry.Snapshot != nil:
+			entry.Snapshot.JournalSeq = seq
+			messages = append(messages, *entry.Snapshot)
+		default:
+			return nil, fmt.Errorf("empty journal entry %q", key)
+		}
+	}
+	return messages, nil
+}
+
+// load reads the ConfigMap the first time the journal is used
+func (j *configMapJournal) load(ctx context.Context) error {
+	if j.loaded {
+		return nil
+	}
+
+	cm := &corev1.ConfigMap{}
+	err := j.client.Get(ctx, client.ObjectKey{
+		Name:      j.targetSource.Name + journalConfigMapSuffix,
+		Namespace: j.targetSource.Namespace,
+	}, cm)
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      j.targetSource.Name + journalConfigMapSuffix,
+				Namespace: j.targetSource.Namespace,
+				Labels: map[string]string{
+					LabelTargetSourceName: j.targetSource.Name,
+				},
+			},
+		}
+		if err := controllerutil.SetControllerReference(j.targetSource, cm, j.scheme); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to read discovery journal: %w", err)
+	}
+
+	j.nextSeq = 1
+	j.size = 0
+	for k, v := range cm.Data {
+		seq, err := strconv.ParseUint(k, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid journal key %q: %w", k, err)
+		}
+		if seq >= j.nextSeq {
+			j.nextSeq = seq + 1
+		}
+		j.size += len(k) + len(v)
+	}
+	j.configMap = cm
+	j.loaded = true
+	return nil
+}
+
+// write replaces the journaled messages by data, creating the ConfigMap if
+// needed. Changes made by others are overwritten.
+func (j *configMapJournal) write(ctx context.Context, data map[string]string) error {
+	cm := j.configMap.DeepCopy()
+	cm.Data = data
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if cm.ResourceVersion == "" {
+			err := j.client.Create(ctx, cm)
+			if apierrors.IsAlreadyExists(err) {
+				return j.refreshResourceVersion(ctx, cm)
+			}
+			return err
+		}
+		err := j.client.Update(ctx, cm)
+		if apierrors.IsConflict(err) {
+			return j.refreshResourceVersion(ctx, cm)
+		}
+		if apierrors.IsNotFound(err) {
+			cm.ResourceVersion = ""
+			return apierrors.NewConflict(corev1.Resource("configmaps"), cm.Name, err)
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write discovery journal: %w", err)
+	}
+	j.configMap = cm
+	return nil
+}
+
+// refreshResourceVersion reads the current resourceVersion of the ConfigMap
+// and reports a conflict so that the write is retried
+func (j *configMapJournal) refreshResourceVersion(ctx context.Context, cm *corev1.ConfigMap) error {
+	latest := &corev1.ConfigMap{}
+	if err := j.client.Get(ctx, client.ObjectKeyFromObject(cm), latest); err != nil {
+		return err
+	}
+	cm.ResourceVersion = latest.ResourceVersion
+	return apierrors.NewConflict(corev1.Resource("configmaps"), cm.Name, fmt.Errorf("journal was modified"))
+}
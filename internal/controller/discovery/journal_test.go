@@ -0,0 +1,83 @@
+package discovery
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/controller/discovery/core"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func mockJournal(t *testing.T) (*configMapJournal, client.Client, *runtime.Scheme, *gnmicv1alpha1.TargetSource) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, gnmicv1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	ts := mockTargetSource()
+	return NewConfigMapJournal(c, scheme, &ts), c, scheme, &ts
+}
+
+func TestConfigMapJournal_ReplaysAfterReopen(t *testing.T) {
+	j, c, scheme, ts := mockJournal(t)
+	ctx := context.Background()
+
+	seqs, err := j.Append(ctx,
+		core.DiscoveryEvent{Event: core.EventApply, OperationID: "op-1", Target: core.DiscoveredTarget{Name: "router-1", Port: 57400}},
+		core.DiscoverySnapshot{SnapshotID: "snap-1", TotalChunks: 1, Targets: []core.DiscoveredTarget{{Name: "router-2"}}},
+		core.DiscoveryEvent{Event: core.EventDelete, Target: core.DiscoveredTarget{Name: "router-3"}},
+	)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1, 2, 3}, seqs)
+	require.NoError(t, j.Ack(ctx, seqs[0]))
+
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "ts1-discovery-journal"}, cm))
+	require.Len(t, cm.Data, 2)
+	require.Equal(t, "ts1", cm.Labels[LabelTargetSourceName])
+
+	// a new journal, e.g. after a restart, returns the unacknowledged messages
+	reopened := NewConfigMapJournal(c, scheme, ts)
+	pending, err := reopened.Pending(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+
+	snapshot, ok := pending[0].(core.DiscoverySnapshot)
+	require.True(t, ok)
+	require.Equal(t, uint64(2), snapshot.JournalSeq)
+	require.Equal(t, "router-2", snapshot.Targets[0].Name)
+	event, ok := pending[1].(core.DiscoveryEvent)
+	require.True(t, ok)
+	require.Equal(t, uint64(3), event.JournalSeq)
+	require.Equal(t, core.EventDelete, event.Event)
+
+	// sequence numbers continue after the journaled ones
+	seqs, err = reopened.Append(ctx, core.DiscoveryEvent{Event: core.EventApply})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{4}, seqs)
+}
+
+func TestConfigMapJournal_Full(t *testing.T) {
+	j, _, _, _ := mockJournal(t)
+	ctx := context.Background()
+
+	big := core.DiscoveryEvent{Target: core.DiscoveredTarget{Name: strings.Repeat("r", journalConfigMapMaxBytes/2)}}
+	_, err := j.Append(ctx, big)
+	require.NoError(t, err)
+
+	_, err = j.Append(ctx, big)
+	require.ErrorIs(t, err, core.ErrJournalFull)
+
+	// acknowledged messages free their space
+	require.NoError(t, j.Ack(ctx, 1))
+	_, err = j.Append(ctx, big)
+	require.NoError(t, err)
+}
@@ -9,19 +9,42 @@ import (
 	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
 	"github.com/gnmic/operator/internal/controller/discovery/core"
 	"github.com/gnmic/operator/internal/controller/discovery/loaders/http"
+	"github.com/gnmic/operator/internal/controller/discovery/loaders/static"
 )
 
-// NewLoader creates a loader by name
+// NewLoader creates the loader of a TargetSource. Several providers are
+// combined by a merge loader.
 func NewLoader(ctx context.Context, c client.Client, cfg *core.CommonLoaderConfig, spec gnmicv1alpha1.TargetSourceSpec) (core.Loader, error) {
+	cfg.ResourceFetcher = newK8sResourceFetcher(c)
 
 	switch {
-	case spec.Provider.HTTP != nil:
-		httpSpec := *spec.Provider.HTTP
+	case spec.Provider != nil:
+		return newProviderLoader(cfg, *spec.Provider)
+	case len(spec.Providers) > 0:
+		return newMergeLoader(cfg, spec.Providers, spec.Merge)
+	default:
+		return nil, fmt.Errorf("no targetsource provider, check TargetSource CRD for %s", cfg.TargetsourceNN)
+	}
+}
+
+// newProviderLoader creates the loader of a single provider
+func newProviderLoader(cfg *core.CommonLoaderConfig, spec gnmicv1alpha1.ProviderSpec) (core.Loader, error) {
+	switch {
+	case spec.HTTP != nil:
+		httpSpec := *spec.HTTP
 		if httpSpec.Push != nil {
 			cfg.PushConfig = httpSpec.Push
+			if httpSpec.Push.Mapping != nil {
+				mapper, err := http.NewPushMapper(httpSpec.Push.Mapping)
+				if err != nil {
+					return nil, fmt.Errorf("push mapping: %w", err)
+				}
+				cfg.PushMapper = mapper
+			}
 		}
-		cfg.ResourceFetcher = newK8sResourceFetcher(c)
 		return http.New(*cfg, httpSpec), nil
+	case spec.Static != nil:
+		return static.New(*cfg, *spec.Static), nil
 	default:
 		return nil, fmt.Errorf("unknown targetsource provider, check TargetSource CRD for %s", cfg.TargetsourceNN)
 	}
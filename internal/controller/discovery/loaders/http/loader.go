@@ -19,6 +19,7 @@ import (
 	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
 	"github.com/gnmic/operator/internal/controller/discovery/core"
 	loaderUtils "github.com/gnmic/operator/internal/controller/discovery/loaders/utils"
+	"github.com/gnmic/operator/internal/utils"
 )
 
 // Loader implements the HTTP pull discovery mechanism
@@ -86,7 +87,7 @@ func (l *Loader) Run(ctx context.Context, out chan<- []core.DiscoveryMessage) er
 	logger.Info(
 		"HTTP polling discovery started",
 		"interval", interval.String(),
-		"url", l.spec.URL,
+		"url", utils.RedactURL(l.spec.URL),
 	)
 
 	// helper function to fetch targets and emit discovery messages
@@ -108,7 +109,7 @@ func (l *Loader) Run(ctx context.Context, out chan<- []core.DiscoveryMessage) er
 			logger.Error(
 				err,
 				"Failed to fetch targets from HTTP endpoint",
-				"url", l.spec.URL,
+				"url", utils.RedactURL(l.spec.URL),
 			)
 			l.reportStatus(ctx, core.StatusUpdate{
 				Conditions: []metav1.Condition{
@@ -229,7 +230,7 @@ func (l *Loader) fetchTargetsFromHTTPEndpoint(
 
 	for {
 		if _, exists := seen[currentURL]; exists {
-			logger.Error(fmt.Errorf("pagination loop detected"), "stopping pagination", "url", currentURL)
+			logger.Error(fmt.Errorf("pagination loop detected"), "stopping pagination", "url", utils.RedactURL(currentURL))
 			break
 		}
 		seen[currentURL] = struct{}{}
@@ -316,43 +317,14 @@ func (l *Loader) fetchPage(
 
 // extractTargetsFromResponse extracts items from the response and maps each item into a DiscoveredTarget
 func (l *Loader) extractTargetsFromResponse(raw any, logger logr.Logger) ([]core.DiscoveredTarget, error) {
-	var items []any
-	// If ResponseMapping is configured and TargetsField is provided we treat
-	// it as a CEL expression that evaluates against the whole response and
-	// must return an array of items.
-	if l.spec.ResponseMapping != nil && l.spec.ResponseMapping.TargetsField != "" {
-		prog, err := compileCEL(l.spec.ResponseMapping.TargetsField)
-		if err != nil {
-			return nil, fmt.Errorf("invalid TargetsField CEL expression: %w", err)
-		}
-		out, _, err := prog.Eval(map[string]any{"self": raw})
-		if err != nil {
-			return nil, fmt.Errorf("evaluating TargetsField CEL expression failed: %w", err)
-		}
-		if out == nil {
-			return nil, fmt.Errorf("TargetsField expression returned nil")
-		}
-		array, ok := out.Value().([]any)
-		if !ok {
-			return nil, fmt.Errorf("invalid HTTP response: targetsField expression must evaluate to an array of objects")
-		}
-		items = array
-	} else {
-		//If TargetsField is empty, the raw response is expected to be an array of items.
-		array, ok := raw.([]any)
-		if !ok {
-			return nil, fmt.Errorf("invalid HTTP response: expected a JSON array when itemsField is not set")
-		}
-		items = array
+	mapper, err := NewMapper(l.spec.ResponseMapping)
+	if err != nil {
+		return nil, err
 	}
-
-	// Map items to targets
-	var targets []core.DiscoveredTarget
-	targets, err := l.mapItemsToTargets(items, raw, logger)
+	targets, err := mapper.Targets(raw, logger)
 	if err != nil {
-		return nil, fmt.Errorf("mapping items to targets failed: %w", err)
+		return nil, fmt.Errorf("invalid HTTP response: %w", err)
 	}
-
 	return targets, nil
 }
 
@@ -5,65 +5,172 @@ import (
 	"math"
 	"reflect"
 	"strconv"
+	"strings"
 
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
 	"github.com/gnmic/operator/internal/controller/discovery/core"
+	"github.com/gnmic/operator/internal/utils"
 	"github.com/go-logr/logr"
 	"github.com/google/cel-go/cel"
 	"github.com/google/cel-go/common/types/ref"
 	"github.com/google/cel-go/ext"
 )
 
-// mapItemsToTargets converts a list of raw JSON items into DiscoveredTargets using the configured mapping rules
-func (l *Loader) mapItemsToTargets(items []any, full any, logger logr.Logger) ([]core.DiscoveredTarget, error) {
-	// Compile CEL expressions once for efficiency
-	compiled, err := l.compileMapping()
+// Mapper extracts targets from JSON documents following a ResponseMappingSpec.
+// It is used for HTTP responses and, with the operation of each target, for
+// pushed payloads.
+type Mapper struct {
+	compiled *compiledMapping
+}
+
+// NewMapper compiles the CEL expressions of a mapping. A nil mapping reads
+// the target fields directly from a JSON array of targets.
+func NewMapper(rm *gnmicv1alpha1.ResponseMappingSpec) (*Mapper, error) {
+	compiled, err := compileMapping(rm)
 	if err != nil {
 		return nil, fmt.Errorf("compile mapping: %w", err)
 	}
+	return &Mapper{compiled: compiled}, nil
+}
 
-	// Map items to targets
+// NewPushMapper compiles the CEL expressions of a push mapping.
+func NewPushMapper(pm *gnmicv1alpha1.PushMappingSpec) (*Mapper, error) {
+	if pm == nil {
+		return NewMapper(nil)
+	}
+	m, err := NewMapper(&pm.ResponseMappingSpec)
+	if err != nil {
+		return nil, err
+	}
+	if pm.Operation != "" {
+		m.compiled.operation, err = compileCEL(pm.Operation)
+		if err != nil {
+			return nil, fmt.Errorf("compile mapping: operation: %w", err)
+		}
+	}
+	return m, nil
+}
+
+// Items selects the target objects of a document with the targetsField
+// expression, or returns the document itself if it is an array.
+func (m *Mapper) Items(raw any) ([]any, error) {
+	// If TargetsField is provided we treat it as a CEL expression that
+	// evaluates against the whole document and must return an array of items.
+	if m.compiled.targetsField != nil {
+		out, _, err := m.compiled.targetsField.Eval(map[string]any{"self": raw})
+		if err != nil {
+			return nil, fmt.Errorf("evaluating TargetsField CEL expression failed: %w", err)
+		}
+		if out == nil {
+			return nil, fmt.Errorf("TargetsField expression returned nil")
+		}
+		array, ok := normalizeCEL(out).([]any)
+		if !ok {
+			return nil, fmt.Errorf("targetsField expression must evaluate to an array of objects")
+		}
+		return array, nil
+	}
+	// If TargetsField is empty, the document is expected to be an array of items.
+	array, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a JSON array when targetsField is not set")
+	}
+	return array, nil
+}
+
+// Targets maps the target objects of a document into DiscoveredTargets.
+// Objects that cannot be mapped are logged and skipped.
+func (m *Mapper) Targets(raw any, logger logr.Logger) ([]core.DiscoveredTarget, error) {
+	items, err := m.Items(raw)
+	if err != nil {
+		return nil, err
+	}
+	return m.mapItemsToTargets(items, raw, logger), nil
+}
+
+// Events maps the target objects of a pushed document into discovery events,
+// using the operation of each target. Objects that cannot be mapped are
+// logged and skipped.
+func (m *Mapper) Events(raw any, logger logr.Logger) ([]core.DiscoveryEvent, error) {
+	items, err := m.Items(raw)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]core.DiscoveryEvent, 0, len(items))
+	m.forEachTarget(items, raw, logger, func(obj map[string]any, target core.DiscoveredTarget) error {
+		action, err := m.getEvent(obj, raw)
+		if err != nil {
+			return err
+		}
+		events = append(events, core.DiscoveryEvent{Target: target, Event: action})
+		return nil
+	})
+	return events, nil
+}
+
+// mapItemsToTargets converts a list of raw JSON items into DiscoveredTargets using the configured mapping rules
+func (m *Mapper) mapItemsToTargets(items []any, full any, logger logr.Logger) []core.DiscoveredTarget {
 	targets := make([]core.DiscoveredTarget, 0, len(items))
+	m.forEachTarget(items, full, logger, func(_ map[string]any, target core.DiscoveredTarget) error {
+		targets = append(targets, target)
+		return nil
+	})
+	return targets
+}
+
+// forEachTarget maps each item and calls fn with the result, skipping the
+// items that fail to map
+func (m *Mapper) forEachTarget(items []any, full any, logger logr.Logger, fn func(map[string]any, core.DiscoveredTarget) error) {
 	for _, item := range items {
 		obj, ok := item.(map[string]any)
 		if !ok {
 			logger.Error(fmt.Errorf("invalid target format"),
 				"failed to convert target to map",
-				"item", item,
+				"item", utils.Redact(item),
 			)
 			continue
 		}
-		target, err := l.mapItemToTarget(obj, full, compiled)
+		target, err := m.mapItemToTarget(obj, full)
+		if err == nil {
+			err = fn(obj, target)
+		}
 		if err != nil {
 			logger.Error(err,
 				"failed to map target",
-				"item", obj,
+				"item", utils.Redact(obj),
 			)
-			continue
 		}
-
-		targets = append(targets, target)
 	}
-
-	return targets, nil
 }
 
 type compiledMapping struct {
+	targetsField cel.Program
+
 	name    cel.Program
 	address cel.Program
 	port    cel.Program
 
-	targetProfile cel.Program
-	labels        cel.Program
+	targetProfile  cel.Program
+	credentialsRef cel.Program
+	labels         cel.Program
+
+	// push mappings only
+	operation cel.Program
 }
 
-func (l *Loader) compileMapping() (*compiledMapping, error) {
-	rm := l.spec.ResponseMapping
+func compileMapping(rm *gnmicv1alpha1.ResponseMappingSpec) (*compiledMapping, error) {
 	cm := &compiledMapping{}
 	if rm == nil {
 		return cm, nil
 	}
 
 	var err error
+	if rm.TargetsField != "" {
+		cm.targetsField, err = compileCEL(rm.TargetsField)
+		if err != nil {
+			return nil, fmt.Errorf("targetsField: %w", err)
+		}
+	}
 	if rm.Name != "" {
 		cm.name, err = compileCEL(rm.Name)
 		if err != nil {
@@ -88,6 +195,12 @@ func (l *Loader) compileMapping() (*compiledMapping, error) {
 			return nil, fmt.Errorf("targetProfile: %w", err)
 		}
 	}
+	if rm.CredentialsRef != "" {
+		cm.credentialsRef, err = compileCEL(rm.CredentialsRef)
+		if err != nil {
+			return nil, fmt.Errorf("credentialsRef: %w", err)
+		}
+	}
 	if rm.Labels != "" {
 		cm.labels, err = compileCEL(rm.Labels)
 		if err != nil {
@@ -99,29 +212,60 @@ func (l *Loader) compileMapping() (*compiledMapping, error) {
 }
 
 // mapItemToTarget converts a raw JSON object into a DiscoveredTarget
-func (l *Loader) mapItemToTarget(item map[string]any, full any, cm *compiledMapping) (core.DiscoveredTarget, error) {
-	name, err := l.getName(item, full, cm)
+func (m *Mapper) mapItemToTarget(item map[string]any, full any) (core.DiscoveredTarget, error) {
+	cm := m.compiled
+	name, err := getName(item, full, cm)
 	if err != nil {
 		return core.DiscoveredTarget{}, err
 	}
 
-	address, err := l.getAddress(item, full, cm)
+	address, err := getAddress(item, full, cm)
 	if err != nil {
 		return core.DiscoveredTarget{}, err
 	}
 
 	return core.DiscoveredTarget{
-		Name:          name,
-		Address:       address,
-		Port:          l.getPort(item, full, cm),
-		Labels:        l.getLabels(item, full, cm),
-		TargetProfile: l.getTargetProfile(item, full, cm),
+		Name:           name,
+		Address:        address,
+		Port:           getPort(item, full, cm),
+		Labels:         getLabels(item, full, cm),
+		TargetProfile:  getTargetProfile(item, full, cm),
+		CredentialsRef: getCredentialsRef(item, full, cm),
 	}, nil
 }
 
+// getEvent extracts the operation of a pushed target using the compiled CEL
+// expression if provided, otherwise it falls back to the default "operation"
+// field. Targets without operation are applied.
+func (m *Mapper) getEvent(item map[string]any, full any) (core.EventAction, error) {
+	var op any = item["operation"]
+	if m.compiled.operation != nil {
+		val, err := evalCEL(m.compiled.operation, item, full)
+		if err != nil {
+			return core.EventApply, err
+		}
+		op = val
+	}
+	if op == nil {
+		return core.EventApply, nil
+	}
+	str, ok := op.(string)
+	if !ok {
+		return core.EventApply, fmt.Errorf("operation must be a string")
+	}
+	switch strings.ToLower(str) {
+	case "", "created", "updated":
+		return core.EventApply, nil
+	case "deleted":
+		return core.EventDelete, nil
+	default:
+		return core.EventApply, fmt.Errorf("unknown operation %q, must be created, updated or deleted", str)
+	}
+}
+
 // getName extracts the target name from the item using the compiled CEL expression if provided,
 // otherwise it falls back to the default "name" field
-func (l *Loader) getName(item map[string]any, full any, cm *compiledMapping) (string, error) {
+func getName(item map[string]any, full any, cm *compiledMapping) (string, error) {
 	if cm.name != nil {
 		val, err := evalCEL(cm.name, item, full)
 		if err != nil {
@@ -144,7 +288,7 @@ func (l *Loader) getName(item map[string]any, full any, cm *compiledMapping) (st
 
 // getAddress extracts the target address from the item using the compiled CEL expression if provided,
 // otherwise it falls back to the default "address" field
-func (l *Loader) getAddress(item map[string]any, full any, cm *compiledMapping) (string, error) {
+func getAddress(item map[string]any, full any, cm *compiledMapping) (string, error) {
 	if cm.address != nil {
 		val, err := evalCEL(cm.address, item, full)
 		if err != nil {
@@ -167,7 +311,7 @@ func (l *Loader) getAddress(item map[string]any, full any, cm *compiledMapping)
 
 // getPort extracts the target port from the item using the compiled CEL expression if provided,
 // otherwise it falls back to the default "port" field
-func (l *Loader) getPort(item map[string]any, full any, cm *compiledMapping) int32 {
+func getPort(item map[string]any, full any, cm *compiledMapping) int32 {
 	if cm.port != nil {
 		val, err := evalCEL(cm.port, item, full)
 		if err == nil {
@@ -181,7 +325,7 @@ func (l *Loader) getPort(item map[string]any, full any, cm *compiledMapping) int
 
 // getLabels extracts the target labels from the item using the compiled CEL expressions if provided,
 // otherwise it falls back to the default "labels" field
-func (l *Loader) getLabels(item map[string]any, full any, cm *compiledMapping) map[string]string {
+func getLabels(item map[string]any, full any, cm *compiledMapping) map[string]string {
 	result := make(map[string]string)
 
 	if cm != nil && cm.labels != nil {
@@ -209,7 +353,7 @@ func (l *Loader) getLabels(item map[string]any, full any, cm *compiledMapping) m
 
 // getTargetProfile extracts the target profile from the item using the compiled CEL expression if provided,
 // otherwise it falls back to the default "targetProfile" field
-func (l *Loader) getTargetProfile(item map[string]any, full any, cm *compiledMapping) string {
+func getTargetProfile(item map[string]any, full any, cm *compiledMapping) string {
 	if cm.targetProfile != nil {
 		val, err := evalCEL(cm.targetProfile, item, full)
 		if err == nil {
@@ -226,6 +370,25 @@ func (l *Loader) getTargetProfile(item map[string]any, full any, cm *compiledMap
 	return ""
 }
 
+// getCredentialsRef extracts the credentials Secret of the target from the item using the compiled
+// CEL expression if provided, otherwise it falls back to the default "credentialsRef" field
+func getCredentialsRef(item map[string]any, full any, cm *compiledMapping) string {
+	if cm.credentialsRef != nil {
+		val, err := evalCEL(cm.credentialsRef, item, full)
+		if err == nil {
+			if str, ok := val.(string); ok {
+				return str
+			}
+		}
+		return ""
+	}
+
+	if val, ok := item["credentialsRef"].(string); ok {
+		return val
+	}
+	return ""
+}
+
 var celEnv = mustNewEnv()
 
 // mustNewEnv creates a CEL environment with the necessary variable declarations for evaluating expressions
@@ -291,8 +454,17 @@ func normalizeCEL(v any) any {
 		return raw
 	}
 
-	// For maps, keys are converted to strings
+	// Lists built in CEL, e.g. [self.data], hold ref.Val elements
 	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		out := make([]any, rv.Len())
+		for i := range out {
+			out[i] = normalizeCEL(rv.Index(i).Interface())
+		}
+		return out
+	}
+
+	// For maps, keys are converted to strings
 	if rv.Kind() == reflect.Map {
 		out := make(map[string]any)
 		for _, key := range rv.MapKeys() {
@@ -18,7 +18,7 @@ func TestExtractTargetsAndMapping(t *testing.T) {
 		{
 			name:   "direct mapping all fields",
 			config: gnmicv1alpha1.HTTPConfig{},
-			raw:    []any{map[string]any{"name": "t1", "address": "1.1.1.1", "port": "9000", "labels": map[string]any{"env": "prod", "region": "us-east"}, "targetProfile": "edge-profile"}},
+			raw:    []any{map[string]any{"name": "t1", "address": "1.1.1.1", "port": "9000", "labels": map[string]any{"env": "prod", "region": "us-east"}, "targetProfile": "edge-profile", "credentialsRef": "t1-local"}},
 			validate: func(t *testing.T, targets []core.DiscoveredTarget) {
 				if len(targets) != 1 {
 					t.Fatalf("direct mapping: expected 1 target, got %d", len(targets))
@@ -39,6 +39,9 @@ func TestExtractTargetsAndMapping(t *testing.T) {
 				if tgt.TargetProfile != "edge-profile" {
 					t.Fatalf("direct mapping TargetProfile failed: got %q", tgt.TargetProfile)
 				}
+				if tgt.CredentialsRef != "t1-local" {
+					t.Fatalf("direct mapping CredentialsRef failed: got %q", tgt.CredentialsRef)
+				}
 			},
 		},
 		{
@@ -116,6 +119,24 @@ func TestExtractTargetsAndMapping(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "CEL CredentialsRef mapping",
+			config: gnmicv1alpha1.HTTPConfig{ResponseMapping: &gnmicv1alpha1.ResponseMappingSpec{
+				CredentialsRef: `has(item.custom_fields.credentials) ? item.custom_fields.credentials : ""`,
+			}},
+			raw: []any{
+				map[string]any{"name": "t1", "address": "10.0.0.1", "custom_fields": map[string]any{"credentials": "t1-local"}},
+				map[string]any{"name": "t2", "address": "10.0.0.2", "custom_fields": map[string]any{}},
+			},
+			validate: func(t *testing.T, targets []core.DiscoveredTarget) {
+				if len(targets) != 2 {
+					t.Fatalf("CredentialsRef mapping: expected 2 targets, got %d", len(targets))
+				}
+				if targets[0].CredentialsRef != "t1-local" || targets[1].CredentialsRef != "" {
+					t.Fatalf("CredentialsRef mapping failed: got %q and %q", targets[0].CredentialsRef, targets[1].CredentialsRef)
+				}
+			},
+		},
 		{
 			name:   "CEL all mapping options combined",
 			config: gnmicv1alpha1.HTTPConfig{ResponseMapping: &gnmicv1alpha1.ResponseMappingSpec{TargetsField: "self.results", Name: "item.hostname", Address: "item.ip", Port: "item.port", Labels: `{"env": item.env}`, TargetProfile: `item.type == "edge" ? "edge-profile" : "default"`}},
@@ -145,12 +166,66 @@ func TestExtractTargetsAndMapping(t *testing.T) {
 }
 
 func TestMapItemsToTargetsSkipsInvalidItems(t *testing.T) {
-	loader := makeLoader(gnmicv1alpha1.HTTPConfig{}, nil)
-	tgts, err := loader.mapItemsToTargets([]any{"not-a-map", map[string]any{"name": "n", "address": "a"}}, nil, logr.Discard())
+	mapper, err := NewMapper(nil)
 	if err != nil {
-		t.Fatalf("mapItemsToTargets failed: %v", err)
+		t.Fatalf("NewMapper failed: %v", err)
 	}
+	tgts := mapper.mapItemsToTargets([]any{"not-a-map", map[string]any{"name": "n", "address": "a"}}, nil, logr.Discard())
 	if len(tgts) != 1 || tgts[0].Name != "n" {
 		t.Fatalf("unexpected targets: %#v", tgts)
 	}
 }
+
+func TestPushMapperNetBoxEvents(t *testing.T) {
+	mapper, err := NewPushMapper(&gnmicv1alpha1.PushMappingSpec{
+		ResponseMappingSpec: gnmicv1alpha1.ResponseMappingSpec{
+			TargetsField: "[self.data]",
+			Address:      "item.primary_ip.address.split('/')[0]",
+			Labels:       `{"site": item.site.slug}`,
+		},
+		Operation: "self.event",
+	})
+	if err != nil {
+		t.Fatalf("NewPushMapper failed: %v", err)
+	}
+
+	payload := func(event string) any {
+		return map[string]any{
+			"event": event,
+			"model": "device",
+			"data": map[string]any{
+				"name":       "leaf1",
+				"primary_ip": map[string]any{"address": "10.0.0.1/32"},
+				"site":       map[string]any{"slug": "dc1"},
+			},
+		}
+	}
+
+	events, err := mapper.Events(payload("created"), logr.Discard())
+	if err != nil {
+		t.Fatalf("Events failed: %v", err)
+	}
+	if len(events) != 1 || events[0].Event != core.EventApply {
+		t.Fatalf("unexpected events: %#v", events)
+	}
+	if tgt := events[0].Target; tgt.Name != "leaf1" || tgt.Address != "10.0.0.1" || tgt.Labels["site"] != "dc1" {
+		t.Fatalf("unexpected target: %#v", tgt)
+	}
+
+	events, err = mapper.Events(payload("deleted"), logr.Discard())
+	if err != nil {
+		t.Fatalf("Events failed: %v", err)
+	}
+	if len(events) != 1 || events[0].Event != core.EventDelete {
+		t.Fatalf("unexpected events: %#v", events)
+	}
+
+	// unknown operations are skipped
+	events, err = mapper.Events(payload("renamed"), logr.Discard())
+	if err != nil {
+		t.Fatalf("Events failed: %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("expected no events, got %#v", events)
+	}
+}
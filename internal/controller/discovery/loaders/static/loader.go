@@ -0,0 +1,68 @@
+package static
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/controller/discovery/core"
+	loaderUtils "github.com/gnmic/operator/internal/controller/discovery/loaders/utils"
+)
+
+// Loader implements the static discovery mechanism
+// It emits the targets listed in its spec as a single snapshot
+type Loader struct {
+	loaderCfg core.CommonLoaderConfig
+	spec      gnmicv1alpha1.StaticConfig
+}
+
+// New creates a new static loader instance with the provided configuration
+func New(cfg core.CommonLoaderConfig, staticConfig gnmicv1alpha1.StaticConfig) core.Loader {
+	return &Loader{loaderCfg: cfg, spec: staticConfig}
+}
+
+// Name returns the loader's name, used for logging and metrics
+func (l *Loader) Name() string {
+	return "static"
+}
+
+// Run emits the targets once and waits for ctx to be canceled, as the spec
+// only changes with a new discovery runtime
+func (l *Loader) Run(ctx context.Context, out chan<- []core.DiscoveryMessage) error {
+	logger := log.FromContext(ctx).WithValues(
+		"component", "loader",
+		"name", l.Name(),
+		"targetsource", l.loaderCfg.TargetsourceNN,
+	)
+
+	targets := make([]core.DiscoveredTarget, len(l.spec.Targets))
+	for i, t := range l.spec.Targets {
+		targets[i] = core.DiscoveredTarget{
+			Name:           t.Name,
+			Address:        t.Address,
+			Port:           t.Port,
+			Labels:         t.Labels,
+			TargetProfile:  t.TargetProfile,
+			CredentialsRef: t.CredentialsRef,
+		}
+	}
+
+	snapshotID := fmt.Sprintf("%s-%s-%s", l.loaderCfg.TargetsourceNN.Namespace, l.loaderCfg.TargetsourceNN.Name, uuid.NewString())
+	if err := loaderUtils.SendSnapshot(ctx, out, targets, snapshotID, l.loaderCfg.ChunkSize); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("failed to send static targets: %w", err)
+	}
+	logger.Info(
+		"Discovery snapshot sent",
+		"snapshotID", snapshotID,
+		"targets", len(targets),
+	)
+
+	<-ctx.Done()
+	return nil
+}
@@ -0,0 +1,52 @@
+package static
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/controller/discovery/core"
+)
+
+func TestRunSendsTargetsOnce(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := New(core.CommonLoaderConfig{
+		TargetsourceNN: types.NamespacedName{Namespace: "default", Name: "ts1"},
+		ChunkSize:      10,
+	}, gnmicv1alpha1.StaticConfig{Targets: []gnmicv1alpha1.StaticTarget{
+		{Name: "router1", Address: "10.0.0.1", Port: 57400, Labels: map[string]string{"site": "ams"}},
+		{Name: "router2", Address: "10.0.0.2", TargetProfile: "arista"},
+	}})
+
+	out := make(chan []core.DiscoveryMessage, 2)
+	done := make(chan error)
+	go func() { done <- l.Run(ctx, out) }()
+
+	messages := <-out
+	snapshot, ok := messages[0].(core.DiscoverySnapshot)
+	if !ok {
+		t.Fatalf("expected a snapshot, got %T", messages[0])
+	}
+	if snapshot.TotalChunks != 1 || len(snapshot.Targets) != 2 {
+		t.Fatalf("unexpected snapshot: %+v", snapshot)
+	}
+	if snapshot.Targets[0].Labels["site"] != "ams" || snapshot.Targets[1].TargetProfile != "arista" {
+		t.Fatalf("unexpected targets: %+v", snapshot.Targets)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("loader did not stop")
+	}
+	if len(out) != 0 {
+		t.Fatalf("expected a single snapshot, got %d more messages", len(out))
+	}
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/gnmic/operator/internal/controller/discovery/core"
@@ -92,3 +93,84 @@ func SendEvents(ctx context.Context, out chan<- []core.DiscoveryMessage, events
 		return sendMessages(ctx, out, messages[i:end])
 	})
 }
+
+// ErrQueueFull is returned by TrySendEvents when the channel buffer cannot
+// take the events
+var ErrQueueFull = errors.New("discovery queue is full")
+
+// ErrTooLarge is returned by TrySendEvents and TrySendSnapshot when the
+// channel buffer cannot take the messages even when it is empty
+var ErrTooLarge = errors.New("discovery message exceeds the queue capacity")
+
+// TrySendEvents sends discovery events over a buffered channel in chunks
+// without blocking. It returns, before sending anything, ErrTooLarge when
+// the buffer cannot hold all chunks and ErrQueueFull when its free space
+// cannot. The number of events sent is returned, as another producer may
+// fill the buffer concurrently.
+func TrySendEvents(out chan<- []core.DiscoveryMessage, events []core.DiscoveryEvent, chunkSize int) (int, error) {
+	if len(events) == 0 {
+		return 0, fmt.Errorf("no events to process")
+	}
+	chunks := (len(events) + chunkSize - 1) / chunkSize
+	if chunks > cap(out) {
+		return 0, ErrTooLarge
+	}
+	if cap(out)-len(out) < chunks {
+		return 0, ErrQueueFull
+	}
+	messages := eventsToMessages(events)
+	sent := 0
+	err := forEachChunk(len(messages), chunkSize, func(i, end int) error {
+		select {
+		case out <- messages[i:end]:
+			sent = end
+			return nil
+		default:
+			return ErrQueueFull
+		}
+	})
+	return sent, err
+}
+
+// SnapshotChunks splits discovered targets in the chunks of a snapshot
+// linked to an operation, e.g. to journal them before sending them with
+// TrySendSnapshot.
+func SnapshotChunks(targets []core.DiscoveredTarget, snapshotID, operationID string, chunkSize int) []core.DiscoverySnapshot {
+	chunks := createDiscoverySnapshots(targets, snapshotID, chunkSize)
+	for i := range chunks {
+		chunks[i].OperationID = operationID
+	}
+	return chunks
+}
+
+// TrySendSnapshot sends the chunks of a snapshot over a buffered channel
+// without blocking, like TrySendEvents. It returns the number of chunks sent.
+func TrySendSnapshot(out chan<- []core.DiscoveryMessage, chunks []core.DiscoverySnapshot) (int, error) {
+	if len(chunks) == 0 {
+		return 0, fmt.Errorf("no targets in Snapshot")
+	}
+	if len(chunks) > cap(out) {
+		return 0, ErrTooLarge
+	}
+	if cap(out)-len(out) < len(chunks) {
+		return 0, ErrQueueFull
+	}
+	for i, chunk := range chunks {
+		select {
+		case out <- []core.DiscoveryMessage{chunk}:
+		default:
+			return i, ErrQueueFull
+		}
+	}
+	return len(chunks), nil
+}
+
+// AbortSnapshot tells the receiver of a partially sent snapshot to discard
+// its chunks. It blocks until the message is sent or ctx is done.
+func AbortSnapshot(ctx context.Context, out chan<- []core.DiscoveryMessage, snapshotID, operationID string) error {
+	return sendMessages(ctx, out, []core.DiscoveryMessage{core.DiscoverySnapshot{
+		SnapshotID:  snapshotID,
+		OperationID: operationID,
+		Aborted:     true,
+	}})
+}
@@ -79,3 +79,55 @@ func TestSendEvents_Basic(t *testing.T) {
 	msgs := drainChannel(ch)
 	require.Len(t, msgs, 2) // 2 chunks for 4 events with chunkSize=2
 }
+
+func TestTrySendEvents_QueueFull(t *testing.T) {
+	ch, _, cancel := mockChannel(2)
+	defer cancel()
+
+	sent, err := TrySendEvents(ch, mockEvents(4), 2)
+	require.NoError(t, err)
+	require.Equal(t, 4, sent)
+
+	// the buffer is full, nothing is sent
+	sent, err = TrySendEvents(ch, mockEvents(1), 2)
+	require.ErrorIs(t, err, ErrQueueFull)
+	require.Equal(t, 0, sent)
+
+	msgs := drainChannel(ch)
+	require.Len(t, msgs, 2)
+}
+
+func TestTrySendEvents_TooLarge(t *testing.T) {
+	ch, _, cancel := mockChannel(2)
+	defer cancel()
+
+	// 3 chunks never fit in a buffer of 2, even when it is empty
+	sent, err := TrySendEvents(ch, mockEvents(5), 2)
+	require.ErrorIs(t, err, ErrTooLarge)
+	require.Equal(t, 0, sent)
+	require.Empty(t, drainChannel(ch))
+}
+
+func TestTrySendSnapshot_TooLarge(t *testing.T) {
+	ch, _, cancel := mockChannel(2)
+	defer cancel()
+
+	chunks := SnapshotChunks(mockTargets(5), "snap-1", "op-1", 2)
+	sent, err := TrySendSnapshot(ch, chunks)
+	require.ErrorIs(t, err, ErrTooLarge)
+	require.Equal(t, 0, sent)
+	require.Empty(t, drainChannel(ch))
+}
+
+func TestAbortSnapshot(t *testing.T) {
+	ch, ctx, cancel := mockChannel(1)
+	defer cancel()
+
+	require.NoError(t, AbortSnapshot(ctx, ch, "snap-1", "op-1"))
+	msgs := drainChannel(ch)
+	require.Len(t, msgs, 1)
+	abort := msgs[0][0].(core.DiscoverySnapshot)
+	require.True(t, abort.Aborted)
+	require.Equal(t, "snap-1", abort.SnapshotID)
+	require.Empty(t, abort.Targets)
+}
@@ -35,6 +35,7 @@ func generateTargetResource(d core.DiscoveredTarget, ts *gnmicv1alpha1.TargetSou
 		targetProfile = d.TargetProfile
 	}
 	t.Spec.Profile = targetProfile
+	t.Spec.CredentialsRef = d.CredentialsRef
 
 	// Copy TargetLabels from TargetSource Spec & DiscoveredTarget. Discovered labels take precedence over TargetSource labels.
 	maps.Copy(t.Labels, ts.Spec.TargetLabels)
@@ -0,0 +1,569 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/controller/discovery/core"
+	loaderUtils "github.com/gnmic/operator/internal/controller/discovery/loaders/utils"
+)
+
+const (
+	// mergeSyncTimeout is how long the merge loader waits for the first
+	// snapshot of every provider before it emits the targets it has
+	mergeSyncTimeout = 2 * time.Minute
+	// maxReportedConflicts caps the conflicts written to the status
+	maxReportedConflicts = 100
+)
+
+// mergeProvider is a provider of a TargetSource with several providers
+type mergeProvider struct {
+	name   string
+	loader core.Loader
+	// synced is set once the provider emitted a complete snapshot
+	synced bool
+	// targets are the current targets of the provider by merge key
+	targets map[string]core.DiscoveredTarget
+	// keys are the merge keys of the targets by name
+	keys map[string]string
+	// snapshot collects the chunks of the snapshot being received
+	snapshot *providerSnapshot
+}
+
+type providerSnapshot struct {
+	id       string
+	received int
+	targets  []core.DiscoveredTarget
+}
+
+// providerMessages are discovery messages emitted by the provider at index
+type providerMessages struct {
+	index    int
+	messages []core.DiscoveryMessage
+}
+
+// mergeLoader runs the loaders of several providers and emits the
+// combination of their targets according to a merge policy. Snapshots are
+// only emitted once every provider synced, so that targets of a slower
+// provider are not deleted on startup.
+type mergeLoader struct {
+	cfg       *core.CommonLoaderConfig
+	policy    gnmicv1alpha1.MergePolicy
+	key       gnmicv1alpha1.MergeKey
+	keyLabel  string
+	providers []*mergeProvider
+	// push receives the messages pushed through the API for pushIndex,
+	// -1 if no provider accepts pushes
+	push      chan []core.DiscoveryMessage
+	pushIndex int
+
+	synced bool
+	// conflicts are the conflicts last written to the status, nil if they
+	// were not written yet
+	conflicts []gnmicv1alpha1.TargetConflict
+}
+
+func newMergeLoader(cfg *core.CommonLoaderConfig, providers []gnmicv1alpha1.NamedProviderSpec, merge *gnmicv1alpha1.MergeSpec) (*mergeLoader, error) {
+	m := &mergeLoader{
+		cfg:       cfg,
+		policy:    gnmicv1alpha1.MergePolicyUnion,
+		key:       gnmicv1alpha1.MergeKeyName,
+		push:      make(chan []core.DiscoveryMessage, cfg.BufferSize),
+		pushIndex: -1,
+	}
+	if merge != nil {
+		if merge.Policy != "" {
+			m.policy = merge.Policy
+		}
+		if merge.Key != "" {
+			m.key = merge.Key
+		}
+		m.keyLabel = merge.KeyLabel
+	}
+	cfg.MergePolicy = m.policy
+
+	for i, p := range providers {
+		loader, err := newProviderLoader(cfg, p.ProviderSpec)
+		if err != nil {
+			return nil, fmt.Errorf("provider %q: %w", p.Name, err)
+		}
+		mp := &mergeProvider{
+			name:    p.Name,
+			loader:  loader,
+			targets: make(map[string]core.DiscoveredTarget),
+			keys:    make(map[string]string),
+		}
+		if p.HTTP != nil && p.HTTP.Push != nil && p.HTTP.Push.Enabled && m.pushIndex < 0 {
+			m.pushIndex = i
+		}
+		m.providers = append(m.providers, mp)
+	}
+	return m, nil
+}
+
+// set stores a target of the provider under key, replacing the target of the
+// same name
+func (p *mergeProvider) set(key string, t core.DiscoveredTarget) {
+	if old, ok := p.keys[t.Name]; ok && old != key {
+		p.remove(t.Name)
+	}
+	p.targets[key] = t
+	p.keys[t.Name] = key
+}
+
+// remove deletes the target named name and returns its key
+func (p *mergeProvider) remove(name string) (string, bool) {
+	key, ok := p.keys[name]
+	if !ok {
+		return "", false
+	}
+	delete(p.keys, name)
+	// another target of the provider may have taken the key over
+	if t, ok := p.targets[key]; ok && t.Name == name {
+		delete(p.targets, key)
+	}
+	return key, true
+}
+
+// matchKey returns the key matching a target of the provider at index with
+// the targets of the other providers. A target without a value for the key
+// gets a key of its own.
+func (m *mergeLoader) matchKey(index int, t core.DiscoveredTarget) string {
+	var value string
+	switch m.key {
+	case gnmicv1alpha1.MergeKeyAddress:
+		value = t.Address
+	case gnmicv1alpha1.MergeKeyLabel:
+		value = t.Labels[m.keyLabel]
+	default:
+		return t.Name
+	}
+	if value == "" {
+		// NUL is neither part of an address nor of a label value
+		return fmt.Sprintf("\x00%d/%s", index, t.Name)
+	}
+	return value
+}
+
+// Name returns the loader's name, used for logging and metrics
+func (m *mergeLoader) Name() string {
+	return "merge"
+}
+
+// PushChannel returns the channel the API sends pushed messages to
+func (m *mergeLoader) PushChannel() chan<- []core.DiscoveryMessage {
+	return m.push
+}
+
+// Run starts the provider loaders and merges their messages until ctx is
+// canceled
+func (m *mergeLoader) Run(ctx context.Context, out chan<- []core.DiscoveryMessage) error {
+	logger := log.FromContext(ctx).WithValues(
+		"component", "loader",
+		"name", m.Name(),
+		"targetsource", m.cfg.TargetsourceNN,
+		"policy", m.policy,
+	)
+	logger.Info("Merge loader started", "providers", len(m.providers))
+
+	in := make(chan providerMessages, m.cfg.BufferSize)
+	for i, p := range m.providers {
+		ch := make(chan []core.DiscoveryMessage, m.cfg.BufferSize)
+		go func() {
+			if err := p.loader.Run(ctx, ch); err != nil {
+				logger.Error(err, "Provider loader exited", "provider", p.name)
+			}
+		}()
+		go forwardProviderMessages(ctx, i, ch, in)
+	}
+	if m.pushIndex >= 0 {
+		go forwardProviderMessages(ctx, m.pushIndex, m.push, in)
+	}
+
+	syncTimer := time.NewTimer(mergeSyncTimeout)
+	defer syncTimer.Stop()
+	m.checkSynced(ctx, out, logger)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Merge loader stopped")
+			return nil
+		case <-syncTimer.C:
+			if !m.synced {
+				logger.Info("Not all providers synced, emitting the targets discovered so far")
+				m.synced = true
+				m.emitSnapshot(ctx, out, logger)
+			}
+		case pm := <-in:
+			for _, message := range pm.messages {
+				switch msg := message.(type) {
+				case core.DiscoverySnapshot:
+					m.handleSnapshot(ctx, pm.index, msg, out, logger)
+				case core.DiscoveryEvent:
+					m.handleEvent(ctx, pm.index, msg, out, logger)
+				default:
+					logger.Error(nil, "Unknown discovery message type", "type", fmt.Sprintf("%T", msg))
+				}
+			}
+		}
+	}
+}
+
+// forwardProviderMessages tags the messages of a provider with its index
+func forwardProviderMessages(ctx context.Context, index int, ch <-chan []core.DiscoveryMessage, in chan<- providerMessages) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case messages := <-ch:
+			select {
+			case <-ctx.Done():
+				return
+			case in <- providerMessages{index: index, messages: messages}:
+			}
+		}
+	}
+}
+
+// handleSnapshot collects the chunks of a provider snapshot and replaces
+// the targets of the provider once it is complete
+func (m *mergeLoader) handleSnapshot(ctx context.Context, index int, chunk core.DiscoverySnapshot, out chan<- []core.DiscoveryMessage, logger logr.Logger) {
+	p := m.providers[index]
+	if chunk.Aborted {
+		if p.snapshot != nil && p.snapshot.id == chunk.SnapshotID {
+			p.snapshot = nil
+		}
+		return
+	}
+	if p.snapshot == nil || p.snapshot.id != chunk.SnapshotID {
+		p.snapshot = &providerSnapshot{id: chunk.SnapshotID}
+	}
+	p.snapshot.received++
+	p.snapshot.targets = append(p.snapshot.targets, chunk.Targets...)
+	if p.snapshot.received < chunk.TotalChunks {
+		return
+	}
+
+	p.targets = make(map[string]core.DiscoveredTarget, len(p.snapshot.targets))
+	p.keys = make(map[string]string, len(p.snapshot.targets))
+	for _, t := range p.snapshot.targets {
+		p.set(m.matchKey(index, t), t)
+	}
+	p.snapshot = nil
+	p.synced = true
+
+	if m.synced {
+		m.emitSnapshot(ctx, out, logger)
+		return
+	}
+	m.checkSynced(ctx, out, logger)
+}
+
+// checkSynced emits the first snapshot once all providers synced
+func (m *mergeLoader) checkSynced(ctx context.Context, out chan<- []core.DiscoveryMessage, logger logr.Logger) {
+	for _, p := range m.providers {
+		if !p.synced {
+			return
+		}
+	}
+	m.synced = true
+	m.emitSnapshot(ctx, out, logger)
+}
+
+// emitSnapshot sends the merged targets of all providers as a snapshot
+func (m *mergeLoader) emitSnapshot(ctx context.Context, out chan<- []core.DiscoveryMessage, logger logr.Logger) {
+	m.reportConflicts(ctx, logger)
+
+	targets := m.mergeAll()
+	if len(targets) == 0 {
+		logger.Info("No merged targets, skipping snapshot")
+		return
+	}
+	snapshotID := fmt.Sprintf("%s-%s-%s", m.cfg.TargetsourceNN.Namespace, m.cfg.TargetsourceNN.Name, uuid.NewString())
+	if err := loaderUtils.SendSnapshot(ctx, out, targets, snapshotID, m.cfg.ChunkSize); err != nil {
+		logger.Error(err, "Failed to send merged snapshot", "snapshotID", snapshotID)
+		return
+	}
+	logger.Info(
+		"Merged snapshot sent",
+		"snapshotID", snapshotID,
+		"targets", len(targets),
+	)
+}
+
+// handleEvent updates the targets of a provider and emits the resulting
+// merged target. The operation and journal entry of the event are resolved
+// here when the merged event does not carry them.
+func (m *mergeLoader) handleEvent(ctx context.Context, index int, event core.DiscoveryEvent, out chan<- []core.DiscoveryMessage, logger logr.Logger) {
+	p := m.providers[index]
+	name := event.Target.Name
+	// a changed key, e.g. a new address, also changes the target merged
+	// under the previous key
+	var key, oldKey string
+	switch event.Event {
+	case core.EventApply:
+		key = m.matchKey(index, event.Target)
+		if k, ok := p.keys[name]; ok && k != key {
+			oldKey = k
+		}
+	case core.EventDelete:
+		var ok bool
+		if key, ok = p.keys[name]; !ok {
+			key = m.matchKey(index, event.Target)
+		}
+	}
+	prev, hadPrev := m.merge(key)
+	var oldPrev core.DiscoveredTarget
+	var hadOldPrev bool
+	if oldKey != "" {
+		oldPrev, hadOldPrev = m.merge(oldKey)
+	}
+
+	switch event.Event {
+	case core.EventApply:
+		p.set(key, event.Target)
+	case core.EventDelete:
+		p.remove(name)
+	}
+	m.reportConflicts(ctx, logger)
+
+	var messages []core.DiscoveryMessage
+	if oldKey != "" {
+		oldMerged, ok := m.merge(oldKey)
+		messages = append(messages, mergedChanges(oldPrev, hadOldPrev, oldMerged, ok)...)
+	}
+	merged, ok := m.merge(key)
+	if hadPrev && ok && prev.Name != merged.Name {
+		// the merged target is now named after another provider
+		messages = append(messages, core.DiscoveryEvent{Target: core.DiscoveredTarget{Name: prev.Name}, Event: core.EventDelete})
+	}
+	switch {
+	case ok && event.Event == core.EventApply:
+		result := event
+		result.Target = merged
+		messages = append(messages, result)
+	case ok:
+		// another provider still has the target, the deletion succeeded
+		// for the provider but the target is updated
+		m.resolve(ctx, event, logger)
+		messages = append(messages, core.DiscoveryEvent{Target: merged, Event: core.EventApply})
+	case event.Event == core.EventDelete:
+		result := event
+		if hadPrev {
+			result.Target.Name = prev.Name
+		}
+		messages = append(messages, result)
+	default:
+		// the merged target does not exist, e.g. an unmatched enrichment
+		m.resolve(ctx, event, logger)
+	}
+	if len(messages) == 0 {
+		return
+	}
+
+	select {
+	case <-ctx.Done():
+	case out <- messages:
+	}
+}
+
+// mergedChanges returns the events turning the merged target before into the
+// merged target after
+func mergedChanges(before core.DiscoveredTarget, hadBefore bool, after core.DiscoveredTarget, hasAfter bool) []core.DiscoveryMessage {
+	var messages []core.DiscoveryMessage
+	if hadBefore && (!hasAfter || before.Name != after.Name) {
+		messages = append(messages, core.DiscoveryEvent{Target: core.DiscoveredTarget{Name: before.Name}, Event: core.EventDelete})
+	}
+	if hasAfter {
+		messages = append(messages, core.DiscoveryEvent{Target: after, Event: core.EventApply})
+	}
+	return messages
+}
+
+// resolve reports an event as applied and removes it from the journal
+func (m *mergeLoader) resolve(ctx context.Context, event core.DiscoveryEvent, logger logr.Logger) {
+	m.cfg.Operations.Report(event.OperationID, event, nil)
+	if m.cfg.Journal == nil || event.JournalSeq == 0 {
+		return
+	}
+	if err := m.cfg.Journal.Ack(ctx, event.JournalSeq); err != nil {
+		logger.Error(err, "Failed to acknowledge journaled discovery message", "seq", event.JournalSeq)
+	}
+}
+
+// mergeAll returns the merged targets of all providers sorted by name
+func (m *mergeLoader) mergeAll() []core.DiscoveredTarget {
+	var targets []core.DiscoveredTarget
+	for _, key := range m.targetKeys() {
+		if t, ok := m.merge(key); ok {
+			targets = append(targets, t)
+		}
+	}
+	slices.SortStableFunc(targets, func(a, b core.DiscoveredTarget) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return targets
+}
+
+// targetKeys returns the sorted merge keys of the targets of all providers
+func (m *mergeLoader) targetKeys() []string {
+	seen := make(map[string]struct{})
+	var keys []string
+	for _, p := range m.providers {
+		for key := range p.targets {
+			if _, ok := seen[key]; !ok {
+				seen[key] = struct{}{}
+				keys = append(keys, key)
+			}
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// merge combines the targets matching key according to the merge policy. The
+// merged target is named after the first provider that has it. Targets
+// without an address after merging are dropped.
+func (m *mergeLoader) merge(key string) (core.DiscoveredTarget, bool) {
+	var merged core.DiscoveredTarget
+	found := false
+	for i, p := range m.providers {
+		t, ok := p.targets[key]
+		if !ok {
+			if i == 0 && m.policy == gnmicv1alpha1.MergePolicyEnrich {
+				return core.DiscoveredTarget{}, false
+			}
+			continue
+		}
+		if !found {
+			merged = t
+			merged.Labels = make(map[string]string, len(t.Labels))
+			for k, v := range t.Labels {
+				merged.Labels[k] = v
+			}
+			found = true
+			if m.policy == gnmicv1alpha1.MergePolicyUnion {
+				break
+			}
+			continue
+		}
+		fillTarget(&merged, t)
+	}
+	if !found || merged.Address == "" {
+		return core.DiscoveredTarget{}, false
+	}
+	return merged, true
+}
+
+// fillTarget sets the empty fields and missing labels of dst from src
+func fillTarget(dst *core.DiscoveredTarget, src core.DiscoveredTarget) {
+	if dst.Address == "" {
+		dst.Address = src.Address
+	}
+	if dst.Port == 0 {
+		dst.Port = src.Port
+	}
+	if dst.TargetProfile == "" {
+		dst.TargetProfile = src.TargetProfile
+	}
+	if dst.CredentialsRef == "" {
+		dst.CredentialsRef = src.CredentialsRef
+	}
+	for k, v := range src.Labels {
+		if _, ok := dst.Labels[k]; !ok {
+			dst.Labels[k] = v
+		}
+	}
+}
+
+// computeConflicts returns the targets several providers disagree on, by
+// merge key and capped to maxReportedConflicts
+func (m *mergeLoader) computeConflicts() []gnmicv1alpha1.TargetConflict {
+	conflicts := []gnmicv1alpha1.TargetConflict{}
+	for _, key := range m.targetKeys() {
+		var providers []string
+		var targets []core.DiscoveredTarget
+		for _, p := range m.providers {
+			if t, ok := p.targets[key]; ok {
+				providers = append(providers, p.name)
+				targets = append(targets, t)
+			}
+		}
+		if len(targets) < 2 {
+			continue
+		}
+		fields := conflictingFields(targets)
+		if len(fields) == 0 {
+			continue
+		}
+		conflicts = append(conflicts, gnmicv1alpha1.TargetConflict{
+			Target:    targets[0].Name,
+			Providers: providers,
+			Fields:    fields,
+		})
+		if len(conflicts) == maxReportedConflicts {
+			break
+		}
+	}
+	return conflicts
+}
+
+// conflictingFields returns the fields with different non-empty values
+func conflictingFields(targets []core.DiscoveredTarget) []string {
+	values := map[string]map[string]struct{}{}
+	add := func(field, value string) {
+		if value == "" {
+			return
+		}
+		if values[field] == nil {
+			values[field] = map[string]struct{}{}
+		}
+		values[field][value] = struct{}{}
+	}
+	for _, t := range targets {
+		add("name", t.Name)
+		add("address", t.Address)
+		if t.Port != 0 {
+			add("port", fmt.Sprint(t.Port))
+		}
+		add("targetProfile", t.TargetProfile)
+		add("credentialsRef", t.CredentialsRef)
+		for k, v := range t.Labels {
+			add("labels."+k, v)
+		}
+	}
+
+	var fields []string
+	for field, v := range values {
+		if len(v) > 1 {
+			fields = append(fields, field)
+		}
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+// reportConflicts writes the conflicts to the status when they changed
+func (m *mergeLoader) reportConflicts(ctx context.Context, logger logr.Logger) {
+	conflicts := m.computeConflicts()
+	if m.conflicts != nil && reflect.DeepEqual(conflicts, m.conflicts) {
+		return
+	}
+	m.conflicts = conflicts
+	if m.cfg.Updater == nil {
+		return
+	}
+	if err := m.cfg.Updater.UpdateStatus(ctx, core.StatusUpdate{Conflicts: &conflicts}); err != nil {
+		logger.Error(err, "Failed to update TargetSource conflicts")
+	}
+}
@@ -0,0 +1,288 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/controller/discovery/core"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+type recordingUpdater struct {
+	updates []core.StatusUpdate
+}
+
+func (u *recordingUpdater) UpdateStatus(_ context.Context, update core.StatusUpdate) error {
+	u.updates = append(u.updates, update)
+	return nil
+}
+
+// mockMergeLoader returns a merge loader whose providers hold the given targets
+func mockMergeLoader(t *testing.T, policy gnmicv1alpha1.MergePolicy, targets ...[]core.DiscoveredTarget) *mergeLoader {
+	t.Helper()
+	return mockKeyedMergeLoader(t, &gnmicv1alpha1.MergeSpec{Policy: policy}, targets...)
+}
+
+// mockKeyedMergeLoader returns a merge loader with the given merge spec whose
+// providers hold the given targets
+func mockKeyedMergeLoader(t *testing.T, merge *gnmicv1alpha1.MergeSpec, targets ...[]core.DiscoveredTarget) *mergeLoader {
+	t.Helper()
+	providers := make([]gnmicv1alpha1.NamedProviderSpec, len(targets))
+	names := []string{"netbox", "overrides", "cmdb"}
+	for i := range targets {
+		providers[i] = gnmicv1alpha1.NamedProviderSpec{
+			Name: names[i],
+			ProviderSpec: gnmicv1alpha1.ProviderSpec{Static: &gnmicv1alpha1.StaticConfig{
+				Targets: []gnmicv1alpha1.StaticTarget{{Name: "unused"}},
+			}},
+		}
+	}
+	m, err := newMergeLoader(&core.CommonLoaderConfig{ChunkSize: 10, BufferSize: 10}, providers, merge)
+	require.NoError(t, err)
+	for i, list := range targets {
+		for _, target := range list {
+			m.providers[i].set(m.matchKey(i, target), target)
+		}
+	}
+	return m
+}
+
+func TestMergeLoader_Policies(t *testing.T) {
+	netbox := []core.DiscoveredTarget{
+		{Name: "router1", Address: "10.0.0.1", Labels: map[string]string{"site": "ams"}},
+		{Name: "router2", Address: "10.0.0.2"},
+	}
+	overrides := []core.DiscoveredTarget{
+		{Name: "router1", Address: "10.9.9.1", Port: 6030, TargetProfile: "arista", Labels: map[string]string{"site": "fra", "role": "spine"}},
+		{Name: "router3", Port: 57400},
+		{Name: "router4", Address: "10.0.0.4"},
+	}
+
+	tests := []struct {
+		policy gnmicv1alpha1.MergePolicy
+		want   []core.DiscoveredTarget
+	}{
+		{
+			policy: gnmicv1alpha1.MergePolicyUnion,
+			want: []core.DiscoveredTarget{
+				{Name: "router1", Address: "10.0.0.1", Labels: map[string]string{"site": "ams"}},
+				{Name: "router2", Address: "10.0.0.2", Labels: map[string]string{}},
+				{Name: "router4", Address: "10.0.0.4", Labels: map[string]string{}},
+			},
+		},
+		{
+			policy: gnmicv1alpha1.MergePolicyPrecedence,
+			want: []core.DiscoveredTarget{
+				{Name: "router1", Address: "10.0.0.1", Port: 6030, TargetProfile: "arista", Labels: map[string]string{"site": "ams", "role": "spine"}},
+				{Name: "router2", Address: "10.0.0.2", Labels: map[string]string{}},
+				{Name: "router4", Address: "10.0.0.4", Labels: map[string]string{}},
+			},
+		},
+		{
+			policy: gnmicv1alpha1.MergePolicyEnrich,
+			want: []core.DiscoveredTarget{
+				{Name: "router1", Address: "10.0.0.1", Port: 6030, TargetProfile: "arista", Labels: map[string]string{"site": "ams", "role": "spine"}},
+				{Name: "router2", Address: "10.0.0.2", Labels: map[string]string{}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			m := mockMergeLoader(t, tt.policy, netbox, overrides)
+			require.Equal(t, tt.want, m.mergeAll())
+		})
+	}
+}
+
+func TestMergeLoader_Keys(t *testing.T) {
+	netbox := []core.DiscoveredTarget{
+		{Name: "leaf1", Address: "10.0.0.1", Labels: map[string]string{"serial": "SN1"}},
+		{Name: "leaf2", Address: "10.0.0.2"},
+	}
+	cmdb := []core.DiscoveredTarget{
+		{Name: "sn1", Address: "10.0.0.1", Port: 6030, Labels: map[string]string{"serial": "SN1", "role": "spine"}},
+		{Name: "sn2", Address: "10.0.0.2", Port: 57400},
+	}
+
+	tests := []struct {
+		name  string
+		merge *gnmicv1alpha1.MergeSpec
+		want  []core.DiscoveredTarget
+	}{
+		{
+			name:  "name",
+			merge: &gnmicv1alpha1.MergeSpec{Policy: gnmicv1alpha1.MergePolicyEnrich},
+			want: []core.DiscoveredTarget{
+				{Name: "leaf1", Address: "10.0.0.1", Labels: map[string]string{"serial": "SN1"}},
+				{Name: "leaf2", Address: "10.0.0.2", Labels: map[string]string{}},
+			},
+		},
+		{
+			name:  "address",
+			merge: &gnmicv1alpha1.MergeSpec{Policy: gnmicv1alpha1.MergePolicyEnrich, Key: gnmicv1alpha1.MergeKeyAddress},
+			want: []core.DiscoveredTarget{
+				{Name: "leaf1", Address: "10.0.0.1", Port: 6030, Labels: map[string]string{"serial": "SN1", "role": "spine"}},
+				{Name: "leaf2", Address: "10.0.0.2", Port: 57400, Labels: map[string]string{}},
+			},
+		},
+		{
+			// leaf2 has no serial, it is not matched
+			name:  "label",
+			merge: &gnmicv1alpha1.MergeSpec{Policy: gnmicv1alpha1.MergePolicyEnrich, Key: gnmicv1alpha1.MergeKeyLabel, KeyLabel: "serial"},
+			want: []core.DiscoveredTarget{
+				{Name: "leaf1", Address: "10.0.0.1", Port: 6030, Labels: map[string]string{"serial": "SN1", "role": "spine"}},
+				{Name: "leaf2", Address: "10.0.0.2", Labels: map[string]string{}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mockKeyedMergeLoader(t, tt.merge, netbox, cmdb)
+			require.Equal(t, tt.want, m.mergeAll())
+		})
+	}
+}
+
+func TestMergeLoader_Conflicts(t *testing.T) {
+	m := mockMergeLoader(t, gnmicv1alpha1.MergePolicyPrecedence,
+		[]core.DiscoveredTarget{
+			{Name: "router1", Address: "10.0.0.1", Labels: map[string]string{"site": "ams"}},
+			{Name: "router2", Address: "10.0.0.2"},
+		},
+		[]core.DiscoveredTarget{
+			{Name: "router1", Address: "10.9.9.1", Port: 6030, Labels: map[string]string{"site": "fra"}},
+			{Name: "router2", Port: 57400},
+		},
+	)
+	updater := &recordingUpdater{}
+	m.cfg.Updater = updater
+
+	m.reportConflicts(context.Background(), logr.Discard())
+	want := []gnmicv1alpha1.TargetConflict{{
+		Target:    "router1",
+		Providers: []string{"netbox", "overrides"},
+		Fields:    []string{"address", "labels.site"},
+	}}
+	require.Len(t, updater.updates, 1)
+	require.Equal(t, want, *updater.updates[0].Conflicts)
+
+	// unchanged conflicts are not written again
+	m.reportConflicts(context.Background(), logr.Discard())
+	require.Len(t, updater.updates, 1)
+
+	delete(m.providers[1].targets, "router1")
+	m.reportConflicts(context.Background(), logr.Discard())
+	require.Len(t, updater.updates, 2)
+	require.Empty(t, *updater.updates[1].Conflicts)
+}
+
+func TestMergeLoader_Events(t *testing.T) {
+	ctx := context.Background()
+	m := mockMergeLoader(t, gnmicv1alpha1.MergePolicyEnrich,
+		[]core.DiscoveredTarget{{Name: "router1", Address: "10.0.0.1"}},
+		[]core.DiscoveredTarget{{Name: "router1", Labels: map[string]string{"role": "spine"}}},
+	)
+	m.cfg.Operations = core.NewOperationStore(time.Minute)
+	out := make(chan []core.DiscoveryMessage, 10)
+
+	// an enrichment is emitted as the merged target
+	m.handleEvent(ctx, 1, core.DiscoveryEvent{
+		Event:  core.EventApply,
+		Target: core.DiscoveredTarget{Name: "router1", Labels: map[string]string{"role": "leaf"}},
+	}, out, logr.Discard())
+	require.Equal(t, []core.DiscoveryMessage{core.DiscoveryEvent{
+		Event:  core.EventApply,
+		Target: core.DiscoveredTarget{Name: "router1", Address: "10.0.0.1", Labels: map[string]string{"role": "leaf"}},
+	}}, <-out)
+
+	// an enrichment without base target is resolved without any message
+	events := []core.DiscoveryEvent{{Event: core.EventApply, Target: core.DiscoveredTarget{Name: "router2"}}}
+	op, _, err := m.cfg.Operations.Create("", "", events)
+	require.NoError(t, err)
+	m.handleEvent(ctx, 1, events[0], out, logr.Discard())
+	require.Empty(t, out)
+	op, _ = m.cfg.Operations.Get(op.ID)
+	require.Equal(t, core.OperationSucceeded, op.State)
+
+	// deleting the enrichment updates the target
+	m.handleEvent(ctx, 1, core.DiscoveryEvent{Event: core.EventDelete, Target: core.DiscoveredTarget{Name: "router1"}}, out, logr.Discard())
+	require.Equal(t, []core.DiscoveryMessage{core.DiscoveryEvent{
+		Event:  core.EventApply,
+		Target: core.DiscoveredTarget{Name: "router1", Address: "10.0.0.1", Labels: map[string]string{}},
+	}}, <-out)
+
+	// deleting the base target deletes the target
+	deletion := core.DiscoveryEvent{Event: core.EventDelete, Target: core.DiscoveredTarget{Name: "router1"}}
+	m.handleEvent(ctx, 0, deletion, out, logr.Discard())
+	require.Equal(t, []core.DiscoveryMessage{deletion}, <-out)
+}
+
+func TestMergeLoader_KeyedEvents(t *testing.T) {
+	ctx := context.Background()
+	m := mockKeyedMergeLoader(t, &gnmicv1alpha1.MergeSpec{Policy: gnmicv1alpha1.MergePolicyUnion, Key: gnmicv1alpha1.MergeKeyAddress},
+		[]core.DiscoveredTarget{{Name: "leaf1", Address: "10.0.0.1"}},
+		[]core.DiscoveredTarget{{Name: "sn1", Address: "10.0.0.1"}},
+	)
+	m.cfg.Operations = core.NewOperationStore(time.Minute)
+	out := make(chan []core.DiscoveryMessage, 10)
+
+	// an address change moves leaf1 away from sn1, which is applied under its
+	// own name
+	m.handleEvent(ctx, 0, core.DiscoveryEvent{
+		Event:  core.EventApply,
+		Target: core.DiscoveredTarget{Name: "leaf1", Address: "10.0.0.9"},
+	}, out, logr.Discard())
+	require.Equal(t, []core.DiscoveryMessage{
+		core.DiscoveryEvent{Event: core.EventDelete, Target: core.DiscoveredTarget{Name: "leaf1"}},
+		core.DiscoveryEvent{Event: core.EventApply, Target: core.DiscoveredTarget{Name: "sn1", Address: "10.0.0.1", Labels: map[string]string{}}},
+		core.DiscoveryEvent{Event: core.EventApply, Target: core.DiscoveredTarget{Name: "leaf1", Address: "10.0.0.9", Labels: map[string]string{}}},
+	}, <-out)
+
+	// deleting sn1, alone at its address now, deletes the merged target
+	deletion := core.DiscoveryEvent{Event: core.EventDelete, Target: core.DiscoveredTarget{Name: "sn1"}}
+	m.handleEvent(ctx, 1, deletion, out, logr.Discard())
+	require.Equal(t, []core.DiscoveryMessage{deletion}, <-out)
+	require.Empty(t, m.providers[1].targets)
+}
+
+func TestMergeLoader_RunWaitsForAllProviders(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m, err := newMergeLoader(&core.CommonLoaderConfig{ChunkSize: 1, BufferSize: 10}, []gnmicv1alpha1.NamedProviderSpec{
+		{Name: "inventory", ProviderSpec: gnmicv1alpha1.ProviderSpec{Static: &gnmicv1alpha1.StaticConfig{
+			Targets: []gnmicv1alpha1.StaticTarget{
+				{Name: "router1", Address: "10.0.0.1"},
+				{Name: "router2", Address: "10.0.0.2"},
+			},
+		}}},
+		{Name: "overrides", ProviderSpec: gnmicv1alpha1.ProviderSpec{Static: &gnmicv1alpha1.StaticConfig{
+			Targets: []gnmicv1alpha1.StaticTarget{{Name: "router2", Port: 6030}},
+		}}},
+	}, &gnmicv1alpha1.MergeSpec{Policy: gnmicv1alpha1.MergePolicyPrecedence})
+	require.NoError(t, err)
+
+	out := make(chan []core.DiscoveryMessage, 10)
+	go func() { _ = m.Run(ctx, out) }()
+
+	var targets []core.DiscoveredTarget
+	for len(targets) < 2 {
+		select {
+		case messages := <-out:
+			snapshot, ok := messages[0].(core.DiscoverySnapshot)
+			require.True(t, ok)
+			require.Equal(t, 2, snapshot.TotalChunks)
+			targets = append(targets, snapshot.Targets...)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the merged snapshot")
+		}
+	}
+	require.Equal(t, "router2", targets[1].Name)
+	require.Equal(t, int32(6030), targets[1].Port)
+}
@@ -2,7 +2,10 @@ package discovery
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -16,11 +19,16 @@ import (
 
 type snapshotBuffer struct {
 	snapshotID  string
+	operationID string
 	totalChunks int
 	received    map[int][]core.DiscoveredTarget
 	complete    bool
+	// journal sequence numbers of the received chunks
+	seqs []uint64
 }
 
+var errSnapshotNotApplied = errors.New("snapshot was not applied")
+
 // MessageProcessor consumes discovery messages and applies them to Kubernetes
 type MessageProcessor struct {
 	client         client.Client
@@ -33,16 +41,31 @@ type MessageProcessor struct {
 	deferredEvents []core.DiscoveryEvent
 	targetCount    int32
 	updater        core.StatusUpdater
+	// operations receives the per target results of pushed events
+	operations *core.OperationStore
+	// journal holds the journaled messages until they are handled, nil if
+	// journaling is disabled
+	journal core.Journal
+	// replayedSeq is the highest sequence number replayed from the journal,
+	// the same messages received over the channel are skipped
+	replayedSeq uint64
+	// handled messages, acknowledged once the queue is drained
+	acks          []uint64
+	reportedDepth int32
 }
 
 // NewMessageProcessor wires a MessageProcessor instance
-func NewMessageProcessor(c client.Client, s *runtime.Scheme, ts *gnmicv1alpha1.TargetSource, in <-chan []core.DiscoveryMessage, u core.StatusUpdater) *MessageProcessor {
+func NewMessageProcessor(c client.Client, s *runtime.Scheme, ts *gnmicv1alpha1.TargetSource, in <-chan []core.DiscoveryMessage, u core.StatusUpdater, ops *core.OperationStore, j core.Journal) *MessageProcessor {
 	return &MessageProcessor{
 		client:       c,
 		scheme:       s,
 		targetSource: ts,
 		in:           in,
 		updater:      u,
+		operations:   ops,
+		journal:      j,
+		// the depth left in the status by a previous run is overwritten
+		reportedDepth: -1,
 	}
 }
 
@@ -64,21 +87,10 @@ func (m *MessageProcessor) Run(ctx context.Context) error {
 		m.targetCount = int32(len(existing))
 	}
 
-	for {
-		select {
-		case batch, ok := <-m.in:
-			if !ok {
-				// Channel closed, pipeline is shutting down
-				logger.Info("Input channel closed; stopping message processor")
-				return nil
-			}
-			m.queue = append(m.queue, batch...)
-
-		case <-ctx.Done():
-			logger.Info("Context was canceled; stopping message processor")
-			return nil
-		}
+	// Replay the messages that were not handled before a restart
+	m.replayJournal(ctx, logger)
 
+	for {
 		for len(m.queue) > 0 {
 			if ctx.Err() != nil {
 				return ctx.Err()
@@ -94,11 +106,109 @@ func (m *MessageProcessor) Run(ctx context.Context) error {
 					"Could not process the message",
 					"error", err,
 				)
+				m.flushAcks(ctx, logger)
+				m.failPending(fmt.Errorf("discovery pipeline stopped: %w", err))
 				return nil
 			}
 
 		}
+		m.flushAcks(ctx, logger)
+		m.reportQueueDepth(ctx, logger)
+
+		select {
+		case batch, ok := <-m.in:
+			if !ok {
+				// Channel closed, pipeline is shutting down
+				logger.Info("Input channel closed; stopping message processor")
+				return nil
+			}
+			m.queue = append(m.queue, m.skipReplayed(batch)...)
+
+		case <-ctx.Done():
+			logger.Info("Context was canceled; stopping message processor")
+			return nil
+		}
+	}
+}
+
+// replayJournal queues the messages left in the journal by a previous run
+func (m *MessageProcessor) replayJournal(ctx context.Context, logger logr.Logger) {
+	if m.journal == nil {
+		return
+	}
+	pending, err := m.journal.Pending(ctx)
+	if err != nil {
+		logger.Error(err, "error reading discovery journal")
+		return
+	}
+	if len(pending) == 0 {
+		return
+	}
+	logger.Info("Replaying unacknowledged discovery messages",
+		"messages", len(pending),
+	)
+	for _, msg := range pending {
+		m.replayedSeq = max(m.replayedSeq, core.JournalSeq(msg))
+	}
+	m.queue = append(pending, m.queue...)
+}
+
+// skipReplayed drops the messages of a batch that were already replayed
+// from the journal, as they were journaled before the replay
+func (m *MessageProcessor) skipReplayed(batch []core.DiscoveryMessage) []core.DiscoveryMessage {
+	if m.replayedSeq == 0 {
+		return batch
+	}
+	return slices.DeleteFunc(batch, func(msg core.DiscoveryMessage) bool {
+		seq := core.JournalSeq(msg)
+		return seq != 0 && seq <= m.replayedSeq
+	})
+}
+
+// ack marks journaled messages as handled, whatever the outcome: a message
+// that failed is reported as such and not replayed
+func (m *MessageProcessor) ack(seqs ...uint64) {
+	for _, seq := range seqs {
+		if seq != 0 {
+			m.acks = append(m.acks, seq)
+		}
+	}
+}
+
+// flushAcks removes the handled messages from the journal. If that fails,
+// they are replayed after a restart.
+func (m *MessageProcessor) flushAcks(ctx context.Context, logger logr.Logger) {
+	if m.journal == nil || len(m.acks) == 0 {
+		m.acks = nil
+		return
+	}
+	if err := m.journal.Ack(ctx, m.acks...); err != nil {
+		logger.Error(err, "error acknowledging discovery messages")
+		return
+	}
+	m.acks = nil
+}
+
+// queueDepth is the number of messages received but not applied yet
+func (m *MessageProcessor) queueDepth() int32 {
+	depth := len(m.in) + len(m.queue) + len(m.deferredEvents)
+	if m.activeSnapshot != nil {
+		depth += len(m.activeSnapshot.received)
+	}
+	return int32(depth)
+}
+
+// reportQueueDepth updates the TargetSource status when the queue depth changed
+func (m *MessageProcessor) reportQueueDepth(ctx context.Context, logger logr.Logger) {
+	depth := m.queueDepth()
+	if m.updater == nil || depth == m.reportedDepth {
+		return
 	}
+	if err := m.updater.UpdateStatus(ctx, core.StatusUpdate{QueueDepth: &depth}); err != nil {
+		logger.Error(err, "error updating TargetSource queue depth")
+		return
+	}
+	m.reportedDepth = depth
 }
 
 // processMessage handles all of the incoming messages from the channel
@@ -118,6 +228,10 @@ func (m *MessageProcessor) processMessage(ctx context.Context, message core.Disc
 			"targets", len(msg.Targets),
 		)
 
+		if msg.Aborted {
+			return m.abortSnapshot(ctx, msg, logger)
+		}
+
 		for i := range msg.Targets {
 			msg.Targets[i] = normalizeTarget(msg.Targets[i], m.targetSource.Name)
 		}
@@ -161,6 +275,8 @@ func (m *MessageProcessor) processSnapshot(ctx context.Context, chunk core.Disco
 				"Discarded incomplete discovery snapshot",
 				"snapshotID", snapshot.snapshotID,
 			)
+			m.operations.Resolve(snapshot.operationID, core.OperationSuperseded, nil)
+			m.ack(snapshot.seqs...)
 		}
 
 		// Start collecting the new snapshot
@@ -173,11 +289,16 @@ func (m *MessageProcessor) processSnapshot(ctx context.Context, chunk core.Disco
 func (m *MessageProcessor) startNewSnapshot(ctx context.Context, chunk core.DiscoverySnapshot, logger logr.Logger) error {
 	m.activeSnapshot = &snapshotBuffer{
 		snapshotID:  chunk.SnapshotID,
+		operationID: chunk.OperationID,
 		totalChunks: chunk.TotalChunks,
 		received:    make(map[int][]core.DiscoveredTarget),
 		complete:    false,
 	}
 	// Delete buffered events that will be current with new snapshot
+	for _, event := range m.deferredEvents {
+		m.operations.Supersede(event.OperationID, m.pushedEvent(event))
+		m.ack(event.JournalSeq)
+	}
 	m.deferredEvents = nil
 
 	return m.collectSnapshot(ctx, chunk, logger)
@@ -185,6 +306,9 @@ func (m *MessageProcessor) startNewSnapshot(ctx context.Context, chunk core.Disc
 
 func (m *MessageProcessor) collectSnapshot(ctx context.Context, chunk core.DiscoverySnapshot, logger logr.Logger) error {
 	snapshot := m.activeSnapshot
+	// chunks are acknowledged with their snapshot, invalid ones right away
+	// so that they are not replayed
+	snapshot.seqs = append(snapshot.seqs, chunk.JournalSeq)
 
 	if chunk.TotalChunks != snapshot.totalChunks {
 		logger.Error(
@@ -192,6 +316,7 @@ func (m *MessageProcessor) collectSnapshot(ctx context.Context, chunk core.Disco
 			"Snapshot totalChunks mismatch",
 			"snapshotID", snapshot.snapshotID,
 		)
+		m.ack(chunk.JournalSeq)
 		return fmt.Errorf("snapshot totalChunks mismatch")
 	}
 	if chunk.ChunkIndex < 0 || chunk.ChunkIndex >= snapshot.totalChunks {
@@ -200,6 +325,7 @@ func (m *MessageProcessor) collectSnapshot(ctx context.Context, chunk core.Disco
 			"Snapshot chunk index out of range",
 			"chunkIndex", chunk.ChunkIndex,
 		)
+		m.ack(snapshot.seqs...)
 		m.resetSnapshot()
 		return fmt.Errorf("invalid chunk index")
 	}
@@ -209,6 +335,7 @@ func (m *MessageProcessor) collectSnapshot(ctx context.Context, chunk core.Disco
 			"Duplicate snapshot chunk received",
 			"chunkIndex", chunk.ChunkIndex,
 		)
+		m.ack(snapshot.seqs...)
 		m.resetSnapshot()
 		return fmt.Errorf("duplicate snapshot chunk")
 	}
@@ -233,6 +360,8 @@ func (m *MessageProcessor) processEvent(ctx context.Context, event core.Discover
 
 	// Apply events
 	err := m.applyEvent(ctx, event, logger)
+	m.operations.Report(event.OperationID, m.pushedEvent(event), err)
+	m.ack(event.JournalSeq)
 	if err == nil {
 		// Logged here rather than in applyEvent: this is the single-event path, whereas
 		// applySnapshot calls applyEvent once per discovered target and logs aggregate
@@ -250,6 +379,10 @@ func (m *MessageProcessor) processEvent(ctx context.Context, event core.Discover
 			m.updateStatus(ctx, logger)
 		}
 	}
+	if errors.Is(err, ErrCredentialsRefNotAllowed) {
+		// only the target is rejected, the pipeline keeps running
+		return nil
+	}
 
 	return err
 }
@@ -279,6 +412,7 @@ func (m *MessageProcessor) applySnapshot(ctx context.Context, snapshot *snapshot
 				"Missing snapshot chunk",
 				"chunkIndex", i,
 			)
+			m.ack(snapshot.seqs...)
 			m.resetSnapshot()
 			return fmt.Errorf("missing snapshot chunk %d", i)
 		}
@@ -319,28 +453,58 @@ func (m *MessageProcessor) applySnapshot(ctx context.Context, snapshot *snapshot
 		"numOfDelete", nDelete,
 	)
 
+	rejected := 0
 	for _, e := range events {
-		m.applyEvent(ctx, e, logger)
+		err := m.applyEvent(ctx, e, logger)
+		if errors.Is(err, ErrCredentialsRefNotAllowed) {
+			rejected++
+		}
+		m.operations.Report(snapshot.operationID, m.pushedEvent(e), err)
 	}
+	m.ack(snapshot.seqs...)
 
-	// Replay deferred events
-	for _, event := range m.deferredEvents {
+	// Because of idempotency, allTargets = desired state = targets existing in Kubernetes. Overwrites the counter to "reset" it.
+	m.targetCount = int32(len(allTargets) - rejected)
+	m.updateStatus(ctx, logger)
+
+	m.resetSnapshot()
+	return m.replayDeferredEvents(ctx, logger)
+}
+
+// abortSnapshot discards the chunks of a snapshot that will not be
+// completed and applies the events deferred by it
+func (m *MessageProcessor) abortSnapshot(ctx context.Context, abort core.DiscoverySnapshot, logger logr.Logger) error {
+	if m.activeSnapshot == nil || m.activeSnapshot.snapshotID != abort.SnapshotID {
+		// the snapshot was already applied or discarded
+		return nil
+	}
+	logger.Info(
+		"Aborted discovery snapshot",
+		"snapshotID", abort.SnapshotID,
+		"receivedChunks", len(m.activeSnapshot.received),
+	)
+	m.ack(m.activeSnapshot.seqs...)
+	m.resetSnapshot()
+	return m.replayDeferredEvents(ctx, logger)
+}
+
+// replayDeferredEvents processes the events deferred by a snapshot once it is
+// no longer active, so they are applied instead of being deferred again
+func (m *MessageProcessor) replayDeferredEvents(ctx context.Context, logger logr.Logger) error {
+	deferred := m.deferredEvents
+	m.deferredEvents = nil
+	for i, event := range deferred {
 		select {
 		case <-ctx.Done():
+			m.deferredEvents = deferred[i:]
 			return nil
 		default:
 		}
 		if err := m.processEvent(ctx, event, logger); err != nil {
+			m.deferredEvents = deferred[i+1:]
 			return err
 		}
 	}
-
-	// Because of idempotency, allTargets = desired state = targets existing in Kubernetes. Overwrites the counter to "reset" it.
-	m.targetCount = int32(len(allTargets))
-	m.updateStatus(ctx, logger)
-
-	m.resetSnapshot()
-	m.deferredEvents = nil
 	return nil
 }
 
@@ -354,6 +518,12 @@ func (m *MessageProcessor) applyEvent(ctx context.Context, event core.DiscoveryE
 			return err
 		}
 	case core.EventApply:
+		if err := CheckCredentialsRef(ctx, m.client, m.targetSource, event.Target.CredentialsRef); err != nil {
+			logger.Error(err, "target rejected",
+				"targetName", event.Target.Name,
+			)
+			return err
+		}
 		target := generateTargetResource(event.Target, m.targetSource)
 
 		if err := applyTarget(ctx, m.client, m.scheme, target, m.targetSource); err != nil {
@@ -392,6 +562,36 @@ func (m *MessageProcessor) updateStatus(ctx context.Context, logger logr.Logger)
 	}
 }
 
+// pushedEvent returns an event with the name its target was pushed with,
+// before normalizeTarget prefixed it, as operations report the pushed names.
+func (m *MessageProcessor) pushedEvent(event core.DiscoveryEvent) core.DiscoveryEvent {
+	event.Target.Name = strings.TrimPrefix(event.Target.Name, m.targetSource.Name+"-")
+	return event
+}
+
+// failPending reports the events that will not be processed anymore as failed
+func (m *MessageProcessor) failPending(err error) {
+	if m.activeSnapshot != nil {
+		m.operations.Resolve(m.activeSnapshot.operationID, core.OperationFailed, err)
+	}
+	for _, event := range m.deferredEvents {
+		m.operations.Report(event.OperationID, m.pushedEvent(event), err)
+	}
+	for _, msg := range m.queue {
+		switch msg := msg.(type) {
+		case core.DiscoveryEvent:
+			// queued events are not normalized yet
+			m.operations.Report(msg.OperationID, msg, err)
+		case core.DiscoverySnapshot:
+			m.operations.Resolve(msg.OperationID, core.OperationFailed, err)
+		}
+	}
+}
+
 func (m *MessageProcessor) resetSnapshot() {
+	if m.activeSnapshot != nil {
+		// the targets of a pushed snapshot that is dropped are not applied
+		m.operations.Resolve(m.activeSnapshot.operationID, core.OperationFailed, errSnapshotNotApplied)
+	}
 	m.activeSnapshot = nil
 }
@@ -40,6 +40,8 @@ func mockMessageProcessor(opts ...func(*MessageProcessor)) *MessageProcessor {
 		&targetSource,
 		targetChannel,
 		nil,
+		nil,
+		nil,
 	)
 
 	for _, opt := range opts {
@@ -258,3 +260,173 @@ func TestResetSnapshot(t *testing.T) {
 
 	require.Nil(t, mp.activeSnapshot)
 }
+
+func TestApplySnapshot_ReportsDeferredEvents(t *testing.T) {
+	ops := core.NewOperationStore(0)
+	m := mockMessageProcessor(func(m *MessageProcessor) {
+		m.operations = ops
+	})
+
+	events := []core.DiscoveryEvent{
+		{Event: core.EventApply, Target: core.DiscoveredTarget{Name: "router-2", Address: "10.0.0.2"}},
+	}
+	op, created, err := ops.Create("", "", events)
+	require.NoError(t, err)
+	require.True(t, created)
+
+	m.activeSnapshot = &snapshotBuffer{
+		snapshotID:  "snap-1",
+		totalChunks: 1,
+		received:    map[int][]core.DiscoveredTarget{},
+	}
+	require.NoError(t, m.processMessage(context.Background(), events[0], logr.Discard()))
+
+	got, _ := ops.Get(op.ID)
+	require.Equal(t, core.OperationPending, got.State)
+
+	chunk := core.DiscoverySnapshot{
+		SnapshotID:  "snap-1",
+		TotalChunks: 1,
+		Targets:     []core.DiscoveredTarget{{Name: "router-1", Address: "10.0.0.1"}},
+	}
+	require.NoError(t, m.collectSnapshot(context.Background(), chunk, logr.Discard()))
+
+	// the deferred event is applied after the snapshot, not dropped
+	got, _ = ops.Get(op.ID)
+	require.Equal(t, core.OperationSucceeded, got.State)
+	require.Nil(t, m.deferredEvents)
+	require.Equal(t, int32(2), m.targetCount)
+}
+
+func TestAbortSnapshot_AppliesDeferredEvents(t *testing.T) {
+	ops := core.NewOperationStore(0)
+	m := mockMessageProcessor(func(m *MessageProcessor) {
+		m.operations = ops
+	})
+	ctx := context.Background()
+
+	events := []core.DiscoveryEvent{
+		{Event: core.EventApply, Target: core.DiscoveredTarget{Name: "router-2", Address: "10.0.0.2"}},
+	}
+	op, _, err := ops.Create("", "", events)
+	require.NoError(t, err)
+
+	chunk := core.DiscoverySnapshot{
+		SnapshotID:  "snap-1",
+		TotalChunks: 2,
+		Targets:     []core.DiscoveredTarget{{Name: "router-1", Address: "10.0.0.1"}},
+	}
+	require.NoError(t, m.processMessage(ctx, chunk, logr.Discard()))
+	require.NoError(t, m.processMessage(ctx, events[0], logr.Discard()))
+	require.Len(t, m.deferredEvents, 1)
+
+	// aborting another snapshot keeps the active one
+	require.NoError(t, m.processMessage(ctx, core.DiscoverySnapshot{SnapshotID: "snap-0", Aborted: true}, logr.Discard()))
+	require.NotNil(t, m.activeSnapshot)
+
+	require.NoError(t, m.processMessage(ctx, core.DiscoverySnapshot{SnapshotID: "snap-1", Aborted: true}, logr.Discard()))
+	require.Nil(t, m.activeSnapshot)
+	require.Nil(t, m.deferredEvents)
+	got, _ := ops.Get(op.ID)
+	require.Equal(t, core.OperationSucceeded, got.State)
+	require.Equal(t, int32(1), m.targetCount)
+}
+
+func TestStartNewSnapshot_SupersedesDeferredEvents(t *testing.T) {
+	ops := core.NewOperationStore(0)
+	m := mockMessageProcessor(func(m *MessageProcessor) {
+		m.operations = ops
+	})
+
+	events := []core.DiscoveryEvent{
+		{Event: core.EventApply, Target: core.DiscoveredTarget{Name: "router-1"}},
+	}
+	op, _, err := ops.Create("", "", events)
+	require.NoError(t, err)
+	m.deferredEvents = events
+
+	chunk := core.DiscoverySnapshot{
+		SnapshotID:  "snap-2",
+		TotalChunks: 2,
+	}
+	require.NoError(t, m.startNewSnapshot(context.Background(), chunk, logr.Discard()))
+
+	got, _ := ops.Get(op.ID)
+	require.Equal(t, core.OperationSucceeded, got.State)
+	require.Equal(t, core.OperationSuperseded, got.Targets[0].State)
+}
+
+func TestApplySnapshot_ReportsOperation(t *testing.T) {
+	ops := core.NewOperationStore(0)
+	m := mockMessageProcessor(func(m *MessageProcessor) {
+		m.operations = ops
+	})
+	ctx := context.Background()
+
+	// an existing target that is missing from the pushed snapshot
+	require.NoError(t, m.processMessage(ctx, core.DiscoveryEvent{
+		Event:  core.EventApply,
+		Target: core.DiscoveredTarget{Name: "router-old", Address: "10.0.0.9"},
+	}, logr.Discard()))
+
+	events := []core.DiscoveryEvent{
+		{Event: core.EventApply, Target: core.DiscoveredTarget{Name: "router-1", Address: "10.0.0.1"}},
+	}
+	op, _, err := ops.Create("", "", events)
+	require.NoError(t, err)
+
+	require.NoError(t, m.processMessage(ctx, core.DiscoverySnapshot{
+		SnapshotID:  op.ID,
+		OperationID: op.ID,
+		TotalChunks: 1,
+		Targets:     []core.DiscoveredTarget{events[0].Target},
+	}, logr.Discard()))
+
+	got, _ := ops.Get(op.ID)
+	require.Equal(t, core.OperationSucceeded, got.State)
+	require.Len(t, got.Targets, 2)
+	require.Equal(t, "router-old", got.Targets[1].Name)
+	require.Equal(t, core.EventDelete.String(), got.Targets[1].Event)
+}
+
+func TestRun_ReplaysJournal(t *testing.T) {
+	ch := make(chan []core.DiscoveryMessage, 10)
+	m := mockMessageProcessor(withTargetChannel(ch), func(m *MessageProcessor) {
+		m.journal = NewConfigMapJournal(m.client, m.scheme, m.targetSource)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// messages journaled before a restart
+	journaled := []core.DiscoveryMessage{
+		core.DiscoveryEvent{Event: core.EventApply, Target: core.DiscoveredTarget{Name: "router-1", Address: "10.0.0.1"}},
+		core.DiscoveryEvent{Event: core.EventApply, Target: core.DiscoveredTarget{Name: "router-2", Address: "10.0.0.2"}},
+	}
+	seqs, err := m.journal.Append(ctx, journaled...)
+	require.NoError(t, err)
+
+	// the second one was also queued before the processor started
+	event := journaled[1].(core.DiscoveryEvent)
+	event.JournalSeq = seqs[1]
+	ch <- []core.DiscoveryMessage{event}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- m.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		pending, err := m.journal.Pending(ctx)
+		return err == nil && len(pending) == 0
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// the queued message is handled before Run returns
+	close(ch)
+	require.NoError(t, <-errCh)
+
+	targets, err := fetchExistingTargets(context.Background(), m.client, m.targetSource)
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+	// the event received over the channel was not applied twice
+	require.Equal(t, int32(2), m.targetCount)
+}
@@ -28,9 +28,9 @@ func NewK8sStatusUpdater(c client.Client, s *runtime.Scheme, ts *gnmicv1alpha1.T
 	}
 }
 
-// UpdateStatus takes a StatusUpdate holding Conditions and a pointer referencing the TargetsCount.
+// UpdateStatus takes a StatusUpdate holding Conditions and pointers referencing the TargetsCount, QueueDepth and Conflicts.
 // If TargetsCount is set, the LastSync time gets set to metav1.Now().
-// Replaces LastTransitionTime of each Condition with metav1.Now().
+// Replaces LastTransitionTime of each Condition with metav1.Now(). Conditions are left as they are if none are set.
 func (c *k8sStatusUpdater) UpdateStatus(ctx context.Context, update core.StatusUpdate) error {
 
 	return c.patchStatus(ctx, func(
@@ -38,16 +38,24 @@ func (c *k8sStatusUpdater) UpdateStatus(ctx context.Context, update core.StatusU
 	) {
 		now := metav1.Now()
 
-		// Update status fields: Replace all Conditions and set TargetsCount and LastSync if pointer != nil
-		for i := range update.Conditions {
-			update.Conditions[i].LastTransitionTime = now
+		// Update status fields: Replace all Conditions and set TargetsCount, LastSync, QueueDepth and Conflicts if pointer != nil
+		if update.Conditions != nil {
+			for i := range update.Conditions {
+				update.Conditions[i].LastTransitionTime = now
+			}
+			ts.Status.Conditions = update.Conditions
 		}
-		ts.Status.Conditions = update.Conditions
 
 		if update.TargetsCount != nil {
 			ts.Status.TargetsCount = *update.TargetsCount
 			ts.Status.LastSync = now
 		}
+		if update.QueueDepth != nil {
+			ts.Status.QueueDepth = *update.QueueDepth
+		}
+		if update.Conflicts != nil {
+			ts.Status.Conflicts = *update.Conflicts
+		}
 	})
 }
 
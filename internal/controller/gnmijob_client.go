@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+// errGNMIServerDisabled is returned when dialing the gNMI server of a pod
+// of a cluster that does not run one.
+var errGNMIServerDisabled = errors.New("the gNMI server of the cluster pods is not enabled")
+
+// gnmiPodDialer returns a gNMI client for the gNMI server of a cluster pod
+// and a function closing it.
+type gnmiPodDialer func(ctx context.Context, cluster *gnmicv1alpha1.Cluster, pod string) (gnmi.GNMIClient, func(), error)
+
+// podDialer returns the dialer of the pods used by the gNMI jobs and the
+// scheduled polls, reading the cluster certificates with c.
+func podDialer(c client.Reader) gnmiPodDialer {
+	return func(ctx context.Context, cluster *gnmicv1alpha1.Cluster, pod string) (gnmi.GNMIClient, func(), error) {
+		return dialPod(ctx, c, cluster, pod)
+	}
+}
+
+// dialPod connects to the gNMI server of a cluster pod. The gNMI server uses
+// the TLS configuration of the REST API, with certificates from an issuer,
+// and the controller authenticates with its client certificate. It never
+// connects without TLS.
+func dialPod(ctx context.Context, c client.Reader, cluster *gnmicv1alpha1.Cluster, pod string) (gnmi.GNMIClient, func(), error) {
+	if !gnmic.GNMIServerEnabled(cluster) {
+		return nil, nil, errGNMIServerDisabled
+	}
+	tlsConfig, err := clusterAPITLSConfig(ctx, c, cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+	stsName := fmt.Sprintf("%s%s", resourcePrefix, cluster.Name)
+	address := fmt.Sprintf("%s.%s.%s.svc.%s:%d", pod, stsName, cluster.Namespace, gnmic.ClusterDomain(), cluster.Spec.API.GNMIPort)
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	if err != nil {
+		return nil, nil, err
+	}
+	return gnmi.NewGNMIClient(conn), func() { _ = conn.Close() }, nil
+}
@@ -0,0 +1,698 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+	"github.com/gnmic/operator/internal/utils"
+)
+
+// Condition type and reasons of GetJobs and SetJobs
+const (
+	// GNMIJobConditionTypeSucceeded indicates the last run succeeded on all of its targets
+	GNMIJobConditionTypeSucceeded = "Succeeded"
+
+	GNMIJobReasonSucceeded          = "Succeeded"
+	GNMIJobReasonTargetsFailed      = "TargetsFailed"
+	GNMIJobReasonNoTargets          = "NoTargets"
+	GNMIJobReasonClusterNotFound    = "ClusterNotFound"
+	GNMIJobReasonGNMIServerDisabled = "GNMIServerDisabled"
+	GNMIJobReasonInvalidOutputs     = "InvalidOutputs"
+	GNMIJobReasonInvalid            = "Invalid"
+)
+
+// Results of a job for a target, reported in status.targets.
+const (
+	GNMIJobResultSucceeded = "Succeeded"
+	GNMIJobResultFailed    = "Failed"
+)
+
+const (
+	defaultGNMIJobTimeout = 30 * time.Second
+	// gnmiJobConcurrency bounds the requests of a run in flight at once.
+	gnmiJobConcurrency = 16
+	// gnmiJobRetryInterval is how often a job that cannot run, e.g. because
+	// its cluster does not exist, is checked again.
+	gnmiJobRetryInterval = 30 * time.Second
+	// gnmiJobProgressInterval is how often the progress of a run in flight
+	// is recorded in the job status.
+	gnmiJobProgressInterval = 5 * time.Second
+)
+
+// gnmiJobRequest sends the request of a job for a target and fills in the
+// counts and timestamp of the result from the response. It returns the
+// response as notifications, written to the outputs of the job.
+type gnmiJobRequest func(ctx context.Context, c gnmi.GNMIClient, target string, result *gnmicv1alpha1.GNMIJobTargetResult) ([]*gnmi.Notification, error)
+
+// gnmiJobRunner runs the gNMI requests of GetJobs and SetJobs. The requests
+// are sent to the gNMI server of the cluster pod each target is assigned to,
+// which forwards them to the target named in the prefix of the request.
+type gnmiJobRunner struct {
+	client.Client
+	// runs tracks the runs in flight of the jobs of the reconciler
+	runs *gnmiJobRuns
+	// dial defaults to dialing the pods with the controller certificate
+	dial gnmiPodDialer
+	// now defaults to time.Now
+	now func() time.Time
+}
+
+// gnmiJobRuns tracks the runs of jobs in flight. A run sends its requests in
+// the background, so that a job with many targets or slow targets does not
+// hold back the reconciliation of the other jobs, and wakes its job once it
+// completes.
+type gnmiJobRuns struct {
+	mu      sync.Mutex
+	running map[types.NamespacedName]struct{}
+	// ctx is the context of the runs, which outlive the reconcile starting
+	// them. It is canceled when the manager stops.
+	ctx context.Context
+	// done wakes the jobs whose run completed
+	done chan event.GenericEvent
+	// wg tracks the runs in flight, waited for in tests
+	wg sync.WaitGroup
+}
+
+// setup creates the channel waking the jobs whose run completed and the
+// context of the runs. The context is canceled when the manager stops, which
+// then waits for the runs in flight to return.
+func (g *gnmiJobRuns) setup(mgr ctrl.Manager) error {
+	g.done = make(chan event.GenericEvent)
+	ctx, cancel := context.WithCancel(context.Background())
+	g.ctx = ctx
+	return mgr.Add(manager.RunnableFunc(func(mgrCtx context.Context) error {
+		<-mgrCtx.Done()
+		cancel()
+		g.wg.Wait()
+		return nil
+	}))
+}
+
+// context returns the context of the runs, the background context in tests.
+func (g *gnmiJobRuns) context() context.Context {
+	if g.ctx == nil {
+		return context.Background()
+	}
+	return g.ctx
+}
+
+// start records that a job runs, unless it already does.
+func (g *gnmiJobRuns) start(jobNN types.NamespacedName) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.running[jobNN]; ok {
+		return false
+	}
+	if g.running == nil {
+		g.running = make(map[types.NamespacedName]struct{})
+	}
+	g.running[jobNN] = struct{}{}
+	return true
+}
+
+func (g *gnmiJobRuns) isRunning(jobNN types.NamespacedName) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	_, ok := g.running[jobNN]
+	return ok
+}
+
+// finish records that the run of a job completed and wakes the job.
+func (g *gnmiJobRuns) finish(ctx context.Context, job client.Object) {
+	g.mu.Lock()
+	delete(g.running, client.ObjectKeyFromObject(job))
+	g.mu.Unlock()
+	if g.done == nil {
+		return
+	}
+	select {
+	case g.done <- event.GenericEvent{Object: job}:
+	case <-ctx.Done():
+	}
+}
+
+// GetJobReconciler reconciles a GetJob object
+type GetJobReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	runs gnmiJobRuns
+	// dial and now are replaced in tests
+	dial gnmiPodDialer
+	now  func() time.Time
+}
+
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=getjobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=getjobs/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=getjobs/finalizers,verbs=update
+
+// Reconcile starts the Get requests of the GetJob when it is due. Their
+// results are recorded in its status once they complete.
+func (r *GetJobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var job gnmicv1alpha1.GetJob
+	if err := r.Get(ctx, req.NamespacedName, &job); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	spec := &job.Spec
+	runner := &gnmiJobRunner{Client: r.Client, runs: &r.runs, dial: r.dial, now: r.now}
+	return runner.reconcile(ctx, &job, &spec.GNMIJobSpec, &job.Status.GNMIJobStatus,
+		func(ctx context.Context, c gnmi.GNMIClient, target string, result *gnmicv1alpha1.GNMIJobTargetResult) ([]*gnmi.Notification, error) {
+			getReq, err := gnmic.BuildGetRequest(spec, target)
+			if err != nil {
+				return nil, err
+			}
+			rsp, err := c.Get(ctx, getReq)
+			if err != nil {
+				return nil, err
+			}
+			var latest int64
+			for _, n := range rsp.GetNotification() {
+				result.Updates += int32(len(n.GetUpdate()))
+				latest = max(latest, n.GetTimestamp())
+			}
+			result.Notifications = int32(len(rsp.GetNotification()))
+			result.Timestamp = gnmiTimestamp(latest)
+			return rsp.GetNotification(), nil
+		})
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GetJobReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := r.runs.setup(mgr); err != nil {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&gnmicv1alpha1.GetJob{}).
+		// jobs whose run completed
+		WatchesRawSource(source.Channel(r.runs.done, &handler.EnqueueRequestForObject{})).
+		Named("getjob").
+		Complete(r)
+}
+
+// SetJobReconciler reconciles a SetJob object
+type SetJobReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	runs gnmiJobRuns
+	// dial and now are replaced in tests
+	dial gnmiPodDialer
+	now  func() time.Time
+}
+
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=setjobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=setjobs/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=setjobs/finalizers,verbs=update
+
+// Reconcile starts the Set requests of the SetJob when it is due. Their
+// results are recorded in its status once they complete.
+func (r *SetJobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var job gnmicv1alpha1.SetJob
+	if err := r.Get(ctx, req.NamespacedName, &job); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	spec := &job.Spec
+	runner := &gnmiJobRunner{Client: r.Client, runs: &r.runs, dial: r.dial, now: r.now}
+	return runner.reconcile(ctx, &job, &spec.GNMIJobSpec, &job.Status.GNMIJobStatus,
+		func(ctx context.Context, c gnmi.GNMIClient, target string, result *gnmicv1alpha1.GNMIJobTargetResult) ([]*gnmi.Notification, error) {
+			setReq, err := gnmic.BuildSetRequest(spec, target)
+			if err != nil {
+				return nil, err
+			}
+			rsp, err := c.Set(ctx, setReq)
+			if err != nil {
+				return nil, err
+			}
+			result.Updates = int32(len(rsp.GetResponse()))
+			result.Timestamp = gnmiTimestamp(rsp.GetTimestamp())
+			return []*gnmi.Notification{setResponseNotification(rsp)}, nil
+		})
+}
+
+// setResponseNotification returns the results of a SetResponse as a
+// notification with an update per result, valued with its operation.
+func setResponseNotification(rsp *gnmi.SetResponse) *gnmi.Notification {
+	n := &gnmi.Notification{Timestamp: rsp.GetTimestamp(), Prefix: rsp.GetPrefix()}
+	for _, res := range rsp.GetResponse() {
+		n.Update = append(n.Update, &gnmi.Update{
+			Path: res.GetPath(),
+			Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: res.GetOp().String()}},
+		})
+	}
+	return n
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SetJobReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := r.runs.setup(mgr); err != nil {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&gnmicv1alpha1.SetJob{}).
+		// jobs whose run completed
+		WatchesRawSource(source.Channel(r.runs.done, &handler.EnqueueRequestForObject{})).
+		Named("setjob").
+		Complete(r)
+}
+
+// reconcile starts the run of the job when it is due: once per generation of
+// its spec when it has no schedule, or every time its schedule fires.
+// A scheduled run that was missed, e.g. while the controller was down, runs
+// once as soon as possible, and so does a run that was in flight then.
+func (r *gnmiJobRunner) reconcile(ctx context.Context, job client.Object, spec *gnmicv1alpha1.GNMIJobSpec, status *gnmicv1alpha1.GNMIJobStatus, send gnmiJobRequest) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("job", job.GetName(), "namespace", job.GetNamespace())
+	now := r.clock()
+	// the job is reconciled again once its run completes
+	if r.runs.isRunning(client.ObjectKeyFromObject(job)) {
+		return ctrl.Result{}, nil
+	}
+
+	next, err := nextGNMIJobRun(spec, status, job.GetCreationTimestamp().Time, now)
+	if err != nil {
+		status.ObservedGeneration = job.GetGeneration()
+		status.NextRunTime = nil
+		setGNMIJobCondition(job.GetGeneration(), status, metav1.ConditionFalse, GNMIJobReasonInvalid, err.Error())
+		return ctrl.Result{}, r.Status().Update(ctx, job)
+	}
+
+	due := !spec.Suspend
+	if spec.Schedule == "" {
+		due = due && status.ObservedGeneration != job.GetGeneration()
+	} else {
+		due = due && !next.IsZero() && !next.After(now)
+	}
+	if !due {
+		return r.idle(ctx, job, spec, status, next, now)
+	}
+
+	var cluster gnmicv1alpha1.Cluster
+	if err := r.Get(ctx, types.NamespacedName{Name: spec.ClusterRef, Namespace: job.GetNamespace()}, &cluster); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		logger.Info("referenced cluster not found, will retry", "clusterRef", spec.ClusterRef)
+		setGNMIJobCondition(job.GetGeneration(), status, metav1.ConditionFalse, GNMIJobReasonClusterNotFound,
+			fmt.Sprintf("cluster %s not found", spec.ClusterRef))
+		return ctrl.Result{RequeueAfter: gnmiJobRetryInterval}, r.Status().Update(ctx, job)
+	}
+	if !gnmic.GNMIServerEnabled(&cluster) {
+		setGNMIJobCondition(job.GetGeneration(), status, metav1.ConditionFalse, GNMIJobReasonGNMIServerDisabled,
+			fmt.Sprintf("cluster %s does not enable the gNMI server of its pods, set api.gnmiPort and api.tls.issuerRef", cluster.Name))
+		return ctrl.Result{RequeueAfter: gnmiJobRetryInterval}, r.Status().Update(ctx, job)
+	}
+
+	targets, err := resolveGNMIJobTargets(ctx, r.Client, job.GetNamespace(), spec)
+	if err != nil {
+		if !errors.Is(err, errInvalidSelector) {
+			return ctrl.Result{}, err
+		}
+		status.ObservedGeneration = job.GetGeneration()
+		setGNMIJobCondition(job.GetGeneration(), status, metav1.ConditionFalse, GNMIJobReasonInvalid, err.Error())
+		return ctrl.Result{}, r.Status().Update(ctx, job)
+	}
+
+	outputs, err := resolveGNMIJobOutputs(ctx, r.Client, job.GetNamespace(), spec.Outputs)
+	if err != nil {
+		if !errors.Is(err, errInvalidGNMIJobOutput) {
+			return ctrl.Result{}, err
+		}
+		logger.Info("invalid job outputs, will retry", "error", err.Error())
+		setGNMIJobCondition(job.GetGeneration(), status, metav1.ConditionFalse, GNMIJobReasonInvalidOutputs, err.Error())
+		return ctrl.Result{RequeueAfter: gnmiJobRetryInterval}, r.Status().Update(ctx, job)
+	}
+	write := newGNMIJobWriter(job.GetName(), outputs)
+
+	if !r.runs.start(client.ObjectKeyFromObject(job)) {
+		return ctrl.Result{}, nil
+	}
+	status.Active = &gnmicv1alpha1.GNMIJobActiveRun{StartTime: metav1.NewTime(now), Targets: int32(len(targets))}
+	if err := r.Status().Update(ctx, job); err != nil {
+		r.runs.finish(ctx, job)
+		return ctrl.Result{}, err
+	}
+	logger.Info("running job", "cluster", cluster.Name, "targets", len(targets))
+	generation := job.GetGeneration()
+	// the run outlives this reconcile, it is only canceled when the manager stops
+	runCtx := log.IntoContext(r.runs.context(), logger)
+	r.runs.wg.Add(1)
+	go func() {
+		defer r.runs.wg.Done()
+		defer r.runs.finish(runCtx, job)
+		var completed atomic.Int32
+		progressDone := make(chan struct{})
+		var progressWG sync.WaitGroup
+		progressWG.Add(1)
+		go func() {
+			defer progressWG.Done()
+			r.reportProgress(runCtx, job, now, &completed, progressDone)
+		}()
+		results := r.run(runCtx, &cluster, spec, targets, send, write, &completed)
+		close(progressDone)
+		progressWG.Wait()
+		if err := r.complete(runCtx, job, generation, now, results); err != nil {
+			logger.Error(err, "failed to record the results of the job")
+		}
+	}()
+
+	// the job runs next according to its schedule, counted from this run
+	ran := status.DeepCopy()
+	ran.LastRunTime = &metav1.Time{Time: now}
+	next, _ = nextGNMIJobRun(spec, ran, job.GetCreationTimestamp().Time, now)
+	return requeueGNMIJob(nextGNMIJobRunTime(spec, next), now), nil
+}
+
+// reportProgress records in the status of a job how many targets the run
+// started at start is done with, until done is closed.
+func (r *gnmiJobRunner) reportProgress(ctx context.Context, job client.Object, start time.Time, completed *atomic.Int32, done <-chan struct{}) {
+	ticker := time.NewTicker(gnmiJobProgressInterval)
+	defer ticker.Stop()
+	var reported int32
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		count := completed.Load()
+		if count == reported {
+			continue
+		}
+		err := r.updateStatus(ctx, job, func(_ *gnmicv1alpha1.GNMIJobSpec, status *gnmicv1alpha1.GNMIJobStatus, _ time.Time) bool {
+			if status.Active == nil || !status.Active.StartTime.Time.Equal(start) {
+				return false
+			}
+			status.Active.Completed = count
+			return true
+		})
+		if err != nil {
+			log.FromContext(ctx).Error(err, "failed to record the progress of the job", "job", job.GetName())
+			continue
+		}
+		reported = count
+	}
+}
+
+// complete records the results of the run of a job, started at start for
+// the given generation of its spec.
+func (r *gnmiJobRunner) complete(ctx context.Context, job client.Object, generation int64, start time.Time, results []gnmicv1alpha1.GNMIJobTargetResult) error {
+	return r.updateStatus(ctx, job, func(spec *gnmicv1alpha1.GNMIJobSpec, status *gnmicv1alpha1.GNMIJobStatus, created time.Time) bool {
+		status.Active = nil
+		status.Targets = results
+		status.LastRunTime = &metav1.Time{Time: start}
+		status.ObservedGeneration = generation
+		status.Succeeded, status.Failed = 0, 0
+		var failed []string
+		for _, result := range status.Targets {
+			if result.Result == GNMIJobResultSucceeded {
+				status.Succeeded++
+				continue
+			}
+			status.Failed++
+			failed = append(failed, result.Target)
+		}
+		switch {
+		case len(results) == 0:
+			setGNMIJobCondition(generation, status, metav1.ConditionFalse, GNMIJobReasonNoTargets, "the job selects no targets")
+		case len(failed) > 0:
+			setGNMIJobCondition(generation, status, metav1.ConditionFalse, GNMIJobReasonTargetsFailed,
+				fmt.Sprintf("failed on %d of %d targets: %s", len(failed), len(results), summarizeNames(failed)))
+		default:
+			setGNMIJobCondition(generation, status, metav1.ConditionTrue, GNMIJobReasonSucceeded,
+				fmt.Sprintf("succeeded on %d targets", len(results)))
+		}
+		next, _ := nextGNMIJobRun(spec, status, created, start)
+		status.NextRunTime = nextGNMIJobRunTime(spec, next)
+		return true
+	})
+}
+
+// updateStatus applies mutate to the latest status of a job and updates it,
+// retrying on conflicts. mutate is given the creation time of the job and
+// reports whether the status changed.
+func (r *gnmiJobRunner) updateStatus(ctx context.Context, job client.Object, mutate func(spec *gnmicv1alpha1.GNMIJobSpec, status *gnmicv1alpha1.GNMIJobStatus, created time.Time) bool) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := job.DeepCopyObject().(client.Object)
+		if err := r.Get(ctx, client.ObjectKeyFromObject(job), latest); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		spec, status := gnmiJobParts(latest)
+		if spec == nil || !mutate(spec, status, latest.GetCreationTimestamp().Time) {
+			return nil
+		}
+		return r.Status().Update(ctx, latest)
+	})
+}
+
+// gnmiJobParts returns the spec and status shared by GetJobs and SetJobs.
+func gnmiJobParts(job client.Object) (*gnmicv1alpha1.GNMIJobSpec, *gnmicv1alpha1.GNMIJobStatus) {
+	switch job := job.(type) {
+	case *gnmicv1alpha1.GetJob:
+		return &job.Spec.GNMIJobSpec, &job.Status.GNMIJobStatus
+	case *gnmicv1alpha1.SetJob:
+		return &job.Spec.GNMIJobSpec, &job.Status.GNMIJobStatus
+	}
+	return nil, nil
+}
+
+// idle records the next run of a job that is not due and requeues it then.
+func (r *gnmiJobRunner) idle(ctx context.Context, job client.Object, spec *gnmicv1alpha1.GNMIJobSpec, status *gnmicv1alpha1.GNMIJobStatus, next, now time.Time) (ctrl.Result, error) {
+	nextRunTime := nextGNMIJobRunTime(spec, next)
+	// a run in flight when the controller stopped is gone
+	if status.ObservedGeneration != job.GetGeneration() || !nextRunTime.Equal(status.NextRunTime) || status.Active != nil {
+		status.ObservedGeneration = job.GetGeneration()
+		status.NextRunTime = nextRunTime
+		status.Active = nil
+		if err := r.Status().Update(ctx, job); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return requeueGNMIJob(nextRunTime, now), nil
+}
+
+// nextGNMIJobRunTime is the status.nextRunTime of a job running next at next.
+func nextGNMIJobRunTime(spec *gnmicv1alpha1.GNMIJobSpec, next time.Time) *metav1.Time {
+	if spec.Schedule == "" || spec.Suspend || next.IsZero() {
+		return nil
+	}
+	return &metav1.Time{Time: next}
+}
+
+func requeueGNMIJob(nextRunTime *metav1.Time, now time.Time) ctrl.Result {
+	if nextRunTime == nil {
+		return ctrl.Result{}
+	}
+	return ctrl.Result{RequeueAfter: nextRunTime.Sub(now)}
+}
+
+// run sends the request of the job to each target through the gNMI server of
+// the cluster pod the target is assigned to, writing the responses with
+// write if the job has outputs, and counting the targets it is done with in
+// completed. It returns the results sorted by target name.
+func (r *gnmiJobRunner) run(ctx context.Context, cluster *gnmicv1alpha1.Cluster, spec *gnmicv1alpha1.GNMIJobSpec, targets []gnmicv1alpha1.Target, send gnmiJobRequest, write gnmiJobWriter, completed *atomic.Int32) []gnmicv1alpha1.GNMIJobTargetResult {
+	timeout := defaultGNMIJobTimeout
+	if spec.Timeout != nil && spec.Timeout.Duration > 0 {
+		timeout = spec.Timeout.Duration
+	}
+
+	results := make([]gnmicv1alpha1.GNMIJobTargetResult, len(targets))
+	byPod := make(map[string][]int)
+	for i := range targets {
+		results[i] = gnmicv1alpha1.GNMIJobTargetResult{Target: targets[i].Name}
+		state, ok := targets[i].Status.ClusterStates[cluster.Name]
+		if !ok || state.Pod == "" {
+			results[i].Result = GNMIJobResultFailed
+			results[i].Message = fmt.Sprintf("target is not assigned to a pod of cluster %s", cluster.Name)
+			completed.Add(1)
+			continue
+		}
+		results[i].Pod = state.Pod
+		byPod[state.Pod] = append(byPod[state.Pod], i)
+	}
+
+	dial := r.dial
+	if dial == nil {
+		dial = podDialer(r.Client)
+	}
+	sem := make(chan struct{}, gnmiJobConcurrency)
+	var wg sync.WaitGroup
+	for _, pod := range slices.Sorted(maps.Keys(byPod)) {
+		indexes := byPod[pod]
+		c, closeClient, err := dial(ctx, cluster, pod)
+		if err != nil {
+			for _, i := range indexes {
+				results[i].Result = GNMIJobResultFailed
+				results[i].Message = fmt.Sprintf("failed to connect to pod %s: %v", pod, err)
+			}
+			completed.Add(int32(len(indexes)))
+			continue
+		}
+		var podWG sync.WaitGroup
+		for _, i := range indexes {
+			sem <- struct{}{}
+			podWG.Add(1)
+			go func(result *gnmicv1alpha1.GNMIJobTargetResult, name string) {
+				defer func() { <-sem; completed.Add(1); podWG.Done() }()
+				reqCtx, cancel := context.WithTimeout(ctx, timeout)
+				defer cancel()
+				notifications, err := send(reqCtx, c, name, result)
+				if err == nil && write != nil {
+					err = write(reqCtx, name, notifications)
+				}
+				if err != nil {
+					result.Result = GNMIJobResultFailed
+					result.Message = err.Error()
+					return
+				}
+				result.Result = GNMIJobResultSucceeded
+			}(&results[i], targets[i].Namespace+gnmic.Delimiter+targets[i].Name)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			podWG.Wait()
+			closeClient()
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func (r *gnmiJobRunner) clock() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
+// nextGNMIJobRun returns when a scheduled job runs next: the first time its
+// schedule fires after its last run, or after its creation if it never ran.
+// It returns the zero time for a job without a schedule.
+func nextGNMIJobRun(spec *gnmicv1alpha1.GNMIJobSpec, status *gnmicv1alpha1.GNMIJobStatus, created, now time.Time) (time.Time, error) {
+	if spec.Schedule == "" {
+		return time.Time{}, nil
+	}
+	schedule, err := utils.ParseCron(spec.Schedule)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid schedule: %w", err)
+	}
+	loc := time.UTC
+	if spec.TimeZone != "" {
+		if loc, err = time.LoadLocation(spec.TimeZone); err != nil {
+			return time.Time{}, fmt.Errorf("invalid schedule time zone %q: %w", spec.TimeZone, err)
+		}
+	}
+	from := created
+	if status.LastRunTime != nil {
+		from = status.LastRunTime.Time
+	}
+	if from.IsZero() || from.After(now) {
+		from = now
+	}
+	return schedule.Next(from.In(loc)), nil
+}
+
+// resolveGNMIJobTargets resolves the targets of a job using refs and
+// selectors (union of all selectors), sorted by name.
+func resolveGNMIJobTargets(ctx context.Context, c client.Client, namespace string, spec *gnmicv1alpha1.GNMIJobSpec) ([]gnmicv1alpha1.Target, error) {
+	byName := make(map[string]gnmicv1alpha1.Target)
+	for _, ref := range spec.TargetRefs {
+		var target gnmicv1alpha1.Target
+		if err := c.Get(ctx, types.NamespacedName{Name: ref, Namespace: namespace}, &target); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, err
+			}
+			continue
+		}
+		byName[target.Name] = target
+	}
+	for _, labelSelector := range spec.TargetSelectors {
+		if len(labelSelector.MatchLabels) == 0 && len(labelSelector.MatchExpressions) == 0 {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errInvalidSelector, err)
+		}
+		var targetList gnmicv1alpha1.TargetList
+		if err := c.List(ctx, &targetList, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil, err
+		}
+		for _, target := range targetList.Items {
+			byName[target.Name] = target
+		}
+	}
+	result := make([]gnmicv1alpha1.Target, 0, len(byName))
+	for _, name := range slices.Sorted(maps.Keys(byName)) {
+		result = append(result, byName[name])
+	}
+	return result, nil
+}
+
+func setGNMIJobCondition(generation int64, status *gnmicv1alpha1.GNMIJobStatus, conditionStatus metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               GNMIJobConditionTypeSucceeded,
+		Status:             conditionStatus,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
+
+// summarizeNames joins the first names of a list for a condition message.
+func summarizeNames(names []string) string {
+	const maxNames = 10
+	if len(names) <= maxNames {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:maxNames], ", "), len(names)-maxNames)
+}
+
+// gnmiTimestamp converts a gNMI timestamp in nanoseconds since the epoch.
+func gnmiTimestamp(ns int64) *metav1.Time {
+	if ns <= 0 {
+		return nil
+	}
+	return &metav1.Time{Time: time.Unix(0, ns)}
+}
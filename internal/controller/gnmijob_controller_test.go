@@ -0,0 +1,433 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/pkg/api/path"
+	"google.golang.org/grpc"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+)
+
+// fakeGNMIPods records the requests sent to the gNMI server of each pod and
+// fails the ones for the targets in fail.
+type fakeGNMIPods struct {
+	mu   sync.Mutex
+	gets map[string][]*gnmi.GetRequest
+	sets map[string][]*gnmi.SetRequest
+	fail map[string]bool
+}
+
+type fakeGNMIPodClient struct {
+	gnmi.GNMIClient
+	pods *fakeGNMIPods
+	pod  string
+}
+
+func (c *fakeGNMIPodClient) Get(ctx context.Context, req *gnmi.GetRequest, _ ...grpc.CallOption) (*gnmi.GetResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.pods.mu.Lock()
+	defer c.pods.mu.Unlock()
+	c.pods.gets[c.pod] = append(c.pods.gets[c.pod], req)
+	if c.pods.fail[req.GetPrefix().GetTarget()] {
+		return nil, errors.New("unknown target")
+	}
+	return &gnmi.GetResponse{Notification: []*gnmi.Notification{
+		{Timestamp: 1700000000000000000, Update: []*gnmi.Update{
+			{Path: mustGNMIPath("/system/information/version"), Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "24.3"}}},
+			{Path: mustGNMIPath("/interfaces/interface[name=e1]/state/counters"), Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{JsonIetfVal: []byte(`{"in-octets":"10","openconfig-interfaces:out-octets":"20"}`)}}},
+		}},
+		{Timestamp: 1700000001000000000, Update: []*gnmi.Update{
+			{Path: mustGNMIPath("/system/state/hostname"), Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: req.GetPrefix().GetTarget()}}},
+		}},
+	}}, nil
+}
+
+func (c *fakeGNMIPodClient) Set(_ context.Context, req *gnmi.SetRequest, _ ...grpc.CallOption) (*gnmi.SetResponse, error) {
+	c.pods.mu.Lock()
+	defer c.pods.mu.Unlock()
+	c.pods.sets[c.pod] = append(c.pods.sets[c.pod], req)
+	return &gnmi.SetResponse{Timestamp: 1700000000000000000, Response: make([]*gnmi.UpdateResult, len(req.GetUpdate()))}, nil
+}
+
+func mustGNMIPath(p string) *gnmi.Path {
+	gp, err := path.ParsePath(p)
+	if err != nil {
+		panic(err)
+	}
+	return gp
+}
+
+func newFakeGNMIPods(fail ...string) *fakeGNMIPods {
+	pods := &fakeGNMIPods{
+		gets: make(map[string][]*gnmi.GetRequest),
+		sets: make(map[string][]*gnmi.SetRequest),
+		fail: make(map[string]bool),
+	}
+	for _, target := range fail {
+		pods.fail[target] = true
+	}
+	return pods
+}
+
+func (p *fakeGNMIPods) dial(_ context.Context, _ *gnmicv1alpha1.Cluster, pod string) (gnmi.GNMIClient, func(), error) {
+	return &fakeGNMIPodClient{pods: p, pod: pod}, func() {}, nil
+}
+
+func gnmiJobCluster(gnmiPort int32) *gnmicv1alpha1.Cluster {
+	return &gnmicv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "default"},
+		Spec: gnmicv1alpha1.ClusterSpec{API: &gnmicv1alpha1.APIConfig{
+			GNMIPort: gnmiPort,
+			TLS:      &gnmicv1alpha1.ClusterTLSConfig{IssuerRef: "issuer"},
+		}},
+	}
+}
+
+// assignedTarget is a target the cluster c1 assigned to pod.
+func assignedTarget(name, pod string, labels map[string]string) *gnmicv1alpha1.Target {
+	t := target(name, "", labels)
+	if pod != "" {
+		t.Status.ClusterStates = map[string]gnmicv1alpha1.ClusterTargetState{"c1": {Pod: pod}}
+	}
+	return t
+}
+
+func getJob(schedule string) *gnmicv1alpha1.GetJob {
+	return &gnmicv1alpha1.GetJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "inventory", Namespace: "default", Generation: 1,
+			CreationTimestamp: metav1.NewTime(time.Date(2026, 1, 1, 9, 30, 0, 0, time.UTC)),
+		},
+		Spec: gnmicv1alpha1.GetJobSpec{
+			GNMIJobSpec: gnmicv1alpha1.GNMIJobSpec{
+				ClusterRef:      "c1",
+				TargetSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"role": "leaf"}}},
+				Schedule:        schedule,
+			},
+			Paths: []string{"/system/information"},
+		},
+	}
+}
+
+func gnmiJobClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+	return fake.NewClientBuilder().WithScheme(secretWatchScheme(t)).
+		WithObjects(objs...).
+		WithStatusSubresource(&gnmicv1alpha1.GetJob{}, &gnmicv1alpha1.SetJob{}).
+		Build()
+}
+
+func reconcileGetJob(t *testing.T, r *GetJobReconciler) (ctrl.Result, *gnmicv1alpha1.GetJob) {
+	t.Helper()
+	nn := types.NamespacedName{Name: "inventory", Namespace: "default"}
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: nn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the requests are sent in the background
+	r.runs.wg.Wait()
+	var job gnmicv1alpha1.GetJob
+	if err := r.Get(context.Background(), nn, &job); err != nil {
+		t.Fatal(err)
+	}
+	return result, &job
+}
+
+// A job without a schedule runs once per generation, each target through the
+// pod the cluster assigned it to.
+func TestGetJobReconciler_OnDemand(t *testing.T) {
+	leaf := map[string]string{"role": "leaf"}
+	pods := newFakeGNMIPods("default/leaf2")
+	r := &GetJobReconciler{
+		Client: gnmiJobClient(t, gnmiJobCluster(9339), getJob(""),
+			assignedTarget("leaf1", "gnmic-c1-0", leaf),
+			assignedTarget("leaf2", "gnmic-c1-1", leaf),
+			assignedTarget("leaf3", "", leaf),
+			assignedTarget("spine1", "gnmic-c1-0", map[string]string{"role": "spine"})),
+		dial: pods.dial,
+	}
+
+	result, job := reconcileGetJob(t, r)
+	if result.RequeueAfter != 0 {
+		t.Fatalf("on-demand job requeued after %s", result.RequeueAfter)
+	}
+	if len(pods.gets["gnmic-c1-0"]) != 1 || len(pods.gets["gnmic-c1-1"]) != 1 {
+		t.Fatalf("unexpected requests per pod: %v", pods.gets)
+	}
+	if got := pods.gets["gnmic-c1-0"][0].GetPrefix().GetTarget(); got != "default/leaf1" {
+		t.Fatalf("request target = %q", got)
+	}
+
+	status := job.Status
+	if status.Succeeded != 1 || status.Failed != 2 || status.ObservedGeneration != 1 || status.LastRunTime == nil || status.Active != nil {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	leaf1 := status.Targets[0]
+	if leaf1.Target != "leaf1" || leaf1.Pod != "gnmic-c1-0" || leaf1.Result != GNMIJobResultSucceeded ||
+		leaf1.Notifications != 2 || leaf1.Updates != 3 || leaf1.Timestamp == nil || leaf1.Timestamp.Unix() != 1700000001 {
+		t.Fatalf("unexpected leaf1 result: %+v", leaf1)
+	}
+	if leaf2 := status.Targets[1]; leaf2.Result != GNMIJobResultFailed || leaf2.Message != "unknown target" {
+		t.Fatalf("unexpected leaf2 result: %+v", leaf2)
+	}
+	if leaf3 := status.Targets[2]; leaf3.Result != GNMIJobResultFailed || !strings.Contains(leaf3.Message, "not assigned") {
+		t.Fatalf("unexpected leaf3 result: %+v", leaf3)
+	}
+	cond := meta.FindStatusCondition(status.Conditions, GNMIJobConditionTypeSucceeded)
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != GNMIJobReasonTargetsFailed ||
+		!strings.Contains(cond.Message, "leaf2, leaf3") {
+		t.Fatalf("unexpected condition: %+v", cond)
+	}
+
+	// the same generation does not run again
+	reconcileGetJob(t, r)
+	if len(pods.gets["gnmic-c1-0"]) != 1 {
+		t.Fatalf("job ran again: %v", pods.gets)
+	}
+}
+
+// A run in flight is reported in the status and does not start again.
+func TestGetJobReconciler_Running(t *testing.T) {
+	pods := newFakeGNMIPods()
+	release := make(chan struct{})
+	dials := 0
+	r := &GetJobReconciler{
+		Client: gnmiJobClient(t, gnmiJobCluster(9339), getJob(""), assignedTarget("leaf1", "gnmic-c1-0", map[string]string{"role": "leaf"})),
+		dial: func(ctx context.Context, cluster *gnmicv1alpha1.Cluster, pod string) (gnmi.GNMIClient, func(), error) {
+			dials++
+			<-release
+			return pods.dial(ctx, cluster, pod)
+		},
+	}
+	ctx := context.Background()
+	nn := types.NamespacedName{Name: "inventory", Namespace: "default"}
+	for range 2 {
+		if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: nn}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var job gnmicv1alpha1.GetJob
+	if err := r.Get(ctx, nn, &job); err != nil {
+		t.Fatal(err)
+	}
+	if active := job.Status.Active; active == nil || active.Targets != 1 || active.Completed != 0 || job.Status.LastRunTime != nil {
+		t.Fatalf("unexpected status of the run in flight: %+v", job.Status)
+	}
+
+	close(release)
+	r.runs.wg.Wait()
+	if err := r.Get(ctx, nn, &job); err != nil {
+		t.Fatal(err)
+	}
+	if dials != 1 || job.Status.Active != nil || job.Status.Succeeded != 1 {
+		t.Fatalf("dials = %d, status %+v", dials, job.Status)
+	}
+}
+
+func TestGetJobReconciler_Schedule(t *testing.T) {
+	leaf := map[string]string{"role": "leaf"}
+	pods := newFakeGNMIPods()
+	now := time.Date(2026, 1, 1, 9, 45, 0, 0, time.UTC)
+	r := &GetJobReconciler{
+		Client: gnmiJobClient(t, gnmiJobCluster(9339), getJob("0 * * * *"), assignedTarget("leaf1", "gnmic-c1-0", leaf)),
+		dial:   pods.dial,
+		now:    func() time.Time { return now },
+	}
+
+	// created at 9:30, the first run is at 10:00
+	result, job := reconcileGetJob(t, r)
+	if len(pods.gets) != 0 {
+		t.Fatal("job ran before its schedule")
+	}
+	if result.RequeueAfter != 15*time.Minute || job.Status.NextRunTime == nil || !job.Status.NextRunTime.Time.Equal(now.Add(15*time.Minute)) {
+		t.Fatalf("requeue after %s, next run %v", result.RequeueAfter, job.Status.NextRunTime)
+	}
+
+	now = time.Date(2026, 1, 1, 10, 0, 5, 0, time.UTC)
+	result, job = reconcileGetJob(t, r)
+	if len(pods.gets["gnmic-c1-0"]) != 1 {
+		t.Fatalf("job did not run on schedule: %v", pods.gets)
+	}
+	if job.Status.Succeeded != 1 || !meta.IsStatusConditionTrue(job.Status.Conditions, GNMIJobConditionTypeSucceeded) {
+		t.Fatalf("unexpected status: %+v", job.Status)
+	}
+	if result.RequeueAfter != time.Hour-5*time.Second {
+		t.Fatalf("requeue after %s", result.RequeueAfter)
+	}
+
+	// a suspended job does not run and has no next run
+	job.Spec.Suspend = true
+	job.Generation = 2
+	if err := r.Update(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+	now = time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC)
+	result, job = reconcileGetJob(t, r)
+	if len(pods.gets["gnmic-c1-0"]) != 1 || result.RequeueAfter != 0 || job.Status.NextRunTime != nil {
+		t.Fatalf("suspended job: requests %v, requeue after %s, next run %v", pods.gets, result.RequeueAfter, job.Status.NextRunTime)
+	}
+}
+
+// The run of a job goes on once the reconcile starting it returned and its
+// context is canceled.
+func TestGetJobReconciler_RunOutlivesReconcile(t *testing.T) {
+	pods := newFakeGNMIPods()
+	r := &GetJobReconciler{
+		Client: gnmiJobClient(t, gnmiJobCluster(9339), getJob(""), assignedTarget("leaf1", "gnmic-c1-0", map[string]string{"role": "leaf"})),
+		dial:   pods.dial,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	nn := types.NamespacedName{Name: "inventory", Namespace: "default"}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: nn}); err != nil {
+		t.Fatal(err)
+	}
+	r.runs.wg.Wait()
+	var job gnmicv1alpha1.GetJob
+	if err := r.Get(context.Background(), nn, &job); err != nil {
+		t.Fatal(err)
+	}
+	if job.Status.Succeeded != 1 {
+		t.Fatalf("unexpected status: %+v", job.Status)
+	}
+}
+
+func TestGetJobReconciler_GNMIServerDisabled(t *testing.T) {
+	withoutTLS := gnmiJobCluster(9339)
+	withoutTLS.Spec.API.TLS = nil
+	for name, cluster := range map[string]*gnmicv1alpha1.Cluster{
+		"no gnmiPort": gnmiJobCluster(0),
+		"no api tls":  withoutTLS,
+	} {
+		t.Run(name, func(t *testing.T) {
+			pods := newFakeGNMIPods()
+			r := &GetJobReconciler{
+				Client: gnmiJobClient(t, cluster, getJob(""), assignedTarget("leaf1", "gnmic-c1-0", map[string]string{"role": "leaf"})),
+				dial:   pods.dial,
+			}
+			result, job := reconcileGetJob(t, r)
+			if len(pods.gets) != 0 || result.RequeueAfter != gnmiJobRetryInterval {
+				t.Fatalf("requests %v, requeue after %s", pods.gets, result.RequeueAfter)
+			}
+			cond := meta.FindStatusCondition(job.Status.Conditions, GNMIJobConditionTypeSucceeded)
+			if cond == nil || cond.Reason != GNMIJobReasonGNMIServerDisabled {
+				t.Fatalf("unexpected condition: %+v", cond)
+			}
+			// the job still runs once the gNMI server is enabled
+			if job.Status.ObservedGeneration != 0 {
+				t.Fatalf("observed generation = %d", job.Status.ObservedGeneration)
+			}
+		})
+	}
+}
+
+func TestDialPod_RequiresTLS(t *testing.T) {
+	cluster := gnmiJobCluster(9339)
+	cluster.Spec.API.TLS = nil
+	if _, _, err := dialPod(context.Background(), nil, cluster, "gnmic-c1-0"); !errors.Is(err, errGNMIServerDisabled) {
+		t.Fatalf("err = %v, want %v", err, errGNMIServerDisabled)
+	}
+}
+
+func TestSetJobReconciler(t *testing.T) {
+	pods := newFakeGNMIPods()
+	job := &gnmicv1alpha1.SetJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "enable-grpc", Namespace: "default", Generation: 1},
+		Spec: gnmicv1alpha1.SetJobSpec{
+			GNMIJobSpec: gnmicv1alpha1.GNMIJobSpec{ClusterRef: "c1", TargetRefs: []string{"leaf1", "missing"}},
+			Updates: []gnmicv1alpha1.SetJobUpdate{
+				{Path: "/system/grpc-server[name=mgmt]/admin-state", Value: apiextensionsv1.JSON{Raw: []byte(`"enable"`)}},
+			},
+			Encoding: "JSON_IETF",
+		},
+	}
+	r := &SetJobReconciler{
+		Client: gnmiJobClient(t, gnmiJobCluster(9339), job, assignedTarget("leaf1", "gnmic-c1-0", nil)),
+		dial:   pods.dial,
+	}
+	nn := types.NamespacedName{Name: "enable-grpc", Namespace: "default"}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: nn}); err != nil {
+		t.Fatal(err)
+	}
+	r.runs.wg.Wait()
+	if err := r.Get(context.Background(), nn, job); err != nil {
+		t.Fatal(err)
+	}
+
+	sets := pods.sets["gnmic-c1-0"]
+	if len(sets) != 1 || sets[0].GetPrefix().GetTarget() != "default/leaf1" ||
+		string(sets[0].GetUpdate()[0].GetVal().GetJsonIetfVal()) != `"enable"` {
+		t.Fatalf("unexpected requests: %v", pods.sets)
+	}
+	if job.Status.Succeeded != 1 || job.Status.Failed != 0 || len(job.Status.Targets) != 1 || job.Status.Targets[0].Updates != 1 {
+		t.Fatalf("unexpected status: %+v", job.Status)
+	}
+}
+
+func TestNextGNMIJobRun_TimeZone(t *testing.T) {
+	spec := &gnmicv1alpha1.GNMIJobSpec{Schedule: "0 2 * * *", TimeZone: "Europe/Paris"}
+	status := &gnmicv1alpha1.GNMIJobStatus{LastRunTime: &metav1.Time{Time: time.Date(2026, 1, 1, 1, 0, 0, 0, time.UTC)}}
+	next, err := nextGNMIJobRun(spec, status, time.Time{}, time.Date(2026, 1, 1, 1, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2:00 in Paris is 1:00 UTC in winter, the next one is the day after
+	if want := time.Date(2026, 1, 2, 1, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Fatalf("next = %s, want %s", next.UTC(), want)
+	}
+
+	spec.TimeZone = "Nowhere/Special"
+	if _, err := nextGNMIJobRun(spec, status, time.Time{}, time.Now()); err == nil {
+		t.Fatal("expected an error for an unknown time zone")
+	}
+}
+
+func TestBuildConfigContent_GNMIServer(t *testing.T) {
+	r := &ClusterReconciler{}
+	content, err := r.buildConfigContent(gnmiJobCluster(9339))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config map[string]any
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		t.Fatal(err)
+	}
+	server, ok := config["gnmi-server"].(map[string]any)
+	if !ok || server["address"] != ":9339" {
+		t.Fatalf("unexpected gnmi-server config: %v", config["gnmi-server"])
+	}
+	// the server only accepts the controller's client certificate
+	tls, _ := server["tls"].(map[string]any)
+	if tls["client-auth"] != "require-verify" || tls["ca-file"] == nil || tls["cert-file"] == nil {
+		t.Fatalf("unexpected gnmi-server tls config: %v", server["tls"])
+	}
+
+	withoutTLS := gnmiJobCluster(9339)
+	withoutTLS.Spec.API.TLS = nil
+	for _, cluster := range []*gnmicv1alpha1.Cluster{gnmiJobCluster(0), withoutTLS} {
+		content, err = r.buildConfigContent(cluster)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(content), "gnmi-server") {
+			t.Fatalf("gnmi-server enabled without gnmiPort and api tls:\n%s", content)
+		}
+	}
+}
@@ -0,0 +1,441 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/pkg/api/path"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+// errInvalidGNMIJobOutput is returned when an output of a job does not exist
+// or cannot be written to by the controller.
+var errInvalidGNMIJobOutput = errors.New("invalid output")
+
+// gnmiJobOutputTypes are the output types the controller writes the
+// responses of jobs to. The other types are only implemented by gNMIc.
+var gnmiJobOutputTypes = map[string]bool{
+	gnmic.TCPOutputType:      true,
+	gnmic.UDPOutputType:      true,
+	gnmic.InfluxDBOutputType: true,
+}
+
+// gnmiJobEvent is a response of a job in the gNMIc event format, the one the
+// gNMIc pods write the updates of subscriptions with.
+type gnmiJobEvent struct {
+	Name      string            `json:"name"`
+	Timestamp int64             `json:"timestamp"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	Values    map[string]any    `json:"values,omitempty"`
+}
+
+// gnmiJobOutput writes the events of jobs to an Output.
+type gnmiJobOutput struct {
+	name  string
+	write func(ctx context.Context, events []gnmiJobEvent) error
+}
+
+// gnmiJobWriter writes the notifications of the response of a target to the
+// outputs of a job.
+type gnmiJobWriter func(ctx context.Context, target string, notifications []*gnmi.Notification) error
+
+// newGNMIJobWriter returns the writer of the responses of a job to its
+// outputs, or nil if it has none.
+func newGNMIJobWriter(job string, outputs []gnmiJobOutput) gnmiJobWriter {
+	if len(outputs) == 0 {
+		return nil
+	}
+	return func(ctx context.Context, target string, notifications []*gnmi.Notification) error {
+		events, err := gnmiJobEvents(job, target, notifications)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		for _, output := range outputs {
+			if err := output.write(ctx, events); err != nil {
+				return fmt.Errorf("failed to write to output %s: %w", output.name, err)
+			}
+		}
+		return nil
+	}
+}
+
+// resolveGNMIJobOutputs resolves the outputs of a job, with the addresses of
+// their serviceRef or serviceSelector and the values of their secretRef.
+func resolveGNMIJobOutputs(ctx context.Context, c client.Reader, namespace string, names []string) ([]gnmiJobOutput, error) {
+	outputs := make([]gnmiJobOutput, 0, len(names))
+	for _, name := range names {
+		var output gnmicv1alpha1.Output
+		if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &output); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("%w: output %s not found", errInvalidGNMIJobOutput, name)
+			}
+			return nil, err
+		}
+		if !gnmiJobOutputTypes[output.Spec.Type] {
+			return nil, fmt.Errorf("%w: output %s is of type %s, jobs write to tcp, udp and influxdb outputs only",
+				errInvalidGNMIJobOutput, name, output.Spec.Type)
+		}
+		addresses, err := resolveOutputServiceAddresses(ctx, c, &output)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("%w: output %s: %v", errInvalidGNMIJobOutput, name, err)
+			}
+			return nil, err
+		}
+		var secrets map[string]string
+		if output.Spec.SecretRef != nil {
+			secrets, err = fetchSecretValues(ctx, c, namespace, output.Spec.SecretRef)
+			if err != nil {
+				if apierrors.IsNotFound(err) || errors.Is(err, errSecretKeyNotFound) {
+					return nil, fmt.Errorf("%w: output %s: %v", errInvalidGNMIJobOutput, name, err)
+				}
+				return nil, err
+			}
+		}
+		config, err := gnmic.OutputConfig(&output.Spec, addresses, secrets)
+		if err != nil {
+			return nil, fmt.Errorf("%w: output %s: %v", errInvalidGNMIJobOutput, name, err)
+		}
+		write, err := gnmiJobOutputWriter(output.Spec.Type, config)
+		if err != nil {
+			return nil, fmt.Errorf("%w: output %s: %v", errInvalidGNMIJobOutput, name, err)
+		}
+		outputs = append(outputs, gnmiJobOutput{name: name, write: write})
+	}
+	return outputs, nil
+}
+
+// gnmiJobOutputWriter returns the function writing events to an output of
+// the given type and config.
+func gnmiJobOutputWriter(outputType string, config map[string]any) (func(ctx context.Context, events []gnmiJobEvent) error, error) {
+	switch outputType {
+	case gnmic.TCPOutputType, gnmic.UDPOutputType:
+		address, _ := config["address"].(string)
+		if address == "" {
+			return nil, errors.New("address is required")
+		}
+		if outputType == gnmic.UDPOutputType {
+			return func(ctx context.Context, events []gnmiJobEvent) error {
+				return writeUDPEvents(ctx, address, events)
+			}, nil
+		}
+		delimiter := "\n"
+		if d, ok := config["delimiter"].(string); ok && d != "" {
+			delimiter = d
+		}
+		return func(ctx context.Context, events []gnmiJobEvent) error {
+			return writeTCPEvents(ctx, address, delimiter, events)
+		}, nil
+	case gnmic.InfluxDBOutputType:
+		rawURL, _ := config["url"].(string)
+		if rawURL == "" {
+			return nil, errors.New("url is required")
+		}
+		// the url of an output selecting several services lists all of them
+		var writeURLs []string
+		for u := range strings.SplitSeq(rawURL, ",") {
+			writeURL, err := influxDBWriteURL(strings.TrimSpace(u), config)
+			if err != nil {
+				return nil, err
+			}
+			writeURLs = append(writeURLs, writeURL)
+		}
+		token, _ := config["token"].(string)
+		return func(ctx context.Context, events []gnmiJobEvent) error {
+			body := influxDBLines(events)
+			for _, writeURL := range writeURLs {
+				if err := writeInfluxDB(ctx, writeURL, token, body); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported output type %s", outputType)
+}
+
+func writeTCPEvents(ctx context.Context, address, delimiter string, events []gnmiJobEvent) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetWriteDeadline(deadline)
+	}
+	var buf bytes.Buffer
+	for _, event := range events {
+		b, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		buf.WriteString(delimiter)
+	}
+	_, err = conn.Write(buf.Bytes())
+	return err
+}
+
+// writeUDPEvents writes each event in its own datagram.
+func writeUDPEvents(ctx context.Context, address string, events []gnmiJobEvent) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetWriteDeadline(deadline)
+	}
+	for _, event := range events {
+		b, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := conn.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// influxDBWriteURL returns the URL of the InfluxDB v2 write API for the org
+// and bucket of an output.
+func influxDBWriteURL(rawURL string, config map[string]any) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("url %q must be http or https", rawURL)
+	}
+	bucket, _ := config["bucket"].(string)
+	if bucket == "" {
+		return "", errors.New("bucket is required")
+	}
+	org, _ := config["org"].(string)
+	u = u.JoinPath("api", "v2", "write")
+	q := u.Query()
+	q.Set("bucket", bucket)
+	q.Set("org", org)
+	q.Set("precision", "ns")
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func writeInfluxDB(ctx context.Context, writeURL, token string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, writeURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Token "+token)
+	}
+	rsp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(rsp.Body, 512))
+		return fmt.Errorf("influxdb write failed with status %d: %s", rsp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// influxDBLines returns the events in the InfluxDB line protocol, the name of
+// the event being the measurement.
+func influxDBLines(events []gnmiJobEvent) []byte {
+	var buf bytes.Buffer
+	for _, event := range events {
+		if len(event.Values) == 0 {
+			continue
+		}
+		buf.WriteString(influxDBEscape(event.Name, ", "))
+		for _, k := range slices.Sorted(maps.Keys(event.Tags)) {
+			buf.WriteByte(',')
+			buf.WriteString(influxDBEscape(k, ",= "))
+			buf.WriteByte('=')
+			buf.WriteString(influxDBEscape(event.Tags[k], ",= "))
+		}
+		for i, k := range slices.Sorted(maps.Keys(event.Values)) {
+			if i == 0 {
+				buf.WriteByte(' ')
+			} else {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(influxDBEscape(k, ",= "))
+			buf.WriteByte('=')
+			buf.WriteString(influxDBField(event.Values[k]))
+		}
+		if event.Timestamp > 0 {
+			buf.WriteByte(' ')
+			buf.WriteString(strconv.FormatInt(event.Timestamp, 10))
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+func influxDBEscape(s, chars string) string {
+	if !strings.ContainsAny(s, chars) {
+		return s
+	}
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(chars, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func influxDBField(v any) string {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10) + "i"
+	case uint64:
+		return strconv.FormatUint(v, 10) + "u"
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return influxDBString(v)
+	}
+	// leaf-lists and the like are written as JSON strings
+	b, _ := json.Marshal(v)
+	return influxDBString(string(b))
+}
+
+func influxDBString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// gnmiJobEvents converts the notifications of the response of a target to
+// events, one per update. The keys of the path of an update are its tags,
+// as elem_key, and its value is keyed by the path without keys. JSON values
+// are flattened into a value per leaf.
+func gnmiJobEvents(job, target string, notifications []*gnmi.Notification) ([]gnmiJobEvent, error) {
+	var events []gnmiJobEvent
+	for _, n := range notifications {
+		for _, u := range n.GetUpdate() {
+			p := &gnmi.Path{Origin: n.GetPrefix().GetOrigin(), Elem: path.PathElems(n.GetPrefix(), u.GetPath())}
+			if u.GetPath().GetOrigin() != "" {
+				p.Origin = u.GetPath().GetOrigin()
+			}
+			event := gnmiJobEvent{
+				Name:      job,
+				Timestamp: n.GetTimestamp(),
+				Tags:      map[string]string{"source": target},
+				Values:    make(map[string]any),
+			}
+			for _, elem := range p.GetElem() {
+				for k, v := range elem.GetKey() {
+					event.Tags[elem.GetName()+"_"+k] = v
+				}
+			}
+			value, err := gnmiTypedValue(u.GetVal())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path.GnmiPathToXPath(p, false), err)
+			}
+			name := path.GnmiPathToXPath(p, true)
+			if p.GetOrigin() == "" {
+				name = "/" + name
+			}
+			flattenGNMIJobValue(event.Values, name, value)
+			if len(event.Values) == 0 {
+				continue
+			}
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func flattenGNMIJobValue(values map[string]any, name string, value any) {
+	if value == nil {
+		return
+	}
+	obj, ok := value.(map[string]any)
+	if !ok {
+		values[name] = value
+		return
+	}
+	for k, v := range obj {
+		// drop the module name of JSON_IETF members
+		if i := strings.LastIndex(k, ":"); i >= 0 {
+			k = k[i+1:]
+		}
+		flattenGNMIJobValue(values, strings.TrimSuffix(name, "/")+"/"+k, v)
+	}
+}
+
+func gnmiTypedValue(tv *gnmi.TypedValue) (any, error) {
+	switch v := tv.GetValue().(type) {
+	case *gnmi.TypedValue_StringVal:
+		return v.StringVal, nil
+	case *gnmi.TypedValue_AsciiVal:
+		return v.AsciiVal, nil
+	case *gnmi.TypedValue_IntVal:
+		return v.IntVal, nil
+	case *gnmi.TypedValue_UintVal:
+		return v.UintVal, nil
+	case *gnmi.TypedValue_BoolVal:
+		return v.BoolVal, nil
+	case *gnmi.TypedValue_FloatVal:
+		return float64(v.FloatVal), nil
+	case *gnmi.TypedValue_DoubleVal:
+		return v.DoubleVal, nil
+	case *gnmi.TypedValue_BytesVal:
+		return v.BytesVal, nil
+	case *gnmi.TypedValue_JsonVal:
+		return unmarshalGNMIJSON(v.JsonVal)
+	case *gnmi.TypedValue_JsonIetfVal:
+		return unmarshalGNMIJSON(v.JsonIetfVal)
+	case *gnmi.TypedValue_LeaflistVal:
+		elems := make([]any, 0, len(v.LeaflistVal.GetElement()))
+		for _, elem := range v.LeaflistVal.GetElement() {
+			value, err := gnmiTypedValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, value)
+		}
+		return elems, nil
+	case nil:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported value type %T", tv.GetValue())
+}
+
+func unmarshalGNMIJSON(b []byte) (any, error) {
+	var value any
+	if err := json.Unmarshal(b, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
@@ -0,0 +1,261 @@
+package controller
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+)
+
+func testOutput(name, outputType, config string) *gnmicv1alpha1.Output {
+	return &gnmicv1alpha1.Output{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: gnmicv1alpha1.OutputSpec{
+			Type:   outputType,
+			Config: apiextensionsv1.JSON{Raw: []byte(config)},
+		},
+	}
+}
+
+// tcpEvents accepts connections on a local port and collects the events
+// written to them, one per line.
+type tcpEvents struct {
+	ln     net.Listener
+	mu     sync.Mutex
+	events []gnmiJobEvent
+	wg     sync.WaitGroup
+}
+
+func listenTCPEvents(t *testing.T) *tcpEvents {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &tcpEvents{ln: ln}
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				var event gnmiJobEvent
+				if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+					t.Errorf("invalid event %q: %v", scanner.Text(), err)
+					continue
+				}
+				l.mu.Lock()
+				l.events = append(l.events, event)
+				l.mu.Unlock()
+			}
+			conn.Close()
+		}
+	}()
+	return l
+}
+
+// close stops listening and returns the events received.
+func (l *tcpEvents) close() []gnmiJobEvent {
+	l.ln.Close()
+	l.wg.Wait()
+	return l.events
+}
+
+func TestGetJobReconciler_Outputs(t *testing.T) {
+	tcp := listenTCPEvents(t)
+
+	var influxMu sync.Mutex
+	var influxBody, influxAuth, influxQuery string
+	influx := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		influxMu.Lock()
+		defer influxMu.Unlock()
+		influxBody += string(b)
+		influxAuth = req.Header.Get("Authorization")
+		influxQuery = req.URL.Path + "?" + req.URL.RawQuery
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer influx.Close()
+
+	influxOutput := testOutput("influx", "influxdb", `{"url":"`+influx.URL+`","org":"ops","bucket":"inventory"}`)
+	influxOutput.Spec.SecretRef = &gnmicv1alpha1.ConfigSecretRef{
+		Name:  "influx-token",
+		Items: []gnmicv1alpha1.SecretKeyToPath{{Key: "token", Path: "token"}},
+	}
+	job := getJob("")
+	job.Spec.Outputs = []string{"events", "influx"}
+	pods := newFakeGNMIPods()
+	r := &GetJobReconciler{
+		Client: gnmiJobClient(t, gnmiJobCluster(9339), job,
+			assignedTarget("leaf1", "gnmic-c1-0", map[string]string{"role": "leaf"}),
+			testOutput("events", "tcp", `{"address":"`+tcp.ln.Addr().String()+`"}`),
+			influxOutput,
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "influx-token", Namespace: "default"},
+				Data:       map[string][]byte{"token": []byte("s3cr3t")},
+			}),
+		dial: pods.dial,
+	}
+	_, got := reconcileGetJob(t, r)
+	if got.Status.Succeeded != 1 {
+		t.Fatalf("unexpected status: %+v", got.Status)
+	}
+
+	events := tcp.close()
+	if len(events) != 3 {
+		t.Fatalf("events = %+v", events)
+	}
+	counters := events[1]
+	if counters.Name != "inventory" || counters.Timestamp != 1700000000000000000 ||
+		counters.Tags["source"] != "default/leaf1" || counters.Tags["interface_name"] != "e1" ||
+		counters.Values["/interfaces/interface/state/counters/in-octets"] != "10" ||
+		counters.Values["/interfaces/interface/state/counters/out-octets"] != "20" {
+		t.Fatalf("unexpected counters event: %+v", counters)
+	}
+
+	influxMu.Lock()
+	defer influxMu.Unlock()
+	if influxAuth != "Token s3cr3t" || influxQuery != "/api/v2/write?bucket=inventory&org=ops&precision=ns" {
+		t.Fatalf("unexpected influxdb request: auth %q, %s", influxAuth, influxQuery)
+	}
+	want := `inventory,source=default/leaf1 /system/information/version="24.3" 1700000000000000000`
+	if !strings.Contains(influxBody, want+"\n") {
+		t.Fatalf("influxdb body does not contain %q:\n%s", want, influxBody)
+	}
+}
+
+func TestGetJobReconciler_InvalidOutputs(t *testing.T) {
+	for name, objs := range map[string][]*gnmicv1alpha1.Output{
+		"missing":     nil,
+		"unsupported": {testOutput("events", "prometheus", `{}`)},
+		"no address":  {testOutput("events", "tcp", `{}`)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			job := getJob("")
+			job.Spec.Outputs = []string{"events"}
+			pods := newFakeGNMIPods()
+			c := gnmiJobClient(t, gnmiJobCluster(9339), job, assignedTarget("leaf1", "gnmic-c1-0", map[string]string{"role": "leaf"}))
+			for _, output := range objs {
+				if err := c.Create(t.Context(), output); err != nil {
+					t.Fatal(err)
+				}
+			}
+			r := &GetJobReconciler{Client: c, dial: pods.dial}
+			result, got := reconcileGetJob(t, r)
+			if len(pods.gets) != 0 || result.RequeueAfter != gnmiJobRetryInterval {
+				t.Fatalf("requests %v, requeue after %s", pods.gets, result.RequeueAfter)
+			}
+			cond := meta.FindStatusCondition(got.Status.Conditions, GNMIJobConditionTypeSucceeded)
+			if cond == nil || cond.Reason != GNMIJobReasonInvalidOutputs {
+				t.Fatalf("unexpected condition: %+v", cond)
+			}
+		})
+	}
+}
+
+// A target whose response cannot be written to the outputs of the job fails.
+func TestGetJobReconciler_OutputWriteFails(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := ln.Addr().String()
+	ln.Close()
+
+	job := getJob("")
+	job.Spec.Outputs = []string{"events"}
+	r := &GetJobReconciler{
+		Client: gnmiJobClient(t, gnmiJobCluster(9339), job,
+			assignedTarget("leaf1", "gnmic-c1-0", map[string]string{"role": "leaf"}),
+			testOutput("events", "tcp", `{"address":"`+address+`"}`)),
+		dial: newFakeGNMIPods().dial,
+	}
+	_, got := reconcileGetJob(t, r)
+	if got.Status.Failed != 1 || !strings.Contains(got.Status.Targets[0].Message, "failed to write to output events") {
+		t.Fatalf("unexpected status: %+v", got.Status)
+	}
+	// the counts of the response are still reported
+	if got.Status.Targets[0].Updates != 3 {
+		t.Fatalf("unexpected result: %+v", got.Status.Targets[0])
+	}
+}
+
+func TestWriteUDPEvents(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	events := []gnmiJobEvent{
+		{Name: "j", Timestamp: 1, Values: map[string]any{"/a": "x"}},
+		{Name: "j", Timestamp: 2, Values: map[string]any{"/b": "y"}},
+	}
+	if err := writeUDPEvents(t.Context(), conn.LocalAddr().String(), events); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 1024)
+	for i := range events {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var event gnmiJobEvent
+		if err := json.Unmarshal(buf[:n], &event); err != nil || event.Timestamp != events[i].Timestamp {
+			t.Fatalf("datagram %d = %s (%v)", i, buf[:n], err)
+		}
+	}
+}
+
+func TestGNMIJobEvents_SetResponse(t *testing.T) {
+	rsp := &gnmi.SetResponse{
+		Timestamp: 42,
+		Prefix:    mustGNMIPath("/system"),
+		Response: []*gnmi.UpdateResult{
+			{Path: mustGNMIPath("grpc-server[name=mgmt]/config/enable"), Op: gnmi.UpdateResult_UPDATE},
+		},
+	}
+	events, err := gnmiJobEvents("enable-grpc", "default/leaf1", []*gnmi.Notification{setResponseNotification(rsp)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Tags["grpc-server_name"] != "mgmt" ||
+		events[0].Values["/system/grpc-server/config/enable"] != "UPDATE" || events[0].Timestamp != 42 {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+}
+
+func TestInfluxDBLines(t *testing.T) {
+	lines := influxDBLines([]gnmiJobEvent{
+		{
+			Name:      "my job",
+			Timestamp: 7,
+			Tags:      map[string]string{"source": "default/leaf 1", "interface_name": "a=b,c"},
+			Values: map[string]any{
+				"/count": int64(3), "/rate": 1.5, "/up": true, "/desc": `say "hi"`,
+				"/list": []any{"a", "b"}, "/big": uint64(5),
+			},
+		},
+		{Name: "empty"},
+	})
+	want := `my\ job,interface_name=a\=b\,c,source=default/leaf\ 1 /big=5u,/count=3i,/desc="say \"hi\"",/list="[\"a\",\"b\"]",/rate=1.5,/up=true 7` + "\n"
+	if string(lines) != want {
+		t.Fatalf("lines =\n%s\nwant\n%s", lines, want)
+	}
+}
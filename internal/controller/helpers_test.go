@@ -8,6 +8,7 @@ import (
 	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
 	"github.com/gnmic/operator/internal/gnmic"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -114,6 +115,7 @@ func TestBuildContainerPorts(t *testing.T) {
 			API: &gnmicv1alpha1.APIConfig{
 				RestPort: 8080,
 				GNMIPort: 9339,
+				TLS:      &gnmicv1alpha1.ClusterTLSConfig{IssuerRef: "issuer"},
 			},
 			GRPCTunnel: &gnmicv1alpha1.GRPCTunnelConfig{Port: 57400},
 		},
@@ -168,6 +170,19 @@ func TestGenerationOrLabelsChangedPredicate(t *testing.T) {
 	}
 }
 
+func TestTargetChangedPredicate(t *testing.T) {
+	p := targetChangedPredicate{}
+	old := &gnmicv1alpha1.Target{ObjectMeta: metav1.ObjectMeta{Generation: 1, Annotations: map[string]string{"netbox.dev/serial": "A1"}}}
+	new := old.DeepCopy()
+	if p.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: new}) {
+		t.Fatal("expected false when unchanged")
+	}
+	new.Annotations["netbox.dev/serial"] = "B2"
+	if !p.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: new}) {
+		t.Fatal("expected true on annotation change")
+	}
+}
+
 func TestPipelineReconciler(t *testing.T) {
 	scheme := runtime.NewScheme()
 	if err := gnmicv1alpha1.AddToScheme(scheme); err != nil {
@@ -245,6 +260,66 @@ func TestPipelineReconciler(t *testing.T) {
 	if updated3.Status.Status != "Disabled" {
 		t.Fatalf("status = %q", updated3.Status.Status)
 	}
+
+	// invalid spec, reported without a cluster
+	pipeline4 := &gnmicv1alpha1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "p4", Namespace: "default", Generation: 2},
+		Spec: gnmicv1alpha1.PipelineSpec{ClusterRef: "missing", Enabled: true, Schedule: &gnmicv1alpha1.PipelineSchedule{
+			TimeZone: "Mars/Olympus_Mons",
+		}},
+	}
+	cl4 := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(pipeline4).
+		WithStatusSubresource(&gnmicv1alpha1.Pipeline{}).
+		Build()
+	r4 := &PipelineReconciler{Client: cl4, Scheme: scheme}
+	if _, err := r4.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "p4"}}); err != nil {
+		t.Fatal(err)
+	}
+	var updated4 gnmicv1alpha1.Pipeline
+	_ = cl4.Get(context.Background(), client.ObjectKeyFromObject(pipeline4), &updated4)
+	ready := meta.FindStatusCondition(updated4.Status.Conditions, PipelineConditionTypeReady)
+	if updated4.Status.Status != "Error" || ready == nil || ready.Reason != "InvalidSchedule" || ready.ObservedGeneration != 2 {
+		t.Fatalf("status = %+v, want an InvalidSchedule error", updated4.Status)
+	}
+
+	// elapsed TTL disables the pipeline, a running one requeues at its expiry
+	enabledAt := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	expired := &gnmicv1alpha1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "p5", Namespace: "default"},
+		Spec:       gnmicv1alpha1.PipelineSpec{ClusterRef: "c1", Enabled: true, TTLAfterEnable: &metav1.Duration{Duration: time.Hour}},
+		Status:     gnmicv1alpha1.PipelineStatus{EnabledAt: &enabledAt},
+	}
+	running := expired.DeepCopy()
+	running.Name = "p6"
+	running.Spec.TTLAfterEnable = &metav1.Duration{Duration: 3 * time.Hour}
+	cl5 := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(cluster, expired, running).
+		WithStatusSubresource(&gnmicv1alpha1.Pipeline{}).
+		Build()
+	r5 := &PipelineReconciler{Client: cl5, Scheme: scheme}
+	if _, err := r5.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "p5"}}); err != nil {
+		t.Fatal(err)
+	}
+	var updated5 gnmicv1alpha1.Pipeline
+	_ = cl5.Get(context.Background(), client.ObjectKeyFromObject(expired), &updated5)
+	if updated5.Spec.Enabled {
+		t.Fatal("pipeline with an elapsed TTL is still enabled")
+	}
+	res, err = r5.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "p6"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RequeueAfter < 59*time.Minute || res.RequeueAfter > time.Hour+time.Second {
+		t.Fatalf("requeue = %v, want about an hour", res.RequeueAfter)
+	}
+	var updated6 gnmicv1alpha1.Pipeline
+	_ = cl5.Get(context.Background(), client.ObjectKeyFromObject(running), &updated6)
+	if !updated6.Spec.Enabled || updated6.Status.Status != "Ready" {
+		t.Fatalf("pipeline = %+v, want it enabled and ready", updated6)
+	}
 }
 
 func TestTunnelTargetPolicyReconciler(t *testing.T) {
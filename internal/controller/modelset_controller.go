@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/yang"
+)
+
+const (
+	// ModelSetConditionTypeReady indicates the YANG modules of a ModelSet were loaded
+	ModelSetConditionTypeReady = "Ready"
+)
+
+// ModelSetReconciler loads the YANG modules of ModelSets, and reports in their
+// status whether they could be parsed.
+type ModelSetReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// Models is shared with the Cluster controller and the Subscription
+	// webhook, which check paths against the loaded schemas
+	Models *yang.Loader
+}
+
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=modelsets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=modelsets/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+
+func (r *ModelSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var modelSet gnmicv1alpha1.ModelSet
+	if err := r.Get(ctx, req.NamespacedName, &modelSet); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.Models.Forget(req.NamespacedName)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	status := gnmicv1alpha1.ModelSetStatus{
+		ObservedGeneration: modelSet.Generation,
+		Conditions:         slices.Clone(modelSet.Status.Conditions),
+	}
+	cond := metav1.Condition{
+		Type:               ModelSetConditionTypeReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: modelSet.Generation,
+		Reason:             "Loaded",
+	}
+	schema, err := r.Models.Load(ctx, r.Client, &modelSet)
+	var apiErr apierrors.APIStatus
+	switch {
+	case errors.As(err, &apiErr) && !apierrors.IsNotFound(err):
+		return ctrl.Result{}, err
+	case err != nil:
+		// the ModelSet is loaded again once its ConfigMaps change
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "LoadFailed"
+		cond.Message = err.Error()
+	default:
+		status.Modules = schema.Modules
+		cond.Message = "The YANG modules were loaded"
+	}
+	meta.SetStatusCondition(&status.Conditions, cond)
+
+	if equality.Semantic.DeepEqual(status, modelSet.Status) {
+		return ctrl.Result{}, nil
+	}
+	patch := client.MergeFrom(modelSet.DeepCopy())
+	modelSet.Status = status
+	return ctrl.Result{}, client.IgnoreNotFound(r.Status().Patch(ctx, &modelSet, patch))
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ModelSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&gnmicv1alpha1.ModelSet{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.findModelSetsForConfigMap),
+			builder.WithPredicates(configMapDataChangedPredicate{}),
+		).
+		Named("modelset").
+		Complete(r)
+}
+
+// findModelSetsForConfigMap returns the ModelSets whose modules a ConfigMap
+// holds.
+func (r *ModelSetReconciler) findModelSetsForConfigMap(ctx context.Context, obj client.Object) []reconcile.Request {
+	var modelSets gnmicv1alpha1.ModelSetList
+	if err := r.List(ctx, &modelSets, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, modelSet := range modelSets.Items {
+		if slices.Contains(modelSet.Spec.ConfigMaps, obj.GetName()) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: modelSet.Name, Namespace: modelSet.Namespace}})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+	"github.com/gnmic/operator/internal/yang"
+)
+
+const testSystemModule = `module test-system {
+  namespace "urn:test:system";
+  prefix sys;
+
+  container system {
+    container config {
+      leaf hostname { type string; }
+    }
+    container state {
+      config false;
+      leaf hostname { type string; }
+      leaf boot-time { type uint64; }
+    }
+  }
+}`
+
+func testModelSet(version string) *gnmicv1alpha1.ModelSet {
+	return &gnmicv1alpha1.ModelSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "default", Generation: 1},
+		Spec:       gnmicv1alpha1.ModelSetSpec{Version: version, ConfigMaps: []string{"models"}},
+	}
+}
+
+func testModelsConfigMap(module string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "default"},
+		Data:       map[string]string{"test-system.yang": module},
+	}
+}
+
+func TestModelSetReconciler(t *testing.T) {
+	ctx := context.Background()
+	scheme := secretWatchScheme(t)
+	configMap := testModelsConfigMap(testSystemModule)
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(testModelSet("1.0.0"), configMap).
+		WithStatusSubresource(&gnmicv1alpha1.ModelSet{}).
+		Build()
+	r := &ModelSetReconciler{Client: c, Scheme: scheme, Models: yang.NewLoader()}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "models", Namespace: "default"}}
+
+	ready := func() (*gnmicv1alpha1.ModelSet, *metav1.Condition) {
+		t.Helper()
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+		var modelSet gnmicv1alpha1.ModelSet
+		if err := c.Get(ctx, req.NamespacedName, &modelSet); err != nil {
+			t.Fatal(err)
+		}
+		return &modelSet, meta.FindStatusCondition(modelSet.Status.Conditions, ModelSetConditionTypeReady)
+	}
+
+	modelSet, cond := ready()
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.Reason != "Loaded" {
+		t.Fatalf("unexpected condition: %+v", cond)
+	}
+	if strings.Join(modelSet.Status.Modules, ",") != "test-system" || modelSet.Status.ObservedGeneration != 1 {
+		t.Fatalf("unexpected status: %+v", modelSet.Status)
+	}
+
+	configMap.Data["test-system.yang"] = "module test-system {"
+	if err := c.Update(ctx, configMap); err != nil {
+		t.Fatal(err)
+	}
+	if modelSet, cond = ready(); cond.Status != metav1.ConditionFalse || cond.Reason != "LoadFailed" || len(modelSet.Status.Modules) != 0 {
+		t.Fatalf("unexpected status: %+v", modelSet.Status)
+	}
+
+	if err := c.Delete(ctx, configMap); err != nil {
+		t.Fatal(err)
+	}
+	if _, cond = ready(); cond.Status != metav1.ConditionFalse || !strings.Contains(cond.Message, "failed to get ConfigMap models") {
+		t.Fatalf("unexpected condition: %+v", cond)
+	}
+
+	if got := r.findModelSetsForConfigMap(ctx, configMap); len(got) != 1 || got[0].Name != "models" {
+		t.Fatalf("requests = %v, want models", got)
+	}
+}
+
+func TestPathsCondition(t *testing.T) {
+	ctx := context.Background()
+	scheme := secretWatchScheme(t)
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(testModelSet("1.0.0"), testModelsConfigMap(testSystemModule)).
+		Build()
+	r := &ClusterReconciler{Client: c, Scheme: scheme, Models: yang.NewLoader()}
+
+	data := gnmic.NewPipelineData()
+	data.TargetProfiles["default/checked"] = gnmicv1alpha1.TargetProfileSpec{
+		ModelSet: &gnmicv1alpha1.ModelSetReference{Name: "models", Version: "1.0.0"},
+	}
+	data.TargetProfiles["default/unchecked"] = gnmicv1alpha1.TargetProfileSpec{}
+	data.Targets["default/leaf1"] = *target("leaf1", "checked", nil)
+	data.Targets["default/leaf2"] = *target("leaf2", "checked", nil)
+	data.Targets["default/leaf3"] = *target("leaf3", "unchecked", nil)
+	data.Subscriptions["default/p1/system"] = gnmicv1alpha1.SubscriptionSpec{
+		Prefix: "/system",
+		Paths:  []string{"state/hostname", "state/uptime"},
+	}
+	data.Subscriptions["default/p1/interfaces"] = gnmicv1alpha1.SubscriptionSpec{Paths: []string{"/interfaces"}}
+
+	cond := r.pathsCondition(ctx, data, 1, metav1.Now())
+	if cond.Status != metav1.ConditionFalse || cond.Reason != "InvalidPaths" {
+		t.Fatalf("unexpected condition: %+v", cond)
+	}
+	// each subscription is reported once, not once per target
+	want := `Paths not in the ModelSets of their targets: interfaces /interfaces (models@1.0.0): "interfaces" not found under /, ` +
+		`system state/uptime (models@1.0.0): "uptime" not found under /system/state`
+	if cond.Message != want {
+		t.Fatalf("message = %q, want %q", cond.Message, want)
+	}
+
+	// the subscriptions a target selects are the only ones checked
+	leaf1 := data.Targets["default/leaf1"]
+	leaf1.Spec.Subscriptions = []string{"system"}
+	data.Targets["default/leaf1"] = leaf1
+	delete(data.Targets, "default/leaf2")
+	if cond := r.pathsCondition(ctx, data, 1, metav1.Now()); strings.Contains(cond.Message, "interfaces") {
+		t.Fatalf("unexpected condition: %+v", cond)
+	}
+
+	data.Subscriptions["default/p1/system"] = gnmicv1alpha1.SubscriptionSpec{Paths: []string{"/system/state/boot-time"}}
+	if cond := r.pathsCondition(ctx, data, 1, metav1.Now()); cond.Status != metav1.ConditionTrue {
+		t.Fatalf("unexpected condition: %+v", cond)
+	}
+
+	data.TargetProfiles["default/checked"] = gnmicv1alpha1.TargetProfileSpec{
+		ModelSet: &gnmicv1alpha1.ModelSetReference{Name: "models", Version: "2.0.0"},
+	}
+	cond = r.pathsCondition(ctx, data, 1, metav1.Now())
+	if cond.Status != metav1.ConditionUnknown || cond.Reason != "ModelSetsNotLoaded" || !strings.Contains(cond.Message, "has version 1.0.0, not 2.0.0") {
+		t.Fatalf("unexpected condition: %+v", cond)
+	}
+}
+
+func TestFindClustersForModelSet(t *testing.T) {
+	checked := profile("checked", "")
+	checked.Spec.ModelSet = &gnmicv1alpha1.ModelSetReference{Name: "models"}
+	r := reconcilerWith(t,
+		testModelSet("1.0.0"),
+		testModelsConfigMap(testSystemModule),
+		checked,
+		profile("unchecked", ""),
+		target("leaf1", "checked", map[string]string{"tag": "prod"}),
+		target("leaf2", "unchecked", map[string]string{"tag": "lab"}),
+		pipelineSelectingTargets("p1", "c1", true, map[string]string{"tag": "prod"}),
+		pipelineSelectingTargets("p2", "c2", true, map[string]string{"tag": "lab"}),
+	)
+	ctx := context.Background()
+	for name, reqs := range map[string][]ctrl.Request{
+		"ModelSet":  r.findClustersForModelSet(ctx, testModelSet("1.0.0")),
+		"ConfigMap": r.findClustersForConfigMap(ctx, testModelsConfigMap(testSystemModule)),
+	} {
+		if len(reqs) != 1 || reqs[0].Name != "c1" {
+			t.Errorf("%s: clusters = %v, want [c1]", name, reqs)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+// defaultSubscriptionEncoding is the encoding of the subscriptions when
+// neither they nor the profile of their target set one, as in gNMIc.
+const defaultSubscriptionEncoding = "JSON"
+
+// unsupportedEncodings lists the subscriptions of a pipeline whose encoding
+// the capabilities probe of their targets did not find, as
+// "target: subscription (ENCODING)". Targets that were not probed are skipped.
+func unsupportedEncodings(pipelineData *gnmic.PipelineData) []string {
+	var unsupported []string
+	subscriptionKeys := slices.Sorted(maps.Keys(pipelineData.Subscriptions))
+	for _, targetNN := range slices.Sorted(maps.Keys(pipelineData.Targets)) {
+		target := pipelineData.Targets[targetNN]
+		caps := target.Status.Capabilities
+		if caps == nil || len(caps.SupportedEncodings) == 0 {
+			continue
+		}
+		profile := pipelineData.TargetProfiles[target.Namespace+gnmic.Delimiter+target.Spec.Profile]
+		for _, key := range subscriptionKeys {
+			// subscriptions are keyed by pipeline, the name comes last
+			name := key[strings.LastIndex(key, gnmic.Delimiter)+1:]
+			if len(target.Spec.Subscriptions) > 0 && !slices.Contains(target.Spec.Subscriptions, name) {
+				continue
+			}
+			encoding := pipelineData.Subscriptions[key].Encoding
+			if encoding == "" {
+				encoding = profile.Encoding
+			}
+			if encoding == "" {
+				encoding = defaultSubscriptionEncoding
+			}
+			if !slices.ContainsFunc(caps.SupportedEncodings, func(e string) bool { return strings.EqualFold(e, encoding) }) {
+				unsupported = append(unsupported, fmt.Sprintf("%s: %s (%s)", target.Name, name, strings.ToUpper(encoding)))
+			}
+		}
+	}
+	return unsupported
+}
+
+// encodingsCondition reports the subscriptions of a pipeline whose encoding
+// their targets do not advertise. It only warns: the subscriptions are
+// collected anyway, and fail on the targets rejecting their encoding.
+func encodingsCondition(pipelineData *gnmic.PipelineData, generation int64, now metav1.Time) metav1.Condition {
+	cond := metav1.Condition{
+		Type:               PipelineConditionTypeEncodingsSupported,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		LastTransitionTime: now,
+		Reason:             "EncodingsSupported",
+		Message:            "The probed targets advertise the encodings of their subscriptions",
+	}
+	if unsupported := unsupportedEncodings(pipelineData); len(unsupported) > 0 {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "UnsupportedEncodings"
+		cond.Message = "Encodings not advertised by their targets: " + summarizeNames(unsupported)
+	}
+	return cond
+}
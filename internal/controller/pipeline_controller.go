@@ -18,14 +18,22 @@ package controller
 
 import (
 	"context"
+	"slices"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
 )
@@ -34,14 +42,21 @@ import (
 type PipelineReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// Clusters resolves the references of the pipelines as the plans do, to
+	// report their ResolvedRefs condition. Unset, the condition is reported
+	// by the Cluster controller only.
+	Clusters *ClusterReconciler
 }
 
 //+kubebuilder:rbac:groups=operator.gnmic.dev,resources=pipelines,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=operator.gnmic.dev,resources=pipelines/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=operator.gnmic.dev,resources=pipelines/finalizers,verbs=update
 //+kubebuilder:rbac:groups=operator.gnmic.dev,resources=clusters,verbs=get;list;watch
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=targets;subscriptions;outputs;inputs;processors;targetprofiles;tunneltargetpolicies,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=secrets;configmaps,verbs=get;list;watch
 
-// Reconcile validates the Pipeline and updates its status.
+// Reconcile validates the Pipeline and updates its status, including the
+// ResolvedRefs condition of an active pipeline.
 // The actual configuration building happens in the ClusterReconciler which watches Pipelines.
 func (r *PipelineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
@@ -53,6 +68,39 @@ func (r *PipelineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 
 	logger = logger.WithValues("pipeline", pipeline.Name, "namespace", pipeline.Namespace)
 
+	// ttlAfterEnable counts from the time the pipeline was last enabled
+	if syncPipelineEnabledAt(&pipeline, metav1.Now()) {
+		if err := r.Status().Update(ctx, &pipeline); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// once ttlAfterEnable has elapsed the pipeline disables itself
+	now := time.Now()
+	expiry := pipelineTTLExpiry(&pipeline)
+	if !expiry.IsZero() && !now.Before(expiry) {
+		base := pipeline.DeepCopy()
+		pipeline.Spec.Enabled = false
+		if err := r.Patch(ctx, &pipeline, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{})); err != nil {
+			return ctrl.Result{}, err
+		}
+		logger.Info("disabled pipeline, ttlAfterEnable elapsed", "ttlAfterEnable", pipeline.Spec.TTLAfterEnable.Duration, "expiry", expiry)
+		// the update of spec.enabled triggers a new reconcile
+		return ctrl.Result{}, nil
+	}
+
+	// report errors of the pipeline's own spec without waiting for a Cluster
+	// to skip it; the Cluster controller reports the same condition
+	if err := validatePipelineSpec(&pipeline); err != nil {
+		logger.Info("invalid pipeline spec", "error", err.Error())
+		if setPipelineSpecError(&pipeline, err, metav1.Now()) {
+			if err := r.Status().Update(ctx, &pipeline); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return requeueAtTransition(ctrl.Result{}, expiry, now), nil
+	}
+
 	// validate the referenced cluster exists
 	var cluster gnmicv1alpha1.Cluster
 	clusterNN := types.NamespacedName{
@@ -84,13 +132,178 @@ func (r *PipelineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		}
 	}
 
+	// report the references that do not resolve as soon as they change,
+	// rather than on the next reconcile of the cluster
+	if pipeline.Spec.Enabled && r.Clusters != nil {
+		if err := r.updateResolvedRefs(ctx, &cluster, &pipeline, now); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	logger.Info("reconciled pipeline", "clusterRef", pipeline.Spec.ClusterRef, "enabled", pipeline.Spec.Enabled)
-	return ctrl.Result{}, nil
+	return requeueAtTransition(ctrl.Result{}, expiry, now), nil
+}
+
+// updateResolvedRefs resolves the references of an active pipeline and
+// patches its ResolvedRefs condition and unresolved references when they
+// changed. Errors of the pipeline's own spec are left to the Cluster
+// controller, which reports them in the Ready condition.
+func (r *PipelineReconciler) updateResolvedRefs(ctx context.Context, cluster *gnmicv1alpha1.Cluster, pipeline *gnmicv1alpha1.Pipeline, now time.Time) error {
+	schedule, err := evaluatePipelineSchedule(pipeline, now)
+	if err != nil || schedule.state != PipelineStateActive {
+		return nil
+	}
+	resolved, err := r.Clusters.resolvePipeline(ctx, cluster, pipeline, schedule, newResourceUsage())
+	if err != nil {
+		if pipelineSpecReason(err) != "" {
+			return nil
+		}
+		return err
+	}
+
+	base := pipeline.DeepCopy()
+	changed := meta.SetStatusCondition(&pipeline.Status.Conditions, resolved.refs.condition(pipeline.Generation, metav1.NewTime(now)))
+	if unresolved := resolved.refs.sortedUnresolved(); !slices.Equal(pipeline.Status.UnresolvedRefs, unresolved) {
+		pipeline.Status.UnresolvedRefs = unresolved
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return r.Status().Patch(ctx, pipeline, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}))
+}
+
+// pipelineTTLExpiry returns when an enabled pipeline's ttlAfterEnable elapses,
+// zero if it has no TTL or its enabledAt is not recorded yet.
+func pipelineTTLExpiry(pipeline *gnmicv1alpha1.Pipeline) time.Time {
+	ttl := pipeline.Spec.TTLAfterEnable
+	if !pipeline.Spec.Enabled || ttl == nil || pipeline.Status.EnabledAt == nil {
+		return time.Time{}
+	}
+	return pipeline.Status.EnabledAt.Add(ttl.Duration)
+}
+
+// syncPipelineEnabledAt records when an enabled pipeline was enabled, and
+// resets the schedule state of a disabled one. It reports whether the status
+// changed.
+func syncPipelineEnabledAt(pipeline *gnmicv1alpha1.Pipeline, now metav1.Time) bool {
+	status := &pipeline.Status
+	if pipeline.Spec.Enabled {
+		if status.EnabledAt != nil {
+			return false
+		}
+		status.EnabledAt = &now
+		return true
+	}
+	if status.EnabledAt == nil && status.EffectiveState == PipelineStateDisabled &&
+		status.NextTransitionTime == nil && status.SuspendedTargetsCount == 0 {
+		return false
+	}
+	status.EnabledAt = nil
+	status.EffectiveState = PipelineStateDisabled
+	status.NextTransitionTime = nil
+	status.SuspendedTargetsCount = 0
+	return true
+}
+
+// setPipelineSpecError sets the status of a pipeline whose spec is invalid.
+// It reports whether the status changed.
+func setPipelineSpecError(pipeline *gnmicv1alpha1.Pipeline, err error, now metav1.Time) bool {
+	status := &pipeline.Status
+	changed := status.Status != "Error"
+	status.Status = "Error"
+	return meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               PipelineConditionTypeReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: pipeline.Generation,
+		LastTransitionTime: now,
+		Reason:             pipelineSpecReason(err),
+		Message:            err.Error(),
+	}) || changed
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *PipelineReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&gnmicv1alpha1.Pipeline{}).
-		Complete(r)
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&gnmicv1alpha1.Pipeline{})
+	if r.Clusters != nil {
+		// the resources a pipeline references, with the predicates of the
+		// Cluster controller, which finds the clusters they reconcile
+		specOrLabelsPredicate := generationOrLabelsChangedPredicate{}
+		b = b.
+			Watches(
+				&gnmicv1alpha1.Target{},
+				handler.EnqueueRequestsFromMapFunc(r.pipelinesFor(r.Clusters.findClustersForTarget)),
+				builder.WithPredicates(targetChangedPredicate{}),
+			).
+			Watches(
+				&gnmicv1alpha1.Subscription{},
+				handler.EnqueueRequestsFromMapFunc(r.pipelinesFor(r.Clusters.findClustersForSubscription)),
+				builder.WithPredicates(specOrLabelsPredicate),
+			).
+			Watches(
+				&gnmicv1alpha1.Output{},
+				handler.EnqueueRequestsFromMapFunc(r.pipelinesFor(r.Clusters.findClustersForOutput)),
+				builder.WithPredicates(specOrLabelsPredicate),
+			).
+			Watches(
+				&gnmicv1alpha1.Input{},
+				handler.EnqueueRequestsFromMapFunc(r.pipelinesFor(r.Clusters.findClustersForInput)),
+				builder.WithPredicates(specOrLabelsPredicate),
+			).
+			Watches(
+				&gnmicv1alpha1.Processor{},
+				handler.EnqueueRequestsFromMapFunc(r.pipelinesFor(r.Clusters.findClustersForProcessor)),
+				builder.WithPredicates(specOrLabelsPredicate),
+			).
+			Watches(
+				&gnmicv1alpha1.TargetProfile{},
+				handler.EnqueueRequestsFromMapFunc(r.pipelinesFor(r.Clusters.findClustersForTargetProfile)),
+				builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+			).
+			Watches(
+				&gnmicv1alpha1.TunnelTargetPolicy{},
+				handler.EnqueueRequestsFromMapFunc(r.pipelinesFor(r.Clusters.findClustersForTunnelTargetPolicy)),
+				builder.WithPredicates(specOrLabelsPredicate),
+			).
+			Watches(
+				&corev1.Secret{},
+				handler.EnqueueRequestsFromMapFunc(r.pipelinesFor(r.Clusters.findClustersForSecret)),
+				builder.WithPredicates(secretDataChangedPredicate{}),
+			).
+			Watches(
+				&corev1.ConfigMap{},
+				handler.EnqueueRequestsFromMapFunc(r.pipelinesFor(r.Clusters.findClustersForConfigMap)),
+				builder.WithPredicates(configMapDataChangedPredicate{}),
+			)
+	}
+	return b.Complete(r)
+}
+
+// pipelinesFor maps an object to the enabled pipelines of the clusters
+// findClusters maps it to, whose references it may resolve.
+func (r *PipelineReconciler) pipelinesFor(findClusters handler.MapFunc) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		clusters := findClusters(ctx, obj)
+		if len(clusters) == 0 {
+			return nil
+		}
+		var pipelineList gnmicv1alpha1.PipelineList
+		if err := r.List(ctx, &pipelineList, client.InNamespace(obj.GetNamespace())); err != nil {
+			return nil
+		}
+		var requests []reconcile.Request
+		for i := range pipelineList.Items {
+			pipeline := &pipelineList.Items[i]
+			if !pipeline.Spec.Enabled {
+				continue
+			}
+			if slices.ContainsFunc(clusters, func(req reconcile.Request) bool {
+				return req.Name == pipeline.Spec.ClusterRef
+			}) {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(pipeline)})
+			}
+		}
+		return requests
+	}
 }
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gnmic/operator/internal/gnmic"
+	"github.com/gnmic/operator/internal/yang"
+)
+
+// invalidPaths checks the paths of the subscriptions of a pipeline against
+// the ModelSets of the profiles of their targets. It lists the paths not in
+// the models as "subscription path (ModelSet): reason", and the ModelSets that
+// could not be loaded. A subscription is checked once per ModelSet, whatever
+// the number of targets sharing it.
+func (r *ClusterReconciler) invalidPaths(ctx context.Context, pipelineData *gnmic.PipelineData) (invalid, unloaded []string) {
+	if r.Models == nil {
+		return nil, nil
+	}
+	schemas := make(map[string]*yang.Schema)
+	checked := make(map[string]struct{})
+	subscriptionKeys := slices.Sorted(maps.Keys(pipelineData.Subscriptions))
+	for _, targetNN := range slices.Sorted(maps.Keys(pipelineData.Targets)) {
+		target := pipelineData.Targets[targetNN]
+		ref := pipelineData.TargetProfiles[target.Namespace+gnmic.Delimiter+target.Spec.Profile].ModelSet
+		if ref == nil {
+			continue
+		}
+		modelSet := ref.Name
+		if ref.Version != "" {
+			modelSet += "@" + ref.Version
+		}
+		modelSetNN := target.Namespace + gnmic.Delimiter + modelSet
+		schema, ok := schemas[modelSetNN]
+		if !ok {
+			var err error
+			if schema, err = r.Models.LoadReference(ctx, r.Client, target.Namespace, ref); err != nil {
+				unloaded = append(unloaded, fmt.Sprintf("%s (%v)", modelSet, err))
+			}
+			schemas[modelSetNN] = schema
+		}
+		if schema == nil {
+			continue
+		}
+		for _, key := range subscriptionKeys {
+			// subscriptions are keyed by pipeline, the name comes last
+			name := key[strings.LastIndex(key, gnmic.Delimiter)+1:]
+			if len(target.Spec.Subscriptions) > 0 && !slices.Contains(target.Spec.Subscriptions, name) {
+				continue
+			}
+			if _, ok := checked[modelSetNN+" "+key]; ok {
+				continue
+			}
+			checked[modelSetNN+" "+key] = struct{}{}
+			sub := pipelineData.Subscriptions[key]
+			paths := slices.Clone(sub.Paths)
+			for _, stream := range sub.Streams {
+				paths = append(paths, stream.Paths...)
+			}
+			for _, p := range paths {
+				if _, err := schema.Check(yang.JoinPath(sub.Prefix, p), ""); err != nil {
+					invalid = append(invalid, fmt.Sprintf("%s %s (%s): %v", name, p, modelSet, err))
+				}
+			}
+		}
+	}
+	return invalid, unloaded
+}
+
+// pathsCondition reports the subscription paths of a pipeline that are not
+// in the ModelSets of the profiles of their targets. It only warns, as the
+// encodings condition does: the targets reject the paths they do not know.
+func (r *ClusterReconciler) pathsCondition(ctx context.Context, pipelineData *gnmic.PipelineData, generation int64, now metav1.Time) metav1.Condition {
+	cond := metav1.Condition{
+		Type:               PipelineConditionTypePathsValid,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		LastTransitionTime: now,
+		Reason:             "PathsValid",
+		Message:            "The subscription paths are in the ModelSets of their targets",
+	}
+	invalid, unloaded := r.invalidPaths(ctx, pipelineData)
+	switch {
+	case len(invalid) > 0:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "InvalidPaths"
+		cond.Message = "Paths not in the ModelSets of their targets: " + summarizeNames(invalid)
+		if len(unloaded) > 0 {
+			cond.Message += "; ModelSets not loaded: " + summarizeNames(unloaded)
+		}
+	case len(unloaded) > 0:
+		cond.Status = metav1.ConditionUnknown
+		cond.Reason = "ModelSetsNotLoaded"
+		cond.Message = "ModelSets not loaded: " + summarizeNames(unloaded)
+	}
+	return cond
+}
@@ -0,0 +1,585 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/controller/credentials"
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+// errInvalidSelector marks resolution errors caused by an invalid label
+// selector of the pipeline's own spec.
+var errInvalidSelector = errors.New("invalid label selector")
+
+// pipelineSpecError is an error caused by a pipeline's own spec. Such errors
+// are confined to the pipeline, unlike API errors which abort the cluster
+// reconcile so that it is retried as a whole.
+type pipelineSpecError struct {
+	// reason of the pipeline's Ready condition
+	reason string
+	err    error
+}
+
+func (e *pipelineSpecError) Error() string { return e.err.Error() }
+
+func (e *pipelineSpecError) Unwrap() error { return e.err }
+
+// pipelineSpecReason returns the Ready condition reason of an error caused by
+// a pipeline's own spec, or an empty string for other errors.
+func pipelineSpecReason(err error) string {
+	var specErr *pipelineSpecError
+	switch {
+	case errors.As(err, &specErr):
+		return specErr.reason
+	case errors.Is(err, errInvalidSelector):
+		return "InvalidSelector"
+	}
+	return ""
+}
+
+// validatePipelineSpec checks the parts of a pipeline's spec the API server
+// cannot: its label selectors and its schedule.
+func validatePipelineSpec(pipeline *gnmicv1alpha1.Pipeline) error {
+	spec := &pipeline.Spec
+	for _, s := range []struct {
+		field     string
+		selectors []metav1.LabelSelector
+	}{
+		{"targetSelectors", spec.TargetSelectors},
+		{"subscriptionSelectors", spec.SubscriptionSelectors},
+		{"tunnelTargetPolicySelectors", spec.TunnelTargetPolicySelectors},
+		{"outputs.outputSelectors", spec.Outputs.OutputSelectors},
+		{"outputs.processorSelectors", spec.Outputs.ProcessorSelectors},
+		{"inputs.inputSelectors", spec.Inputs.InputSelectors},
+		{"inputs.processorSelectors", spec.Inputs.ProcessorSelectors},
+	} {
+		for i := range s.selectors {
+			if _, err := metav1.LabelSelectorAsSelector(&s.selectors[i]); err != nil {
+				return fmt.Errorf("%w: %s[%d]: %v", errInvalidSelector, s.field, i, err)
+			}
+		}
+	}
+
+	schedule := spec.Schedule
+	if schedule == nil {
+		return nil
+	}
+	if _, err := time.LoadLocation(schedule.TimeZone); err != nil {
+		return &pipelineSpecError{reason: "InvalidSchedule", err: fmt.Errorf("invalid schedule time zone %q: %w", schedule.TimeZone, err)}
+	}
+	now := time.Now()
+	for i := range schedule.ActiveWindows {
+		if _, _, err := scheduleWindowAt(&schedule.ActiveWindows[i], now); err != nil {
+			return &pipelineSpecError{reason: "InvalidSchedule", err: fmt.Errorf("active window %d: %w", i, err)}
+		}
+	}
+	for i := range schedule.MaintenanceWindows {
+		window := &schedule.MaintenanceWindows[i]
+		if _, _, err := scheduleWindowAt(&window.ScheduleWindow, now); err != nil {
+			return &pipelineSpecError{reason: "InvalidSchedule", err: fmt.Errorf("maintenance window %d: %w", i, err)}
+		}
+		if window.TargetSelector == nil {
+			continue
+		}
+		if _, err := metav1.LabelSelectorAsSelector(window.TargetSelector); err != nil {
+			return fmt.Errorf("%w: schedule.maintenanceWindows[%d].targetSelector: %v", errInvalidSelector, i, err)
+		}
+	}
+	return nil
+}
+
+// maxUnresolvedRefsInMessage bounds the references listed in the
+// ResolvedRefs condition message; status.unresolvedRefs has all of them.
+const maxUnresolvedRefsInMessage = 10
+
+// pipelineRefs collects the references of a pipeline, and of the resources it
+// selects, that could not be resolved.
+type pipelineRefs struct {
+	unresolved []gnmicv1alpha1.UnresolvedReference
+}
+
+// add records an unresolved reference; the first message for a given kind
+// and name wins.
+func (p *pipelineRefs) add(kind, name, message string) {
+	for _, ref := range p.unresolved {
+		if ref.Kind == kind && ref.Name == name {
+			return
+		}
+	}
+	p.unresolved = append(p.unresolved, gnmicv1alpha1.UnresolvedReference{Kind: kind, Name: name, Message: message})
+}
+
+// addMissing records the refs that did not resolve to any of the objects.
+func addMissing[T any, PT interface {
+	*T
+	client.Object
+}](p *pipelineRefs, kind string, refs []string, resolved []T) {
+	names := make(map[string]struct{}, len(resolved))
+	for i := range resolved {
+		names[PT(&resolved[i]).GetName()] = struct{}{}
+	}
+	for _, ref := range refs {
+		if _, ok := names[ref]; !ok {
+			p.add(kind, ref, "not found")
+		}
+	}
+}
+
+// addMissingStreamSubscriptions records the stream subscriptions that are
+// not among the pipeline's subscriptions, where the plan builder looks them up.
+func (p *pipelineRefs) addMissingStreamSubscriptions(subscriptions []gnmicv1alpha1.Subscription) {
+	names := make(map[string]struct{}, len(subscriptions))
+	for _, sub := range subscriptions {
+		names[sub.Name] = struct{}{}
+	}
+	for _, sub := range subscriptions {
+		for _, name := range sub.Spec.StreamSubscriptions {
+			if _, ok := names[name]; !ok {
+				p.add(resourceKindSubscription, name,
+					fmt.Sprintf("stream subscription of Subscription %s is not selected by the pipeline", sub.Name))
+			}
+		}
+	}
+}
+
+// addUnmatchedTargets records the targets of a pipeline whose subscriptions
+// or outputs lists name none of the pipeline's resolved ones: gNMIc would use
+// all of them for an empty list, so the plan leaves such targets out.
+func (p *pipelineRefs) addUnmatchedTargets(pipelineNN string, data *gnmic.PipelineData) {
+	names := func(namespacedNames iter.Seq[string]) map[string]struct{} {
+		out := make(map[string]struct{})
+		for nn := range namespacedNames {
+			out[strings.TrimPrefix(nn, pipelineNN+gnmic.Delimiter)] = struct{}{}
+		}
+		return out
+	}
+	subscriptions := names(maps.Keys(data.Subscriptions))
+	outputs := names(maps.Keys(data.Outputs))
+	matches := func(listed []string, names map[string]struct{}) bool {
+		return slices.ContainsFunc(listed, func(name string) bool {
+			_, ok := names[name]
+			return ok
+		})
+	}
+	for _, targetNN := range slices.Sorted(maps.Keys(data.Targets)) {
+		target := data.Targets[targetNN]
+		switch {
+		case len(target.Spec.Subscriptions) > 0 && !matches(target.Spec.Subscriptions, subscriptions):
+			p.add("Target", target.Name, "spec.subscriptions names none of the pipeline's subscriptions, not collected by the pipeline")
+		case len(target.Spec.Outputs) > 0 && !matches(target.Spec.Outputs, outputs):
+			p.add("Target", target.Name, "spec.outputs names none of the pipeline's outputs, not collected by the pipeline")
+		}
+	}
+}
+
+// condition returns the ResolvedRefs condition reporting the references.
+func (p *pipelineRefs) condition(generation int64, now metav1.Time) metav1.Condition {
+	cond := metav1.Condition{
+		Type:               PipelineConditionTypeResolvedRefs,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		LastTransitionTime: now,
+		Reason:             "ResolvedRefs",
+		Message:            "All references were resolved",
+	}
+	if len(p.unresolved) == 0 {
+		return cond
+	}
+	cond.Status = metav1.ConditionFalse
+	cond.Reason = "UnresolvedRefs"
+	var b strings.Builder
+	b.WriteString("Unresolved references: ")
+	for i, ref := range p.sortedUnresolved() {
+		if i == maxUnresolvedRefsInMessage {
+			fmt.Fprintf(&b, "; and %d more", len(p.unresolved)-i)
+			break
+		}
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		fmt.Fprintf(&b, "%s %s", ref.Kind, ref.Name)
+		if ref.Message != "" {
+			fmt.Fprintf(&b, " (%s)", ref.Message)
+		}
+	}
+	cond.Message = b.String()
+	return cond
+}
+
+// sortedUnresolved returns the unresolved references sorted by kind and name,
+// for a stable status.
+func (p *pipelineRefs) sortedUnresolved() []gnmicv1alpha1.UnresolvedReference {
+	if len(p.unresolved) == 0 {
+		return nil
+	}
+	refs := slices.Clone(p.unresolved)
+	slices.SortFunc(refs, func(a, b gnmicv1alpha1.UnresolvedReference) int {
+		if c := strings.Compare(a.Kind, b.Kind); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return refs
+}
+
+// isolatePipelineError reports an error caused by a pipeline's own spec in
+// its status and tells whether the pipeline can be skipped. Other errors are
+// left to abort the cluster reconcile.
+func (r *ClusterReconciler) isolatePipelineError(ctx context.Context, pipeline *gnmicv1alpha1.Pipeline, err error) bool {
+	reason := pipelineSpecReason(err)
+	if reason == "" {
+		return false
+	}
+	logger := log.FromContext(ctx)
+	logger.Error(err, "skipping pipeline", "pipeline", pipeline.Name)
+	if err := r.updatePipelineStatusWithError(ctx, pipeline, reason, err.Error()); err != nil {
+		logger.Error(err, "failed to update pipeline status with error")
+	}
+	return true
+}
+
+// resolvedPipeline is a pipeline with the resources it references resolved.
+type resolvedPipeline struct {
+	data *gnmic.PipelineData
+	// references that could not be resolved
+	refs *pipelineRefs
+	// the number of targets left out by the pipeline's schedule
+	suspendedTargets     int32
+	subscriptions        []gnmicv1alpha1.Subscription
+	outputs              []gnmicv1alpha1.Output
+	inputs               []gnmicv1alpha1.Input
+	outputProcessors     []gnmicv1alpha1.Processor
+	inputProcessors      []gnmicv1alpha1.Processor
+	tunnelTargetPolicies []gnmicv1alpha1.TunnelTargetPolicy
+}
+
+// resolvePipeline resolves the resources an active pipeline of a cluster
+// references into its pipeline data. References that cannot be resolved are
+// recorded rather than failing the pipeline; errors caused by the pipeline's
+// own spec are pipelineSpecErrors, other errors are API errors.
+func (r *ClusterReconciler) resolvePipeline(ctx context.Context, cluster *gnmicv1alpha1.Cluster, pipeline *gnmicv1alpha1.Pipeline, schedule *pipelineSchedule, usage *resourceUsage) (*resolvedPipeline, error) {
+	logger := log.FromContext(ctx)
+	pipelineNN := pipeline.Namespace + gnmic.Delimiter + pipeline.Name
+	pipelineData := gnmic.NewPipelineData()
+
+	// references that could not be resolved, reported in the pipeline status
+	refs := &pipelineRefs{}
+
+	// retrieve targets for this pipeline
+	targets, err := r.resolveTargets(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	addMissing(refs, "Target", pipeline.Spec.TargetRefs, targets)
+	targets, suspendedTargets := filterSuspendedTargets(targets, schedule.suspendedBy)
+	// a target whose profile or credentials Secret is missing is skipped
+	// rather than failing the whole cluster
+	profilesCount, err := r.addPipelineTargets(ctx, pipeline, targets, pipelineData, refs, usage)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("cluster pipeline resolved targets", "count", len(pipelineData.Targets), "targetProfiles", profilesCount)
+
+	// retrieve subscriptions for this pipeline
+	subscriptions, err := r.resolveSubscriptions(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	addMissing(refs, resourceKindSubscription, pipeline.Spec.SubscriptionRefs, subscriptions)
+	refs.addMissingStreamSubscriptions(subscriptions)
+	for _, subscription := range subscriptions {
+		// Key by pipeline like outputs so two pipelines sharing one
+		// Subscription CR each get their own output binding. A flat
+		// namespace/name key merges both pipelines' outputs onto every
+		// target that uses the subscription.
+		pipelineData.Subscriptions[pipelineNN+gnmic.Delimiter+subscription.Name] = subscription.Spec
+	}
+	logger.Info("cluster pipeline resolved subscriptions", "count", len(subscriptions))
+
+	// retrieve outputs for this pipeline
+	outputs, err := r.resolveOutputs(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	addMissing(refs, resourceKindOutput, pipeline.Spec.Outputs.OutputRefs, outputs)
+	for _, output := range outputs {
+		outputNN := pipelineNN + gnmic.Delimiter + output.Name
+		// an output whose Secret is missing is skipped rather than
+		// configured without its credentials
+		secrets, ok, err := r.resolveConfigSecret(resourceKindOutput, output.Namespace, output.Name, output.Spec.SecretRef, refs, usage)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		pipelineData.Outputs[outputNN] = output.Spec
+		if secrets != nil {
+			pipelineData.ResolvedOutputSecrets[outputNN] = secrets
+		}
+
+		// resolve service addresses for outputs that support it (nats, kafka, jetstream)
+		if gnmic.OutputTypesWithServiceRef[output.Spec.Type] {
+			resolvedAddrs, err := resolveOutputServiceAddresses(ctx, r.Client, &output)
+			if err != nil {
+				logger.Error(err, "failed to resolve service addresses for output", "output", output.Name)
+				usage.invalidate(resourceKindOutput, output.Name, err.Error())
+				// continue without resolved addresses - the output config may have static address
+			} else if len(resolvedAddrs) > 0 {
+				pipelineData.ResolvedOutputAddresses[outputNN] = resolvedAddrs
+			}
+		}
+	}
+	logger.Info("cluster pipeline resolved outputs", "count", len(outputs))
+	// targets whose subscriptions or outputs name none of the pipeline's
+	// are not collected by it
+	refs.addUnmatchedTargets(pipelineNN, pipelineData)
+
+	// retrieve inputs for this pipeline
+	inputs, err := r.resolveInputs(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	addMissing(refs, resourceKindInput, pipeline.Spec.Inputs.InputRefs, inputs)
+	for _, input := range inputs {
+		inputNN := pipelineNN + gnmic.Delimiter + input.Name
+		secrets, ok, err := r.resolveConfigSecret(resourceKindInput, input.Namespace, input.Name, input.Spec.SecretRef, refs, usage)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		pipelineData.Inputs[inputNN] = input.Spec
+		if secrets != nil {
+			pipelineData.ResolvedInputSecrets[inputNN] = secrets
+		}
+	}
+	logger.Info("cluster pipeline resolved inputs", "count", len(inputs))
+
+	// retrieve output processors for this pipeline (order: refs first, then sorted selectors)
+	outputProcessors, err := r.resolveOutputProcessors(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	addMissing(refs, resourceKindProcessor, pipeline.Spec.Outputs.ProcessorRefs, outputProcessors)
+	for _, processor := range outputProcessors {
+		processorNN := pipelineNN + gnmic.Delimiter + processor.Name
+		pipelineData.OutputProcessors[processorNN] = processor.Spec
+		pipelineData.OutputProcessorOrder = append(pipelineData.OutputProcessorOrder, processorNN)
+	}
+	logger.Info("cluster pipeline resolved output processors", "count", len(outputProcessors))
+
+	// retrieve input processors for this pipeline (order: refs first, then sorted selectors)
+	inputProcessors, err := r.resolveInputProcessors(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	addMissing(refs, resourceKindProcessor, pipeline.Spec.Inputs.ProcessorRefs, inputProcessors)
+	for _, processor := range inputProcessors {
+		processorNN := pipelineNN + gnmic.Delimiter + processor.Name
+		pipelineData.InputProcessors[processorNN] = processor.Spec
+		pipelineData.InputProcessorOrder = append(pipelineData.InputProcessorOrder, processorNN)
+	}
+	logger.Info("cluster pipeline resolved input processors", "count", len(inputProcessors))
+
+	// retrieve tunnel target policies for this pipeline
+	tunnelTargetPolicies, err := r.resolveTunnelTargetPolicies(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	addMissing(refs, resourceKindTunnelTargetPolicy, pipeline.Spec.TunnelTargetPolicyRefs, tunnelTargetPolicies)
+	// validate: if pipeline has tunnel target policies, cluster must have GRPCTunnel configured
+	if len(tunnelTargetPolicies) > 0 && cluster.Spec.GRPCTunnel == nil {
+		return nil, &pipelineSpecError{
+			reason: "ClusterMissingTunnel",
+			err:    fmt.Errorf("Cluster %s does not have gRPC tunnel configured, but pipeline references tunnel target policies", cluster.Name),
+		}
+	}
+	tunnelProfileNames := make(map[string]struct{})
+	for _, policy := range tunnelTargetPolicies {
+		pipelineData.TunnelTargetPolicies[policy.Namespace+gnmic.Delimiter+policy.Name] = policy.Spec
+		if policy.Spec.Profile != "" {
+			tunnelProfileNames[policy.Spec.Profile] = struct{}{}
+		}
+	}
+	// retrieve target profiles for tunnel target policies (they share TargetProfiles)
+	for profileName := range tunnelProfileNames {
+		if _, exists := pipelineData.TargetProfiles[pipeline.Namespace+gnmic.Delimiter+profileName]; exists {
+			continue // already fetched for targets
+		}
+		var targetProfile gnmicv1alpha1.TargetProfile
+		if err := r.Get(ctx, types.NamespacedName{Name: profileName, Namespace: pipeline.Namespace}, &targetProfile); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, err
+			}
+			logger.Info("target profile not found for tunnel target policy, skipping", "profile", profileName)
+			for _, policy := range tunnelTargetPolicies {
+				if policy.Spec.Profile == profileName {
+					usage.invalidate(resourceKindTunnelTargetPolicy, policy.Name, fmt.Sprintf("TargetProfile %q not found", profileName))
+					refs.add(resourceKindTargetProfile, profileName, fmt.Sprintf("profile of TunnelTargetPolicy %s not found", policy.Name))
+				}
+			}
+			continue
+		}
+		projections, unresolved, err := r.resolveTargetTLS(ctx, &targetProfile)
+		if err != nil {
+			return nil, err
+		}
+		if unresolved != nil {
+			logger.Info("target profile TLS not resolved for tunnel target policy, skipping", "profile", profileName, "kind", unresolved.Kind, "name", unresolved.Name)
+			usage.invalidate(resourceKindTargetProfile, profileName, fmt.Sprintf("%s %q: %s", unresolved.Kind, unresolved.Name, unresolved.Message))
+			for _, policy := range tunnelTargetPolicies {
+				if policy.Spec.Profile == profileName {
+					usage.invalidate(resourceKindTunnelTargetPolicy, policy.Name, fmt.Sprintf("TLS of TargetProfile %q not resolved", profileName))
+				}
+			}
+			refs.add(unresolved.Kind, unresolved.Name, unresolved.Message)
+			continue
+		}
+		profileNN := targetProfile.Namespace + gnmic.Delimiter + targetProfile.Name
+		pipelineData.TargetProfiles[profileNN] = targetProfile.Spec
+		if len(projections) > 0 {
+			pipelineData.ResolvedTargetTLS[profileNN] = projections
+		}
+	}
+	logger.Info("cluster pipeline tunnel target policies", "policies", len(tunnelTargetPolicies))
+
+	return &resolvedPipeline{
+		data:                 pipelineData,
+		refs:                 refs,
+		suspendedTargets:     suspendedTargets,
+		subscriptions:        subscriptions,
+		outputs:              outputs,
+		inputs:               inputs,
+		outputProcessors:     outputProcessors,
+		inputProcessors:      inputProcessors,
+		tunnelTargetPolicies: tunnelTargetPolicies,
+	}, nil
+}
+
+// addPipelineTargets adds the targets and their profiles to the pipeline
+// data. Targets whose TargetProfile, credentials Secret or TLS objects are
+// missing, or whose own credentials Secret is, are skipped and the missing
+// reference recorded. It returns the number of distinct profiles the targets
+// use.
+func (r *ClusterReconciler) addPipelineTargets(ctx context.Context, pipeline *gnmicv1alpha1.Pipeline, targets []gnmicv1alpha1.Target, pipelineData *gnmic.PipelineData, refs *pipelineRefs, usage *resourceUsage) (int, error) {
+	profileNames := make(map[string]struct{})
+	for _, target := range targets {
+		profileNames[target.Spec.Profile] = struct{}{}
+	}
+
+	unusable := make(map[string]gnmicv1alpha1.UnresolvedReference)
+	for profileName := range profileNames {
+		var profile gnmicv1alpha1.TargetProfile
+		if err := r.Get(ctx, types.NamespacedName{Name: profileName, Namespace: pipeline.Namespace}, &profile); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return 0, err
+			}
+			unusable[profileName] = gnmicv1alpha1.UnresolvedReference{
+				Kind: resourceKindTargetProfile, Name: profileName, Message: "not found",
+			}
+			continue
+		}
+		if src := credentials.SecretSource(&profile.Spec); src != nil {
+			var secret corev1.Secret
+			if err := r.Get(ctx, types.NamespacedName{Name: src.Name, Namespace: profile.Namespace}, &secret); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return 0, err
+				}
+				usage.invalidate(resourceKindTargetProfile, profile.Name, fmt.Sprintf("credentials Secret %q not found", src.Name))
+				unusable[profileName] = gnmicv1alpha1.UnresolvedReference{
+					Kind: "Secret", Name: src.Name, Message: fmt.Sprintf("credentials of TargetProfile %s not found", profileName),
+				}
+				continue
+			}
+		} else if profile.Spec.Credentials != nil {
+			// Vault and files are read through the credentials store, which
+			// keeps serving the last credentials read when its backend fails:
+			// only credentials never read make the profile unusable.
+			if _, err := r.FetchCredentials(profile.Namespace, profile.Name, &profile.Spec); err != nil {
+				kind, name := credentials.SourceName(&profile.Spec)
+				usage.invalidate(resourceKindTargetProfile, profile.Name, fmt.Sprintf("credentials from %s %q: %v", kind, name, err))
+				unusable[profileName] = gnmicv1alpha1.UnresolvedReference{
+					Kind: kind, Name: name, Message: fmt.Sprintf("credentials of TargetProfile %s: %v", profileName, err),
+				}
+				continue
+			}
+		}
+		projections, unresolved, err := r.resolveTargetTLS(ctx, &profile)
+		if err != nil {
+			return 0, err
+		}
+		if unresolved != nil {
+			usage.invalidate(resourceKindTargetProfile, profile.Name, fmt.Sprintf("%s %q: %s", unresolved.Kind, unresolved.Name, unresolved.Message))
+			unusable[profileName] = *unresolved
+			continue
+		}
+		profileNN := profile.Namespace + gnmic.Delimiter + profile.Name
+		pipelineData.TargetProfiles[profileNN] = profile.Spec
+		if len(projections) > 0 {
+			pipelineData.ResolvedTargetTLS[profileNN] = projections
+		}
+	}
+
+	skipped := make(map[string]int)
+	for _, target := range targets {
+		if _, ok := unusable[target.Spec.Profile]; ok {
+			skipped[target.Spec.Profile]++
+			continue
+		}
+		if ref := target.Spec.CredentialsRef; ref != "" {
+			var secret corev1.Secret
+			if err := r.Get(ctx, types.NamespacedName{Name: ref, Namespace: target.Namespace}, &secret); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return 0, err
+				}
+				refs.add("Secret", ref, fmt.Sprintf("credentials of Target %s not found, target skipped", target.Name))
+				continue
+			}
+		}
+		pipelineData.Targets[target.Namespace+gnmic.Delimiter+target.Name] = target
+	}
+	for profileName, ref := range unusable {
+		refs.add(ref.Kind, ref.Name, fmt.Sprintf("%s, %d targets skipped", ref.Message, skipped[profileName]))
+	}
+	return len(profileNames), nil
+}
+
+// resolveConfigSecret fetches the Secret values of an Output or Input. When the
+// Secret or one of its keys is missing, the resource is marked invalid, the
+// reference recorded and false returned: the resource is skipped.
+func (r *ClusterReconciler) resolveConfigSecret(kind, namespace, name string, ref *gnmicv1alpha1.ConfigSecretRef, refs *pipelineRefs, usage *resourceUsage) (map[string]string, bool, error) {
+	if ref == nil {
+		return nil, true, nil
+	}
+	values, err := r.FetchSecretValues(namespace, ref)
+	switch {
+	case err == nil:
+		return values, true, nil
+	case apierrors.IsNotFound(err):
+		usage.invalidate(kind, name, fmt.Sprintf("Secret %q not found", ref.Name))
+		refs.add("Secret", ref.Name, fmt.Sprintf("Secret of %s %s not found", kind, name))
+		return nil, false, nil
+	case errors.Is(err, errSecretKeyNotFound):
+		usage.invalidate(kind, name, fmt.Sprintf("Secret %q: %v", ref.Name, err))
+		refs.add("Secret", ref.Name, fmt.Sprintf("%v, required by %s %s", err, kind, name))
+		return nil, false, nil
+	}
+	return nil, false, err
+}
@@ -0,0 +1,243 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+func refsTarget(name, profile string) gnmicv1alpha1.Target {
+	return gnmicv1alpha1.Target{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       gnmicv1alpha1.TargetSpec{Profile: profile},
+	}
+}
+
+func TestPipelineRefs_Missing(t *testing.T) {
+	refs := &pipelineRefs{}
+	addMissing(refs, "Target", []string{"t1", "t2", "t2"}, []gnmicv1alpha1.Target{refsTarget("t1", "p")})
+	refs.addMissingStreamSubscriptions([]gnmicv1alpha1.Subscription{{
+		ObjectMeta: metav1.ObjectMeta{Name: "parent"},
+		Spec:       gnmicv1alpha1.SubscriptionSpec{StreamSubscriptions: []string{"parent", "child"}},
+	}})
+
+	got := refs.sortedUnresolved()
+	if len(got) != 2 || got[0].Kind != resourceKindSubscription || got[0].Name != "child" ||
+		got[1].Kind != "Target" || got[1].Name != "t2" {
+		t.Fatalf("unresolved = %+v, want Subscription child and Target t2", got)
+	}
+	cond := refs.condition(4, metav1.Now())
+	if cond.Status != metav1.ConditionFalse || cond.Reason != "UnresolvedRefs" || cond.ObservedGeneration != 4 {
+		t.Fatalf("condition = %+v", cond)
+	}
+	if !strings.Contains(cond.Message, "Target t2 (not found)") {
+		t.Fatalf("message %q does not name the missing target", cond.Message)
+	}
+
+	if cond := (&pipelineRefs{}).condition(1, metav1.Now()); cond.Status != metav1.ConditionTrue {
+		t.Fatalf("no unresolved refs: condition = %+v", cond)
+	}
+}
+
+func TestPipelineRefs_MessageIsBounded(t *testing.T) {
+	refs := &pipelineRefs{}
+	for i := 0; i < maxUnresolvedRefsInMessage+5; i++ {
+		refs.add("Target", fmt.Sprintf("t%02d", i), "not found")
+	}
+	msg := refs.condition(1, metav1.Now()).Message
+	if !strings.HasSuffix(msg, "; and 5 more") || strings.Contains(msg, "t10") {
+		t.Fatalf("message = %q", msg)
+	}
+}
+
+func TestPipelineRefs_UnmatchedTargets(t *testing.T) {
+	data := gnmic.NewPipelineData()
+	for name, spec := range map[string]gnmicv1alpha1.TargetSpec{
+		"all":         {},
+		"listed":      {Subscriptions: []string{"interfaces", "bgp"}, Outputs: []string{"kafka"}},
+		"no-sub":      {Subscriptions: []string{"bgp"}},
+		"no-output":   {Outputs: []string{"prometheus"}},
+		"sub-and-out": {Subscriptions: []string{"bgp"}, Outputs: []string{"prometheus"}},
+	} {
+		data.Targets["default/"+name] = gnmicv1alpha1.Target{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}, Spec: spec}
+	}
+	data.Subscriptions["default/p1/interfaces"] = gnmicv1alpha1.SubscriptionSpec{}
+	data.Outputs["default/p1/kafka"] = gnmicv1alpha1.OutputSpec{}
+
+	refs := &pipelineRefs{}
+	refs.addUnmatchedTargets("default/p1", data)
+	got := refs.sortedUnresolved()
+	if len(got) != 3 || got[0].Name != "no-output" || !strings.Contains(got[0].Message, "spec.outputs") ||
+		got[1].Name != "no-sub" || !strings.Contains(got[1].Message, "spec.subscriptions") ||
+		got[2].Name != "sub-and-out" || !strings.Contains(got[2].Message, "spec.subscriptions") {
+		t.Fatalf("unresolved = %+v, want no-output, no-sub and sub-and-out", got)
+	}
+}
+
+func TestAddPipelineTargets_SkipsTargetsWithUnusableProfiles(t *testing.T) {
+	r := reconcilerWith(t,
+		profile("ok", "creds"), secret("creds"),
+		profile("nocreds", "missing"),
+	)
+	pipeline := &gnmicv1alpha1.Pipeline{ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "default"}}
+	targets := []gnmicv1alpha1.Target{
+		refsTarget("t1", "ok"),
+		refsTarget("t2", "nocreds"),
+		refsTarget("t3", "gone"),
+		refsTarget("t4", "gone"),
+	}
+	data := gnmic.NewPipelineData()
+	refs := &pipelineRefs{}
+	usage := newResourceUsage()
+
+	n, err := r.addPipelineTargets(context.Background(), pipeline, targets, data, refs, usage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 || len(data.Targets) != 1 || len(data.TargetProfiles) != 1 {
+		t.Fatalf("profiles %d, targets %v, data profiles %d; want 3, [t1], 1", n, data.Targets, len(data.TargetProfiles))
+	}
+	if _, ok := data.Targets["default/t1"]; !ok {
+		t.Fatalf("targets = %v, want default/t1", data.Targets)
+	}
+
+	got := refs.sortedUnresolved()
+	if len(got) != 2 ||
+		got[0].Kind != "Secret" || got[0].Name != "missing" || !strings.Contains(got[0].Message, "1 targets skipped") ||
+		got[1].Kind != resourceKindTargetProfile || got[1].Name != "gone" || !strings.Contains(got[1].Message, "2 targets skipped") {
+		t.Fatalf("unresolved = %+v", got)
+	}
+	if reason := usage.invalid[resourceKey{kind: resourceKindTargetProfile, name: "nocreds"}]; reason == "" {
+		t.Fatal("profile with a missing Secret not reported invalid")
+	}
+}
+
+func TestIsolatePipelineError(t *testing.T) {
+	pipeline := &gnmicv1alpha1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "default"},
+		Spec: gnmicv1alpha1.PipelineSpec{
+			TargetSelectors: []metav1.LabelSelector{{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "site", Operator: "Bogus"},
+			}}},
+		},
+	}
+	r := statusReconcilerWith(t, pipeline)
+	ctx := context.Background()
+
+	_, err := r.resolveTargets(ctx, pipeline)
+	if err == nil || !r.isolatePipelineError(ctx, pipeline, err) {
+		t.Fatalf("resolveTargets error %v not confined to the pipeline", err)
+	}
+	var got gnmicv1alpha1.Pipeline
+	if err := r.Get(ctx, types.NamespacedName{Name: "p1", Namespace: "default"}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Status.Conditions) != 1 || got.Status.Conditions[0].Reason != "InvalidSelector" {
+		t.Fatalf("pipeline status = %+v, want InvalidSelector", got.Status)
+	}
+
+	if r.isolatePipelineError(ctx, pipeline, fmt.Errorf("connection refused")) {
+		t.Fatal("an API error must abort the cluster reconcile")
+	}
+}
+
+func TestValidatePipelineSpec(t *testing.T) {
+	bogus := metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "site", Operator: "Bogus"}}}
+	for _, tc := range []struct {
+		name   string
+		spec   gnmicv1alpha1.PipelineSpec
+		reason string
+	}{
+		{"valid", gnmicv1alpha1.PipelineSpec{
+			TargetSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"site": "a"}}},
+		}, ""},
+		{"output processor selector", gnmicv1alpha1.PipelineSpec{
+			Outputs: gnmicv1alpha1.OutputSelector{ProcessorSelectors: []metav1.LabelSelector{bogus}},
+		}, "InvalidSelector"},
+		{"time zone", gnmicv1alpha1.PipelineSpec{
+			Schedule: &gnmicv1alpha1.PipelineSchedule{TimeZone: "Mars/Olympus_Mons"},
+		}, "InvalidSchedule"},
+		// checked even while the window is closed
+		{"maintenance window selector", gnmicv1alpha1.PipelineSpec{
+			Schedule: &gnmicv1alpha1.PipelineSchedule{MaintenanceWindows: []gnmicv1alpha1.MaintenanceWindow{{
+				ScheduleWindow: gnmicv1alpha1.ScheduleWindow{
+					Start:    &metav1.Time{Time: time.Now().Add(time.Hour)},
+					Duration: metav1.Duration{Duration: time.Hour},
+				},
+				TargetSelector: &bogus,
+			}}},
+		}, "InvalidSelector"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validatePipelineSpec(&gnmicv1alpha1.Pipeline{Spec: tc.spec})
+			if reason := pipelineSpecReason(err); reason != tc.reason || (err == nil) != (tc.reason == "") {
+				t.Fatalf("err = %v (reason %q), want reason %q", err, reason, tc.reason)
+			}
+		})
+	}
+}
+
+// The Pipeline controller reports the references of a pipeline as they
+// change, without waiting for a reconcile of its cluster.
+func TestPipelineReconciler_ResolvedRefs(t *testing.T) {
+	cluster := &gnmicv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "default"}}
+	pipeline := &gnmicv1alpha1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "default", Generation: 3},
+		Spec: gnmicv1alpha1.PipelineSpec{
+			ClusterRef: "c1",
+			Enabled:    true,
+			TargetRefs: []string{"t1"},
+		},
+	}
+	other := &gnmicv1alpha1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "p2", Namespace: "default"},
+		Spec:       gnmicv1alpha1.PipelineSpec{ClusterRef: "c2", Enabled: true},
+	}
+	clusters := statusReconcilerWith(t, cluster, pipeline, other,
+		&gnmicv1alpha1.TargetProfile{ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: "default"}})
+	r := &PipelineReconciler{Client: clusters.Client, Scheme: clusters.Scheme, Clusters: clusters}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "p1", Namespace: "default"}}
+
+	resolvedRefs := func() (*metav1.Condition, []gnmicv1alpha1.UnresolvedReference) {
+		t.Helper()
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+		var got gnmicv1alpha1.Pipeline
+		if err := r.Get(ctx, req.NamespacedName, &got); err != nil {
+			t.Fatal(err)
+		}
+		return meta.FindStatusCondition(got.Status.Conditions, PipelineConditionTypeResolvedRefs), got.Status.UnresolvedRefs
+	}
+
+	cond, unresolved := resolvedRefs()
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.ObservedGeneration != 3 ||
+		len(unresolved) != 1 || unresolved[0].Kind != "Target" || unresolved[0].Name != "t1" {
+		t.Fatalf("condition %+v, unresolved %+v, want Target t1 unresolved", cond, unresolved)
+	}
+
+	// creating the target maps to the pipelines of the clusters it is used by
+	target := refsTarget("t1", "p")
+	if err := r.Create(ctx, &target); err != nil {
+		t.Fatal(err)
+	}
+	reqs := r.pipelinesFor(clusters.findClustersForTarget)(ctx, &target)
+	if len(reqs) != 1 || reqs[0] != req {
+		t.Fatalf("target mapped to %v, want %v", reqs, req)
+	}
+	cond, unresolved = resolvedRefs()
+	if cond == nil || cond.Status != metav1.ConditionTrue || len(unresolved) != 0 {
+		t.Fatalf("condition %+v, unresolved %+v, want all references resolved", cond, unresolved)
+	}
+}
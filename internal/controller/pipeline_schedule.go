@@ -0,0 +1,211 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/utils"
+)
+
+// Pipeline effective states, reported in status.effectiveState.
+const (
+	PipelineStateActive    = "Active"
+	PipelineStateSuspended = "Suspended"
+	PipelineStateExpired   = "Expired"
+	PipelineStateDisabled  = "Disabled"
+)
+
+// maxCronWindowSteps bounds the walk over consecutive firings of a cron window,
+// e.g. a window opening every minute and lasting a month.
+const maxCronWindowSteps = 100000
+
+// pipelineSchedule is the state of an enabled pipeline's schedule and TTL at a
+// point in time.
+type pipelineSchedule struct {
+	state string
+	// why the pipeline is not active
+	reason string
+	// next is the next schedule boundary, zero if there is none.
+	next time.Time
+	// suspendedBy are the target selectors of the maintenance windows
+	// currently open for part of the pipeline's targets.
+	suspendedBy []labels.Selector
+}
+
+// evaluatePipelineSchedule works out the effective state of an enabled
+// pipeline at now. A TTL counts from status.enabledAt; until the Pipeline
+// controller has recorded it, the pipeline is treated as enabled just now.
+func evaluatePipelineSchedule(pipeline *gnmicv1alpha1.Pipeline, now time.Time) (*pipelineSchedule, error) {
+	ps := &pipelineSchedule{state: PipelineStateActive}
+
+	if ttl := pipeline.Spec.TTLAfterEnable; ttl != nil {
+		enabledAt := now
+		if pipeline.Status.EnabledAt != nil {
+			enabledAt = pipeline.Status.EnabledAt.Time
+		}
+		expiry := enabledAt.Add(ttl.Duration)
+		if !now.Before(expiry) {
+			ps.state = PipelineStateExpired
+			ps.reason = fmt.Sprintf("ttlAfterEnable %s elapsed at %s", ttl.Duration, expiry.UTC().Format(time.RFC3339))
+			return ps, nil
+		}
+		ps.earliest(expiry)
+	}
+
+	schedule := pipeline.Spec.Schedule
+	if schedule == nil {
+		return ps, nil
+	}
+	loc := time.UTC
+	if schedule.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(schedule.TimeZone); err != nil {
+			return nil, fmt.Errorf("invalid schedule time zone %q: %w", schedule.TimeZone, err)
+		}
+	}
+	now = now.In(loc)
+
+	inActiveWindow := false
+	for i := range schedule.ActiveWindows {
+		open, boundary, err := scheduleWindowAt(&schedule.ActiveWindows[i], now)
+		if err != nil {
+			return nil, fmt.Errorf("active window %d: %w", i, err)
+		}
+		inActiveWindow = inActiveWindow || open
+		ps.earliest(boundary)
+	}
+	if len(schedule.ActiveWindows) > 0 && !inActiveWindow {
+		ps.state = PipelineStateSuspended
+		ps.reason = "outside of the active windows"
+	}
+
+	for i := range schedule.MaintenanceWindows {
+		window := &schedule.MaintenanceWindows[i]
+		open, boundary, err := scheduleWindowAt(&window.ScheduleWindow, now)
+		if err != nil {
+			return nil, fmt.Errorf("maintenance window %d: %w", i, err)
+		}
+		ps.earliest(boundary)
+		if !open {
+			continue
+		}
+		if window.TargetSelector == nil {
+			if ps.state == PipelineStateActive {
+				ps.state = PipelineStateSuspended
+				ps.reason = fmt.Sprintf("in maintenance window %d", i)
+			}
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(window.TargetSelector)
+		if err != nil {
+			return nil, fmt.Errorf("maintenance window %d: invalid target selector: %w", i, err)
+		}
+		ps.suspendedBy = append(ps.suspendedBy, selector)
+	}
+	if ps.state != PipelineStateActive {
+		ps.suspendedBy = nil
+	}
+	return ps, nil
+}
+
+func (ps *pipelineSchedule) earliest(t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	if ps.next.IsZero() || t.Before(ps.next) {
+		ps.next = t
+	}
+}
+
+// scheduleWindowAt reports whether a window is open at now, and its next
+// boundary: when it closes if open, when it next opens otherwise. The
+// boundary is zero if the window never opens again.
+func scheduleWindowAt(w *gnmicv1alpha1.ScheduleWindow, now time.Time) (bool, time.Time, error) {
+	d := w.Duration.Duration
+	if d <= 0 {
+		return false, time.Time{}, fmt.Errorf("duration must be positive")
+	}
+	if w.Cron == "" {
+		if w.Start == nil {
+			return false, time.Time{}, fmt.Errorf("one of cron or start is required")
+		}
+		start := w.Start.Time
+		switch end := start.Add(d); {
+		case now.Before(start):
+			return false, start, nil
+		case now.Before(end):
+			return true, end, nil
+		default:
+			return false, time.Time{}, nil
+		}
+	}
+
+	cron, err := utils.ParseCron(w.Cron)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	// the first firing whose window could still be open
+	fired := cron.Next(now.Add(-d))
+	if fired.IsZero() || fired.After(now) {
+		return false, fired, nil
+	}
+	// the window closes once the last firing so far plus the duration has
+	// passed and no new firing reopened it in the meantime
+	end := fired.Add(d)
+	for i := 0; i < maxCronWindowSteps; i++ {
+		next := cron.Next(fired)
+		if next.IsZero() || !next.Before(end) {
+			break
+		}
+		fired = next
+		end = fired.Add(d)
+	}
+	return true, end, nil
+}
+
+// filterSuspendedTargets drops the targets that are suspended, or selected by
+// one of the maintenance windows currently open. It returns the kept targets
+// and the number of dropped ones.
+func filterSuspendedTargets(targets []gnmicv1alpha1.Target, suspendedBy []labels.Selector) ([]gnmicv1alpha1.Target, int32) {
+	kept := targets[:0:0]
+	var suspended int32
+	for _, target := range targets {
+		if target.Spec.Suspend || matchesAnySelector(target.Labels, suspendedBy) {
+			suspended++
+			continue
+		}
+		kept = append(kept, target)
+	}
+	return kept, suspended
+}
+
+func matchesAnySelector(lbls map[string]string, selectors []labels.Selector) bool {
+	for _, selector := range selectors {
+		if selector.Matches(labels.Set(lbls)) {
+			return true
+		}
+	}
+	return false
+}
+
+// requeueAtTransition shortens a reconcile result so that it runs again just
+// after the next schedule boundary.
+func requeueAtTransition(result ctrl.Result, next, now time.Time) ctrl.Result {
+	if next.IsZero() {
+		return result
+	}
+	// land just after the boundary so it is evaluated as passed
+	after := next.Sub(now) + time.Second
+	if after < time.Second {
+		after = time.Second
+	}
+	if result.RequeueAfter == 0 || after < result.RequeueAfter {
+		result.RequeueAfter = after
+	}
+	return result
+}
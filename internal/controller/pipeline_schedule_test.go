@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+)
+
+// Wednesday 2025-01-15 10:30 UTC
+var scheduleNow = time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+
+func scheduledPipeline(schedule *gnmicv1alpha1.PipelineSchedule) *gnmicv1alpha1.Pipeline {
+	return &gnmicv1alpha1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "default"},
+		Spec:       gnmicv1alpha1.PipelineSpec{Enabled: true, Schedule: schedule},
+	}
+}
+
+func cronWindow(cron string, d time.Duration) gnmicv1alpha1.ScheduleWindow {
+	return gnmicv1alpha1.ScheduleWindow{Cron: cron, Duration: metav1.Duration{Duration: d}}
+}
+
+func TestEvaluatePipelineSchedule_TTL(t *testing.T) {
+	p := scheduledPipeline(nil)
+	p.Spec.TTLAfterEnable = &metav1.Duration{Duration: time.Hour}
+
+	// not yet recorded by the Pipeline controller: counts from now
+	ps, err := evaluatePipelineSchedule(p, scheduleNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ps.state != PipelineStateActive || !ps.next.Equal(scheduleNow.Add(time.Hour)) {
+		t.Fatalf("got %s next %v, want Active until %v", ps.state, ps.next, scheduleNow.Add(time.Hour))
+	}
+
+	enabledAt := metav1.NewTime(scheduleNow.Add(-time.Hour))
+	p.Status.EnabledAt = &enabledAt
+	if ps, _ = evaluatePipelineSchedule(p, scheduleNow); ps.state != PipelineStateExpired || !ps.next.IsZero() {
+		t.Fatalf("got %s next %v, want Expired with no next transition", ps.state, ps.next)
+	}
+}
+
+func TestEvaluatePipelineSchedule_ActiveWindows(t *testing.T) {
+	p := scheduledPipeline(&gnmicv1alpha1.PipelineSchedule{
+		ActiveWindows: []gnmicv1alpha1.ScheduleWindow{cronWindow("0 8 * * 1-5", 2*time.Hour)},
+	})
+	ps, err := evaluatePipelineSchedule(p, scheduleNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2025, 1, 16, 8, 0, 0, 0, time.UTC); ps.state != PipelineStateSuspended || !ps.next.Equal(want) {
+		t.Fatalf("got %s next %v, want Suspended until %v", ps.state, ps.next, want)
+	}
+
+	// the same window in a time zone where it is 9:30 now
+	p.Spec.Schedule.TimeZone = "Etc/GMT+1"
+	if ps, _ = evaluatePipelineSchedule(p, scheduleNow); ps.state != PipelineStateActive {
+		t.Fatalf("got %s, want Active", ps.state)
+	}
+	if want := time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC); !ps.next.Equal(want) {
+		t.Fatalf("next = %v, want %v", ps.next, want)
+	}
+}
+
+// Windows opening more often than they last chain into one: the pipeline only
+// becomes active again when the last one closes.
+func TestEvaluatePipelineSchedule_OverlappingCronWindows(t *testing.T) {
+	p := scheduledPipeline(&gnmicv1alpha1.PipelineSchedule{
+		MaintenanceWindows: []gnmicv1alpha1.MaintenanceWindow{
+			{ScheduleWindow: cronWindow("0 9-11 * * *", 90*time.Minute)},
+		},
+	})
+	ps, err := evaluatePipelineSchedule(p, scheduleNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2025, 1, 15, 12, 30, 0, 0, time.UTC); ps.state != PipelineStateSuspended || !ps.next.Equal(want) {
+		t.Fatalf("got %s next %v, want Suspended until %v", ps.state, ps.next, want)
+	}
+}
+
+func TestEvaluatePipelineSchedule_TargetMaintenanceWindow(t *testing.T) {
+	start := metav1.NewTime(scheduleNow.Add(-time.Minute))
+	p := scheduledPipeline(&gnmicv1alpha1.PipelineSchedule{
+		MaintenanceWindows: []gnmicv1alpha1.MaintenanceWindow{{
+			ScheduleWindow: gnmicv1alpha1.ScheduleWindow{Start: &start, Duration: metav1.Duration{Duration: time.Hour}},
+			TargetSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"site": "par1"}},
+		}},
+	})
+	ps, err := evaluatePipelineSchedule(p, scheduleNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ps.state != PipelineStateActive || len(ps.suspendedBy) != 1 {
+		t.Fatalf("got %s with %d selectors, want Active with 1", ps.state, len(ps.suspendedBy))
+	}
+	if want := start.Add(time.Hour); !ps.next.Equal(want) {
+		t.Fatalf("next = %v, want %v", ps.next, want)
+	}
+
+	targets := []gnmicv1alpha1.Target{
+		{ObjectMeta: metav1.ObjectMeta{Name: "t1", Labels: map[string]string{"site": "par1"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "t2", Labels: map[string]string{"site": "lon1"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "t3"}, Spec: gnmicv1alpha1.TargetSpec{Suspend: true}},
+	}
+	kept, suspended := filterSuspendedTargets(targets, ps.suspendedBy)
+	if suspended != 2 || len(kept) != 1 || kept[0].Name != "t2" {
+		t.Fatalf("kept %v, suspended %d; want [t2], 2", kept, suspended)
+	}
+	if len(targets) != 3 || targets[0].Name != "t1" {
+		t.Fatal("filtering modified the input slice")
+	}
+
+	// once the window is over
+	if ps, _ = evaluatePipelineSchedule(p, scheduleNow.Add(2*time.Hour)); len(ps.suspendedBy) != 0 || !ps.next.IsZero() {
+		t.Fatalf("after the window: %d selectors, next %v", len(ps.suspendedBy), ps.next)
+	}
+}
+
+func TestEvaluatePipelineSchedule_InvalidTimeZone(t *testing.T) {
+	p := scheduledPipeline(&gnmicv1alpha1.PipelineSchedule{TimeZone: "Mars/Olympus"})
+	if _, err := evaluatePipelineSchedule(p, scheduleNow); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestRequeueAtTransition(t *testing.T) {
+	if got := requeueAtTransition(ctrl.Result{}, time.Time{}, scheduleNow); got.RequeueAfter != 0 {
+		t.Fatalf("no transition: RequeueAfter = %v", got.RequeueAfter)
+	}
+	if got := requeueAtTransition(ctrl.Result{}, scheduleNow.Add(time.Minute), scheduleNow); got.RequeueAfter != time.Minute+time.Second {
+		t.Fatalf("RequeueAfter = %v, want just after the boundary", got.RequeueAfter)
+	}
+	if got := requeueAtTransition(ctrl.Result{RequeueAfter: 10 * time.Second}, scheduleNow.Add(time.Minute), scheduleNow); got.RequeueAfter != 10*time.Second {
+		t.Fatalf("RequeueAfter = %v, an earlier requeue must be kept", got.RequeueAfter)
+	}
+}
+
+func TestSyncPipelineEnabledAt(t *testing.T) {
+	now := metav1.NewTime(scheduleNow)
+	p := scheduledPipeline(nil)
+	if !syncPipelineEnabledAt(p, now) || !p.Status.EnabledAt.Equal(&now) {
+		t.Fatal("enabledAt not recorded")
+	}
+	later := metav1.NewTime(scheduleNow.Add(time.Hour))
+	if syncPipelineEnabledAt(p, later) || !p.Status.EnabledAt.Equal(&now) {
+		t.Fatal("enabledAt moved while the pipeline stayed enabled")
+	}
+
+	p.Spec.Enabled = false
+	p.Status.EffectiveState = PipelineStateExpired
+	if !syncPipelineEnabledAt(p, later) || p.Status.EnabledAt != nil || p.Status.EffectiveState != PipelineStateDisabled {
+		t.Fatalf("disabled pipeline status = %+v", p.Status)
+	}
+	if syncPipelineEnabledAt(p, later) {
+		t.Fatal("second pass on a disabled pipeline reported a change")
+	}
+}
@@ -0,0 +1,354 @@
+package controller
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+	gapi "github.com/openconfig/gnmic/pkg/api/types"
+)
+
+const (
+	// defaultPlanHistoryLimit is the number of applied plans kept per cluster
+	// when spec.planHistory.limit is not set.
+	defaultPlanHistoryLimit = 10
+	// maxPlanCauses caps the causes recorded with a revision so a plan that
+	// adds thousands of targets does not blow the annotation size limit.
+	maxPlanCauses = 50
+
+	planHistoryDataKey = "plan.json.gz"
+)
+
+// PlanRevision is an apply plan as it was applied to a cluster's pods,
+// together with what caused it.
+//
+// Revisions are persisted to one ConfigMap each, owned by the Cluster. Target
+// credentials and the output and input values resolved from Secrets are
+// stripped before persisting: ConfigMaps are not meant to hold secrets.
+// Applying a revision again (see spec.planHistory.pinnedRevision) takes the
+// credentials from the plan built for the current state instead.
+type PlanRevision struct {
+	Revision int64 `json:"revision"`
+	// The Cluster generation at the time the plan was applied
+	ClusterGeneration int64       `json:"clusterGeneration"`
+	Timestamp         metav1.Time `json:"timestamp"`
+	// The plan entries that changed compared to the previous revision
+	Causes []string `json:"causes,omitempty"`
+	// Set when the cluster is currently pinned to this revision
+	Pinned bool `json:"pinned,omitempty"`
+	// Set when this revision is the one currently applied
+	Current bool             `json:"current,omitempty"`
+	Plan    *gnmic.ApplyPlan `json:"plan,omitempty"`
+}
+
+// PlanRevisionDiff is the difference between two plan revisions of a cluster.
+// A From of 0 stands for an empty plan.
+type PlanRevisionDiff struct {
+	From int64           `json:"from"`
+	To   int64           `json:"to"`
+	Diff *gnmic.PlanDiff `json:"diff"`
+}
+
+func planHistoryLimit(cluster *gnmicv1alpha1.Cluster) int {
+	if cluster.Spec.PlanHistory == nil || cluster.Spec.PlanHistory.Limit <= 0 {
+		return defaultPlanHistoryLimit
+	}
+	return int(cluster.Spec.PlanHistory.Limit)
+}
+
+func pinnedPlanRevision(cluster *gnmicv1alpha1.Cluster) int64 {
+	if cluster.Spec.PlanHistory == nil {
+		return 0
+	}
+	return cluster.Spec.PlanHistory.PinnedRevision
+}
+
+func planRevisionConfigMapName(clusterName string, revision int64) string {
+	return fmt.Sprintf("%s%s-plan-%d", resourcePrefix, clusterName, revision)
+}
+
+// withoutCredentials returns a shallow copy of the plan whose target and tunnel
+// target configs carry no username, password or token, and whose output and
+// input configs carry no value resolved from a Secret.
+//
+// CurrentTargetAssignment is dropped as well: it records where targets run
+// right now, which changes as pods come and go without the configuration
+// changing.
+func withoutCredentials(plan *gnmic.ApplyPlan) *gnmic.ApplyPlan {
+	p := *gnmic.WithoutSecrets(plan)
+	p.CurrentTargetAssignment = nil
+	p.Targets = make(map[string]*gapi.TargetConfig, len(plan.Targets))
+	for name, tc := range plan.Targets {
+		if tc == nil {
+			p.Targets[name] = nil
+			continue
+		}
+		c := *tc
+		c.Username, c.Password, c.Token = nil, nil, nil
+		p.Targets[name] = &c
+	}
+	p.TunnelTargetMatches = make(map[string]*gnmic.TunnelTargetMatch, len(plan.TunnelTargetMatches))
+	for name, m := range plan.TunnelTargetMatches {
+		if m == nil || m.Config == nil {
+			p.TunnelTargetMatches[name] = m
+			continue
+		}
+		mc := *m
+		c := *m.Config
+		c.Username, c.Password, c.Token = nil, nil, nil
+		mc.Config = &c
+		p.TunnelTargetMatches[name] = &mc
+	}
+	return &p
+}
+
+// restoreCredentials copies the credentials of the live plan onto the matching
+// targets, tunnel target matches, outputs and inputs of a plan read back from
+// the history. Entries that no longer exist in the live plan are left without
+// credentials.
+func restoreCredentials(plan, live *gnmic.ApplyPlan) {
+	gnmic.RestoreSecrets(plan, live)
+	for name, tc := range plan.Targets {
+		if ltc, ok := live.Targets[name]; ok && tc != nil && ltc != nil {
+			tc.Username, tc.Password, tc.Token = ltc.Username, ltc.Password, ltc.Token
+		}
+	}
+	for name, m := range plan.TunnelTargetMatches {
+		lm, ok := live.TunnelTargetMatches[name]
+		if !ok || m == nil || m.Config == nil || lm == nil || lm.Config == nil {
+			continue
+		}
+		m.Config.Username, m.Config.Password, m.Config.Token = lm.Config.Username, lm.Config.Password, lm.Config.Token
+	}
+}
+
+// listPlanHistory returns the plan history ConfigMaps of a cluster, oldest first.
+func (r *ClusterReconciler) listPlanHistory(ctx context.Context, namespace, clusterName string) ([]corev1.ConfigMap, error) {
+	var cmList corev1.ConfigMapList
+	if err := r.List(ctx, &cmList,
+		client.InNamespace(namespace),
+		client.MatchingLabels{LabelClusterName: clusterName},
+		client.HasLabels{LabelPlanRevision},
+	); err != nil {
+		return nil, err
+	}
+	items := cmList.Items
+	sort.Slice(items, func(i, j int) bool {
+		return configMapPlanRevision(&items[i]) < configMapPlanRevision(&items[j])
+	})
+	return items, nil
+}
+
+func configMapPlanRevision(cm *corev1.ConfigMap) int64 {
+	rev, _ := strconv.ParseInt(cm.Labels[LabelPlanRevision], 10, 64)
+	return rev
+}
+
+// decodePlanRevision reads a PlanRevision from its ConfigMap.
+// The plan itself is only decoded when withPlan is set.
+func decodePlanRevision(cm *corev1.ConfigMap, withPlan bool) (*PlanRevision, error) {
+	rev := &PlanRevision{
+		Revision:  configMapPlanRevision(cm),
+		Timestamp: cm.CreationTimestamp,
+	}
+	if g, ok := cm.Annotations[AnnotationPlanClusterGeneration]; ok {
+		rev.ClusterGeneration, _ = strconv.ParseInt(g, 10, 64)
+	}
+	if c, ok := cm.Annotations[AnnotationPlanCauses]; ok && c != "" {
+		if err := json.Unmarshal([]byte(c), &rev.Causes); err != nil {
+			return nil, fmt.Errorf("failed to decode causes of plan revision %d: %w", rev.Revision, err)
+		}
+	}
+	if !withPlan {
+		return rev, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(cm.BinaryData[planHistoryDataKey]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress plan revision %d: %w", rev.Revision, err)
+	}
+	defer zr.Close()
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress plan revision %d: %w", rev.Revision, err)
+	}
+	rev.Plan = &gnmic.ApplyPlan{}
+	if err := json.Unmarshal(data, rev.Plan); err != nil {
+		return nil, fmt.Errorf("failed to decode plan revision %d: %w", rev.Revision, err)
+	}
+	return rev, nil
+}
+
+// getPlanRevision reads a single revision, including its plan, from a cluster's history.
+func (r *ClusterReconciler) getPlanRevision(ctx context.Context, namespace, clusterName string, revision int64) (*PlanRevision, error) {
+	var cm corev1.ConfigMap
+	nn := types.NamespacedName{Name: planRevisionConfigMapName(clusterName, revision), Namespace: namespace}
+	if err := r.Get(ctx, nn, &cm); err != nil {
+		return nil, fmt.Errorf("failed to get plan revision %d of cluster %s/%s: %w", revision, namespace, clusterName, err)
+	}
+	return decodePlanRevision(&cm, true)
+}
+
+// pinnedPlan returns the plan of the revision a cluster is pinned to, ready to be
+// applied: credentials and current target assignment are taken from the live plan.
+func (r *ClusterReconciler) pinnedPlan(ctx context.Context, cluster *gnmicv1alpha1.Cluster, revision int64, live *gnmic.ApplyPlan) (*gnmic.ApplyPlan, error) {
+	rev, err := r.getPlanRevision(ctx, cluster.Namespace, cluster.Name, revision)
+	if err != nil {
+		return nil, err
+	}
+	restoreCredentials(rev.Plan, live)
+	rev.Plan.CurrentTargetAssignment = live.CurrentTargetAssignment
+	return rev.Plan, nil
+}
+
+// recordPlanRevision adds an applied plan to the cluster's history and returns
+// its revision. A plan identical to the latest revision is not recorded again;
+// the latest revision number is returned instead. Revisions beyond the history
+// limit are pruned, oldest first, except for a pinned revision.
+func (r *ClusterReconciler) recordPlanRevision(ctx context.Context, cluster *gnmicv1alpha1.Cluster, plan *gnmic.ApplyPlan) (int64, error) {
+	logger := log.FromContext(ctx)
+
+	// Fingerprinted without credentials, so a credential rotation alone does
+	// not add a revision that would look identical to the previous one.
+	stored := withoutCredentials(plan)
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal plan: %w", err)
+	}
+	fp := fingerprint(data)
+	history, err := r.listPlanHistory(ctx, cluster.Namespace, cluster.Name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list plan history: %w", err)
+	}
+
+	revision := int64(1)
+	var previous *PlanRevision
+	if len(history) > 0 {
+		latest := &history[len(history)-1]
+		if latest.Annotations[AnnotationPlanFingerprint] == fp {
+			return configMapPlanRevision(latest), nil
+		}
+		revision = configMapPlanRevision(latest) + 1
+		previous, err = decodePlanRevision(latest, true)
+		if err != nil {
+			// a corrupted revision must not block recording new ones
+			logger.Error(err, "failed to decode latest plan revision, recording causes against an empty plan")
+			previous = nil
+		}
+	}
+
+	var causes []string
+	var previousPlan *gnmic.ApplyPlan
+	if previous != nil {
+		if previous.ClusterGeneration != cluster.Generation {
+			causes = append(causes, fmt.Sprintf("cluster %s generation %d", cluster.Name, cluster.Generation))
+		}
+		previousPlan = previous.Plan
+	}
+	causes = append(causes, gnmic.DiffPlans(previousPlan, stored).Summary()...)
+	if len(causes) > maxPlanCauses {
+		more := len(causes) - maxPlanCauses
+		causes = append(causes[:maxPlanCauses], fmt.Sprintf("... and %d more", more))
+	}
+
+	cm, err := r.buildPlanRevisionConfigMap(cluster, revision, fp, causes, data)
+	if err != nil {
+		return 0, err
+	}
+	if err := r.Create(ctx, cm); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return 0, fmt.Errorf("failed to record plan revision %d: %w", revision, err)
+		}
+		// the cached list lagged behind a revision recorded by a previous reconcile
+		var existing corev1.ConfigMap
+		if err := r.Get(ctx, client.ObjectKeyFromObject(cm), &existing); err != nil {
+			return 0, fmt.Errorf("failed to get plan revision %d: %w", revision, err)
+		}
+		if existing.Annotations[AnnotationPlanFingerprint] != fp {
+			return 0, fmt.Errorf("plan revision %d already exists with a different plan", revision)
+		}
+		return revision, nil
+	}
+	logger.Info("recorded plan revision", "revision", revision, "causes", len(causes))
+
+	history = append(history, *cm)
+	r.prunePlanHistory(ctx, cluster, history)
+	return revision, nil
+}
+
+func (r *ClusterReconciler) buildPlanRevisionConfigMap(cluster *gnmicv1alpha1.Cluster, revision int64, fp string, causes []string, data []byte) (*corev1.ConfigMap, error) {
+	// plans of large clusters are mostly repeated target configs, which compress
+	// well below the ConfigMap size limit.
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to compress plan: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress plan: %w", err)
+	}
+	causesJSON, err := json.Marshal(causes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal plan causes: %w", err)
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      planRevisionConfigMapName(cluster.Name, revision),
+			Namespace: cluster.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":       LabelValueName,
+				"app.kubernetes.io/managed-by": LabelValueManagedBy,
+				LabelClusterName:               cluster.Name,
+				LabelPlanRevision:              strconv.FormatInt(revision, 10),
+			},
+			Annotations: map[string]string{
+				AnnotationPlanClusterGeneration: strconv.FormatInt(cluster.Generation, 10),
+				AnnotationPlanFingerprint:       fp,
+				AnnotationPlanCauses:            string(causesJSON),
+			},
+		},
+		BinaryData: map[string][]byte{
+			planHistoryDataKey: buf.Bytes(),
+		},
+	}
+	if err := controllerutil.SetControllerReference(cluster, cm, r.Scheme); err != nil {
+		return nil, err
+	}
+	return cm, nil
+}
+
+// prunePlanHistory deletes the oldest revisions beyond the history limit.
+// history must be sorted oldest first. The latest revision, the one just
+// applied, is never pruned, nor is a pinned one. Errors are logged only: a
+// revision left behind is pruned on the next recording.
+func (r *ClusterReconciler) prunePlanHistory(ctx context.Context, cluster *gnmicv1alpha1.Cluster, history []corev1.ConfigMap) {
+	logger := log.FromContext(ctx)
+	limit := planHistoryLimit(cluster)
+	pinned := pinnedPlanRevision(cluster)
+	excess := len(history) - limit
+	for i := 0; i < len(history)-1 && excess > 0; i++ {
+		if configMapPlanRevision(&history[i]) == pinned {
+			continue
+		}
+		if err := r.Delete(ctx, &history[i]); err != nil && !apierrors.IsNotFound(err) {
+			logger.Error(err, "failed to prune plan revision", "configMap", history[i].Name)
+			continue
+		}
+		excess--
+	}
+}
@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+	gapi "github.com/openconfig/gnmic/pkg/api/types"
+)
+
+func historyCluster(limit int32) *gnmicv1alpha1.Cluster {
+	return &gnmicv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "default", UID: "c1-uid", Generation: 1},
+		Spec: gnmicv1alpha1.ClusterSpec{
+			Image:       "gnmic",
+			PlanHistory: &gnmicv1alpha1.PlanHistoryConfig{Limit: limit},
+		},
+	}
+}
+
+func historyPlan(address, password string) *gnmic.ApplyPlan {
+	return &gnmic.ApplyPlan{
+		Targets: map[string]*gapi.TargetConfig{
+			"default/t1": {Name: "default/t1", Address: address, Username: ptr.To("admin"), Password: ptr.To(password)},
+		},
+		CurrentTargetAssignment: map[int]map[string]struct{}{0: {"default/t1": {}}},
+	}
+}
+
+func TestRecordPlanRevision(t *testing.T) {
+	ctx := context.Background()
+	cluster := historyCluster(2)
+	r := reconcilerWith(t, cluster)
+
+	rev, err := r.recordPlanRevision(ctx, cluster, historyPlan("10.0.0.1:57400", "secret"))
+	if err != nil || rev != 1 {
+		t.Fatalf("rev=%d err=%v, want 1", rev, err)
+	}
+	// same configuration, other assignment and credentials: not a new revision
+	same := historyPlan("10.0.0.1:57400", "rotated")
+	same.CurrentTargetAssignment = map[int]map[string]struct{}{1: {"default/t1": {}}}
+	if rev, err = r.recordPlanRevision(ctx, cluster, same); err != nil || rev != 1 {
+		t.Fatalf("rev=%d err=%v, want 1", rev, err)
+	}
+	if rev, err = r.recordPlanRevision(ctx, cluster, historyPlan("10.0.0.2:57400", "secret")); err != nil || rev != 2 {
+		t.Fatalf("rev=%d err=%v, want 2", rev, err)
+	}
+
+	stored, err := r.getPlanRevision(ctx, "default", "c1", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := stored.Plan.Targets["default/t1"]
+	if tc.Address != "10.0.0.2:57400" {
+		t.Fatalf("address = %q", tc.Address)
+	}
+	if tc.Username != nil || tc.Password != nil {
+		t.Fatal("credentials must not be persisted")
+	}
+	if stored.Plan.CurrentTargetAssignment != nil {
+		t.Fatal("target assignment must not be persisted")
+	}
+	if len(stored.Causes) != 1 || stored.Causes[0] != "target default/t1 changed" {
+		t.Fatalf("causes = %v", stored.Causes)
+	}
+
+	// a third revision prunes the first one
+	if rev, err = r.recordPlanRevision(ctx, cluster, historyPlan("10.0.0.3:57400", "secret")); err != nil || rev != 3 {
+		t.Fatalf("rev=%d err=%v, want 3", rev, err)
+	}
+	history, err := r.listPlanHistory(ctx, "default", "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || configMapPlanRevision(&history[0]) != 2 || configMapPlanRevision(&history[1]) != 3 {
+		t.Fatalf("unexpected history after pruning: %d entries", len(history))
+	}
+}
+
+func TestPrunePlanHistoryKeepsPinnedRevision(t *testing.T) {
+	ctx := context.Background()
+	cluster := historyCluster(1)
+	r := reconcilerWith(t, cluster)
+
+	for i, addr := range []string{"10.0.0.1:57400", "10.0.0.2:57400"} {
+		if i == 1 {
+			cluster.Spec.PlanHistory.PinnedRevision = 1
+		}
+		if _, err := r.recordPlanRevision(ctx, cluster, historyPlan(addr, "secret")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	history, err := r.listPlanHistory(ctx, "default", "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("history = %d entries, want the pinned revision kept", len(history))
+	}
+}
+
+func TestPinnedPlanRestoresLiveState(t *testing.T) {
+	ctx := context.Background()
+	cluster := historyCluster(10)
+	r := reconcilerWith(t, cluster)
+
+	if _, err := r.recordPlanRevision(ctx, cluster, historyPlan("10.0.0.1:57400", "old")); err != nil {
+		t.Fatal(err)
+	}
+	live := historyPlan("10.0.0.9:57400", "new")
+	plan, err := r.pinnedPlan(ctx, cluster, 1, live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := plan.Targets["default/t1"]
+	if tc.Address != "10.0.0.1:57400" {
+		t.Fatalf("address = %q, want the pinned one", tc.Address)
+	}
+	if tc.Password == nil || *tc.Password != "new" {
+		t.Fatal("expected credentials from the live plan")
+	}
+	if _, ok := plan.CurrentTargetAssignment[0]["default/t1"]; !ok {
+		t.Fatal("expected the live target assignment")
+	}
+
+	if _, err := r.pinnedPlan(ctx, cluster, 7, live); err == nil {
+		t.Fatal("expected an error for a missing revision")
+	}
+}
+
+func TestPinAndReleasePlanRevision(t *testing.T) {
+	ctx := context.Background()
+	cluster := historyCluster(10)
+	r := reconcilerWith(t, cluster)
+
+	if err := r.PinPlanRevision(ctx, "default", "c1", 1); err == nil {
+		t.Fatal("expected an error pinning a revision that does not exist")
+	}
+	if _, err := r.recordPlanRevision(ctx, cluster, historyPlan("10.0.0.1:57400", "secret")); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.PinPlanRevision(ctx, "default", "c1", 1); err != nil {
+		t.Fatal(err)
+	}
+	revisions, err := r.ListPlanRevisions(ctx, "default", "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 1 || !revisions[0].Pinned || revisions[0].Plan != nil {
+		t.Fatalf("unexpected revisions: %+v", revisions)
+	}
+	if err := r.ReleasePlanPin(ctx, "default", "c1"); err != nil {
+		t.Fatal(err)
+	}
+	var got gnmicv1alpha1.Cluster
+	if err := r.Get(ctx, client.ObjectKeyFromObject(cluster), &got); err != nil {
+		t.Fatal(err)
+	}
+	if pinnedPlanRevision(&got) != 0 {
+		t.Fatal("expected pin to be released")
+	}
+}
+
+func TestPlanHistoryStripsOutputSecrets(t *testing.T) {
+	ctx := context.Background()
+	cluster := historyCluster(10)
+	r := reconcilerWith(t, cluster)
+
+	withSecret := func(password string) *gnmic.ApplyPlan {
+		plan := historyPlan("10.0.0.1:57400", "secret")
+		plan.Outputs = map[string]map[string]any{
+			"default/p1/o1": {"type": "kafka", "sasl": map[string]any{"user": "gnmic", "password": password}},
+		}
+		plan.OutputSecretPaths = map[string][]string{"default/p1/o1": {"sasl.password"}}
+		return plan
+	}
+	if _, err := r.recordPlanRevision(ctx, cluster, withSecret("old")); err != nil {
+		t.Fatal(err)
+	}
+	// a rotated Secret alone is not a new revision
+	if rev, err := r.recordPlanRevision(ctx, cluster, withSecret("new")); err != nil || rev != 1 {
+		t.Fatalf("rev=%d err=%v, want 1", rev, err)
+	}
+	stored, err := r.getPlanRevision(ctx, "default", "c1", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sasl := stored.Plan.Outputs["default/p1/o1"]["sasl"].(map[string]any)
+	if _, ok := sasl["password"]; ok || sasl["user"] != "gnmic" {
+		t.Fatalf("stored sasl = %v, want the password stripped", sasl)
+	}
+
+	plan, err := r.pinnedPlan(ctx, cluster, 1, withSecret("new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sasl = plan.Outputs["default/p1/o1"]["sasl"].(map[string]any)
+	if sasl["password"] != "new" {
+		t.Fatalf("pinned sasl = %v, want the password of the live plan", sasl)
+	}
+	if len(plan.OutputSecretPaths["default/p1/o1"]) != 1 {
+		t.Fatal("expected the secret paths of the pinned plan, for redaction")
+	}
+}
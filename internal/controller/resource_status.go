@@ -0,0 +1,388 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+)
+
+// Kinds of the resources referenced from Pipelines whose status reports
+// their usage by Clusters.
+const (
+	resourceKindSubscription       = "Subscription"
+	resourceKindOutput             = "Output"
+	resourceKindInput              = "Input"
+	resourceKindProcessor          = "Processor"
+	resourceKindTargetProfile      = "TargetProfile"
+	resourceKindTunnelTargetPolicy = "TunnelTargetPolicy"
+)
+
+// Condition types and reasons for the referenced resources' status
+const (
+	// ResourceConditionTypeReady indicates the resource could be used as configured
+	ResourceConditionTypeReady = "Ready"
+
+	ResourceReasonValid   = "Valid"
+	ResourceReasonInvalid = "Invalid"
+)
+
+// resourceStatusKinds are the kinds of the referenced resources.
+var resourceStatusKinds = []struct {
+	kind      string
+	newObject func() client.Object
+	newList   func() client.ObjectList
+}{
+	{resourceKindSubscription,
+		func() client.Object { return &gnmicv1alpha1.Subscription{} },
+		func() client.ObjectList { return &gnmicv1alpha1.SubscriptionList{} }},
+	{resourceKindOutput,
+		func() client.Object { return &gnmicv1alpha1.Output{} },
+		func() client.ObjectList { return &gnmicv1alpha1.OutputList{} }},
+	{resourceKindInput,
+		func() client.Object { return &gnmicv1alpha1.Input{} },
+		func() client.ObjectList { return &gnmicv1alpha1.InputList{} }},
+	{resourceKindProcessor,
+		func() client.Object { return &gnmicv1alpha1.Processor{} },
+		func() client.ObjectList { return &gnmicv1alpha1.ProcessorList{} }},
+	{resourceKindTargetProfile,
+		func() client.Object { return &gnmicv1alpha1.TargetProfile{} },
+		func() client.ObjectList { return &gnmicv1alpha1.TargetProfileList{} }},
+	{resourceKindTunnelTargetPolicy,
+		func() client.Object { return &gnmicv1alpha1.TunnelTargetPolicy{} },
+		func() client.ObjectList { return &gnmicv1alpha1.TunnelTargetPolicyList{} }},
+}
+
+// resourceStatusClustersField indexes the referenced resources by the
+// clusters their status reports a usage for.
+const resourceStatusClustersField = "status.clusters"
+
+// indexResourceStatusClusters returns the clusters the status of a
+// referenced resource reports a usage for.
+func indexResourceStatusClusters(obj client.Object) []string {
+	status := resourceStatusOf(obj)
+	if status == nil || len(status.Clusters) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(status.Clusters))
+	for name := range status.Clusters {
+		names = append(names, name)
+	}
+	return names
+}
+
+// indexResourceStatuses registers the resourceStatusClustersField index of
+// each kind of referenced resource.
+func indexResourceStatuses(ctx context.Context, indexer client.FieldIndexer) error {
+	for _, kind := range resourceStatusKinds {
+		if err := indexer.IndexField(ctx, kind.newObject(), resourceStatusClustersField, indexResourceStatusClusters); err != nil {
+			return fmt.Errorf("failed to index %s status: %w", kind.kind, err)
+		}
+	}
+	return nil
+}
+
+type resourceKey struct {
+	kind string
+	name string
+}
+
+// resourceUse is what a cluster's pipelines make of one referenced resource.
+type resourceUse struct {
+	pipelines map[string]struct{}
+	targets   map[string]struct{}
+}
+
+// resourceUsage collects, while a cluster is reconciled, the Pipelines using
+// each referenced resource, the targets it applies to and why it could not be
+// used as configured, if it could not.
+type resourceUsage struct {
+	used    map[resourceKey]*resourceUse
+	invalid map[resourceKey]string
+}
+
+func newResourceUsage() *resourceUsage {
+	return &resourceUsage{
+		used:    make(map[resourceKey]*resourceUse),
+		invalid: make(map[resourceKey]string),
+	}
+}
+
+// use records that a pipeline uses a resource for the given targets.
+func (u *resourceUsage) use(kind, name, pipeline string, targets ...string) {
+	key := resourceKey{kind: kind, name: name}
+	ru, ok := u.used[key]
+	if !ok {
+		ru = &resourceUse{
+			pipelines: make(map[string]struct{}),
+			targets:   make(map[string]struct{}),
+		}
+		u.used[key] = ru
+	}
+	ru.pipelines[pipeline] = struct{}{}
+	for _, target := range targets {
+		ru.targets[target] = struct{}{}
+	}
+}
+
+// invalidate records why a resource could not be used. The first reason wins.
+func (u *resourceUsage) invalidate(kind, name, reason string) {
+	key := resourceKey{kind: kind, name: name}
+	if _, ok := u.invalid[key]; !ok {
+		u.invalid[key] = reason
+	}
+}
+
+// names returns the names of the resources of a kind that are used or
+// invalid.
+func (u *resourceUsage) names(kind string) []string {
+	var names []string
+	for key := range u.used {
+		if key.kind == kind {
+			names = append(names, key.name)
+		}
+	}
+	for key := range u.invalid {
+		if _, ok := u.used[key]; !ok && key.kind == kind {
+			names = append(names, key.name)
+		}
+	}
+	return names
+}
+
+// updateResourceStatuses records a cluster's usage in the status of the
+// referenced resources of its namespace, and removes it from the ones the
+// cluster no longer uses. Only the resources the cluster uses and the ones
+// whose status still reports it are fetched, and only changed statuses are
+// patched. Failures are logged and do not hold up the cluster.
+func (r *ClusterReconciler) updateResourceStatuses(ctx context.Context, namespace, clusterName string, usage *resourceUsage) {
+	logger := log.FromContext(ctx)
+	now := metav1.Now()
+
+	for _, kind := range resourceStatusKinds {
+		// resources whose status reports the cluster
+		objs := make(map[string]client.Object)
+		list := kind.newList()
+		if err := r.List(ctx, list, client.InNamespace(namespace),
+			client.MatchingFields{resourceStatusClustersField: clusterName}); err != nil {
+			logger.Error(err, "failed to list resources for status update", "kind", kind.kind)
+			continue
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			logger.Error(err, "failed to extract resources for status update", "kind", kind.kind)
+			continue
+		}
+		for _, item := range items {
+			if obj, ok := item.(client.Object); ok {
+				objs[obj.GetName()] = obj
+			}
+		}
+		// resources the cluster uses
+		for _, name := range usage.names(kind.kind) {
+			if _, ok := objs[name]; ok {
+				continue
+			}
+			obj := kind.newObject()
+			if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, obj); err != nil {
+				if !apierrors.IsNotFound(err) {
+					logger.Error(err, "failed to get resource for status update", "kind", kind.kind, "name", name)
+				}
+				continue
+			}
+			objs[name] = obj
+		}
+
+		for name, obj := range objs {
+			key := resourceKey{kind: kind.kind, name: name}
+			use, invalid := usage.used[key], usage.invalid[key]
+			if err := r.patchResourceStatus(ctx, obj, func(obj client.Object) bool {
+				return setResourceUsage(resourceStatusOf(obj), obj.GetGeneration(), clusterName, use, invalid, now)
+			}); err != nil {
+				logger.Error(err, "failed to update resource status", "kind", kind.kind, "name", name)
+			}
+		}
+	}
+}
+
+// patchResourceStatus applies mutate to a resource and patches its status if
+// mutate reports a change, re-fetching the resource on conflict.
+func (r *ClusterReconciler) patchResourceStatus(ctx context.Context, obj client.Object, mutate func(client.Object) bool) error {
+	key := client.ObjectKeyFromObject(obj)
+	for attempt := 0; attempt < maxConflictRetries; attempt++ {
+		if attempt > 0 {
+			if err := r.Get(ctx, key, obj); err != nil {
+				return client.IgnoreNotFound(err)
+			}
+		}
+		base := obj.DeepCopyObject().(client.Object)
+		if !mutate(obj) {
+			return nil
+		}
+		patch := client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{})
+		if err := r.Status().Patch(ctx, obj, patch); err != nil {
+			if apierrors.IsConflict(err) {
+				continue
+			}
+			return client.IgnoreNotFound(err)
+		}
+		return nil
+	}
+	return fmt.Errorf("giving up after %d conflicts", maxConflictRetries)
+}
+
+// resourceStatusOf returns the status shared by the referenced resources.
+func resourceStatusOf(obj client.Object) *gnmicv1alpha1.ResourceStatus {
+	switch o := obj.(type) {
+	case *gnmicv1alpha1.Subscription:
+		return &o.Status.ResourceStatus
+	case *gnmicv1alpha1.Output:
+		return &o.Status.ResourceStatus
+	case *gnmicv1alpha1.Input:
+		return &o.Status.ResourceStatus
+	case *gnmicv1alpha1.Processor:
+		return &o.Status.ResourceStatus
+	case *gnmicv1alpha1.TargetProfile:
+		return &o.Status.ResourceStatus
+	case *gnmicv1alpha1.TunnelTargetPolicy:
+		return &o.Status.ResourceStatus
+	}
+	return nil
+}
+
+// setResourceUsage sets a cluster's usage of a resource in its status, or
+// removes it if the cluster does not use the resource. The Ready condition
+// reflects the usage of all clusters and is removed once none uses the
+// resource. It reports whether the status changed.
+func setResourceUsage(status *gnmicv1alpha1.ResourceStatus, generation int64, clusterName string, use *resourceUse, invalid string, now metav1.Time) bool {
+	if status == nil {
+		return false
+	}
+	newStatus := *status.DeepCopy()
+
+	if use == nil {
+		if _, ok := newStatus.Clusters[clusterName]; !ok {
+			return false
+		}
+		delete(newStatus.Clusters, clusterName)
+		if len(newStatus.Clusters) == 0 {
+			newStatus.Clusters = nil
+		}
+	} else {
+		pipelines := make([]string, 0, len(use.pipelines))
+		for pipeline := range use.pipelines {
+			pipelines = append(pipelines, pipeline)
+		}
+		sort.Strings(pipelines)
+		if newStatus.Clusters == nil {
+			newStatus.Clusters = make(map[string]gnmicv1alpha1.ResourceUsage)
+		}
+		newStatus.Clusters[clusterName] = gnmicv1alpha1.ResourceUsage{
+			Pipelines:    pipelines,
+			TargetsCount: int32(len(use.targets)),
+			Invalid:      invalid,
+		}
+		newStatus.ObservedGeneration = generation
+	}
+
+	newStatus.TargetsCount = 0
+	for _, usage := range newStatus.Clusters {
+		newStatus.TargetsCount += usage.TargetsCount
+	}
+	setResourceReadyCondition(&newStatus, now)
+
+	if resourceStatusEqual(*status, newStatus) {
+		return false
+	}
+	*status = newStatus
+	return true
+}
+
+// setResourceReadyCondition sets the Ready condition from the usage of all
+// clusters: it is False when any of them could not use the resource, and is
+// removed when no cluster uses it.
+func setResourceReadyCondition(status *gnmicv1alpha1.ResourceStatus, now metav1.Time) {
+	conditions := make([]metav1.Condition, 0, len(status.Conditions)+1)
+	var oldReady *metav1.Condition
+	for i, cond := range status.Conditions {
+		if cond.Type == ResourceConditionTypeReady {
+			oldReady = &status.Conditions[i]
+			continue
+		}
+		conditions = append(conditions, cond)
+	}
+	if len(status.Clusters) == 0 {
+		status.Conditions = conditions
+		if len(conditions) == 0 {
+			status.Conditions = nil
+		}
+		return
+	}
+
+	clusterNames := make([]string, 0, len(status.Clusters))
+	for name := range status.Clusters {
+		clusterNames = append(clusterNames, name)
+	}
+	sort.Strings(clusterNames)
+	var reasons []string
+	for _, name := range clusterNames {
+		if invalid := status.Clusters[name].Invalid; invalid != "" {
+			reasons = append(reasons, fmt.Sprintf("cluster %s: %s", name, invalid))
+		}
+	}
+
+	readyCondition := metav1.Condition{
+		Type:               ResourceConditionTypeReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: status.ObservedGeneration,
+		LastTransitionTime: now,
+		Reason:             ResourceReasonValid,
+		Message:            "Resource resolved successfully",
+	}
+	if len(reasons) > 0 {
+		readyCondition.Status = metav1.ConditionFalse
+		readyCondition.Reason = ResourceReasonInvalid
+		readyCondition.Message = strings.Join(reasons, "; ")
+	}
+	// preserve LastTransitionTime for an unchanged condition
+	if oldReady != nil && oldReady.Status == readyCondition.Status {
+		readyCondition.LastTransitionTime = oldReady.LastTransitionTime
+	}
+	status.Conditions = append(conditions, readyCondition)
+}
+
+// resourceStatusEqual compares two ResourceStatus structs for equality
+func resourceStatusEqual(a, b gnmicv1alpha1.ResourceStatus) bool {
+	if a.ObservedGeneration != b.ObservedGeneration ||
+		a.TargetsCount != b.TargetsCount ||
+		len(a.Clusters) != len(b.Clusters) ||
+		len(a.Conditions) != len(b.Conditions) {
+		return false
+	}
+	for name, au := range a.Clusters {
+		bu, ok := b.Clusters[name]
+		if !ok || au.TargetsCount != bu.TargetsCount || au.Invalid != bu.Invalid || !slices.Equal(au.Pipelines, bu.Pipelines) {
+			return false
+		}
+	}
+	for i := range a.Conditions {
+		if a.Conditions[i].Type != b.Conditions[i].Type ||
+			a.Conditions[i].Status != b.Conditions[i].Status ||
+			a.Conditions[i].ObservedGeneration != b.Conditions[i].ObservedGeneration ||
+			a.Conditions[i].Reason != b.Conditions[i].Reason ||
+			a.Conditions[i].Message != b.Conditions[i].Message {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+)
+
+func statusReconcilerWith(t *testing.T, objs ...client.Object) *ClusterReconciler {
+	t.Helper()
+	scheme := secretWatchScheme(t)
+	b := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).
+		WithStatusSubresource(objs...)
+	for _, kind := range resourceStatusKinds {
+		b = b.WithIndex(kind.newObject(), resourceStatusClustersField, indexResourceStatusClusters)
+	}
+	cl := b.Build()
+	return &ClusterReconciler{Client: cl, Scheme: scheme}
+}
+
+func TestSetResourceUsage(t *testing.T) {
+	now := metav1.Now()
+	var status gnmicv1alpha1.ResourceStatus
+
+	u := newResourceUsage()
+	u.use(resourceKindOutput, "o1", "p2", "default/t1", "default/t2")
+	u.use(resourceKindOutput, "o1", "p1", "default/t2")
+	use := u.used[resourceKey{kind: resourceKindOutput, name: "o1"}]
+
+	if !setResourceUsage(&status, 3, "c1", use, "", now) {
+		t.Fatal("first usage not reported as a change")
+	}
+	c1 := status.Clusters["c1"]
+	if !slices.Equal(c1.Pipelines, []string{"p1", "p2"}) || c1.TargetsCount != 2 || status.TargetsCount != 2 {
+		t.Fatalf("usage = %+v, total %d; want [p1 p2] with 2 targets", c1, status.TargetsCount)
+	}
+	if status.ObservedGeneration != 3 || len(status.Conditions) != 1 || status.Conditions[0].Status != metav1.ConditionTrue {
+		t.Fatalf("status = %+v, want generation 3 and Ready", status)
+	}
+	if setResourceUsage(&status, 3, "c1", use, "", metav1.NewTime(now.Add(time.Minute))) {
+		t.Fatal("unchanged usage reported as a change")
+	}
+
+	// a second cluster finds the resource invalid
+	if !setResourceUsage(&status, 3, "c2", use, "service not found", now) {
+		t.Fatal("second cluster not reported as a change")
+	}
+	if ready := status.Conditions[0]; ready.Status != metav1.ConditionFalse || ready.Reason != ResourceReasonInvalid || ready.Message != "cluster c2: service not found" {
+		t.Fatalf("ready = %+v, want Invalid", ready)
+	}
+	if status.Clusters["c2"].Invalid != "service not found" || status.Clusters["c1"].Invalid != "" {
+		t.Fatalf("clusters = %+v, want the reason on c2 only", status.Clusters)
+	}
+	if status.TargetsCount != 4 {
+		t.Fatalf("targetsCount = %d, want the sum across clusters", status.TargetsCount)
+	}
+	// the valid cluster reconciling again does not flip the condition
+	if setResourceUsage(&status, 3, "c1", use, "", now) {
+		t.Fatal("unchanged usage of the valid cluster reported as a change")
+	}
+	if ready := status.Conditions[0]; ready.Status != metav1.ConditionFalse {
+		t.Fatalf("ready = %+v, want it to stay Invalid", ready)
+	}
+
+	// clusters dropping the resource
+	if !setResourceUsage(&status, 3, "c2", nil, "", now) || len(status.Clusters) != 1 || status.TargetsCount != 2 {
+		t.Fatalf("after c2 dropped it: %+v", status)
+	}
+	if ready := status.Conditions[0]; ready.Status != metav1.ConditionTrue {
+		t.Fatalf("ready = %+v, want Ready once the invalid cluster dropped it", ready)
+	}
+	if !setResourceUsage(&status, 3, "c1", nil, "", now) || status.Clusters != nil || len(status.Conditions) != 0 {
+		t.Fatalf("after all clusters dropped it: %+v", status)
+	}
+	if setResourceUsage(&status, 3, "c1", nil, "", now) {
+		t.Fatal("unused resource reported as a change")
+	}
+}
+
+func TestUpdateResourceStatuses(t *testing.T) {
+	output := &gnmicv1alpha1.Output{
+		ObjectMeta: metav1.ObjectMeta{Name: "o1", Namespace: "default", Generation: 2},
+	}
+	tp := profile("tp1", "missing")
+	stale := &gnmicv1alpha1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "default"},
+		Status: gnmicv1alpha1.SubscriptionStatus{ResourceStatus: gnmicv1alpha1.ResourceStatus{
+			Clusters: map[string]gnmicv1alpha1.ResourceUsage{"c1": {Pipelines: []string{"p1"}}},
+		}},
+	}
+	other := &gnmicv1alpha1.Output{
+		ObjectMeta: metav1.ObjectMeta{Name: "o2", Namespace: "default"},
+		Status: gnmicv1alpha1.OutputStatus{ResourceStatus: gnmicv1alpha1.ResourceStatus{
+			Clusters: map[string]gnmicv1alpha1.ResourceUsage{"c2": {Pipelines: []string{"p2"}}},
+		}},
+	}
+	r := statusReconcilerWith(t, output, tp, stale, other)
+	ctx := context.Background()
+	var before gnmicv1alpha1.Output
+	if err := r.Get(ctx, types.NamespacedName{Name: "o2", Namespace: "default"}, &before); err != nil {
+		t.Fatal(err)
+	}
+
+	u := newResourceUsage()
+	u.use(resourceKindOutput, "o1", "p1", "default/t1")
+	u.use(resourceKindTargetProfile, "tp1", "p1", "default/t1")
+	u.invalidate(resourceKindTargetProfile, "tp1", `credentials Secret "missing" not found`)
+	r.updateResourceStatuses(ctx, "default", "c1", u)
+
+	var gotOutput gnmicv1alpha1.Output
+	if err := r.Get(ctx, types.NamespacedName{Name: "o1", Namespace: "default"}, &gotOutput); err != nil {
+		t.Fatal(err)
+	}
+	if s := gotOutput.Status; s.ObservedGeneration != 2 || s.TargetsCount != 1 || len(s.Conditions) != 1 ||
+		s.Conditions[0].Reason != ResourceReasonValid {
+		t.Fatalf("output status = %+v", s)
+	}
+
+	var gotProfile gnmicv1alpha1.TargetProfile
+	if err := r.Get(ctx, types.NamespacedName{Name: "tp1", Namespace: "default"}, &gotProfile); err != nil {
+		t.Fatal(err)
+	}
+	if s := gotProfile.Status; len(s.Conditions) != 1 || s.Conditions[0].Reason != ResourceReasonInvalid {
+		t.Fatalf("profile status = %+v, want Invalid", s)
+	}
+
+	var gotSub gnmicv1alpha1.Subscription
+	if err := r.Get(ctx, types.NamespacedName{Name: "s1", Namespace: "default"}, &gotSub); err != nil {
+		t.Fatal(err)
+	}
+	if gotSub.Status.Clusters != nil {
+		t.Fatalf("stale usage kept: %+v", gotSub.Status)
+	}
+
+	var gotOther gnmicv1alpha1.Output
+	if err := r.Get(ctx, types.NamespacedName{Name: "o2", Namespace: "default"}, &gotOther); err != nil {
+		t.Fatal(err)
+	}
+	if gotOther.ResourceVersion != before.ResourceVersion {
+		t.Fatalf("resource not used by c1 patched: %s -> %s", before.ResourceVersion, gotOther.ResourceVersion)
+	}
+
+	// an unchanged usage is not patched again
+	r.updateResourceStatuses(ctx, "default", "c1", u)
+	var again gnmicv1alpha1.Output
+	if err := r.Get(ctx, types.NamespacedName{Name: "o1", Namespace: "default"}, &again); err != nil {
+		t.Fatal(err)
+	}
+	if again.ResourceVersion != gotOutput.ResourceVersion {
+		t.Fatalf("unchanged status patched: %s -> %s", gotOutput.ResourceVersion, again.ResourceVersion)
+	}
+}
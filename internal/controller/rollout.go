@@ -0,0 +1,371 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+const (
+	rolloutStrategyCanary     = "Canary"
+	defaultCanaryBakeDuration = 2 * time.Minute
+	// how often the canary is checked while it bakes
+	canaryCheckInterval = 15 * time.Second
+)
+
+// canaryRollout is the state of a canary rollout in progress for one cluster.
+//
+// It is in-memory like the ApplyCache it builds on. An operator restart
+// forgets both, and with nothing recorded to revert to the next plan is
+// applied to every pod at once.
+type canaryRollout struct {
+	// planHash identifies the plan being rolled out. A different plan
+	// supersedes the rollout.
+	planHash string
+	pod      int
+	// the configuration the canary pod held before, to revert to.
+	prevHash string
+	prevBody []byte
+	// the canary plan, re-applied if the pod loses it while baking.
+	body []byte
+	// targets are the plan names of the targets running on the canary pod,
+	// baseline the ones among them already unhealthy before it was changed.
+	targets   []string
+	baseline  map[string]struct{}
+	startedAt time.Time
+	// failure is set once the canary was judged unhealthy. The rollout then
+	// stays halted until the plan changes again.
+	failure  string
+	reverted bool
+}
+
+// canaryBakingError is returned while a changed plan runs on the canary pod
+// only and the remaining pods still hold the previous one.
+type canaryBakingError struct {
+	pod       int
+	remaining time.Duration
+}
+
+func (e *canaryBakingError) Error() string {
+	return fmt.Sprintf("canary pod %d is running the new plan, %s left before the remaining pods are updated",
+		e.pod, e.remaining.Round(time.Second))
+}
+
+// canaryFailedError is returned while a rollout is halted because its canary
+// pod failed.
+type canaryFailedError struct {
+	pod    int
+	reason string
+}
+
+func (e *canaryFailedError) Error() string {
+	return fmt.Sprintf("canary pod %d reverted to the previous plan: %s", e.pod, e.reason)
+}
+
+func canaryEnabled(cluster *gnmicv1alpha1.Cluster) bool {
+	return cluster.Spec.Rollout != nil && cluster.Spec.Rollout.Strategy == rolloutStrategyCanary
+}
+
+func canaryBakeDuration(cluster *gnmicv1alpha1.Cluster) time.Duration {
+	if cluster.Spec.Rollout != nil && cluster.Spec.Rollout.BakeDuration != nil && cluster.Spec.Rollout.BakeDuration.Duration > 0 {
+		return cluster.Spec.Rollout.BakeDuration.Duration
+	}
+	return defaultCanaryBakeDuration
+}
+
+// planConfigFingerprint identifies a plan regardless of how its targets are
+// distributed across the pods.
+func planConfigFingerprint(plan *gnmic.ApplyPlan) (string, error) {
+	config := *plan
+	config.CurrentTargetAssignment = nil
+	body, err := json.Marshal(&config)
+	if err != nil {
+		return "", err
+	}
+	return fingerprint(body), nil
+}
+
+// rolloutFingerprint identifies a distributed plan by the per-pod hashes.
+func rolloutFingerprint(hashes map[int]string) string {
+	pods := make([]int, 0, len(hashes))
+	for podIndex := range hashes {
+		pods = append(pods, podIndex)
+	}
+	sort.Ints(pods)
+	var sb strings.Builder
+	for _, podIndex := range pods {
+		fmt.Fprintf(&sb, "%d=%s\n", podIndex, hashes[podIndex])
+	}
+	return fingerprint([]byte(sb.String()))
+}
+
+func (r *ClusterReconciler) getRollout(key string) *canaryRollout {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	return r.rollouts[key]
+}
+
+// rolledOutConfig returns the planConfigFingerprint of the plan last applied
+// to every pod of a cluster.
+func (r *ClusterReconciler) rolledOutConfig(key string) string {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	return r.rolledOut[key]
+}
+
+// markRolledOut records the plan applied to every pod of a cluster using the
+// canary strategy.
+func (r *ClusterReconciler) markRolledOut(cluster *gnmicv1alpha1.Cluster, plan *gnmic.ApplyPlan) error {
+	if !canaryEnabled(cluster) {
+		return nil
+	}
+	configHash, err := planConfigFingerprint(plan)
+	if err != nil {
+		return err
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.rolledOut == nil {
+		r.rolledOut = make(map[string]string)
+	}
+	r.rolledOut[cluster.Namespace+"/"+cluster.Name] = configHash
+	return nil
+}
+
+func (r *ClusterReconciler) setRollout(key string, state *canaryRollout) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if state == nil {
+		delete(r.rollouts, key)
+		return
+	}
+	if r.rollouts == nil {
+		r.rollouts = make(map[string]*canaryRollout)
+	}
+	r.rollouts[key] = state
+}
+
+// canaryGate runs the canary stage of a rollout. It returns nil once the
+// remaining pods may be applied: the canary stayed healthy for the bake
+// duration, the cluster does not use the canary strategy, the plan only moves
+// targets between pods (e.g. a scale), or no pod holds a previous
+// configuration the canary could be reverted to (first apply, or an operator
+// restart). Otherwise it returns a *canaryBakingError or a *canaryFailedError
+// and the caller must not touch the other pods.
+//
+// The canary is the lowest changed pod. It gets the new subscriptions,
+// outputs and processors but only the targets it already runs: it is the
+// pipeline configuration that is being tested, and starting targets that are
+// still collected by another pod would double-collect them for the whole
+// bake duration.
+func (r *ClusterReconciler) canaryGate(
+	ctx context.Context,
+	cluster *gnmicv1alpha1.Cluster,
+	plan *gnmic.ApplyPlan,
+	dist *gnmic.DistributeResult,
+	hashes map[int]string,
+	changed map[int]bool,
+	send func(podIndex int, body []byte) error,
+) error {
+	if !canaryEnabled(cluster) {
+		return nil
+	}
+	logger := log.FromContext(ctx)
+	key := cluster.Namespace + "/" + cluster.Name
+	planHash := rolloutFingerprint(hashes)
+
+	state := r.getRollout(key)
+	if state != nil && state.planHash != planHash {
+		logger.Info("plan changed during canary rollout, starting over", "pod", state.pod)
+		r.setRollout(key, nil)
+		state = nil
+	}
+	if state == nil {
+		// the same configuration on other pods has nothing to be tested
+		configHash, err := planConfigFingerprint(plan)
+		if err != nil {
+			return fmt.Errorf("failed to fingerprint plan: %w", err)
+		}
+		if configHash == r.rolledOutConfig(key) {
+			logger.Info("plan only moves targets between pods, skipping the canary")
+			return nil
+		}
+		return r.startCanary(ctx, cluster, plan, dist, planHash, hashes, changed, send)
+	}
+	if state.failure != "" {
+		if !state.reverted {
+			r.revertCanary(ctx, cluster, state, send)
+		}
+		return &canaryFailedError{pod: state.pod, reason: state.failure}
+	}
+	// the canary is checked on every pass of the bake, not only at its end,
+	// so that a failure is reverted as soon as it shows
+	if err := r.checkCanary(ctx, cluster, state, send); err != nil {
+		return err
+	}
+	if remaining := time.Until(state.startedAt.Add(canaryBakeDuration(cluster))); remaining > 0 {
+		return &canaryBakingError{pod: state.pod, remaining: remaining}
+	}
+	logger.Info("canary pod healthy, rolling out to the remaining pods", "pod", state.pod)
+	r.setRollout(key, nil)
+	return nil
+}
+
+// checkCanary re-applies the canary plan if the pod no longer holds it, e.g.
+// after a restart, and judges the health of the canary's targets. A failed
+// apply or too many targets becoming unhealthy fail the rollout.
+func (r *ClusterReconciler) checkCanary(ctx context.Context, cluster *gnmicv1alpha1.Cluster, state *canaryRollout, send func(podIndex int, body []byte) error) error {
+	podKey := streamKey(cluster.Namespace, cluster.Name, state.pod)
+	if hash := fingerprint(state.body); !r.Applied.Unchanged(podKey, hash) {
+		if err := send(state.pod, state.body); err != nil {
+			return r.failCanary(ctx, cluster, state, fmt.Sprintf("apply failed: %v", err), send)
+		}
+		r.Applied.Record(podKey, hash, nil)
+	}
+
+	unhealthy, err := r.unhealthyCanaryTargets(ctx, cluster, state.pod, state.targets)
+	if err != nil {
+		return fmt.Errorf("failed to check canary pod %d health: %w", state.pod, err)
+	}
+	newlyUnhealthy := 0
+	for name := range unhealthy {
+		if _, ok := state.baseline[name]; !ok {
+			newlyUnhealthy++
+		}
+	}
+	maxPercent := cluster.Spec.Rollout.MaxUnhealthyTargetsPercent
+	if newlyUnhealthy*100 > int(maxPercent)*len(state.targets) {
+		return r.failCanary(ctx, cluster, state, fmt.Sprintf("%d of %d targets became unhealthy (%d%% allowed)",
+			newlyUnhealthy, len(state.targets), maxPercent), send)
+	}
+	return nil
+}
+
+// failCanary halts a rollout and reverts its canary pod.
+func (r *ClusterReconciler) failCanary(ctx context.Context, cluster *gnmicv1alpha1.Cluster, state *canaryRollout, reason string, send func(podIndex int, body []byte) error) error {
+	state.failure = reason
+	log.FromContext(ctx).Info("canary pod failed, reverting", "pod", state.pod, "reason", reason)
+	r.revertCanary(ctx, cluster, state, send)
+	return &canaryFailedError{pod: state.pod, reason: reason}
+}
+
+func (r *ClusterReconciler) startCanary(
+	ctx context.Context,
+	cluster *gnmicv1alpha1.Cluster,
+	plan *gnmic.ApplyPlan,
+	dist *gnmic.DistributeResult,
+	planHash string,
+	hashes map[int]string,
+	changed map[int]bool,
+	send func(podIndex int, body []byte) error,
+) error {
+	logger := log.FromContext(ctx)
+
+	pods := make([]int, 0, len(changed))
+	for podIndex := range changed {
+		pods = append(pods, podIndex)
+	}
+	sort.Ints(pods)
+	state := &canaryRollout{planHash: planHash, pod: -1}
+	for _, podIndex := range pods {
+		// a pod with no previous configuration, or being refreshed with the
+		// one it already holds, has nothing to be reverted to
+		prevHash, prevBody, ok := r.Applied.Previous(streamKey(cluster.Namespace, cluster.Name, podIndex))
+		if !ok || prevHash == hashes[podIndex] {
+			continue
+		}
+		state.pod, state.prevHash, state.prevBody = podIndex, prevHash, prevBody
+		break
+	}
+	if state.pod < 0 {
+		return nil
+	}
+
+	canaryPlan := dist.PerPodPlans[state.pod]
+	if len(plan.CurrentTargetAssignment) > 0 {
+		canaryPlan = shrinkPodPlan(canaryPlan, plan.CurrentTargetAssignment[state.pod])
+	}
+	body, err := json.Marshal(canaryPlan)
+	if err != nil {
+		return fmt.Errorf("failed to marshal canary plan for pod %d: %w", state.pod, err)
+	}
+	state.body = body
+	for name := range canaryPlan.Targets {
+		state.targets = append(state.targets, name)
+	}
+	sort.Strings(state.targets)
+	state.baseline, err = r.unhealthyCanaryTargets(ctx, cluster, state.pod, state.targets)
+	if err != nil {
+		return fmt.Errorf("failed to check canary pod %d health: %w", state.pod, err)
+	}
+
+	logger.Info("applying plan to canary pod", "pod", state.pod, "targets", len(state.targets))
+	key := cluster.Namespace + "/" + cluster.Name
+	if err := send(state.pod, body); err != nil {
+		r.setRollout(key, state)
+		return r.failCanary(ctx, cluster, state, fmt.Sprintf("apply failed: %v", err), send)
+	}
+	// The body is not kept: until the rollout completes, the configuration
+	// the canary held before stays the one to revert to.
+	r.Applied.Record(streamKey(cluster.Namespace, cluster.Name, state.pod), fingerprint(body), nil)
+	state.startedAt = time.Now()
+	r.setRollout(key, state)
+	return &canaryBakingError{pod: state.pod, remaining: canaryBakeDuration(cluster)}
+}
+
+// revertCanary re-applies the configuration the canary pod held before the
+// rollout. A failed revert is retried on the next reconcile.
+func (r *ClusterReconciler) revertCanary(ctx context.Context, cluster *gnmicv1alpha1.Cluster, state *canaryRollout, send func(podIndex int, body []byte) error) {
+	key := streamKey(cluster.Namespace, cluster.Name, state.pod)
+	if err := send(state.pod, state.prevBody); err != nil {
+		log.FromContext(ctx).Error(err, "failed to revert canary pod", "pod", state.pod)
+		r.Applied.Invalidate(key)
+		return
+	}
+	r.Applied.Record(key, state.prevHash, state.prevBody)
+	state.reverted = true
+}
+
+// unhealthyCanaryTargets returns the targets among the canary's that are not
+// running and connected on the canary pod, or have a subscription that is not
+// running, as reported into the Target status by the TargetState controller
+// from the pod's SSE stream. Targets that no longer exist are not counted.
+func (r *ClusterReconciler) unhealthyCanaryTargets(ctx context.Context, cluster *gnmicv1alpha1.Cluster, podIndex int, targets []string) (map[string]struct{}, error) {
+	podName := fmt.Sprintf("%s%s-%d", resourcePrefix, cluster.Name, podIndex)
+	unhealthy := make(map[string]struct{})
+	for _, name := range targets {
+		namespace, targetName, ok := parseTargetName(name)
+		if !ok {
+			continue
+		}
+		var target gnmicv1alpha1.Target
+		if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: targetName}, &target); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		state, ok := target.Status.ClusterStates[cluster.Name]
+		if !ok || state.Pod != podName || state.State != "running" || state.ConnectionState != "READY" {
+			unhealthy[name] = struct{}{}
+			continue
+		}
+		for _, subState := range state.Subscriptions {
+			if subState != "running" {
+				unhealthy[name] = struct{}{}
+				break
+			}
+		}
+	}
+	return unhealthy, nil
+}
@@ -0,0 +1,275 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	gapi "github.com/openconfig/gnmic/pkg/api/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+type canaryFixture struct {
+	r       *ClusterReconciler
+	cluster *gnmicv1alpha1.Cluster
+	plan    *gnmic.ApplyPlan
+	dist    *gnmic.DistributeResult
+	hashes  map[int]string
+	changed map[int]bool
+	sent    map[int][]string
+	sendErr error
+}
+
+func newCanaryFixture(t *testing.T, objs ...client.Object) *canaryFixture {
+	t.Helper()
+	cluster := &gnmicv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "default"},
+		Spec: gnmicv1alpha1.ClusterSpec{
+			Rollout: &gnmicv1alpha1.RolloutConfig{Strategy: rolloutStrategyCanary},
+		},
+	}
+	r := reconcilerWith(t, objs...)
+	r.m = &sync.RWMutex{}
+	r.Applied = NewApplyCache()
+	for podIndex := 0; podIndex < 2; podIndex++ {
+		r.Applied.Record(streamKey("default", "c1", podIndex), "old", []byte("old"))
+	}
+	podPlan := func(target string) *gnmic.ApplyPlan {
+		return &gnmic.ApplyPlan{
+			Targets:    map[string]*gapi.TargetConfig{target: {Name: target}},
+			Processors: map[string]map[string]any{"p1": {"event-strings": map[string]any{}}},
+		}
+	}
+	return &canaryFixture{
+		r:       r,
+		cluster: cluster,
+		plan: &gnmic.ApplyPlan{
+			CurrentTargetAssignment: map[int]map[string]struct{}{
+				0: {"default/t1": {}},
+				1: {"default/t2": {}},
+			},
+		},
+		dist: &gnmic.DistributeResult{PerPodPlans: map[int]*gnmic.ApplyPlan{
+			0: podPlan("default/t1"),
+			1: podPlan("default/t2"),
+		}},
+		hashes:  map[int]string{0: "new0", 1: "new1"},
+		changed: map[int]bool{0: true, 1: true},
+		sent:    make(map[int][]string),
+	}
+}
+
+func (f *canaryFixture) gate(t *testing.T) error {
+	t.Helper()
+	return f.r.canaryGate(context.Background(), f.cluster, f.plan, f.dist, f.hashes, f.changed,
+		func(podIndex int, body []byte) error {
+			f.sent[podIndex] = append(f.sent[podIndex], string(body))
+			return f.sendErr
+		})
+}
+
+// endBake moves the rollout's start back past the bake duration.
+func (f *canaryFixture) endBake(t *testing.T) {
+	t.Helper()
+	state := f.r.getRollout("default/c1")
+	if state == nil {
+		t.Fatal("no rollout in progress")
+	}
+	state.startedAt = state.startedAt.Add(-canaryBakeDuration(f.cluster))
+}
+
+func canaryTarget(name, state string) *gnmicv1alpha1.Target {
+	return &gnmicv1alpha1.Target{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Status: gnmicv1alpha1.TargetStatus{ClusterStates: map[string]gnmicv1alpha1.ClusterTargetState{
+			"c1": {Pod: "gnmic-c1-0", State: state, ConnectionState: "READY"},
+		}},
+	}
+}
+
+func TestCanaryGate_ProceedsAfterHealthyBake(t *testing.T) {
+	f := newCanaryFixture(t, canaryTarget("t1", "running"))
+
+	var baking *canaryBakingError
+	if err := f.gate(t); !errors.As(err, &baking) || baking.pod != 0 {
+		t.Fatalf("first pass = %v, want canary pod 0 baking", err)
+	}
+	if len(f.sent[0]) != 1 || len(f.sent[1]) != 0 {
+		t.Fatalf("sent = %v, want the canary pod only", f.sent)
+	}
+	if err := f.gate(t); !errors.As(err, &baking) {
+		t.Fatalf("second pass = %v, want still baking", err)
+	}
+	if len(f.sent[0]) != 1 {
+		t.Fatal("canary re-applied while baking")
+	}
+
+	f.endBake(t)
+	if err := f.gate(t); err != nil {
+		t.Fatalf("after bake = %v, want nil", err)
+	}
+	if f.r.getRollout("default/c1") != nil {
+		t.Fatal("completed rollout was not cleared")
+	}
+}
+
+// A canary must never start targets that another pod still collects, or they
+// are double-collected for the whole bake.
+func TestCanaryGate_CanaryKeepsItsCurrentTargets(t *testing.T) {
+	f := newCanaryFixture(t)
+	f.dist.PerPodPlans[0].Targets["default/t2"] = &gapi.TargetConfig{Name: "default/t2"}
+
+	_ = f.gate(t)
+	state := f.r.getRollout("default/c1")
+	if state == nil || len(state.targets) != 1 || state.targets[0] != "default/t1" {
+		t.Fatalf("canary targets = %v, want [default/t1]", state)
+	}
+}
+
+func TestCanaryGate_RevertsUnhealthyCanary(t *testing.T) {
+	target := canaryTarget("t1", "running")
+	f := newCanaryFixture(t, target)
+
+	_ = f.gate(t)
+	target.Status.ClusterStates["c1"] = gnmicv1alpha1.ClusterTargetState{Pod: "gnmic-c1-0", State: "failed"}
+	if err := f.r.Update(context.Background(), target); err != nil {
+		t.Fatal(err)
+	}
+	f.endBake(t)
+
+	var failed *canaryFailedError
+	if err := f.gate(t); !errors.As(err, &failed) {
+		t.Fatalf("after bake = %v, want canary failed", err)
+	}
+	if got := f.sent[0]; len(got) != 2 || got[1] != "old" {
+		t.Fatalf("sent to canary = %v, want the new plan then the previous one", got)
+	}
+	if !f.r.Applied.Unchanged(streamKey("default", "c1", 0), "old") {
+		t.Fatal("reverted pod not recorded as holding the previous configuration")
+	}
+
+	// halted until the plan changes
+	if err := f.gate(t); !errors.As(err, &failed) || len(f.sent[0]) != 2 || len(f.sent[1]) != 0 {
+		t.Fatalf("halted pass = %v, sent %v; want failed and nothing sent", err, f.sent)
+	}
+	f.hashes[0] = "newer0"
+	var baking *canaryBakingError
+	if err := f.gate(t); !errors.As(err, &baking) {
+		t.Fatalf("new plan = %v, want a new canary", err)
+	}
+}
+
+// A target that was already down before the canary is not the new plan's fault.
+func TestCanaryGate_IgnoresTargetsUnhealthyBeforehand(t *testing.T) {
+	f := newCanaryFixture(t, canaryTarget("t1", "failed"))
+
+	_ = f.gate(t)
+	f.endBake(t)
+	if err := f.gate(t); err != nil {
+		t.Fatalf("after bake = %v, want nil", err)
+	}
+}
+
+func TestCanaryGate_ApplyErrorRevertsImmediately(t *testing.T) {
+	f := newCanaryFixture(t)
+	f.sendErr = errors.New("invalid processor")
+
+	var failed *canaryFailedError
+	if err := f.gate(t); !errors.As(err, &failed) {
+		t.Fatalf("gate = %v, want canary failed", err)
+	}
+	// the revert failed as well; it is retried on the next pass
+	f.sendErr = nil
+	_ = f.gate(t)
+	if state := f.r.getRollout("default/c1"); state == nil || !state.reverted {
+		t.Fatal("failed revert was not retried")
+	}
+}
+
+// Without a configuration to revert to there is nothing a canary protects.
+func TestCanaryGate_NoPreviousConfigAppliesEverywhere(t *testing.T) {
+	f := newCanaryFixture(t)
+	f.r.Applied = NewApplyCache()
+
+	if err := f.gate(t); err != nil {
+		t.Fatalf("gate = %v, want nil", err)
+	}
+	if len(f.sent) != 0 {
+		t.Fatalf("sent = %v, want nothing", f.sent)
+	}
+
+	f.cluster.Spec.Rollout = nil
+	f.r.Applied.Record(streamKey("default", "c1", 0), "old", []byte("old"))
+	if err := f.gate(t); err != nil || len(f.sent) != 0 {
+		t.Fatalf("gate without canary strategy = %v, sent %v", err, f.sent)
+	}
+}
+
+// A canary failing early in the bake is reverted then, not at its end.
+func TestCanaryGate_RevertsWhileBaking(t *testing.T) {
+	target := canaryTarget("t1", "running")
+	f := newCanaryFixture(t, target)
+
+	_ = f.gate(t)
+	state := target.Status.ClusterStates["c1"]
+	state.Subscriptions = map[string]string{"sub1": "stopped"}
+	target.Status.ClusterStates["c1"] = state
+	if err := f.r.Update(context.Background(), target); err != nil {
+		t.Fatal(err)
+	}
+
+	var failed *canaryFailedError
+	if err := f.gate(t); !errors.As(err, &failed) {
+		t.Fatalf("during bake = %v, want canary failed", err)
+	}
+	if got := f.sent[0]; len(got) != 2 || got[1] != "old" {
+		t.Fatalf("sent to canary = %v, want the new plan then the previous one", got)
+	}
+}
+
+// A canary pod that lost its configuration while baking, e.g. on a restart,
+// gets it again; an apply error then fails the rollout.
+func TestCanaryGate_ReappliesLostCanaryPlan(t *testing.T) {
+	f := newCanaryFixture(t, canaryTarget("t1", "running"))
+
+	_ = f.gate(t)
+	f.r.Applied.Invalidate(streamKey("default", "c1", 0))
+	var baking *canaryBakingError
+	if err := f.gate(t); !errors.As(err, &baking) {
+		t.Fatalf("after restart = %v, want still baking", err)
+	}
+	if got := f.sent[0]; len(got) != 2 || got[1] != got[0] {
+		t.Fatalf("sent to canary = %v, want the canary plan twice", got)
+	}
+
+	f.r.Applied.Invalidate(streamKey("default", "c1", 0))
+	f.sendErr = errors.New("invalid processor")
+	var failed *canaryFailedError
+	if err := f.gate(t); !errors.As(err, &failed) {
+		t.Fatalf("failed re-apply = %v, want canary failed", err)
+	}
+}
+
+// Moving targets between pods, e.g. on a scale, leaves nothing to test.
+func TestCanaryGate_SkipsTargetMoves(t *testing.T) {
+	f := newCanaryFixture(t, canaryTarget("t1", "running"))
+	if err := f.r.markRolledOut(f.cluster, f.plan); err != nil {
+		t.Fatal(err)
+	}
+
+	f.plan.CurrentTargetAssignment = map[int]map[string]struct{}{0: {"default/t1": {}, "default/t2": {}}}
+	if err := f.gate(t); err != nil || len(f.sent) != 0 {
+		t.Fatalf("target moves = %v, sent %v; want nil and nothing sent", err, f.sent)
+	}
+
+	f.plan.Processors = map[string]map[string]any{"p1": {"event-strings": map[string]any{}}}
+	var baking *canaryBakingError
+	if err := f.gate(t); !errors.As(err, &baking) {
+		t.Fatalf("changed processors = %v, want a canary", err)
+	}
+}
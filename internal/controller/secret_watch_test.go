@@ -161,6 +161,46 @@ func TestFindClustersForSecret_DeduplicatesClusters(t *testing.T) {
 	}
 }
 
+// Outputs and Inputs write Secret keys into their config: rotating the Secret
+// must reach the clusters whose pipelines select them.
+func TestFindClustersForSecret_ReachesOutputsAndInputs(t *testing.T) {
+	ref := &gnmicv1alpha1.ConfigSecretRef{Name: "kafka", Items: []gnmicv1alpha1.SecretKeyToPath{{Key: "password", Path: "sasl.password"}}}
+	output := &gnmicv1alpha1.Output{
+		ObjectMeta: metav1.ObjectMeta{Name: "o1", Namespace: "default", Labels: map[string]string{"out": "kafka"}},
+		Spec:       gnmicv1alpha1.OutputSpec{Type: "kafka", SecretRef: ref},
+	}
+	input := &gnmicv1alpha1.Input{
+		ObjectMeta: metav1.ObjectMeta{Name: "i1", Namespace: "default"},
+		Spec:       gnmicv1alpha1.InputSpec{Type: "kafka", SecretRef: ref},
+	}
+	p1 := &gnmicv1alpha1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "default"},
+		Spec: gnmicv1alpha1.PipelineSpec{
+			ClusterRef: "c1",
+			Enabled:    true,
+			Outputs: gnmicv1alpha1.OutputSelector{
+				OutputSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"out": "kafka"}}},
+			},
+		},
+	}
+	p2 := &gnmicv1alpha1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "p2", Namespace: "default"},
+		Spec: gnmicv1alpha1.PipelineSpec{
+			ClusterRef: "c2",
+			Enabled:    true,
+			Inputs:     gnmicv1alpha1.InputSelector{InputRefs: []string{"i1"}},
+		},
+	}
+	r := reconcilerWith(t, secret("kafka"), output, input, p1, p2)
+	got := clusterNames(t, r, secret("kafka"))
+	if len(got) != 2 || got[0] != "c1" || got[1] != "c2" {
+		t.Fatalf("clusters = %v, want [c1 c2]", got)
+	}
+	if got := clusterNames(t, r, secret("other")); len(got) != 0 {
+		t.Fatalf("clusters = %v, want none", got)
+	}
+}
+
 // Secrets carry no generation, so the predicate is the only thing standing
 // between the controller and a reconcile per unrelated Secret write.
 func TestSecretDataChangedPredicate(t *testing.T) {
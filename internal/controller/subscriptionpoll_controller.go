@@ -0,0 +1,604 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	gapi "github.com/openconfig/gnmic/pkg/api/types"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+	"github.com/gnmic/operator/internal/utils"
+)
+
+// Results of a poll for a target, reported in status.polls.
+const (
+	TargetPollResultSucceeded = "Succeeded"
+	TargetPollResultFailed    = "Failed"
+)
+
+const (
+	// pollConcurrency bounds the polls of a cluster in flight at once.
+	pollConcurrency = 16
+	// pollBatchWindow groups the polls due within it in one run, so that
+	// targets spread by the jitter do not cost one reconcile each.
+	pollBatchWindow = time.Second
+	// pollResyncInterval is how often a cluster is checked for POLL
+	// subscriptions added to its plan when none is due sooner.
+	pollResyncInterval = time.Minute
+	// defaultPollJitterDivisor spreads the polls over a tenth of the time
+	// between two polls by default.
+	defaultPollJitterDivisor = 10
+	// pollDoneBufferSize bounds the clusters whose polls completed waiting to
+	// be reconciled again; beyond it they are reconciled when their next poll
+	// is due.
+	pollDoneBufferSize = 64
+	// pollTimeout bounds the wait for the response to a poll.
+	pollTimeout = 30 * time.Second
+)
+
+// ClusterAPI is what the poll scheduler needs of the Cluster controller: the
+// plans applied to the clusters.
+type ClusterAPI interface {
+	GetClusterPlan(namespace, name string) (*gnmic.ApplyPlan, error)
+}
+
+// subscriptionPoller polls the target of a task for its subscription.
+type subscriptionPoller func(ctx context.Context, cluster *gnmicv1alpha1.Cluster, task *pollTask) error
+
+// SubscriptionPollReconciler polls the targets of the POLL Subscriptions with
+// a pollInterval or pollSchedule. Each target is polled on a POLL Subscribe
+// stream the controller holds on the gNMI server of the cluster pod
+// collecting the target, which must be enabled with api.gnmiPort. The last
+// poll of each target is recorded in its status.
+// The polls of a cluster run in the background, one run at a time, and the
+// cluster is reconciled again once they complete.
+type SubscriptionPollReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// Clusters gives the plans applied to the clusters, which name the
+	// subscriptions of each target
+	Clusters ClusterAPI
+
+	// poll, dial and now are replaced in tests
+	poll subscriptionPoller
+	dial gnmiPodDialer
+	now  func() time.Time
+
+	// ctx is the context of the runs and the poll streams, canceled when
+	// the manager stops
+	ctx context.Context
+	// streams holds the poll streams of the targets
+	streams pollStreams
+
+	mu sync.Mutex
+	// running holds the clusters whose polls are in flight
+	running map[types.NamespacedName]struct{}
+	// polled is when the polls of each cluster last ran, by pollTask.key,
+	// until the status read from the cache catches up
+	polled map[types.NamespacedName]map[string]time.Time
+	// done wakes the clusters whose polls completed
+	done chan event.GenericEvent
+	// wg tracks the runs in flight, waited for in tests
+	wg sync.WaitGroup
+}
+
+// pollSchedule is when the targets of a POLL Subscription are polled.
+type pollSchedule struct {
+	interval time.Duration
+	cron     utils.CronSchedule
+	jitter   time.Duration
+}
+
+// pollTask is the poll of a target for a subscription of the plan.
+type pollTask struct {
+	targetNN, subscriptionNN string
+	pipeline, subscription   string
+	pod                      string
+	// config is the subscription in the plan
+	config   *gapi.SubscriptionConfig
+	schedule *pollSchedule
+	next     time.Time
+	result   gnmicv1alpha1.TargetPoll
+}
+
+// key identifies the poll of a target for a subscription in the schedule.
+func (t *pollTask) key() string {
+	return t.targetNN + " " + t.subscriptionNN
+}
+
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=clusters,verbs=get;list;watch
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=subscriptions,verbs=get;list;watch
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=targets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=targets/status,verbs=get;update;patch
+
+// Reconcile polls the targets of a cluster whose POLL subscriptions are due,
+// and requeues the cluster when the next poll is.
+func (r *SubscriptionPollReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var cluster gnmicv1alpha1.Cluster
+	if err := r.Get(ctx, req.NamespacedName, &cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.streams.retain(req.NamespacedName, nil)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	plan, err := r.Clusters.GetClusterPlan(cluster.Namespace, cluster.Name)
+	if err != nil {
+		// the plan is built by the Cluster controller, check again later
+		return ctrl.Result{RequeueAfter: pollResyncInterval}, nil
+	}
+
+	var targetList gnmicv1alpha1.TargetList
+	if err := r.List(ctx, &targetList, client.InNamespace(cluster.Namespace)); err != nil {
+		return ctrl.Result{}, err
+	}
+	targets := make(map[string]*gnmicv1alpha1.Target, len(targetList.Items))
+	for i := range targetList.Items {
+		target := &targetList.Items[i]
+		targets[target.Namespace+gnmic.Delimiter+target.Name] = target
+	}
+
+	now := r.clock()
+	tasks, err := r.pollTasks(ctx, &cluster, plan, targets, now)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	// drop the polls of subscriptions the targets no longer have
+	if err := r.patchPolls(ctx, &cluster, targets, tasks, nil); err != nil {
+		return ctrl.Result{}, err
+	}
+	// and close their streams
+	r.streams.retain(req.NamespacedName, tasks)
+
+	var due []*pollTask
+	for _, task := range tasks {
+		if !task.next.After(now.Add(pollBatchWindow)) {
+			due = append(due, task)
+		}
+	}
+	if len(due) > 0 && r.startRun(req.NamespacedName, due, now) {
+		logger.Info("polling targets", "cluster", cluster.Name, "polls", len(due))
+		// the run outlives the reconcile, it runs until the manager stops
+		runCtx := log.IntoContext(r.runContext(), logger)
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			r.run(runCtx, cluster.DeepCopy(), targets, tasks, due, now)
+		}()
+	}
+	// the polls due are either sent or in flight, due again on the next
+	// firing of their schedule
+	for _, task := range due {
+		task.next = task.schedule.next(task.key(), now, now)
+	}
+	next := now.Add(pollResyncInterval)
+	for _, task := range tasks {
+		if !task.next.IsZero() && task.next.Before(next) {
+			next = task.next
+		}
+	}
+	return ctrl.Result{RequeueAfter: next.Sub(now)}, nil
+}
+
+// startRun records that the polls of a cluster are in flight, unless they
+// already are.
+func (r *SubscriptionPollReconciler) startRun(clusterNN types.NamespacedName, due []*pollTask, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.running[clusterNN]; ok {
+		return false
+	}
+	if r.running == nil {
+		r.running = make(map[types.NamespacedName]struct{})
+		r.polled = make(map[types.NamespacedName]map[string]time.Time)
+	}
+	r.running[clusterNN] = struct{}{}
+	polled := make(map[string]time.Time, len(due))
+	for _, task := range due {
+		polled[task.key()] = now
+	}
+	r.polled[clusterNN] = polled
+	return true
+}
+
+// lastPolled returns when the polls of a cluster last ran for a task, as
+// recorded by startRun.
+func (r *SubscriptionPollReconciler) lastPolled(clusterNN types.NamespacedName, key string) time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.polled[clusterNN][key]
+}
+
+// pollTasks returns the polls of the targets of a plan for its scheduled
+// POLL subscriptions, with when each is due next.
+func (r *SubscriptionPollReconciler) pollTasks(ctx context.Context, cluster *gnmicv1alpha1.Cluster, plan *gnmic.ApplyPlan, targets map[string]*gnmicv1alpha1.Target, now time.Time) ([]*pollTask, error) {
+	logger := log.FromContext(ctx)
+
+	schedules := make(map[string]*pollSchedule)
+	for _, subNN := range slices.Sorted(maps.Keys(plan.Subscriptions)) {
+		if plan.Subscriptions[subNN].Mode != "POLL" {
+			continue
+		}
+		// subscriptions are keyed by pipeline, the name comes last
+		name := subNN[strings.LastIndex(subNN, gnmic.Delimiter)+1:]
+		var subscription gnmicv1alpha1.Subscription
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: cluster.Namespace}, &subscription); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		schedule, err := newPollSchedule(&subscription.Spec, now)
+		if err != nil {
+			logger.Error(err, "invalid poll schedule", "subscription", name)
+			continue
+		}
+		if schedule != nil {
+			schedules[subNN] = schedule
+		}
+	}
+	if len(schedules) == 0 {
+		return nil, nil
+	}
+
+	var tasks []*pollTask
+	for _, targetNN := range slices.Sorted(maps.Keys(plan.Targets)) {
+		target, ok := targets[targetNN]
+		if !ok {
+			continue
+		}
+		for _, subNN := range plan.Targets[targetNN].Subscriptions {
+			schedule, ok := schedules[subNN]
+			if !ok {
+				continue
+			}
+			// subNN is namespace/pipeline/name
+			parts := strings.SplitN(subNN, gnmic.Delimiter, 3)
+			if len(parts) != 3 {
+				continue
+			}
+			task := &pollTask{
+				targetNN:       targetNN,
+				subscriptionNN: subNN,
+				pipeline:       parts[1],
+				subscription:   parts[2],
+				pod:            target.Status.ClusterStates[cluster.Name].Pod,
+				config:         plan.Subscriptions[subNN],
+			}
+			// the status may not show the last run yet
+			last := r.lastPolled(client.ObjectKeyFromObject(cluster), task.key())
+			if poll := findTargetPoll(target.Status.Polls, cluster.Name, task.pipeline, task.subscription); poll != nil && poll.LastPollTime.After(last) {
+				last = poll.LastPollTime.Time
+			}
+			task.schedule = schedule
+			if task.next = schedule.next(task.key(), last, now); task.next.IsZero() {
+				// the schedule never fires
+				continue
+			}
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
+
+// run sends the polls, at most pollConcurrency at once, records their results
+// and wakes the cluster.
+func (r *SubscriptionPollReconciler) run(ctx context.Context, cluster *gnmicv1alpha1.Cluster, targets map[string]*gnmicv1alpha1.Target, tasks, due []*pollTask, now time.Time) {
+	logger := log.FromContext(ctx)
+	clusterNN := client.ObjectKeyFromObject(cluster)
+	defer func() {
+		r.mu.Lock()
+		delete(r.running, clusterNN)
+		r.mu.Unlock()
+		if r.done == nil {
+			return
+		}
+		select {
+		case r.done <- event.GenericEvent{Object: &gnmicv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: cluster.Name, Namespace: cluster.Namespace}}}:
+		default:
+			// the cluster is reconciled when its next poll is due
+		}
+	}()
+
+	r.sendPolls(ctx, cluster, due, now)
+	if err := r.patchPolls(ctx, cluster, targets, tasks, due); err != nil {
+		logger.Error(err, "failed to record the polls", "cluster", cluster.Name)
+	}
+}
+
+// sendPolls sends the polls, at most pollConcurrency at once, and sets their
+// results.
+func (r *SubscriptionPollReconciler) sendPolls(ctx context.Context, cluster *gnmicv1alpha1.Cluster, tasks []*pollTask, now time.Time) {
+	poll := r.poll
+	if poll == nil {
+		poll = r.sendPoll
+	}
+	sem := make(chan struct{}, pollConcurrency)
+	var wg sync.WaitGroup
+	for _, task := range tasks {
+		task.result = gnmicv1alpha1.TargetPoll{
+			Cluster:      cluster.Name,
+			Pipeline:     task.pipeline,
+			Subscription: task.subscription,
+			Pod:          task.pod,
+			Result:       TargetPollResultSucceeded,
+			LastPollTime: metav1.NewTime(now),
+		}
+		if task.pod == "" {
+			task.result.Result = TargetPollResultFailed
+			task.result.Message = fmt.Sprintf("target is not assigned to a pod of cluster %s", cluster.Name)
+			continue
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(task *pollTask) {
+			defer func() { <-sem; wg.Done() }()
+			if err := poll(ctx, cluster, task); err != nil {
+				task.result.Result = TargetPollResultFailed
+				task.result.Message = err.Error()
+			}
+		}(task)
+	}
+	wg.Wait()
+}
+
+// patchPolls records the polls that ran in the status of their targets, and
+// drops the polls of the cluster for subscriptions the targets no longer
+// have. The patch touches no other field of the status and is retried on
+// conflicts, so that the clusters polling the same target do not drop each
+// other's polls.
+func (r *SubscriptionPollReconciler) patchPolls(ctx context.Context, cluster *gnmicv1alpha1.Cluster, targets map[string]*gnmicv1alpha1.Target, tasks, ran []*pollTask) error {
+	current := make(map[string]struct{}, len(tasks))
+	for _, task := range tasks {
+		current[task.targetNN+" "+task.pipeline+" "+task.subscription] = struct{}{}
+	}
+	results := make(map[string][]gnmicv1alpha1.TargetPoll)
+	for _, task := range ran {
+		results[task.targetNN] = append(results[task.targetNN], task.result)
+	}
+
+	for _, targetNN := range slices.Sorted(maps.Keys(targets)) {
+		merge := func(polls []gnmicv1alpha1.TargetPoll) ([]gnmicv1alpha1.TargetPoll, bool) {
+			return mergeTargetPolls(polls, cluster.Name, targetNN, current, results[targetNN])
+		}
+		if _, changed := merge(targets[targetNN].Status.Polls); !changed {
+			continue
+		}
+		if err := r.mutatePolls(ctx, client.ObjectKeyFromObject(targets[targetNN]), merge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mutatePolls patches the polls of a target, merged into its latest status,
+// with an optimistic lock.
+func (r *SubscriptionPollReconciler) mutatePolls(ctx context.Context, targetNN types.NamespacedName, merge func([]gnmicv1alpha1.TargetPoll) ([]gnmicv1alpha1.TargetPoll, bool)) error {
+	for attempt := 0; attempt < maxConflictRetries; attempt++ {
+		var target gnmicv1alpha1.Target
+		if err := r.Get(ctx, targetNN, &target); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		polls, changed := merge(target.Status.Polls)
+		if !changed {
+			return nil
+		}
+		patch := client.MergeFromWithOptions(target.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		target.Status.Polls = polls
+		err := r.Status().Patch(ctx, &target, patch)
+		if apierrors.IsConflict(err) {
+			continue
+		}
+		return client.IgnoreNotFound(err)
+	}
+	return fmt.Errorf("failed to record the polls of target %s after %d conflicts", targetNN, maxConflictRetries)
+}
+
+// mergeTargetPolls drops from the polls of a target the ones of the cluster
+// for subscriptions it no longer has, and sets the results of the polls that
+// ran. It reports whether the polls changed.
+func mergeTargetPolls(polls []gnmicv1alpha1.TargetPoll, clusterName, targetNN string, current map[string]struct{}, results []gnmicv1alpha1.TargetPoll) ([]gnmicv1alpha1.TargetPoll, bool) {
+	merged := make([]gnmicv1alpha1.TargetPoll, 0, len(polls))
+	for _, poll := range polls {
+		if _, ok := current[targetNN+" "+poll.Pipeline+" "+poll.Subscription]; poll.Cluster == clusterName && !ok {
+			continue
+		}
+		merged = append(merged, poll)
+	}
+	if len(merged) == len(polls) && len(results) == 0 {
+		return polls, false
+	}
+	for _, result := range results {
+		if poll := findTargetPoll(merged, result.Cluster, result.Pipeline, result.Subscription); poll != nil {
+			*poll = result
+			continue
+		}
+		merged = append(merged, result)
+	}
+	slices.SortFunc(merged, func(a, b gnmicv1alpha1.TargetPoll) int {
+		return strings.Compare(a.Cluster+" "+a.Pipeline+" "+a.Subscription, b.Cluster+" "+b.Pipeline+" "+b.Subscription)
+	})
+	if len(merged) == 0 {
+		merged = nil
+	}
+	return merged, true
+}
+
+// sendPoll polls a target on the POLL Subscribe stream of its subscription,
+// held on the gNMI server of the pod collecting it.
+func (r *SubscriptionPollReconciler) sendPoll(ctx context.Context, cluster *gnmicv1alpha1.Cluster, task *pollTask) error {
+	if task.config == nil {
+		return fmt.Errorf("subscription %s is not in the plan of cluster %s", task.subscriptionNN, cluster.Name)
+	}
+	req, err := gnmic.BuildPollSubscribeRequest(task.config, task.targetNN)
+	if err != nil {
+		return fmt.Errorf("invalid subscription: %w", err)
+	}
+	dial := r.dial
+	if dial == nil {
+		dial = podDialer(r.Client)
+	}
+	ctx, cancel := context.WithTimeout(ctx, pollTimeout)
+	defer cancel()
+	return r.streams.poll(ctx, r.runContext(), dial, cluster, task, req)
+}
+
+func (r *SubscriptionPollReconciler) clock() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
+// runContext returns the context of the runs and the poll streams.
+func (r *SubscriptionPollReconciler) runContext() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SubscriptionPollReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.done = make(chan event.GenericEvent, pollDoneBufferSize)
+	ctx, cancel := context.WithCancel(context.Background())
+	r.ctx = ctx
+	// the runs in flight and the poll streams end with the manager
+	if err := mgr.Add(manager.RunnableFunc(func(mgrCtx context.Context) error {
+		<-mgrCtx.Done()
+		cancel()
+		r.wg.Wait()
+		r.streams.closeAll()
+		return nil
+	})); err != nil {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&gnmicv1alpha1.Cluster{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(
+			&gnmicv1alpha1.Subscription{},
+			handler.EnqueueRequestsFromMapFunc(r.findClustersForSubscription),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		// clusters whose polls completed
+		WatchesRawSource(source.Channel(r.done, &handler.EnqueueRequestForObject{})).
+		Named("subscriptionpoll").
+		Complete(r)
+}
+
+// findClustersForSubscription returns the clusters of the namespace of a POLL
+// Subscription, so that a changed schedule takes effect right away.
+func (r *SubscriptionPollReconciler) findClustersForSubscription(ctx context.Context, obj client.Object) []reconcile.Request {
+	subscription, ok := obj.(*gnmicv1alpha1.Subscription)
+	if !ok || subscription.Spec.Mode != "POLL" {
+		return nil
+	}
+	var clusters gnmicv1alpha1.ClusterList
+	if err := r.List(ctx, &clusters, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(clusters.Items))
+	for _, cluster := range clusters.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}})
+	}
+	return requests
+}
+
+// newPollSchedule returns when the targets of a POLL subscription are polled,
+// or nil when the operator does not poll them.
+func newPollSchedule(spec *gnmicv1alpha1.SubscriptionSpec, now time.Time) (*pollSchedule, error) {
+	if spec.Mode != "POLL" {
+		return nil, nil
+	}
+	schedule := &pollSchedule{interval: spec.PollInterval.Duration}
+	switch {
+	case spec.PollSchedule != "":
+		cron, err := utils.ParseCron(spec.PollSchedule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pollSchedule: %w", err)
+		}
+		schedule.interval, schedule.cron = 0, cron
+	case schedule.interval <= 0:
+		return nil, nil
+	}
+
+	if spec.PollJitter != nil {
+		schedule.jitter = spec.PollJitter.Duration
+	} else {
+		first := schedule.fire(now)
+		schedule.jitter = schedule.fire(first).Sub(first) / defaultPollJitterDivisor
+	}
+	return schedule, nil
+}
+
+// fire returns the first time after t the schedule fires. Intervals are
+// counted from the Unix epoch, so that they do not move when the operator
+// restarts.
+func (s *pollSchedule) fire(t time.Time) time.Time {
+	if s.cron != nil {
+		return s.cron.Next(t.UTC())
+	}
+	return t.Truncate(s.interval).Add(s.interval)
+}
+
+// next returns when a target is polled next: the first time the schedule
+// fires after its last poll, shifted by the offset of the target. A target
+// never polled is polled when the schedule next fires, or right away if it
+// fired within the batch window, and one whose poll was missed, e.g. while the
+// operator was down, is polled right away. A poll sent early, within the batch
+// window, counts for the time it was due.
+func (s *pollSchedule) next(key string, last, now time.Time) time.Time {
+	from := now.Add(-pollBatchWindow)
+	if !last.IsZero() && !last.After(now) {
+		from = last.Add(pollBatchWindow)
+	}
+	offset := s.offset(key)
+	fire := s.fire(from.Add(-offset))
+	if fire.IsZero() {
+		return fire
+	}
+	return fire.Add(offset)
+}
+
+// offset spreads the polls of the targets over the jitter window. It is
+// derived from the target and subscription, so that a target keeps its slot
+// from one poll to the next.
+func (s *pollSchedule) offset(key string) time.Duration {
+	if s.jitter <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return time.Duration(h.Sum64() % uint64(s.jitter))
+}
+
+func findTargetPoll(polls []gnmicv1alpha1.TargetPoll, cluster, pipeline, subscription string) *gnmicv1alpha1.TargetPoll {
+	for i := range polls {
+		if polls[i].Cluster == cluster && polls[i].Pipeline == pipeline && polls[i].Subscription == subscription {
+			return &polls[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,374 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	gapi "github.com/openconfig/gnmic/pkg/api/types"
+	"google.golang.org/grpc"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+func TestSubscriptionPollReconciler(t *testing.T) {
+	ctx := context.Background()
+	scheme := secretWatchScheme(t)
+
+	leaf1 := target("leaf1", "default", nil)
+	leaf1.Status.ClusterStates = map[string]gnmicv1alpha1.ClusterTargetState{"c1": {Pod: "gnmic-c1-0"}}
+	leaf2 := target("leaf2", "default", nil)
+	leaf3 := target("leaf3", "default", nil)
+	leaf3.Status.Polls = []gnmicv1alpha1.TargetPoll{
+		{Cluster: "c1", Pipeline: "p1", Subscription: "removed", Result: TargetPollResultSucceeded},
+		{Cluster: "c2", Pipeline: "p2", Subscription: "other", Result: TargetPollResultSucceeded},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(
+			&gnmicv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "default", Generation: 1}},
+			&gnmicv1alpha1.Subscription{
+				ObjectMeta: metav1.ObjectMeta{Name: "poll", Namespace: "default"},
+				Spec: gnmicv1alpha1.SubscriptionSpec{
+					Paths:        []string{"/system/state"},
+					Mode:         "POLL",
+					PollInterval: metav1.Duration{Duration: time.Minute},
+					PollJitter:   &metav1.Duration{},
+				},
+			},
+			&gnmicv1alpha1.Subscription{
+				ObjectMeta: metav1.ObjectMeta{Name: "stream", Namespace: "default"},
+				Spec:       gnmicv1alpha1.SubscriptionSpec{Paths: []string{"/interfaces"}, Mode: "STREAM/SAMPLE"},
+			},
+			leaf1, leaf2, leaf3,
+		).
+		WithStatusSubresource(&gnmicv1alpha1.Target{}).
+		Build()
+
+	clusters := NewClusterReconcilerForTest()
+	clusters.CachePlan("default", "c1", &gnmic.ApplyPlan{
+		Subscriptions: map[string]*gapi.SubscriptionConfig{
+			"default/p1/poll":   {Mode: "POLL"},
+			"default/p1/stream": {Mode: "STREAM", StreamMode: "SAMPLE"},
+		},
+		Targets: map[string]*gapi.TargetConfig{
+			"default/leaf1": {Subscriptions: []string{"default/p1/poll", "default/p1/stream"}},
+			"default/leaf2": {Subscriptions: []string{"default/p1/poll"}},
+		},
+	})
+
+	var mu sync.Mutex
+	var polled []string
+	var pollErr error
+	now := time.Date(2026, 1, 1, 12, 0, 10, 0, time.UTC)
+	r := &SubscriptionPollReconciler{
+		Client:   c,
+		Scheme:   scheme,
+		Clusters: clusters,
+		poll: func(_ context.Context, _ *gnmicv1alpha1.Cluster, task *pollTask) error {
+			mu.Lock()
+			defer mu.Unlock()
+			polled = append(polled, task.pod+" "+task.targetNN+" "+task.subscriptionNN)
+			return pollErr
+		},
+		now: func() time.Time { return now },
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "c1", Namespace: "default"}}
+	reconcile := func(wantRequeue time.Duration) {
+		t.Helper()
+		result, err := r.Reconcile(ctx, req)
+		if err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+		// the polls run in the background
+		r.wg.Wait()
+		if result.RequeueAfter != wantRequeue {
+			t.Fatalf("RequeueAfter = %s, want %s", result.RequeueAfter, wantRequeue)
+		}
+	}
+	polls := func(name string) []gnmicv1alpha1.TargetPoll {
+		t.Helper()
+		var target gnmicv1alpha1.Target
+		if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, &target); err != nil {
+			t.Fatal(err)
+		}
+		return target.Status.Polls
+	}
+
+	// the targets are first polled when the interval next ends
+	reconcile(50 * time.Second)
+	if len(polled) != 0 {
+		t.Fatalf("polled = %v, want none", polled)
+	}
+	// the polls of subscriptions the targets no longer have are dropped
+	if got := polls("leaf3"); len(got) != 1 || got[0].Cluster != "c2" {
+		t.Fatalf("leaf3 polls = %+v", got)
+	}
+
+	now = time.Date(2026, 1, 1, 12, 1, 0, 0, time.UTC)
+	reconcile(time.Minute)
+	if !slices.Equal(polled, []string{"gnmic-c1-0 default/leaf1 default/p1/poll"}) {
+		t.Fatalf("polled = %v", polled)
+	}
+	got := polls("leaf1")
+	if len(got) != 1 || got[0].Result != TargetPollResultSucceeded || got[0].Pod != "gnmic-c1-0" ||
+		got[0].Pipeline != "p1" || got[0].Subscription != "poll" || !got[0].LastPollTime.Time.Equal(now) {
+		t.Fatalf("leaf1 polls = %+v", got)
+	}
+	if got := polls("leaf2"); len(got) != 1 || got[0].Result != TargetPollResultFailed || got[0].Message != "target is not assigned to a pod of cluster c1" {
+		t.Fatalf("leaf2 polls = %+v", got)
+	}
+
+	// a target just polled is not polled again before the next interval
+	polled = nil
+	now = now.Add(10 * time.Second)
+	reconcile(50 * time.Second)
+	if len(polled) != 0 {
+		t.Fatalf("polled = %v, want none", polled)
+	}
+
+	pollErr = errors.New("the poll stream ended: unknown target")
+	now = now.Add(50 * time.Second)
+	reconcile(time.Minute)
+	if got := polls("leaf1"); got[0].Result != TargetPollResultFailed || got[0].Message != pollErr.Error() {
+		t.Fatalf("leaf1 polls = %+v", got)
+	}
+}
+
+// A poll recorded while another cluster records its own keeps both.
+func TestSubscriptionPollReconciler_PatchConflict(t *testing.T) {
+	ctx := context.Background()
+	leaf1 := target("leaf1", "default", nil)
+	leaf1.Status.Polls = []gnmicv1alpha1.TargetPoll{{Cluster: "c2", Pipeline: "p2", Subscription: "poll", Result: TargetPollResultSucceeded}}
+	conflicts := 0
+	c := fake.NewClientBuilder().WithScheme(secretWatchScheme(t)).
+		WithObjects(leaf1).
+		WithStatusSubresource(&gnmicv1alpha1.Target{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourcePatch: func(ctx context.Context, c client.Client, subResource string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+				if conflicts == 0 {
+					conflicts++
+					// cluster c3 records its poll first
+					var other gnmicv1alpha1.Target
+					if err := c.Get(ctx, client.ObjectKeyFromObject(obj), &other); err != nil {
+						return err
+					}
+					other.Status.Polls = append(other.Status.Polls, gnmicv1alpha1.TargetPoll{Cluster: "c3", Pipeline: "p3", Subscription: "poll", Result: TargetPollResultFailed})
+					if err := c.Status().Update(ctx, &other); err != nil {
+						return err
+					}
+				}
+				return c.SubResource(subResource).Patch(ctx, obj, patch, opts...)
+			},
+		}).
+		Build()
+	r := &SubscriptionPollReconciler{Client: c}
+
+	task := &pollTask{targetNN: "default/leaf1", pipeline: "p1", subscription: "poll", result: gnmicv1alpha1.TargetPoll{
+		Cluster: "c1", Pipeline: "p1", Subscription: "poll", Result: TargetPollResultSucceeded,
+	}}
+	cluster := &gnmicv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "default"}}
+	if err := r.patchPolls(ctx, cluster, map[string]*gnmicv1alpha1.Target{"default/leaf1": leaf1}, []*pollTask{task}, []*pollTask{task}); err != nil {
+		t.Fatal(err)
+	}
+	var got gnmicv1alpha1.Target
+	if err := c.Get(ctx, types.NamespacedName{Name: "leaf1", Namespace: "default"}, &got); err != nil {
+		t.Fatal(err)
+	}
+	var clusters []string
+	for _, poll := range got.Status.Polls {
+		clusters = append(clusters, poll.Cluster)
+	}
+	if conflicts != 1 || !slices.Equal(clusters, []string{"c1", "c2", "c3"}) {
+		t.Fatalf("conflicts = %d, polls of clusters %v, want c1 c2 c3", conflicts, clusters)
+	}
+}
+
+func TestPollSchedule(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 30, 0, time.UTC)
+
+	schedule, err := newPollSchedule(&gnmicv1alpha1.SubscriptionSpec{Mode: "POLL", PollInterval: metav1.Duration{Duration: 10 * time.Minute}}, now)
+	if err != nil || schedule == nil {
+		t.Fatalf("newPollSchedule() = %v, %v", schedule, err)
+	}
+	// a tenth of the interval by default
+	if schedule.jitter != time.Minute {
+		t.Fatalf("jitter = %s, want 1m", schedule.jitter)
+	}
+	slots := make(map[time.Duration]struct{})
+	for _, key := range []string{"default/leaf1", "default/leaf2", "default/leaf3", "default/leaf4"} {
+		next := schedule.next(key, time.Time{}, now)
+		// the polls fall within the jitter window after the interval ends
+		offset := next.Sub(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)) % (10 * time.Minute)
+		if !next.After(now) || next.Sub(now) > 10*time.Minute || offset >= time.Minute {
+			t.Fatalf("%s: next = %s, outside of the jitter window", key, next)
+		}
+		// a target keeps its slot from one poll to the next
+		if again := schedule.next(key, next, next); again.Sub(next) != 10*time.Minute {
+			t.Fatalf("%s: next after %s = %s", key, next, again)
+		}
+		slots[offset] = struct{}{}
+	}
+	if len(slots) < 2 {
+		t.Fatalf("the polls were not spread: %v", slots)
+	}
+	// a missed poll is due right away
+	if next := schedule.next("default/leaf1", now.Add(-time.Hour), now); next.After(now) {
+		t.Fatalf("missed poll due at %s, after %s", next, now)
+	}
+
+	schedule, err = newPollSchedule(&gnmicv1alpha1.SubscriptionSpec{Mode: "POLL", PollSchedule: "0 * * * *"}, now)
+	if err != nil || schedule.jitter != 6*time.Minute {
+		t.Fatalf("newPollSchedule() = %+v, %v", schedule, err)
+	}
+	schedule.jitter = 0
+	if next := schedule.next("default/leaf1", time.Time{}, now); !next.Equal(time.Date(2026, 1, 1, 13, 0, 0, 0, time.UTC)) {
+		t.Fatalf("next = %s", next)
+	}
+
+	for _, spec := range []gnmicv1alpha1.SubscriptionSpec{
+		{Mode: "POLL"},
+		{Mode: "STREAM/SAMPLE", PollInterval: metav1.Duration{Duration: time.Minute}},
+	} {
+		if schedule, err := newPollSchedule(&spec, now); schedule != nil || err != nil {
+			t.Fatalf("newPollSchedule(%+v) = %+v, %v, want nil", spec, schedule, err)
+		}
+	}
+	if _, err := newPollSchedule(&gnmicv1alpha1.SubscriptionSpec{Mode: "POLL", PollSchedule: "every minute"}, now); err == nil {
+		t.Fatal("newPollSchedule() of an invalid schedule succeeded")
+	}
+}
+
+// fakePollServer answers the POLL subscriptions of a pod: a sync_response
+// to the SubscriptionList and to each Poll, or an error for the targets in
+// fail.
+type fakePollServer struct {
+	mu       sync.Mutex
+	requests []string
+	fail     map[string]bool
+	conns    int
+}
+
+type fakePollClient struct {
+	gnmi.GNMIClient
+	server *fakePollServer
+}
+
+type fakePollStream struct {
+	grpc.ClientStream
+	ctx    context.Context
+	server *fakePollServer
+	target string
+	rsps   chan error
+}
+
+func (s *fakePollServer) dial(_ context.Context, _ *gnmicv1alpha1.Cluster, pod string) (gnmi.GNMIClient, func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conns++
+	return &fakePollClient{server: s}, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.conns--
+	}, nil
+}
+
+func (c *fakePollClient) Subscribe(ctx context.Context, _ ...grpc.CallOption) (gnmi.GNMI_SubscribeClient, error) {
+	return &fakePollStream{ctx: ctx, server: c.server, rsps: make(chan error, 1)}, nil
+}
+
+func (s *fakePollStream) Send(req *gnmi.SubscribeRequest) error {
+	s.server.mu.Lock()
+	defer s.server.mu.Unlock()
+	if list := req.GetSubscribe(); list != nil {
+		if list.GetMode() != gnmi.SubscriptionList_POLL {
+			return errors.New("not a POLL subscription")
+		}
+		s.target = list.GetPrefix().GetTarget()
+		s.server.requests = append(s.server.requests, "subscribe "+s.target)
+	} else {
+		s.server.requests = append(s.server.requests, "poll "+s.target)
+	}
+	if s.server.fail[s.target] {
+		s.rsps <- errors.New("unknown target")
+	} else {
+		s.rsps <- nil
+	}
+	return nil
+}
+
+func (s *fakePollStream) Recv() (*gnmi.SubscribeResponse, error) {
+	select {
+	case err := <-s.rsps:
+		if err != nil {
+			return nil, err
+		}
+		return &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_SyncResponse{SyncResponse: true}}, nil
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+func TestSubscriptionPollReconciler_SendPoll(t *testing.T) {
+	ctx := context.Background()
+	server := &fakePollServer{fail: map[string]bool{"default/leaf2": true}}
+	r := &SubscriptionPollReconciler{dial: server.dial}
+	cluster := gnmiJobCluster(9339)
+	config := &gapi.SubscriptionConfig{Mode: "POLL", Paths: []string{"/system/state"}}
+	task := func(target, pod string) *pollTask {
+		return &pollTask{targetNN: target, subscriptionNN: "default/p1/poll", pod: pod, config: config}
+	}
+
+	// the first poll opens the stream, the next ones are sent on it
+	for range 2 {
+		if err := r.sendPoll(ctx, cluster, task("default/leaf1", "gnmic-c1-0")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.sendPoll(ctx, cluster, task("default/leaf2", "gnmic-c1-0")); err == nil || !strings.Contains(err.Error(), "unknown target") {
+		t.Fatalf("err = %v", err)
+	}
+	want := []string{"subscribe default/leaf1", "poll default/leaf1", "subscribe default/leaf2"}
+	if !slices.Equal(server.requests, want) || server.conns != 1 {
+		t.Fatalf("requests = %v on %d connections", server.requests, server.conns)
+	}
+
+	// a failed stream is opened again, a target moved to another pod gets a
+	// new stream and the connection to a pod closes with its last stream
+	server.requests = nil
+	delete(server.fail, "default/leaf2")
+	if err := r.sendPoll(ctx, cluster, task("default/leaf2", "gnmic-c1-1")); err != nil {
+		t.Fatal(err)
+	}
+	r.streams.retain(client.ObjectKeyFromObject(cluster), []*pollTask{task("default/leaf1", "gnmic-c1-1"), task("default/leaf2", "gnmic-c1-1")})
+	if err := r.sendPoll(ctx, cluster, task("default/leaf1", "gnmic-c1-1")); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"subscribe default/leaf2", "subscribe default/leaf1"}
+	if !slices.Equal(server.requests, want) || server.conns != 1 {
+		t.Fatalf("requests = %v on %d connections", server.requests, server.conns)
+	}
+
+	r.streams.closeAll()
+	if server.conns != 0 {
+		t.Fatalf("%d connections left open", server.conns)
+	}
+
+	// the pods are polled through their gNMI server only
+	cluster.Spec.API.TLS = nil
+	r.dial = nil
+	if err := r.sendPoll(ctx, cluster, task("default/leaf1", "gnmic-c1-0")); !errors.Is(err, errGNMIServerDisabled) {
+		t.Fatalf("err = %v, want %v", err, errGNMIServerDisabled)
+	}
+}
@@ -0,0 +1,235 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/pkg/api"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+)
+
+// pollStreams holds the POLL Subscribe streams the targets are polled on,
+// one per target and subscription, open on the gNMI server of the pod
+// collecting the target. The streams to a pod share one connection.
+type pollStreams struct {
+	mu sync.Mutex
+	// conns holds the connections to the pods, by cluster and pod
+	conns map[pollConnKey]*pollConn
+	// streams holds the streams of each cluster, by pollTask.key
+	streams map[types.NamespacedName]map[string]*pollStream
+}
+
+type pollConnKey struct {
+	cluster types.NamespacedName
+	pod     string
+}
+
+// pollConn is a connection to the gNMI server of a pod, closed with its last
+// stream.
+type pollConn struct {
+	client  gnmi.GNMIClient
+	close   func()
+	streams int
+}
+
+// pollStream is a POLL Subscribe stream of a target. The target is polled
+// once when the stream opens, then on each Poll request sent on it; each poll
+// ends with a sync_response.
+type pollStream struct {
+	conn   pollConnKey
+	req    *gnmi.SubscribeRequest
+	stream gnmi.GNMI_SubscribeClient
+	cancel context.CancelFunc
+	// opened is set until the first poll consumes the sync_response of the
+	// poll sent when the stream opened
+	opened bool
+	// syncs signals the sync_responses received
+	syncs chan struct{}
+	// done is closed once the stream ends, with err
+	done chan struct{}
+	err  error
+}
+
+// poll polls the target of a stream and waits for the end of its response.
+func (s *pollStream) poll(ctx context.Context) error {
+	if s.opened {
+		s.opened = false
+	} else {
+		// drop the sync_response of a poll that timed out
+		select {
+		case <-s.syncs:
+		default:
+		}
+		poll, err := api.NewSubscribePollRequest()
+		if err != nil {
+			return err
+		}
+		if err := s.stream.Send(poll); err != nil {
+			return fmt.Errorf("failed to send the poll: %w", err)
+		}
+	}
+	select {
+	case <-s.syncs:
+		return nil
+	case <-s.done:
+		return s.err
+	case <-ctx.Done():
+		return fmt.Errorf("no response to the poll: %w", ctx.Err())
+	}
+}
+
+// receive reads the responses of a stream until it ends. The updates are
+// collected by the pod, the poll only waits for their end.
+func (s *pollStream) receive() {
+	defer close(s.done)
+	for {
+		rsp, err := s.stream.Recv()
+		if err != nil {
+			s.err = fmt.Errorf("the poll stream ended: %w", err)
+			return
+		}
+		if rsp.GetSyncResponse() {
+			select {
+			case s.syncs <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// poll polls a target for a subscription on its stream, opening the stream
+// first when the target has none on the pod of the task, or the request of
+// its stream changed. The streams live in streamCtx, on pods dialed with dial.
+func (p *pollStreams) poll(ctx, streamCtx context.Context, dial gnmiPodDialer, cluster *gnmicv1alpha1.Cluster, task *pollTask, req *gnmi.SubscribeRequest) error {
+	s, err := p.stream(ctx, streamCtx, dial, cluster, task, req)
+	if err != nil {
+		return err
+	}
+	if err := s.poll(ctx); err != nil {
+		// the next poll opens a new stream
+		p.closeStream(client.ObjectKeyFromObject(cluster), task.key(), s)
+		return err
+	}
+	return nil
+}
+
+// stream returns the open stream of a task, or opens it.
+func (p *pollStreams) stream(ctx, streamCtx context.Context, dial gnmiPodDialer, cluster *gnmicv1alpha1.Cluster, task *pollTask, req *gnmi.SubscribeRequest) (*pollStream, error) {
+	clusterNN := client.ObjectKeyFromObject(cluster)
+	connKey := pollConnKey{cluster: clusterNN, pod: task.pod}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if s, ok := p.streams[clusterNN][task.key()]; ok {
+		select {
+		case <-s.done:
+		default:
+			if s.conn == connKey && proto.Equal(s.req, req) {
+				return s, nil
+			}
+		}
+		p.closeStreamLocked(clusterNN, task.key(), s)
+	}
+
+	conn, ok := p.conns[connKey]
+	if !ok {
+		c, closeClient, err := dial(ctx, cluster, task.pod)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to pod %s: %w", task.pod, err)
+		}
+		conn = &pollConn{client: c, close: closeClient}
+		if p.conns == nil {
+			p.conns = make(map[pollConnKey]*pollConn)
+			p.streams = make(map[types.NamespacedName]map[string]*pollStream)
+		}
+		p.conns[connKey] = conn
+	}
+	sctx, cancel := context.WithCancel(streamCtx)
+	stream, err := conn.client.Subscribe(sctx)
+	if err == nil {
+		err = stream.Send(req)
+	}
+	if err != nil {
+		cancel()
+		if conn.streams == 0 {
+			conn.close()
+			delete(p.conns, connKey)
+		}
+		return nil, fmt.Errorf("failed to open the poll stream on pod %s: %w", task.pod, err)
+	}
+	conn.streams++
+	s := &pollStream{
+		conn:   connKey,
+		req:    req,
+		stream: stream,
+		cancel: cancel,
+		opened: true,
+		syncs:  make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	go s.receive()
+	if p.streams[clusterNN] == nil {
+		p.streams[clusterNN] = make(map[string]*pollStream)
+	}
+	p.streams[clusterNN][task.key()] = s
+	return s, nil
+}
+
+// retain closes the streams of a cluster but the ones of the tasks polled on
+// the pods they were opened on.
+func (p *pollStreams) retain(clusterNN types.NamespacedName, tasks []*pollTask) {
+	pods := make(map[string]string, len(tasks))
+	for _, task := range tasks {
+		pods[task.key()] = task.pod
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, s := range p.streams[clusterNN] {
+		if pod, ok := pods[key]; !ok || pod != s.conn.pod {
+			p.closeStreamLocked(clusterNN, key, s)
+		}
+	}
+}
+
+// closeAll closes the streams of all the clusters.
+func (p *pollStreams) closeAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for clusterNN, streams := range p.streams {
+		for key, s := range streams {
+			p.closeStreamLocked(clusterNN, key, s)
+		}
+	}
+}
+
+func (p *pollStreams) closeStream(clusterNN types.NamespacedName, key string, s *pollStream) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closeStreamLocked(clusterNN, key, s)
+}
+
+// closeStreamLocked closes a stream, and its connection when it is the last
+// stream to its pod.
+func (p *pollStreams) closeStreamLocked(clusterNN types.NamespacedName, key string, s *pollStream) {
+	if p.streams[clusterNN][key] != s {
+		// already closed
+		return
+	}
+	s.cancel()
+	delete(p.streams[clusterNN], key)
+	if len(p.streams[clusterNN]) == 0 {
+		delete(p.streams, clusterNN)
+	}
+	if conn, ok := p.conns[s.conn]; ok {
+		if conn.streams--; conn.streams == 0 {
+			conn.close()
+			delete(p.conns, s.conn)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+const targetTLSVolumeName = "target-tls"
+
+// trustBundleGVK is the trust-manager Bundle, read as unstructured to avoid
+// depending on trust-manager.
+var trustBundleGVK = schema.GroupVersionKind{Group: "trust.cert-manager.io", Version: "v1alpha1", Kind: "Bundle"}
+
+// resolveTargetTLS returns the volume projections mounting the CA bundle,
+// client certificate and proto files of a TargetProfile under its
+// gnmic.TargetTLSProfileDir.
+// The referenced objects live in the namespace of the profile, which is the
+// namespace of the cluster, so they are projected as is: nothing is copied.
+// When an object or one of its keys is missing, the unresolved reference is
+// returned and the profile must not be used.
+func (r *ClusterReconciler) resolveTargetTLS(ctx context.Context, profile *gnmicv1alpha1.TargetProfile) ([]corev1.VolumeProjection, *gnmicv1alpha1.UnresolvedReference, error) {
+	profileNN := profile.Namespace + gnmic.Delimiter + profile.Name
+	dir := gnmic.TargetTLSProfileDir(profileNN)
+	var projections []corev1.VolumeProjection
+
+	if proto := profile.Spec.Proto; proto != nil {
+		var cm corev1.ConfigMap
+		unresolved, err := getTargetTLSObject(ctx, r.Client, profile, "ConfigMap", proto.ConfigMapName, &cm)
+		if unresolved != nil || err != nil {
+			return nil, unresolved, err
+		}
+		// all keys are projected so that the files can import each other
+		keys := slices.Collect(maps.Keys(cm.Data))
+		keys = slices.AppendSeq(keys, maps.Keys(cm.BinaryData))
+		slices.Sort(keys)
+		for _, file := range proto.Files {
+			if !slices.Contains(keys, file) {
+				return nil, missingTargetTLSKey(profile, "ConfigMap", proto.ConfigMapName, file), nil
+			}
+		}
+		items := make([]corev1.KeyToPath, 0, len(keys))
+		for _, key := range keys {
+			items = append(items, corev1.KeyToPath{Key: key, Path: path.Join(dir, gnmic.TargetProtoDir, key)})
+		}
+		projections = append(projections, corev1.VolumeProjection{ConfigMap: &corev1.ConfigMapProjection{
+			LocalObjectReference: corev1.LocalObjectReference{Name: proto.ConfigMapName},
+			Items:                items,
+			Optional:             ptr.To(true),
+		}})
+	}
+
+	if profile.Spec.TLS == nil {
+		return projections, nil, nil
+	}
+
+	if ref := profile.Spec.TLS.CABundleRef; ref != nil {
+		caFile := path.Join(dir, gnmic.TargetTLSCAFile)
+		configMap, secret := ref.ConfigMap, ref.Secret
+		if ref.Bundle != "" {
+			var unresolved *gnmicv1alpha1.UnresolvedReference
+			var err error
+			configMap, secret, unresolved, err = resolveTrustBundleTarget(ctx, r.Client, profile, ref.Bundle)
+			if unresolved != nil || err != nil {
+				return nil, unresolved, err
+			}
+		}
+		switch {
+		case configMap != nil:
+			var cm corev1.ConfigMap
+			unresolved, err := getTargetTLSObject(ctx, r.Client, profile, "ConfigMap", configMap.Name, &cm)
+			if unresolved != nil || err != nil {
+				return nil, unresolved, err
+			}
+			_, inData := cm.Data[configMap.Key]
+			_, inBinaryData := cm.BinaryData[configMap.Key]
+			if !inData && !inBinaryData {
+				return nil, missingTargetTLSKey(profile, "ConfigMap", configMap.Name, configMap.Key), nil
+			}
+			projections = append(projections, corev1.VolumeProjection{ConfigMap: &corev1.ConfigMapProjection{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMap.Name},
+				Items:                []corev1.KeyToPath{{Key: configMap.Key, Path: caFile}},
+				Optional:             ptr.To(true),
+			}})
+		case secret != nil:
+			var s corev1.Secret
+			unresolved, err := getTargetTLSObject(ctx, r.Client, profile, "Secret", secret.Name, &s)
+			if unresolved != nil || err != nil {
+				return nil, unresolved, err
+			}
+			if _, ok := s.Data[secret.Key]; !ok {
+				return nil, missingTargetTLSKey(profile, "Secret", secret.Name, secret.Key), nil
+			}
+			projections = append(projections, corev1.VolumeProjection{Secret: &corev1.SecretProjection{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secret.Name},
+				Items:                []corev1.KeyToPath{{Key: secret.Key, Path: caFile}},
+				Optional:             ptr.To(true),
+			}})
+		}
+	}
+
+	if name := profile.Spec.TLS.ClientCertificateRef; name != "" {
+		var s corev1.Secret
+		unresolved, err := getTargetTLSObject(ctx, r.Client, profile, "Secret", name, &s)
+		if unresolved != nil || err != nil {
+			return nil, unresolved, err
+		}
+		for _, key := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+			if _, ok := s.Data[key]; !ok {
+				return nil, missingTargetTLSKey(profile, "Secret", name, key), nil
+			}
+		}
+		projections = append(projections, corev1.VolumeProjection{Secret: &corev1.SecretProjection{
+			LocalObjectReference: corev1.LocalObjectReference{Name: name},
+			Items: []corev1.KeyToPath{
+				{Key: corev1.TLSCertKey, Path: path.Join(dir, gnmic.TargetTLSCertFile)},
+				{Key: corev1.TLSPrivateKeyKey, Path: path.Join(dir, gnmic.TargetTLSKeyFile)},
+			},
+			Optional: ptr.To(true),
+		}})
+	}
+	return projections, nil, nil
+}
+
+// resolveTrustBundleTarget returns the ConfigMap or Secret trust-manager syncs
+// a Bundle to. Both are named after the Bundle.
+func resolveTrustBundleTarget(ctx context.Context, c client.Reader, profile *gnmicv1alpha1.TargetProfile, name string) (*corev1.ConfigMapKeySelector, *corev1.SecretKeySelector, *gnmicv1alpha1.UnresolvedReference, error) {
+	bundle := &unstructured.Unstructured{}
+	bundle.SetGroupVersionKind(trustBundleGVK)
+	unresolved, err := getTargetTLSObject(ctx, c, profile, trustBundleGVK.Kind, name, bundle)
+	if unresolved != nil || err != nil {
+		return nil, nil, unresolved, err
+	}
+	if key, _, _ := unstructured.NestedString(bundle.Object, "spec", "target", "configMap", "key"); key != "" {
+		return &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: key}, nil, nil, nil
+	}
+	if key, _, _ := unstructured.NestedString(bundle.Object, "spec", "target", "secret", "key"); key != "" {
+		return nil, &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: key}, nil, nil
+	}
+	return nil, nil, &gnmicv1alpha1.UnresolvedReference{
+		Kind: trustBundleGVK.Kind, Name: name,
+		Message: fmt.Sprintf("CA bundle of TargetProfile %s has no ConfigMap or Secret target", profile.Name),
+	}, nil
+}
+
+// getTargetTLSObject fetches an object referenced by the TLS configuration or
+// the proto files of a TargetProfile, returning the unresolved reference when
+// it does not exist.
+func getTargetTLSObject(ctx context.Context, c client.Reader, profile *gnmicv1alpha1.TargetProfile, kind, name string, obj client.Object) (*gnmicv1alpha1.UnresolvedReference, error) {
+	err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: profile.Namespace}, obj)
+	if err == nil {
+		return nil, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	return &gnmicv1alpha1.UnresolvedReference{
+		Kind: kind, Name: name, Message: fmt.Sprintf("%s referenced by TargetProfile %s not found", kind, profile.Name),
+	}, nil
+}
+
+func missingTargetTLSKey(profile *gnmicv1alpha1.TargetProfile, kind, name, key string) *gnmicv1alpha1.UnresolvedReference {
+	return &gnmicv1alpha1.UnresolvedReference{
+		Kind: kind, Name: name, Message: fmt.Sprintf("key %q required by TargetProfile %s not found", key, profile.Name),
+	}
+}
+
+// targetTLSProjections collects the TLS projections of the profiles used by
+// the pipelines, once per profile and sorted so the pod template is stable.
+func targetTLSProjections(pipelineDataMap map[string]*gnmic.PipelineData) []corev1.VolumeProjection {
+	byProfile := make(map[string][]corev1.VolumeProjection)
+	for _, pipelineData := range pipelineDataMap {
+		for profileNN, projections := range pipelineData.ResolvedTargetTLS {
+			byProfile[profileNN] = projections
+		}
+	}
+	profiles := slices.Sorted(maps.Keys(byProfile))
+	var result []corev1.VolumeProjection
+	for _, profileNN := range profiles {
+		result = append(result, byProfile[profileNN]...)
+	}
+	return result
+}
+
+// keepMountedTargetTLS adds to targetTLS the projections that the statefulset
+// of the cluster mounts for other profiles. It is used when some pipelines
+// could not be resolved, so that the files of their profiles stay mounted
+// instead of rolling the pods without them.
+func (r *ClusterReconciler) keepMountedTargetTLS(ctx context.Context, cluster *gnmicv1alpha1.Cluster, targetTLS []corev1.VolumeProjection) ([]corev1.VolumeProjection, error) {
+	var statefulSet appsv1.StatefulSet
+	err := r.Get(ctx, types.NamespacedName{Name: resourcePrefix + cluster.Name, Namespace: cluster.Namespace}, &statefulSet)
+	if apierrors.IsNotFound(err) {
+		return targetTLS, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	byDir := make(map[string][]corev1.VolumeProjection)
+	for _, projection := range targetTLS {
+		dir := projectionProfileDir(projection)
+		byDir[dir] = append(byDir[dir], projection)
+	}
+	resolved := make(map[string]struct{}, len(byDir))
+	for dir := range byDir {
+		resolved[dir] = struct{}{}
+	}
+	for _, volume := range statefulSet.Spec.Template.Spec.Volumes {
+		if volume.Name != targetTLSVolumeName || volume.Projected == nil {
+			continue
+		}
+		for _, projection := range volume.Projected.Sources {
+			dir := projectionProfileDir(projection)
+			if _, ok := resolved[dir]; !ok {
+				byDir[dir] = append(byDir[dir], projection)
+			}
+		}
+	}
+	var result []corev1.VolumeProjection
+	for _, dir := range slices.Sorted(maps.Keys(byDir)) {
+		result = append(result, byDir[dir]...)
+	}
+	return result, nil
+}
+
+// projectionProfileDir returns the gnmic.TargetTLSProfileDir a projection of
+// resolveTargetTLS mounts its files under.
+func projectionProfileDir(projection corev1.VolumeProjection) string {
+	var items []corev1.KeyToPath
+	switch {
+	case projection.ConfigMap != nil:
+		items = projection.ConfigMap.Items
+	case projection.Secret != nil:
+		items = projection.Secret.Items
+	}
+	if len(items) == 0 {
+		return ""
+	}
+	dir, _, _ := strings.Cut(items[0].Path, "/")
+	return dir
+}
+
+// referencesTargetTLSObject reports whether the TLS configuration or the proto
+// files of a TargetProfile reference the ConfigMap or Secret.
+func referencesTargetTLSObject(profile *gnmicv1alpha1.TargetProfile, kind, name string) bool {
+	if proto := profile.Spec.Proto; proto != nil && kind == "ConfigMap" && proto.ConfigMapName == name {
+		return true
+	}
+	tls := profile.Spec.TLS
+	if tls == nil {
+		return false
+	}
+	if kind == "Secret" && tls.ClientCertificateRef == name {
+		return true
+	}
+	ref := tls.CABundleRef
+	if ref == nil {
+		return false
+	}
+	switch kind {
+	case "ConfigMap":
+		return ref.Bundle == name || (ref.ConfigMap != nil && ref.ConfigMap.Name == name)
+	case "Secret":
+		return ref.Bundle == name || (ref.Secret != nil && ref.Secret.Name == name)
+	}
+	return false
+}
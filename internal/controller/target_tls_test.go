@@ -0,0 +1,288 @@
+package controller
+
+import (
+	"context"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+func tlsProfile(name string, tls *gnmicv1alpha1.TargetTLSConfig) *gnmicv1alpha1.TargetProfile {
+	return &gnmicv1alpha1.TargetProfile{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       gnmicv1alpha1.TargetProfileSpec{TLS: tls},
+	}
+}
+
+func caConfigMap(name, key string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Data:       map[string]string{key: "-----BEGIN CERTIFICATE-----"},
+	}
+}
+
+func trustBundle(name string, target map[string]any) *unstructured.Unstructured {
+	bundle := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"target": target},
+	}}
+	bundle.SetGroupVersionKind(trustBundleGVK)
+	bundle.SetName(name)
+	bundle.SetNamespace("default")
+	return bundle
+}
+
+func TestResolveTargetTLS(t *testing.T) {
+	clientCert := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "client", Namespace: "default"},
+		Data:       map[string][]byte{"tls.crt": []byte("c"), "tls.key": []byte("k")},
+	}
+	r := reconcilerWith(t,
+		caConfigMap("devices-ca", "ca.pem"),
+		caConfigMap("devices", "bundle.pem"),
+		trustBundle("devices", map[string]any{"configMap": map[string]any{"key": "bundle.pem"}}),
+		trustBundle("notarget", map[string]any{}),
+		clientCert,
+		secret("creds"),
+	)
+	ctx := context.Background()
+
+	projections, unresolved, err := r.resolveTargetTLS(ctx, tlsProfile("arista", &gnmicv1alpha1.TargetTLSConfig{
+		CABundleRef: &gnmicv1alpha1.CABundleRef{ConfigMap: &corev1.ConfigMapKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "devices-ca"}, Key: "ca.pem",
+		}},
+		ClientCertificateRef: "client",
+	}))
+	if err != nil || unresolved != nil {
+		t.Fatalf("unexpected error %v, unresolved %+v", err, unresolved)
+	}
+	want := []corev1.VolumeProjection{
+		{ConfigMap: &corev1.ConfigMapProjection{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "devices-ca"},
+			Items:                []corev1.KeyToPath{{Key: "ca.pem", Path: "default_arista/ca.crt"}},
+			Optional:             ptr.To(true),
+		}},
+		{Secret: &corev1.SecretProjection{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "client"},
+			Items: []corev1.KeyToPath{
+				{Key: "tls.crt", Path: "default_arista/tls.crt"},
+				{Key: "tls.key", Path: "default_arista/tls.key"},
+			},
+			Optional: ptr.To(true),
+		}},
+	}
+	if !reflect.DeepEqual(projections, want) {
+		t.Fatalf("projections = %+v, want %+v", projections, want)
+	}
+
+	// a trust-manager Bundle resolves to its target ConfigMap
+	projections, unresolved, err = r.resolveTargetTLS(ctx, tlsProfile("nokia", &gnmicv1alpha1.TargetTLSConfig{
+		CABundleRef: &gnmicv1alpha1.CABundleRef{Bundle: "devices"},
+	}))
+	if err != nil || unresolved != nil {
+		t.Fatalf("unexpected error %v, unresolved %+v", err, unresolved)
+	}
+	if len(projections) != 1 || projections[0].ConfigMap.Name != "devices" || projections[0].ConfigMap.Items[0].Key != "bundle.pem" {
+		t.Fatalf("projections = %+v", projections)
+	}
+
+	unresolvedTests := []struct {
+		name     string
+		tls      *gnmicv1alpha1.TargetTLSConfig
+		wantKind string
+		wantMsg  string
+	}{
+		{
+			name:     "missing bundle",
+			tls:      &gnmicv1alpha1.TargetTLSConfig{CABundleRef: &gnmicv1alpha1.CABundleRef{Bundle: "gone"}},
+			wantKind: "Bundle",
+			wantMsg:  "not found",
+		},
+		{
+			name:     "bundle without target",
+			tls:      &gnmicv1alpha1.TargetTLSConfig{CABundleRef: &gnmicv1alpha1.CABundleRef{Bundle: "notarget"}},
+			wantKind: "Bundle",
+			wantMsg:  "no ConfigMap or Secret target",
+		},
+		{
+			name: "missing key",
+			tls: &gnmicv1alpha1.TargetTLSConfig{CABundleRef: &gnmicv1alpha1.CABundleRef{Secret: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "creds"}, Key: "ca.crt",
+			}}},
+			wantKind: "Secret",
+			wantMsg:  `key "ca.crt"`,
+		},
+		{
+			name:     "client certificate without key",
+			tls:      &gnmicv1alpha1.TargetTLSConfig{ClientCertificateRef: "creds"},
+			wantKind: "Secret",
+			wantMsg:  `key "tls.crt"`,
+		},
+	}
+	for _, tt := range unresolvedTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, unresolved, err := r.resolveTargetTLS(ctx, tlsProfile("p", tt.tls))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if unresolved == nil || unresolved.Kind != tt.wantKind || !strings.Contains(unresolved.Message, tt.wantMsg) {
+				t.Fatalf("unresolved = %+v, want %s %q", unresolved, tt.wantKind, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestResolveTargetTLS_ProtoFiles(t *testing.T) {
+	protos := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "eos-proto", Namespace: "default"},
+		Data:       map[string]string{"eos_ext.proto": "syntax", "common.proto": "syntax"},
+		BinaryData: map[string][]byte{"descriptor.bin": {0}},
+	}
+	r := reconcilerWith(t, protos)
+	profile := func(files ...string) *gnmicv1alpha1.TargetProfile {
+		p := tlsProfile("eos", nil)
+		p.Spec.Proto = &gnmicv1alpha1.TargetProtoConfig{ConfigMapName: "eos-proto", Files: files}
+		return p
+	}
+	ctx := context.Background()
+
+	// every key is mounted so the files can import each other
+	projections, unresolved, err := r.resolveTargetTLS(ctx, profile("eos_ext.proto"))
+	if err != nil || unresolved != nil {
+		t.Fatalf("unexpected error %v, unresolved %+v", err, unresolved)
+	}
+	want := []corev1.VolumeProjection{{ConfigMap: &corev1.ConfigMapProjection{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "eos-proto"},
+		Items: []corev1.KeyToPath{
+			{Key: "common.proto", Path: "default_eos/proto/common.proto"},
+			{Key: "descriptor.bin", Path: "default_eos/proto/descriptor.bin"},
+			{Key: "eos_ext.proto", Path: "default_eos/proto/eos_ext.proto"},
+		},
+		Optional: ptr.To(true),
+	}}}
+	if !reflect.DeepEqual(projections, want) {
+		t.Fatalf("projections = %+v, want %+v", projections, want)
+	}
+
+	_, unresolved, err = r.resolveTargetTLS(ctx, profile("eos_ext.proto", "missing.proto"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unresolved == nil || unresolved.Kind != "ConfigMap" || !strings.Contains(unresolved.Message, `key "missing.proto"`) {
+		t.Fatalf("unresolved = %+v, want the missing file", unresolved)
+	}
+	if !referencesTargetTLSObject(profile("eos_ext.proto"), "ConfigMap", "eos-proto") {
+		t.Fatal("proto ConfigMap not referenced by the profile")
+	}
+}
+
+func TestAddPipelineTargets_SkipsTargetsWithUnresolvedTLS(t *testing.T) {
+	r := reconcilerWith(t,
+		caConfigMap("devices-ca", "ca.crt"),
+		tlsProfile("ok", &gnmicv1alpha1.TargetTLSConfig{CABundleRef: &gnmicv1alpha1.CABundleRef{ConfigMap: &corev1.ConfigMapKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "devices-ca"}, Key: "ca.crt",
+		}}}),
+		tlsProfile("nocert", &gnmicv1alpha1.TargetTLSConfig{ClientCertificateRef: "missing"}),
+	)
+	pipeline := &gnmicv1alpha1.Pipeline{ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "default"}}
+	data := gnmic.NewPipelineData()
+	refs := &pipelineRefs{}
+	usage := newResourceUsage()
+
+	_, err := r.addPipelineTargets(context.Background(), pipeline,
+		[]gnmicv1alpha1.Target{refsTarget("t1", "ok"), refsTarget("t2", "nocert")}, data, refs, usage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Targets) != 1 || len(data.ResolvedTargetTLS["default/ok"]) != 1 {
+		t.Fatalf("targets %v, TLS %v; want t1 with the CA bundle of ok", data.Targets, data.ResolvedTargetTLS)
+	}
+	got := refs.sortedUnresolved()
+	if len(got) != 1 || got[0].Kind != "Secret" || got[0].Name != "missing" || !strings.Contains(got[0].Message, "1 targets skipped") {
+		t.Fatalf("unresolved = %+v", got)
+	}
+	if reason := usage.invalid[resourceKey{kind: resourceKindTargetProfile, name: "nocert"}]; reason == "" {
+		t.Fatal("profile with a missing client certificate not reported invalid")
+	}
+}
+
+func TestTargetTLSProjections_SortedAndDeduplicated(t *testing.T) {
+	projection := func(name string) []corev1.VolumeProjection {
+		return []corev1.VolumeProjection{{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: name}}}}
+	}
+	p1, p2 := gnmic.NewPipelineData(), gnmic.NewPipelineData()
+	p1.ResolvedTargetTLS["default/b"] = projection("b")
+	p1.ResolvedTargetTLS["default/a"] = projection("a")
+	p2.ResolvedTargetTLS["default/a"] = projection("a")
+
+	got := targetTLSProjections(map[string]*gnmic.PipelineData{"default/p1": p1, "default/p2": p2})
+	if len(got) != 2 || got[0].ConfigMap.Name != "a" || got[1].ConfigMap.Name != "b" {
+		t.Fatalf("projections = %+v, want a then b", got)
+	}
+}
+
+// The files of a profile whose pipeline could not be resolved stay mounted.
+func TestKeepMountedTargetTLS(t *testing.T) {
+	projection := func(name, profileNN string) corev1.VolumeProjection {
+		return corev1.VolumeProjection{ConfigMap: &corev1.ConfigMapProjection{
+			LocalObjectReference: corev1.LocalObjectReference{Name: name},
+			Items:                []corev1.KeyToPath{{Key: "ca.crt", Path: path.Join(gnmic.TargetTLSProfileDir(profileNN), gnmic.TargetTLSCAFile)}},
+		}}
+	}
+	cluster := &gnmicv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "default"}}
+	ctx := context.Background()
+
+	// nothing is mounted before the statefulset exists
+	r := reconcilerWith(t)
+	resolved := []corev1.VolumeProjection{projection("b-new", "default/b")}
+	if got, err := r.keepMountedTargetTLS(ctx, cluster, resolved); err != nil || !reflect.DeepEqual(got, resolved) {
+		t.Fatalf("projections = %+v, err %v", got, err)
+	}
+
+	r = reconcilerWith(t, &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "gnmic-c1", Namespace: "default"},
+		Spec: appsv1.StatefulSetSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+			Name: targetTLSVolumeName,
+			VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{
+				projection("a", "default/a"), projection("b-old", "default/b"), projection("c", "default/c"),
+			}}},
+		}}}}},
+	})
+	got, err := r.keepMountedTargetTLS(ctx, cluster, resolved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range got {
+		names = append(names, p.ConfigMap.Name)
+	}
+	if strings.Join(names, ",") != "a,b-new,c" {
+		t.Fatalf("projections = %v, want a,b-new,c", names)
+	}
+}
+
+// A CA bundle that appears after the profile was found unusable must reach
+// the clusters collecting with the profile.
+func TestFindClustersForConfigMap_ReachesCollectingCluster(t *testing.T) {
+	r := reconcilerWith(t,
+		tlsProfile("default", &gnmicv1alpha1.TargetTLSConfig{CABundleRef: &gnmicv1alpha1.CABundleRef{Bundle: "devices"}}),
+		target("leaf1", "default", map[string]string{"tag": "prod"}),
+		pipelineSelectingTargets("p1", "c1", true, map[string]string{"tag": "prod"}),
+	)
+	ctx := context.Background()
+	if got := r.findClustersForConfigMap(ctx, caConfigMap("devices", "ca.crt")); len(got) != 1 || got[0].Name != "c1" {
+		t.Fatalf("requests = %v, want c1", got)
+	}
+	if got := r.findClustersForConfigMap(ctx, caConfigMap("unrelated", "ca.crt")); len(got) != 0 {
+		t.Fatalf("requests = %v, want none", got)
+	}
+}
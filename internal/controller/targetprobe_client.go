@@ -0,0 +1,286 @@
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"path"
+	"slices"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"golang.org/x/net/proxy"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+// targetProbe is how a target is reached by the capabilities probe
+type targetProbe struct {
+	address string
+	// tls is nil when the target is probed in plaintext
+	tls      *tls.Config
+	proxy    string
+	metadata map[string]string
+}
+
+// targetProber sends a gNMI Capabilities request to a target
+type targetProber func(ctx context.Context, probe *targetProbe) (*gnmi.CapabilityResponse, error)
+
+// probeCapabilities connects to a target and sends it a Capabilities request.
+func probeCapabilities(ctx context.Context, probe *targetProbe) (*gnmi.CapabilityResponse, error) {
+	creds := insecure.NewCredentials()
+	if probe.tls != nil {
+		creds = credentials.NewTLS(probe.tls)
+	}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if probe.proxy != "" {
+		dialer, err := proxyDialer(probe.proxy)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, address string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp", address)
+		}))
+	}
+	conn, err := grpc.NewClient("passthrough:///"+probe.address, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+	if len(probe.metadata) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(probe.metadata))
+	}
+	return gnmi.NewGNMIClient(conn).Capabilities(ctx, &gnmi.CapabilityRequest{})
+}
+
+// proxyDialer returns a dialer connecting through a SOCKS5 proxy, written as
+// gNMIc does: socks5://host:port.
+func proxyDialer(address string) (proxy.ContextDialer, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %w", address, err)
+	}
+	dialer, err := proxy.FromURL(u, proxy.Direct)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %w", address, err)
+	}
+	contextDialer, ok := dialer.(proxy.ContextDialer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported proxy %q", address)
+	}
+	return contextDialer, nil
+}
+
+// probeMetadata returns the gRPC metadata of the probes of a target, set as
+// gNMIc does: the metadata of the profile and the target, and the credentials.
+// The credentials are only sent over TLS: a target probed in plaintext gets the
+// metadata alone.
+func probeMetadata(target *gnmicv1alpha1.Target, profile *gnmicv1alpha1.TargetProfileSpec, creds *gnmic.Credentials, secure bool) map[string]string {
+	md := make(map[string]string)
+	for _, m := range []map[string]string{profile.Metadata, target.Spec.Metadata} {
+		maps.Copy(md, m)
+	}
+	if creds == nil || !secure {
+		return md
+	}
+	if creds.Username != "" {
+		md["username"] = creds.Username
+	}
+	if creds.Password != "" {
+		md["password"] = creds.Password
+	}
+	if token := creds.Token; token != "" {
+		if profile.AuthScheme != "" {
+			token = profile.AuthScheme + " " + token
+		}
+		md["authorization"] = token
+	}
+	return md
+}
+
+// probeCluster returns the first Cluster collecting a target with a
+// clientTLS, whose TLS settings the gNMIc pods connect to the target with, or
+// nil if none does.
+func probeCluster(ctx context.Context, c client.Reader, target *gnmicv1alpha1.Target) (*gnmicv1alpha1.Cluster, error) {
+	for _, name := range slices.Sorted(maps.Keys(target.Status.ClusterStates)) {
+		var cluster gnmicv1alpha1.Cluster
+		err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: target.Namespace}, &cluster)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if cluster.Spec.ClientTLS != nil {
+			return &cluster, nil
+		}
+	}
+	return nil, nil
+}
+
+// probeTLSConfig returns the TLS configuration probing a target, nil when it
+// is probed in plaintext. It is the configuration of the gNMIc pods of cluster
+// (see gnmic.TargetTLS), with the files they mount read from their Secrets and
+// ConfigMaps. The client certificate of a cluster using the cert-manager CSI
+// driver is issued per pod and cannot be presented by the probe.
+func probeTLSConfig(ctx context.Context, c client.Reader, target *gnmicv1alpha1.Target, profile *gnmicv1alpha1.TargetProfile, cluster *gnmicv1alpha1.Cluster) (*tls.Config, error) {
+	var clientTLS *gnmic.ClientTLSPaths
+	if cluster != nil {
+		clientTLS = gnmic.ClientTLSConfigForCluster(cluster)
+	}
+	targetTLS := gnmic.TargetTLS(target, &profile.Spec, clientTLS)
+	if ptr.Deref(targetTLS.Insecure, false) {
+		return nil, nil
+	}
+	config := &tls.Config{
+		ServerName:         targetTLS.TLSServerName,
+		InsecureSkipVerify: ptr.Deref(targetTLS.SkipVerify, false),
+	}
+
+	var ca []byte
+	var err error
+	switch ptr.Deref(targetTLS.TLSCA, "") {
+	case "":
+	case gnmic.ClientCABundleFilePath:
+		if ca, err = readConfigMapKey(ctx, c, cluster.Namespace, cluster.Spec.ClientTLS.BundleRef, path.Base(gnmic.ClientCABundleFilePath)); err != nil {
+			return nil, fmt.Errorf("failed to get the CA bundle of cluster %s: %w", cluster.Name, err)
+		}
+	default:
+		if ca, err = readProbeCABundle(ctx, c, profile); err != nil {
+			return nil, err
+		}
+	}
+	if ca != nil {
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, errors.New("CA bundle holds no PEM encoded certificate")
+		}
+	}
+
+	var certSecret string
+	switch ptr.Deref(targetTLS.TLSCert, "") {
+	case "":
+	case gnmic.ClientTLSCertFilePath:
+		if !cluster.Spec.ClientTLS.UseCSIDriver {
+			certSecret = fmt.Sprintf("%s%s-client-tls", resourcePrefix, cluster.Name)
+		}
+	default:
+		certSecret = profile.Spec.TLS.ClientCertificateRef
+	}
+	if certSecret != "" {
+		var secret corev1.Secret
+		if err := c.Get(ctx, types.NamespacedName{Name: certSecret, Namespace: profile.Namespace}, &secret); err != nil {
+			return nil, fmt.Errorf("failed to get client certificate: %w", err)
+		}
+		certificate, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate %s: %w", certSecret, err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	if config.MinVersion, err = tlsVersion(targetTLS.TLSMinVersion); err != nil {
+		return nil, err
+	}
+	if config.MaxVersion, err = tlsVersion(targetTLS.TLSMaxVersion); err != nil {
+		return nil, err
+	}
+	for _, name := range targetTLS.CipherSuites {
+		id, ok := cipherSuiteID(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown cipher suite %q", name)
+		}
+		config.CipherSuites = append(config.CipherSuites, id)
+	}
+	return config, nil
+}
+
+// readConfigMapKey reads a key of a ConfigMap, text or binary.
+func readConfigMapKey(ctx context.Context, c client.Reader, namespace, name, key string) ([]byte, error) {
+	var cm corev1.ConfigMap
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &cm); err != nil {
+		return nil, err
+	}
+	if v, ok := cm.Data[key]; ok {
+		return []byte(v), nil
+	}
+	if v, ok := cm.BinaryData[key]; ok {
+		return v, nil
+	}
+	return nil, fmt.Errorf("key %q not found in ConfigMap %s", key, name)
+}
+
+// readProbeCABundle reads the PEM encoded CA bundle of a profile.
+func readProbeCABundle(ctx context.Context, c client.Reader, profile *gnmicv1alpha1.TargetProfile) ([]byte, error) {
+	ref := profile.Spec.TLS.CABundleRef
+	configMap, secret := ref.ConfigMap, ref.Secret
+	if ref.Bundle != "" {
+		var unresolved *gnmicv1alpha1.UnresolvedReference
+		var err error
+		configMap, secret, unresolved, err = resolveTrustBundleTarget(ctx, c, profile, ref.Bundle)
+		if err != nil {
+			return nil, err
+		}
+		if unresolved != nil {
+			return nil, errors.New(unresolved.Message)
+		}
+	}
+	switch {
+	case configMap != nil:
+		ca, err := readConfigMapKey(ctx, c, profile.Namespace, configMap.Name, configMap.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get CA bundle: %w", err)
+		}
+		return ca, nil
+	case secret != nil:
+		var s corev1.Secret
+		if err := c.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: profile.Namespace}, &s); err != nil {
+			return nil, fmt.Errorf("failed to get CA bundle: %w", err)
+		}
+		if ca, ok := s.Data[secret.Key]; ok {
+			return ca, nil
+		}
+		return nil, fmt.Errorf("key %q not found in Secret %s", secret.Key, secret.Name)
+	}
+	return nil, errors.New("CA bundle references no ConfigMap, Secret or Bundle")
+}
+
+// tlsVersion maps the TLS versions of a profile, e.g. 1.2, to their value.
+func tlsVersion(version string) (uint16, error) {
+	switch version {
+	case "":
+		return 0, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unknown TLS version %q", version)
+}
+
+func cipherSuiteID(name string) (uint16, bool) {
+	for _, suites := range [][]*tls.CipherSuite{tls.CipherSuites(), tls.InsecureCipherSuites()} {
+		for _, suite := range suites {
+			if suite.Name == name {
+				return suite.ID, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+const (
+	defaultTargetProbeInterval = 10 * time.Minute
+	defaultTargetProbeTimeout  = 10 * time.Second
+	// targetProbeConcurrency is how many targets are probed at once, a probe
+	// waits for the target up to the timeout of its profile
+	targetProbeConcurrency = 8
+)
+
+// TargetProbeReconciler probes the Targets whose TargetProfile enables it
+// with a gNMI Capabilities RPC, and records their capabilities in their
+// status. The probe connects from the operator, before and independently of
+// the collection by the gNMIc pods.
+type TargetProbeReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// Credentials fetches the credentials of the targets from the backend
+	// their profile selects
+	Credentials gnmic.CredentialsFetcher
+
+	// probe and now are replaced in tests
+	probe targetProber
+	now   func() time.Time
+}
+
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=targets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=targets/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=targetprofiles,verbs=get;list;watch
+//+kubebuilder:rbac:groups=operator.gnmic.dev,resources=clusters,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+
+func (r *TargetProbeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var target gnmicv1alpha1.Target
+	if err := r.Get(ctx, req.NamespacedName, &target); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	var profile gnmicv1alpha1.TargetProfile
+	err := r.Get(ctx, types.NamespacedName{Name: target.Spec.Profile, Namespace: target.Namespace}, &profile)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	if apierrors.IsNotFound(err) || profile.Spec.Probe == nil || target.Spec.Address == "" {
+		// probing is disabled, the last result would only grow stale
+		if target.Status.Capabilities == nil {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, r.patchCapabilities(ctx, &target, nil)
+	}
+	if target.Spec.Suspend {
+		return ctrl.Result{}, nil
+	}
+
+	interval := defaultTargetProbeInterval
+	if d := profile.Spec.Probe.Interval.Duration; d > 0 {
+		interval = d
+	}
+	now := r.clock()
+	// a changed target is probed again right away
+	if last := target.Status.Capabilities; last != nil && last.ObservedGeneration == target.Generation {
+		if next := last.LastProbeTime.Add(interval); next.After(now) {
+			return ctrl.Result{RequeueAfter: next.Sub(now)}, nil
+		}
+	}
+
+	caps := r.probeTarget(ctx, &target, &profile, now)
+	if !caps.Reachable {
+		logger.Info("target capabilities probe failed", "target", req.String(), "error", caps.Error)
+	}
+	if err := r.patchCapabilities(ctx, &target, caps); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// probeTarget sends a Capabilities request to a target. The capabilities of
+// the last successful probe are kept when it fails.
+func (r *TargetProbeReconciler) probeTarget(ctx context.Context, target *gnmicv1alpha1.Target, profile *gnmicv1alpha1.TargetProfile, now time.Time) *gnmicv1alpha1.TargetCapabilities {
+	caps := &gnmicv1alpha1.TargetCapabilities{
+		LastProbeTime:      metav1.NewTime(now),
+		ObservedGeneration: target.Generation,
+	}
+	if last := target.Status.Capabilities; last != nil {
+		caps.GNMIVersion = last.GNMIVersion
+		caps.SupportedEncodings = last.SupportedEncodings
+		caps.SupportedModels = last.SupportedModels
+	}
+
+	resp, err := r.capabilities(ctx, target, profile)
+	if err != nil {
+		caps.Error = err.Error()
+		return caps
+	}
+	caps.Reachable = true
+	caps.GNMIVersion = resp.GetGNMIVersion()
+	caps.SupportedEncodings = nil
+	for _, encoding := range resp.GetSupportedEncodings() {
+		caps.SupportedEncodings = append(caps.SupportedEncodings, encoding.String())
+	}
+	caps.SupportedModels = nil
+	for _, model := range resp.GetSupportedModels() {
+		caps.SupportedModels = append(caps.SupportedModels, gnmicv1alpha1.TargetModel{
+			Name:         model.GetName(),
+			Organization: model.GetOrganization(),
+			Version:      model.GetVersion(),
+		})
+	}
+	return caps
+}
+
+func (r *TargetProbeReconciler) capabilities(ctx context.Context, target *gnmicv1alpha1.Target, profile *gnmicv1alpha1.TargetProfile) (*gnmi.CapabilityResponse, error) {
+	cluster, err := probeCluster(ctx, r.Client, target)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := probeTLSConfig(ctx, r.Client, target, profile, cluster)
+	if err != nil {
+		return nil, err
+	}
+	var creds *gnmic.Credentials
+	if r.Credentials != nil {
+		if creds, err = gnmic.FetchTargetCredentials(r.Credentials, target, &profile.Spec); err != nil {
+			return nil, err
+		}
+	}
+
+	timeout := defaultTargetProbeTimeout
+	if d := profile.Spec.Timeout.Duration; d > 0 {
+		timeout = d
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	probe := r.probe
+	if probe == nil {
+		probe = probeCapabilities
+	}
+	return probe(ctx, &targetProbe{
+		address:  target.Spec.Address,
+		tls:      tlsConfig,
+		proxy:    profile.Spec.Proxy,
+		metadata: probeMetadata(target, &profile.Spec, creds, tlsConfig != nil),
+	})
+}
+
+// patchCapabilities sets the capabilities in the status of a target. The
+// patch touches no other field, those are written by the TargetState
+// controller.
+func (r *TargetProbeReconciler) patchCapabilities(ctx context.Context, target *gnmicv1alpha1.Target, caps *gnmicv1alpha1.TargetCapabilities) error {
+	patch := client.MergeFrom(target.DeepCopy())
+	target.Status.Capabilities = caps
+	return client.IgnoreNotFound(r.Status().Patch(ctx, target, patch))
+}
+
+func (r *TargetProbeReconciler) clock() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *TargetProbeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&gnmicv1alpha1.Target{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(
+			&gnmicv1alpha1.TargetProfile{},
+			handler.EnqueueRequestsFromMapFunc(r.findTargetsForProfile),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: targetProbeConcurrency}).
+		Named("targetprobe").
+		Complete(r)
+}
+
+// findTargetsForProfile returns the Targets using a TargetProfile, so that
+// enabling or disabling the probe takes effect right away.
+func (r *TargetProbeReconciler) findTargetsForProfile(ctx context.Context, obj client.Object) []reconcile.Request {
+	var targets gnmicv1alpha1.TargetList
+	if err := r.List(ctx, &targets, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, target := range targets.Items {
+		if target.Spec.Profile == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: target.Name, Namespace: target.Namespace}})
+		}
+	}
+	return requests
+}
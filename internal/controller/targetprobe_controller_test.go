@@ -0,0 +1,413 @@
+package controller
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
+)
+
+// fakeCapabilitiesServer is a gNMI server answering Capabilities requests
+// sent with the expected credentials.
+type fakeCapabilitiesServer struct {
+	gnmi.UnimplementedGNMIServer
+	username, password string
+	requests           atomic.Int32
+}
+
+func (s *fakeCapabilitiesServer) Capabilities(ctx context.Context, _ *gnmi.CapabilityRequest) (*gnmi.CapabilityResponse, error) {
+	s.requests.Add(1)
+	md, _ := metadata.FromIncomingContext(ctx)
+	if !slices.Equal(md.Get("username"), []string{s.username}) || !slices.Equal(md.Get("password"), []string{s.password}) {
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	return &gnmi.CapabilityResponse{
+		GNMIVersion:        "0.10.0",
+		SupportedEncodings: []gnmi.Encoding{gnmi.Encoding_JSON_IETF, gnmi.Encoding_PROTO},
+		SupportedModels:    []*gnmi.ModelData{{Name: "openconfig-interfaces", Organization: "OpenConfig working group", Version: "3.0.0"}},
+	}, nil
+}
+
+// startFakeCapabilitiesServer serves srv in plaintext, or over TLS with the
+// certificate and key of serverCert when set.
+func startFakeCapabilitiesServer(t *testing.T, srv *fakeCapabilitiesServer, serverCert ...string) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var opts []grpc.ServerOption
+	if len(serverCert) == 2 {
+		certificate, err := tls.X509KeyPair([]byte(serverCert[0]), []byte(serverCert[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts = append(opts, grpc.Creds(credentials.NewServerTLSFromCert(&certificate)))
+	}
+	s := grpc.NewServer(opts...)
+	gnmi.RegisterGNMIServer(s, srv)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+	return lis.Addr().String()
+}
+
+// secretCredentials reads the credentials of the profiles from their Secret
+type secretCredentials struct{ client.Client }
+
+func (c secretCredentials) FetchCredentials(namespace, _ string, profile *gnmicv1alpha1.TargetProfileSpec) (*gnmic.Credentials, error) {
+	var secret corev1.Secret
+	if err := c.Get(context.Background(), types.NamespacedName{Name: profile.CredentialsRef, Namespace: namespace}, &secret); err != nil {
+		return nil, err
+	}
+	return &gnmic.Credentials{Username: string(secret.Data["username"]), Password: string(secret.Data["password"])}, nil
+}
+
+// tlsProbedObjects returns a profile probing over TLS, verifying the
+// certificate of the fake server with the device-ca ConfigMap, and the
+// certificate and key the fake server is started with.
+func tlsProbedObjects(t *testing.T) (*gnmicv1alpha1.TargetProfile, *corev1.ConfigMap, []string) {
+	t.Helper()
+	cert, key := testCertificate(t, "device", true)
+	p := probedProfile(true)
+	p.Spec.TLS = &gnmicv1alpha1.TargetTLSConfig{CABundleRef: &gnmicv1alpha1.CABundleRef{ConfigMap: &corev1.ConfigMapKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "device-ca"}, Key: "ca.crt",
+	}}}
+	ca := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "device-ca", Namespace: "default"},
+		Data:       map[string]string{"ca.crt": cert},
+	}
+	return p, ca, []string{cert, key}
+}
+
+func probedProfile(probe bool) *gnmicv1alpha1.TargetProfile {
+	p := profile("default-profile", "device-creds")
+	p.Spec.Timeout = metav1.Duration{Duration: 2 * time.Second}
+	if probe {
+		p.Spec.Probe = &gnmicv1alpha1.TargetProbeConfig{Interval: metav1.Duration{Duration: 5 * time.Minute}}
+	}
+	return p
+}
+
+func probedTarget(address string) *gnmicv1alpha1.Target {
+	t := target("leaf1", "default-profile", nil)
+	t.Generation = 1
+	t.Spec.Address = address
+	return t
+}
+
+func reconcileTargetProbe(t *testing.T, r *TargetProbeReconciler) (ctrl.Result, *gnmicv1alpha1.Target) {
+	t.Helper()
+	nn := types.NamespacedName{Name: "leaf1", Namespace: "default"}
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: nn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var target gnmicv1alpha1.Target
+	if err := r.Get(context.Background(), nn, &target); err != nil {
+		t.Fatal(err)
+	}
+	return result, &target
+}
+
+func TestTargetProbeReconciler(t *testing.T) {
+	profile, ca, serverCert := tlsProbedObjects(t)
+	srv := &fakeCapabilitiesServer{username: "admin", password: "secret"}
+	address := startFakeCapabilitiesServer(t, srv, serverCert...)
+	c := fake.NewClientBuilder().WithScheme(secretWatchScheme(t)).
+		WithObjects(profile, ca, probedTarget(address), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "device-creds", Namespace: "default"},
+			Data:       map[string][]byte{"username": []byte("admin"), "password": []byte("secret")},
+		}).
+		WithStatusSubresource(&gnmicv1alpha1.Target{}).
+		Build()
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	r := &TargetProbeReconciler{Client: c, Credentials: secretCredentials{c}, now: func() time.Time { return now }}
+
+	result, target := reconcileTargetProbe(t, r)
+	caps := target.Status.Capabilities
+	if caps == nil || !caps.Reachable || caps.Error != "" || caps.GNMIVersion != "0.10.0" || caps.ObservedGeneration != 1 {
+		t.Fatalf("unexpected capabilities: %+v", caps)
+	}
+	if !slices.Equal(caps.SupportedEncodings, []string{"JSON_IETF", "PROTO"}) {
+		t.Fatalf("encodings = %v", caps.SupportedEncodings)
+	}
+	if len(caps.SupportedModels) != 1 || caps.SupportedModels[0].Name != "openconfig-interfaces" || caps.SupportedModels[0].Version != "3.0.0" {
+		t.Fatalf("models = %v", caps.SupportedModels)
+	}
+	if result.RequeueAfter != 5*time.Minute {
+		t.Fatalf("requeue after %s", result.RequeueAfter)
+	}
+
+	// not probed again before the interval
+	now = now.Add(time.Minute)
+	result, _ = reconcileTargetProbe(t, r)
+	if srv.requests.Load() != 1 || result.RequeueAfter != 4*time.Minute {
+		t.Fatalf("requests %d, requeue after %s", srv.requests.Load(), result.RequeueAfter)
+	}
+
+	// a failed probe keeps the capabilities found before
+	now = now.Add(4 * time.Minute)
+	r.probe = func(context.Context, *targetProbe) (*gnmi.CapabilityResponse, error) {
+		return nil, errors.New("connection refused")
+	}
+	_, target = reconcileTargetProbe(t, r)
+	caps = target.Status.Capabilities
+	if caps.Reachable || caps.Error != "connection refused" || !caps.LastProbeTime.Time.Equal(now) || len(caps.SupportedEncodings) != 2 {
+		t.Fatalf("unexpected capabilities: %+v", caps)
+	}
+
+	// disabling the probe drops the result
+	var p gnmicv1alpha1.TargetProfile
+	if err := c.Get(context.Background(), types.NamespacedName{Name: "default-profile", Namespace: "default"}, &p); err != nil {
+		t.Fatal(err)
+	}
+	p.Spec.Probe = nil
+	if err := c.Update(context.Background(), &p); err != nil {
+		t.Fatal(err)
+	}
+	result, target = reconcileTargetProbe(t, r)
+	if target.Status.Capabilities != nil || result.RequeueAfter != 0 {
+		t.Fatalf("capabilities %+v, requeue after %s", target.Status.Capabilities, result.RequeueAfter)
+	}
+}
+
+func TestTargetProbeReconciler_WrongCredentials(t *testing.T) {
+	profile, ca, serverCert := tlsProbedObjects(t)
+	address := startFakeCapabilitiesServer(t, &fakeCapabilitiesServer{username: "admin", password: "secret"}, serverCert...)
+	c := fake.NewClientBuilder().WithScheme(secretWatchScheme(t)).
+		WithObjects(profile, ca, probedTarget(address), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "device-creds", Namespace: "default"},
+			Data:       map[string][]byte{"username": []byte("admin"), "password": []byte("wrong")},
+		}).
+		WithStatusSubresource(&gnmicv1alpha1.Target{}).
+		Build()
+	r := &TargetProbeReconciler{Client: c, Credentials: secretCredentials{c}}
+
+	_, target := reconcileTargetProbe(t, r)
+	caps := target.Status.Capabilities
+	if caps == nil || caps.Reachable || !strings.Contains(caps.Error, "invalid credentials") {
+		t.Fatalf("unexpected capabilities: %+v", caps)
+	}
+}
+
+func TestTargetProbeReconciler_PlaintextWithoutCredentials(t *testing.T) {
+	address := startFakeCapabilitiesServer(t, &fakeCapabilitiesServer{username: "admin", password: "secret"})
+	c := fake.NewClientBuilder().WithScheme(secretWatchScheme(t)).
+		WithObjects(probedProfile(true), probedTarget(address), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "device-creds", Namespace: "default"},
+			Data:       map[string][]byte{"username": []byte("admin"), "password": []byte("secret")},
+		}).
+		WithStatusSubresource(&gnmicv1alpha1.Target{}).
+		Build()
+	r := &TargetProbeReconciler{Client: c, Credentials: secretCredentials{c}}
+
+	// the valid credentials are not sent in plaintext
+	_, target := reconcileTargetProbe(t, r)
+	caps := target.Status.Capabilities
+	if caps == nil || caps.Reachable || !strings.Contains(caps.Error, "invalid credentials") {
+		t.Fatalf("unexpected capabilities: %+v", caps)
+	}
+}
+
+func TestProbeTLSConfig(t *testing.T) {
+	p := probedProfile(true)
+	p.Spec.TLS = &gnmicv1alpha1.TargetTLSConfig{
+		ServerName: "leaf.example.com",
+		MinVersion: "1.2",
+		CABundleRef: &gnmicv1alpha1.CABundleRef{ConfigMap: &corev1.ConfigMapKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "device-ca"}, Key: "ca.crt",
+		}},
+		CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+	}
+	target := probedTarget("10.0.0.1:57400")
+	target.Spec.TLSServerName = "leaf1.example.com"
+	c := fake.NewClientBuilder().WithScheme(secretWatchScheme(t)).
+		WithObjects(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "device-ca", Namespace: "default"},
+			Data:       map[string]string{"ca.crt": testCACert(t)},
+		}).
+		Build()
+
+	config, err := probeTLSConfig(context.Background(), c, target, p, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.ServerName != "leaf1.example.com" || config.RootCAs == nil || config.MinVersion == 0 || len(config.CipherSuites) != 1 {
+		t.Fatalf("unexpected TLS config: %+v", config)
+	}
+
+	p.Spec.TLS.CABundleRef.ConfigMap.Key = "bundle.pem"
+	if _, err := probeTLSConfig(context.Background(), c, target, p, nil); err == nil || !strings.Contains(err.Error(), "bundle.pem") {
+		t.Fatalf("expected an error for the missing key, got %v", err)
+	}
+
+	// without a CA bundle the device certificates are verified with the system roots
+	p.Spec.TLS = &gnmicv1alpha1.TargetTLSConfig{}
+	if config, err := probeTLSConfig(context.Background(), c, target, p, nil); err != nil || config.InsecureSkipVerify || config.RootCAs != nil {
+		t.Fatalf("config %+v, err %v", config, err)
+	}
+	p.Spec.TLS.InsecureSkipVerify = true
+	if config, err := probeTLSConfig(context.Background(), c, target, p, nil); err != nil || !config.InsecureSkipVerify {
+		t.Fatalf("config %+v, err %v", config, err)
+	}
+
+	// without TLS settings the targets are probed in plaintext
+	p.Spec.TLS = nil
+	if config, err := probeTLSConfig(context.Background(), c, target, p, nil); err != nil || config != nil {
+		t.Fatalf("config %v, err %v", config, err)
+	}
+}
+
+func TestProbeTLSConfig_ClusterClientTLS(t *testing.T) {
+	cert, key := testCertificate(t, "c1.default", false)
+	cluster := &gnmicv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "default"},
+		Spec: gnmicv1alpha1.ClusterSpec{ClientTLS: &gnmicv1alpha1.ClusterTLSConfig{
+			IssuerRef: "device-issuer",
+			BundleRef: "device-ca",
+		}},
+	}
+	target := probedTarget("10.0.0.1:57400")
+	target.Status.ClusterStates = map[string]gnmicv1alpha1.ClusterTargetState{"c0": {}, "c1": {}}
+	c := fake.NewClientBuilder().WithScheme(secretWatchScheme(t)).
+		WithObjects(
+			cluster,
+			&gnmicv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c0", Namespace: "default"}},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "device-ca", Namespace: "default"},
+				Data:       map[string]string{"ca.crt": testCACert(t)},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "gnmic-c1-client-tls", Namespace: "default"},
+				Data:       map[string][]byte{corev1.TLSCertKey: []byte(cert), corev1.TLSPrivateKeyKey: []byte(key)},
+			},
+		).
+		Build()
+
+	// the target is probed with the clientTLS of the cluster collecting it,
+	// as its pods connect to it, even though its profile sets no TLS
+	probed, err := probeCluster(context.Background(), c, target)
+	if err != nil || probed == nil || probed.Name != "c1" {
+		t.Fatalf("probe cluster %v, err %v", probed, err)
+	}
+	config, err := probeTLSConfig(context.Background(), c, target, probedProfile(true), probed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config == nil || config.RootCAs == nil || len(config.Certificates) != 1 {
+		t.Fatalf("unexpected TLS config: %+v", config)
+	}
+
+	// the certificates issued by the CSI driver are the pods' own
+	probed.Spec.ClientTLS.UseCSIDriver = true
+	config, err = probeTLSConfig(context.Background(), c, target, probedProfile(true), probed)
+	if err != nil || config == nil || len(config.Certificates) != 0 {
+		t.Fatalf("config %+v, err %v", config, err)
+	}
+}
+
+func TestUnsupportedEncodings(t *testing.T) {
+	probed := func(name string, encodings ...string) gnmicv1alpha1.Target {
+		t := *target(name, "default-profile", nil)
+		if encodings != nil {
+			t.Status.Capabilities = &gnmicv1alpha1.TargetCapabilities{Reachable: true, SupportedEncodings: encodings}
+		}
+		return t
+	}
+	data := gnmic.NewPipelineData()
+	data.TargetProfiles["default/default-profile"] = gnmicv1alpha1.TargetProfileSpec{Encoding: "PROTO"}
+	data.Targets["default/leaf1"] = probed("leaf1", "JSON_IETF", "PROTO")
+	data.Targets["default/leaf2"] = probed("leaf2", "JSON")
+	data.Targets["default/leaf3"] = probed("leaf3")
+	leaf4 := probed("leaf4", "JSON")
+	leaf4.Spec.Subscriptions = []string{"interfaces"}
+	data.Targets["default/leaf4"] = leaf4
+	data.Subscriptions["default/p1/interfaces"] = gnmicv1alpha1.SubscriptionSpec{Encoding: "json_ietf"}
+	data.Subscriptions["default/p1/system"] = gnmicv1alpha1.SubscriptionSpec{}
+
+	want := []string{
+		"leaf2: interfaces (JSON_IETF)", "leaf2: system (PROTO)",
+		"leaf4: interfaces (JSON_IETF)",
+	}
+	if got := unsupportedEncodings(data); !slices.Equal(got, want) {
+		t.Fatalf("unsupported = %v, want %v", got, want)
+	}
+	cond := encodingsCondition(data, 1, metav1.Now())
+	if cond.Status != metav1.ConditionFalse || cond.Reason != "UnsupportedEncodings" || !strings.Contains(cond.Message, "leaf4: interfaces") {
+		t.Fatalf("unexpected condition: %+v", cond)
+	}
+
+	delete(data.Targets, "default/leaf2")
+	delete(data.Targets, "default/leaf4")
+	if cond := encodingsCondition(data, 1, metav1.Now()); cond.Status != metav1.ConditionTrue {
+		t.Fatalf("unexpected condition: %+v", cond)
+	}
+}
+
+// testCACert returns a PEM encoded self-signed CA certificate.
+func testCACert(t *testing.T) string {
+	t.Helper()
+	cert, _ := testCertificate(t, "device-ca", false)
+	return cert
+}
+
+// testCertificate returns a PEM encoded self-signed CA certificate and its
+// key. A server certificate is valid for 127.0.0.1 and verifies itself.
+func testCertificate(t *testing.T, commonName string, server bool) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	if server {
+		template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
@@ -49,6 +49,9 @@ type TargetSourceReconciler struct {
 
 	BufferSize int
 	ChunkSize  int
+	// Journal is the kind of journal persisting pushed discovery messages,
+	// see discovery.NewJournal. Empty disables journaling.
+	Journal string
 
 	DiscoveryRegistry *discovery.Registry[
 		types.NamespacedName,
@@ -193,11 +196,6 @@ func (r *TargetSourceReconciler) startDiscovery(
 	targetChannel := make(chan []discoveryTypes.DiscoveryMessage, r.BufferSize)
 	ctx, cancel := context.WithCancel(context.Background())
 	statusUpdater := discovery.NewK8sStatusUpdater(r.Client, r.Scheme, targetSource)
-	loaderConfig := discoveryTypes.CommonLoaderConfig{
-		TargetsourceNN: key,
-		ChunkSize:      r.ChunkSize,
-		Updater:        statusUpdater,
-	}
 
 	// Cleanup function to cleanup discovery runtime of targetsource
 	cleanup := func() {
@@ -205,12 +203,28 @@ func (r *TargetSourceReconciler) startDiscovery(
 		r.DiscoveryRegistry.Unregister(key)
 	}
 
+	operations := discoveryTypes.NewOperationStore(discoveryTypes.DefaultOperationTTL)
+	journal, err := discovery.NewJournal(r.Journal, r.Client, r.Scheme, targetSource)
+	if err != nil {
+		cleanup()
+		return err
+	}
+	loaderConfig := discoveryTypes.CommonLoaderConfig{
+		TargetsourceNN: key,
+		ChunkSize:      r.ChunkSize,
+		Updater:        statusUpdater,
+		BufferSize:     r.BufferSize,
+		Operations:     operations,
+		Journal:        journal,
+	}
 	messageProcessor := discovery.NewMessageProcessor(
 		r.Client,
 		r.Scheme,
 		targetSource,
 		targetChannel,
 		statusUpdater,
+		operations,
+		journal,
 	)
 	loader, err := discovery.NewLoader(reconcileCtx, r.Client, &loaderConfig, targetSource.Spec)
 	if err != nil {
@@ -219,11 +233,19 @@ func (r *TargetSourceReconciler) startDiscovery(
 		return err
 	}
 
+	// Pushed messages go through loaders combining them with other providers
+	var pushChannel chan<- []discoveryTypes.DiscoveryMessage = targetChannel
+	if receiver, ok := loader.(discoveryTypes.PushReceiver); ok {
+		pushChannel = receiver.PushChannel()
+	}
+
 	// Register discovery runtime of targetsource
 	if err := r.DiscoveryRegistry.Register(key, discoveryTypes.DiscoveryRegistryValue{
-		Channel:            targetChannel,
+		Channel:            pushChannel,
 		Stop:               cancel,
 		CommonLoaderConfig: &loaderConfig,
+		Operations:         operations,
+		Journal:            journal,
 	}); err != nil {
 		return err
 	}
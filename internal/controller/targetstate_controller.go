@@ -18,19 +18,14 @@ package controller
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
 	"sync"
 	"time"
 
-	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
-	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -542,38 +537,9 @@ func (r *TargetStateReconciler) createHTTPClient(ctx context.Context, cluster *g
 	if cluster.Spec.API == nil || cluster.Spec.API.TLS == nil {
 		return &http.Client{}, nil
 	}
-	tlsConfig := &tls.Config{}
-	if cluster.Spec.API.TLS.IssuerRef != "" {
-		cert, err := os.ReadFile(gnmic.GetControllerCertPath())
-		if err != nil {
-			return nil, fmt.Errorf("failed to read controller cert: %w", err)
-		}
-		key, err := os.ReadFile(gnmic.GetControllerKeyPath())
-		if err != nil {
-			return nil, fmt.Errorf("failed to read controller key: %w", err)
-		}
-		certificate, err := tls.X509KeyPair(cert, key)
-		if err != nil {
-			return nil, err
-		}
-		tlsConfig.Certificates = []tls.Certificate{certificate}
-
-		ca, err := r.getIssuerCA(ctx, cluster.Namespace, cluster.Spec.API.TLS.IssuerRef)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get issuer CA: %w", err)
-		}
-		tlsConfig.RootCAs = x509.NewCertPool()
-		tlsConfig.RootCAs.AppendCertsFromPEM(ca)
-	}
-	if cluster.Spec.API.TLS.BundleRef != "" {
-		ca, err := os.ReadFile(gnmic.GetControllerCAPath())
-		if err != nil {
-			return nil, fmt.Errorf("failed to read controller CA: %w", err)
-		}
-		if tlsConfig.RootCAs == nil {
-			tlsConfig.RootCAs = x509.NewCertPool()
-		}
-		tlsConfig.RootCAs.AppendCertsFromPEM(ca)
+	tlsConfig, err := clusterAPITLSConfig(ctx, r.Client, cluster)
+	if err != nil {
+		return nil, err
 	}
 	return &http.Client{
 		Transport: &http.Transport{
@@ -582,26 +548,6 @@ func (r *TargetStateReconciler) createHTTPClient(ctx context.Context, cluster *g
 	}, nil
 }
 
-// getIssuerCA fetches the CA certificate from a cert-manager Issuer's backing secret.
-func (r *TargetStateReconciler) getIssuerCA(ctx context.Context, namespace, issuerName string) ([]byte, error) {
-	issuer := &certmanagerv1.Issuer{}
-	if err := r.Get(ctx, types.NamespacedName{Name: issuerName, Namespace: namespace}, issuer); err != nil {
-		return nil, fmt.Errorf("failed to get issuer %s: %w", issuerName, err)
-	}
-	if issuer.Spec.CA == nil || issuer.Spec.CA.SecretName == "" {
-		return nil, fmt.Errorf("issuer %s is not a CA issuer or has no secret configured", issuerName)
-	}
-	caSecret := &corev1.Secret{}
-	if err := r.Get(ctx, types.NamespacedName{Name: issuer.Spec.CA.SecretName, Namespace: namespace}, caSecret); err != nil {
-		return nil, fmt.Errorf("failed to get CA secret %s: %w", issuer.Spec.CA.SecretName, err)
-	}
-	caCert, ok := caSecret.Data["tls.crt"]
-	if !ok {
-		return nil, fmt.Errorf("CA secret %s does not contain tls.crt", issuer.Spec.CA.SecretName)
-	}
-	return caCert, nil
-}
-
 func (r *TargetStateReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&gnmicv1alpha1.Cluster{}).
@@ -1,12 +1,17 @@
 package gnmic
 
 import (
+	"encoding/json"
+	"reflect"
 	"testing"
 	"time"
 
 	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	gapi "github.com/openconfig/gnmic/pkg/api/types"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 )
 
 func rawJSON(s string) *apiextensionsv1.JSON {
@@ -34,8 +39,8 @@ func TestBuildTargetConfig(t *testing.T) {
 
 	profile.TLS = &gnmicv1alpha1.TargetTLSConfig{MaxVersion: "1.3", MinVersion: "1.2"}
 	cfg = buildTargetConfig(target, profile, nil, nil)
-	if cfg.SkipVerify == nil || !*cfg.SkipVerify {
-		t.Fatal("expected skip verify with profile TLS only")
+	if cfg.SkipVerify == nil || *cfg.SkipVerify {
+		t.Fatal("expected verification with system roots with profile TLS only")
 	}
 
 	profileOnlyMax := &gnmicv1alpha1.TargetProfileSpec{
@@ -52,6 +57,121 @@ func TestBuildTargetConfig(t *testing.T) {
 	if cfg.TLSCert == nil || *cfg.TLSCert != "/cert" {
 		t.Fatal("expected client cert paths")
 	}
+
+	profile.TLS.CABundleRef = &gnmicv1alpha1.CABundleRef{Bundle: "devices"}
+	profile.TLS.ClientCertificateRef = "router-client"
+	cfg = buildTargetConfig(target, profile, nil, clientTLS)
+	if cfg.TLSCA == nil || *cfg.TLSCA != "/etc/gnmic/target-tls/default_default/ca.crt" {
+		t.Fatalf("expected the profile CA bundle, got %v", cfg.TLSCA)
+	}
+	if *cfg.TLSCert != "/etc/gnmic/target-tls/default_default/tls.crt" || *cfg.TLSKey != "/etc/gnmic/target-tls/default_default/tls.key" {
+		t.Fatalf("expected the profile client certificate, got %s %s", *cfg.TLSCert, *cfg.TLSKey)
+	}
+	if *cfg.SkipVerify {
+		t.Fatal("expected verification with a CA bundle")
+	}
+
+	profile.TLS = &gnmicv1alpha1.TargetTLSConfig{InsecureSkipVerify: true}
+	cfg = buildTargetConfig(target, profile, nil, nil)
+	if cfg.SkipVerify == nil || !*cfg.SkipVerify {
+		t.Fatal("expected skip verify with insecureSkipVerify")
+	}
+}
+
+func TestBuildTargetConfig_ProfileAndTargetOptions(t *testing.T) {
+	profile := &gnmicv1alpha1.TargetProfileSpec{
+		Encoding:        "PROTO",
+		AuthScheme:      "Bearer",
+		Proxy:           "socks5://proxy:1080",
+		GzipCompression: true,
+		BufferSize:      ptr.To(int32(200)),
+		GRPCBuffers: &gnmicv1alpha1.GRPCBufferConfig{
+			ReadBufferSize: ptr.To(int32(0)),
+			WindowSize:     ptr.To(int32(1 << 20)),
+		},
+		Proto:    &gnmicv1alpha1.TargetProtoConfig{ConfigMapName: "eos-proto", Files: []string{"eos_ext.proto"}},
+		Metadata: map[string]string{"x-vendor": "arista", "x-site": "par"},
+		Labels:   map[string]string{"vendor": "arista", "site": "par"},
+		TLS:      &gnmicv1alpha1.TargetTLSConfig{ServerName: "routers.example.com"},
+	}
+	target := &gnmicv1alpha1.Target{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "router1"},
+		Spec: gnmicv1alpha1.TargetSpec{
+			Address:       "10.0.0.1:57400",
+			Profile:       "eos",
+			BufferSize:    ptr.To(int32(1000)),
+			TLSServerName: "router1.example.com",
+			Metadata:      map[string]string{"x-site": "lon"},
+			Labels:        map[string]string{"site": "lon", "rack": "r1"},
+		},
+	}
+
+	cfg := buildTargetConfig(target, profile, nil, nil)
+	if cfg.AuthScheme != "Bearer" || cfg.Proxy != "socks5://proxy:1080" || cfg.Gzip == nil || !*cfg.Gzip {
+		t.Fatalf("profile options not mapped: %+v", cfg)
+	}
+	if cfg.BufferSize != 1000 || cfg.TLSServerName != "router1.example.com" {
+		t.Fatalf("target overrides not mapped: buffer %d, server name %q", cfg.BufferSize, cfg.TLSServerName)
+	}
+	if cfg.GRPCReadBufferSize == nil || *cfg.GRPCReadBufferSize != 0 || cfg.GRPCWindowSize == nil || *cfg.GRPCWindowSize != 1<<20 || cfg.GRPCConnWindowSize != nil {
+		t.Fatalf("gRPC buffers = %v %v %v", cfg.GRPCReadBufferSize, cfg.GRPCWindowSize, cfg.GRPCConnWindowSize)
+	}
+	if !reflect.DeepEqual(cfg.ProtoDirs, []string{"/etc/gnmic/target-tls/default_eos/proto"}) ||
+		!reflect.DeepEqual(cfg.ProtoFiles, []string{"/etc/gnmic/target-tls/default_eos/proto/eos_ext.proto"}) {
+		t.Fatalf("proto = %v %v", cfg.ProtoDirs, cfg.ProtoFiles)
+	}
+	if want := map[string]string{"x-vendor": "arista", "x-site": "lon"}; !reflect.DeepEqual(cfg.Metadata, want) {
+		t.Fatalf("metadata = %v, want %v", cfg.Metadata, want)
+	}
+	if want := map[string]string{"vendor": "arista", "site": "lon", "rack": "r1"}; !reflect.DeepEqual(cfg.EventTags, want) {
+		t.Fatalf("event tags = %v, want %v", cfg.EventTags, want)
+	}
+	// the target overrides are not written into the profile
+	if profile.Metadata["x-site"] != "par" || profile.Labels["site"] != "par" {
+		t.Fatal("profile maps modified")
+	}
+
+	// without TLS the server name is not used
+	profile.TLS = nil
+	cfg = buildTargetConfig(target, profile, nil, nil)
+	if cfg.TLSServerName != "" {
+		t.Fatalf("server name %q set on an insecure target", cfg.TLSServerName)
+	}
+}
+
+func TestBuildTargetConfig_TargetTags(t *testing.T) {
+	target := &gnmicv1alpha1.Target{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "router1",
+			Labels:      map[string]string{"site": "par", "netbox.dev/role": "spine", "team": "noc"},
+			Annotations: map[string]string{"netbox.dev/serial": "SN123"},
+		},
+		Spec: gnmicv1alpha1.TargetSpec{
+			Address: "10.0.0.1:57400",
+			Profile: "default",
+			Labels:  map[string]string{"site": "par-2"},
+		},
+	}
+	profile := &gnmicv1alpha1.TargetProfileSpec{
+		Encoding: "JSON",
+		Labels:   map[string]string{"vendor": "arista", "role": "unknown"},
+		TargetTags: &gnmicv1alpha1.TargetTagsConfig{
+			Labels: []gnmicv1alpha1.TargetTag{
+				{Key: "site"},
+				{Key: "netbox.dev/role"},
+				{Key: "rack"},
+			},
+			Annotations: []gnmicv1alpha1.TargetTag{{Key: "netbox.dev/serial", Name: "serial_number"}},
+		},
+	}
+
+	cfg := buildTargetConfig(target, profile, nil, nil)
+	// unselected and missing keys get no tag, the target spec labels win
+	want := map[string]string{"vendor": "arista", "role": "spine", "site": "par-2", "serial_number": "SN123"}
+	if !reflect.DeepEqual(cfg.EventTags, want) {
+		t.Fatalf("event tags = %v, want %v", cfg.EventTags, want)
+	}
 }
 
 func TestDurationOrDefault(t *testing.T) {
@@ -187,10 +307,128 @@ func TestBuildSubscriptionConfig(t *testing.T) {
 	}
 }
 
+func TestBuildSubscriptionConfig_Modes(t *testing.T) {
+	second := metav1.Duration{Duration: time.Second}
+	minute := metav1.Duration{Duration: time.Minute}
+	tests := []struct {
+		name           string
+		spec           gnmicv1alpha1.SubscriptionSpec
+		wantMode       string
+		wantStreamMode string
+		wantSample     time.Duration
+		wantHeartbeat  time.Duration
+	}{
+		{name: "default", spec: gnmicv1alpha1.SubscriptionSpec{}},
+		{name: "once", spec: gnmicv1alpha1.SubscriptionSpec{Mode: "ONCE"}, wantMode: "ONCE"},
+		{name: "poll", spec: gnmicv1alpha1.SubscriptionSpec{Mode: "POLL"}, wantMode: "POLL"},
+		{name: "stream", spec: gnmicv1alpha1.SubscriptionSpec{Mode: "STREAM", SampleInterval: second}, wantMode: "STREAM", wantSample: time.Second},
+		{
+			name:           "sample",
+			spec:           gnmicv1alpha1.SubscriptionSpec{Mode: "STREAM/SAMPLE", SampleInterval: second, HeartbeatInterval: minute},
+			wantMode:       "STREAM",
+			wantStreamMode: "SAMPLE",
+			wantSample:     time.Second,
+			wantHeartbeat:  time.Minute,
+		},
+		{
+			name:           "on change",
+			spec:           gnmicv1alpha1.SubscriptionSpec{Mode: "STREAM/ON_CHANGE", HeartbeatInterval: minute},
+			wantMode:       "STREAM",
+			wantStreamMode: "ON_CHANGE",
+			wantHeartbeat:  time.Minute,
+		},
+		{
+			name:           "target defined",
+			spec:           gnmicv1alpha1.SubscriptionSpec{Mode: "STREAM/TARGET_DEFINED", SampleInterval: second},
+			wantMode:       "STREAM",
+			wantStreamMode: "TARGET_DEFINED",
+			wantSample:     time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.spec.Paths = []string{"/interfaces"}
+			cfg := buildSubscriptionConfig("default/p1/sub", &tt.spec, nil, nil)
+			if cfg.Mode != tt.wantMode || cfg.StreamMode != tt.wantStreamMode {
+				t.Fatalf("mode = %s/%s, want %s/%s", cfg.Mode, cfg.StreamMode, tt.wantMode, tt.wantStreamMode)
+			}
+			var sample, heartbeat time.Duration
+			if cfg.SampleInterval != nil {
+				sample = *cfg.SampleInterval
+			}
+			if cfg.HeartbeatInterval != nil {
+				heartbeat = *cfg.HeartbeatInterval
+			}
+			if sample != tt.wantSample || heartbeat != tt.wantHeartbeat {
+				t.Fatalf("intervals = %s/%s, want %s/%s", sample, heartbeat, tt.wantSample, tt.wantHeartbeat)
+			}
+			if len(cfg.Paths) != 1 || len(cfg.StreamSubscriptions) != 0 {
+				t.Fatalf("paths = %v, stream subscriptions = %v", cfg.Paths, cfg.StreamSubscriptions)
+			}
+		})
+	}
+}
+
+func TestBuildSubscriptionConfig_Streams(t *testing.T) {
+	spec := &gnmicv1alpha1.SubscriptionSpec{
+		Prefix:         "/interfaces/interface",
+		Paths:          []string{"state/counters"},
+		Mode:           "STREAM/SAMPLE",
+		SampleInterval: metav1.Duration{Duration: 10 * time.Second},
+		SetTarget:      true,
+		Models:         []string{"openconfig-interfaces"},
+		Streams: []gnmicv1alpha1.SubscriptionStream{
+			{Paths: []string{"state/oper-status"}, Mode: "ON_CHANGE"},
+			{Paths: []string{"state/description"}},
+			{Paths: []string{"config"}, Mode: "TARGET_DEFINED", SampleInterval: metav1.Duration{Duration: time.Minute}},
+		},
+		StreamSubscriptions: []string{"system"},
+	}
+	allSubs := map[string]gnmicv1alpha1.SubscriptionSpec{
+		"default/p1/system": {Prefix: "/system", Paths: []string{"state"}, Mode: "STREAM/ON_CHANGE", SuppressRedundant: true},
+	}
+	cfg := buildSubscriptionConfig("default/p1/interfaces", spec, nil, allSubs)
+	if !cfg.SetTarget || len(cfg.Models) != 1 || cfg.Prefix != "/interfaces/interface" {
+		t.Fatalf("unexpected subscription: %+v", cfg)
+	}
+	if cfg.Mode != "STREAM" || cfg.StreamMode != "" || len(cfg.Paths) != 0 || cfg.SampleInterval != nil {
+		t.Fatalf("the paths and stream mode belong to the stream subscriptions: %+v", cfg)
+	}
+
+	type stream struct {
+		name, path, mode string
+		sample           time.Duration
+		suppress         bool
+	}
+	want := []stream{
+		{path: "state/counters", mode: "SAMPLE", sample: 10 * time.Second},
+		{path: "state/oper-status", mode: "ON_CHANGE"},
+		{path: "state/description", mode: "SAMPLE", sample: 10 * time.Second},
+		{path: "config", mode: "TARGET_DEFINED", sample: time.Minute},
+		{name: "system", path: "/system/state", mode: "ON_CHANGE", suppress: true},
+	}
+	if len(cfg.StreamSubscriptions) != len(want) {
+		t.Fatalf("stream subscriptions = %d, want %d", len(cfg.StreamSubscriptions), len(want))
+	}
+	for i, w := range want {
+		sc := cfg.StreamSubscriptions[i]
+		got := stream{name: sc.Name, mode: sc.StreamMode, suppress: sc.SuppressRedundant}
+		if len(sc.Paths) == 1 {
+			got.path = sc.Paths[0]
+		}
+		if sc.SampleInterval != nil {
+			got.sample = *sc.SampleInterval
+		}
+		if got != w || sc.Mode != "STREAM" {
+			t.Errorf("stream subscription %d = %+v, want %+v", i, got, w)
+		}
+	}
+}
+
 func TestBuildTunnelTargetMatch(t *testing.T) {
 	policy := &gnmicv1alpha1.TunnelTargetPolicySpec{Profile: "default"}
 	profile := &gnmicv1alpha1.TargetProfileSpec{Encoding: "JSON"}
-	match := buildTunnelTargetMatch(policy, profile, &Credentials{Token: "t"}, nil)
+	match := buildTunnelTargetMatch(policy, "default/p", profile, &Credentials{Token: "t"}, nil)
 	if match.Config == nil || match.Config.Insecure == nil || !*match.Config.Insecure {
 		t.Fatalf("unexpected match: %+v", match)
 	}
@@ -198,3 +436,142 @@ func TestBuildTunnelTargetMatch(t *testing.T) {
 		t.Fatal("expected token on tunnel target config")
 	}
 }
+
+func TestBuildOutputConfig_OTLP(t *testing.T) {
+	spec := &gnmicv1alpha1.OutputSpec{
+		Type:   OTLPOutputType,
+		Config: *rawJSON(`{"endpoint":"static:4317","tls":{"skip-verify":true,"ca-file":"/own/ca.crt"},"resource-attributes":{"service.name":"gnmic"}}`),
+	}
+	out, err := buildOutputConfig(spec, &outputConfigOptions{
+		ResolvedAddresses: []string{"otel:4317"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["endpoint"] != "otel:4317" {
+		t.Fatalf("endpoint = %v, want the resolved address", out["endpoint"])
+	}
+	// the output has its own tls settings, not the ones the cluster uses
+	// with its targets
+	tls, ok := out["tls"].(map[string]any)
+	if !ok || !reflect.DeepEqual(tls, map[string]any{"skip-verify": true, "ca-file": "/own/ca.crt"}) {
+		t.Fatalf("tls = %v, want the output's own", out["tls"])
+	}
+	if _, err := json.Marshal(out); err != nil {
+		t.Fatalf("config is not JSON encodable: %v", err)
+	}
+}
+
+// The targets' client TLS of the cluster is not copied into the outputs.
+func TestPlanBuilder_OutputTLSNotFromClientTLS(t *testing.T) {
+	pipeline := NewPipelineData()
+	pipeline.Outputs["default/p1/otel"] = gnmicv1alpha1.OutputSpec{Type: OTLPOutputType, Config: *rawJSON(`{"endpoint":"otel:4317","tls":{}}`)}
+	plan, err := NewPlanBuilder("c", nil).
+		WithClientTLS(&ClientTLSPaths{CertFile: "/cert", KeyFile: "/key", CAFile: "/ca"}).
+		AddPipeline("default/p1", pipeline).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tls := plan.Outputs["default/p1/otel"]["tls"]; !reflect.DeepEqual(tls, map[string]any{}) {
+		t.Fatalf("tls = %v, want the output's own", tls)
+	}
+}
+
+func TestFormatServiceAddress_OTLP(t *testing.T) {
+	for _, tc := range []struct {
+		config string
+		want   string
+	}{
+		{``, "otel:4317"},
+		{`protocol: grpc`, "otel:4317"},
+		{`protocol: http`, "http://otel:4317"},
+		{"protocol: http\ntls: {}", "https://otel:4317"},
+	} {
+		spec := &gnmicv1alpha1.OutputSpec{Type: OTLPOutputType}
+		if tc.config != "" {
+			spec.Config = *rawJSON(tc.config)
+		}
+		if got := FormatServiceAddress(spec, "otel", 4317); got != tc.want {
+			t.Errorf("config %q: address = %q, want %q", tc.config, got, tc.want)
+		}
+	}
+}
+
+func TestBuildGetRequest(t *testing.T) {
+	spec := &gnmicv1alpha1.GetJobSpec{
+		Prefix:   "/system",
+		Paths:    []string{"information", "name"},
+		DataType: "STATE",
+		Encoding: "JSON_IETF",
+	}
+	req, err := BuildGetRequest(spec, "default/router1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.GetPrefix().GetTarget() != "default/router1" || req.GetPrefix().GetElem()[0].GetName() != "system" {
+		t.Fatalf("unexpected prefix: %v", req.GetPrefix())
+	}
+	if len(req.GetPath()) != 2 || req.GetType() != gnmi.GetRequest_STATE || req.GetEncoding() != gnmi.Encoding_JSON_IETF {
+		t.Fatalf("unexpected request: %v", req)
+	}
+
+	spec.Paths = []string{"/a/b[name=x"}
+	if _, err := BuildGetRequest(spec, "default/router1"); err == nil {
+		t.Fatal("expected an error for a malformed path")
+	}
+}
+
+func TestBuildPollSubscribeRequest(t *testing.T) {
+	encoding := "JSON_IETF"
+	sub := &gapi.SubscriptionConfig{
+		Mode:     "POLL",
+		Prefix:   "/interfaces",
+		Paths:    []string{"interface/state", "interface/subinterfaces"},
+		Encoding: &encoding,
+	}
+	req, err := BuildPollSubscribeRequest(sub, "default/router1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := req.GetSubscribe()
+	if list.GetMode() != gnmi.SubscriptionList_POLL || list.GetEncoding() != gnmi.Encoding_JSON_IETF {
+		t.Fatalf("unexpected subscription list: %v", list)
+	}
+	if list.GetPrefix().GetTarget() != "default/router1" || list.GetPrefix().GetElem()[0].GetName() != "interfaces" {
+		t.Fatalf("unexpected prefix: %v", list.GetPrefix())
+	}
+	if len(list.GetSubscription()) != 2 || list.GetSubscription()[1].GetPath().GetElem()[1].GetName() != "subinterfaces" {
+		t.Fatalf("unexpected subscriptions: %v", list.GetSubscription())
+	}
+}
+
+func TestBuildSetRequest(t *testing.T) {
+	spec := &gnmicv1alpha1.SetJobSpec{
+		Deletes:  []string{"/system/banner"},
+		Replaces: []gnmicv1alpha1.SetJobUpdate{{Path: "/system/name", Value: *rawJSON(`"router1"`)}},
+		Updates:  []gnmicv1alpha1.SetJobUpdate{{Path: "/system/grpc-server[name=mgmt]", Value: *rawJSON(`{"admin-state":"enable"}`)}},
+		Encoding: "JSON_IETF",
+	}
+	req, err := BuildSetRequest(spec, "default/router1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.GetPrefix().GetTarget() != "default/router1" {
+		t.Fatalf("unexpected prefix: %v", req.GetPrefix())
+	}
+	if len(req.GetDelete()) != 1 || len(req.GetReplace()) != 1 || len(req.GetUpdate()) != 1 {
+		t.Fatalf("unexpected request: %v", req)
+	}
+	if got := string(req.GetUpdate()[0].GetVal().GetJsonIetfVal()); got != `{"admin-state":"enable"}` {
+		t.Fatalf("update value = %q", got)
+	}
+
+	spec.Encoding = ""
+	req, err = BuildSetRequest(spec, "default/router1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(req.GetReplace()[0].GetVal().GetJsonVal()); got != `"router1"` {
+		t.Fatalf("replace value = %q", got)
+	}
+}
@@ -46,8 +46,8 @@ func TestBuildTargetConfig_AllBranches(t *testing.T) {
 	// client TLS without CA file
 	clientTLS := &ClientTLSPaths{CertFile: "/c", KeyFile: "/k"}
 	cfg = buildTargetConfig(target, profile, nil, clientTLS)
-	if cfg.SkipVerify == nil || !*cfg.SkipVerify {
-		t.Fatal("expected skip verify without CA")
+	if cfg.SkipVerify == nil || *cfg.SkipVerify || cfg.TLSCA != nil {
+		t.Fatal("expected verification with system roots without CA")
 	}
 
 	// client TLS with CA + full profile TLS and keepalives
@@ -78,7 +78,7 @@ func TestBuildTunnelTargetMatch_AllBranches(t *testing.T) {
 	profile := &gnmicv1alpha1.TargetProfileSpec{Encoding: "JSON"}
 
 	// nil profile
-	if m := buildTunnelTargetMatch(policy, nil, nil, nil); m.Config != nil {
+	if m := buildTunnelTargetMatch(policy, "default/p", nil, nil, nil); m.Config != nil {
 		t.Fatal("expected no config without profile")
 	}
 
@@ -87,8 +87,8 @@ func TestBuildTunnelTargetMatch_AllBranches(t *testing.T) {
 		Encoding: "JSON",
 		TLS:      &gnmicv1alpha1.TargetTLSConfig{MaxVersion: "1.3"},
 	}
-	m := buildTunnelTargetMatch(policy, profileTLS, nil, nil)
-	if m.Config.SkipVerify == nil || !*m.Config.SkipVerify {
+	m := buildTunnelTargetMatch(policy, "default/p", profileTLS, nil, nil)
+	if m.Config.SkipVerify == nil || *m.Config.SkipVerify || m.Config.TLSMaxVersion != "1.3" {
 		t.Fatal("profile tls only")
 	}
 
@@ -98,15 +98,22 @@ func TestBuildTunnelTargetMatch_AllBranches(t *testing.T) {
 		TLS:      &gnmicv1alpha1.TargetTLSConfig{ServerName: "tun.example", MinVersion: "1.2"},
 	}
 	clientTLS := &ClientTLSPaths{CertFile: "/c", KeyFile: "/k", CAFile: "/ca"}
-	m = buildTunnelTargetMatch(policy, profileFull, &Credentials{Username: "u"}, clientTLS)
+	m = buildTunnelTargetMatch(policy, "default/p", profileFull, &Credentials{Username: "u"}, clientTLS)
 	if m.Config.TLSServerName != "tun.example" {
 		t.Fatalf("config: %+v", m.Config)
 	}
 
+	// profile CA bundle overrides the cluster one
+	profileFull.TLS.CABundleRef = &gnmicv1alpha1.CABundleRef{Bundle: "devices"}
+	m = buildTunnelTargetMatch(policy, "default/p", profileFull, nil, clientTLS)
+	if *m.Config.TLSCA != "/etc/gnmic/target-tls/default_p/ca.crt" || *m.Config.TLSCert != "/c" {
+		t.Fatalf("config: %+v", m.Config)
+	}
+
 	// client TLS no CA early return when profile.TLS nil
 	profileNoTLS := &gnmicv1alpha1.TargetProfileSpec{Encoding: "JSON"}
 	clientNoCA := &ClientTLSPaths{CertFile: "/c"}
-	m = buildTunnelTargetMatch(policy, profileNoTLS, nil, clientNoCA)
+	m = buildTunnelTargetMatch(policy, "default/p", profileNoTLS, nil, clientNoCA)
 	if m.Config.TLSCert == nil {
 		t.Fatal("expected cert")
 	}
@@ -138,15 +145,17 @@ func TestBuildSubscriptionConfig_Full(t *testing.T) {
 	if cfg.Encoding == nil || cfg.Qos == nil || cfg.History == nil {
 		t.Fatal("expected optional fields")
 	}
-	if len(cfg.StreamSubscriptions) != 1 || cfg.StreamSubscriptions[0] == nil {
-		t.Fatal("expected child subscription")
+	// the paths of the parent are streamed first, the child keeps the name
+	// it is referenced with
+	if len(cfg.StreamSubscriptions) != 2 || cfg.StreamSubscriptions[1] == nil || cfg.StreamSubscriptions[1].Name != "default/child" {
+		t.Fatalf("expected parent paths and child subscription: %+v", cfg.StreamSubscriptions)
 	}
 
-	// missing child in map is skipped
+	// missing child in map is skipped, it no longer leaves a nil slot
 	spec.StreamSubscriptions = []string{"default/missing"}
 	cfg = buildSubscriptionConfig("default/p", spec, nil, map[string]gnmicv1alpha1.SubscriptionSpec{})
-	if cfg.StreamSubscriptions[0] != nil {
-		t.Fatal("expected nil slot for missing child")
+	if len(cfg.StreamSubscriptions) != 0 || len(cfg.Paths) != 1 {
+		t.Fatalf("expected the parent paths only: %+v", cfg)
 	}
 }
 
@@ -775,7 +784,7 @@ func TestBuildTunnelTargetMatch_ProfileTLSVariants(t *testing.T) {
 		Encoding: "JSON",
 		TLS:      &gnmicv1alpha1.TargetTLSConfig{MinVersion: "1.2", CipherSuites: []string{"TLS_RSA"}},
 	}
-	m := buildTunnelTargetMatch(policy, profile, nil, nil)
+	m := buildTunnelTargetMatch(policy, "default/p", profile, nil, nil)
 	if m.Config.TLSMinVersion != "1.2" {
 		t.Fatal("min version")
 	}
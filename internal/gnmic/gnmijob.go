@@ -0,0 +1,76 @@
+package gnmic
+
+import (
+	"strings"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/pkg/api"
+	gapi "github.com/openconfig/gnmic/pkg/api/types"
+	"google.golang.org/protobuf/proto"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// BuildGetRequest creates the gNMI GetRequest of a GetJob for a target.
+// The target is the name of the target in the gNMIc pods, the gNMI server of
+// the pod forwards the request to it.
+func BuildGetRequest(spec *gnmicv1alpha1.GetJobSpec, target string) (*gnmi.GetRequest, error) {
+	opts := []api.GNMIOption{api.Prefix(spec.Prefix), api.Target(target), api.DataType(spec.DataType)}
+	if spec.Encoding != "" {
+		opts = append(opts, api.Encoding(spec.Encoding))
+	}
+	for _, p := range spec.Paths {
+		opts = append(opts, api.Path(p))
+	}
+	return api.NewGetRequest(opts...)
+}
+
+// BuildSetRequest creates the gNMI SetRequest of a SetJob for a target.
+// The values are sent as is, as JSON or JSON_IETF values.
+func BuildSetRequest(spec *gnmicv1alpha1.SetJobSpec, target string) (*gnmi.SetRequest, error) {
+	opts := []api.GNMIOption{api.Prefix(spec.Prefix), api.Target(target)}
+	for _, p := range spec.Deletes {
+		opts = append(opts, api.Delete(p))
+	}
+	for _, u := range spec.Replaces {
+		opts = append(opts, api.Replace(api.Path(u.Path), setJobValue(u.Value, spec.Encoding)))
+	}
+	for _, u := range spec.Updates {
+		opts = append(opts, api.Update(api.Path(u.Path), setJobValue(u.Value, spec.Encoding)))
+	}
+	return api.NewSetRequest(opts...)
+}
+
+// BuildPollSubscribeRequest creates the SubscribeRequest opening a POLL
+// subscription of the plan for a target, sent to the gNMI server of the pod
+// collecting the target. The target is polled by the Poll requests sent on the
+// stream afterwards.
+func BuildPollSubscribeRequest(sub *gapi.SubscriptionConfig, target string) (*gnmi.SubscribeRequest, error) {
+	opts := []api.GNMIOption{api.SubscriptionListModePOLL(), api.Prefix(sub.Prefix), api.Target(target), api.UpdatesOnly(sub.UpdatesOnly)}
+	if sub.Encoding != nil {
+		opts = append(opts, api.Encoding(*sub.Encoding))
+	}
+	if sub.Qos != nil {
+		opts = append(opts, api.Qos(*sub.Qos))
+	}
+	for _, p := range sub.Paths {
+		opts = append(opts, api.Subscription(api.Path(p)))
+	}
+	return api.NewSubscribeRequest(opts...)
+}
+
+// setJobValue sets the value of a gNMI Update to the raw JSON of a SetJob value.
+func setJobValue(value apiextensionsv1.JSON, encoding string) api.GNMIOption {
+	return func(msg proto.Message) error {
+		upd, ok := msg.(*gnmi.Update)
+		if !ok {
+			return api.ErrInvalidMsgType
+		}
+		if strings.EqualFold(encoding, "JSON_IETF") {
+			upd.Val = &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{JsonIetfVal: value.Raw}}
+		} else {
+			upd.Val = &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonVal{JsonVal: value.Raw}}
+		}
+		return nil
+	}
+}
@@ -17,6 +17,9 @@ func buildInputConfig(spec *gnmicv1alpha1.InputSpec, outputs []string, processor
 		}
 	}
 
+	// nested objects (sasl, tls...) must be JSON encodable
+	config = convert(config).(map[string]any)
+
 	// set the type
 	config["type"] = spec.Type
 
@@ -20,7 +20,13 @@ const (
 	FileOutputType            = "file"
 	NATSOutputType            = "nats"
 	JetstreamOutputType       = "jetstream"
-	// TODO: OTLP
+	OTLPOutputType            = "otlp"
+)
+
+const (
+	// OTLP transport protocols
+	OTLPProtocolGRPC = "grpc"
+	OTLPProtocolHTTP = "http"
 )
 
 const (
@@ -36,6 +42,7 @@ var OutputTypesWithServiceRef = map[string]bool{
 	KafkaOutputType:           true,
 	PrometheusWriteOutputType: true,
 	InfluxDBOutputType:        true,
+	OTLPOutputType:            true,
 }
 
 // TODO: move  this under PiplelineData struct
@@ -45,20 +52,10 @@ type outputConfigOptions struct {
 	// Resolved addresses to inject into the output config
 	// list of resolved addresses (e.g: "nats://service:4222")
 	ResolvedAddresses []string
-	// TLS options to inject into the output config
-	TLS *TLSOptions
 	// URL to append to discovered service addresses
 	URL string
 }
 
-type TLSOptions struct {
-	CertFile   string
-	KeyFile    string
-	CAFile     string
-	SkipVerify bool
-	ClientAuth string
-}
-
 // buildOutputConfig creates a gNMIc output config map from an OutputSpec
 // resolvedAddresses contains addresses resolved from serviceRef/serviceSelector
 func buildOutputConfig(spec *gnmicv1alpha1.OutputSpec, options *outputConfigOptions) (map[string]any, error) {
@@ -94,10 +91,17 @@ func buildOutputConfig(spec *gnmicv1alpha1.OutputSpec, options *outputConfigOpti
 
 			case InfluxDBOutputType:
 				config["url"] = strings.Join(options.ResolvedAddresses, ",")
+			case OTLPOutputType:
+				// OTLP exports to a single endpoint, the address of its
+				// serviceRef
+				config["endpoint"] = options.ResolvedAddresses[0]
 			}
 		}
 	}
 
+	// nested objects (tls, resource-attributes...) must be JSON encodable
+	config = convert(config).(map[string]any)
+
 	// apply default values
 	switch spec.Type {
 	case "prometheus":
@@ -109,6 +113,26 @@ func buildOutputConfig(spec *gnmicv1alpha1.OutputSpec, options *outputConfigOpti
 	return config, nil
 }
 
+// OutputConfig returns the config of an output as written to the gNMIc pods,
+// with the addresses resolved from its serviceRef or serviceSelector and the
+// values of its secretRef.
+func OutputConfig(spec *gnmicv1alpha1.OutputSpec, resolvedAddresses []string, secrets map[string]string) (map[string]any, error) {
+	config, err := buildOutputConfig(spec, &outputConfigOptions{ResolvedAddresses: resolvedAddresses})
+	if err != nil {
+		return nil, err
+	}
+	injectSecrets(config, secrets)
+	return config, nil
+}
+
+// OTLPProtocol returns the protocol of an OTLP output config, grpc by default.
+func OTLPProtocol(config map[string]any) string {
+	if protocol, ok := config["protocol"].(string); ok && protocol != "" {
+		return protocol
+	}
+	return OTLPProtocolGRPC
+}
+
 // FormatServiceAddress formats a service address with the appropriate scheme for the output type
 func FormatServiceAddress(spec *gnmicv1alpha1.OutputSpec, host string, port int32) string {
 	switch spec.Type {
@@ -140,6 +164,21 @@ func FormatServiceAddress(spec *gnmicv1alpha1.OutputSpec, host string, port int3
 			}
 		}
 		return fmt.Sprintf("http://%s:%d", host, port)
+	case OTLPOutputType:
+		var config map[string]any
+		if spec.Config.Raw != nil {
+			if err := yaml.Unmarshal(spec.Config.Raw, &config); err != nil {
+				return fmt.Sprintf("%s:%d", host, port)
+			}
+		}
+		// gRPC endpoints are host:port, HTTP endpoints are URLs
+		if OTLPProtocol(config) != OTLPProtocolHTTP {
+			return fmt.Sprintf("%s:%d", host, port)
+		}
+		if config["tls"] != nil {
+			return fmt.Sprintf("https://%s:%d", host, port)
+		}
+		return fmt.Sprintf("http://%s:%d", host, port)
 	default:
 		return fmt.Sprintf("%s:%d", host, port)
 	}
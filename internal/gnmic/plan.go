@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"hash/fnv"
 	"log/slog"
+	"maps"
 	"os"
+	"slices"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/gnmic/operator/api/v1alpha1"
 	"github.com/gnmic/operator/internal/utils"
@@ -107,6 +110,8 @@ func (b *PlanBuilder) Build() (*ApplyPlan, error) {
 		Processors:              make(map[string]map[string]any),
 		TunnelTargetMatches:     make(map[string]*TunnelTargetMatch),
 		PrometheusPorts:         make(map[string]int32),
+		OutputSecretPaths:       make(map[string][]string),
+		InputSecretPaths:        make(map[string][]string),
 	}
 	// Credentials are memoised per build only — see credsCache.
 	b.credsCache = make(map[string]*Credentials)
@@ -280,15 +285,19 @@ func (b *PlanBuilder) findTargetCurrentAssignmentBoundedLoadHashing(targetCR v1a
 	return &podIdx
 }
 
-// fetchCredentialsCached resolves a secret reference, reusing any value already fetched
-// during the current Build(). The cache is cleared at the start of every Build() so that a
-// rotated secret is picked up on the next reconcile.
-func (b *PlanBuilder) fetchCredentialsCached(namespace, secretRef string) (*Credentials, error) {
-	key := namespace + Delimiter + secretRef
+// fetchCredentialsCached resolves the credentials of a target profile, reusing any value
+// already fetched during the current Build(). The cache is cleared at the start of every
+// Build() so that rotated credentials are picked up on the next reconcile.
+// An empty profile name reads the credentials Secret of a target.
+func (b *PlanBuilder) fetchCredentialsCached(namespace, profileName string, profileSpec *v1alpha1.TargetProfileSpec) (*Credentials, error) {
+	key := namespace + Delimiter + profileName
+	if profileName == "" {
+		key = namespace + Delimiter + "Secret/" + profileSpec.Credentials.Secret.Name
+	}
 	if creds, ok := b.credsCache[key]; ok {
 		return creds, nil
 	}
-	creds, err := b.credsFetcher.FetchCredentials(namespace, secretRef)
+	creds, err := b.credsFetcher.FetchCredentials(namespace, profileName, profileSpec)
 	if err != nil {
 		return nil, err
 	}
@@ -299,6 +308,33 @@ func (b *PlanBuilder) fetchCredentialsCached(namespace, secretRef string) (*Cred
 	return creds, nil
 }
 
+// targetCredentialsSpec returns a profile reading the credentials of a target from its
+// Secret, with the key names of the profile's Secret credentials if any
+func targetCredentialsSpec(secretName string, profileSpec *v1alpha1.TargetProfileSpec) *v1alpha1.TargetProfileSpec {
+	src := &v1alpha1.SecretCredentials{Name: secretName}
+	if profileSpec.Credentials != nil && profileSpec.Credentials.Secret != nil {
+		src.CredentialKeys = profileSpec.Credentials.Secret.CredentialKeys
+	}
+	return &v1alpha1.TargetProfileSpec{Credentials: &v1alpha1.CredentialsSource{Secret: src}}
+}
+
+// FetchTargetCredentials fetches the credentials of a target, those of the
+// target overriding the profile's. It returns nil when neither sets any.
+func FetchTargetCredentials(fetcher CredentialsFetcher, target *v1alpha1.Target, profileSpec *v1alpha1.TargetProfileSpec) (*Credentials, error) {
+	switch {
+	case target.Spec.CredentialsRef != "":
+		return fetcher.FetchCredentials(target.Namespace, "", targetCredentialsSpec(target.Spec.CredentialsRef, profileSpec))
+	case hasCredentials(profileSpec):
+		return fetcher.FetchCredentials(target.Namespace, target.Spec.Profile, profileSpec)
+	}
+	return nil, nil
+}
+
+// hasCredentials reports whether a target profile reads credentials from any backend
+func hasCredentials(profileSpec *v1alpha1.TargetProfileSpec) bool {
+	return profileSpec.CredentialsRef != "" || profileSpec.Credentials != nil
+}
+
 func (b *PlanBuilder) buildTargets(plan *ApplyPlan, pipelineData *PipelineData) error {
 	for targetNN, target := range pipelineData.Targets {
 		if _, ok := plan.Targets[targetNN]; ok {
@@ -313,20 +349,45 @@ func (b *PlanBuilder) buildTargets(plan *ApplyPlan, pipelineData *PipelineData)
 			continue
 		}
 
-		// fetch credentials if needed
+		subscriptions := sortedKeys(b.relationships.targetSubscriptions[targetNN])
+		var outputs []string
+		if len(target.Spec.Subscriptions) > 0 {
+			subscriptions = selectNames(subscriptions, namespace, target.Spec.Subscriptions)
+		}
+		if len(target.Spec.Outputs) > 0 {
+			// the outputs the target's subscriptions write to
+			subscriptionOutputs := make(map[string]struct{})
+			for _, subNN := range subscriptions {
+				maps.Copy(subscriptionOutputs, b.relationships.subscriptionOutputs[subNN])
+			}
+			outputs = selectNames(sortedKeys(subscriptionOutputs), namespace, target.Spec.Outputs)
+		}
+		// gNMIc uses all subscriptions or outputs when a target lists none:
+		// a target whose lists match none of its pipelines' is not collected,
+		// which its pipelines report in their ResolvedRefs condition
+		if (len(target.Spec.Subscriptions) > 0 && len(subscriptions) == 0) || (len(target.Spec.Outputs) > 0 && len(outputs) == 0) {
+			logger.Debug("target subscriptions or outputs match none of its pipelines", "target", targetNN)
+			continue
+		}
+
+		// fetch credentials if needed, those of the target override the profile's
 		var creds *Credentials
-		if profileSpec.CredentialsRef != "" && b.credsFetcher != nil {
+		if b.credsFetcher != nil {
 			var err error
-			creds, err = b.fetchCredentialsCached(namespace, profileSpec.CredentialsRef)
+			switch {
+			case target.Spec.CredentialsRef != "":
+				creds, err = b.fetchCredentialsCached(namespace, "", targetCredentialsSpec(target.Spec.CredentialsRef, &profileSpec))
+			case hasCredentials(&profileSpec):
+				creds, err = b.fetchCredentialsCached(namespace, target.Spec.Profile, &profileSpec)
+			}
 			if err != nil {
 				return err
 			}
 		}
 
-		subscriptions := sortedKeys(b.relationships.targetSubscriptions[targetNN])
-
 		targetConfig := buildTargetConfig(&target, &profileSpec, creds, b.clientTLS)
 		targetConfig.Subscriptions = subscriptions
+		targetConfig.Outputs = outputs
 
 		plan.Targets[targetNN] = targetConfig
 	}
@@ -334,6 +395,21 @@ func (b *PlanBuilder) buildTargets(plan *ApplyPlan, pipelineData *PipelineData)
 	return nil
 }
 
+// selectNames returns the namespaced names whose name is listed, keeping
+// their order. The names may be scoped by pipeline, as namespace/pipeline/name.
+func selectNames(namespacedNames []string, namespace string, names []string) []string {
+	selected := make([]string, 0, len(names))
+	for _, nn := range namespacedNames {
+		if !strings.HasPrefix(nn, namespace+Delimiter) {
+			continue
+		}
+		if slices.Contains(names, nn[strings.LastIndex(nn, Delimiter)+1:]) {
+			selected = append(selected, nn)
+		}
+	}
+	return selected
+}
+
 func (b *PlanBuilder) buildSubscriptions(plan *ApplyPlan, pipelineData *PipelineData) {
 	for subNN, subSpec := range pipelineData.Subscriptions {
 		if _, ok := plan.Subscriptions[subNN]; ok {
@@ -364,6 +440,9 @@ func (b *PlanBuilder) buildOutputs(plan *ApplyPlan, pipelineData *PipelineData)
 		if err != nil {
 			return err
 		}
+		if paths := injectSecrets(outputConfig, pipelineData.ResolvedOutputSecrets[outputNN]); len(paths) > 0 {
+			plan.OutputSecretPaths[outputNN] = paths
+		}
 
 		plan.Outputs[outputNN] = outputConfig
 	}
@@ -383,6 +462,9 @@ func (b *PlanBuilder) buildInputs(plan *ApplyPlan, pipelineData *PipelineData) e
 		if err != nil {
 			return err
 		}
+		if paths := injectSecrets(inputConfig, pipelineData.ResolvedInputSecrets[inputNN]); len(paths) > 0 {
+			plan.InputSecretPaths[inputNN] = paths
+		}
 
 		plan.Inputs[inputNN] = inputConfig
 	}
@@ -444,7 +526,8 @@ func (b *PlanBuilder) buildTunnelTargetMatches(plan *ApplyPlan, pipelineData *Pi
 		namespace, _ := utils.SplitNN(policyNN)
 
 		// find the target profile for this policy
-		profileSpec, ok := pipelineData.TargetProfiles[namespace+Delimiter+policySpec.Profile]
+		profileNN := namespace + Delimiter + policySpec.Profile
+		profileSpec, ok := pipelineData.TargetProfiles[profileNN]
 		if !ok {
 			// skip if profile not found - validation should catch this earlier
 			continue
@@ -452,16 +535,16 @@ func (b *PlanBuilder) buildTunnelTargetMatches(plan *ApplyPlan, pipelineData *Pi
 
 		// fetch credentials if needed
 		var creds *Credentials
-		if profileSpec.CredentialsRef != "" && b.credsFetcher != nil {
+		if hasCredentials(&profileSpec) && b.credsFetcher != nil {
 			var err error
-			creds, err = b.fetchCredentialsCached(namespace, profileSpec.CredentialsRef)
+			creds, err = b.fetchCredentialsCached(namespace, policySpec.Profile, &profileSpec)
 			if err != nil {
 				return err
 			}
 		}
 
 		// build the tunnel target match config
-		tunnelMatch := buildTunnelTargetMatch(&policySpec, &profileSpec, creds, b.clientTLS)
+		tunnelMatch := buildTunnelTargetMatch(&policySpec, profileNN, &profileSpec, creds, b.clientTLS)
 		plan.TunnelTargetMatches[policyNN] = tunnelMatch
 	}
 
@@ -17,7 +17,7 @@ type mockCredsFetcher struct {
 	err   error
 }
 
-func (m *mockCredsFetcher) FetchCredentials(_, _ string) (*Credentials, error) {
+func (m *mockCredsFetcher) FetchCredentials(_, _ string, _ *gnmicv1alpha1.TargetProfileSpec) (*Credentials, error) {
 	return m.creds, m.err
 }
 
@@ -110,6 +110,102 @@ func TestPlanBuilder_CredentialsError(t *testing.T) {
 	}
 }
 
+// secretCredsFetcher returns the password of the Secret credentials are read from
+type secretCredsFetcher struct{}
+
+func (secretCredsFetcher) FetchCredentials(_, _ string, profile *gnmicv1alpha1.TargetProfileSpec) (*Credentials, error) {
+	if profile.CredentialsRef != "" {
+		return &Credentials{Username: "admin", Password: profile.CredentialsRef}, nil
+	}
+	src := profile.Credentials.Secret
+	return &Credentials{Username: src.UsernameKey, Password: src.Name}, nil
+}
+
+func TestPlanBuilder_TargetCredentialsOverrideProfile(t *testing.T) {
+	pipeline := NewPipelineData()
+	for _, target := range []struct{ name, credentialsRef string }{{"t1", ""}, {"t2", "t2-local"}} {
+		pipeline.Targets["default/"+target.name] = gnmicv1alpha1.Target{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: target.name},
+			Spec:       gnmicv1alpha1.TargetSpec{Address: "10.0.0.1:57400", Profile: "default", CredentialsRef: target.credentialsRef},
+		}
+	}
+	pipeline.TargetProfiles["default/default"] = gnmicv1alpha1.TargetProfileSpec{
+		Encoding: "JSON",
+		Credentials: &gnmicv1alpha1.CredentialsSource{Secret: &gnmicv1alpha1.SecretCredentials{
+			Name: "shared", CredentialKeys: gnmicv1alpha1.CredentialKeys{UsernameKey: "user"},
+		}},
+	}
+
+	plan, err := NewPlanBuilder("c", secretCredsFetcher{}).AddPipeline("p", pipeline).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := plan.Targets["default/t1"].Password; got == nil || *got != "shared" {
+		t.Fatalf("t1 password = %v, want the profile Secret", got)
+	}
+	// the target Secret is read with the key names of the profile
+	t2 := plan.Targets["default/t2"]
+	if t2.Password == nil || *t2.Password != "t2-local" || t2.Username == nil || *t2.Username != "user" {
+		t.Fatalf("t2 credentials = %v/%v, want the target Secret with the profile keys", t2.Username, t2.Password)
+	}
+}
+
+func TestPlanBuilder_TargetSubscriptionsAndOutputs(t *testing.T) {
+	pipeline := NewPipelineData()
+	for name, spec := range map[string]gnmicv1alpha1.TargetSpec{
+		"t1": {Address: "10.0.0.1:57400", Profile: "default"},
+		"t2": {Address: "10.0.0.2:57400", Profile: "default", Subscriptions: []string{"interfaces", "bgp"}, Outputs: []string{"kafka"}},
+		"t3": {Address: "10.0.0.3:57400", Profile: "default", Subscriptions: []string{"unknown"}},
+	} {
+		pipeline.Targets["default/"+name] = gnmicv1alpha1.Target{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}, Spec: spec}
+	}
+	pipeline.TargetProfiles["default/default"] = gnmicv1alpha1.TargetProfileSpec{Encoding: "JSON"}
+	pipeline.Subscriptions["default/interfaces"] = gnmicv1alpha1.SubscriptionSpec{Paths: []string{"/interfaces"}, Mode: "ONCE"}
+	pipeline.Subscriptions["default/system"] = gnmicv1alpha1.SubscriptionSpec{Paths: []string{"/system"}, Mode: "ONCE"}
+	pipeline.Outputs["default/kafka"] = gnmicv1alpha1.OutputSpec{Type: "kafka"}
+	pipeline.Outputs["default/prom"] = gnmicv1alpha1.OutputSpec{Type: "file"}
+
+	plan, err := NewPlanBuilder("c", nil).AddPipeline("p", pipeline).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t1 := plan.Targets["default/t1"]
+	if !reflect.DeepEqual(t1.Subscriptions, []string{"default/interfaces", "default/system"}) || t1.Outputs != nil {
+		t.Fatalf("t1 = %v %v, want all subscriptions and outputs", t1.Subscriptions, t1.Outputs)
+	}
+	t2 := plan.Targets["default/t2"]
+	if !reflect.DeepEqual(t2.Subscriptions, []string{"default/interfaces"}) || !reflect.DeepEqual(t2.Outputs, []string{"default/kafka"}) {
+		t.Fatalf("t2 = %v %v, want the listed subscriptions and outputs of its pipeline", t2.Subscriptions, t2.Outputs)
+	}
+	// an empty list would make gNMIc use every subscription
+	if _, ok := plan.Targets["default/t3"]; ok {
+		t.Fatal("t3 lists no subscription of its pipeline, it must not be collected")
+	}
+}
+
+// The controller scopes the subscriptions and outputs by pipeline.
+func TestPlanBuilder_TargetListsScopedByPipeline(t *testing.T) {
+	pipeline := NewPipelineData()
+	pipeline.Targets["default/t1"] = gnmicv1alpha1.Target{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "t1"},
+		Spec:       gnmicv1alpha1.TargetSpec{Address: "10.0.0.1:57400", Profile: "default", Subscriptions: []string{"interfaces"}, Outputs: []string{"kafka"}},
+	}
+	pipeline.TargetProfiles["default/default"] = gnmicv1alpha1.TargetProfileSpec{Encoding: "JSON"}
+	pipeline.Subscriptions["default/p1/interfaces"] = gnmicv1alpha1.SubscriptionSpec{Paths: []string{"/interfaces"}, Mode: "ONCE"}
+	pipeline.Subscriptions["default/p1/system"] = gnmicv1alpha1.SubscriptionSpec{Paths: []string{"/system"}, Mode: "ONCE"}
+	pipeline.Outputs["default/p1/kafka"] = gnmicv1alpha1.OutputSpec{Type: "kafka"}
+	pipeline.Outputs["default/p1/file"] = gnmicv1alpha1.OutputSpec{Type: "file"}
+
+	plan, err := NewPlanBuilder("c", nil).AddPipeline("default/p1", pipeline).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t1, ok := plan.Targets["default/t1"]
+	if !ok || !reflect.DeepEqual(t1.Subscriptions, []string{"default/p1/interfaces"}) || !reflect.DeepEqual(t1.Outputs, []string{"default/p1/kafka"}) {
+		t.Fatalf("t1 = %+v, want the listed subscription and output of its pipeline", t1)
+	}
+}
+
 func TestPlanBuilder_RelationshipSlicesSorted(t *testing.T) {
 	// Multiple names force map-iteration order to matter if slices are left unsorted.
 	pipeline := NewPipelineData()
@@ -0,0 +1,134 @@
+package gnmic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// PlanDiff describes the differences between two apply plans, per plan section.
+// Entries are the plan keys (e.g. namespace/name for targets) that were added,
+// removed or changed between the "from" and the "to" plan.
+type PlanDiff struct {
+	Targets             SectionDiff `json:"targets"`
+	Subscriptions       SectionDiff `json:"subscriptions"`
+	Outputs             SectionDiff `json:"outputs"`
+	Inputs              SectionDiff `json:"inputs"`
+	Processors          SectionDiff `json:"processors"`
+	TunnelTargetMatches SectionDiff `json:"tunnel-target-matches"`
+}
+
+// SectionDiff lists the keys that differ within a single plan section
+type SectionDiff struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+}
+
+// Empty reports whether the section has no differences
+func (d SectionDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Empty reports whether the two plans are identical in every section
+func (d *PlanDiff) Empty() bool {
+	for _, s := range d.sections() {
+		if !s.diff.Empty() {
+			return false
+		}
+	}
+	return true
+}
+
+// Summary returns one human readable line per differing entry,
+// e.g. "processor default/pipeline1/drop-fields changed".
+func (d *PlanDiff) Summary() []string {
+	var out []string
+	for _, s := range d.sections() {
+		for _, k := range s.diff.Added {
+			out = append(out, fmt.Sprintf("%s %s added", s.kind, k))
+		}
+		for _, k := range s.diff.Removed {
+			out = append(out, fmt.Sprintf("%s %s removed", s.kind, k))
+		}
+		for _, k := range s.diff.Changed {
+			out = append(out, fmt.Sprintf("%s %s changed", s.kind, k))
+		}
+	}
+	return out
+}
+
+type namedSectionDiff struct {
+	kind string
+	diff SectionDiff
+}
+
+func (d *PlanDiff) sections() []namedSectionDiff {
+	return []namedSectionDiff{
+		{kind: "target", diff: d.Targets},
+		{kind: "subscription", diff: d.Subscriptions},
+		{kind: "output", diff: d.Outputs},
+		{kind: "input", diff: d.Inputs},
+		{kind: "processor", diff: d.Processors},
+		{kind: "tunnel-target-match", diff: d.TunnelTargetMatches},
+	}
+}
+
+// DiffPlans compares two apply plans. A nil plan is treated as an empty plan.
+// CurrentTargetAssignment and PrometheusPorts are not compared: the former
+// reflects where targets currently run rather than what was configured, and
+// the latter is derived from the outputs.
+func DiffPlans(from, to *ApplyPlan) *PlanDiff {
+	if from == nil {
+		from = &ApplyPlan{}
+	}
+	if to == nil {
+		to = &ApplyPlan{}
+	}
+	return &PlanDiff{
+		Targets:             diffSection(from.Targets, to.Targets),
+		Subscriptions:       diffSection(from.Subscriptions, to.Subscriptions),
+		Outputs:             diffSection(from.Outputs, to.Outputs),
+		Inputs:              diffSection(from.Inputs, to.Inputs),
+		Processors:          diffSection(from.Processors, to.Processors),
+		TunnelTargetMatches: diffSection(from.TunnelTargetMatches, to.TunnelTargetMatches),
+	}
+}
+
+func diffSection[V any](from, to map[string]V) SectionDiff {
+	var d SectionDiff
+	for k, fv := range from {
+		tv, ok := to[k]
+		if !ok {
+			d.Removed = append(d.Removed, k)
+			continue
+		}
+		if !sameEntry(fv, tv) {
+			d.Changed = append(d.Changed, k)
+		}
+	}
+	for k := range to {
+		if _, ok := from[k]; !ok {
+			d.Added = append(d.Added, k)
+		}
+	}
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Strings(d.Changed)
+	return d
+}
+
+// sameEntry compares two plan entries by their JSON encoding, which is what
+// is sent to the gNMIc pods. A plan read back from storage holds decoded
+// values (e.g. float64 numbers) that marshal identically to the originals
+// but would not compare equal as Go values.
+func sameEntry(a, b any) bool {
+	ab, errA := json.Marshal(a)
+	bb, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return bytes.Equal(ab, bb)
+}
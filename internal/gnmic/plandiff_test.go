@@ -0,0 +1,77 @@
+package gnmic
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	gapi "github.com/openconfig/gnmic/pkg/api/types"
+)
+
+func TestDiffPlans(t *testing.T) {
+	from := &ApplyPlan{
+		Targets: map[string]*gapi.TargetConfig{
+			"default/t1": {Name: "default/t1", Address: "10.0.0.1:57400"},
+			"default/t2": {Name: "default/t2", Address: "10.0.0.2:57400"},
+		},
+		Processors: map[string]map[string]any{
+			"default/p1/drop": {"event-drop": map[string]any{"condition": "true"}},
+		},
+	}
+	to := &ApplyPlan{
+		Targets: map[string]*gapi.TargetConfig{
+			"default/t1": {Name: "default/t1", Address: "10.0.0.1:57400"},
+			"default/t3": {Name: "default/t3", Address: "10.0.0.3:57400"},
+		},
+		Processors: map[string]map[string]any{
+			"default/p1/drop": {"event-drop": map[string]any{"condition": "false"}},
+		},
+	}
+
+	d := DiffPlans(from, to)
+	if !slices.Equal(d.Targets.Added, []string{"default/t3"}) {
+		t.Fatalf("targets added = %v", d.Targets.Added)
+	}
+	if !slices.Equal(d.Targets.Removed, []string{"default/t2"}) {
+		t.Fatalf("targets removed = %v", d.Targets.Removed)
+	}
+	if len(d.Targets.Changed) != 0 {
+		t.Fatalf("targets changed = %v", d.Targets.Changed)
+	}
+	if !slices.Equal(d.Processors.Changed, []string{"default/p1/drop"}) {
+		t.Fatalf("processors changed = %v", d.Processors.Changed)
+	}
+	if d.Empty() {
+		t.Fatal("expected non-empty diff")
+	}
+	want := []string{
+		"target default/t3 added",
+		"target default/t2 removed",
+		"processor default/p1/drop changed",
+	}
+	if got := d.Summary(); !slices.Equal(got, want) {
+		t.Fatalf("summary = %v, want %v", got, want)
+	}
+}
+
+func TestDiffPlansDecodedPlanIsUnchanged(t *testing.T) {
+	plan := &ApplyPlan{
+		Outputs: map[string]map[string]any{
+			"default/p1/prom": {"type": "prometheus", "listen": ":9804", "expiration": 60},
+		},
+	}
+	b, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded ApplyPlan
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if d := DiffPlans(plan, &decoded); !d.Empty() {
+		t.Fatalf("expected empty diff, got %v", d.Summary())
+	}
+	if d := DiffPlans(nil, nil); !d.Empty() {
+		t.Fatal("expected empty diff for nil plans")
+	}
+}
@@ -0,0 +1,211 @@
+package gnmic
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gnmic/operator/internal/utils"
+	gapi "github.com/openconfig/gnmic/pkg/api/types"
+)
+
+// RedactedValue replaces secret values in plans returned by the API
+const RedactedValue = utils.RedactedValue
+
+// setConfigPath sets the value at a dot-separated config path, creating the
+// intermediate objects. A non-object value on the way is replaced.
+func setConfigPath(config map[string]any, path string, value any) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := config[key].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			config[key] = next
+		}
+		config = next
+	}
+	config[keys[len(keys)-1]] = value
+}
+
+// getConfigPath returns the value at a dot-separated config path.
+func getConfigPath(config map[string]any, path string) (any, bool) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := config[key].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		config = next
+	}
+	v, ok := config[keys[len(keys)-1]]
+	return v, ok
+}
+
+// deleteConfigPath removes the value at a dot-separated config path.
+// Intermediate objects left empty are kept.
+func deleteConfigPath(config map[string]any, path string) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := config[key].(map[string]any)
+		if !ok {
+			return
+		}
+		config = next
+	}
+	delete(config, keys[len(keys)-1])
+}
+
+// copyConfig returns a deep copy of a config object.
+func copyConfig(config map[string]any) map[string]any {
+	if config == nil {
+		return nil
+	}
+	return convert(config).(map[string]any)
+}
+
+// injectSecrets writes secret values into a config and returns their paths, sorted.
+func injectSecrets(config map[string]any, secrets map[string]string) []string {
+	if len(secrets) == 0 {
+		return nil
+	}
+	paths := make([]string, 0, len(secrets))
+	for path, value := range secrets {
+		setConfigPath(config, path, value)
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// mapSecretConfigs returns a copy of configs where the configs with secret
+// paths are deep copied and passed to fn. Other configs are shared.
+func mapSecretConfigs(configs map[string]map[string]any, secretPaths map[string][]string, fn func(config map[string]any, paths []string)) map[string]map[string]any {
+	if configs == nil {
+		return nil
+	}
+	out := make(map[string]map[string]any, len(configs))
+	for name, config := range configs {
+		paths, ok := secretPaths[name]
+		if !ok || config == nil {
+			out[name] = config
+			continue
+		}
+		c := copyConfig(config)
+		fn(c, paths)
+		out[name] = c
+	}
+	return out
+}
+
+// RedactPlan returns a shallow copy of the plan safe to expose: target and
+// tunnel target match passwords and tokens, the output and input config values
+// resolved from Secrets and the values of known secret keys (see
+// utils.IsSecretKey) in output, input and processor configs are replaced with
+// RedactedValue.
+func RedactPlan(plan *ApplyPlan) *ApplyPlan {
+	if plan == nil {
+		return nil
+	}
+	p := *plan
+	if plan.Targets != nil {
+		p.Targets = make(map[string]*gapi.TargetConfig, len(plan.Targets))
+		for name, tc := range plan.Targets {
+			p.Targets[name] = redactTargetConfig(tc)
+		}
+	}
+	if plan.TunnelTargetMatches != nil {
+		p.TunnelTargetMatches = make(map[string]*TunnelTargetMatch, len(plan.TunnelTargetMatches))
+		for name, m := range plan.TunnelTargetMatches {
+			if m == nil || m.Config == nil {
+				p.TunnelTargetMatches[name] = m
+				continue
+			}
+			mc := *m
+			mc.Config = redactTargetConfig(m.Config)
+			p.TunnelTargetMatches[name] = &mc
+		}
+	}
+	p.Outputs = redactConfigs(plan.Outputs, plan.OutputSecretPaths)
+	p.Inputs = redactConfigs(plan.Inputs, plan.InputSecretPaths)
+	p.Processors = redactConfigs(plan.Processors, nil)
+	return &p
+}
+
+// redactTargetConfig returns a copy of a target config with its password and
+// token redacted. The username is kept.
+func redactTargetConfig(tc *gapi.TargetConfig) *gapi.TargetConfig {
+	if tc == nil {
+		return nil
+	}
+	c := *tc
+	redacted := RedactedValue
+	if c.Password != nil && *c.Password != "" {
+		c.Password = &redacted
+	}
+	if c.Token != nil && *c.Token != "" {
+		c.Token = &redacted
+	}
+	return &c
+}
+
+// redactConfigs redacts the known secret keys and the given secret paths of
+// a set of configs.
+func redactConfigs(configs map[string]map[string]any, secretPaths map[string][]string) map[string]map[string]any {
+	if configs == nil {
+		return nil
+	}
+	out := make(map[string]map[string]any, len(configs))
+	for name, config := range configs {
+		if config == nil {
+			out[name] = nil
+			continue
+		}
+		c := utils.Redact(config).(map[string]any)
+		for _, path := range secretPaths[name] {
+			if _, ok := getConfigPath(c, path); ok {
+				setConfigPath(c, path, RedactedValue)
+			}
+		}
+		out[name] = c
+	}
+	return out
+}
+
+// WithoutSecrets returns a shallow copy of the plan where the output and input
+// config values resolved from Secrets are removed.
+func WithoutSecrets(plan *ApplyPlan) *ApplyPlan {
+	p := *plan
+	strip := func(config map[string]any, paths []string) {
+		for _, path := range paths {
+			deleteConfigPath(config, path)
+		}
+	}
+	p.Outputs = mapSecretConfigs(plan.Outputs, plan.OutputSecretPaths, strip)
+	p.Inputs = mapSecretConfigs(plan.Inputs, plan.InputSecretPaths, strip)
+	return &p
+}
+
+// RestoreSecrets copies the output and input config values resolved from
+// Secrets in the live plan onto the matching configs of a plan stripped with
+// WithoutSecrets. Configs that no longer exist in the live plan are left
+// without their secret values.
+func RestoreSecrets(plan, live *ApplyPlan) {
+	plan.OutputSecretPaths = restoreConfigSecrets(plan.Outputs, live.Outputs, live.OutputSecretPaths)
+	plan.InputSecretPaths = restoreConfigSecrets(plan.Inputs, live.Inputs, live.InputSecretPaths)
+}
+
+func restoreConfigSecrets(configs, live map[string]map[string]any, livePaths map[string][]string) map[string][]string {
+	restored := make(map[string][]string)
+	for name, paths := range livePaths {
+		config, ok := configs[name]
+		if !ok || config == nil || live[name] == nil {
+			continue
+		}
+		for _, path := range paths {
+			if v, ok := getConfigPath(live[name], path); ok {
+				setConfigPath(config, path, v)
+				restored[name] = append(restored[name], path)
+			}
+		}
+	}
+	return restored
+}
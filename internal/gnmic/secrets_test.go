@@ -0,0 +1,92 @@
+package gnmic
+
+import (
+	"testing"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	gapi "github.com/openconfig/gnmic/pkg/api/types"
+)
+
+func TestBuildOutputsInjectsSecrets(t *testing.T) {
+	pd := NewPipelineData()
+	pd.Outputs["default/p1/o1"] = gnmicv1alpha1.OutputSpec{
+		Type:   KafkaOutputType,
+		Config: *rawJSON(`{"topic":"telemetry","sasl":{"user":"gnmic","password":"plaintext"}}`),
+	}
+	pd.ResolvedOutputSecrets["default/p1/o1"] = map[string]string{"sasl.password": "s3cr3t"}
+	pd.Inputs["default/p1/i1"] = gnmicv1alpha1.InputSpec{
+		Type:   "nats",
+		Config: *rawJSON(`{"address":"nats:4222"}`),
+	}
+	pd.ResolvedInputSecrets["default/p1/i1"] = map[string]string{"password": "n4ts"}
+
+	b := NewPlanBuilder("c1", nil)
+	b.AddPipeline("default/p1", pd)
+	plan, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sasl := plan.Outputs["default/p1/o1"]["sasl"].(map[string]any)
+	if sasl["password"] != "s3cr3t" || sasl["user"] != "gnmic" {
+		t.Fatalf("sasl = %v, want the Secret value injected next to the config", sasl)
+	}
+	if plan.Inputs["default/p1/i1"]["password"] != "n4ts" {
+		t.Fatalf("input = %v", plan.Inputs["default/p1/i1"])
+	}
+
+	redacted := RedactPlan(plan)
+	if got := redacted.Outputs["default/p1/o1"]["sasl"].(map[string]any)["password"]; got != RedactedValue {
+		t.Fatalf("redacted password = %v", got)
+	}
+	if got := redacted.Inputs["default/p1/i1"]["password"]; got != RedactedValue {
+		t.Fatalf("redacted input password = %v", got)
+	}
+	// the plan sent to the pods is untouched
+	if sasl["password"] != "s3cr3t" {
+		t.Fatal("redaction modified the plan")
+	}
+
+	stripped := WithoutSecrets(plan)
+	if _, ok := stripped.Outputs["default/p1/o1"]["sasl"].(map[string]any)["password"]; ok {
+		t.Fatal("expected the password removed")
+	}
+	RestoreSecrets(stripped, plan)
+	if stripped.Outputs["default/p1/o1"]["sasl"].(map[string]any)["password"] != "s3cr3t" {
+		t.Fatal("expected the password restored from the live plan")
+	}
+}
+
+func TestRedactPlan(t *testing.T) {
+	password, token, user := "p4ss", "t0k3n", "admin"
+	plan := &ApplyPlan{
+		Targets: map[string]*gapi.TargetConfig{
+			"default/r1": {Name: "default/r1", Username: &user, Password: &password, Token: &token},
+			"default/r2": {Name: "default/r2"},
+		},
+		TunnelTargetMatches: map[string]*TunnelTargetMatch{
+			"default/m1": {Type: "router", Config: &gapi.TargetConfig{Username: &user, Password: &password}},
+		},
+		Outputs: map[string]map[string]any{
+			"default/p1/o1": {"type": "influxdb", "url": "http://influx:8086", "token": "influx-token"},
+		},
+	}
+
+	redacted := RedactPlan(plan)
+	r1 := redacted.Targets["default/r1"]
+	if *r1.Password != RedactedValue || *r1.Token != RedactedValue || *r1.Username != user {
+		t.Fatalf("target = %+v", r1)
+	}
+	if redacted.Targets["default/r2"].Password != nil {
+		t.Fatal("expected an unset password to stay unset")
+	}
+	if got := *redacted.TunnelTargetMatches["default/m1"].Config.Password; got != RedactedValue {
+		t.Fatalf("tunnel match password = %v", got)
+	}
+	if o := redacted.Outputs["default/p1/o1"]; o["token"] != RedactedValue || o["url"] != "http://influx:8086" {
+		t.Fatalf("output = %v", o)
+	}
+	// the plan sent to the pods is untouched
+	if *plan.Targets["default/r1"].Password != password || plan.Outputs["default/p1/o1"]["token"] != "influx-token" {
+		t.Fatal("redaction modified the plan")
+	}
+}
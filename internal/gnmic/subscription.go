@@ -2,27 +2,30 @@ package gnmic
 
 import (
 	"strings"
+	"time"
 
 	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
 	gapi "github.com/openconfig/gnmic/pkg/api/types"
 )
 
-// buildSubscriptionConfig creates a gNMIc SubscriptionConfig from a Subscription
-// TODO: complete the mapping from spec to config
+// buildSubscriptionConfig creates a gNMIc SubscriptionConfig from a Subscription.
+// Its stream subscriptions are looked up in allSubs by key, or by name among
+// the subscriptions of the same pipeline, keyed like subNN.
 func buildSubscriptionConfig(subNN string, subscription *gnmicv1alpha1.SubscriptionSpec, outputs []string, allSubs map[string]gnmicv1alpha1.SubscriptionSpec) *gapi.SubscriptionConfig {
 	mode, streamMode := specModeToConfig(subscription.Mode)
 
 	config := &gapi.SubscriptionConfig{
 		Name:              subNN,
+		Models:            subscription.Models,
 		Prefix:            subscription.Prefix,
+		Target:            subscription.Target,
+		SetTarget:         subscription.SetTarget,
 		Paths:             subscription.Paths,
 		Mode:              mode,
 		StreamMode:        streamMode,
 		UpdatesOnly:       subscription.UpdatesOnly,
 		SuppressRedundant: subscription.SuppressRedundant,
 		Depth:             subscription.Depth,
-		Target:            subscription.Target,
-		Outputs:           outputs,
 	}
 
 	if len(outputs) > 0 {
@@ -48,20 +51,98 @@ func buildSubscriptionConfig(subNN string, subscription *gnmicv1alpha1.Subscript
 			End:      subscription.History.End.Time,
 		}
 	}
-	// handle streamSubscriptions
-	if len(subscription.StreamSubscriptions) > 0 {
-		config.StreamSubscriptions = make([]*gapi.SubscriptionConfig, len(subscription.StreamSubscriptions))
-		for i, streamSubscription := range subscription.StreamSubscriptions {
-			streamSubSpec, ok := allSubs[streamSubscription]
-			if !ok {
-				continue
-			}
-			config.StreamSubscriptions[i] = buildSubscriptionConfig(streamSubscription, &streamSubSpec, nil, nil)
-		}
+
+	streams := buildStreamSubscriptionConfigs(subNN, subscription, allSubs)
+	if len(streams) == 0 {
+		return config
+	}
+	// gNMIc sends either the paths of a subscription or its stream
+	// subscriptions: the paths of the subscription go first, with its
+	// own stream mode
+	if len(subscription.Paths) > 0 {
+		streams = append([]*gapi.SubscriptionConfig{
+			streamConfig("", "", subscription.Paths, streamMode, config.SampleInterval, config.HeartbeatInterval, subscription.SuppressRedundant),
+		}, streams...)
 	}
+	config.Paths = nil
+	config.StreamMode = ""
+	config.SampleInterval = nil
+	config.HeartbeatInterval = nil
+	config.SuppressRedundant = false
+	config.StreamSubscriptions = streams
 	return config
 }
 
+// buildStreamSubscriptionConfigs returns the stream subscriptions of a
+// Subscription: its streams, then the Subscriptions it references. A stream
+// without a mode or intervals of its own takes those of the Subscription. A
+// referenced Subscription missing from allSubs is skipped, the pipeline
+// reports it as an unresolved reference.
+func buildStreamSubscriptionConfigs(subNN string, subscription *gnmicv1alpha1.SubscriptionSpec, allSubs map[string]gnmicv1alpha1.SubscriptionSpec) []*gapi.SubscriptionConfig {
+	_, defaultMode := specModeToConfig(subscription.Mode)
+	var streams []*gapi.SubscriptionConfig
+	for _, stream := range subscription.Streams {
+		mode := stream.Mode
+		if mode == "" {
+			mode = defaultMode
+		}
+		sampleInterval, heartbeatInterval := stream.SampleInterval.Duration, stream.HeartbeatInterval.Duration
+		if sampleInterval == 0 && mode != "ON_CHANGE" {
+			sampleInterval = subscription.SampleInterval.Duration
+		}
+		if heartbeatInterval == 0 {
+			heartbeatInterval = subscription.HeartbeatInterval.Duration
+		}
+		streams = append(streams, streamConfig("", "", stream.Paths, mode,
+			durationOrNil(sampleInterval), durationOrNil(heartbeatInterval), stream.SuppressRedundant))
+	}
+
+	// the referenced subscriptions are keyed by pipeline like subNN; they
+	// keep the name they are referenced with
+	pipelinePrefix := subNN[:strings.LastIndex(subNN, Delimiter)+1]
+	for _, name := range subscription.StreamSubscriptions {
+		streamSub, ok := allSubs[name]
+		if !ok {
+			streamSub, ok = allSubs[pipelinePrefix+name]
+		}
+		if !ok {
+			continue
+		}
+		_, mode := specModeToConfig(streamSub.Mode)
+		streams = append(streams, streamConfig(name, streamSub.Prefix, streamSub.Paths, mode,
+			durationOrNil(streamSub.SampleInterval.Duration), durationOrNil(streamSub.HeartbeatInterval.Duration), streamSub.SuppressRedundant))
+	}
+	return streams
+}
+
+// streamConfig returns a stream subscription of paths. A prefix is joined to
+// the paths, stream subscriptions share the prefix of their subscription.
+func streamConfig(name, prefix string, paths []string, streamMode string, sampleInterval, heartbeatInterval *time.Duration, suppressRedundant bool) *gapi.SubscriptionConfig {
+	if prefix != "" {
+		prefixed := make([]string, 0, len(paths))
+		for _, p := range paths {
+			prefixed = append(prefixed, strings.TrimSuffix(prefix, "/")+"/"+strings.TrimPrefix(p, "/"))
+		}
+		paths = prefixed
+	}
+	return &gapi.SubscriptionConfig{
+		Name:              name,
+		Paths:             paths,
+		Mode:              "STREAM",
+		StreamMode:        streamMode,
+		SampleInterval:    sampleInterval,
+		HeartbeatInterval: heartbeatInterval,
+		SuppressRedundant: suppressRedundant,
+	}
+}
+
+func durationOrNil(d time.Duration) *time.Duration {
+	if d <= 0 {
+		return nil
+	}
+	return &d
+}
+
 // specModeToConfig splits a mode string like "STREAM/SAMPLE" into mode and stream mode
 func specModeToConfig(mode string) (string, string) {
 	parts := strings.SplitN(mode, "/", 2)
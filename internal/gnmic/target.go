@@ -1,6 +1,8 @@
 package gnmic
 
 import (
+	"maps"
+	"path"
 	"time"
 
 	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
@@ -12,12 +14,98 @@ import (
 // buildTargetConfig creates a gNMIc TargetConfig from a Target and TargetProfile
 // clientTLS contains paths to client certificates for mTLS with targets (from cluster.Spec.ClientTLS)
 func buildTargetConfig(target *gnmicv1alpha1.Target, profile *gnmicv1alpha1.TargetProfileSpec, creds *Credentials, clientTLS *ClientTLSPaths) *gapi.TargetConfig {
+	config := buildProfileConfig(target.Namespace+Delimiter+target.Spec.Profile, profile, creds, clientTLS)
+	config.Name = target.Namespace + Delimiter + target.Name
+	config.Address = target.Spec.Address
+
+	// tunnel-target-type is not mapped: gNMIc sets it on the targets that
+	// register through its tunnel server, which TunnelTargetPolicies match,
+	// and only dials those through the tunnel
+
+	// the fields of the target override those of its profile
+	if target.Spec.BufferSize != nil {
+		config.BufferSize = uint(*target.Spec.BufferSize)
+	}
+	applyTargetServerName(config, target)
+	config.Metadata = mergeStringMaps(config.Metadata, target.Spec.Metadata)
+	// event tags: the profile labels, then the selected target labels and
+	// annotations, then the labels of the target spec
+	config.EventTags = mergeStringMaps(config.EventTags, targetTags(target, profile.TargetTags))
+	config.EventTags = mergeStringMaps(config.EventTags, target.Spec.Labels)
+	return config
+}
+
+// TargetTLS returns the TLS fields of the gNMIc configuration of a target, as
+// the gNMIc pods with clientTLS connect to it: Insecure in plaintext, otherwise
+// the files of the CA bundle and client certificate, and whether the server
+// certificate is verified.
+func TargetTLS(target *gnmicv1alpha1.Target, profile *gnmicv1alpha1.TargetProfileSpec, clientTLS *ClientTLSPaths) *gapi.TargetConfig {
+	config := &gapi.TargetConfig{}
+	applyTargetTLS(config, target.Namespace+Delimiter+target.Spec.Profile, profile, clientTLS)
+	applyTargetServerName(config, target)
+	return config
+}
+
+// applyTargetServerName sets the TLS server name of a target, which overrides
+// the one of its profile
+func applyTargetServerName(config *gapi.TargetConfig, target *gnmicv1alpha1.Target) {
+	if target.Spec.TLSServerName != "" && !ptr.Deref(config.Insecure, false) {
+		config.TLSServerName = target.Spec.TLSServerName
+	}
+}
+
+// targetTags returns the labels and annotations of a target selected by the
+// targetTags of its profile, by event tag name
+func targetTags(target *gnmicv1alpha1.Target, config *gnmicv1alpha1.TargetTagsConfig) map[string]string {
+	if config == nil {
+		return nil
+	}
+	tags := make(map[string]string)
+	for _, selected := range []struct {
+		tags   []gnmicv1alpha1.TargetTag
+		values map[string]string
+	}{
+		{config.Labels, target.Labels},
+		{config.Annotations, target.Annotations},
+	} {
+		for _, tag := range selected.tags {
+			value, ok := selected.values[tag.Key]
+			if !ok {
+				continue
+			}
+			tags[TargetTagName(tag)] = value
+		}
+	}
+	return tags
+}
+
+// TargetTagName returns the name of the event tag of a target label or
+// annotation: its name, or the key without its prefix.
+func TargetTagName(tag gnmicv1alpha1.TargetTag) string {
+	if tag.Name != "" {
+		return tag.Name
+	}
+	return path.Base(tag.Key)
+}
+
+// buildProfileConfig creates the part of a gNMIc TargetConfig set by a
+// TargetProfile, shared by Targets and tunnel targets.
+// profileNN is the namespaced name of the profile, locating its mounted files.
+func buildProfileConfig(profileNN string, profile *gnmicv1alpha1.TargetProfileSpec, creds *Credentials, clientTLS *ClientTLSPaths) *gapi.TargetConfig {
 	config := &gapi.TargetConfig{
-		Name:       target.Namespace + Delimiter + target.Name,
-		Address:    target.Spec.Address,
 		Timeout:    durationOrDefault(&profile.Timeout, 10*time.Second),
 		RetryTimer: durationOrDefault(&profile.RetryTimer, 2*time.Second),
 		Encoding:   ptr.To(profile.Encoding),
+		AuthScheme: profile.AuthScheme,
+		Proxy:      profile.Proxy,
+		Metadata:   mergeStringMaps(nil, profile.Metadata),
+		EventTags:  mergeStringMaps(nil, profile.Labels),
+	}
+	if profile.GzipCompression {
+		config.Gzip = ptr.To(true)
+	}
+	if profile.BufferSize != nil {
+		config.BufferSize = uint(*profile.BufferSize)
 	}
 
 	// set credentials if provided
@@ -33,43 +121,58 @@ func buildTargetConfig(target *gnmicv1alpha1.Target, profile *gnmicv1alpha1.Targ
 		}
 	}
 
+	applyTargetTLS(config, profileNN, profile, clientTLS)
+	applyKeepalives(config, profile)
+	applyGRPCBuffers(config, profile)
+	if profile.Proto != nil {
+		dir := TargetProtoDirPath(profileNN)
+		config.ProtoDirs = []string{dir}
+		for _, file := range profile.Proto.Files {
+			config.ProtoFiles = append(config.ProtoFiles, path.Join(dir, file))
+		}
+	}
+	return config
+}
+
+// applyTargetTLS sets the TLS fields of a target config.
+// The CA bundle and client certificate of the profile, mounted under TargetTLSProfileDir,
+// override the ones of the cluster clientTLS.
+// Server certificates are verified unless the profile sets insecureSkipVerify,
+// with the system roots when no CA bundle is configured.
+func applyTargetTLS(config *gapi.TargetConfig, profileNN string, profile *gnmicv1alpha1.TargetProfileSpec, clientTLS *ClientTLSPaths) {
 	// no client TLS configuration at the cluster level or target profile level
 	if clientTLS == nil && profile.TLS == nil {
 		config.Insecure = ptr.To(true)
-		return config
+		return
 	}
-	// TLS not enabled at the cluster level but enabled at the target profile level
-	if clientTLS == nil && profile.TLS != nil {
-		config.SkipVerify = ptr.To(true)
-		if profile.TLS.MaxVersion != "" {
-			config.TLSMaxVersion = profile.TLS.MaxVersion
+
+	// use client TLS configuration from cluster (for mTLS with targets)
+	if clientTLS != nil {
+		if clientTLS.CertFile != "" {
+			config.TLSCert = ptr.To(clientTLS.CertFile)
 		}
-		if profile.TLS.MinVersion != "" {
-			config.TLSMinVersion = profile.TLS.MinVersion
+		if clientTLS.KeyFile != "" {
+			config.TLSKey = ptr.To(clientTLS.KeyFile)
 		}
-		if len(profile.TLS.CipherSuites) > 0 {
-			config.CipherSuites = profile.TLS.CipherSuites
+		if clientTLS.CAFile != "" {
+			config.TLSCA = ptr.To(clientTLS.CAFile)
 		}
-		return config
+	}
+	config.SkipVerify = ptr.To(false)
+	if profile.TLS == nil {
+		return
 	}
 
-	// use client TLS configuration from cluster (for mTLS with targets)
-	if clientTLS.CertFile != "" {
-		config.TLSCert = ptr.To(clientTLS.CertFile)
+	if profile.TLS.CABundleRef != nil {
+		config.TLSCA = ptr.To(TargetTLSCAFilePath(profileNN))
 	}
-	if clientTLS.KeyFile != "" {
-		config.TLSKey = ptr.To(clientTLS.KeyFile)
+	if profile.TLS.ClientCertificateRef != "" {
+		config.TLSCert = ptr.To(TargetTLSCertFilePath(profileNN))
+		config.TLSKey = ptr.To(TargetTLSKeyFilePath(profileNN))
 	}
-	if clientTLS.CAFile != "" {
-		config.TLSCA = ptr.To(clientTLS.CAFile)
-		config.SkipVerify = ptr.To(false)
-	} else {
-		// TLS is enabled but without CA verification (TrustBundleRef not supported yet)
+	if profile.TLS.InsecureSkipVerify {
 		config.SkipVerify = ptr.To(true)
 	}
-	if profile.TLS == nil {
-		return config
-	}
 	if profile.TLS.ServerName != "" {
 		config.TLSServerName = profile.TLS.ServerName
 	}
@@ -82,7 +185,9 @@ func buildTargetConfig(target *gnmicv1alpha1.Target, profile *gnmicv1alpha1.Targ
 	if len(profile.TLS.CipherSuites) > 0 {
 		config.CipherSuites = profile.TLS.CipherSuites
 	}
+}
 
+func applyKeepalives(config *gapi.TargetConfig, profile *gnmicv1alpha1.TargetProfileSpec) {
 	if profile.TCPKeepAlive != nil {
 		config.TCPKeepalive = profile.TCPKeepAlive.Duration
 	}
@@ -93,7 +198,40 @@ func buildTargetConfig(target *gnmicv1alpha1.Target, profile *gnmicv1alpha1.Targ
 			PermitWithoutStream: profile.GRCPKeepAlive.PermitWithoutStream,
 		}
 	}
-	return config
+}
+
+func applyGRPCBuffers(config *gapi.TargetConfig, profile *gnmicv1alpha1.TargetProfileSpec) {
+	buffers := profile.GRPCBuffers
+	if buffers == nil {
+		return
+	}
+	for _, size := range []struct {
+		value  *int32
+		config **int
+	}{
+		{buffers.ReadBufferSize, &config.GRPCReadBufferSize},
+		{buffers.WriteBufferSize, &config.GRPCWriteBufferSize},
+		{buffers.ConnWindowSize, &config.GRPCConnWindowSize},
+		{buffers.WindowSize, &config.GRPCWindowSize},
+		{buffers.StaticConnWindowSize, &config.GRPCStaticConnWindowSize},
+		{buffers.StaticStreamWindowSize, &config.GRPCStaticStreamWindowSize},
+	} {
+		if size.value != nil {
+			*size.config = ptr.To(int(*size.value))
+		}
+	}
+}
+
+// mergeStringMaps returns a copy of base with the entries of overrides, nil
+// when both are empty
+func mergeStringMaps(base, overrides map[string]string) map[string]string {
+	if len(base) == 0 && len(overrides) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(base)+len(overrides))
+	maps.Copy(merged, base)
+	maps.Copy(merged, overrides)
+	return merged
 }
 
 func durationOrDefault(duration *metav1.Duration, defaultDuration time.Duration) time.Duration {
@@ -2,6 +2,8 @@ package gnmic
 
 import (
 	"os"
+	"path"
+	"strings"
 
 	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
 )
@@ -31,6 +33,15 @@ const (
 	ClientCABundleMountPath = "/etc/gnmic/client-ca"
 	ClientCABundleFilePath  = ClientCABundleMountPath + "/ca.crt"
 
+	// Path where the TLS files of target profiles are mounted in gNMIc pods,
+	// one directory per profile (see TargetTLSProfileDir)
+	TargetTLSMountPath = "/etc/gnmic/target-tls"
+	TargetTLSCAFile    = "ca.crt"
+	TargetTLSCertFile  = "tls.crt"
+	TargetTLSKeyFile   = "tls.key"
+	// directory of the proto files of a target profile, below its TLS files
+	TargetProtoDir = "proto"
+
 	// Path where tunnel CA bundle is mounted in gNMIc pods (for verifying tunnel client certs)
 	TunnelCABundleMountPath = "/etc/gnmic/tunnel-ca"
 	TunnelCABundleFilePath  = TunnelCABundleMountPath + "/ca.crt"
@@ -46,6 +57,32 @@ const (
 	DefaultControllerCAPath   = "/etc/gnmic-operator/ca/ca.crt"
 )
 
+// TargetTLSProfileDir returns the directory holding the TLS files of a target profile,
+// relative to TargetTLSMountPath
+func TargetTLSProfileDir(profileNN string) string {
+	return strings.ReplaceAll(profileNN, Delimiter, "_")
+}
+
+// TargetTLSCAFilePath returns the path of the CA bundle of a target profile
+func TargetTLSCAFilePath(profileNN string) string {
+	return path.Join(TargetTLSMountPath, TargetTLSProfileDir(profileNN), TargetTLSCAFile)
+}
+
+// TargetTLSCertFilePath returns the path of the client certificate of a target profile
+func TargetTLSCertFilePath(profileNN string) string {
+	return path.Join(TargetTLSMountPath, TargetTLSProfileDir(profileNN), TargetTLSCertFile)
+}
+
+// TargetTLSKeyFilePath returns the path of the client key of a target profile
+func TargetTLSKeyFilePath(profileNN string) string {
+	return path.Join(TargetTLSMountPath, TargetTLSProfileDir(profileNN), TargetTLSKeyFile)
+}
+
+// TargetProtoDirPath returns the directory holding the proto files of a target profile
+func TargetProtoDirPath(profileNN string) string {
+	return path.Join(TargetTLSMountPath, TargetTLSProfileDir(profileNN), TargetProtoDir)
+}
+
 // GetControllerCertPath returns the path to the controller's client certificate
 func GetControllerCertPath() string {
 	if path := os.Getenv("GNMIC_TLS_CERT"); path != "" {
@@ -95,6 +132,15 @@ func TLSConfigForClusterPod(cluster *gnmicv1alpha1.Cluster) *TLSConfig {
 	return tlsConfig
 }
 
+// GNMIServerEnabled reports whether the pods of a cluster run a gNMI server.
+// The server forwards requests to any target of the pod, so it is only
+// enabled with API certificates from an issuer, which make it require the
+// controller's client certificate.
+func GNMIServerEnabled(cluster *gnmicv1alpha1.Cluster) bool {
+	api := cluster.Spec.API
+	return api != nil && api.GNMIPort != 0 && api.TLS != nil && api.TLS.IssuerRef != ""
+}
+
 // TunnelServerTLSConfig returns the TLS configuration for the gRPC tunnel server
 func TunnelServerTLSConfig(cluster *gnmicv1alpha1.Cluster) *TLSConfig {
 	if cluster.Spec.GRPCTunnel == nil || cluster.Spec.GRPCTunnel.TLS == nil {
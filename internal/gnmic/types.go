@@ -3,6 +3,7 @@ package gnmic
 import (
 	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
 	gapi "github.com/openconfig/gnmic/pkg/api/types"
+	corev1 "k8s.io/api/core/v1"
 )
 
 // Delimiter used for namespaced names (namespace/name)
@@ -19,6 +20,10 @@ type ApplyPlan struct {
 	Processors              map[string]map[string]any           `json:"processors,omitempty"`
 	TunnelTargetMatches     map[string]*TunnelTargetMatch       `json:"tunnel-target-matches,omitempty"`
 	PrometheusPorts         map[string]int32                    `json:"prometheus-output-ports,omitempty"` // For status reporting
+	// Config paths of outputs and inputs whose values are resolved from Secrets (name -> paths).
+	// Not sent to the pods: used to redact the plan and to keep it out of the plan history.
+	OutputSecretPaths map[string][]string `json:"-"`
+	InputSecretPaths  map[string][]string `json:"-"`
 }
 
 // TunnelTargetMatch defines a policy for matching tunnel targets
@@ -50,6 +55,12 @@ type PipelineData struct {
 	InputProcessorOrder []string
 	// ResolvedOutputAddresses holds resolved service addresses for outputs (outputNN -> addresses)
 	ResolvedOutputAddresses map[string][]string
+	// ResolvedOutputSecrets holds the Secret values of outputs (outputNN -> config path -> value)
+	ResolvedOutputSecrets map[string]map[string]string
+	// ResolvedInputSecrets holds the Secret values of inputs (inputNN -> config path -> value)
+	ResolvedInputSecrets map[string]map[string]string
+	// ResolvedTargetTLS holds the volume projections mounting the TLS files of target profiles (profileNN -> projections)
+	ResolvedTargetTLS map[string][]corev1.VolumeProjection
 }
 
 // NewPipelineData creates a new PipelineData with initialized maps
@@ -64,6 +75,9 @@ func NewPipelineData() *PipelineData {
 		InputProcessors:         make(map[string]gnmicv1alpha1.ProcessorSpec),
 		TunnelTargetPolicies:    make(map[string]gnmicv1alpha1.TunnelTargetPolicySpec),
 		ResolvedOutputAddresses: make(map[string][]string),
+		ResolvedOutputSecrets:   make(map[string]map[string]string),
+		ResolvedInputSecrets:    make(map[string]map[string]string),
+		ResolvedTargetTLS:       make(map[string][]corev1.VolumeProjection),
 	}
 }
 
@@ -74,8 +88,9 @@ type Credentials struct {
 	Token    string
 }
 
-// CredentialsFetcher is an interface for fetching credentials from a secret reference
+// CredentialsFetcher is an interface for fetching the credentials of a target profile
 type CredentialsFetcher interface {
-	// FetchCredentials retrieves credentials from a secret reference in the given namespace
-	FetchCredentials(namespace, secretRef string) (*Credentials, error)
+	// FetchCredentials retrieves the credentials of the named target profile in the given
+	// namespace from the backend the profile selects
+	FetchCredentials(namespace, profileName string, profile *gnmicv1alpha1.TargetProfileSpec) (*Credentials, error)
 }
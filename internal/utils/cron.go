@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/robfig/cron/v3"
+)
+
+// CronSchedule is a parsed cron expression. Its Next returns the first time
+// strictly after t the schedule fires, or the zero time if it never fires
+// within the next five years (e.g. "0 0 30 2 *").
+type CronSchedule = cron.Schedule
+
+// ParseCron parses a standard 5 field cron expression: minute hour
+// day-of-month month day-of-week, or a descriptor such as "@daily".
+// When both day fields are restricted a day matches if either does, as in
+// cron(8). Sunday is day 0 or 7 of the week. The time zone is set by the
+// caller: CRON_TZ= and TZ= prefixes are rejected.
+func ParseCron(expr string) (CronSchedule, error) {
+	trimmed := strings.TrimSpace(expr)
+	if strings.HasPrefix(trimmed, "CRON_TZ=") || strings.HasPrefix(trimmed, "TZ=") {
+		return nil, fmt.Errorf("cron expression %q: time zone prefixes are not supported", expr)
+	}
+	schedule, err := cron.ParseStandard(normalizeDayOfWeek(trimmed))
+	if err != nil {
+		return nil, fmt.Errorf("cron expression %q: %w", expr, err)
+	}
+	return schedule, nil
+}
+
+// normalizeDayOfWeek rewrites day 7 of the day-of-week field of an
+// expression as 0, the only Sunday robfig/cron accepts. Ranges and steps
+// ending on 7 are expanded into the days they match. Malformed items are
+// left to the parser to reject.
+func normalizeDayOfWeek(expr string) string {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return expr
+	}
+	items := strings.Split(fields[4], ",")
+	for i, item := range items {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+		lo, hi, isRange := strings.Cut(rng, "-")
+		if !isRange {
+			hi = lo
+		}
+		if hi != "7" {
+			continue
+		}
+		start, err := strconv.Atoi(lo)
+		if err != nil || start > 7 {
+			continue
+		}
+		step := 1
+		if hasStep {
+			if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+				continue
+			}
+		}
+		var days []string
+		for day := start; day <= 7; day += step {
+			days = append(days, strconv.Itoa(day%7))
+		}
+		items[i] = strings.Join(days, ",")
+	}
+	fields[4] = strings.Join(items, ",")
+	return strings.Join(fields, " ")
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron_Errors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"* * * * 8-7",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"CRON_TZ=Europe/Paris 0 2 * * *",
+		"TZ=UTC 0 2 * * *",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want error", expr)
+		}
+	}
+}
+
+func TestParseCron_Valid(t *testing.T) {
+	for _, expr := range []string{
+		"* * * * 7",
+		"* * * * 0-7",
+		"* * * * */7",
+		" 0 2 * * 7 ",
+	} {
+		if _, err := ParseCron(expr); err != nil {
+			t.Errorf("ParseCron(%q): %v", expr, err)
+		}
+	}
+}
+
+func TestCronSchedule_Next(t *testing.T) {
+	// Wednesday
+	from := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 22 * * 6", time.Date(2025, 1, 18, 22, 0, 0, 0, time.UTC)},
+		{"0 2 * * 7", time.Date(2025, 1, 19, 2, 0, 0, 0, time.UTC)},
+		{"0 2 * * SUN", time.Date(2025, 1, 19, 2, 0, 0, 0, time.UTC)},
+		{"0 2 * * 6-7", time.Date(2025, 1, 18, 2, 0, 0, 0, time.UTC)},
+		{"0 2 * * 4,7", time.Date(2025, 1, 16, 2, 0, 0, 0, time.UTC)},
+		{"0 2 * * 5-7/2", time.Date(2025, 1, 17, 2, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * 1-5", time.Date(2025, 1, 15, 13, 0, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2025, 1, 16, 10, 30, 0, 0, time.UTC)},
+		// restricted day-of-month and day-of-week match either
+		{"0 0 20 * 4", time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+		// a stepped day-of-month is restricted too
+		{"0 0 */10 * 4", time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		s, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q: Next = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCronSchedule_NextInLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	s, err := ParseCron("0 22 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := s.Next(time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC).In(loc))
+	if want := time.Date(2025, 1, 15, 20, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("Next = %v, want %v", got, want)
+	}
+}
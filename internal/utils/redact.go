@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// RedactedValue replaces secret values in API responses and logs
+const RedactedValue = "<redacted>"
+
+// secretKeys are config keys whose values are secrets in gNMIc configs and
+// discovery payloads, compared case-insensitively.
+var secretKeys = map[string]bool{
+	"password":      true,
+	"passwd":        true,
+	"token":         true,
+	"secret":        true,
+	"credentials":   true,
+	"api-key":       true,
+	"apikey":        true,
+	"client-secret": true,
+	"private-key":   true,
+	"authorization": true,
+}
+
+// secretKeySuffixes mark compound keys such as "bearer-token" or "sasl_password"
+var secretKeySuffixes = []string{"password", "token", "secret"}
+
+// IsSecretKey reports whether a config key is known to hold a secret value.
+func IsSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	if secretKeys[key] {
+		return true
+	}
+	for _, suffix := range secretKeySuffixes {
+		if strings.HasSuffix(key, "-"+suffix) || strings.HasSuffix(key, "_"+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns a deep copy of a decoded JSON/YAML value where the scalar
+// values of secret keys are replaced with RedactedValue. Objects under a
+// secret key (e.g. authorization) are redacted recursively rather than
+// replaced, so their structure stays visible.
+func Redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			out[k] = redactValue(IsSecretKey(k), val)
+		}
+		return out
+	case map[any]any:
+		out := make(map[any]any, len(v))
+		for k, val := range v {
+			ks, _ := k.(string)
+			out[k] = redactValue(IsSecretKey(ks), val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i := range v {
+			out[i] = Redact(v[i])
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func redactValue(secret bool, v any) any {
+	switch v.(type) {
+	case map[string]any, map[any]any, []any:
+		if secret {
+			return redactAll(v)
+		}
+		return Redact(v)
+	case nil:
+		return nil
+	}
+	if secret {
+		return RedactedValue
+	}
+	return v
+}
+
+// redactAll replaces every scalar of a value.
+func redactAll(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			out[k] = redactAll(val)
+		}
+		return out
+	case map[any]any:
+		out := make(map[any]any, len(v))
+		for k, val := range v {
+			out[k] = redactAll(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i := range v {
+			out[i] = redactAll(v[i])
+		}
+		return out
+	case nil:
+		return nil
+	default:
+		return RedactedValue
+	}
+}
+
+// RedactJSON redacts a JSON document for logging. Bodies that are not JSON
+// are returned unchanged.
+func RedactJSON(data []byte) string {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return string(data)
+	}
+	b, err := json.Marshal(Redact(v))
+	if err != nil {
+		return string(data)
+	}
+	return string(b)
+}
+
+// RedactURL masks the password of a URL's user info and the values of secret
+// query parameters.
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	if u.RawQuery != "" {
+		q := u.Query()
+		redacted := false
+		for k := range q {
+			if IsSecretKey(k) {
+				for i := range q[k] {
+					q[k][i] = RedactedValue
+				}
+				redacted = true
+			}
+		}
+		if redacted {
+			u.RawQuery = q.Encode()
+		}
+	}
+	return u.Redacted()
+}
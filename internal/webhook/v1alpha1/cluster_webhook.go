@@ -166,6 +166,14 @@ func validateClusterSpec(spec *operatorv1alpha1.ClusterSpec) error {
 					"gnmiPort must not be the same as restPort",
 				))
 			}
+			// the gNMI server forwards requests to the targets of the pod,
+			// it must only accept the controller's client certificate.
+			if spec.API.TLS == nil || spec.API.TLS.IssuerRef == "" {
+				allErrs = append(allErrs, field.Required(
+					apiPath.Child("tls", "issuerRef"),
+					"gnmiPort requires API certificates from an issuer, the gNMI server only accepts the controller's client certificate",
+				))
+			}
 		}
 
 		allErrs = append(allErrs, validateClusterTLS(spec.API.TLS, apiPath.Child("tls"))...)
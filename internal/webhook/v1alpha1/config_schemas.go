@@ -0,0 +1,749 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	operatorv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+)
+
+// configKind is the expected JSON kind of a config value.
+type configKind int
+
+const (
+	kindAny configKind = iota
+	kindString
+	kindInt
+	kindBool
+	// a Go duration string ("10s") or a number of nanoseconds
+	kindDuration
+	kindStringList
+	kindStringMap
+	kindObject
+	kindList
+)
+
+func (k configKind) String() string {
+	switch k {
+	case kindString:
+		return "a string"
+	case kindInt:
+		return "an integer"
+	case kindBool:
+		return "a boolean"
+	case kindDuration:
+		return "a duration"
+	case kindStringList:
+		return "a list of strings"
+	case kindStringMap:
+		return "a map of strings"
+	case kindObject:
+		return "an object"
+	case kindList:
+		return "a list"
+	}
+	return "any value"
+}
+
+// configField describes a single key of a gNMIc config object.
+type configField struct {
+	kind configKind
+	// allowed values of a string field
+	enum []string
+	// keys of an object field, or of the items of a list field;
+	// nil accepts any key without a warning
+	fields   configFields
+	required bool
+	// the key replacing a deprecated one
+	replacedBy string
+	// the key is set by the operator from the Pipeline
+	managed bool
+}
+
+type configFields map[string]configField
+
+// configSchema describes the config of one gNMIc output, input or processor type.
+// Keys not listed in fields are passed to gNMIc unchecked, with a warning
+// unless the schema is open.
+type configSchema struct {
+	fields configFields
+	// open schemas expect keys not listed in fields (plugins, scripts...)
+	open bool
+}
+
+// merge returns the union of the given field sets, later ones winning.
+func merge(sets ...configFields) configFields {
+	fields := configFields{}
+	for _, set := range sets {
+		for k, v := range set {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+var (
+	str       = configField{kind: kindString}
+	integer   = configField{kind: kindInt}
+	boolean   = configField{kind: kindBool}
+	duration  = configField{kind: kindDuration}
+	strList   = configField{kind: kindStringList}
+	strMap    = configField{kind: kindStringMap}
+	anyObject = configField{kind: kindObject}
+
+	managedStrList = configField{kind: kindStringList, managed: true}
+	formats        = configField{kind: kindString, enum: []string{"json", "event", "proto", "prototext", "protojson", "flat"}}
+
+	tlsConfig = configField{kind: kindObject, fields: configFields{
+		"ca-file":     str,
+		"cert-file":   str,
+		"key-file":    str,
+		"skip-verify": boolean,
+		"server-name": str,
+	}}
+	saslConfig = configField{kind: kindObject, fields: configFields{
+		"user":      str,
+		"password":  str,
+		"mechanism": {kind: kindString, enum: []string{"PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512", "OAUTHBEARER"}},
+		"token-url": str,
+	}}
+	cacheConfig = configField{kind: kindObject, fields: configFields{
+		"type":       {kind: kindString, enum: []string{"oc", "nats", "jetstream", "redis"}},
+		"expiration": duration,
+		"address":    str,
+		"timeout":    duration,
+		"debug":      boolean,
+	}}
+)
+
+// common keys of gNMIc outputs.
+var outputCommonFields = configFields{
+	"event-processors":    managedStrList,
+	"add-target":          {kind: kindString, enum: []string{"overwrite", "if-not-present"}},
+	"target-template":     str,
+	"override-timestamps": boolean,
+	"enable-metrics":      boolean,
+	"debug":               boolean,
+}
+
+// keys of outputs publishing formatted messages.
+var outputMessageFields = configFields{
+	"format":       formats,
+	"split-events": boolean,
+	"msg-template": str,
+	"num-workers":  integer,
+	"buffer-size":  integer,
+}
+
+// keys of outputs converting events into metrics.
+var outputMetricFields = configFields{
+	"metric-prefix":            str,
+	"append-subscription-name": boolean,
+	"strings-as-numbers":       boolean,
+}
+
+var natsFields = configFields{
+	"name":              str,
+	"address":           str,
+	"username":          str,
+	"password":          str,
+	"connect-time-wait": duration,
+	"tls":               tlsConfig,
+	"write-timeout":     duration,
+}
+
+// outputConfigSchemas is the registry of gNMIc output config schemas, by output type.
+var outputConfigSchemas = map[string]configSchema{
+	"file": {fields: merge(outputCommonFields, outputMessageFields, configFields{
+		"filename":          str,
+		"file-type":         {kind: kindString, enum: []string{"stdout", "stderr"}},
+		"multiline":         boolean,
+		"indent":            str,
+		"separator":         str,
+		"concurrency-limit": integer,
+		"calculate-latency": boolean,
+		"rotation": {kind: kindObject, fields: configFields{
+			"max-size":    integer,
+			"max-age":     integer,
+			"max-backups": integer,
+			"compress":    boolean,
+		}},
+	})},
+	"kafka": {fields: merge(outputCommonFields, outputMessageFields, configFields{
+		"address":            str,
+		"topic":              str,
+		"topic-prefix":       str,
+		"name":               str,
+		"sasl":               saslConfig,
+		"tls":                tlsConfig,
+		"max-retry":          integer,
+		"timeout":            duration,
+		"recovery-wait-time": duration,
+		"insert-key":         boolean,
+		"compression-codec":  {kind: kindString, enum: []string{"none", "gzip", "snappy", "lz4", "zstd"}},
+		"required-acks":      {kind: kindString, enum: []string{"no-response", "wait-for-local", "wait-for-all"}},
+		"cache":              cacheConfig,
+		"calculate-latency":  boolean,
+	})},
+	"prometheus": {fields: merge(outputCommonFields, outputMetricFields, configFields{
+		"listen":               str,
+		"path":                 str,
+		"expiration":           duration,
+		"export-timestamps":    boolean,
+		"strings-as-labels":    boolean,
+		"timeout":              duration,
+		"num-workers":          integer,
+		"tls":                  tlsConfig,
+		"cache":                cacheConfig,
+		"service-registration": anyObject,
+	})},
+	"prometheus_write": {fields: merge(outputCommonFields, outputMetricFields, configFields{
+		"url":     str,
+		"timeout": duration,
+		"headers": strMap,
+		"authorization": {kind: kindObject, fields: configFields{
+			"type":        str,
+			"credentials": str,
+		}},
+		"authentication": {kind: kindObject, fields: configFields{
+			"username": str,
+			"password": str,
+		}},
+		"tls":                       tlsConfig,
+		"interval":                  duration,
+		"buffer-size":               integer,
+		"max-time-series-per-write": integer,
+		"max-retries":               integer,
+		"metadata": {kind: kindObject, fields: configFields{
+			"include":               boolean,
+			"interval":              duration,
+			"max-entries-per-write": integer,
+		}},
+		"num-workers": integer,
+		"num-writers": integer,
+	})},
+	"nats": {fields: merge(outputCommonFields, outputMessageFields, natsFields, configFields{
+		"address":        str,
+		"subject":        str,
+		"subject-prefix": str,
+	})},
+	"jetstream": {fields: merge(outputCommonFields, outputMessageFields, natsFields, configFields{
+		"address":        str,
+		"stream":         {kind: kindString, required: true},
+		"subject":        str,
+		"subject-format": {kind: kindString, enum: []string{"static", "subscription.target", "target.subscription"}},
+		"create-stream": {kind: kindObject, fields: configFields{
+			"description":  str,
+			"subjects":     strList,
+			"storage":      {kind: kindString, enum: []string{"file", "memory"}},
+			"max-msgs":     integer,
+			"max-bytes":    integer,
+			"max-age":      duration,
+			"max-msg-size": integer,
+		}},
+	})},
+	"influxdb": {fields: merge(outputCommonFields, configFields{
+		"url":                 str,
+		"org":                 str,
+		"bucket":              str,
+		"token":               str,
+		"batch-size":          integer,
+		"flush-timer":         duration,
+		"use-gzip":            boolean,
+		"enable-tls":          {kind: kindBool, replacedBy: "tls"},
+		"tls":                 tlsConfig,
+		"health-check-period": duration,
+		"timestamp-precision": {kind: kindString, enum: []string{"s", "ms", "us", "ns"}},
+		"cache":               cacheConfig,
+		"delete-tag":          str,
+	})},
+	"tcp": {fields: merge(outputCommonFields, outputMessageFields, configFields{
+		"address":        {kind: kindString, required: true},
+		"rate":           duration,
+		"delimiter":      str,
+		"keep-alive":     duration,
+		"retry-interval": duration,
+	})},
+	"udp": {fields: merge(outputCommonFields, outputMessageFields, configFields{
+		"address":        {kind: kindString, required: true},
+		"rate":           duration,
+		"retry-interval": duration,
+	})},
+	"otlp": {fields: merge(outputCommonFields, outputMetricFields, configFields{
+		"endpoint":            str,
+		"protocol":            {kind: kindString, enum: []string{"grpc", "http"}},
+		"timeout":             duration,
+		"tls":                 tlsConfig,
+		"resource-attributes": strMap,
+		"headers":             strMap,
+		"batch-size":          integer,
+		"interval":            duration,
+		"num-workers":         integer,
+		"max-retries":         integer,
+	})},
+}
+
+// common keys of gNMIc inputs.
+var inputCommonFields = configFields{
+	"name":             str,
+	"format":           {kind: kindString, enum: []string{"event", "proto"}},
+	"debug":            boolean,
+	"num-workers":      integer,
+	"outputs":          managedStrList,
+	"event-processors": managedStrList,
+}
+
+// inputConfigSchemas is the registry of gNMIc input config schemas, by input type.
+var inputConfigSchemas = map[string]configSchema{
+	"kafka": {fields: merge(inputCommonFields, configFields{
+		"address":            str,
+		"topics":             str,
+		"sasl":               saslConfig,
+		"tls":                tlsConfig,
+		"group-id":           str,
+		"session-timeout":    duration,
+		"heartbeat-interval": duration,
+		"recovery-wait-time": duration,
+		"version":            str,
+	})},
+	"nats": {fields: merge(inputCommonFields, natsFields, configFields{
+		"address":     str,
+		"subject":     str,
+		"queue":       str,
+		"buffer-size": integer,
+	})},
+	"jetstream": {fields: merge(inputCommonFields, natsFields, configFields{
+		"address":          str,
+		"stream":           {kind: kindString, required: true},
+		"subjects":         strList,
+		"deliver-policy":   {kind: kindString, enum: []string{"all", "last", "new", "last-per-subject"}},
+		"buffer-size":      integer,
+		"fetch-batch-size": integer,
+		"max-ack-pending":  integer,
+	})},
+}
+
+// keys of processors selecting events by tag and value names or regexes.
+var processorSelectFields = configFields{
+	"tags":        strList,
+	"tag-names":   strList,
+	"values":      strList,
+	"value-names": strList,
+}
+
+var processorDebug = configFields{"debug": boolean}
+
+// processorConfigSchemas is the registry of gNMIc event processor config schemas, by processor type.
+var processorConfigSchemas = map[string]configSchema{
+	"event-add-tag": {fields: merge(processorDebug, processorSelectFields, configFields{
+		"condition": str,
+		"overwrite": boolean,
+		"add":       {kind: kindStringMap, required: true},
+	})},
+	"event-allow": {fields: merge(processorDebug, processorSelectFields, configFields{
+		"condition": str,
+	})},
+	"event-combine": {fields: merge(processorDebug, configFields{
+		"processors": {kind: kindList, required: true, fields: configFields{
+			"condition": str,
+			"name":      {kind: kindString, required: true},
+		}},
+	})},
+	"event-convert": {fields: merge(processorDebug, configFields{
+		"value-names": strList,
+		"type":        {kind: kindString, required: true, enum: []string{"int", "uint", "string", "float"}},
+	})},
+	"event-data-convert": {fields: merge(processorDebug, configFields{
+		"value-names": strList,
+		"from":        str,
+		"to":          {kind: kindString, required: true},
+		"keep":        boolean,
+		"old":         str,
+		"new":         str,
+	})},
+	"event-date-string": {fields: merge(processorDebug, configFields{
+		"value-names": strList,
+		"tag-names":   strList,
+		"precision":   {kind: kindString, enum: []string{"s", "ms", "us", "ns"}},
+		"location":    str,
+		"format":      str,
+	})},
+	"event-delete": {fields: merge(processorDebug, processorSelectFields)},
+	"event-drop": {fields: merge(processorDebug, processorSelectFields, configFields{
+		"condition": str,
+	})},
+	"event-duration-convert": {fields: merge(processorDebug, configFields{
+		"value-names": strList,
+		"keep":        boolean,
+	})},
+	"event-extract-tags": {fields: merge(processorDebug, processorSelectFields, configFields{
+		"overwrite": boolean,
+	})},
+	"event-group-by": {fields: merge(processorDebug, configFields{
+		"condition": str,
+		"tags":      strList,
+		"by-name":   boolean,
+	})},
+	"event-ieee-float32": {fields: merge(processorDebug, configFields{
+		"value-names": strList,
+	})},
+	"event-jq": {fields: merge(processorDebug, configFields{
+		"condition":  str,
+		"expression": str,
+	})},
+	"event-merge": {fields: merge(processorDebug, configFields{
+		"always": boolean,
+	})},
+	"event-override-ts": {fields: merge(processorDebug, configFields{
+		"precision": {kind: kindString, enum: []string{"s", "ms", "us", "ns"}},
+	})},
+	// plugin configs are defined by the plugin
+	"event-plugin": {open: true},
+	"event-rate-limit": {fields: merge(processorDebug, configFields{
+		"per-second": integer,
+		"cache-size": integer,
+	})},
+	// scripts read their own keys from the config
+	"event-starlark": {open: true, fields: merge(processorDebug, configFields{
+		"source": str,
+		"script": str,
+	})},
+	"event-strings": {fields: merge(processorDebug, processorSelectFields, configFields{
+		"transforms": {kind: kindList, required: true},
+	})},
+	"event-time-epoch": {fields: merge(processorDebug, configFields{
+		"value-names": strList,
+		"precision":   {kind: kindString, enum: []string{"s", "ms", "us", "ns"}},
+		"format":      str,
+	})},
+	"event-to-tag": {fields: merge(processorDebug, configFields{
+		"values":      strList,
+		"value-names": strList,
+		"keep":        boolean,
+	})},
+	"event-trigger": {fields: merge(processorDebug, configFields{
+		"condition":       str,
+		"min-occurrences": integer,
+		"max-occurrences": integer,
+		"window":          duration,
+		"actions":         strList,
+		"action":          {kind: kindObject, replacedBy: "actions"},
+		"vars":            anyObject,
+		"vars-file":       str,
+		"async":           boolean,
+	})},
+	"event-value-tag": {fields: merge(processorDebug, configFields{
+		"tag-name":   str,
+		"value-name": {kind: kindString, required: true},
+		"consume":    boolean,
+	})},
+	"event-write": {fields: merge(processorDebug, processorSelectFields, configFields{
+		"condition": str,
+		"dst":       str,
+		"separator": str,
+		"indent":    str,
+	})},
+}
+
+// validateTypedConfig validates the type and config of an output, input or
+// processor against the schema registry of its kind, and returns the parsed config.
+func validateTypedConfig(schemas map[string]configSchema, typ string, raw []byte, specPath *field.Path) (map[string]any, admission.Warnings, field.ErrorList) {
+	schema, ok := schemas[typ]
+	if !ok {
+		types := make([]string, 0, len(schemas))
+		for t := range schemas {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		return nil, nil, field.ErrorList{field.NotSupported(specPath.Child("type"), typ, types)}
+	}
+
+	configPath := specPath.Child("config")
+	config := map[string]any{}
+	if raw != nil {
+		if err := json.Unmarshal(raw, &config); err != nil {
+			return nil, nil, field.ErrorList{field.Invalid(configPath, string(raw), "config must be an object")}
+		}
+	}
+	if config == nil {
+		// a null config
+		config = map[string]any{}
+	}
+
+	warnings, errs := validateConfigObject(config, schema.fields, schema.open, configPath)
+	return config, warnings, errs
+}
+
+// validateConfigObject validates the keys of a config object. Unknown keys
+// return a warning, as the schemas may lag behind the gNMIc version in use.
+func validateConfigObject(obj map[string]any, fields configFields, open bool, fldPath *field.Path) (admission.Warnings, field.ErrorList) {
+	var allErrs field.ErrorList
+	var warnings admission.Warnings
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	// deterministic error order
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		keyPath := fldPath.Child(k)
+		f, ok := fields[k]
+		if !ok {
+			if !open {
+				warnings = append(warnings, fmt.Sprintf("%s is not a known config key and is passed to gNMIc unchecked", keyPath))
+			}
+			continue
+		}
+		if f.replacedBy != "" {
+			warnings = append(warnings, fmt.Sprintf("%s is deprecated, use %s instead", keyPath, fldPath.Child(f.replacedBy)))
+		}
+		if f.managed {
+			warnings = append(warnings, fmt.Sprintf("%s is set by the operator from the Pipeline and will be overridden", keyPath))
+		}
+		valueWarnings, errs := validateConfigValue(obj[k], f, keyPath)
+		warnings = append(warnings, valueWarnings...)
+		allErrs = append(allErrs, errs...)
+	}
+
+	for _, k := range knownKeys(fields) {
+		if _, ok := obj[k]; !ok && fields[k].required {
+			allErrs = append(allErrs, field.Required(fldPath.Child(k), fmt.Sprintf("%s is required", k)))
+		}
+	}
+	return warnings, allErrs
+}
+
+// validateConfigValue validates a config value against its field description.
+func validateConfigValue(v any, f configField, fldPath *field.Path) (admission.Warnings, field.ErrorList) {
+	if f.kind == kindAny {
+		return nil, nil
+	}
+	invalid := field.ErrorList{field.Invalid(fldPath, v, fmt.Sprintf("must be %s", f.kind))}
+
+	switch f.kind {
+	case kindString:
+		s, ok := v.(string)
+		if !ok {
+			return nil, invalid
+		}
+		if len(f.enum) > 0 && !contains(f.enum, s) {
+			return nil, field.ErrorList{field.NotSupported(fldPath, s, f.enum)}
+		}
+	case kindInt:
+		n, ok := v.(float64)
+		if !ok || n != math.Trunc(n) {
+			return nil, invalid
+		}
+	case kindBool:
+		if _, ok := v.(bool); !ok {
+			return nil, invalid
+		}
+	case kindDuration:
+		switch d := v.(type) {
+		case float64:
+			if d != math.Trunc(d) {
+				return nil, invalid
+			}
+		case string:
+			if _, err := time.ParseDuration(d); err != nil {
+				return nil, invalid
+			}
+		default:
+			return nil, invalid
+		}
+	case kindStringList:
+		items, ok := v.([]any)
+		if !ok {
+			return nil, invalid
+		}
+		var errs field.ErrorList
+		for i, item := range items {
+			if _, ok := item.(string); !ok {
+				errs = append(errs, field.Invalid(fldPath.Index(i), item, "must be a string"))
+			}
+		}
+		return nil, errs
+	case kindStringMap:
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, invalid
+		}
+		var errs field.ErrorList
+		for k, item := range m {
+			if _, ok := item.(string); !ok {
+				errs = append(errs, field.Invalid(fldPath.Key(k), item, "must be a string"))
+			}
+		}
+		return nil, errs
+	case kindObject:
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, invalid
+		}
+		if f.fields != nil {
+			return validateConfigObject(m, f.fields, false, fldPath)
+		}
+	case kindList:
+		items, ok := v.([]any)
+		if !ok {
+			return nil, invalid
+		}
+		if f.fields == nil {
+			return nil, nil
+		}
+		var warnings admission.Warnings
+		var errs field.ErrorList
+		for i, item := range items {
+			m, ok := item.(map[string]any)
+			if !ok {
+				errs = append(errs, field.Invalid(fldPath.Index(i), item, "must be an object"))
+				continue
+			}
+			itemWarnings, itemErrs := validateConfigObject(m, f.fields, false, fldPath.Index(i))
+			warnings = append(warnings, itemWarnings...)
+			errs = append(errs, itemErrs...)
+		}
+		return warnings, errs
+	}
+	return nil, nil
+}
+
+func knownKeys(fields configFields) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// validateConfigSecretRef validates the Secret keys mapped onto an output or
+// input config. Paths must not contradict the type's schema; a path to an
+// unknown key, or to a value also set in the config, returns a warning.
+func validateConfigSecretRef(schemas map[string]configSchema, typ string, config map[string]any, ref *operatorv1alpha1.ConfigSecretRef, fldPath *field.Path) (admission.Warnings, field.ErrorList) {
+	var allErrs field.ErrorList
+	var warnings admission.Warnings
+	if ref == nil {
+		return nil, nil
+	}
+
+	if ref.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), "name is required"))
+	}
+	if len(ref.Items) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("items"), "at least one item is required"))
+	}
+	schema, known := schemas[typ]
+	paths := make(map[string]struct{}, len(ref.Items))
+	for i, item := range ref.Items {
+		itemPath := fldPath.Child("items").Index(i)
+		if item.Key == "" {
+			allErrs = append(allErrs, field.Required(itemPath.Child("key"), "key is required"))
+		}
+		if item.Path == "" {
+			allErrs = append(allErrs, field.Required(itemPath.Child("path"), "path is required"))
+			continue
+		}
+		if _, dup := paths[item.Path]; dup {
+			allErrs = append(allErrs, field.Duplicate(itemPath.Child("path"), item.Path))
+			continue
+		}
+		paths[item.Path] = struct{}{}
+		// an unknown type is reported on spec.type
+		if !known {
+			continue
+		}
+		msg, knownPath := schemaPathError(schema, item.Path)
+		if msg != "" {
+			allErrs = append(allErrs, field.Invalid(itemPath.Child("path"), item.Path, msg))
+			continue
+		}
+		if !knownPath {
+			warnings = append(warnings, fmt.Sprintf("%s: spec.config.%s is not a known config key and is passed to gNMIc unchecked", itemPath.Child("path"), item.Path))
+		}
+		if configHasPath(config, item.Path) {
+			warnings = append(warnings, fmt.Sprintf("spec.config.%s is overridden by the value of key %q of Secret %s", item.Path, item.Key, ref.Name))
+		}
+	}
+	return warnings, allErrs
+}
+
+// schemaPathError returns why a dot-separated path cannot name a string key
+// of the schema, or an empty string, and whether the schema lists the key.
+func schemaPathError(schema configSchema, path string) (string, bool) {
+	fields, open := schema.fields, schema.open
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		if key == "" {
+			return "path must be dot-separated config keys", false
+		}
+		f, ok := fields[key]
+		if !ok {
+			// unknown keys are passed to gNMIc unchecked, expected in open schemas
+			return "", open
+		}
+		if i == len(keys)-1 {
+			if f.kind != kindString && f.kind != kindAny {
+				return fmt.Sprintf("%s is %s, not a string", path, f.kind), true
+			}
+			return "", true
+		}
+		if f.kind != kindObject {
+			return fmt.Sprintf("%s is not an object", strings.Join(keys[:i+1], ".")), true
+		}
+		// free-form objects accept any key below them
+		if f.fields == nil {
+			return "", true
+		}
+		fields, open = f.fields, false
+	}
+	return "", true
+}
+
+// configHasPath tells whether a dot-separated path is set in a config.
+func configHasPath(config map[string]any, path string) bool {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := config[key].(map[string]any)
+		if !ok {
+			return false
+		}
+		config = next
+	}
+	_, ok := config[keys[len(keys)-1]]
+	return ok
+}
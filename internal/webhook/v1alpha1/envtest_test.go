@@ -14,6 +14,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	operatorv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/yang"
 )
 
 func webhookEnvtestAssetsDir() string {
@@ -76,7 +77,7 @@ func TestSetupWebhooksWithManager(t *testing.T) {
 		{"cluster", SetupClusterWebhookWithManager},
 		{"pipeline", SetupPipelineWebhookWithManager},
 		{"target", SetupTargetWebhookWithManager},
-		{"subscription", SetupSubscriptionWebhookWithManager},
+		{"subscription", func(mgr ctrl.Manager) error { return SetupSubscriptionWebhookWithManager(mgr, yang.NewLoader()) }},
 		{"output", SetupOutputWebhookWithManager},
 		{"input", SetupInputWebhookWithManager},
 		{"processor", SetupProcessorWebhookWithManager},
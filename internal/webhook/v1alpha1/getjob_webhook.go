@@ -0,0 +1,126 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	operatorv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var getjoblog = logf.Log.WithName("getjob-resource")
+
+// SetupGetJobWebhookWithManager registers the webhook for GetJob in the manager.
+func SetupGetJobWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &operatorv1alpha1.GetJob{}).
+		WithValidator(&GetJobCustomValidator{}).
+		WithDefaulter(&GetJobCustomDefaulter{}).
+		Complete()
+}
+
+// TODO(user): EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+
+// +kubebuilder:webhook:path=/mutate-operator-gnmic-dev-v1alpha1-getjob,mutating=true,failurePolicy=fail,sideEffects=None,groups=operator.gnmic.dev,resources=getjobs,verbs=create;update,versions=v1alpha1,name=mgetjob-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// GetJobCustomDefaulter struct is responsible for setting default values on the custom resource of the
+// Kind GetJob when those are created or updated.
+//
+// NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
+// as it is used only for temporary operations and does not need to be deeply copied.
+type GetJobCustomDefaulter struct {
+	// TODO(user): Add more fields as needed for defaulting
+}
+
+var _ admission.Defaulter[*operatorv1alpha1.GetJob] = &GetJobCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind GetJob.
+func (d *GetJobCustomDefaulter) Default(_ context.Context, getjob *operatorv1alpha1.GetJob) error {
+	getjoblog.Info("Defaulting for GetJob", "name", getjob.GetName())
+
+	// TODO(user): fill in your defaulting logic.
+
+	return nil
+}
+
+// TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
+// NOTE: If you want to customise the 'path', use the flags '--defaulting-path' or '--validation-path'.
+// +kubebuilder:webhook:path=/validate-operator-gnmic-dev-v1alpha1-getjob,mutating=false,failurePolicy=fail,sideEffects=None,groups=operator.gnmic.dev,resources=getjobs,verbs=create;update,versions=v1alpha1,name=vgetjob-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// GetJobCustomValidator struct is responsible for validating the GetJob resource
+// when it is created, updated, or deleted.
+//
+// NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
+// as this struct is used only for temporary operations and does not need to be deeply copied.
+type GetJobCustomValidator struct{}
+
+var _ admission.Validator[*operatorv1alpha1.GetJob] = &GetJobCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type GetJob.
+func (v *GetJobCustomValidator) ValidateCreate(_ context.Context, getjob *operatorv1alpha1.GetJob) (admission.Warnings, error) {
+	getjoblog.Info("Validation for GetJob upon creation", "name", getjob.GetName())
+
+	return nil, validateGetJobSpec(getjob.GetName(), &getjob.Spec)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type GetJob.
+func (v *GetJobCustomValidator) ValidateUpdate(_ context.Context, _ *operatorv1alpha1.GetJob, getjob *operatorv1alpha1.GetJob) (admission.Warnings, error) {
+	getjoblog.Info("Validation for GetJob upon update", "name", getjob.GetName())
+
+	return nil, validateGetJobSpec(getjob.GetName(), &getjob.Spec)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type GetJob.
+func (v *GetJobCustomValidator) ValidateDelete(_ context.Context, getjob *operatorv1alpha1.GetJob) (admission.Warnings, error) {
+	getjoblog.Info("Validation for GetJob upon deletion", "name", getjob.GetName())
+
+	return nil, nil
+}
+
+// validateGetJobSpec validates the GetJobSpec fields.
+func validateGetJobSpec(name string, spec *operatorv1alpha1.GetJobSpec) error {
+	specPath := field.NewPath("spec")
+	allErrs := validateGNMIJobSpec(&spec.GNMIJobSpec, specPath)
+	allErrs = append(allErrs, validateGNMIPrefix(spec.Prefix, specPath.Child("prefix"))...)
+
+	// at least one path is required.
+	if len(spec.Paths) == 0 {
+		allErrs = append(allErrs, field.Required(
+			specPath.Child("paths"),
+			"at least one path is required",
+		))
+	}
+	for i, p := range spec.Paths {
+		allErrs = append(allErrs, validateGNMIPath(p, specPath.Child("paths").Index(i))...)
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(
+		operatorv1alpha1.GroupVersion.WithKind("GetJob").GroupKind(),
+		name,
+		allErrs,
+	)
+}
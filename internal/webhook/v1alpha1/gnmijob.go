@@ -0,0 +1,74 @@
+package v1alpha1
+
+import (
+	"time"
+
+	"github.com/openconfig/gnmic/pkg/api/path"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	operatorv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/utils"
+)
+
+// validateGNMIJobSpec validates the fields GetJobs and SetJobs share.
+func validateGNMIJobSpec(spec *operatorv1alpha1.GNMIJobSpec, specPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	// clusterRef is required.
+	if spec.ClusterRef == "" {
+		allErrs = append(allErrs, field.Required(
+			specPath.Child("clusterRef"),
+			"clusterRef is required",
+		))
+	}
+
+	// at least one target source is required.
+	if len(spec.TargetSelectors) == 0 && len(spec.TargetRefs) == 0 {
+		allErrs = append(allErrs, field.Required(
+			specPath,
+			"at least one of targetSelectors or targetRefs is required",
+		))
+	}
+	allErrs = append(allErrs, validateResourceNames(spec.TargetRefs, specPath.Child("targetRefs"))...)
+	for i := range spec.TargetSelectors {
+		if _, err := metav1.LabelSelectorAsSelector(&spec.TargetSelectors[i]); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("targetSelectors").Index(i), spec.TargetSelectors[i], err.Error()))
+		}
+	}
+
+	if spec.Schedule != "" {
+		if _, err := utils.ParseCron(spec.Schedule); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("schedule"), spec.Schedule, err.Error()))
+		}
+	}
+	if spec.TimeZone != "" {
+		if spec.Schedule == "" {
+			allErrs = append(allErrs, field.Forbidden(specPath.Child("timeZone"), "timeZone requires a schedule"))
+		} else if _, err := time.LoadLocation(spec.TimeZone); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("timeZone"), spec.TimeZone, err.Error()))
+		}
+	}
+	if spec.Timeout != nil && spec.Timeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("timeout"), spec.Timeout.Duration.String(), "must be positive"))
+	}
+	allErrs = append(allErrs, validateResourceNames(spec.Outputs, specPath.Child("outputs"))...)
+	return allErrs
+}
+
+func validateGNMIPrefix(prefix string, fldPath *field.Path) field.ErrorList {
+	if prefix == "" {
+		return nil
+	}
+	if _, err := path.CreatePrefix(prefix, ""); err != nil {
+		return field.ErrorList{field.Invalid(fldPath, prefix, err.Error())}
+	}
+	return nil
+}
+
+func validateGNMIPath(p string, fldPath *field.Path) field.ErrorList {
+	if _, err := path.ParsePath(p); err != nil {
+		return field.ErrorList{field.Invalid(fldPath, p, err.Error())}
+	}
+	return nil
+}
@@ -19,6 +19,8 @@ package v1alpha1
 import (
 	"context"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -81,18 +83,14 @@ var _ admission.Validator[*operatorv1alpha1.Input] = &InputCustomValidator{}
 func (v *InputCustomValidator) ValidateCreate(_ context.Context, input *operatorv1alpha1.Input) (admission.Warnings, error) {
 	inputlog.Info("Validation for Input upon creation", "name", input.GetName())
 
-	// TODO(user): fill in your validation logic upon object creation.
-
-	return nil, nil
+	return validateInputSpec(input.GetName(), &input.Spec, nil)
 }
 
 // ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Input.
-func (v *InputCustomValidator) ValidateUpdate(_ context.Context, _ *operatorv1alpha1.Input, input *operatorv1alpha1.Input) (admission.Warnings, error) {
+func (v *InputCustomValidator) ValidateUpdate(_ context.Context, oldInput *operatorv1alpha1.Input, input *operatorv1alpha1.Input) (admission.Warnings, error) {
 	inputlog.Info("Validation for Input upon update", "name", input.GetName())
 
-	// TODO(user): fill in your validation logic upon object update.
-
-	return nil, nil
+	return validateInputSpec(input.GetName(), &input.Spec, &oldInput.Spec)
 }
 
 // ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Input.
@@ -103,3 +101,30 @@ func (v *InputCustomValidator) ValidateDelete(ctx context.Context, input *operat
 
 	return nil, nil
 }
+
+// validateInputSpec validates the type, config and secretRef of an InputSpec
+// against the input schema registry. On update, old is the spec being updated.
+func validateInputSpec(name string, spec, old *operatorv1alpha1.InputSpec) (admission.Warnings, error) {
+	specPath := field.NewPath("spec")
+	config, warnings, allErrs := validateTypedConfig(inputConfigSchemas, spec.Type, spec.Config.Raw, specPath)
+	if old != nil && old.Type == spec.Type {
+		// the config of an existing input is only warned about where it
+		// did not change, e.g. after a schema was tightened. A changed type
+		// is validated as new.
+		_, _, oldErrs := validateTypedConfig(inputConfigSchemas, old.Type, old.Config.Raw, specPath)
+		var ratchetWarnings admission.Warnings
+		allErrs, ratchetWarnings = ratchetErrors(allErrs, oldErrs, spec, old)
+		warnings = append(warnings, ratchetWarnings...)
+	}
+	secretWarnings, errs := validateConfigSecretRef(inputConfigSchemas, spec.Type, config, spec.SecretRef, specPath.Child("secretRef"))
+	warnings = append(warnings, secretWarnings...)
+	allErrs = append(allErrs, errs...)
+	if len(allErrs) == 0 {
+		return warnings, nil
+	}
+	return warnings, apierrors.NewInvalid(
+		operatorv1alpha1.GroupVersion.WithKind("Input").GroupKind(),
+		name,
+		allErrs,
+	)
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	operatorv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var modelsetlog = logf.Log.WithName("modelset-resource")
+
+// SetupModelSetWebhookWithManager registers the webhook for ModelSet in the manager.
+func SetupModelSetWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &operatorv1alpha1.ModelSet{}).
+		WithValidator(&ModelSetCustomValidator{}).
+		WithDefaulter(&ModelSetCustomDefaulter{}).
+		Complete()
+}
+
+// TODO(user): EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+
+// +kubebuilder:webhook:path=/mutate-operator-gnmic-dev-v1alpha1-modelset,mutating=true,failurePolicy=fail,sideEffects=None,groups=operator.gnmic.dev,resources=modelsets,verbs=create;update,versions=v1alpha1,name=mmodelset-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// ModelSetCustomDefaulter struct is responsible for setting default values on the custom resource of the
+// Kind ModelSet when those are created or updated.
+//
+// NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
+// as it is used only for temporary operations and does not need to be deeply copied.
+type ModelSetCustomDefaulter struct {
+	// TODO(user): Add more fields as needed for defaulting
+}
+
+var _ admission.Defaulter[*operatorv1alpha1.ModelSet] = &ModelSetCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind ModelSet.
+func (d *ModelSetCustomDefaulter) Default(_ context.Context, modelset *operatorv1alpha1.ModelSet) error {
+	modelsetlog.Info("Defaulting for ModelSet", "name", modelset.GetName())
+
+	// TODO(user): fill in your defaulting logic.
+
+	return nil
+}
+
+// TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
+// NOTE: If you want to customise the 'path', use the flags '--defaulting-path' or '--validation-path'.
+// +kubebuilder:webhook:path=/validate-operator-gnmic-dev-v1alpha1-modelset,mutating=false,failurePolicy=fail,sideEffects=None,groups=operator.gnmic.dev,resources=modelsets,verbs=create;update,versions=v1alpha1,name=vmodelset-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// ModelSetCustomValidator struct is responsible for validating the ModelSet resource
+// when it is created, updated, or deleted.
+//
+// NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
+// as this struct is used only for temporary operations and does not need to be deeply copied.
+type ModelSetCustomValidator struct{}
+
+var _ admission.Validator[*operatorv1alpha1.ModelSet] = &ModelSetCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type ModelSet.
+func (v *ModelSetCustomValidator) ValidateCreate(_ context.Context, modelset *operatorv1alpha1.ModelSet) (admission.Warnings, error) {
+	modelsetlog.Info("Validation for ModelSet upon creation", "name", modelset.GetName())
+
+	return nil, validateModelSetSpec(modelset.GetName(), &modelset.Spec)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type ModelSet.
+func (v *ModelSetCustomValidator) ValidateUpdate(_ context.Context, _ *operatorv1alpha1.ModelSet, modelset *operatorv1alpha1.ModelSet) (admission.Warnings, error) {
+	modelsetlog.Info("Validation for ModelSet upon update", "name", modelset.GetName())
+
+	return nil, validateModelSetSpec(modelset.GetName(), &modelset.Spec)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type ModelSet.
+func (v *ModelSetCustomValidator) ValidateDelete(_ context.Context, modelset *operatorv1alpha1.ModelSet) (admission.Warnings, error) {
+	modelsetlog.Info("Validation for ModelSet upon deletion", "name", modelset.GetName())
+
+	return nil, nil
+}
+
+// validateModelSetSpec validates the ModelSetSpec fields.
+func validateModelSetSpec(name string, spec *operatorv1alpha1.ModelSetSpec) error {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	// version is required.
+	if spec.Version == "" {
+		allErrs = append(allErrs, field.Required(
+			specPath.Child("version"),
+			"version is required",
+		))
+	}
+
+	// at least one ConfigMap is required.
+	if len(spec.ConfigMaps) == 0 {
+		allErrs = append(allErrs, field.Required(
+			specPath.Child("configMaps"),
+			"at least one ConfigMap holding YANG modules is required",
+		))
+	}
+	allErrs = append(allErrs, validateResourceNames(spec.ConfigMaps, specPath.Child("configMaps"))...)
+
+	// modules are named once.
+	seen := make(map[string]struct{}, len(spec.Modules))
+	for i, module := range spec.Modules {
+		if _, ok := seen[module]; ok {
+			allErrs = append(allErrs, field.Duplicate(specPath.Child("modules").Index(i), module))
+		}
+		seen[module] = struct{}{}
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(
+		operatorv1alpha1.GroupVersion.WithKind("ModelSet").GroupKind(),
+		name,
+		allErrs,
+	)
+}
@@ -18,12 +18,18 @@ package v1alpha1
 
 import (
 	"context"
+	"net"
+	"net/url"
+	"strconv"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	operatorv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
 )
 
 // nolint:unused
@@ -81,18 +87,14 @@ var _ admission.Validator[*operatorv1alpha1.Output] = &OutputCustomValidator{}
 func (v *OutputCustomValidator) ValidateCreate(_ context.Context, output *operatorv1alpha1.Output) (admission.Warnings, error) {
 	outputlog.Info("Validation for Output upon creation", "name", output.GetName())
 
-	// TODO(user): fill in your validation logic upon object creation.
-
-	return nil, nil
+	return validateOutputSpec(output.GetName(), &output.Spec, nil)
 }
 
 // ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Output.
-func (v *OutputCustomValidator) ValidateUpdate(_ context.Context, _ *operatorv1alpha1.Output, output *operatorv1alpha1.Output) (admission.Warnings, error) {
+func (v *OutputCustomValidator) ValidateUpdate(_ context.Context, oldOutput *operatorv1alpha1.Output, output *operatorv1alpha1.Output) (admission.Warnings, error) {
 	outputlog.Info("Validation for Output upon update", "name", output.GetName())
 
-	// TODO(user): fill in your validation logic upon object update.
-
-	return nil, nil
+	return validateOutputSpec(output.GetName(), &output.Spec, &oldOutput.Spec)
 }
 
 // ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Output.
@@ -103,3 +105,105 @@ func (v *OutputCustomValidator) ValidateDelete(ctx context.Context, output *oper
 
 	return nil, nil
 }
+
+// validateOutputSpec validates the OutputSpec fields. On update, old is the
+// spec being updated.
+func validateOutputSpec(name string, spec, old *operatorv1alpha1.OutputSpec) (admission.Warnings, error) {
+	specPath := field.NewPath("spec")
+
+	// type and config are validated against the output schema registry.
+	config, warnings, allErrs := validateTypedConfig(outputConfigSchemas, spec.Type, spec.Config.Raw, specPath)
+	if old != nil && old.Type == spec.Type {
+		// the config of an existing output is only warned about where it
+		// did not change, e.g. after a schema was tightened. A changed type
+		// is validated as new.
+		_, _, oldErrs := validateTypedConfig(outputConfigSchemas, old.Type, old.Config.Raw, specPath)
+		var ratchetWarnings admission.Warnings
+		allErrs, ratchetWarnings = ratchetErrors(allErrs, oldErrs, spec, old)
+		warnings = append(warnings, ratchetWarnings...)
+	}
+
+	secretWarnings, errs := validateConfigSecretRef(outputConfigSchemas, spec.Type, config, spec.SecretRef, specPath.Child("secretRef"))
+	warnings = append(warnings, secretWarnings...)
+	allErrs = append(allErrs, errs...)
+
+	// an otlp output exports to a single endpoint, which a selector matching
+	// several Services cannot resolve to
+	if spec.Type == gnmic.OTLPOutputType && spec.ServiceSelector != nil {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("serviceSelector"),
+			"otlp outputs export to a single endpoint, use serviceRef"))
+	}
+
+	if spec.Type == gnmic.OTLPOutputType && config != nil {
+		otlpWarnings, errs := validateOTLPOutput(spec, config, specPath)
+		warnings = append(warnings, otlpWarnings...)
+		allErrs = append(allErrs, errs...)
+	}
+
+	if len(allErrs) == 0 {
+		return warnings, nil
+	}
+	return warnings, apierrors.NewInvalid(
+		operatorv1alpha1.GroupVersion.WithKind("Output").GroupKind(),
+		name,
+		allErrs,
+	)
+}
+
+// validateOTLPOutput validates the endpoint of an otlp output config against
+// its protocol; key types are checked by the output schema.
+func validateOTLPOutput(spec *operatorv1alpha1.OutputSpec, config map[string]any, specPath *field.Path) (admission.Warnings, field.ErrorList) {
+	var allErrs field.ErrorList
+	var warnings admission.Warnings
+	configPath := specPath.Child("config")
+
+	// an unsupported protocol is reported by the schema.
+	protocol := gnmic.OTLPProtocol(config)
+	if protocol != gnmic.OTLPProtocolGRPC && protocol != gnmic.OTLPProtocolHTTP {
+		return nil, nil
+	}
+
+	// endpoint is required unless resolved from a service.
+	fromService := spec.ServiceRef != nil || spec.ServiceSelector != nil
+	endpoint, hasEndpoint := config["endpoint"]
+	switch {
+	case !hasEndpoint && !fromService:
+		allErrs = append(allErrs, field.Required(
+			configPath.Child("endpoint"),
+			"endpoint is required unless serviceRef or serviceSelector is set",
+		))
+	case hasEndpoint:
+		if fromService {
+			warnings = append(warnings, "spec.config.endpoint is overridden by the address resolved from serviceRef or serviceSelector")
+		}
+		if msg := otlpEndpointError(endpoint, protocol); msg != "" {
+			allErrs = append(allErrs, field.Invalid(configPath.Child("endpoint"), endpoint, msg))
+		}
+	}
+
+	return warnings, allErrs
+}
+
+// otlpEndpointError returns why an otlp endpoint is invalid for the protocol,
+// or an empty string: gRPC endpoints are host:port, HTTP endpoints are URLs.
+func otlpEndpointError(v any, protocol string) string {
+	endpoint, ok := v.(string)
+	if !ok || endpoint == "" {
+		return "endpoint must be a non-empty string"
+	}
+	if protocol == gnmic.OTLPProtocolHTTP {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "endpoint must be an http:// or https:// URL when protocol is http"
+		}
+		return ""
+	}
+	host, portStr, err := net.SplitHostPort(endpoint)
+	if err != nil || host == "" {
+		return "endpoint must be in host:port format when protocol is grpc"
+	}
+	if port, err := strconv.Atoi(portStr); err != nil || port < 1 || port > 65535 {
+		return "port must be between 1 and 65535"
+	}
+	return ""
+}
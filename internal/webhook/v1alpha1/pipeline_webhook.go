@@ -18,14 +18,17 @@ package v1alpha1
 
 import (
 	"context"
+	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	operatorv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/utils"
 )
 
 // nolint:unused
@@ -143,6 +146,17 @@ func validatePipelineSpec(spec *operatorv1alpha1.PipelineSpec) error {
 		))
 	}
 
+	if spec.TTLAfterEnable != nil && spec.TTLAfterEnable.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(
+			specPath.Child("ttlAfterEnable"),
+			spec.TTLAfterEnable.Duration.String(),
+			"must be positive",
+		))
+	}
+	if spec.Schedule != nil {
+		allErrs = append(allErrs, validatePipelineSchedule(spec.Schedule, specPath.Child("schedule"))...)
+	}
+
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -152,3 +166,44 @@ func validatePipelineSpec(spec *operatorv1alpha1.PipelineSpec) error {
 		allErrs,
 	)
 }
+
+func validatePipelineSchedule(schedule *operatorv1alpha1.PipelineSchedule, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if schedule.TimeZone != "" {
+		if _, err := time.LoadLocation(schedule.TimeZone); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("timeZone"), schedule.TimeZone, err.Error()))
+		}
+	}
+	for i := range schedule.ActiveWindows {
+		allErrs = append(allErrs, validateScheduleWindow(&schedule.ActiveWindows[i], fldPath.Child("activeWindows").Index(i))...)
+	}
+	for i := range schedule.MaintenanceWindows {
+		window := &schedule.MaintenanceWindows[i]
+		windowPath := fldPath.Child("maintenanceWindows").Index(i)
+		allErrs = append(allErrs, validateScheduleWindow(&window.ScheduleWindow, windowPath)...)
+		if window.TargetSelector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(window.TargetSelector); err != nil {
+				allErrs = append(allErrs, field.Invalid(windowPath.Child("targetSelector"), window.TargetSelector, err.Error()))
+			}
+		}
+	}
+	return allErrs
+}
+
+func validateScheduleWindow(window *operatorv1alpha1.ScheduleWindow, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	switch {
+	case window.Cron == "" && window.Start == nil:
+		allErrs = append(allErrs, field.Required(fldPath, "one of cron or start is required"))
+	case window.Cron != "" && window.Start != nil:
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("start"), "cron and start are mutually exclusive"))
+	case window.Cron != "":
+		if _, err := utils.ParseCron(window.Cron); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("cron"), window.Cron, err.Error()))
+		}
+	}
+	if window.Duration.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("duration"), window.Duration.Duration.String(), "must be positive"))
+	}
+	return allErrs
+}
@@ -19,6 +19,8 @@ package v1alpha1
 import (
 	"context"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -81,18 +83,14 @@ var _ admission.Validator[*operatorv1alpha1.Processor] = &ProcessorCustomValidat
 func (v *ProcessorCustomValidator) ValidateCreate(_ context.Context, processor *operatorv1alpha1.Processor) (admission.Warnings, error) {
 	processorlog.Info("Validation for Processor upon creation", "name", processor.GetName())
 
-	// TODO(user): fill in your validation logic upon object creation.
-
-	return nil, nil
+	return validateProcessorSpec(processor.GetName(), &processor.Spec, nil)
 }
 
 // ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Processor.
-func (v *ProcessorCustomValidator) ValidateUpdate(_ context.Context, _ *operatorv1alpha1.Processor, processor *operatorv1alpha1.Processor) (admission.Warnings, error) {
+func (v *ProcessorCustomValidator) ValidateUpdate(_ context.Context, oldProcessor *operatorv1alpha1.Processor, processor *operatorv1alpha1.Processor) (admission.Warnings, error) {
 	processorlog.Info("Validation for Processor upon update", "name", processor.GetName())
 
-	// TODO(user): fill in your validation logic upon object update.
-
-	return nil, nil
+	return validateProcessorSpec(processor.GetName(), &processor.Spec, &oldProcessor.Spec)
 }
 
 // ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Processor.
@@ -103,3 +101,27 @@ func (v *ProcessorCustomValidator) ValidateDelete(ctx context.Context, processor
 
 	return nil, nil
 }
+
+// validateProcessorSpec validates the type and config of a ProcessorSpec against the
+// processor schema registry. On update, old is the spec being updated.
+func validateProcessorSpec(name string, spec, old *operatorv1alpha1.ProcessorSpec) (admission.Warnings, error) {
+	specPath := field.NewPath("spec")
+	_, warnings, allErrs := validateTypedConfig(processorConfigSchemas, spec.Type, spec.Config.Raw, specPath)
+	if old != nil && old.Type == spec.Type {
+		// the config of an existing processor is only warned about where it
+		// did not change, e.g. after a schema was tightened. A changed type
+		// is validated as new.
+		_, _, oldErrs := validateTypedConfig(processorConfigSchemas, old.Type, old.Config.Raw, specPath)
+		var ratchetWarnings admission.Warnings
+		allErrs, ratchetWarnings = ratchetErrors(allErrs, oldErrs, spec, old)
+		warnings = append(warnings, ratchetWarnings...)
+	}
+	if len(allErrs) == 0 {
+		return warnings, nil
+	}
+	return warnings, apierrors.NewInvalid(
+		operatorv1alpha1.GroupVersion.WithKind("Processor").GroupKind(),
+		name,
+		allErrs,
+	)
+}
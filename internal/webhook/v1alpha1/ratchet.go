@@ -0,0 +1,128 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ratchetErrors splits the errors of an update. An error the old object has
+// as well, on a field whose value did not change, is returned as a warning:
+// an object admitted before a check was added or tightened can still be
+// updated, e.g. to change its other fields. The other errors are enforced.
+// spec and oldSpec are the specs the field paths of the errors, rooted at
+// spec, point into.
+func ratchetErrors(errs, oldErrs field.ErrorList, spec, oldSpec any) (field.ErrorList, admission.Warnings) {
+	if len(errs) == 0 || len(oldErrs) == 0 {
+		return errs, nil
+	}
+	obj, okObj := unstructuredSpec(spec)
+	oldObj, okOld := unstructuredSpec(oldSpec)
+	if !okObj || !okOld {
+		return errs, nil
+	}
+
+	var enforced field.ErrorList
+	var warnings admission.Warnings
+	for _, err := range errs {
+		if hasError(oldErrs, err) {
+			v, set := fieldValue(obj, err.Field)
+			oldV, oldSet := fieldValue(oldObj, err.Field)
+			if set == oldSet && reflect.DeepEqual(v, oldV) {
+				warnings = append(warnings, err.Error()+" (unchanged, not enforced on update)")
+				continue
+			}
+		}
+		enforced = append(enforced, err)
+	}
+	return enforced, warnings
+}
+
+// hasError reports whether errs has an error of the same type, on the same
+// field, with the same detail as err.
+func hasError(errs field.ErrorList, err *field.Error) bool {
+	for _, e := range errs {
+		if e.Type == err.Type && e.Field == err.Field && e.Detail == err.Detail {
+			return true
+		}
+	}
+	return false
+}
+
+// unstructuredSpec returns a spec as its JSON representation, under "spec"
+// like the field paths of the errors.
+func unstructuredSpec(spec any) (map[string]any, bool) {
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return nil, false
+	}
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, false
+	}
+	return map[string]any{"spec": v}, true
+}
+
+// fieldValue returns the value at a field path, e.g.
+// spec.streams[0].sampleInterval or spec.config[topic], and whether it is
+// set.
+func fieldValue(obj any, path string) (any, bool) {
+	v := obj
+	for path != "" {
+		var key string
+		switch {
+		case path[0] == '.':
+			path = path[1:]
+			continue
+		case path[0] == '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return nil, false
+			}
+			key, path = path[1:end], path[end+1:]
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			key, path = path[:end], path[end:]
+		}
+		switch node := v.(type) {
+		case map[string]any:
+			child, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			v = child
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
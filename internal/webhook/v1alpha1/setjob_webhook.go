@@ -0,0 +1,139 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	operatorv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var setjoblog = logf.Log.WithName("setjob-resource")
+
+// SetupSetJobWebhookWithManager registers the webhook for SetJob in the manager.
+func SetupSetJobWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &operatorv1alpha1.SetJob{}).
+		WithValidator(&SetJobCustomValidator{}).
+		WithDefaulter(&SetJobCustomDefaulter{}).
+		Complete()
+}
+
+// TODO(user): EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+
+// +kubebuilder:webhook:path=/mutate-operator-gnmic-dev-v1alpha1-setjob,mutating=true,failurePolicy=fail,sideEffects=None,groups=operator.gnmic.dev,resources=setjobs,verbs=create;update,versions=v1alpha1,name=msetjob-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// SetJobCustomDefaulter struct is responsible for setting default values on the custom resource of the
+// Kind SetJob when those are created or updated.
+//
+// NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
+// as it is used only for temporary operations and does not need to be deeply copied.
+type SetJobCustomDefaulter struct {
+	// TODO(user): Add more fields as needed for defaulting
+}
+
+var _ admission.Defaulter[*operatorv1alpha1.SetJob] = &SetJobCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind SetJob.
+func (d *SetJobCustomDefaulter) Default(_ context.Context, setjob *operatorv1alpha1.SetJob) error {
+	setjoblog.Info("Defaulting for SetJob", "name", setjob.GetName())
+
+	// TODO(user): fill in your defaulting logic.
+
+	return nil
+}
+
+// TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
+// NOTE: If you want to customise the 'path', use the flags '--defaulting-path' or '--validation-path'.
+// +kubebuilder:webhook:path=/validate-operator-gnmic-dev-v1alpha1-setjob,mutating=false,failurePolicy=fail,sideEffects=None,groups=operator.gnmic.dev,resources=setjobs,verbs=create;update,versions=v1alpha1,name=vsetjob-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// SetJobCustomValidator struct is responsible for validating the SetJob resource
+// when it is created, updated, or deleted.
+//
+// NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
+// as this struct is used only for temporary operations and does not need to be deeply copied.
+type SetJobCustomValidator struct{}
+
+var _ admission.Validator[*operatorv1alpha1.SetJob] = &SetJobCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type SetJob.
+func (v *SetJobCustomValidator) ValidateCreate(_ context.Context, setjob *operatorv1alpha1.SetJob) (admission.Warnings, error) {
+	setjoblog.Info("Validation for SetJob upon creation", "name", setjob.GetName())
+
+	return nil, validateSetJobSpec(setjob.GetName(), &setjob.Spec)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type SetJob.
+func (v *SetJobCustomValidator) ValidateUpdate(_ context.Context, _ *operatorv1alpha1.SetJob, setjob *operatorv1alpha1.SetJob) (admission.Warnings, error) {
+	setjoblog.Info("Validation for SetJob upon update", "name", setjob.GetName())
+
+	return nil, validateSetJobSpec(setjob.GetName(), &setjob.Spec)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type SetJob.
+func (v *SetJobCustomValidator) ValidateDelete(_ context.Context, setjob *operatorv1alpha1.SetJob) (admission.Warnings, error) {
+	setjoblog.Info("Validation for SetJob upon deletion", "name", setjob.GetName())
+
+	return nil, nil
+}
+
+// validateSetJobSpec validates the SetJobSpec fields.
+func validateSetJobSpec(name string, spec *operatorv1alpha1.SetJobSpec) error {
+	specPath := field.NewPath("spec")
+	allErrs := validateGNMIJobSpec(&spec.GNMIJobSpec, specPath)
+	allErrs = append(allErrs, validateGNMIPrefix(spec.Prefix, specPath.Child("prefix"))...)
+
+	// at least one operation is required.
+	if len(spec.Deletes) == 0 && len(spec.Replaces) == 0 && len(spec.Updates) == 0 {
+		allErrs = append(allErrs, field.Required(
+			specPath,
+			"at least one of deletes, replaces or updates is required",
+		))
+	}
+	for i, p := range spec.Deletes {
+		allErrs = append(allErrs, validateGNMIPath(p, specPath.Child("deletes").Index(i))...)
+	}
+	allErrs = append(allErrs, validateSetJobUpdates(spec.Replaces, specPath.Child("replaces"))...)
+	allErrs = append(allErrs, validateSetJobUpdates(spec.Updates, specPath.Child("updates"))...)
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(
+		operatorv1alpha1.GroupVersion.WithKind("SetJob").GroupKind(),
+		name,
+		allErrs,
+	)
+}
+
+func validateSetJobUpdates(updates []operatorv1alpha1.SetJobUpdate, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, u := range updates {
+		allErrs = append(allErrs, validateGNMIPath(u.Path, fldPath.Index(i).Child("path"))...)
+		if len(u.Value.Raw) == 0 {
+			allErrs = append(allErrs, field.Required(fldPath.Index(i).Child("value"), "value is required"))
+		}
+	}
+	return allErrs
+}
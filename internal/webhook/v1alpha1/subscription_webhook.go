@@ -18,15 +18,22 @@ package v1alpha1
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	operatorv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/utils"
+	"github.com/gnmic/operator/internal/yang"
 )
 
 // nolint:unused
@@ -34,9 +41,11 @@ import (
 var subscriptionlog = logf.Log.WithName("subscription-resource")
 
 // SetupSubscriptionWebhookWithManager registers the webhook for Subscription in the manager.
-func SetupSubscriptionWebhookWithManager(mgr ctrl.Manager) error {
+// The paths are checked against the ModelSets loaded by models, shared with the
+// controllers so that the modules are not parsed again.
+func SetupSubscriptionWebhookWithManager(mgr ctrl.Manager, models *yang.Loader) error {
 	return ctrl.NewWebhookManagedBy(mgr, &operatorv1alpha1.Subscription{}).
-		WithValidator(&SubscriptionCustomValidator{}).
+		WithValidator(&SubscriptionCustomValidator{Client: mgr.GetClient(), Models: models}).
 		WithDefaulter(&SubscriptionCustomDefaulter{}).
 		Complete()
 }
@@ -74,22 +83,29 @@ func (d *SubscriptionCustomDefaulter) Default(_ context.Context, subscription *o
 //
 // NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
 // as this struct is used only for temporary operations and does not need to be deeply copied.
-type SubscriptionCustomValidator struct{}
+type SubscriptionCustomValidator struct {
+	// Client reads the ModelSets and their ConfigMaps. The paths are not
+	// checked against a ModelSet when nil.
+	Client client.Reader
+	// Models loads the ModelSets the paths are checked against. The paths
+	// are not checked when nil.
+	Models *yang.Loader
+}
 
 var _ admission.Validator[*operatorv1alpha1.Subscription] = &SubscriptionCustomValidator{}
 
 // ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Subscription.
-func (v *SubscriptionCustomValidator) ValidateCreate(_ context.Context, subscription *operatorv1alpha1.Subscription) (admission.Warnings, error) {
+func (v *SubscriptionCustomValidator) ValidateCreate(ctx context.Context, subscription *operatorv1alpha1.Subscription) (admission.Warnings, error) {
 	subscriptionlog.Info("Validation for Subscription upon creation", "name", subscription.GetName())
 
-	return nil, validateSubscriptionSpec(subscription.GetName(), &subscription.Spec)
+	return v.validate(ctx, subscription, nil)
 }
 
 // ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Subscription.
-func (v *SubscriptionCustomValidator) ValidateUpdate(_ context.Context, _ *operatorv1alpha1.Subscription, subscription *operatorv1alpha1.Subscription) (admission.Warnings, error) {
+func (v *SubscriptionCustomValidator) ValidateUpdate(ctx context.Context, oldSubscription *operatorv1alpha1.Subscription, subscription *operatorv1alpha1.Subscription) (admission.Warnings, error) {
 	subscriptionlog.Info("Validation for Subscription upon update", "name", subscription.GetName())
 
-	return nil, validateSubscriptionSpec(subscription.GetName(), &subscription.Spec)
+	return v.validate(ctx, subscription, oldSubscription)
 }
 
 // ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Subscription.
@@ -99,51 +115,160 @@ func (v *SubscriptionCustomValidator) ValidateDelete(_ context.Context, subscrip
 	return nil, nil
 }
 
-// validateSubscriptionSpec validates the SubscriptionSpec fields.
-func validateSubscriptionSpec(name string, spec *operatorv1alpha1.SubscriptionSpec) error {
+// validate validates the Subscription fields, then its paths against the
+// ModelSet it references. On update, old is the Subscription being updated.
+func (v *SubscriptionCustomValidator) validate(ctx context.Context, subscription, old *operatorv1alpha1.Subscription) (admission.Warnings, error) {
+	var oldSpec *operatorv1alpha1.SubscriptionSpec
+	if old != nil {
+		oldSpec = &old.Spec
+	}
+	warnings, err := validateSubscriptionSpec(subscription.GetName(), &subscription.Spec, oldSpec)
+	if err != nil {
+		return warnings, err
+	}
+	if subscription.Spec.ModelSet == nil || v.Client == nil || v.Models == nil {
+		return warnings, nil
+	}
+	pathWarnings, allErrs := v.validateModelPaths(ctx, subscription)
+	warnings = append(warnings, pathWarnings...)
+	if len(allErrs) == 0 {
+		return warnings, nil
+	}
+	return warnings, apierrors.NewInvalid(
+		operatorv1alpha1.GroupVersion.WithKind("Subscription").GroupKind(),
+		subscription.GetName(),
+		allErrs,
+	)
+}
+
+// validateModelPaths checks the paths of a Subscription against the data
+// nodes of its ModelSet. Paths not in the models are rejected; the hints on
+// whether the stream mode suits their leaves are returned as warnings. A
+// ModelSet that cannot be loaded is only warned about, so that it can be
+// applied along with its Subscriptions.
+func (v *SubscriptionCustomValidator) validateModelPaths(ctx context.Context, subscription *operatorv1alpha1.Subscription) (admission.Warnings, field.ErrorList) {
+	spec := &subscription.Spec
+	schema, err := v.Models.LoadReference(ctx, v.Client, subscription.Namespace, spec.ModelSet)
+	if err != nil {
+		return admission.Warnings{fmt.Sprintf("spec.modelSet: the paths were not checked: %v", err)}, nil
+	}
+
+	var warnings admission.Warnings
+	var allErrs field.ErrorList
+	check := func(fldPath *field.Path, paths []string, mode string) {
+		for i, p := range paths {
+			hints, err := schema.Check(yang.JoinPath(spec.Prefix, p), mode)
+			if err != nil {
+				allErrs = append(allErrs, field.Invalid(fldPath.Index(i), p, err.Error()))
+				continue
+			}
+			for _, hint := range hints {
+				warnings = append(warnings, fmt.Sprintf("%s: %s", fldPath.Index(i), hint))
+			}
+		}
+	}
+	_, streamMode, _ := strings.Cut(spec.Mode, "/")
+	check(field.NewPath("spec", "paths"), spec.Paths, streamMode)
+	for i, stream := range spec.Streams {
+		mode := stream.Mode
+		if mode == "" {
+			mode = streamMode
+		}
+		check(field.NewPath("spec", "streams").Index(i).Child("paths"), stream.Paths, mode)
+	}
+	return warnings, allErrs
+}
+
+// subscriptionModes are the gNMI SubscriptionList modes, with the stream mode
+// of STREAM subscriptions. A Subscription without a mode streams in the
+// stream mode the target defines.
+var subscriptionModes = []string{"", "ONCE", "POLL", "STREAM", "STREAM/SAMPLE", "STREAM/ON_CHANGE", "STREAM/TARGET_DEFINED"}
+
+// streamModes are the gNMI stream modes of the paths of a STREAM subscription.
+var streamModes = []string{"", "SAMPLE", "ON_CHANGE", "TARGET_DEFINED"}
+
+// validateSubscriptionSpec validates the SubscriptionSpec fields. On update,
+// old is the spec being updated: the fields that do not apply to the mode are
+// only warned about when they, and the mode, did not change.
+func validateSubscriptionSpec(name string, spec, old *operatorv1alpha1.SubscriptionSpec) (admission.Warnings, error) {
 	var allErrs field.ErrorList
 	specPath := field.NewPath("spec")
 
-	// at least one path is required unless streamSubscriptions are provided.
-	if len(spec.Paths) == 0 && len(spec.StreamSubscriptions) == 0 {
+	// at least one path is required unless stream subscriptions are provided.
+	if len(spec.Paths) == 0 && len(spec.StreamSubscriptions) == 0 && len(spec.Streams) == 0 {
 		allErrs = append(allErrs, field.Required(
 			specPath.Child("paths"),
-			"at least one path is required when streamSubscriptions is empty",
+			"at least one path is required when streamSubscriptions and streams are empty",
 		))
 	}
 
-	// when streamSubscriptions are set, the mode must be a STREAM mode.
-	if len(spec.StreamSubscriptions) > 0 && !strings.HasPrefix(spec.Mode, "STREAM") {
+	if !slices.Contains(subscriptionModes, spec.Mode) {
+		allErrs = append(allErrs, field.NotSupported(specPath.Child("mode"), spec.Mode, subscriptionModes[1:]))
+	}
+	mode, _, _ := strings.Cut(spec.Mode, "/")
+
+	// stream subscriptions are sent in a STREAM SubscriptionList.
+	if (len(spec.StreamSubscriptions) > 0 || len(spec.Streams) > 0) && mode != "" && mode != "STREAM" {
 		allErrs = append(allErrs, field.Invalid(
 			specPath.Child("mode"),
 			spec.Mode,
-			"mode must be STREAM when streamSubscriptions are set",
+			"mode must be STREAM when streamSubscriptions or streams are set",
 		))
 	}
 
-	// sampleInterval must be positive when set.
-	if spec.SampleInterval.Duration < 0 {
-		allErrs = append(allErrs, field.Invalid(
-			specPath.Child("sampleInterval"),
-			spec.SampleInterval.Duration.String(),
-			"sampleInterval must be a positive duration",
-		))
+	modeErrs := validateModeFields(specPath, spec)
+	var warnings admission.Warnings
+	if old != nil {
+		modeErrs, warnings = ratchetErrors(modeErrs, validateModeFields(specPath, old), spec, old)
 	}
+	allErrs = append(allErrs, modeErrs...)
 
-	// heartbeatInterval must be positive when set.
-	if spec.HeartbeatInterval.Duration < 0 {
-		allErrs = append(allErrs, field.Invalid(
-			specPath.Child("heartbeatInterval"),
-			spec.HeartbeatInterval.Duration.String(),
-			"heartbeatInterval must be a positive duration",
+	allErrs = append(allErrs, validatePollSchedule(specPath, mode, spec)...)
+
+	for i, stream := range spec.Streams {
+		streamPath := specPath.Child("streams").Index(i)
+		if len(stream.Paths) == 0 {
+			allErrs = append(allErrs, field.Required(streamPath.Child("paths"), "at least one path is required"))
+		}
+		if !slices.Contains(streamModes, stream.Mode) {
+			allErrs = append(allErrs, field.NotSupported(streamPath.Child("mode"), stream.Mode, streamModes[1:]))
+		}
+	}
+
+	// setTarget sets the target of the prefix, which target sets explicitly.
+	if spec.SetTarget && spec.Target != "" {
+		allErrs = append(allErrs, field.Forbidden(
+			specPath.Child("setTarget"),
+			"setTarget and target are mutually exclusive",
 		))
 	}
 
-	// history: start must be before end when both are set.
+	seenModels := make(map[string]struct{}, len(spec.Models))
+	for i, model := range spec.Models {
+		modelPath := specPath.Child("models").Index(i)
+		if model == "" {
+			allErrs = append(allErrs, field.Required(modelPath, "model name must not be empty"))
+			continue
+		}
+		if _, ok := seenModels[model]; ok {
+			allErrs = append(allErrs, field.Duplicate(modelPath, model))
+		}
+		seenModels[model] = struct{}{}
+	}
+
+	// history: a snapshot or a range whose start is not after its end.
 	if spec.History != nil {
-		if !spec.History.Start.IsZero() && !spec.History.End.IsZero() && spec.History.Start.Before(&spec.History.End) {
+		historyPath := specPath.Child("history")
+		hasRange := !spec.History.Start.IsZero() || !spec.History.End.IsZero()
+		if !spec.History.Snapshot.IsZero() && hasRange {
+			allErrs = append(allErrs, field.Forbidden(
+				historyPath.Child("snapshot"),
+				"snapshot and start/end are mutually exclusive",
+			))
+		}
+		if !spec.History.Start.IsZero() && !spec.History.End.IsZero() && spec.History.End.Before(&spec.History.Start) {
 			allErrs = append(allErrs, field.Invalid(
-				specPath.Child("history", "start"),
+				historyPath.Child("start"),
 				spec.History.Start,
 				"history start must be before end",
 			))
@@ -151,11 +276,143 @@ func validateSubscriptionSpec(name string, spec *operatorv1alpha1.SubscriptionSp
 	}
 
 	if len(allErrs) == 0 {
-		return nil
+		return warnings, nil
 	}
-	return apierrors.NewInvalid(
+	return warnings, apierrors.NewInvalid(
 		operatorv1alpha1.GroupVersion.WithKind("Subscription").GroupKind(),
 		name,
 		allErrs,
 	)
 }
+
+// validateModeFields validates the intervals and suppressRedundant of a
+// subscription and of its streams against their modes.
+func validateModeFields(specPath *field.Path, spec *operatorv1alpha1.SubscriptionSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	mode, streamMode, _ := strings.Cut(spec.Mode, "/")
+
+	// ONCE and POLL subscriptions are not sampled: the intervals and
+	// suppressRedundant only apply to STREAM subscriptions.
+	if mode == "ONCE" || mode == "POLL" {
+		allErrs = append(allErrs, validateStreamOnlyFields(specPath, spec.Mode,
+			spec.SampleInterval, spec.HeartbeatInterval, spec.SuppressRedundant)...)
+	} else {
+		allErrs = append(allErrs, validateStreamFields(specPath, streamMode,
+			spec.SampleInterval, spec.HeartbeatInterval, spec.SuppressRedundant)...)
+	}
+
+	for i, stream := range spec.Streams {
+		effectiveMode := stream.Mode
+		if effectiveMode == "" {
+			effectiveMode = streamMode
+		}
+		allErrs = append(allErrs, validateStreamFields(specPath.Child("streams").Index(i), effectiveMode,
+			stream.SampleInterval, stream.HeartbeatInterval, stream.SuppressRedundant)...)
+	}
+	return allErrs
+}
+
+// minPollInterval is the shortest interval the operator polls targets at.
+const minPollInterval = 10 * time.Second
+
+// validatePollSchedule validates when the operator polls the targets of a
+// POLL subscription.
+func validatePollSchedule(specPath *field.Path, mode string, spec *operatorv1alpha1.SubscriptionSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	if mode != "POLL" {
+		for _, f := range []struct {
+			name string
+			set  bool
+		}{
+			{"pollInterval", spec.PollInterval.Duration != 0},
+			{"pollSchedule", spec.PollSchedule != ""},
+			{"pollJitter", spec.PollJitter != nil},
+		} {
+			if f.set {
+				allErrs = append(allErrs, field.Forbidden(specPath.Child(f.name),
+					fmt.Sprintf("%s only applies to POLL subscriptions", f.name)))
+			}
+		}
+		return allErrs
+	}
+
+	interval := spec.PollInterval.Duration
+	if interval != 0 && spec.PollSchedule != "" {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("pollSchedule"),
+			"pollSchedule and pollInterval are mutually exclusive"))
+	}
+	if interval < 0 || (interval > 0 && interval < minPollInterval) {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("pollInterval"), interval.String(),
+			fmt.Sprintf("pollInterval must be at least %s", minPollInterval)))
+	}
+	if spec.PollSchedule != "" {
+		if _, err := utils.ParseCron(spec.PollSchedule); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("pollSchedule"), spec.PollSchedule, err.Error()))
+		}
+	}
+	if spec.PollJitter == nil {
+		return allErrs
+	}
+	jitterPath := specPath.Child("pollJitter")
+	switch jitter := spec.PollJitter.Duration; {
+	case interval == 0 && spec.PollSchedule == "":
+		allErrs = append(allErrs, field.Forbidden(jitterPath, "pollJitter requires pollInterval or pollSchedule"))
+	case jitter < 0:
+		allErrs = append(allErrs, field.Invalid(jitterPath, jitter.String(), "pollJitter must not be negative"))
+	case interval > 0 && jitter >= interval:
+		allErrs = append(allErrs, field.Invalid(jitterPath, jitter.String(), "pollJitter must be shorter than pollInterval"))
+	}
+	return allErrs
+}
+
+// validateStreamOnlyFields rejects the fields of STREAM subscriptions set on
+// a ONCE or POLL subscription.
+func validateStreamOnlyFields(fldPath *field.Path, mode string, sampleInterval, heartbeatInterval metav1.Duration, suppressRedundant bool) field.ErrorList {
+	var allErrs field.ErrorList
+	if sampleInterval.Duration != 0 {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("sampleInterval"),
+			fmt.Sprintf("sampleInterval only applies to STREAM subscriptions, not %s", mode)))
+	}
+	if heartbeatInterval.Duration != 0 {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("heartbeatInterval"),
+			fmt.Sprintf("heartbeatInterval only applies to STREAM subscriptions, not %s", mode)))
+	}
+	if suppressRedundant {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("suppressRedundant"),
+			fmt.Sprintf("suppressRedundant only applies to STREAM subscriptions, not %s", mode)))
+	}
+	return allErrs
+}
+
+// validateStreamFields validates the intervals of paths streamed in a stream
+// mode: ON_CHANGE paths are not sampled, so they take no sampleInterval and do
+// not suppress redundant samples.
+func validateStreamFields(fldPath *field.Path, streamMode string, sampleInterval, heartbeatInterval metav1.Duration, suppressRedundant bool) field.ErrorList {
+	var allErrs field.ErrorList
+	if sampleInterval.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(
+			fldPath.Child("sampleInterval"),
+			sampleInterval.Duration.String(),
+			"sampleInterval must be a positive duration",
+		))
+	}
+	if heartbeatInterval.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(
+			fldPath.Child("heartbeatInterval"),
+			heartbeatInterval.Duration.String(),
+			"heartbeatInterval must be a positive duration",
+		))
+	}
+	if streamMode != "ON_CHANGE" {
+		return allErrs
+	}
+	if sampleInterval.Duration > 0 {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("sampleInterval"),
+			"sampleInterval does not apply to ON_CHANGE, use heartbeatInterval to resend unchanged values"))
+	}
+	if suppressRedundant {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("suppressRedundant"),
+			"suppressRedundant only applies to sampled paths, not ON_CHANGE"))
+	}
+	return allErrs
+}
@@ -18,16 +18,23 @@ package v1alpha1
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net"
 	"strconv"
+	"strings"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	operatorv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/controller/discovery"
 )
 
 // nolint:unused
@@ -37,7 +44,7 @@ var targetlog = logf.Log.WithName("target-resource")
 // SetupTargetWebhookWithManager registers the webhook for Target in the manager.
 func SetupTargetWebhookWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewWebhookManagedBy(mgr, &operatorv1alpha1.Target{}).
-		WithValidator(&TargetCustomValidator{}).
+		WithValidator(&TargetCustomValidator{Client: mgr.GetClient()}).
 		WithDefaulter(&TargetCustomDefaulter{}).
 		Complete()
 }
@@ -75,22 +82,33 @@ func (d *TargetCustomDefaulter) Default(_ context.Context, target *operatorv1alp
 //
 // NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
 // as this struct is used only for temporary operations and does not need to be deeply copied.
-type TargetCustomValidator struct{}
+type TargetCustomValidator struct {
+	// Client reads the TargetSource of discovered Targets and the Secrets
+	// it allows. The credentialsRef of discovered Targets is not checked
+	// against the TargetSource when nil.
+	Client client.Reader
+}
 
 var _ admission.Validator[*operatorv1alpha1.Target] = &TargetCustomValidator{}
 
 // ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Target.
-func (v *TargetCustomValidator) ValidateCreate(_ context.Context, target *operatorv1alpha1.Target) (admission.Warnings, error) {
+func (v *TargetCustomValidator) ValidateCreate(ctx context.Context, target *operatorv1alpha1.Target) (admission.Warnings, error) {
 	targetlog.Info("Validation for Target upon creation", "name", target.GetName())
 
-	return nil, validateTargetSpec(target.GetName(), &target.Spec)
+	if err := validateTargetSpec(target.GetName(), &target.Spec); err != nil {
+		return nil, err
+	}
+	return nil, v.validateCredentialsRef(ctx, nil, target)
 }
 
 // ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Target.
-func (v *TargetCustomValidator) ValidateUpdate(_ context.Context, _ *operatorv1alpha1.Target, target *operatorv1alpha1.Target) (admission.Warnings, error) {
+func (v *TargetCustomValidator) ValidateUpdate(ctx context.Context, oldTarget *operatorv1alpha1.Target, target *operatorv1alpha1.Target) (admission.Warnings, error) {
 	targetlog.Info("Validation for Target upon update", "name", target.GetName())
 
-	return nil, validateTargetSpec(target.GetName(), &target.Spec)
+	if err := validateTargetSpec(target.GetName(), &target.Spec); err != nil {
+		return nil, err
+	}
+	return nil, v.validateCredentialsRef(ctx, oldTarget, target)
 }
 
 // ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Target.
@@ -147,6 +165,24 @@ func validateTargetSpec(name string, spec *operatorv1alpha1.TargetSpec) error {
 		))
 	}
 
+	if spec.CredentialsRef != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(spec.CredentialsRef) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("credentialsRef"), spec.CredentialsRef, msg))
+		}
+	}
+	allErrs = append(allErrs, validateResourceNames(spec.Subscriptions, specPath.Child("subscriptions"))...)
+	allErrs = append(allErrs, validateResourceNames(spec.Outputs, specPath.Child("outputs"))...)
+	if spec.BufferSize != nil && *spec.BufferSize < 1 {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("bufferSize"), *spec.BufferSize, "buffer size must be at least 1"))
+	}
+	if spec.TLSServerName != "" && net.ParseIP(spec.TLSServerName) == nil {
+		for _, msg := range validation.IsDNS1123Subdomain(strings.ToLower(spec.TLSServerName)) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("tlsServerName"), spec.TLSServerName, msg))
+		}
+	}
+	allErrs = append(allErrs, validateGRPCMetadata(spec.Metadata, specPath.Child("metadata"))...)
+	allErrs = append(allErrs, validateEventTags(spec.Labels, specPath.Child("labels"))...)
+
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -156,3 +192,52 @@ func validateTargetSpec(name string, spec *operatorv1alpha1.TargetSpec) error {
 		allErrs,
 	)
 }
+
+// validateCredentialsRef checks the credentialsRef of a Target discovered by a
+// TargetSource against the Secrets the TargetSource allows. It is only checked
+// when the credentialsRef or the TargetSource of the Target changed, and not
+// once the TargetSource is deleted.
+func (v *TargetCustomValidator) validateCredentialsRef(ctx context.Context, oldTarget, target *operatorv1alpha1.Target) error {
+	source := target.Labels[discovery.LabelTargetSourceName]
+	if v.Client == nil || source == "" || target.Spec.CredentialsRef == "" {
+		return nil
+	}
+	if oldTarget != nil && oldTarget.Spec.CredentialsRef == target.Spec.CredentialsRef &&
+		oldTarget.Labels[discovery.LabelTargetSourceName] == source {
+		return nil
+	}
+	var ts operatorv1alpha1.TargetSource
+	if err := v.Client.Get(ctx, types.NamespacedName{Namespace: target.Namespace, Name: source}, &ts); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get TargetSource %s: %w", source, err)
+	}
+	err := discovery.CheckCredentialsRef(ctx, v.Client, &ts, target.Spec.CredentialsRef)
+	if !errors.Is(err, discovery.ErrCredentialsRefNotAllowed) {
+		return err
+	}
+	return apierrors.NewInvalid(
+		operatorv1alpha1.GroupVersion.WithKind("Target").GroupKind(),
+		target.GetName(),
+		field.ErrorList{field.Forbidden(field.NewPath("spec", "credentialsRef"),
+			fmt.Sprintf("Secret %s is not allowed by the allowedCredentials of TargetSource %s", target.Spec.CredentialsRef, source))},
+	)
+}
+
+// validateResourceNames validates a list of names of resources in the
+// namespace of the Target
+func validateResourceNames(names []string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	seen := make(map[string]struct{}, len(names))
+	for i, name := range names {
+		if _, ok := seen[name]; ok {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i), name))
+		}
+		seen[name] = struct{}{}
+		for _, msg := range validation.IsDNS1123Subdomain(name) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), name, msg))
+		}
+	}
+	return allErrs
+}
@@ -18,15 +18,22 @@ package v1alpha1
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	operatorv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/gnmic"
 )
 
 // nolint:unused
@@ -133,6 +140,19 @@ func validateTargetProfileSpec(spec *operatorv1alpha1.TargetProfileSpec) field.E
 		allErrs = append(allErrs, field.Invalid(specPath.Child("encoding"), spec.Encoding, "encoding must be one of JSON, BYTES, PROTO, ASCII, or JSON_IETF"))
 	}
 
+	if spec.Credentials != nil {
+		credentialsPath := specPath.Child("credentials")
+		if spec.CredentialsRef != "" {
+			allErrs = append(allErrs, field.Forbidden(credentialsPath, "credentials cannot be set with credentialsRef"))
+		}
+		if vault := spec.Credentials.Vault; vault != nil && vault.TLS != nil && vault.TLS.CABundleRef != nil && vault.TLS.InsecureSkipVerify {
+			allErrs = append(allErrs, field.Forbidden(credentialsPath.Child("vault", "tls", "insecureSkipVerify"), "a CA bundle is set to verify Vault, insecureSkipVerify cannot be set with caBundleRef"))
+		}
+		if file := spec.Credentials.File; file != nil && !filepath.IsLocal(file.Path) {
+			allErrs = append(allErrs, field.Invalid(credentialsPath.Child("file", "path"), file.Path, "path must be relative and stay below the operator credentials directory"))
+		}
+	}
+
 	if spec.TLS != nil {
 		tlsPath := specPath.Child("tls")
 		if spec.TLS.MaxVersion != "" && spec.TLS.MaxVersion != "1.1" && spec.TLS.MaxVersion != "1.2" && spec.TLS.MaxVersion != "1.3" {
@@ -141,6 +161,9 @@ func validateTargetProfileSpec(spec *operatorv1alpha1.TargetProfileSpec) field.E
 		if spec.TLS.MinVersion != "" && spec.TLS.MinVersion != "1.1" && spec.TLS.MinVersion != "1.2" && spec.TLS.MinVersion != "1.3" {
 			allErrs = append(allErrs, field.Invalid(tlsPath.Child("minVersion"), spec.TLS.MinVersion, "TLS min version must be one of 1.1, 1.2, or 1.3"))
 		}
+		if spec.TLS.CABundleRef != nil && spec.TLS.InsecureSkipVerify {
+			allErrs = append(allErrs, field.Forbidden(tlsPath.Child("insecureSkipVerify"), "a CA bundle is set to verify the targets, insecureSkipVerify cannot be set with caBundleRef"))
+		}
 	}
 	if spec.TCPKeepAlive != nil && spec.TCPKeepAlive.Duration < 1*time.Second {
 		tcpKeepAlivePath := specPath.Child("tcpKeepAlive")
@@ -155,5 +178,118 @@ func validateTargetProfileSpec(spec *operatorv1alpha1.TargetProfileSpec) field.E
 			allErrs = append(allErrs, field.Invalid(grpcKeepAlivePath.Child("timeout"), spec.GRCPKeepAlive.Timeout.Duration, "gRPC keep-alive timeout must be at least 1 second"))
 		}
 	}
+
+	if strings.ContainsAny(spec.AuthScheme, " \t") {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("authScheme"), spec.AuthScheme, "auth scheme must be a single word, e.g. Bearer"))
+	}
+	if spec.BufferSize != nil && *spec.BufferSize < 1 {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("bufferSize"), *spec.BufferSize, "buffer size must be at least 1"))
+	}
+	if buffers := spec.GRPCBuffers; buffers != nil {
+		buffersPath := specPath.Child("grpcBuffers")
+		for _, size := range []struct {
+			name  string
+			value *int32
+			min   int32
+		}{
+			{"readBufferSize", buffers.ReadBufferSize, 0},
+			{"writeBufferSize", buffers.WriteBufferSize, 0},
+			{"connWindowSize", buffers.ConnWindowSize, minGRPCWindowSize},
+			{"windowSize", buffers.WindowSize, minGRPCWindowSize},
+			{"staticConnWindowSize", buffers.StaticConnWindowSize, minGRPCWindowSize},
+			{"staticStreamWindowSize", buffers.StaticStreamWindowSize, minGRPCWindowSize},
+		} {
+			if size.value != nil && *size.value < size.min {
+				allErrs = append(allErrs, field.Invalid(buffersPath.Child(size.name), *size.value, fmt.Sprintf("must be at least %d", size.min)))
+			}
+		}
+	}
+	if proto := spec.Proto; proto != nil {
+		protoPath := specPath.Child("proto")
+		for _, msg := range validation.IsDNS1123Subdomain(proto.ConfigMapName) {
+			allErrs = append(allErrs, field.Invalid(protoPath.Child("configMapName"), proto.ConfigMapName, msg))
+		}
+		if len(proto.Files) == 0 {
+			allErrs = append(allErrs, field.Required(protoPath.Child("files"), "at least one proto file is required"))
+		}
+		seen := make(map[string]struct{}, len(proto.Files))
+		for i, file := range proto.Files {
+			filePath := protoPath.Child("files").Index(i)
+			if _, ok := seen[file]; ok {
+				allErrs = append(allErrs, field.Duplicate(filePath, file))
+			}
+			seen[file] = struct{}{}
+			if msgs := validation.IsConfigMapKey(file); len(msgs) > 0 {
+				allErrs = append(allErrs, field.Invalid(filePath, file, strings.Join(msgs, ", ")))
+			}
+		}
+	}
+	allErrs = append(allErrs, validateGRPCMetadata(spec.Metadata, specPath.Child("metadata"))...)
+	allErrs = append(allErrs, validateEventTags(spec.Labels, specPath.Child("labels"))...)
+	if spec.TargetTags != nil {
+		allErrs = append(allErrs, validateTargetTags(spec.TargetTags, specPath.Child("targetTags"))...)
+	}
+	if spec.Probe != nil && spec.Probe.Interval.Duration != 0 && spec.Probe.Interval.Duration < time.Minute {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("probe", "interval"), spec.Probe.Interval.Duration, "probe interval must be at least 1 minute"))
+	}
+	return allErrs
+}
+
+// validateTargetTags validates the label and annotation keys added as event
+// tags: two keys cannot render the same tag.
+func validateTargetTags(config *operatorv1alpha1.TargetTagsConfig, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	names := make(map[string]struct{})
+	for _, selected := range []struct {
+		name string
+		tags []operatorv1alpha1.TargetTag
+	}{
+		{"labels", config.Labels},
+		{"annotations", config.Annotations},
+	} {
+		for i, tag := range selected.tags {
+			tagPath := fldPath.Child(selected.name).Index(i)
+			for _, msg := range validation.IsQualifiedName(tag.Key) {
+				allErrs = append(allErrs, field.Invalid(tagPath.Child("key"), tag.Key, msg))
+			}
+			name := gnmic.TargetTagName(tag)
+			if _, ok := names[name]; ok {
+				allErrs = append(allErrs, field.Duplicate(tagPath.Child("name"), name))
+			}
+			names[name] = struct{}{}
+		}
+	}
 	return allErrs
 }
+
+// minGRPCWindowSize is the smallest flow control window gRPC accepts, smaller
+// windows are ignored
+const minGRPCWindowSize = 65535
+
+// validateGRPCMetadata validates metadata sent to the targets: gRPC only
+// accepts lowercase keys, reserves the grpc- prefix and expects binary values
+// for keys ending with -bin.
+func validateGRPCMetadata(metadata map[string]string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, key := range slices.Sorted(maps.Keys(metadata)) {
+		switch {
+		case key == "" || strings.IndexFunc(key, func(r rune) bool {
+			return (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' && r != '.'
+		}) >= 0:
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(key), key, "metadata keys must only contain lowercase letters, digits, '-', '_' and '.'"))
+		case strings.HasPrefix(key, "grpc-"):
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(key), key, "the grpc- prefix is reserved"))
+		case strings.HasSuffix(key, "-bin"):
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(key), key, "binary metadata is not supported"))
+		}
+	}
+	return allErrs
+}
+
+// validateEventTags validates labels added as event tags to the updates
+func validateEventTags(tags map[string]string, fldPath *field.Path) field.ErrorList {
+	if _, ok := tags[""]; ok {
+		return field.ErrorList{field.Invalid(fldPath, "", "event tag names cannot be empty")}
+	}
+	return nil
+}
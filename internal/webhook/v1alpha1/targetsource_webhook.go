@@ -18,7 +18,13 @@ package v1alpha1
 
 import (
 	"context"
+	"slices"
+	"strings"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -81,7 +87,13 @@ var _ admission.Validator[*operatorv1alpha1.TargetSource] = &TargetSourceCustomV
 func (v *TargetSourceCustomValidator) ValidateCreate(_ context.Context, targetsource *operatorv1alpha1.TargetSource) (admission.Warnings, error) {
 	targetsourcelog.Info("Validation for TargetSource upon creation", "name", targetsource.GetName())
 
-	// TODO(user): fill in your validation logic upon object creation.
+	if errs := validateTargetSourceSpec(&targetsource.Spec); len(errs) > 0 {
+		return nil, apierrors.NewInvalid(
+			operatorv1alpha1.GroupVersion.WithKind("TargetSource").GroupKind(),
+			targetsource.GetName(),
+			errs,
+		)
+	}
 
 	return unwatchedNamespaceWarning("TargetSource", targetsource.GetNamespace()), nil
 }
@@ -90,7 +102,13 @@ func (v *TargetSourceCustomValidator) ValidateCreate(_ context.Context, targetso
 func (v *TargetSourceCustomValidator) ValidateUpdate(_ context.Context, _ *operatorv1alpha1.TargetSource, targetsource *operatorv1alpha1.TargetSource) (admission.Warnings, error) {
 	targetsourcelog.Info("Validation for TargetSource upon update", "name", targetsource.GetName())
 
-	// TODO(user): fill in your validation logic upon object update.
+	if errs := validateTargetSourceSpec(&targetsource.Spec); len(errs) > 0 {
+		return nil, apierrors.NewInvalid(
+			operatorv1alpha1.GroupVersion.WithKind("TargetSource").GroupKind(),
+			targetsource.GetName(),
+			errs,
+		)
+	}
 
 	return nil, nil
 }
@@ -103,3 +121,107 @@ func (v *TargetSourceCustomValidator) ValidateDelete(ctx context.Context, target
 
 	return nil, nil
 }
+
+func validateTargetSourceSpec(spec *operatorv1alpha1.TargetSourceSpec) field.ErrorList {
+	specPath := field.NewPath("spec")
+	allErrs := validateAllowedCredentials(spec, specPath)
+
+	switch {
+	case spec.Provider != nil && len(spec.Providers) > 0:
+		return append(allErrs, field.Invalid(specPath, "provider, providers", "only one of provider or providers can be set"))
+	case spec.Provider == nil && len(spec.Providers) == 0:
+		return append(allErrs, field.Required(specPath.Child("provider"), "one of provider or providers must be set"))
+	}
+
+	if spec.Provider != nil {
+		if spec.Merge != nil {
+			allErrs = append(allErrs, field.Forbidden(specPath.Child("merge"), "merge requires several providers"))
+		}
+		if spec.Provider.Static != nil {
+			staticPath := specPath.Child("provider", "static", "targets")
+			for i, t := range spec.Provider.Static.Targets {
+				if t.Address == "" {
+					allErrs = append(allErrs, field.Required(staticPath.Index(i).Child("address"), "address is required unless the target is merged with other providers"))
+				}
+			}
+		}
+		return allErrs
+	}
+
+	if spec.Merge != nil {
+		mergePath := specPath.Child("merge")
+		switch {
+		case spec.Merge.Key == operatorv1alpha1.MergeKeyLabel && spec.Merge.KeyLabel == "":
+			allErrs = append(allErrs, field.Required(mergePath.Child("keyLabel"), "keyLabel is required when key is Label"))
+		case spec.Merge.Key != operatorv1alpha1.MergeKeyLabel && spec.Merge.KeyLabel != "":
+			allErrs = append(allErrs, field.Forbidden(mergePath.Child("keyLabel"), "keyLabel is only used when key is Label"))
+		}
+	}
+
+	pushProviders := 0
+	for i, p := range spec.Providers {
+		if p.HTTP == nil {
+			continue
+		}
+		if p.HTTP.Push != nil && p.HTTP.Push.Enabled {
+			pushProviders++
+			if pushProviders > 1 {
+				allErrs = append(allErrs, field.Forbidden(specPath.Child("providers").Index(i).Child("http", "push"), "only one provider can accept pushed targets"))
+			}
+		}
+		// the targets of a push-only provider are unknown after a restart,
+		// the merged snapshot would delete them
+		if p.HTTP.URL == "" {
+			allErrs = append(allErrs, field.Required(specPath.Child("providers").Index(i).Child("http", "url"), "http providers must poll an url to be merged"))
+		}
+	}
+	return allErrs
+}
+
+// validateAllowedCredentials validates the allowedCredentials of a
+// TargetSource and the credentialsRef of its static targets against them.
+// Secrets allowed by the selector only are checked when the targets are
+// discovered.
+func validateAllowedCredentials(spec *operatorv1alpha1.TargetSourceSpec, specPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	allowed := spec.AllowedCredentials
+	allowedPath := specPath.Child("allowedCredentials")
+	if allowed != nil {
+		if len(allowed.Names) == 0 && allowed.NamePrefix == "" && allowed.Selector == nil {
+			allErrs = append(allErrs, field.Required(allowedPath, "one of names, namePrefix or selector must be set"))
+		}
+		allErrs = append(allErrs, validateResourceNames(allowed.Names, allowedPath.Child("names"))...)
+		if allowed.Selector != nil {
+			allErrs = append(allErrs, metav1validation.ValidateLabelSelector(allowed.Selector, metav1validation.LabelSelectorValidationOptions{}, allowedPath.Child("selector"))...)
+		}
+	}
+
+	checkStatic := func(static *operatorv1alpha1.StaticConfig, staticPath *field.Path) {
+		if static == nil {
+			return
+		}
+		for i, t := range static.Targets {
+			if t.CredentialsRef == "" {
+				continue
+			}
+			refPath := staticPath.Index(i).Child("credentialsRef")
+			for _, msg := range validation.IsDNS1123Subdomain(t.CredentialsRef) {
+				allErrs = append(allErrs, field.Invalid(refPath, t.CredentialsRef, msg))
+			}
+			switch {
+			case allowed == nil:
+				allErrs = append(allErrs, field.Forbidden(refPath, "credentialsRef requires allowedCredentials"))
+			case allowed.Selector == nil && !slices.Contains(allowed.Names, t.CredentialsRef) &&
+				(allowed.NamePrefix == "" || !strings.HasPrefix(t.CredentialsRef, allowed.NamePrefix)):
+				allErrs = append(allErrs, field.Forbidden(refPath, "credentialsRef is not allowed by allowedCredentials"))
+			}
+		}
+	}
+	if spec.Provider != nil {
+		checkStatic(spec.Provider.Static, specPath.Child("provider", "static", "targets"))
+	}
+	for i, p := range spec.Providers {
+		checkStatic(p.Static, specPath.Child("providers").Index(i).Child("static", "targets"))
+	}
+	return allErrs
+}
@@ -2,13 +2,23 @@ package v1alpha1
 
 import (
 	"context"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	operatorv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/yang"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestValidateClusterSpec(t *testing.T) {
@@ -30,6 +40,32 @@ func TestValidateClusterSpec(t *testing.T) {
 	}
 }
 
+func TestValidateClusterSpec_GNMIServerRequiresIssuer(t *testing.T) {
+	for name, tc := range map[string]struct {
+		tls     *operatorv1alpha1.ClusterTLSConfig
+		wantErr bool
+	}{
+		"no tls":       {wantErr: true},
+		"bundle only":  {tls: &operatorv1alpha1.ClusterTLSConfig{BundleRef: "ca"}, wantErr: true},
+		"issuer":       {tls: &operatorv1alpha1.ClusterTLSConfig{IssuerRef: "issuer"}},
+		"issuer (csi)": {tls: &operatorv1alpha1.ClusterTLSConfig{IssuerRef: "issuer", UseCSIDriver: true}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			spec := &operatorv1alpha1.ClusterSpec{
+				Image: "gnmic:latest",
+				API:   &operatorv1alpha1.APIConfig{RestPort: 7890, GNMIPort: 9339, TLS: tc.tls},
+			}
+			err := validateClusterSpec(spec)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "spec.api.tls.issuerRef") {
+				t.Fatalf("err = %v, want spec.api.tls.issuerRef", err)
+			}
+		})
+	}
+}
+
 func TestClusterValidator(t *testing.T) {
 	v := ClusterCustomValidator{}
 	cluster := &operatorv1alpha1.Cluster{
@@ -59,6 +95,52 @@ func TestValidatePipelineSpec(t *testing.T) {
 	}
 }
 
+func TestValidatePipelineSpec_Schedule(t *testing.T) {
+	spec := func(schedule *operatorv1alpha1.PipelineSchedule) *operatorv1alpha1.PipelineSpec {
+		return &operatorv1alpha1.PipelineSpec{
+			ClusterRef:       "cluster-a",
+			TargetRefs:       []string{"t1"},
+			SubscriptionRefs: []string{"sub1"},
+			Outputs:          operatorv1alpha1.OutputSelector{OutputRefs: []string{"out1"}},
+			Schedule:         schedule,
+		}
+	}
+	hour := metav1.Duration{Duration: time.Hour}
+	start := metav1.Now()
+
+	valid := spec(&operatorv1alpha1.PipelineSchedule{
+		TimeZone:      "Europe/Paris",
+		ActiveWindows: []operatorv1alpha1.ScheduleWindow{{Cron: "0 8 * * 1-5", Duration: hour}},
+		MaintenanceWindows: []operatorv1alpha1.MaintenanceWindow{{
+			ScheduleWindow: operatorv1alpha1.ScheduleWindow{Start: &start, Duration: hour},
+			TargetSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"site": "par1"}},
+		}},
+	})
+	if err := validatePipelineSpec(valid); err != nil {
+		t.Fatalf("valid schedule: %v", err)
+	}
+
+	for name, schedule := range map[string]*operatorv1alpha1.PipelineSchedule{
+		"bad time zone": {TimeZone: "Mars/Olympus"},
+		"bad cron":      {ActiveWindows: []operatorv1alpha1.ScheduleWindow{{Cron: "0 25 * * *", Duration: hour}}},
+		"no start":      {ActiveWindows: []operatorv1alpha1.ScheduleWindow{{Duration: hour}}},
+		"cron and start": {MaintenanceWindows: []operatorv1alpha1.MaintenanceWindow{{
+			ScheduleWindow: operatorv1alpha1.ScheduleWindow{Cron: "0 0 * * *", Start: &start, Duration: hour},
+		}}},
+		"no duration": {ActiveWindows: []operatorv1alpha1.ScheduleWindow{{Cron: "0 0 * * *"}}},
+	} {
+		if err := validatePipelineSpec(spec(schedule)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	ttl := spec(nil)
+	ttl.TTLAfterEnable = &metav1.Duration{}
+	if err := validatePipelineSpec(ttl); err == nil {
+		t.Error("zero ttlAfterEnable: expected error")
+	}
+}
+
 func TestValidateTargetSpec(t *testing.T) {
 	if err := validateTargetSpec("t1", &operatorv1alpha1.TargetSpec{}); err == nil {
 		t.Fatal("expected errors")
@@ -72,31 +154,138 @@ func TestValidateTargetSpec(t *testing.T) {
 }
 
 func TestValidateSubscriptionSpec(t *testing.T) {
-	if err := validateSubscriptionSpec("s1", &operatorv1alpha1.SubscriptionSpec{}); err == nil {
+	if _, err := validateSubscriptionSpec("s1", &operatorv1alpha1.SubscriptionSpec{}, nil); err == nil {
 		t.Fatal("expected paths required")
 	}
-	if err := validateSubscriptionSpec("s1", &operatorv1alpha1.SubscriptionSpec{
+	if _, err := validateSubscriptionSpec("s1", &operatorv1alpha1.SubscriptionSpec{
 		Paths: []string{"/"},
 		Mode:  "STREAM/SAMPLE",
-	}); err != nil {
+	}, nil); err != nil {
 		t.Fatal(err)
 	}
-	if err := validateSubscriptionSpec("s1", &operatorv1alpha1.SubscriptionSpec{
+	if _, err := validateSubscriptionSpec("s1", &operatorv1alpha1.SubscriptionSpec{
 		Paths:               []string{"/"},
 		StreamSubscriptions: []string{"child"},
 		Mode:                "POLL",
-	}); err == nil {
+	}, nil); err == nil {
 		t.Fatal("expected stream mode error")
 	}
-	if err := validateSubscriptionSpec("s1", &operatorv1alpha1.SubscriptionSpec{
+	if _, err := validateSubscriptionSpec("s1", &operatorv1alpha1.SubscriptionSpec{
 		Paths:             []string{"/"},
 		SampleInterval:    metav1.Duration{Duration: -1},
 		HeartbeatInterval: metav1.Duration{Duration: -1},
-	}); err == nil {
+	}, nil); err == nil {
 		t.Fatal("expected negative interval errors")
 	}
 }
 
+func TestValidateSubscriptionSpec_Modes(t *testing.T) {
+	second := metav1.Duration{Duration: time.Second}
+	start := metav1.NewTime(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	end := metav1.NewTime(start.Add(time.Hour))
+	tests := []struct {
+		name    string
+		spec    operatorv1alpha1.SubscriptionSpec
+		wantErr string
+	}{
+		{name: "default mode", spec: operatorv1alpha1.SubscriptionSpec{SampleInterval: second}},
+		{name: "once", spec: operatorv1alpha1.SubscriptionSpec{Mode: "ONCE", UpdatesOnly: true}},
+		{name: "poll", spec: operatorv1alpha1.SubscriptionSpec{Mode: "POLL"}},
+		{name: "stream", spec: operatorv1alpha1.SubscriptionSpec{Mode: "STREAM", SampleInterval: second}},
+		{name: "sample", spec: operatorv1alpha1.SubscriptionSpec{Mode: "STREAM/SAMPLE", SampleInterval: second, HeartbeatInterval: second, SuppressRedundant: true}},
+		{name: "on change", spec: operatorv1alpha1.SubscriptionSpec{Mode: "STREAM/ON_CHANGE", HeartbeatInterval: second}},
+		{name: "target defined", spec: operatorv1alpha1.SubscriptionSpec{Mode: "STREAM/TARGET_DEFINED", SampleInterval: second}},
+		{name: "unknown mode", spec: operatorv1alpha1.SubscriptionSpec{Mode: "STREAM/SAMPLED"}, wantErr: `spec.mode: Unsupported value: "STREAM/SAMPLED"`},
+		{name: "lower case mode", spec: operatorv1alpha1.SubscriptionSpec{Mode: "once"}, wantErr: "spec.mode: Unsupported value"},
+		{name: "once sampled", spec: operatorv1alpha1.SubscriptionSpec{Mode: "ONCE", SampleInterval: second}, wantErr: "spec.sampleInterval: Forbidden: sampleInterval only applies to STREAM subscriptions, not ONCE"},
+		{name: "poll heartbeat", spec: operatorv1alpha1.SubscriptionSpec{Mode: "POLL", HeartbeatInterval: second}, wantErr: "spec.heartbeatInterval: Forbidden"},
+		{name: "poll suppress redundant", spec: operatorv1alpha1.SubscriptionSpec{Mode: "POLL", SuppressRedundant: true}, wantErr: "spec.suppressRedundant: Forbidden"},
+		{name: "on change sampled", spec: operatorv1alpha1.SubscriptionSpec{Mode: "STREAM/ON_CHANGE", SampleInterval: second}, wantErr: "spec.sampleInterval: Forbidden: sampleInterval does not apply to ON_CHANGE"},
+		{name: "on change suppress redundant", spec: operatorv1alpha1.SubscriptionSpec{Mode: "STREAM/ON_CHANGE", SuppressRedundant: true}, wantErr: "spec.suppressRedundant: Forbidden"},
+		{name: "poll interval", spec: operatorv1alpha1.SubscriptionSpec{Mode: "POLL", PollInterval: metav1.Duration{Duration: 5 * time.Minute}, PollJitter: &metav1.Duration{Duration: time.Minute}}},
+		{name: "poll schedule", spec: operatorv1alpha1.SubscriptionSpec{Mode: "POLL", PollSchedule: "*/15 * * * *"}},
+		{name: "stream poll interval", spec: operatorv1alpha1.SubscriptionSpec{Mode: "STREAM", PollInterval: metav1.Duration{Duration: time.Minute}}, wantErr: "spec.pollInterval: Forbidden: pollInterval only applies to POLL subscriptions"},
+		{name: "poll interval and schedule", spec: operatorv1alpha1.SubscriptionSpec{Mode: "POLL", PollInterval: metav1.Duration{Duration: time.Minute}, PollSchedule: "* * * * *"}, wantErr: "spec.pollSchedule: Forbidden: pollSchedule and pollInterval are mutually exclusive"},
+		{name: "short poll interval", spec: operatorv1alpha1.SubscriptionSpec{Mode: "POLL", PollInterval: second}, wantErr: "pollInterval must be at least 10s"},
+		{name: "invalid poll schedule", spec: operatorv1alpha1.SubscriptionSpec{Mode: "POLL", PollSchedule: "hourly"}, wantErr: "spec.pollSchedule: Invalid value"},
+		{name: "poll jitter without schedule", spec: operatorv1alpha1.SubscriptionSpec{Mode: "POLL", PollJitter: &second}, wantErr: "pollJitter requires pollInterval or pollSchedule"},
+		{name: "poll jitter too long", spec: operatorv1alpha1.SubscriptionSpec{Mode: "POLL", PollInterval: metav1.Duration{Duration: time.Minute}, PollJitter: &metav1.Duration{Duration: time.Minute}}, wantErr: "pollJitter must be shorter than pollInterval"},
+		{
+			name: "streams",
+			spec: operatorv1alpha1.SubscriptionSpec{
+				Mode:           "STREAM/SAMPLE",
+				SampleInterval: second,
+				Streams: []operatorv1alpha1.SubscriptionStream{
+					{Paths: []string{"/state/oper-status"}, Mode: "ON_CHANGE", HeartbeatInterval: second},
+					{Paths: []string{"/state/counters"}, SuppressRedundant: true},
+					{Paths: []string{"/config"}, Mode: "TARGET_DEFINED", SampleInterval: second},
+				},
+			},
+		},
+		{
+			name:    "streams of a once subscription",
+			spec:    operatorv1alpha1.SubscriptionSpec{Mode: "ONCE", Streams: []operatorv1alpha1.SubscriptionStream{{Paths: []string{"/"}}}},
+			wantErr: "mode must be STREAM when streamSubscriptions or streams are set",
+		},
+		{
+			name:    "stream without paths",
+			spec:    operatorv1alpha1.SubscriptionSpec{Streams: []operatorv1alpha1.SubscriptionStream{{Mode: "SAMPLE"}}},
+			wantErr: "spec.streams[0].paths: Required value",
+		},
+		{
+			name:    "stream with an unknown mode",
+			spec:    operatorv1alpha1.SubscriptionSpec{Streams: []operatorv1alpha1.SubscriptionStream{{Paths: []string{"/"}, Mode: "POLL"}}},
+			wantErr: `spec.streams[0].mode: Unsupported value: "POLL"`,
+		},
+		{
+			name: "on change stream sampled",
+			spec: operatorv1alpha1.SubscriptionSpec{
+				Mode:    "STREAM/ON_CHANGE",
+				Streams: []operatorv1alpha1.SubscriptionStream{{Paths: []string{"/"}, SampleInterval: second}},
+			},
+			wantErr: "spec.streams[0].sampleInterval: Forbidden",
+		},
+		{
+			name:    "negative stream interval",
+			spec:    operatorv1alpha1.SubscriptionSpec{Streams: []operatorv1alpha1.SubscriptionStream{{Paths: []string{"/"}, HeartbeatInterval: metav1.Duration{Duration: -1}}}},
+			wantErr: "spec.streams[0].heartbeatInterval: Invalid value",
+		},
+		{name: "set target", spec: operatorv1alpha1.SubscriptionSpec{SetTarget: true, Models: []string{"openconfig-interfaces"}}},
+		{name: "set target and target", spec: operatorv1alpha1.SubscriptionSpec{SetTarget: true, Target: "leaf1"}, wantErr: "setTarget and target are mutually exclusive"},
+		{name: "empty model", spec: operatorv1alpha1.SubscriptionSpec{Models: []string{""}}, wantErr: "spec.models[0]: Required value"},
+		{name: "duplicate model", spec: operatorv1alpha1.SubscriptionSpec{Models: []string{"a", "a"}}, wantErr: "spec.models[1]: Duplicate value"},
+		{name: "history range", spec: operatorv1alpha1.SubscriptionSpec{History: &operatorv1alpha1.SubscriptionHistoryConfig{Start: start, End: end}}},
+		{name: "history snapshot", spec: operatorv1alpha1.SubscriptionSpec{Mode: "ONCE", History: &operatorv1alpha1.SubscriptionHistoryConfig{Snapshot: start}}},
+		{
+			name:    "history end before start",
+			spec:    operatorv1alpha1.SubscriptionSpec{History: &operatorv1alpha1.SubscriptionHistoryConfig{Start: end, End: start}},
+			wantErr: "history start must be before end",
+		},
+		{
+			name:    "history snapshot and range",
+			spec:    operatorv1alpha1.SubscriptionSpec{History: &operatorv1alpha1.SubscriptionHistoryConfig{Snapshot: start, Start: start}},
+			wantErr: "snapshot and start/end are mutually exclusive",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.spec.Streams) == 0 {
+				tt.spec.Paths = []string{"/interfaces"}
+			}
+			_, err := validateSubscriptionSpec("s1", &tt.spec, nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidateTargetSpec_AddressErrors(t *testing.T) {
 	if err := validateTargetSpec("t", &operatorv1alpha1.TargetSpec{
 		Address: "not-a-hostport",
@@ -127,6 +316,343 @@ func TestValidateTargetProfileSpec(t *testing.T) {
 	if len(errs) != 0 {
 		t.Fatalf("valid profile: %v", errs)
 	}
+	errs = validateTargetProfileSpec(&operatorv1alpha1.TargetProfileSpec{
+		Encoding:   "JSON",
+		Timeout:    metav1.Duration{Duration: time.Second},
+		RetryTimer: metav1.Duration{Duration: time.Second},
+		TLS: &operatorv1alpha1.TargetTLSConfig{
+			CABundleRef:        &operatorv1alpha1.CABundleRef{Bundle: "devices"},
+			InsecureSkipVerify: true,
+		},
+	})
+	if len(errs) != 1 || errs[0].Field != "spec.tls.insecureSkipVerify" {
+		t.Fatalf("expected insecureSkipVerify error with caBundleRef, got %v", errs)
+	}
+	errs = validateTargetProfileSpec(&operatorv1alpha1.TargetProfileSpec{
+		Encoding:       "JSON",
+		Timeout:        metav1.Duration{Duration: time.Second},
+		RetryTimer:     metav1.Duration{Duration: time.Second},
+		CredentialsRef: "device-credentials",
+		Credentials: &operatorv1alpha1.CredentialsSource{
+			File: &operatorv1alpha1.FileCredentials{Path: "../../etc"},
+		},
+	})
+	if len(errs) != 2 || errs[0].Field != "spec.credentials" || errs[1].Field != "spec.credentials.file.path" {
+		t.Fatalf("expected credentials and file path errors, got %v", errs)
+	}
+}
+
+func TestValidateTargetProfileSpec_TargetOptions(t *testing.T) {
+	valid := operatorv1alpha1.TargetProfileSpec{
+		Encoding:   "PROTO",
+		Timeout:    metav1.Duration{Duration: time.Second},
+		RetryTimer: metav1.Duration{Duration: time.Second},
+		AuthScheme: "Bearer",
+		BufferSize: ptr.To(int32(100)),
+		GRPCBuffers: &operatorv1alpha1.GRPCBufferConfig{
+			ReadBufferSize: ptr.To(int32(0)),
+			WindowSize:     ptr.To(int32(1 << 20)),
+		},
+		Proto:    &operatorv1alpha1.TargetProtoConfig{ConfigMapName: "eos-proto", Files: []string{"eos_ext.proto"}},
+		Metadata: map[string]string{"x-site": "par"},
+		Labels:   map[string]string{"vendor": "arista"},
+		Probe:    &operatorv1alpha1.TargetProbeConfig{Interval: metav1.Duration{Duration: 5 * time.Minute}},
+	}
+	if errs := validateTargetProfileSpec(&valid); len(errs) != 0 {
+		t.Fatalf("valid profile: %v", errs)
+	}
+
+	invalid := valid
+	invalid.AuthScheme = "Bearer token"
+	invalid.BufferSize = ptr.To(int32(0))
+	invalid.GRPCBuffers = &operatorv1alpha1.GRPCBufferConfig{ConnWindowSize: ptr.To(int32(1024))}
+	invalid.Proto = &operatorv1alpha1.TargetProtoConfig{ConfigMapName: "eos-proto", Files: []string{"../ext.proto", "a.proto", "a.proto"}}
+	invalid.Metadata = map[string]string{"X-Site": "par", "grpc-timeout": "1s", "trace-bin": "x"}
+	invalid.Labels = map[string]string{"": "arista"}
+	invalid.Probe = &operatorv1alpha1.TargetProbeConfig{Interval: metav1.Duration{Duration: 30 * time.Second}}
+	var got []string
+	for _, err := range validateTargetProfileSpec(&invalid) {
+		got = append(got, err.Field)
+	}
+	want := []string{
+		"spec.authScheme", "spec.bufferSize", "spec.grpcBuffers.connWindowSize",
+		"spec.proto.files[0]", "spec.proto.files[2]",
+		"spec.metadata[X-Site]", "spec.metadata[grpc-timeout]", "spec.metadata[trace-bin]",
+		"spec.labels", "spec.probe.interval",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("errors on %v, want %v", got, want)
+	}
+}
+
+func TestValidateTargetProfileSpec_TargetTags(t *testing.T) {
+	spec := &operatorv1alpha1.TargetProfileSpec{
+		Encoding:   "JSON",
+		Timeout:    metav1.Duration{Duration: time.Second},
+		RetryTimer: metav1.Duration{Duration: time.Second},
+		TargetTags: &operatorv1alpha1.TargetTagsConfig{
+			Labels:      []operatorv1alpha1.TargetTag{{Key: "site"}, {Key: "netbox.dev/role"}},
+			Annotations: []operatorv1alpha1.TargetTag{{Key: "netbox.dev/site", Name: "netbox_site"}},
+		},
+	}
+	if errs := validateTargetProfileSpec(spec); len(errs) != 0 {
+		t.Fatalf("valid profile: %v", errs)
+	}
+
+	// netbox.dev/site renders the site tag of the site label
+	spec.TargetTags.Annotations = []operatorv1alpha1.TargetTag{{Key: "netbox.dev/site"}, {Key: "bad key"}}
+	var got []string
+	for _, err := range validateTargetProfileSpec(spec) {
+		got = append(got, err.Field)
+	}
+	want := []string{"spec.targetTags.annotations[0].name", "spec.targetTags.annotations[1].key"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("errors on %v, want %v", got, want)
+	}
+}
+
+func TestValidateTargetSpec_TargetOptions(t *testing.T) {
+	spec := &operatorv1alpha1.TargetSpec{
+		Address:       "10.0.0.1:57400",
+		Profile:       "default",
+		Subscriptions: []string{"interfaces", "bgp"},
+		Outputs:       []string{"kafka"},
+		BufferSize:    ptr.To(int32(10)),
+		TLSServerName: "Router1.example.com",
+		Metadata:      map[string]string{"x-site": "par"},
+		Labels:        map[string]string{"rack": "r1"},
+	}
+	if err := validateTargetSpec("t1", spec); err != nil {
+		t.Fatalf("valid target: %v", err)
+	}
+	spec.TLSServerName = "10.0.0.1"
+	if err := validateTargetSpec("t1", spec); err != nil {
+		t.Fatalf("IP server name: %v", err)
+	}
+
+	spec.Subscriptions = []string{"interfaces", "interfaces"}
+	spec.Outputs = []string{"Kafka"}
+	spec.BufferSize = ptr.To(int32(0))
+	spec.TLSServerName = "router_1"
+	spec.Metadata = map[string]string{"grpc-x": "y"}
+	err := validateTargetSpec("t1", spec)
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, fld := range []string{"spec.subscriptions[1]", "spec.outputs[0]", "spec.bufferSize", "spec.tlsServerName", "spec.metadata[grpc-x]"} {
+		if !strings.Contains(err.Error(), fld) {
+			t.Errorf("no error on %s in %v", fld, err)
+		}
+	}
+}
+
+func TestValidateTargetSourceSpec(t *testing.T) {
+	static := &operatorv1alpha1.ProviderSpec{Static: &operatorv1alpha1.StaticConfig{
+		Targets: []operatorv1alpha1.StaticTarget{{Name: "router1", Labels: map[string]string{"site": "ams"}}},
+	}}
+	polled := func(push bool) operatorv1alpha1.ProviderSpec {
+		return operatorv1alpha1.ProviderSpec{HTTP: &operatorv1alpha1.HTTPConfig{
+			URL:  "http://netbox/api/dcim/devices/",
+			Push: &operatorv1alpha1.PushSpec{Enabled: push},
+		}}
+	}
+
+	tests := []struct {
+		name    string
+		spec    operatorv1alpha1.TargetSourceSpec
+		wantErr string
+	}{
+		{
+			name:    "no provider",
+			spec:    operatorv1alpha1.TargetSourceSpec{},
+			wantErr: "spec.provider",
+		},
+		{
+			name: "provider and providers",
+			spec: operatorv1alpha1.TargetSourceSpec{
+				Provider:  static,
+				Providers: []operatorv1alpha1.NamedProviderSpec{{Name: "netbox", ProviderSpec: polled(false)}},
+			},
+			wantErr: "only one of provider or providers",
+		},
+		{
+			name:    "static target without address",
+			spec:    operatorv1alpha1.TargetSourceSpec{Provider: static},
+			wantErr: "spec.provider.static.targets[0].address",
+		},
+		{
+			name: "merge with a single provider",
+			spec: operatorv1alpha1.TargetSourceSpec{
+				Provider: &operatorv1alpha1.ProviderSpec{HTTP: polled(false).HTTP},
+				Merge:    &operatorv1alpha1.MergeSpec{Policy: operatorv1alpha1.MergePolicyEnrich},
+			},
+			wantErr: "spec.merge",
+		},
+		{
+			name: "several push providers",
+			spec: operatorv1alpha1.TargetSourceSpec{Providers: []operatorv1alpha1.NamedProviderSpec{
+				{Name: "netbox", ProviderSpec: polled(true)},
+				{Name: "cmdb", ProviderSpec: polled(true)},
+			}},
+			wantErr: "spec.providers[1].http.push",
+		},
+		{
+			name: "push-only provider",
+			spec: operatorv1alpha1.TargetSourceSpec{Providers: []operatorv1alpha1.NamedProviderSpec{
+				{Name: "netbox", ProviderSpec: operatorv1alpha1.ProviderSpec{HTTP: &operatorv1alpha1.HTTPConfig{
+					Push: &operatorv1alpha1.PushSpec{Enabled: true},
+				}}},
+				{Name: "overrides", ProviderSpec: *static},
+			}},
+			wantErr: "spec.providers[0].http.url",
+		},
+		{
+			name: "label key without label",
+			spec: operatorv1alpha1.TargetSourceSpec{
+				Providers: []operatorv1alpha1.NamedProviderSpec{
+					{Name: "netbox", ProviderSpec: polled(false)},
+					{Name: "overrides", ProviderSpec: *static},
+				},
+				Merge: &operatorv1alpha1.MergeSpec{Policy: operatorv1alpha1.MergePolicyEnrich, Key: operatorv1alpha1.MergeKeyLabel},
+			},
+			wantErr: "spec.merge.keyLabel",
+		},
+		{
+			name: "key label with name key",
+			spec: operatorv1alpha1.TargetSourceSpec{
+				Providers: []operatorv1alpha1.NamedProviderSpec{
+					{Name: "netbox", ProviderSpec: polled(false)},
+					{Name: "overrides", ProviderSpec: *static},
+				},
+				Merge: &operatorv1alpha1.MergeSpec{Key: operatorv1alpha1.MergeKeyName, KeyLabel: "serial"},
+			},
+			wantErr: "spec.merge.keyLabel",
+		},
+		{
+			name: "merged by label",
+			spec: operatorv1alpha1.TargetSourceSpec{
+				Providers: []operatorv1alpha1.NamedProviderSpec{
+					{Name: "netbox", ProviderSpec: polled(false)},
+					{Name: "overrides", ProviderSpec: *static},
+				},
+				Merge: &operatorv1alpha1.MergeSpec{Policy: operatorv1alpha1.MergePolicyEnrich, Key: operatorv1alpha1.MergeKeyLabel, KeyLabel: "serial"},
+			},
+		},
+		{
+			name: "merged providers",
+			spec: operatorv1alpha1.TargetSourceSpec{
+				Providers: []operatorv1alpha1.NamedProviderSpec{
+					{Name: "netbox", ProviderSpec: polled(true)},
+					{Name: "overrides", ProviderSpec: *static},
+				},
+				Merge: &operatorv1alpha1.MergeSpec{Policy: operatorv1alpha1.MergePolicyEnrich},
+			},
+		},
+		{
+			name: "empty allowed credentials",
+			spec: operatorv1alpha1.TargetSourceSpec{
+				Provider:           &operatorv1alpha1.ProviderSpec{HTTP: polled(false).HTTP},
+				AllowedCredentials: &operatorv1alpha1.AllowedCredentialsSpec{},
+			},
+			wantErr: "spec.allowedCredentials",
+		},
+		{
+			name: "invalid allowed credentials selector",
+			spec: operatorv1alpha1.TargetSourceSpec{
+				Provider: &operatorv1alpha1.ProviderSpec{HTTP: polled(false).HTTP},
+				AllowedCredentials: &operatorv1alpha1.AllowedCredentialsSpec{Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"not a key": "true"},
+				}},
+			},
+			wantErr: "spec.allowedCredentials.selector",
+		},
+		{
+			name:    "static credentials without allowed credentials",
+			spec:    operatorv1alpha1.TargetSourceSpec{Provider: staticCredentials("leaf1-creds")},
+			wantErr: "spec.provider.static.targets[0].credentialsRef",
+		},
+		{
+			name: "static credentials not allowed",
+			spec: operatorv1alpha1.TargetSourceSpec{
+				Provider:           staticCredentials("operator-token"),
+				AllowedCredentials: &operatorv1alpha1.AllowedCredentialsSpec{NamePrefix: "leaf"},
+			},
+			wantErr: "spec.provider.static.targets[0].credentialsRef",
+		},
+		{
+			name: "static credentials allowed",
+			spec: operatorv1alpha1.TargetSourceSpec{
+				Provider:           staticCredentials("leaf1-creds"),
+				AllowedCredentials: &operatorv1alpha1.AllowedCredentialsSpec{NamePrefix: "leaf"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateTargetSourceSpec(&tt.spec)
+			if tt.wantErr == "" {
+				if len(errs) != 0 {
+					t.Fatalf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			if !strings.Contains(errs.ToAggregate().Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, errs)
+			}
+		})
+	}
+}
+
+func staticCredentials(ref string) *operatorv1alpha1.ProviderSpec {
+	return &operatorv1alpha1.ProviderSpec{Static: &operatorv1alpha1.StaticConfig{
+		Targets: []operatorv1alpha1.StaticTarget{{Name: "leaf1", Address: "10.0.0.1", CredentialsRef: ref}},
+	}}
+}
+
+func TestTargetValidator_CredentialsRef(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = operatorv1alpha1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&operatorv1alpha1.TargetSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "netbox", Namespace: "default"},
+		Spec: operatorv1alpha1.TargetSourceSpec{
+			AllowedCredentials: &operatorv1alpha1.AllowedCredentialsSpec{Names: []string{"leaf-creds"}},
+		},
+	}).Build()
+	v := TargetCustomValidator{Client: c}
+	target := func(source, ref string) *operatorv1alpha1.Target {
+		target := &operatorv1alpha1.Target{
+			ObjectMeta: metav1.ObjectMeta{Name: "leaf1", Namespace: "default"},
+			Spec:       operatorv1alpha1.TargetSpec{Address: "10.0.0.1:57400", Profile: "default", CredentialsRef: ref},
+		}
+		if source != "" {
+			target.Labels = map[string]string{"operator.gnmic.dev/targetsource": source}
+		}
+		return target
+	}
+
+	if _, err := v.ValidateCreate(context.Background(), target("netbox", "leaf-creds")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.ValidateCreate(context.Background(), target("netbox", "operator-token")); err == nil || !strings.Contains(err.Error(), "spec.credentialsRef") {
+		t.Fatalf("expected the credentialsRef to be rejected, got %v", err)
+	}
+	// Targets created by hand and of deleted TargetSources are not checked
+	if _, err := v.ValidateCreate(context.Background(), target("", "operator-token")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.ValidateCreate(context.Background(), target("deleted", "operator-token")); err != nil {
+		t.Fatal(err)
+	}
+	// an unchanged credentialsRef is kept when the allowed credentials change
+	old := target("netbox", "operator-token")
+	if _, err := v.ValidateUpdate(context.Background(), old, target("netbox", "operator-token")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.ValidateCreate(context.Background(), target("", "Not_A_Secret")); err == nil {
+		t.Fatal("expected an invalid Secret name to be rejected")
+	}
 }
 
 func TestValidateTunnelTargetPolicySpec(t *testing.T) {
@@ -237,6 +763,98 @@ func TestSubscriptionWebhookMethods(t *testing.T) {
 	}
 }
 
+// The fields that do not apply to the mode of a Subscription admitted before
+// they were checked are only warned about until they, or the mode, change.
+func TestSubscriptionValidator_RatchetsModeFields(t *testing.T) {
+	second := metav1.Duration{Duration: time.Second}
+	v := SubscriptionCustomValidator{}
+	old := &operatorv1alpha1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1"},
+		Spec: operatorv1alpha1.SubscriptionSpec{
+			Paths:          []string{"/interfaces"},
+			Mode:           "POLL",
+			SampleInterval: second,
+		},
+	}
+	if _, err := v.ValidateCreate(context.Background(), old); err == nil {
+		t.Fatal("expected sampleInterval to be rejected on create")
+	}
+
+	for _, tt := range []struct {
+		name    string
+		update  func(spec *operatorv1alpha1.SubscriptionSpec)
+		wantErr string
+	}{
+		{name: "other field changed", update: func(spec *operatorv1alpha1.SubscriptionSpec) {
+			spec.Paths = append(spec.Paths, "/system")
+		}},
+		{name: "offending field changed", update: func(spec *operatorv1alpha1.SubscriptionSpec) {
+			spec.SampleInterval = metav1.Duration{Duration: 2 * time.Second}
+		}, wantErr: "spec.sampleInterval: Forbidden"},
+		{name: "mode changed", update: func(spec *operatorv1alpha1.SubscriptionSpec) {
+			spec.Mode = "ONCE"
+		}, wantErr: "spec.sampleInterval: Forbidden"},
+		{name: "new offending field", update: func(spec *operatorv1alpha1.SubscriptionSpec) {
+			spec.SuppressRedundant = true
+		}, wantErr: "spec.suppressRedundant: Forbidden"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := old.DeepCopy()
+			tt.update(&sub.Spec)
+			warnings, err := v.ValidateUpdate(context.Background(), old, sub)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(warnings) != 1 || !strings.Contains(warnings[0], "spec.sampleInterval") {
+				t.Fatalf("warnings = %v, want the unchanged sampleInterval", warnings)
+			}
+		})
+	}
+
+	// the fields of a stream ratchet on their own
+	oldStreams := &operatorv1alpha1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1"},
+		Spec: operatorv1alpha1.SubscriptionSpec{
+			Streams: []operatorv1alpha1.SubscriptionStream{{Paths: []string{"/"}, Mode: "ON_CHANGE", SampleInterval: second}},
+		},
+	}
+	sub := oldStreams.DeepCopy()
+	sub.Spec.Streams = append(sub.Spec.Streams, operatorv1alpha1.SubscriptionStream{Paths: []string{"/system"}, Mode: "ON_CHANGE", SuppressRedundant: true})
+	warnings, err := v.ValidateUpdate(context.Background(), oldStreams, sub)
+	if err == nil || !strings.Contains(err.Error(), "spec.streams[1].suppressRedundant") || strings.Contains(err.Error(), "spec.streams[0]") {
+		t.Fatalf("error = %v, want only the new stream rejected", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "spec.streams[0].sampleInterval") {
+		t.Fatalf("warnings = %v", warnings)
+	}
+}
+
+func TestFieldValue(t *testing.T) {
+	obj, ok := unstructuredSpec(&operatorv1alpha1.SubscriptionSpec{
+		Streams: []operatorv1alpha1.SubscriptionStream{{Paths: []string{"/"}, SampleInterval: metav1.Duration{Duration: time.Second}}},
+	})
+	if !ok {
+		t.Fatal("spec not converted")
+	}
+	if v, ok := fieldValue(obj, "spec.streams[0].sampleInterval"); !ok || v != "1s" {
+		t.Fatalf("spec.streams[0].sampleInterval = %v, %t", v, ok)
+	}
+	if v, ok := fieldValue(obj, "spec.streams[0].paths[0]"); !ok || v != "/" {
+		t.Fatalf("spec.streams[0].paths[0] = %v, %t", v, ok)
+	}
+	for _, path := range []string{"spec.streams[1]", "spec.streams[0].paths[0].x", "spec.mode[x]"} {
+		if v, ok := fieldValue(obj, path); ok {
+			t.Fatalf("%s = %v, want unset", path, v)
+		}
+	}
+}
+
 func TestTargetProfileWebhookMethods(t *testing.T) {
 	v := TargetProfileCustomValidator{}
 	d := TargetProfileCustomDefaulter{}
@@ -321,7 +939,7 @@ func TestOtherWebhookDefaulters(t *testing.T) {
 
 func TestOtherWebhookValidators_NoOp(t *testing.T) {
 	ctx := context.Background()
-	output := &operatorv1alpha1.Output{ObjectMeta: metav1.ObjectMeta{Name: "o1"}}
+	output := &operatorv1alpha1.Output{ObjectMeta: metav1.ObjectMeta{Name: "o1"}, Spec: operatorv1alpha1.OutputSpec{Type: "file"}}
 	v := OutputCustomValidator{}
 	if _, err := v.ValidateCreate(ctx, output); err != nil {
 		t.Fatal(err)
@@ -333,7 +951,7 @@ func TestOtherWebhookValidators_NoOp(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	input := &operatorv1alpha1.Input{ObjectMeta: metav1.ObjectMeta{Name: "i1"}}
+	input := &operatorv1alpha1.Input{ObjectMeta: metav1.ObjectMeta{Name: "i1"}, Spec: operatorv1alpha1.InputSpec{Type: "nats", Config: apiextensionsv1.JSON{Raw: []byte(`{"address":"nats:4222"}`)}}}
 	iv := InputCustomValidator{}
 	if _, err := iv.ValidateCreate(ctx, input); err != nil {
 		t.Fatal(err)
@@ -345,7 +963,7 @@ func TestOtherWebhookValidators_NoOp(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	proc := &operatorv1alpha1.Processor{ObjectMeta: metav1.ObjectMeta{Name: "p1"}}
+	proc := &operatorv1alpha1.Processor{ObjectMeta: metav1.ObjectMeta{Name: "p1"}, Spec: operatorv1alpha1.ProcessorSpec{Type: "event-delete"}}
 	pv := ProcessorCustomValidator{}
 	if _, err := pv.ValidateCreate(ctx, proc); err != nil {
 		t.Fatal(err)
@@ -357,7 +975,11 @@ func TestOtherWebhookValidators_NoOp(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	ts := &operatorv1alpha1.TargetSource{ObjectMeta: metav1.ObjectMeta{Name: "ts1"}}
+	ts := &operatorv1alpha1.TargetSource{ObjectMeta: metav1.ObjectMeta{Name: "ts1"}, Spec: operatorv1alpha1.TargetSourceSpec{
+		Provider: &operatorv1alpha1.ProviderSpec{Static: &operatorv1alpha1.StaticConfig{
+			Targets: []operatorv1alpha1.StaticTarget{{Name: "router1", Address: "10.0.0.1"}},
+		}},
+	}}
 	tsv := TargetSourceCustomValidator{}
 	if _, err := tsv.ValidateCreate(ctx, ts); err != nil {
 		t.Fatal(err)
@@ -369,3 +991,397 @@ func TestOtherWebhookValidators_NoOp(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestValidateOutputSpec_OTLP(t *testing.T) {
+	otlp := func(config string) *operatorv1alpha1.OutputSpec {
+		spec := &operatorv1alpha1.OutputSpec{Type: "otlp"}
+		if config != "" {
+			spec.Config.Raw = []byte(config)
+		}
+		return spec
+	}
+	for _, tc := range []struct {
+		name  string
+		spec  *operatorv1alpha1.OutputSpec
+		valid bool
+	}{
+		{"grpc endpoint", otlp(`{"endpoint":"otel:4317"}`), true},
+		{"http endpoint", otlp(`{"protocol":"http","endpoint":"https://otel:4318/v1/metrics"}`), true},
+		{"resource attributes", otlp(`{"endpoint":"otel:4317","resource-attributes":{"service.name":"gnmic"},"tls":{}}`), true},
+		{"missing endpoint", otlp(``), false},
+		{"unknown protocol", otlp(`{"protocol":"thrift","endpoint":"otel:4317"}`), false},
+		{"grpc endpoint with a scheme", otlp(`{"endpoint":"http://otel:4317"}`), false},
+		{"http endpoint without a scheme", otlp(`{"protocol":"http","endpoint":"otel:4318"}`), false},
+		{"bad port", otlp(`{"endpoint":"otel:99999"}`), false},
+		{"non-string attribute", otlp(`{"endpoint":"otel:4317","resource-attributes":{"replicas":3}}`), false},
+		{"tls not an object", otlp(`{"endpoint":"otel:4317","tls":true}`), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := validateOutputSpec("o1", tc.spec, nil)
+			if tc.valid && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+
+	// an endpoint resolved from a service needs none in the config, and
+	// overrides one that is set
+	spec := otlp(``)
+	spec.ServiceRef = &operatorv1alpha1.ServiceReference{Name: "otel-collector"}
+	if _, err := validateOutputSpec("o1", spec, nil); err != nil {
+		t.Fatalf("serviceRef without endpoint: %v", err)
+	}
+	spec.Config.Raw = []byte(`{"endpoint":"otel:4317"}`)
+	if warnings, err := validateOutputSpec("o1", spec, nil); err != nil || len(warnings) != 1 {
+		t.Fatalf("serviceRef with endpoint: warnings %v, err %v", warnings, err)
+	}
+	// a selector may match several services
+	spec.ServiceSelector = &operatorv1alpha1.ServiceSelector{MatchLabels: map[string]string{"app": "otel"}}
+	if _, err := validateOutputSpec("o1", spec, nil); err == nil || !strings.Contains(err.Error(), "spec.serviceSelector: Forbidden") {
+		t.Fatalf("serviceSelector error = %v", err)
+	}
+}
+
+func TestValidateTypedConfigs(t *testing.T) {
+	output := func(typ, config string) *operatorv1alpha1.OutputSpec {
+		return &operatorv1alpha1.OutputSpec{Type: typ, Config: apiextensionsv1.JSON{Raw: []byte(config)}}
+	}
+	for _, tc := range []struct {
+		name     string
+		spec     *operatorv1alpha1.OutputSpec
+		wantErrs []string
+		warnings int
+	}{
+		{"valid kafka", output("kafka", `{"address":"kafka:9092","topic":"telemetry","timeout":"5s","sasl":{"mechanism":"PLAIN"}}`), nil, 0},
+		{"unknown type", output("graphite", `{}`), []string{"spec.type"}, 0},
+		{"unknown key", output("kafka", `{"topik":"telemetry"}`), nil, 1},
+		{"wrong value type", output("kafka", `{"max-retry":"3"}`), []string{"spec.config.max-retry"}, 0},
+		{"bad duration", output("kafka", `{"timeout":"5 seconds"}`), []string{"spec.config.timeout"}, 0},
+		{"unsupported enum value", output("kafka", `{"sasl":{"mechanism":"KERBEROS"}}`), []string{"spec.config.sasl.mechanism"}, 0},
+		{"unknown nested key", output("kafka", `{"tls":{"ca":"/ca.crt"}}`), nil, 1},
+		{"missing required key", output("tcp", `{}`), []string{"spec.config.address"}, 0},
+		{"config not an object", output("file", `[]`), []string{"spec.config"}, 0},
+		{"deprecated key", output("influxdb", `{"url":"http://influxdb:8086","enable-tls":true}`), nil, 1},
+		{"operator managed key", output("file", `{"event-processors":["p1"]}`), nil, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			warnings, err := validateOutputSpec("o1", tc.spec, nil)
+			if len(warnings) != tc.warnings {
+				t.Errorf("warnings = %v, want %d", warnings, tc.warnings)
+			}
+			if len(tc.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			statusErr, ok := err.(*apierrors.StatusError)
+			if !ok {
+				t.Fatalf("error = %v, want an Invalid status error", err)
+			}
+			var fields []string
+			for _, cause := range statusErr.ErrStatus.Details.Causes {
+				fields = append(fields, cause.Field)
+			}
+			if !reflect.DeepEqual(fields, tc.wantErrs) {
+				t.Fatalf("error fields = %v, want %v", fields, tc.wantErrs)
+			}
+		})
+	}
+
+	if _, err := validateInputSpec("i1", &operatorv1alpha1.InputSpec{
+		Type:   "jetstream",
+		Config: apiextensionsv1.JSON{Raw: []byte(`{"address":"nats:4222","stream":"telemetry","deliver-policy":"new"}`)},
+	}, nil); err != nil {
+		t.Fatalf("valid input: %v", err)
+	}
+	if warnings, err := validateInputSpec("i1", &operatorv1alpha1.InputSpec{
+		Type:   "kafka",
+		Config: apiextensionsv1.JSON{Raw: []byte(`{"address":"kafka:9092","group":"gnmic"}`)},
+	}, nil); err != nil || len(warnings) != 1 {
+		t.Fatalf("unknown input key: warnings %v, err %v", warnings, err)
+	}
+
+	if _, err := validateProcessorSpec("p1", &operatorv1alpha1.ProcessorSpec{
+		Type:   "event-combine",
+		Config: apiextensionsv1.JSON{Raw: []byte(`{"processors":[{"name":"p1","condition":".tags.a == \"b\""}]}`)},
+	}, nil); err != nil {
+		t.Fatalf("valid processor: %v", err)
+	}
+	if _, err := validateProcessorSpec("p1", &operatorv1alpha1.ProcessorSpec{
+		Type:   "event-combine",
+		Config: apiextensionsv1.JSON{Raw: []byte(`{"processors":[{"condition":"true"}]}`)},
+	}, nil); err == nil {
+		t.Fatal("expected error for a combined processor without a name")
+	}
+	// plugin and script configs are not checked
+	if _, err := validateProcessorSpec("p1", &operatorv1alpha1.ProcessorSpec{
+		Type:   "event-starlark",
+		Config: apiextensionsv1.JSON{Raw: []byte(`{"script":"/s.star","threshold":10}`)},
+	}, nil); err != nil {
+		t.Fatalf("starlark processor: %v", err)
+	}
+	if _, err := validateProcessorSpec("p1", &operatorv1alpha1.ProcessorSpec{Type: "event-unknown"}, nil); err == nil {
+		t.Fatal("expected error for an unknown processor type")
+	}
+}
+
+func TestOutputValidator_RatchetsConfig(t *testing.T) {
+	v := OutputCustomValidator{}
+	// a jetstream output admitted before stream was required
+	old := &operatorv1alpha1.Output{
+		ObjectMeta: metav1.ObjectMeta{Name: "o1"},
+		Spec: operatorv1alpha1.OutputSpec{
+			Type:   "jetstream",
+			Config: apiextensionsv1.JSON{Raw: []byte(`{"address":"nats:4222"}`)},
+		},
+	}
+	if _, err := v.ValidateCreate(context.Background(), old); err == nil {
+		t.Fatal("expected the missing stream to be rejected on create")
+	}
+
+	for _, tt := range []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{name: "other key changed", config: `{"address":"nats:4223"}`},
+		{name: "new invalid key", config: `{"address":4222}`, wantErr: "spec.config.address: Invalid value"},
+		{name: "offending key set invalid", config: `{"address":"nats:4222","stream":1}`, wantErr: "spec.config.stream: Invalid value"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			output := old.DeepCopy()
+			output.Spec.Config.Raw = []byte(tt.config)
+			warnings, err := v.ValidateUpdate(context.Background(), old, output)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(warnings) != 1 || !strings.Contains(warnings[0], "spec.config.stream") {
+				t.Fatalf("warnings = %v, want the unchanged missing stream", warnings)
+			}
+		})
+	}
+
+	// a changed type is validated as new
+	output := old.DeepCopy()
+	output.Spec.Type = "nats"
+	output.Spec.Config.Raw = []byte(`{"address":1}`)
+	if _, err := v.ValidateUpdate(context.Background(), old, output); err == nil {
+		t.Fatal("expected the invalid address of a changed type to be rejected")
+	}
+}
+
+func TestValidateConfigSecretRef(t *testing.T) {
+	kafka := func(config string, items ...operatorv1alpha1.SecretKeyToPath) *operatorv1alpha1.OutputSpec {
+		return &operatorv1alpha1.OutputSpec{
+			Type:      "kafka",
+			Config:    apiextensionsv1.JSON{Raw: []byte(config)},
+			SecretRef: &operatorv1alpha1.ConfigSecretRef{Name: "kafka-creds", Items: items},
+		}
+	}
+	item := func(key, path string) operatorv1alpha1.SecretKeyToPath {
+		return operatorv1alpha1.SecretKeyToPath{Key: key, Path: path}
+	}
+
+	if warnings, err := validateOutputSpec("o1", kafka(`{"sasl":{"user":"gnmic"}}`, item("password", "sasl.password")), nil); err != nil || len(warnings) != 0 {
+		t.Fatalf("valid secretRef: warnings %v, err %v", warnings, err)
+	}
+	// the Secret overrides a plaintext value
+	if warnings, err := validateOutputSpec("o1", kafka(`{"sasl":{"password":"plain"}}`, item("password", "sasl.password")), nil); err != nil || len(warnings) != 1 {
+		t.Fatalf("overridden value: warnings %v, err %v", warnings, err)
+	}
+	// a path to an unknown key is passed to gNMIc
+	if warnings, err := validateOutputSpec("o1", kafka(`{}`, item("password", "sasl.passwd")), nil); err != nil || len(warnings) != 1 {
+		t.Fatalf("unknown path: warnings %v, err %v", warnings, err)
+	}
+	for name, spec := range map[string]*operatorv1alpha1.OutputSpec{
+		"no items":          kafka(`{}`),
+		"not a string key":  kafka(`{}`, item("retries", "max-retry")),
+		"path through leaf": kafka(`{}`, item("password", "topic.password")),
+		"empty segment":     kafka(`{}`, item("password", "sasl..password")),
+		"duplicate path":    kafka(`{}`, item("a", "sasl.password"), item("b", "sasl.password")),
+		"missing key":       kafka(`{}`, item("", "sasl.password")),
+	} {
+		if _, err := validateOutputSpec("o1", spec, nil); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	input := &operatorv1alpha1.InputSpec{
+		Type:      "nats",
+		Config:    apiextensionsv1.JSON{Raw: []byte(`{"address":"nats:4222"}`)},
+		SecretRef: &operatorv1alpha1.ConfigSecretRef{Name: "nats-creds", Items: []operatorv1alpha1.SecretKeyToPath{item("password", "password")}},
+	}
+	if _, err := validateInputSpec("i1", input, nil); err != nil {
+		t.Fatalf("valid input secretRef: %v", err)
+	}
+	input.SecretRef.Items[0].Path = "password.value"
+	if _, err := validateInputSpec("i1", input, nil); err == nil {
+		t.Fatal("expected error for a path through a string key")
+	}
+}
+
+func TestValidateGetJobSpec(t *testing.T) {
+	valid := func() *operatorv1alpha1.GetJobSpec {
+		return &operatorv1alpha1.GetJobSpec{
+			GNMIJobSpec: operatorv1alpha1.GNMIJobSpec{
+				ClusterRef:      "c1",
+				TargetSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"role": "leaf"}}},
+				Schedule:        "0 * * * *",
+				TimeZone:        "Europe/Paris",
+			},
+			Prefix: "/system",
+			Paths:  []string{"information", "/interfaces/interface[name=ethernet-1/1]/state"},
+		}
+	}
+	if err := validateGetJobSpec("j1", valid()); err != nil {
+		t.Fatalf("valid spec: %v", err)
+	}
+	for name, mutate := range map[string]func(*operatorv1alpha1.GetJobSpec){
+		"no cluster":       func(s *operatorv1alpha1.GetJobSpec) { s.ClusterRef = "" },
+		"no targets":       func(s *operatorv1alpha1.GetJobSpec) { s.TargetSelectors = nil },
+		"invalid ref":      func(s *operatorv1alpha1.GetJobSpec) { s.TargetRefs = []string{"Leaf_1"} },
+		"no paths":         func(s *operatorv1alpha1.GetJobSpec) { s.Paths = nil },
+		"invalid path":     func(s *operatorv1alpha1.GetJobSpec) { s.Paths = []string{"/interfaces/interface[name=e1"} },
+		"invalid schedule": func(s *operatorv1alpha1.GetJobSpec) { s.Schedule = "every hour" },
+		"unknown zone":     func(s *operatorv1alpha1.GetJobSpec) { s.TimeZone = "Nowhere/Special" },
+		"zone only":        func(s *operatorv1alpha1.GetJobSpec) { s.Schedule = "" },
+		"zero timeout":     func(s *operatorv1alpha1.GetJobSpec) { s.Timeout = &metav1.Duration{} },
+		"invalid output":   func(s *operatorv1alpha1.GetJobSpec) { s.Outputs = []string{"Influx_DB"} },
+		"invalid selector": func(s *operatorv1alpha1.GetJobSpec) {
+			s.TargetSelectors[0].MatchExpressions = []metav1.LabelSelectorRequirement{{Key: "role", Operator: "Near"}}
+		},
+	} {
+		spec := valid()
+		mutate(spec)
+		if err := validateGetJobSpec("j1", spec); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestValidateSetJobSpec(t *testing.T) {
+	update := func(path, value string) operatorv1alpha1.SetJobUpdate {
+		return operatorv1alpha1.SetJobUpdate{Path: path, Value: apiextensionsv1.JSON{Raw: []byte(value)}}
+	}
+	spec := &operatorv1alpha1.SetJobSpec{
+		GNMIJobSpec: operatorv1alpha1.GNMIJobSpec{ClusterRef: "c1", TargetRefs: []string{"leaf1"}},
+		Deletes:     []string{"/system/banner"},
+		Updates:     []operatorv1alpha1.SetJobUpdate{update("/system/name/host-name", `"leaf1"`)},
+	}
+	if err := validateSetJobSpec("j1", spec); err != nil {
+		t.Fatalf("valid spec: %v", err)
+	}
+	for name, spec := range map[string]*operatorv1alpha1.SetJobSpec{
+		"no operations":  {GNMIJobSpec: spec.GNMIJobSpec},
+		"invalid delete": {GNMIJobSpec: spec.GNMIJobSpec, Deletes: []string{"/a[b=c"}},
+		"invalid update": {GNMIJobSpec: spec.GNMIJobSpec, Updates: []operatorv1alpha1.SetJobUpdate{update("/a[b=c", `1`)}},
+		"no value":       {GNMIJobSpec: spec.GNMIJobSpec, Replaces: []operatorv1alpha1.SetJobUpdate{update("/system/banner", "")}},
+	} {
+		if err := validateSetJobSpec("j1", spec); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestSubscriptionValidator_ModelSet(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = operatorv1alpha1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&operatorv1alpha1.ModelSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "default", Generation: 1},
+			Spec:       operatorv1alpha1.ModelSetSpec{Version: "1.0.0", ConfigMaps: []string{"models"}},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "default"},
+			Data: map[string]string{"test-interfaces.yang": `module test-interfaces {
+  namespace "urn:test:interfaces";
+  prefix if;
+  container interfaces {
+    list interface {
+      key "name";
+      leaf name { type string; }
+      container state {
+        config false;
+        leaf oper-status { type string; }
+        leaf in-octets { type counter64; }
+      }
+    }
+  }
+  typedef counter64 { type uint64; }
+}`},
+		},
+	).Build()
+	v := SubscriptionCustomValidator{Client: c, Models: yang.NewLoader()}
+	sub := &operatorv1alpha1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "default"},
+		Spec: operatorv1alpha1.SubscriptionSpec{
+			Prefix:   "/interfaces",
+			Paths:    []string{"interface[name=*]/state/oper-status", "interface/state"},
+			Mode:     "STREAM/ON_CHANGE",
+			ModelSet: &operatorv1alpha1.ModelSetReference{Name: "models", Version: "1.0.0"},
+		},
+	}
+	warnings, err := v.ValidateCreate(context.Background(), sub)
+	if err != nil {
+		t.Fatalf("valid paths: %v", err)
+	}
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "spec.paths[1]: /interfaces/interface/state has 1 counter leaves") {
+		t.Fatalf("warnings = %q", warnings)
+	}
+
+	sub.Spec.Paths = []string{"interface[id=1]/state", "interfaces"}
+	_, err = v.ValidateUpdate(context.Background(), sub, sub)
+	if !apierrors.IsInvalid(err) ||
+		!strings.Contains(err.Error(), `spec.paths[0]`) || !strings.Contains(err.Error(), `"id" is not a key of list /interfaces/interface`) ||
+		!strings.Contains(err.Error(), `spec.paths[1]`) || !strings.Contains(err.Error(), `did you mean "interface"?`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the paths of streams are checked in their own stream mode
+	sub.Spec.Paths = []string{"interface/state/oper-status"}
+	sub.Spec.Streams = []operatorv1alpha1.SubscriptionStream{
+		{Paths: []string{"interface/state/in-octets"}, Mode: "SAMPLE"},
+		{Paths: []string{"interface/state", "interface/status"}},
+	}
+	_, err = v.ValidateCreate(context.Background(), sub)
+	if !apierrors.IsInvalid(err) || !strings.Contains(err.Error(), `spec.streams[1].paths[1]`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sub.Spec.Streams[1].Paths = sub.Spec.Streams[1].Paths[:1]
+	warnings, err = v.ValidateCreate(context.Background(), sub)
+	if err != nil || len(warnings) != 1 || !strings.HasPrefix(warnings[0], "spec.streams[1].paths[0]: /interfaces/interface/state has 1 counter leaves") {
+		t.Fatalf("warnings = %q, error = %v", warnings, err)
+	}
+
+	// a ModelSet that cannot be loaded leaves the paths unchecked
+	sub.Spec.ModelSet.Version = "2.0.0"
+	warnings, err = v.ValidateCreate(context.Background(), sub)
+	if err != nil || len(warnings) != 1 || !strings.Contains(warnings[0], "the paths were not checked: ModelSet models has version 1.0.0, not 2.0.0") {
+		t.Fatalf("warnings = %q, error = %v", warnings, err)
+	}
+}
+
+func TestValidateModelSetSpec(t *testing.T) {
+	valid := &operatorv1alpha1.ModelSetSpec{Version: "24.10", ConfigMaps: []string{"srl-models"}, Modules: []string{"srl_nokia-interfaces"}}
+	if err := validateModelSetSpec("models", valid); err != nil {
+		t.Fatalf("valid spec: %v", err)
+	}
+	invalid := &operatorv1alpha1.ModelSetSpec{ConfigMaps: []string{"Models", "Models"}, Modules: []string{"a", "a"}}
+	err := validateModelSetSpec("models", invalid)
+	for _, want := range []string{"spec.version", "spec.configMaps[0]", "spec.configMaps[1]: Duplicate", "spec.modules[1]: Duplicate"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("error %v does not contain %q", err, want)
+		}
+	}
+}
@@ -40,6 +40,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	operatorv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+	"github.com/gnmic/operator/internal/yang"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -121,7 +122,7 @@ var _ = BeforeSuite(func() {
 	err = SetupPipelineWebhookWithManager(mgr)
 	Expect(err).NotTo(HaveOccurred())
 
-	err = SetupSubscriptionWebhookWithManager(mgr)
+	err = SetupSubscriptionWebhookWithManager(mgr, yang.NewLoader())
 	Expect(err).NotTo(HaveOccurred())
 
 	err = SetupTargetWebhookWithManager(mgr)
@@ -0,0 +1,122 @@
+package yang
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+)
+
+// fileSuffix is the suffix of the ConfigMap keys holding YANG modules
+const fileSuffix = ".yang"
+
+// Loader loads the schemas of ModelSets from their ConfigMaps. It keeps the
+// last schema of each ModelSet, and parses its modules again only once the
+// ModelSet or one of its ConfigMaps changed. It is safe for concurrent use:
+// a version of a ModelSet is parsed once however many callers load it, and
+// the ModelSets are parsed concurrently.
+type Loader struct {
+	// mu guards schemas only, it is not held while parsing
+	mu      sync.Mutex
+	schemas map[types.NamespacedName]*loadedSchema
+	// parses runs the parse of each ModelSet version once, keyed by
+	// namespace/name@version
+	parses singleflight.Group
+}
+
+// loadedSchema is the result of loading a ModelSet, a parse error included:
+// it is not retried until the modules change.
+type loadedSchema struct {
+	version string
+	schema  *Schema
+	err     error
+}
+
+// NewLoader returns a Loader with no schema loaded.
+func NewLoader() *Loader {
+	return &Loader{schemas: make(map[types.NamespacedName]*loadedSchema)}
+}
+
+// LoadReference gets the ModelSet a reference names in a namespace, checks
+// its version, and loads its schema.
+func (l *Loader) LoadReference(ctx context.Context, c client.Reader, namespace string, ref *gnmicv1alpha1.ModelSetReference) (*Schema, error) {
+	var modelSet gnmicv1alpha1.ModelSet
+	if err := c.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, &modelSet); err != nil {
+		return nil, fmt.Errorf("failed to get ModelSet %s: %w", ref.Name, err)
+	}
+	if ref.Version != "" && ref.Version != modelSet.Spec.Version {
+		return nil, fmt.Errorf("ModelSet %s has version %s, not %s", ref.Name, modelSet.Spec.Version, ref.Version)
+	}
+	return l.Load(ctx, c, &modelSet)
+}
+
+// Load reads the YANG modules of a ModelSet from its ConfigMaps and returns
+// their schema.
+func (l *Loader) Load(ctx context.Context, c client.Reader, modelSet *gnmicv1alpha1.ModelSet) (*Schema, error) {
+	configMaps := make([]corev1.ConfigMap, len(modelSet.Spec.ConfigMaps))
+	// the schema is parsed again when the ModelSet or a ConfigMap changes
+	version := strconv.FormatInt(modelSet.Generation, 10)
+	for i, name := range modelSet.Spec.ConfigMaps {
+		if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: modelSet.Namespace}, &configMaps[i]); err != nil {
+			return nil, fmt.Errorf("failed to get ConfigMap %s: %w", name, err)
+		}
+		version += "/" + configMaps[i].ResourceVersion
+	}
+
+	key := types.NamespacedName{Name: modelSet.Name, Namespace: modelSet.Namespace}
+	l.mu.Lock()
+	loaded, ok := l.schemas[key]
+	l.mu.Unlock()
+	if ok && loaded.version == version {
+		return loaded.schema, loaded.err
+	}
+
+	v, _, _ := l.parses.Do(key.String()+"@"+version, func() (any, error) {
+		loaded := parseModelSet(modelSet, configMaps, version)
+		l.mu.Lock()
+		l.schemas[key] = loaded
+		l.mu.Unlock()
+		return loaded, nil
+	})
+	loaded = v.(*loadedSchema)
+	return loaded.schema, loaded.err
+}
+
+// parseModelSet parses the YANG modules of a ModelSet in its ConfigMaps.
+func parseModelSet(modelSet *gnmicv1alpha1.ModelSet, configMaps []corev1.ConfigMap, version string) *loadedSchema {
+	files := make(map[string]string)
+	for _, cm := range configMaps {
+		for name, data := range cm.Data {
+			if strings.HasSuffix(name, fileSuffix) {
+				files[name] = data
+			}
+		}
+		for name, data := range cm.BinaryData {
+			if strings.HasSuffix(name, fileSuffix) {
+				files[name] = string(data)
+			}
+		}
+	}
+	loaded := &loadedSchema{version: version}
+	if len(files) == 0 {
+		loaded.err = fmt.Errorf("no %s key in the ConfigMaps of ModelSet %s", fileSuffix, modelSet.Name)
+	} else {
+		loaded.schema, loaded.err = Parse(files, modelSet.Spec.Modules)
+	}
+	return loaded
+}
+
+// Forget drops the schema of a deleted ModelSet.
+func (l *Loader) Forget(key types.NamespacedName) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.schemas, key)
+}
@@ -0,0 +1,123 @@
+package yang
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gnmicv1alpha1 "github.com/gnmic/operator/api/v1alpha1"
+)
+
+func TestLoader(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = gnmicv1alpha1.AddToScheme(scheme)
+
+	modelSet := &gnmicv1alpha1.ModelSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "default", Generation: 1},
+		Spec: gnmicv1alpha1.ModelSetSpec{
+			Version:    "1.0.0",
+			ConfigMaps: []string{"types", "interfaces"},
+		},
+	}
+	types := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "types", Namespace: "default"},
+		Data:       map[string]string{"test-types.yang": testTypesModule, "README": "not a module"},
+	}
+	interfaces := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "interfaces", Namespace: "default"},
+		BinaryData: map[string][]byte{"test-interfaces.yang": []byte(testInterfacesModule)},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(modelSet, types, interfaces).Build()
+	loader := NewLoader()
+
+	ref := &gnmicv1alpha1.ModelSetReference{Name: "models", Version: "1.0.0"}
+	schema, err := loader.LoadReference(ctx, c, "default", ref)
+	if err != nil {
+		t.Fatalf("LoadReference() error = %v", err)
+	}
+	if got := strings.Join(schema.Modules, ","); got != "test-interfaces,test-types" {
+		t.Errorf("Modules = %s", got)
+	}
+	again, err := loader.LoadReference(ctx, c, "default", &gnmicv1alpha1.ModelSetReference{Name: "models"})
+	if err != nil || again != schema {
+		t.Errorf("LoadReference() of an unchanged ModelSet parsed it again: %v", err)
+	}
+
+	if _, err := loader.LoadReference(ctx, c, "default", &gnmicv1alpha1.ModelSetReference{Name: "models", Version: "2.0.0"}); err == nil ||
+		!strings.Contains(err.Error(), "ModelSet models has version 1.0.0, not 2.0.0") {
+		t.Errorf("LoadReference() of another version error = %v", err)
+	}
+	if _, err := loader.LoadReference(ctx, c, "default", &gnmicv1alpha1.ModelSetReference{Name: "missing"}); err == nil {
+		t.Error("LoadReference() of a missing ModelSet succeeded")
+	}
+
+	// a changed ConfigMap is parsed again, and its error kept
+	types.Data = map[string]string{"test-types.yang": "module test-types {"}
+	if err := c.Update(ctx, types); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loader.LoadReference(ctx, c, "default", ref); err == nil {
+		t.Error("LoadReference() of a broken ConfigMap succeeded")
+	}
+}
+
+// Concurrent loads of a ModelSet parse it once, without holding back the
+// loads of the other ModelSets.
+func TestLoader_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = gnmicv1alpha1.AddToScheme(scheme)
+
+	objs := []client.Object{&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "modules", Namespace: "default"},
+		Data:       map[string]string{"test-types.yang": testTypesModule, "test-interfaces.yang": testInterfacesModule},
+	}}
+	modelSets := make([]*gnmicv1alpha1.ModelSet, 3)
+	for i := range modelSets {
+		modelSets[i] = &gnmicv1alpha1.ModelSet{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("models-%d", i), Namespace: "default", Generation: 1},
+			Spec:       gnmicv1alpha1.ModelSetSpec{ConfigMaps: []string{"modules"}},
+		}
+		objs = append(objs, modelSets[i])
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+	loader := NewLoader()
+
+	const loads = 8
+	schemas := make([][loads]*Schema, len(modelSets))
+	var wg sync.WaitGroup
+	for i, modelSet := range modelSets {
+		for j := range loads {
+			wg.Go(func() {
+				schema, err := loader.Load(ctx, c, modelSet)
+				if err != nil {
+					t.Errorf("Load(%s) error = %v", modelSet.Name, err)
+				}
+				schemas[i][j] = schema
+			})
+		}
+	}
+	wg.Wait()
+	for i := range modelSets {
+		for j := range loads {
+			if schemas[i][j] == nil || schemas[i][j] != schemas[i][0] {
+				t.Fatalf("ModelSet %d load %d returned another schema", i, j)
+			}
+		}
+		if i > 0 && schemas[i][0] == schemas[0][0] {
+			t.Fatalf("ModelSet %d shares the schema of ModelSet 0", i)
+		}
+	}
+}
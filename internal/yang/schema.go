@@ -0,0 +1,343 @@
+// Package yang checks gNMI paths against the data tree of YANG modules.
+package yang
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmic/pkg/api/path"
+	goyang "github.com/openconfig/goyang/pkg/yang"
+)
+
+const (
+	// maxParseErrors bounds the errors reported when the modules fail to parse
+	maxParseErrors = 5
+	// maxSuggestions bounds the close names suggested for a misspelled element
+	maxSuggestions = 3
+	// maxHintNodes bounds the data nodes walked to find the leaves a path
+	// subscribes to, for paths as wide as the root of the tree
+	maxHintNodes = 100000
+)
+
+// Schema is the data tree of a set of YANG modules.
+type Schema struct {
+	// Modules are the modules the data tree is built from, as name@revision
+	Modules []string
+	// roots are the top-level data nodes of the modules
+	roots []*goyang.Entry
+}
+
+// Parse parses YANG files, by file name, and builds the data tree of the
+// named modules, of all of them when modules is empty. The files must hold
+// the modules and submodules the named ones import and include.
+func Parse(files map[string]string, modules []string) (*Schema, error) {
+	ms := goyang.NewModules()
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		if err := ms.Parse(files[name], name); err != nil {
+			return nil, err
+		}
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		if len(errs) > maxParseErrors {
+			errs = append(errs[:maxParseErrors], fmt.Errorf("and %d more errors", len(errs)-maxParseErrors))
+		}
+		return nil, errors.Join(errs...)
+	}
+
+	if len(modules) == 0 {
+		// the modules are also keyed by name@revision
+		for name := range ms.Modules {
+			if !strings.Contains(name, "@") {
+				modules = append(modules, name)
+			}
+		}
+		slices.Sort(modules)
+	}
+	s := &Schema{}
+	for _, name := range modules {
+		module, ok := ms.Modules[name]
+		if !ok {
+			return nil, fmt.Errorf("module %q not found", name)
+		}
+		s.Modules = append(s.Modules, module.FullName())
+		entry := goyang.ToEntry(module)
+		for _, child := range slices.Sorted(maps.Keys(entry.Dir)) {
+			if isDataNode(entry.Dir[child]) {
+				s.roots = append(s.roots, entry.Dir[child])
+			}
+		}
+	}
+	return s, nil
+}
+
+// Check checks that a path, in the xpath form of gNMIc, addresses data
+// nodes of the schema, and that its keys are the keys of its lists. Paths of
+// the cli origin are not checked. The error names the first element that
+// does not match, along with the close names that do.
+//
+// With the ON_CHANGE or SAMPLE stream mode, Check also returns hints on
+// whether the mode suits the leaves under the path.
+func (s *Schema) Check(p, mode string) ([]string, error) {
+	gnmiPath, err := path.ParsePath(p)
+	if err != nil {
+		return nil, err
+	}
+	if gnmiPath.GetOrigin() == "cli" {
+		return nil, nil
+	}
+
+	// nodes are the data nodes the walked elements address, the root at first
+	var nodes []*goyang.Entry
+	walked := ""
+	for _, elem := range gnmiPath.GetElem() {
+		name := elem.GetName()
+		if name == "..." {
+			// any descendant, its nodes are checked when subscribing
+			break
+		}
+		candidates := s.roots
+		if walked != "" {
+			candidates = nil
+			for _, node := range nodes {
+				candidates = append(candidates, children(node)...)
+			}
+		}
+		var next []*goyang.Entry
+		if name == "*" {
+			next = candidates
+		} else {
+			prefix, local := splitPrefix(name)
+			for _, c := range candidates {
+				if c.Name == local && (prefix == "" || inModule(c, prefix)) {
+					next = append(next, c)
+				}
+			}
+			if len(next) == 0 {
+				return nil, notFoundError(walked, local, candidates)
+			}
+		}
+		walked += "/" + name
+		if err := checkKeys(walked, elem, next); err != nil {
+			return nil, err
+		}
+		nodes = next
+	}
+	if walked == "" {
+		walked, nodes = "/", s.roots
+	}
+	return modeHints(walked, mode, nodes), nil
+}
+
+// checkKeys checks that the keys of a path element are keys of the lists it
+// may address.
+func checkKeys(walked string, elem *gnmi.PathElem, nodes []*goyang.Entry) error {
+	if len(elem.GetKey()) == 0 {
+		return nil
+	}
+	var err error
+	for _, node := range nodes {
+		if err = checkListKeys(walked, elem.GetKey(), node); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func checkListKeys(walked string, keys map[string]string, node *goyang.Entry) error {
+	if !node.IsList() {
+		return fmt.Errorf("%s is not a list, it has no keys", walked)
+	}
+	listKeys := strings.Fields(node.Key)
+	for _, key := range slices.Sorted(maps.Keys(keys)) {
+		if !slices.Contains(listKeys, key) {
+			msg := fmt.Sprintf("%q is not a key of list %s, its keys are %s", key, walked, strings.Join(listKeys, ", "))
+			if similar := closestNames(key, listKeys); len(similar) > 0 {
+				msg += fmt.Sprintf(", did you mean %s?", quoteNames(similar))
+			}
+			return errors.New(msg)
+		}
+	}
+	return nil
+}
+
+func notFoundError(walked, name string, candidates []*goyang.Entry) error {
+	parent := walked
+	if parent == "" {
+		parent = "/"
+	}
+	msg := fmt.Sprintf("%q not found under %s", name, parent)
+	names := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		names = append(names, c.Name)
+	}
+	if similar := closestNames(name, names); len(similar) > 0 {
+		msg += fmt.Sprintf(", did you mean %s?", quoteNames(similar))
+	}
+	return errors.New(msg)
+}
+
+// modeHints returns hints on subscribing to the leaves under a path with an
+// ON_CHANGE or SAMPLE stream mode: counters change too often for ON_CHANGE,
+// and sampling configuration that seldom changes only repeats it.
+func modeHints(walked, mode string, nodes []*goyang.Entry) []string {
+	if mode != "ON_CHANGE" && mode != "SAMPLE" {
+		return nil
+	}
+	var counters, state, config int
+	var counter string
+	visited := 0
+	var walk func(e *goyang.Entry)
+	walk = func(e *goyang.Entry) {
+		if visited >= maxHintNodes {
+			return
+		}
+		visited++
+		if !e.IsDir() {
+			switch {
+			case isCounter(e):
+				if counters == 0 {
+					counter = schemaPath(e)
+				}
+				counters++
+				state++
+			case e.ReadOnly():
+				state++
+			default:
+				config++
+			}
+			return
+		}
+		for _, child := range children(e) {
+			walk(child)
+		}
+	}
+	for _, node := range nodes {
+		walk(node)
+	}
+
+	switch {
+	case mode == "ON_CHANGE" && counters > 0:
+		return []string{fmt.Sprintf("%s has %d counter leaves, e.g. %s, which change too often for ON_CHANGE; subscribe to them with SAMPLE", walked, counters, counter)}
+	case mode == "SAMPLE" && config > 0 && state == 0:
+		return []string{fmt.Sprintf("%s has configuration leaves only, which seldom change; ON_CHANGE sends them when they do instead of every sample", walked)}
+	}
+	return nil
+}
+
+// children returns the data nodes under a node, through its choices and
+// cases which do not appear in paths.
+func children(e *goyang.Entry) []*goyang.Entry {
+	var nodes []*goyang.Entry
+	for _, name := range slices.Sorted(maps.Keys(e.Dir)) {
+		child := e.Dir[name]
+		switch {
+		case child.IsChoice() || child.IsCase():
+			nodes = append(nodes, children(child)...)
+		case isDataNode(child):
+			nodes = append(nodes, child)
+		}
+	}
+	return nodes
+}
+
+// isDataNode excludes the RPCs and notifications of the modules.
+func isDataNode(e *goyang.Entry) bool {
+	return e.RPC == nil && e.Kind != goyang.NotificationEntry
+}
+
+// inModule reports whether a node is defined by the module of a path element
+// prefix, which is the name or the prefix of the module.
+func inModule(e *goyang.Entry, prefix string) bool {
+	if e.Prefix != nil && e.Prefix.Name == prefix {
+		return true
+	}
+	module, err := e.InstantiatingModule()
+	return err == nil && module == prefix
+}
+
+func splitPrefix(name string) (string, string) {
+	if i := strings.Index(name, ":"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// isCounter reports whether a leaf is typed as a counter, such as the
+// counter32 and counter64 of ietf-yang-types and openconfig-yang-types.
+func isCounter(e *goyang.Entry) bool {
+	return e.Type != nil && strings.Contains(strings.ToLower(e.Type.Name), "counter")
+}
+
+// schemaPath returns the path of a node without its choices and cases.
+func schemaPath(e *goyang.Entry) string {
+	var elems []string
+	for ; e != nil && e.Parent != nil; e = e.Parent {
+		if !e.IsChoice() && !e.IsCase() {
+			elems = append(elems, e.Name)
+		}
+	}
+	slices.Reverse(elems)
+	return "/" + strings.Join(elems, "/")
+}
+
+// closestNames returns the names within a few edits of name, closest first.
+func closestNames(name string, names []string) []string {
+	maxDistance := max(1, len(name)/3)
+	distances := make(map[string]int)
+	for _, n := range names {
+		if d := editDistance(name, n); d <= maxDistance {
+			distances[n] = d
+		}
+	}
+	similar := slices.SortedFunc(maps.Keys(distances), func(a, b string) int {
+		if distances[a] != distances[b] {
+			return distances[a] - distances[b]
+		}
+		return strings.Compare(a, b)
+	})
+	if len(similar) > maxSuggestions {
+		similar = similar[:maxSuggestions]
+	}
+	return similar
+}
+
+func quoteNames(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = fmt.Sprintf("%q", name)
+	}
+	return strings.Join(quoted, " or ")
+}
+
+// editDistance is the Levenshtein distance between two names.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// JoinPath returns a path under the prefix of a subscription.
+func JoinPath(prefix, p string) string {
+	if prefix == "" {
+		return p
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(p, "/")
+}
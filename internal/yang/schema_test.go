@@ -0,0 +1,208 @@
+package yang
+
+import (
+	"strings"
+	"testing"
+)
+
+const testTypesModule = `module test-types {
+  namespace "urn:test:types";
+  prefix tt;
+
+  typedef counter64 {
+    type uint64;
+  }
+}`
+
+const testInterfacesModule = `module test-interfaces {
+  namespace "urn:test:interfaces";
+  prefix if;
+
+  import test-types { prefix tt; }
+
+  container interfaces {
+    list interface {
+      key "name";
+      leaf name {
+        type leafref { path "../config/name"; }
+      }
+      container config {
+        leaf name { type string; }
+        leaf mtu { type uint16; }
+      }
+      container state {
+        config false;
+        leaf oper-status { type string; }
+        container counters {
+          leaf in-octets { type tt:counter64; }
+          leaf out-octets { type tt:counter64; }
+        }
+      }
+      choice medium {
+        case ethernet {
+          container ethernet {
+            leaf mac { type string; }
+          }
+        }
+      }
+    }
+  }
+
+  rpc clear-counters {
+    input { leaf name { type string; } }
+  }
+}`
+
+const testVLANsModule = `module test-vlans {
+  namespace "urn:test:vlans";
+  prefix vlan;
+
+  import test-interfaces { prefix if; }
+
+  augment "/if:interfaces/if:interface" {
+    container vlans {
+      leaf-list id { type uint16; }
+    }
+  }
+}`
+
+func testSchema(t *testing.T) *Schema {
+	t.Helper()
+	s, err := Parse(map[string]string{
+		"test-types.yang":      testTypesModule,
+		"test-interfaces.yang": testInterfacesModule,
+		"test-vlans.yang":      testVLANsModule,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return s
+}
+
+func TestParse(t *testing.T) {
+	s := testSchema(t)
+	if got := strings.Join(s.Modules, ","); got != "test-interfaces,test-types,test-vlans" {
+		t.Errorf("Modules = %s", got)
+	}
+
+	s, err := Parse(map[string]string{
+		"test-types.yang":      testTypesModule,
+		"test-interfaces.yang": testInterfacesModule,
+	}, []string{"test-interfaces"})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := strings.Join(s.Modules, ","); got != "test-interfaces" {
+		t.Errorf("Modules = %s", got)
+	}
+
+	if _, err := Parse(map[string]string{"test-interfaces.yang": testInterfacesModule}, nil); err == nil {
+		t.Error("Parse() without the imported module succeeded")
+	}
+	if _, err := Parse(map[string]string{"test-types.yang": testTypesModule}, []string{"test-routing"}); err == nil ||
+		!strings.Contains(err.Error(), `module "test-routing" not found`) {
+		t.Errorf("Parse() of an unknown module error = %v", err)
+	}
+	if _, err := Parse(map[string]string{"broken.yang": "module broken {"}, nil); err == nil {
+		t.Error("Parse() of a broken module succeeded")
+	}
+}
+
+func TestSchemaCheck(t *testing.T) {
+	s := testSchema(t)
+
+	tests := []struct {
+		name      string
+		path      string
+		mode      string
+		wantErr   string
+		wantHints []string
+	}{
+		{name: "root", path: "/"},
+		{name: "list", path: "/interfaces/interface[name=ethernet-1/1]/state/oper-status"},
+		{name: "wildcard key", path: "/interfaces/interface[name=*]/state"},
+		{name: "without leading slash", path: "interfaces/interface/config/mtu"},
+		{name: "wildcard element", path: "/interfaces/*/config/mtu"},
+		{name: "any descendant", path: "/interfaces/.../whatever"},
+		{name: "through a choice", path: "/interfaces/interface/ethernet/mac"},
+		{name: "augmented", path: "/interfaces/interface/vlans/id"},
+		{name: "module name prefix", path: "/test-interfaces:interfaces/interface/vlans"},
+		{name: "module prefix", path: "/if:interfaces/interface/vlan:vlans"},
+		{name: "origin", path: "openconfig:/interfaces"},
+		{name: "cli origin", path: "cli:/show version"},
+		{
+			name:    "misspelled",
+			path:    "/interfaces/interfase/state",
+			wantErr: `"interfase" not found under /interfaces, did you mean "interface"?`,
+		},
+		{
+			name:    "misspelled root",
+			path:    "/interface",
+			wantErr: `"interface" not found under /, did you mean "interfaces"?`,
+		},
+		{
+			name:    "unknown",
+			path:    "/interfaces/interface/statistics",
+			wantErr: `"statistics" not found under /interfaces/interface`,
+		},
+		{
+			name:    "wrong prefix",
+			path:    "/vlan:interfaces",
+			wantErr: `"interfaces" not found under /`,
+		},
+		{name: "rpc", path: "/clear-counters", wantErr: `"clear-counters" not found`},
+		{
+			name:    "wrong key",
+			path:    "/interfaces/interface[nme=ethernet-1/1]",
+			wantErr: `"nme" is not a key of list /interfaces/interface, its keys are name, did you mean "name"?`,
+		},
+		{
+			name:    "key on a container",
+			path:    "/interfaces[name=x]",
+			wantErr: "/interfaces is not a list, it has no keys",
+		},
+		{name: "malformed", path: "/interfaces/interface[name=x", wantErr: "malformed"},
+		{
+			name:      "on change counters",
+			path:      "/interfaces/interface/state",
+			mode:      "ON_CHANGE",
+			wantHints: []string{"/interfaces/interface/state has 2 counter leaves, e.g. /interfaces/interface/state/counters/in-octets, which change too often for ON_CHANGE; subscribe to them with SAMPLE"},
+		},
+		{name: "on change status", path: "/interfaces/interface/state/oper-status", mode: "ON_CHANGE"},
+		{name: "sample counters", path: "/interfaces/interface/state/counters", mode: "SAMPLE"},
+		{
+			name:      "sample config",
+			path:      "/interfaces/interface/config",
+			mode:      "SAMPLE",
+			wantHints: []string{"/interfaces/interface/config has configuration leaves only, which seldom change; ON_CHANGE sends them when they do instead of every sample"},
+		},
+		{name: "target defined config", path: "/interfaces/interface/config", mode: "TARGET_DEFINED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hints, err := s.Check(tt.path, tt.mode)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Check() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if strings.Join(hints, "\n") != strings.Join(tt.wantHints, "\n") {
+				t.Errorf("Check() hints = %q, want %q", hints, tt.wantHints)
+			}
+		})
+	}
+}
+
+func TestClosestNames(t *testing.T) {
+	names := []string{"interfaces", "interface", "network-instances", "system"}
+	if got := closestNames("interfacs", names); strings.Join(got, ",") != "interface,interfaces" {
+		t.Errorf("closestNames() = %v", got)
+	}
+	if got := closestNames("routing", names); len(got) != 0 {
+		t.Errorf("closestNames() = %v, want none", got)
+	}
+}
@@ -45,14 +45,13 @@ func TestMain(m *testing.M) {
 
 // newCluster applies a Cluster and waits for it to report Ready. The name is
 // per-test so tests never contend for one.
-func newCluster(t *testing.T, name string, replicas int, restPort, gnmiPort int) {
+func newCluster(t *testing.T, name string, replicas, restPort int) {
 	t.Helper()
 	s.K8s.ApplyFile(t, "fixtures/cluster.yaml", map[string]any{
 		"Name":     name,
 		"Image":    harness.GnmicImage(),
 		"Replicas": replicas,
 		"RestPort": restPort,
-		"GnmiPort": gnmiPort,
 	})
 	harness.WaitClusterReady(t, s.K8s, name)
 }
@@ -61,7 +60,7 @@ func newCluster(t *testing.T, name string, replicas int, restPort, gnmiPort int)
 // correctly shaped StatefulSet.
 func TestCluster001_CreatesStatefulSet(t *testing.T) {
 	const name = "sts"
-	newCluster(t, name, 1, defaultRestPort, 0)
+	newCluster(t, name, 1, defaultRestPort)
 
 	sts := &appsv1.StatefulSet{}
 	s.K8s.WaitExists(t, harness.StatefulSetName(name), sts)
@@ -97,8 +96,8 @@ func TestCluster001_CreatesStatefulSet(t *testing.T) {
 // addresses individual pods by DNS to apply config, which only works without a
 // cluster IP.
 func TestCluster002_HeadlessServiceExposesAPI(t *testing.T) {
-	newCluster(t, "svca", 1, defaultRestPort, 0)
-	newCluster(t, "svcb", 1, 7891, 57400)
+	newCluster(t, "svca", 1, defaultRestPort)
+	newCluster(t, "svcb", 1, 7891)
 
 	svcA := s.K8s.Service(t, harness.HeadlessServiceName("svca"))
 	if svcA.Spec.ClusterIP != corev1.ClusterIPNone {
@@ -117,7 +116,6 @@ func TestCluster002_HeadlessServiceExposesAPI(t *testing.T) {
 		t.Errorf("service gnmic-svcb is not headless: clusterIP=%q", svcB.Spec.ClusterIP)
 	}
 	assertPort(t, svcB, 7891)
-	assertPort(t, svcB, 57400)
 
 	// The port is only proven usable by talking to it.
 	pods := s.K8s.WaitReadyPods(t, "svca", 1, harness.Long)
@@ -135,7 +133,7 @@ func TestCluster002_HeadlessServiceExposesAPI(t *testing.T) {
 // the pods at the path the collector reads.
 func TestCluster003_BootstrapConfigMapIsMounted(t *testing.T) {
 	const name = "cfg"
-	newCluster(t, name, 1, defaultRestPort, 0)
+	newCluster(t, name, 1, defaultRestPort)
 
 	cm := s.K8s.ConfigMap(t, harness.ConfigMapName(name))
 	harness.AssertOwnedBy(t, cm, "Cluster", name)
@@ -170,7 +168,7 @@ func TestCluster003_BootstrapConfigMapIsMounted(t *testing.T) {
 // a rollout and converges.
 func TestCluster004_ImageChangeRollsStatefulSet(t *testing.T) {
 	const name = "img"
-	newCluster(t, name, 1, defaultRestPort, 0)
+	newCluster(t, name, 1, defaultRestPort)
 
 	before := &appsv1.StatefulSet{}
 	s.K8s.WaitExists(t, harness.StatefulSetName(name), before)
@@ -216,7 +214,7 @@ func TestCluster004_ImageChangeRollsStatefulSet(t *testing.T) {
 // remove pods, and that status follows in both directions.
 func TestCluster005_ScalingChangesReplicaCount(t *testing.T) {
 	const name = "scale"
-	newCluster(t, name, 1, defaultRestPort, 0)
+	newCluster(t, name, 1, defaultRestPort)
 	s.K8s.WaitReadyPods(t, name, 1, harness.Long)
 
 	s.K8s.Patch(t, s.K8s.Cluster(t, name), `{"spec":{"replicas":3}}`)
@@ -246,7 +244,7 @@ func TestCluster005_ScalingChangesReplicaCount(t *testing.T) {
 // asserted here in its simplest form.
 func TestCluster006_ConfigAppliedWithoutRestart(t *testing.T) {
 	const name = "dyn"
-	newCluster(t, name, 1, defaultRestPort, 0)
+	newCluster(t, name, 1, defaultRestPort)
 	s.K8s.WaitReadyPods(t, name, 1, harness.Long)
 
 	restartsBefore := s.K8s.RestartCounts(t, name)
@@ -273,7 +271,7 @@ func TestCluster006_ConfigAppliedWithoutRestart(t *testing.T) {
 // is only the operator agreeing with itself.
 func TestCluster007_StatusCountersReflectResources(t *testing.T) {
 	const name = "counts"
-	newCluster(t, name, 1, defaultRestPort, 0)
+	newCluster(t, name, 1, defaultRestPort)
 
 	s.K8s.ApplyFile(t, "fixtures/counters.yaml", map[string]any{
 		"Tag":     name,
@@ -307,7 +305,7 @@ func TestCluster007_StatusCountersReflectResources(t *testing.T) {
 // prove collection stopped.
 func TestCluster008_DeletingClusterGarbageCollects(t *testing.T) {
 	const name = "gc"
-	newCluster(t, name, 1, defaultRestPort, 0)
+	newCluster(t, name, 1, defaultRestPort)
 
 	s.K8s.ApplyFile(t, "fixtures/prom.yaml", map[string]any{
 		"Tag":     name,